@@ -0,0 +1,113 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Privado-Inc/privado-cli/pkg/auth"
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/cloud"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/progress"
+	"github.com/Privado-Inc/privado-cli/pkg/telemetry"
+	"github.com/spf13/cobra"
+)
+
+// authCmd represents the auth command
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Authenticate Privado CLI with Privado Cloud",
+}
+
+// Returns the Privado Cloud API token if logged in, else empty string
+// Scans attach the token so results are associated with the identity
+func getAPIToken() string {
+	credentials, err := auth.LoadCredentials(config.AppConfig.CredentialsPath)
+	if err != nil {
+		return ""
+	}
+	return credentials.Token
+}
+
+// Returns the organization scans are synced to, empty for the default
+func getOrganizationId() string {
+	credentials, err := auth.LoadCredentials(config.AppConfig.CredentialsPath)
+	if err != nil {
+		return ""
+	}
+	return credentials.OrganizationId
+}
+
+// Loads credentials or exits with a login hint
+func loadCredentialsOrExit() *auth.Credentials {
+	credentials, err := auth.LoadCredentials(config.AppConfig.CredentialsPath)
+	if err == auth.ErrNotLoggedIn {
+		exitWithError(clierrors.NotLoggedIn.New("Not logged in to Privado Cloud. Run 'privado auth login' first"))
+	} else if err != nil {
+		exitWithError(clierrors.CredentialsLoad.Errorf("Cannot load credentials: %s", err))
+	}
+	return credentials
+}
+
+// Warns if the token has expired or expires soon, so scheduled (CI) scans
+// can be updated before they start failing
+func warnOnTokenExpiry() {
+	credentials, err := auth.LoadCredentials(config.AppConfig.CredentialsPath)
+	if err != nil {
+		return
+	}
+	if credentials.IsFromEnvironment() {
+		credentials.ExpiresAt = getEnvironmentTokenExpiry(credentials.Token)
+	}
+	if !credentials.ExpiresWithin(auth.TokenExpiryWarningPeriod) {
+		return
+	}
+
+	warningMsg := fmt.Sprintf("Privado Cloud token expires on %s. Run 'privado auth rotate' to get a fresh token", credentials.ExpiresAt.Local().Format("2006-01-02 15:04"))
+	if credentials.IsExpired() {
+		warningMsg = "Privado Cloud token has expired. Run 'privado auth login' to login again"
+	}
+	fmt.Println("[WARN]:", warningMsg)
+	progress.Warning(warningMsg)
+	telemetry.DefaultInstance.RecordArrayMetric("warning", "token expiry")
+}
+
+// Returns the expiry of a token provided using the environment (zero if
+// unknown or it does not expire): cached, else looked up with whoami
+func getEnvironmentTokenExpiry(token string) time.Time {
+	if expiresAt, ok := auth.LoadCachedTokenExpiry(config.AppConfig.TokenExpiryCachePath, token, config.AppConfig.TokenExpiryCacheTTL); ok {
+		return expiresAt
+	}
+	identity, err := cloud.NewClient(token).WithTimeout(5 * time.Second).WhoAmI()
+	if err != nil {
+		return time.Time{}
+	}
+	_ = auth.SaveCachedTokenExpiry(config.AppConfig.TokenExpiryCachePath, token, identity.TokenExpiresAt)
+	return identity.TokenExpiresAt
+}
+
+func init() {
+	rootCmd.AddCommand(authCmd)
+}
@@ -0,0 +1,123 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Privado-Inc/privado-cli/pkg/auth"
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/cloud"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var authLoginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Login to Privado Cloud using an API token or your browser",
+	Long:  "Login to Privado Cloud using an API token (--token, --token-stdin) or your browser (default). Credentials are stored locally and attached to subsequent scans",
+	Args:  cobra.ExactArgs(0),
+	Run:   authLogin,
+}
+
+var authLogoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Remove stored Privado Cloud credentials",
+	Args:  cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := auth.RemoveCredentials(config.AppConfig.CredentialsPath); err != nil {
+			exitWithError(clierrors.CredentialsSave.Errorf("Cannot remove credentials: %s", err))
+		}
+		exit("> Logged out of Privado Cloud", false)
+	},
+}
+
+func authLogin(cmd *cobra.Command, args []string) {
+	token, _ := cmd.Flags().GetString("token")
+	tokenFromStdin, _ := cmd.Flags().GetBool("token-stdin")
+
+	if tokenFromStdin {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			exitWithError(clierrors.LoginFailed.Errorf("Cannot read token from stdin: %s", err))
+		}
+		token = strings.TrimSpace(line)
+	}
+
+	credentials := &auth.Credentials{Token: token, CreatedAt: time.Now()}
+
+	if credentials.Token == "" {
+		client := cloud.NewClient("")
+		authorization, err := client.StartDeviceAuthorization()
+		if err != nil {
+			exitWithError(clierrors.LoginFailed.Errorf("Cannot start browser login: %s\nYou can also login using an API token (--token)", err))
+		}
+
+		verificationURL := authorization.VerificationURIComplete
+		if verificationURL == "" {
+			verificationURL = authorization.VerificationURI
+		}
+		fmt.Println("> Your one-time code:", authorization.UserCode)
+		fmt.Println("> Continue to login in your browser:", verificationURL)
+		time.Sleep(config.AppConfig.SlowdownTime)
+		_ = utils.OpenURLInBrowser(verificationURL)
+
+		fmt.Println("\n> Waiting for login to complete..")
+		deviceToken, err := client.WaitForDeviceToken(authorization)
+		if err != nil {
+			exitWithError(clierrors.LoginFailed.Errorf("Login failed: %s", err))
+		}
+		credentials.Token = deviceToken.AccessToken
+		credentials.ExpiresAt = deviceToken.GetExpiry()
+	}
+
+	identity, err := cloud.NewClient(credentials.Token).WhoAmI()
+	if err != nil {
+		exitWithError(clierrors.CredentialsVerification.Errorf("Cannot verify credentials: %s", err))
+	}
+	credentials.UserId = identity.UserId
+	credentials.Email = identity.Email
+	if credentials.ExpiresAt.IsZero() {
+		credentials.ExpiresAt = identity.TokenExpiresAt
+	}
+
+	if err := auth.SaveCredentials(config.AppConfig.CredentialsPath, credentials); err != nil {
+		exitWithError(clierrors.CredentialsSave.Errorf("Cannot save credentials: %s", err))
+	}
+
+	exit(fmt.Sprintf("> Logged in to Privado Cloud as %s", identity.Email), false)
+}
+
+func init() {
+	authLoginCmd.Flags().String("token", "", "Login using the specified Privado Cloud API token")
+	authLoginCmd.Flags().Bool("token-stdin", false, "Read the Privado Cloud API token from stdin")
+	authLoginCmd.MarkFlagsMutuallyExclusive("token", "token-stdin")
+
+	authCmd.AddCommand(authLoginCmd)
+	authCmd.AddCommand(authLogoutCmd)
+}
@@ -0,0 +1,106 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Privado-Inc/privado-cli/pkg/auth"
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/cloud"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var authOrgsCmd = &cobra.Command{
+	Use:   "orgs",
+	Short: "List the Privado Cloud organizations you belong to",
+	Args:  cobra.ExactArgs(0),
+	Run:   authOrgs,
+}
+
+var authUseOrgCmd = &cobra.Command{
+	Use:   "use-org <organization-id>",
+	Short: "Set the Privado Cloud organization scan results are synced to",
+	Args:  cobra.ExactArgs(1),
+	Run:   authUseOrg,
+}
+
+func authOrgs(cmd *cobra.Command, args []string) {
+	credentials := loadCredentialsOrExit()
+
+	identity, err := cloud.NewClient(credentials.Token).WhoAmI()
+	if err != nil {
+		exitWithError(clierrors.OrganizationUnavailable.Errorf("Cannot fetch organizations: %s", err))
+	}
+
+	if len(identity.Organizations) == 0 {
+		exit("You do not belong to any organization", false)
+	}
+
+	for i, organization := range identity.Organizations {
+		// without an explicit selection, the first organization is the default
+		isCurrent := organization.Id == credentials.OrganizationId || (credentials.OrganizationId == "" && i == 0)
+		marker := " "
+		if isCurrent {
+			marker = "*"
+		}
+		fmt.Printf("%s %s\t%s\n", marker, organization.Id, organization.Name)
+	}
+}
+
+func authUseOrg(cmd *cobra.Command, args []string) {
+	organizationId := args[0]
+	if os.Getenv(auth.TokenEnvKey) != "" {
+		exitWithError(clierrors.OrganizationUnavailable.Errorf("Credentials are provided through %s, set %s to select the organization instead", auth.TokenEnvKey, auth.OrganizationEnvKey))
+	}
+	credentials := loadCredentialsOrExit()
+
+	identity, err := cloud.NewClient(credentials.Token).WhoAmI()
+	if err != nil {
+		exitWithError(clierrors.OrganizationUnavailable.Errorf("Cannot fetch organizations: %s", err))
+	}
+
+	var selected *cloud.Organization
+	for i := range identity.Organizations {
+		if identity.Organizations[i].Id == organizationId {
+			selected = &identity.Organizations[i]
+		}
+	}
+	if selected == nil {
+		exitWithError(clierrors.OrganizationUnavailable.Errorf("You do not belong to organization '%s'. Run 'privado auth orgs' to list your organizations", organizationId))
+	}
+
+	credentials.OrganizationId = selected.Id
+	if err := auth.SaveCredentials(config.AppConfig.CredentialsPath, credentials); err != nil {
+		exitWithError(clierrors.CredentialsSave.Errorf("Cannot save credentials: %s", err))
+	}
+
+	exit(fmt.Sprintf("> Scan results will be synced to organization: %s (%s)", selected.Name, selected.Id), false)
+}
+
+func init() {
+	authCmd.AddCommand(authOrgsCmd)
+	authCmd.AddCommand(authUseOrgCmd)
+}
@@ -0,0 +1,84 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/Privado-Inc/privado-cli/pkg/auth"
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/cloud"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var authRotateCmd = &cobra.Command{
+	Use:   "rotate",
+	Short: "Exchange the stored Privado Cloud token for a fresh one",
+	Long:  "Exchange the stored Privado Cloud token for a fresh one. The current token is revoked once the new token is issued",
+	Args:  cobra.ExactArgs(0),
+	Run:   authRotate,
+}
+
+func authRotate(cmd *cobra.Command, args []string) {
+	printToken, _ := cmd.Flags().GetBool("print")
+
+	credentials := loadCredentialsOrExit()
+	if credentials.IsExpired() {
+		exitWithError(clierrors.TokenRotation.New("Privado Cloud token has expired and cannot be rotated. Run 'privado auth login' to login again"))
+	}
+
+	// tokens from the environment cannot be replaced in place
+	fromEnvironment := credentials.IsFromEnvironment()
+	if fromEnvironment && !printToken {
+		exitWithError(clierrors.TokenRotation.Errorf("Token is provided using %s and cannot be updated.\nUse '--print' to print the new token and update it where it is defined", auth.TokenEnvKey))
+	}
+
+	token, err := cloud.NewClient(credentials.Token).RotateToken()
+	if err != nil {
+		exitWithError(clierrors.TokenRotation.Errorf("Cannot rotate token: %s", err))
+	}
+	credentials.Token = token.AccessToken
+	credentials.ExpiresAt = token.GetExpiry()
+
+	if printToken {
+		fmt.Println(credentials.Token)
+	}
+
+	if !fromEnvironment {
+		if err := auth.SaveCredentials(config.AppConfig.CredentialsPath, credentials); err != nil {
+			exitWithError(clierrors.CredentialsSave.Errorf("Cannot save credentials: %s", err))
+		}
+	}
+
+	if credentials.ExpiresAt.IsZero() {
+		exit("> Token rotated", false)
+	}
+	exit(fmt.Sprintf("> Token rotated. New token expires on %s", credentials.ExpiresAt.Local().Format("2006-01-02 15:04")), false)
+}
+
+func init() {
+	authRotateCmd.Flags().Bool("print", false, "Print the new token to stdout (e.g. to update a CI secret)")
+
+	authCmd.AddCommand(authRotateCmd)
+}
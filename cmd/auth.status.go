@@ -0,0 +1,117 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Privado-Inc/privado-cli/pkg/auth"
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/cloud"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var authStatusCmd = &cobra.Command{
+	Use:     "status",
+	Aliases: []string{"whoami"},
+	Short:   "Show the authenticated identity and the identifiers attached to scans",
+	Args:    cobra.ExactArgs(0),
+	Run:     authStatus,
+}
+
+func authStatus(cmd *cobra.Command, args []string) {
+	offline, _ := cmd.Flags().GetBool("offline")
+
+	fmt.Println("Identifiers attached to scans:")
+	fmt.Println("  User hash:", config.UserConfig.UserHash)
+	fmt.Println()
+
+	fmt.Println("Sync:")
+	fmt.Println("  Sync to Privado Cloud:", config.UserConfig.ConfigFile.SyncToPrivadoCloud)
+	if rules := config.UserConfig.ConfigFile.SyncRules; rules != nil {
+		if len(rules.Repositories) > 0 {
+			fmt.Println("  Repositories:", strings.Join(rules.Repositories, ", "))
+		}
+		if len(rules.Branches) > 0 {
+			fmt.Println("  Branches:", strings.Join(rules.Branches, ", "))
+		}
+		fmt.Println("  Strip snippets:", rules.StripSnippets)
+	}
+	fmt.Println()
+
+	credentials, err := auth.LoadCredentials(config.AppConfig.CredentialsPath)
+	if err == auth.ErrNotLoggedIn {
+		exit("> Not logged in to Privado Cloud. Run 'privado auth login' to login", false)
+	} else if err != nil {
+		exitWithError(clierrors.CredentialsLoad.Errorf("Cannot load credentials: %s", err))
+	}
+
+	fmt.Println("Privado Cloud:")
+	if credentials.IsFromEnvironment() {
+		fmt.Println("  Token:", fmt.Sprintf("from %s", auth.TokenEnvKey))
+	} else {
+		fmt.Println("  Token:", fmt.Sprintf("stored in %s", config.AppConfig.CredentialsPath))
+	}
+
+	expiresAt := credentials.ExpiresAt
+	email := credentials.Email
+	organizationName := ""
+	if !offline {
+		identity, err := cloud.NewClient(credentials.Token).WhoAmI()
+		if err != nil {
+			exitWithError(clierrors.CredentialsVerification.Errorf("Cannot verify credentials: %s", err))
+		}
+		email = identity.Email
+		if !identity.TokenExpiresAt.IsZero() {
+			expiresAt = identity.TokenExpiresAt
+		}
+		for _, organization := range identity.Organizations {
+			if organization.Id == credentials.OrganizationId {
+				organizationName = organization.Name
+			}
+		}
+	}
+
+	fmt.Println("  Logged in as:", email)
+	if credentials.OrganizationId == "" {
+		fmt.Println("  Organization: default")
+	} else if organizationName != "" {
+		fmt.Printf("  Organization: %s (%s)\n", organizationName, credentials.OrganizationId)
+	} else {
+		fmt.Println("  Organization:", credentials.OrganizationId)
+	}
+
+	if expiresAt.IsZero() {
+		fmt.Println("  Token expires: never")
+	} else {
+		fmt.Println("  Token expires:", expiresAt.Local().Format("2006-01-02 15:04"))
+	}
+}
+
+func init() {
+	authStatusCmd.Flags().Bool("offline", false, "Show stored details only, without verifying them with Privado Cloud")
+
+	authCmd.AddCommand(authStatusCmd)
+}
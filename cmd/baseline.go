@@ -0,0 +1,101 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/Privado-Inc/privado-cli/pkg/baseline"
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+	"github.com/spf13/cobra"
+)
+
+var baselineCmd = &cobra.Command{
+	Use:   "baseline",
+	Short: "Manage the baseline (accepted findings and data flow sinks) of a repository",
+	Long:  "Manage the baseline (accepted findings and data flow sinks) of a repository, saved to <repository>/.privado/baseline.json. Commit it to block new sinks in pull requests (ci --block-new)",
+}
+
+var baselineSetCmd = &cobra.Command{
+	Use:               "set <repository>",
+	Short:             "Set the baseline of the repository from its results (last scan)",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeDirectoryArgument,
+	Run: func(cmd *cobra.Command, args []string) {
+		repositoryPath := fileutils.GetAbsolutePath(args[0])
+		scanResults, err := results.LoadResults(filepath.Join(repositoryPath, config.AppConfig.PrivacyResultsPathSuffix))
+		if err != nil {
+			exitWithError(clierrors.ResultsRead.Errorf("Cannot read results: %s", err))
+		}
+
+		b := baseline.NewFromResults(scanResults)
+		if err := b.Save(getBaselinePath(repositoryPath)); err != nil {
+			exitWithError(clierrors.BaselineInvalid.Errorf("Cannot save baseline: %s", err))
+		}
+		exit(fmt.Sprintf("> Baseline with %d finding(s) and %d sink(s) saved to: %s", len(b.FindingIds), len(b.SinkIds), getBaselinePath(repositoryPath)), false)
+	},
+}
+
+var baselineShowCmd = &cobra.Command{
+	Use:               "show <repository>",
+	Short:             "Print the baseline of the repository",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeDirectoryArgument,
+	Run: func(cmd *cobra.Command, args []string) {
+		b, err := baseline.Load(getBaselinePath(fileutils.GetAbsolutePath(args[0])))
+		if err != nil {
+			exitWithError(clierrors.BaselineInvalid.Errorf("Cannot read baseline: %s", err))
+		}
+		data, _ := json.MarshalIndent(b, "", "  ")
+		exit(string(data), false)
+	},
+}
+
+var baselineRemoveCmd = &cobra.Command{
+	Use:               "remove <repository>",
+	Short:             "Remove the baseline of the repository",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeDirectoryArgument,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := baseline.Remove(getBaselinePath(fileutils.GetAbsolutePath(args[0]))); err != nil {
+			exitWithError(clierrors.BaselineInvalid.Errorf("Cannot remove baseline: %s", err))
+		}
+		exit("> Baseline removed", false)
+	},
+}
+
+func getBaselinePath(repositoryPath string) string {
+	return filepath.Join(repositoryPath, filepath.Dir(config.AppConfig.PrivacyResultsPathSuffix), baseline.FileName)
+}
+
+func init() {
+	baselineCmd.AddCommand(baselineSetCmd)
+	baselineCmd.AddCommand(baselineShowCmd)
+	baselineCmd.AddCommand(baselineRemoveCmd)
+	rootCmd.AddCommand(baselineCmd)
+}
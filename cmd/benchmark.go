@@ -0,0 +1,205 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Privado-Inc/privado-cli/pkg/benchmark"
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/docker"
+	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+	"github.com/spf13/cobra"
+)
+
+var benchmarkCmd = &cobra.Command{
+	Use:   "benchmark <repository>",
+	Short: "Scan a repository repeatedly to compare durations, memory and findings across images and scan flags",
+	Long: `Scan a repository repeatedly to compare durations, peak memory and the stability of findings across images (--image) and scan flags (--variant), e.g. to tune memory limits or compare engine versions.
+Each configuration (image and variant) is scanned --runs times in a separate privado process. The results of the repository are overwritten by each scan.
+
+Example:
+  privado benchmark ./repo --runs 3 --variant "default=" --variant "xmx8g=--jvm-args=-Xmx8g"`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeDirectoryArgument,
+	Run:               runBenchmark,
+}
+
+func runBenchmark(cmd *cobra.Command, args []string) {
+	repositoryPath := fileutils.GetAbsolutePath(args[0])
+	runs, _ := cmd.Flags().GetInt("runs")
+	images, _ := cmd.Flags().GetStringSlice("image")
+	variantFlags, _ := cmd.Flags().GetStringArray("variant")
+	outputPath, _ := cmd.Flags().GetString("output")
+
+	if exists, _ := fileutils.DoesFileExists(repositoryPath); !exists {
+		exitWithError(clierrors.PathNotFound.Errorf("Repository not found: %s", repositoryPath))
+	}
+	if runs < 1 {
+		exitWithError(clierrors.InvalidFlagValue.Errorf("Invalid value for --runs: %d (must be at least 1)", runs))
+	}
+	if len(images) == 0 {
+		images = []string{config.AppConfig.Container.ImageURL}
+	}
+
+	variants := []benchmark.Configuration{}
+	variantNames := map[string]bool{}
+	for _, variantFlag := range variantFlags {
+		variant, err := benchmark.ParseVariant(variantFlag)
+		if err != nil {
+			exitWithError(clierrors.InvalidFlagValue.Wrap(err))
+		}
+		if variantNames[variant.Name] {
+			exitWithError(clierrors.InvalidFlagValue.Errorf("Duplicate variant: %s", variant.Name))
+		}
+		variantNames[variant.Name] = true
+		variants = append(variants, variant)
+	}
+	if len(variants) == 0 {
+		variants = append(variants, benchmark.Configuration{Name: "default"})
+	}
+	configurations := benchmark.Matrix(images, variants)
+
+	logsDirectory, err := os.MkdirTemp("", "privado-benchmark-")
+	if err != nil {
+		exitWithError(clierrors.BenchmarkFailed.Errorf("Cannot create logs directory: %s", err))
+	}
+
+	// images are pulled beforehand, so the pull is not part of the scan durations
+	for _, image := range images {
+		if err := docker.PullLatestImage(image, nil); err != nil {
+			exitWithError(clierrors.ImagePull.Errorf("Cannot pull image %s: %s", image, err))
+		}
+	}
+
+	fmt.Printf("\n> Benchmarking %s: %d configuration(s), %d run(s) each\n", repositoryPath, len(configurations), runs)
+	fmt.Println("> Scan logs are saved to:", logsDirectory)
+
+	report := benchmark.Report{Repository: repositoryPath, Timestamp: time.Now()}
+	for i, configuration := range configurations {
+		for iteration := 1; iteration <= runs; iteration++ {
+			fmt.Printf("> [%d/%d] %s: run %d of %d\n", i+1, len(configurations), configuration.Name, iteration, runs)
+			run := runBenchmarkScan(repositoryPath, configuration, iteration, filepath.Join(logsDirectory, fmt.Sprintf("%d-%d.log", i+1, iteration)))
+			if run.Error != "" {
+				fmt.Printf("  failed after %s: %s (see %s)\n", run.Duration.Round(time.Second), run.Error, run.LogPath)
+			} else {
+				fmt.Printf("  completed in %s, %d finding(s)\n", run.Duration.Round(time.Second), run.Findings)
+			}
+			report.Runs = append(report.Runs, run)
+		}
+	}
+
+	for _, configuration := range configurations {
+		report.Summaries = append(report.Summaries, benchmark.Summarize(configuration, report.Runs))
+	}
+	printBenchmarkSummary(report.Summaries)
+
+	if outputPath != "" {
+		data, _ := json.MarshalIndent(report, "", "  ")
+		if err := os.WriteFile(outputPath, data, 0644); err != nil {
+			exitWithError(clierrors.BenchmarkFailed.Errorf("Cannot write benchmark report: %s", err))
+		}
+		fmt.Println("\n> Benchmark report saved to:", outputPath)
+	}
+}
+
+// Scans the repository once with the configuration, sampling the
+// memory of the scan container while it runs
+func runBenchmarkScan(repositoryPath string, configuration benchmark.Configuration, iteration int, logPath string) benchmark.Run {
+	run := benchmark.Run{Configuration: configuration.Name, Iteration: iteration, LogPath: logPath}
+
+	// the scan process uses the image of the configuration
+	os.Setenv("PRIVADO_IMAGE", configuration.Image)
+	defer os.Unsetenv("PRIVADO_IMAGE")
+
+	startTime := time.Now()
+	stopMonitor := make(chan struct{})
+	peakMemory := make(chan uint64)
+	go func() {
+		peak, _ := docker.MonitorPeakMemory(configuration.Image, startTime, time.Second, stopMonitor)
+		peakMemory <- peak
+	}()
+
+	err := runScanProcess(repositoryPath, logPath, append([]string{"--no-log-file"}, configuration.Args...))
+	run.Duration = time.Since(startTime)
+	close(stopMonitor)
+	run.PeakMemory = <-peakMemory
+	if err != nil {
+		run.Error = err.Error()
+		return run
+	}
+
+	scanResults, err := results.LoadResults(filepath.Join(repositoryPath, config.AppConfig.PrivacyResultsPathSuffix))
+	if err != nil {
+		run.Error = fmt.Sprintf("cannot read results: %s", err)
+		return run
+	}
+	for _, finding := range scanResults.Findings() {
+		run.FindingIds = append(run.FindingIds, finding.Id)
+	}
+	run.Findings = len(run.FindingIds)
+	return run
+}
+
+func printBenchmarkSummary(summaries []benchmark.Summary) {
+	fmt.Println("\n> Benchmark summary:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CONFIGURATION\tRUNS\tFAILED\tMEAN\tMIN\tMAX\tPEAK MEMORY\tFINDINGS\tSTABLE")
+	for _, s := range summaries {
+		findings := fmt.Sprintf("%d", s.MinFindings)
+		if s.MaxFindings != s.MinFindings {
+			findings = fmt.Sprintf("%d-%d", s.MinFindings, s.MaxFindings)
+		}
+		stable := "yes"
+		if !s.IsStable() {
+			stable = fmt.Sprintf("no (%d finding(s) vary)", s.UnstableFindings)
+		}
+		peakMemory := "-"
+		if s.PeakMemory > 0 {
+			peakMemory = fileutils.FormatSize(int64(s.PeakMemory))
+		}
+		if s.Failed == s.Runs {
+			fmt.Fprintf(w, "%s\t%d\t%d\t-\t-\t-\t%s\t-\t-\n", s.Name, s.Runs, s.Failed, peakMemory)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\t%s\t%s\t%s\n", s.Name, s.Runs, s.Failed,
+			s.MeanDuration.Round(time.Second), s.MinDuration.Round(time.Second), s.MaxDuration.Round(time.Second),
+			peakMemory, findings, stable)
+	}
+	w.Flush()
+}
+
+func init() {
+	benchmarkCmd.Flags().Int("runs", 3, "Number of scans of each configuration")
+	benchmarkCmd.Flags().StringSlice("image", nil, "Image(s) to compare, e.g. two engine versions (default: the image used by scans)")
+	benchmarkCmd.Flags().StringArray("variant", nil, "Scan flags to compare, as <name>=<scan flags>; e.g. 'xmx8g=--jvm-args=-Xmx8g' (repeatable, default: no flags)")
+	benchmarkCmd.Flags().StringP("output", "o", "", "Write the benchmark report (all runs and the summary) as JSON to the file")
+	rootCmd.AddCommand(benchmarkCmd)
+}
@@ -0,0 +1,178 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Privado-Inc/privado-cli/pkg/bundle"
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/docker"
+	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+	"github.com/Privado-Inc/privado-cli/pkg/gitutils"
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+	"github.com/spf13/cobra"
+)
+
+var bundleCmd = &cobra.Command{
+	Use:               "bundle [repository]",
+	Short:             "Package scan results into an archive for audits",
+	Long:              "Package results of the last scan of the repository (default: current directory), a manifest (versions, repository state, checksums) and the rules in effect into a single archive, e.g. to attach to audit tickets",
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeDirectoryArgument,
+	Run:               createBundle,
+}
+
+var bundleInspectCmd = &cobra.Command{
+	Use:               "inspect <bundle>",
+	Short:             "Show the manifest of a bundle and verify its contents",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeArchive,
+	Run:               inspectBundle,
+}
+
+// policies of the findings, as evaluated by the scan
+type bundlePolicy struct {
+	Id string `json:"id"`
+	results.PolicyDetails
+}
+
+func createBundle(cmd *cobra.Command, args []string) {
+	repository := "."
+	if len(args) > 0 {
+		repository = args[0]
+	}
+	repositoryPath := fileutils.GetAbsolutePath(repository)
+	output, _ := cmd.Flags().GetString("out")
+	externalRules, _ := cmd.Flags().GetString("config")
+
+	resultsPath := filepath.Join(repositoryPath, config.AppConfig.PrivacyResultsPathSuffix)
+	scanResults, err := results.LoadResults(resultsPath)
+	if err != nil {
+		exitWithError(clierrors.ResultsRead.Errorf("Cannot read scan results (run 'privado scan' first): %s", err))
+	}
+	manifest := getBundleManifest(scanResults, repositoryPath)
+
+	policies := []bundlePolicy{}
+	for _, violation := range scanResults.Violations {
+		policies = append(policies, bundlePolicy{Id: violation.PolicyId, PolicyDetails: violation.PolicyDetails})
+	}
+	policiesData, _ := json.MarshalIndent(policies, "", "  ")
+
+	entries := []bundle.Entry{
+		{Path: "results", HostPath: filepath.Dir(resultsPath)},
+		{Path: "rules/policies.json", Data: policiesData},
+	}
+	if externalRules != "" {
+		externalRules = fileutils.GetAbsolutePath(externalRules)
+		if exists, _ := fileutils.DoesFileExists(externalRules); !exists {
+			exitWithError(clierrors.PathNotFound.Errorf("Could not validate the config directory: %s", externalRules))
+		}
+		manifest.ExternalRules = true
+		entries = append(entries, bundle.Entry{Path: "rules/external", HostPath: externalRules})
+	}
+
+	if err := bundle.Create(output, manifest, entries); err != nil {
+		exitWithError(clierrors.BundleCreation.Errorf("Cannot create bundle: %s", err))
+	}
+	exit(fmt.Sprintf("> Bundle created: %s", fileutils.GetAbsolutePath(output)), false)
+}
+
+// Returns the manifest of the scan results (versions, repository state,
+// findings), without the files
+func getBundleManifest(scanResults *results.Results, repositoryPath string) bundle.Manifest {
+	findings := scanResults.Findings()
+	manifest := bundle.Manifest{
+		CreatedAt:          time.Now().UTC(),
+		Repository:         scanResults.RepoName,
+		Branch:             scanResults.GitMetadata.BranchName,
+		Commit:             scanResults.GitMetadata.CommitId,
+		RemoteURL:          gitutils.RemoveCredentialsFromURL(scanResults.GitMetadata.RemoteUrl),
+		CLIVersion:         Version,
+		ScanCLIVersion:     scanResults.PrivadoCLIVersion,
+		EngineVersion:      scanResults.PrivadoCoreVersion,
+		Image:              config.AppConfig.Container.ImageURL,
+		ImageDigest:        docker.GetImageDigest(config.AppConfig.Container.ImageURL),
+		Findings:           len(findings),
+		FindingsBySeverity: results.CountFindingsBySeverity(findings),
+	}
+	if manifest.Repository == "" {
+		manifest.Repository = filepath.Base(repositoryPath)
+	}
+	if scanResults.CreatedAt > 0 {
+		manifest.ScannedAt = time.UnixMilli(scanResults.CreatedAt).UTC()
+	}
+	return manifest
+}
+
+func inspectBundle(cmd *cobra.Command, args []string) {
+	manifest, problems, err := bundle.Inspect(args[0])
+	if err != nil {
+		exitWithError(clierrors.BundleVerification.Errorf("Cannot inspect bundle: %s", err))
+	}
+
+	lines := []string{
+		fmt.Sprintf("Repository:     %s", manifest.Repository),
+		fmt.Sprintf("Branch:         %s", manifest.Branch),
+		fmt.Sprintf("Commit:         %s", manifest.Commit),
+		fmt.Sprintf("Scanned at:     %s", manifest.ScannedAt.Format(time.RFC3339)),
+		fmt.Sprintf("Bundled at:     %s", manifest.CreatedAt.Format(time.RFC3339)),
+		fmt.Sprintf("CLI version:    %s (bundled with %s)", manifest.ScanCLIVersion, manifest.CLIVersion),
+		fmt.Sprintf("Engine version: %s", manifest.EngineVersion),
+		fmt.Sprintf("Image:          %s", manifest.ImageDigest),
+		fmt.Sprintf("Findings:       %d (high: %d, medium: %d, low: %d, unknown: %d)",
+			manifest.Findings,
+			manifest.FindingsBySeverity[results.SeverityHigh],
+			manifest.FindingsBySeverity[results.SeverityMedium],
+			manifest.FindingsBySeverity[results.SeverityLow],
+			manifest.FindingsBySeverity[results.SeverityUnknown],
+		),
+		fmt.Sprintf("External rules: %t", manifest.ExternalRules),
+		fmt.Sprintf("Files:          %d", len(manifest.Files)),
+	}
+
+	if len(problems) > 0 {
+		fmt.Println(strings.Join(lines, "\n") + "\n")
+		failures := []string{"Verification failed:"}
+		for _, problem := range problems {
+			failures = append(failures, "  "+problem)
+		}
+		exitWithError(clierrors.BundleVerification.New(strings.Join(failures, "\n")))
+	}
+	lines = append(lines, "\n> All files match the manifest checksums")
+	exit(strings.Join(lines, "\n"), false)
+}
+
+func init() {
+	bundleCmd.Flags().StringP("out", "o", "scan-bundle.tar.gz", "Path of the bundle to create")
+	bundleCmd.Flags().StringP("config", "c", "", "Config (with rules) directory the scan was run with (-c), to include in the bundle")
+	_ = bundleCmd.RegisterFlagCompletionFunc("config", completeDirectory)
+
+	bundleCmd.AddCommand(bundleInspectCmd)
+	rootCmd.AddCommand(bundleCmd)
+}
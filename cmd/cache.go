@@ -0,0 +1,112 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Privado-Inc/privado-cli/pkg/cache"
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+	"github.com/spf13/cobra"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Export or import the dependency caches used by scans (e.g. to persist them between CI jobs)",
+}
+
+var cacheExportCmd = &cobra.Command{
+	Use:   "export <archive>",
+	Short: "Export the dependency caches to an archive (.tar, .tar.gz or .tar.zst)",
+	Long:  "Export the dependency caches to an archive. The format is chosen from the extension: .tar, .tar.gz (.tgz) or .tar.zst (requires zstd)",
+	Args:  cobra.ExactArgs(1),
+	Run:   cacheExport,
+}
+
+var cacheImportCmd = &cobra.Command{
+	Use:               "import <archive>",
+	Short:             "Import dependency caches from an archive created with 'privado cache export'",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeArchive,
+	Run:               cacheImport,
+}
+
+// Returns the cache directories by name, as stored in the archive
+func getCacheDirectories() map[string]string {
+	directories := map[string]string{}
+	for _, pkg := range []string{"m2", "gradle", "npm"} {
+		if directory, err := config.GetPackageCacheDirectory(pkg); err == nil {
+			directories[pkg] = directory
+		} else {
+			fmt.Printf("[WARN]: Could not get package cache directory for pkg %s: %v\n", pkg, err)
+		}
+	}
+	// class archives of the engine (privado preload)
+	if err := os.MkdirAll(config.GetJVMCacheDirectory(), os.ModePerm); err == nil {
+		directories["jvm"] = config.GetJVMCacheDirectory()
+	} else {
+		fmt.Printf("[WARN]: Could not create cache directory for class archives: %v\n", err)
+	}
+	return directories
+}
+
+func cacheExport(cmd *cobra.Command, args []string) {
+	archivePath := fileutils.GetAbsolutePath(args[0])
+
+	directories := getCacheDirectories()
+	for name, directory := range directories {
+		fmt.Printf("> Exporting %s cache: %s\n", name, directory)
+	}
+
+	count, err := cache.Export(archivePath, directories)
+	if err != nil {
+		os.Remove(archivePath)
+		exitWithError(clierrors.CacheArchive.Errorf("Cannot export caches: %s", err))
+	}
+
+	exit(fmt.Sprintf("> Exported %d cached files to: %s", count, archivePath), false)
+}
+
+func cacheImport(cmd *cobra.Command, args []string) {
+	archivePath := fileutils.GetAbsolutePath(args[0])
+	if exists, _ := fileutils.DoesFileExists(archivePath); !exists {
+		// a missing cache is expected on the first run of a pipeline
+		exit(fmt.Sprintf("> No cache archive found at %s, skipping import", archivePath), false)
+	}
+
+	count, err := cache.Import(archivePath, getCacheDirectories())
+	if err != nil {
+		exitWithError(clierrors.CacheArchive.Errorf("Cannot import caches: %s", err))
+	}
+
+	exit(fmt.Sprintf("> Imported %d cached files from: %s", count, archivePath), false)
+}
+
+func init() {
+	cacheCmd.AddCommand(cacheExportCmd)
+	cacheCmd.AddCommand(cacheImportCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
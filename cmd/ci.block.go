@@ -0,0 +1,87 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Privado-Inc/privado-cli/pkg/baseline"
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+	"github.com/spf13/cobra"
+)
+
+type blockedSink struct {
+	Category string `json:"category"`
+	SinkId   string `json:"sinkId"`
+	Name     string `json:"name"`
+}
+
+// Returns the categories to block new sinks of (--block-new)
+func getBlockedCategories(cmd *cobra.Command) []string {
+	categories, _ := cmd.Flags().GetStringSlice("block-new")
+	for i, category := range categories {
+		categories[i] = strings.ToLower(strings.TrimSpace(category))
+		if !baseline.IsValidSinkCategory(categories[i]) {
+			exitWithError(clierrors.InvalidFlagValue.Errorf("Invalid value for --block-new: %s (allowed: %s)", category, strings.Join(baseline.SinkCategories(), ", ")))
+		}
+	}
+	return categories
+}
+
+// Fails the summary if data flows to sinks of the blocked categories
+// appeared since the baseline of the repository
+func evaluateBlockedCategories(cmd *cobra.Command, repositoryPath string, scanResults *results.Results, summary *ciSummary) {
+	categories := getBlockedCategories(cmd)
+	if len(categories) == 0 {
+		return
+	}
+	baselinePath := getCIBaselinePath(cmd, repositoryPath)
+
+	b, err := baseline.Load(baselinePath)
+	if err == baseline.ErrNoBaseline {
+		fmt.Printf("[WARN]: No baseline (%s), new sinks are not blocked. Create one with 'privado baseline set'\n", baselinePath)
+		return
+	} else if err != nil {
+		exitWithError(clierrors.BaselineInvalid.Errorf("Cannot read baseline: %s", err))
+	}
+	if b.SinkIds == nil {
+		fmt.Println("[WARN]: The baseline has no sinks recorded, new sinks are not blocked. Update it with 'privado baseline set'")
+		return
+	}
+
+	blocked := map[string]bool{}
+	for _, category := range categories {
+		blocked[category] = true
+	}
+	summary.BlockedSinks = []blockedSink{}
+	for _, sink := range b.NewSinks(scanResults.DataFlowSinks()) {
+		if category := baseline.GetSinkCategory(sink); blocked[category] {
+			summary.BlockedSinks = append(summary.BlockedSinks, blockedSink{Category: category, SinkId: sink.Id, Name: sink.Name})
+		}
+	}
+	if len(summary.BlockedSinks) > 0 {
+		summary.Passed = false
+	}
+}
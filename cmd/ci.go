@@ -0,0 +1,374 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Privado-Inc/privado-cli/pkg/azuredevops"
+	"github.com/Privado-Inc/privado-cli/pkg/baseline"
+	"github.com/Privado-Inc/privado-cli/pkg/ci"
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+	"github.com/Privado-Inc/privado-cli/pkg/githubactions"
+	"github.com/Privado-Inc/privado-cli/pkg/gitutils"
+	"github.com/Privado-Inc/privado-cli/pkg/policy"
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+	"github.com/spf13/cobra"
+)
+
+var ciCmd = &cobra.Command{
+	Use:   "ci [repository]",
+	Short: "Scan a repository in a CI pipeline and fail on policy violations",
+	Long: fmt.Sprint(
+		"Scan a repository (default: current directory) with defaults suited for CI pipelines: ",
+		"no prompts, no update check and a machine-readable summary. ",
+		"When building a pull/merge request, only findings in changed files are reported. ",
+		"Exits with a non-zero code when findings at or above the --fail-on severity are found, ",
+		"or when the rego policy of --policy-rego fails the scan (this requires an installed opa executable)",
+	),
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeDirectoryArgument,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		telemetryPreRun(nil)
+	},
+	Run: ciScan,
+	PostRun: func(cmd *cobra.Command, args []string) {
+		telemetryPostRun(nil)
+	},
+}
+
+type ciSummary struct {
+	Provider           string            `json:"provider,omitempty"`
+	Repository         string            `json:"repository"`
+	BaseBranch         string            `json:"baseBranch,omitempty"`
+	ChangedFiles       *int              `json:"changedFiles,omitempty"`
+	FailOn             string            `json:"failOn"`
+	Findings           int               `json:"findings"`
+	FindingsBySeverity map[string]int    `json:"findingsBySeverity"`
+	FailedFindings     []results.Finding `json:"failedFindings"`
+	Passed             bool              `json:"passed"`
+
+	// set when a rego policy (--policy-rego) decided
+	Policy           string             `json:"policy,omitempty"`
+	PolicyViolations []policy.Violation `json:"policyViolations,omitempty"`
+
+	// sinks of the --block-new categories not in the baseline
+	BlockedSinks []blockedSink `json:"blockedSinks,omitempty"`
+
+	// baseline the findings are compared with, if set: findings in the
+	// baseline are not evaluated
+	Baseline string `json:"baseline,omitempty"`
+
+	// source files analyzed versus skipped, to interpret the findings
+	Coverage *results.Coverage `json:"coverage,omitempty"`
+}
+
+func ciScan(cmd *cobra.Command, args []string) {
+	repository := "."
+	if len(args) > 0 {
+		repository = args[0]
+	}
+	repositoryPath := fileutils.GetAbsolutePath(repository)
+	failOn, _ := cmd.Flags().GetString("fail-on")
+	allFiles, _ := cmd.Flags().GetBool("all-files")
+	baseBranch, _ := cmd.Flags().GetString("base-branch")
+	format, _ := cmd.Flags().GetString("format")
+
+	failOn = validateFailOn(failOn)
+	validateGatedScanFlags(cmd)
+	validatePolicyFlags(cmd)
+	getBlockedCategories(cmd)
+	if format != "json" && format != "text" && format != "markdown" {
+		exitWithError(clierrors.InvalidFlagValue.Errorf("Invalid value for --format: %s (allowed: json, text, markdown)", format))
+	}
+
+	summary := ciSummary{Repository: filepath.Base(repositoryPath), FailOn: failOn}
+	if !ci.CISessionConfig.IsCI {
+		fmt.Println("[WARN]: CI environment not detected, continuing with CI defaults")
+	} else if ci.CISessionConfig.Provider != nil {
+		summary.Provider = ci.CISessionConfig.Provider.Name
+	}
+
+	// no prompts and no update check in pipelines
+	_ = cmd.Flags().Set("overwrite", "true")
+	_ = cmd.Flags().Set("skip-update-check", "true")
+
+	// in pull request context, report findings in changed files only
+	var changedFiles []string
+	if !allFiles {
+		if baseBranch == "" && ci.CISessionConfig.Provider != nil {
+			baseBranch = ci.CISessionConfig.Provider.GetPullRequestBaseBranch()
+		}
+		if baseBranch != "" {
+			changedFiles = getChangedFilesSince(repositoryPath, baseBranch)
+			if changedFiles != nil {
+				summary.BaseBranch = baseBranch
+				changedFilesCount := len(changedFiles)
+				summary.ChangedFiles = &changedFilesCount
+				fmt.Printf("> Pull request detected: reporting findings in %d changed file(s) (base: %s)\n", changedFilesCount, baseBranch)
+			}
+		}
+	}
+
+	findings := gatedScan(cmd, repository, changedFiles, &summary)
+
+	notification := newScanNotification(repositoryPath, findings)
+	notification.Failed = !summary.Passed
+	if !summary.Passed {
+		notification.Reason = summary.getStatus()
+	}
+	sendNotifications(notification)
+
+	if githubactions.IsGitHubActions() {
+		reportToGitHubActions(cmd, repositoryPath, findings, summary)
+	}
+	if azuredevops.IsAzurePipelines() {
+		reportToAzureDevOps(repositoryPath, findings, summary)
+	}
+
+	if format == "markdown" {
+		exitWithOutcome(renderCIMarkdown(cmd, repositoryPath, findings, summary), summary.getOutcome())
+	}
+	exitWithOutcome(formatCISummary(summary, format), summary.getOutcome())
+}
+
+func (summary ciSummary) getOutcome() string {
+	if !summary.Passed {
+		return config.OutcomePolicyViolation
+	}
+	if summary.Findings > 0 {
+		return config.OutcomeFindingsBelowThreshold
+	}
+	return config.OutcomeClean
+}
+
+func validateFailOn(failOn string) string {
+	failOn = strings.ToLower(failOn)
+	if failOn != "none" && !results.IsValidSeverity(failOn) {
+		exitWithError(clierrors.InvalidFlagValue.Errorf("Invalid value for --fail-on: %s (allowed: %s, none)", failOn, strings.Join(results.Severities, ", ")))
+	}
+	return failOn
+}
+
+// Checks the scan flags allow the results to be evaluated, before scanning
+func validateGatedScanFlags(cmd *cobra.Command) {
+	if shardFlag, _ := cmd.Flags().GetString("shard"); shardFlag != "" {
+		exitWithError(clierrors.ConflictingOptions.New("Sharded scans cannot be evaluated on their own. Use 'privado scan --shard' and 'privado merge' instead"))
+	}
+	if encryptResults, _ := cmd.Flags().GetString("encrypt-results"); encryptResults != "" {
+		exitWithError(clierrors.ConflictingOptions.Errorf("--encrypt-results cannot be used with '%s': encrypted results cannot be evaluated", cmd.CommandPath()))
+	}
+}
+
+// Scans the repository and evaluates findings (in changedFiles, if not nil)
+// against summary.FailOn. Returns the evaluated findings
+func gatedScan(cmd *cobra.Command, repository string, changedFiles []string, summary *ciSummary) []results.Finding {
+	scan(cmd, []string{repository})
+
+	resultsPath := filepath.Join(fileutils.GetAbsolutePath(repository), config.AppConfig.PrivacyResultsPathSuffix)
+	scanResults, err := results.LoadResults(resultsPath)
+	if err != nil {
+		exitWithError(clierrors.ResultsRead.Errorf("Cannot read scan results: %s", err))
+	}
+
+	// false positives reported with 'privado feedback' never fail the build
+	findings, _ := filterExcludedFindings(fileutils.GetAbsolutePath(repository), scanResults.Findings())
+	loadFindingOwners(fileutils.GetAbsolutePath(repository)).Assign(findings)
+	if changedFiles != nil {
+		findings = results.FilterFindingsInFiles(findings, changedFiles)
+	}
+	if summary.Baseline != "" {
+		b, err := baseline.Load(summary.Baseline)
+		if err != nil && err != baseline.ErrNoBaseline {
+			exitWithError(clierrors.BaselineInvalid.Errorf("Cannot read baseline: %s", err))
+		}
+		if b != nil {
+			findings = b.NewFindings(findings)
+		}
+	}
+
+	summary.Findings = len(findings)
+	summary.FindingsBySeverity = results.CountFindingsBySeverity(findings)
+	summary.FailedFindings = []results.Finding{}
+	if summary.FailOn != "none" {
+		summary.FailedFindings = results.FilterFindingsAtOrAbove(findings, summary.FailOn)
+	}
+	summary.Passed = len(summary.FailedFindings) == 0
+	summary.Coverage = loadScanCoverage(fileutils.GetAbsolutePath(repository))
+	evaluatePolicy(cmd, fileutils.GetAbsolutePath(repository), findings, changedFiles, summary)
+	evaluateBlockedCategories(cmd, fileutils.GetAbsolutePath(repository), scanResults, summary)
+
+	return findings
+}
+
+// Returns the path of the baseline new findings are distinguished with:
+// --baseline, else the baseline of the repository
+func getCIBaselinePath(cmd *cobra.Command, repositoryPath string) string {
+	if baselineFlag, _ := cmd.Flags().GetString("baseline"); baselineFlag != "" {
+		return fileutils.GetAbsolutePath(baselineFlag)
+	}
+	return getBaselinePath(repositoryPath)
+}
+
+// Annotates findings and adds a job summary in GitHub Actions. When a
+// baseline is available, only findings not in it are annotated: known
+// findings would otherwise be annotated on every pull request
+func reportToGitHubActions(cmd *cobra.Command, repositoryPath string, findings []results.Finding, summary ciSummary) {
+	failed := map[string]bool{}
+	for _, finding := range summary.FailedFindings {
+		failed[finding.Id] = true
+	}
+
+	annotatedFindings := findings
+	if b, err := baseline.Load(getCIBaselinePath(cmd, repositoryPath)); err == nil {
+		annotatedFindings = b.NewFindings(findings)
+	} else if err != baseline.ErrNoBaseline {
+		fmt.Println("[WARN]: Could not read baseline, annotating all findings:", err)
+	}
+	githubactions.WriteAnnotations(os.Stdout, repositoryPath, annotatedFindings, failed)
+
+	title := fmt.Sprintf("Privado scan: %s", summary.Repository)
+	if summary.BaseBranch != "" {
+		title = fmt.Sprintf("%s (changes against %s)", title, summary.BaseBranch)
+	}
+	if err := githubactions.AppendJobSummary(githubactions.RenderJobSummary(title, repositoryPath, findings, failed)); err != nil {
+		fmt.Println("[WARN]: Could not write job summary:", err)
+	}
+}
+
+// Logs findings as build issues and publishes results and a summary
+// with the build in Azure Pipelines
+func reportToAzureDevOps(repositoryPath string, findings []results.Finding, summary ciSummary) {
+	failed := map[string]bool{}
+	for _, finding := range summary.FailedFindings {
+		failed[finding.Id] = true
+	}
+
+	azuredevops.WriteIssues(os.Stdout, repositoryPath, findings, failed)
+
+	resultsPath := filepath.Join(repositoryPath, config.AppConfig.PrivacyResultsPathSuffix)
+	azuredevops.UploadArtifact(os.Stdout, resultsPath)
+
+	summaryDirectory := os.Getenv("AGENT_TEMPDIRECTORY")
+	if summaryDirectory == "" {
+		summaryDirectory = filepath.Dir(resultsPath)
+	}
+	summaryPath := filepath.Join(summaryDirectory, "Privado scan.md")
+	markdown := azuredevops.RenderBuildSummary(repositoryPath, findings, failed, summary.Passed)
+	if err := azuredevops.UploadBuildSummary(os.Stdout, summaryPath, markdown); err != nil {
+		fmt.Println("[WARN]: Could not write build summary:", err)
+	}
+}
+
+// Returns files changed since the base branch, nil if they cannot be determined
+func getChangedFilesSince(repositoryPath, baseBranch string) []string {
+	baseRef := gitutils.ResolveBranchRef(repositoryPath, baseBranch)
+	if baseRef == "" {
+		fmt.Printf("[WARN]: Base branch '%s' is not available in the checkout (shallow clone?), reporting findings in all files\n", baseBranch)
+		return nil
+	}
+
+	changedFiles, err := gitutils.GetChangedFiles(repositoryPath, baseRef)
+	if err != nil {
+		if gitutils.IsShallowRepository(repositoryPath) {
+			fmt.Printf("[WARN]: The checkout is a shallow clone without the history since '%s', reporting findings in all files. Fetch the full history (e.g. fetch-depth: 0, or git fetch --unshallow) to report findings in changed files only\n", baseBranch)
+			return nil
+		}
+		fmt.Println("[WARN]: Could not determine changed files, reporting findings in all files:", err)
+		return nil
+	}
+	return changedFiles
+}
+
+func formatCISummary(summary ciSummary, format string) string {
+	if format == "json" {
+		data, _ := json.Marshal(summary)
+		return string(data)
+	}
+
+	lines := []string{
+		fmt.Sprintf("\n> Findings: %d (high: %d, medium: %d, low: %d, unknown: %d)",
+			summary.Findings,
+			summary.FindingsBySeverity[results.SeverityHigh],
+			summary.FindingsBySeverity[results.SeverityMedium],
+			summary.FindingsBySeverity[results.SeverityLow],
+			summary.FindingsBySeverity[results.SeverityUnknown],
+		),
+	}
+	if summary.Policy != "" {
+		for _, violation := range summary.PolicyViolations {
+			lines = append(lines, fmt.Sprintf("  %s", violation.Message))
+		}
+	} else {
+		for _, finding := range summary.FailedFindings {
+			lines = append(lines, fmt.Sprintf("  [%s] %s: %s:%d", finding.Severity, finding.PolicyName, finding.RelativeFileName(), finding.LineNumber))
+		}
+	}
+	for _, sink := range summary.BlockedSinks {
+		lines = append(lines, fmt.Sprintf("  [new %s] %s (%s)", sink.Category, sink.Name, sink.SinkId))
+	}
+	if summary.Coverage != nil {
+		lines = append(lines, formatCoverage(summary.Coverage))
+		if summary.Findings == 0 && summary.Coverage.SourceFiles > 0 && summary.Coverage.Percentage < lowCoveragePercentage {
+			lines = append(lines, "[WARN]: Most source files were not analyzed, the absence of findings is inconclusive")
+		}
+	}
+	lines = append(lines, "> "+summary.getStatus())
+	return strings.Join(lines, "\n")
+}
+
+// Returns whether the scan passed and, if not, why
+func (summary ciSummary) getStatus() string {
+	if summary.Passed {
+		return "Passed"
+	} else if len(summary.BlockedSinks) > 0 && len(summary.FailedFindings) == 0 && len(summary.PolicyViolations) == 0 {
+		return fmt.Sprintf("Failed: data flows to %d new sink(s) of blocked categories", len(summary.BlockedSinks))
+	} else if summary.Policy != "" {
+		return fmt.Sprintf("Failed: policy %s reported %d violation(s)", summary.Policy, len(summary.PolicyViolations))
+	}
+	return fmt.Sprintf("Failed: %d finding(s) at or above severity '%s'", len(summary.FailedFindings), summary.FailOn)
+}
+
+func init() {
+	defineScanFlags(ciCmd)
+	ciCmd.Flags().String("fail-on", results.SeverityHigh, "Fail when findings at or above the severity are found (high, medium, low, unknown, none)")
+	ciCmd.Flags().Bool("all-files", false, "Report findings in all files, even when building a pull request")
+	ciCmd.Flags().String("base-branch", "", "Report findings in files changed since the branch (default: detected from the CI environment)")
+	ciCmd.Flags().String("format", "json", "Format of the summary printed after the scan (json, text, markdown: a report for pull request descriptions and comments)")
+	ciCmd.Flags().String("policy-rego", "", "Rego policy (file or directory) deciding whether the scan passes instead of --fail-on, the input has the findings and results; requires an installed opa executable (not embedded in Privado CLI, see https://www.openpolicyagent.org/docs/latest/#running-opa)")
+	ciCmd.Flags().String("policy-query", policy.DefaultQuery, "Query of the rego policy returning the decision: {\"pass\": bool, \"violations\": [{\"message\": ..., \"findingId\": ...}]}")
+	ciCmd.Flags().String("opa-path", "", "Path of the opa executable evaluating --policy-rego (default: opa on PATH)")
+	ciCmd.Flags().StringSlice("block-new", nil, fmt.Sprintf("Fail when data flows to sinks of the categories appear that are not in the baseline (see 'privado baseline'); categories: %s", strings.Join(baseline.SinkCategories(), ", ")))
+	ciCmd.Flags().String("baseline", "", "Baseline to compare with for --block-new, the markdown report and GitHub Actions annotations (default: <repository>/.privado/baseline.json)")
+	_ = ciCmd.RegisterFlagCompletionFunc("block-new", completeValues(baseline.SinkCategories()...))
+	_ = ciCmd.RegisterFlagCompletionFunc("fail-on", completeSeverities(true))
+	_ = ciCmd.RegisterFlagCompletionFunc("format", completeValues("json", "text", "markdown"))
+
+	rootCmd.AddCommand(ciCmd)
+}
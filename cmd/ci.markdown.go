@@ -0,0 +1,78 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Privado-Inc/privado-cli/pkg/baseline"
+	"github.com/Privado-Inc/privado-cli/pkg/report"
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+	"github.com/spf13/cobra"
+)
+
+// Returns the summary of the scan as a markdown report (--format markdown).
+// Findings not in the baseline of the repository, if any, are listed first
+func renderCIMarkdown(cmd *cobra.Command, repositoryPath string, findings []results.Finding, summary ciSummary) string {
+	title := fmt.Sprintf("Privado scan: %s", summary.Repository)
+	if summary.BaseBranch != "" {
+		title = fmt.Sprintf("%s (changes against %s)", title, summary.BaseBranch)
+	}
+
+	r := report.Report{
+		Title:            title,
+		Passed:           summary.Passed,
+		Status:           summary.getStatus(),
+		Findings:         findings,
+		FailedFindingIds: map[string]bool{},
+	}
+	for _, finding := range summary.FailedFindings {
+		r.FailedFindingIds[finding.Id] = true
+	}
+
+	if b, err := baseline.Load(getCIBaselinePath(cmd, repositoryPath)); err == nil {
+		r.NewFindingIds = map[string]bool{}
+		for _, finding := range b.NewFindings(findings) {
+			r.NewFindingIds[finding.Id] = true
+		}
+	} else if err != baseline.ErrNoBaseline {
+		fmt.Println("[WARN]: Could not read baseline, new findings are not distinguished:", err)
+	}
+
+	violations := report.Section{Title: fmt.Sprintf("Policy violations (%s)", summary.Policy)}
+	for _, violation := range summary.PolicyViolations {
+		violations.Lines = append(violations.Lines, violation.Message)
+	}
+	blockedSinks := report.Section{Title: "New sinks of blocked categories"}
+	for _, sink := range summary.BlockedSinks {
+		blockedSinks.Lines = append(blockedSinks.Lines, fmt.Sprintf("%s: %s (`%s`)", sink.Category, sink.Name, sink.SinkId))
+	}
+	scanCoverage := report.Section{Title: "Coverage"}
+	if summary.Coverage != nil {
+		scanCoverage.Lines = append(scanCoverage.Lines, strings.TrimPrefix(formatCoverage(summary.Coverage), "> "))
+	}
+	r.Sections = []report.Section{violations, blockedSinks, scanCoverage}
+
+	return r.RenderMarkdown()
+}
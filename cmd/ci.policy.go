@@ -0,0 +1,111 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+	"github.com/Privado-Inc/privado-cli/pkg/policy"
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+	"github.com/spf13/cobra"
+)
+
+// input document of rego policies (--policy-rego)
+type policyInput struct {
+	Repository   string                 `json:"repository"`
+	BaseBranch   string                 `json:"baseBranch,omitempty"`
+	ChangedFiles []string               `json:"changedFiles,omitempty"`
+	Findings     []results.Finding      `json:"findings"`
+	Results      map[string]interface{} `json:"results"`
+}
+
+// Checks the policy exists before scanning
+func validatePolicyFlags(cmd *cobra.Command) {
+	policyPath, _ := cmd.Flags().GetString("policy-rego")
+	if policyPath == "" {
+		return
+	}
+	if exists, _ := fileutils.DoesFileExists(fileutils.GetAbsolutePath(policyPath)); !exists {
+		exitWithError(clierrors.PathNotFound.Errorf("Policy not found: %s", policyPath))
+	}
+	// fail before the scan rather than after it
+	opaPath, _ := cmd.Flags().GetString("opa-path")
+	if _, err := policy.FindOPA(opaPath); err != nil {
+		exitWithError(clierrors.PolicyOPANotFound.Errorf("Cannot evaluate the rego policy: %s", err))
+	}
+	if cmd.Flags().Changed("fail-on") {
+		fmt.Println("[WARN]: --fail-on is ignored, the rego policy (--policy-rego) decides whether the scan passes")
+	}
+}
+
+// Evaluates the findings with the rego policy (if specified), whose decision
+// replaces the --fail-on threshold
+func evaluatePolicy(cmd *cobra.Command, repositoryPath string, findings []results.Finding, changedFiles []string, summary *ciSummary) {
+	policyPath, _ := cmd.Flags().GetString("policy-rego")
+	if policyPath == "" {
+		return
+	}
+	query, _ := cmd.Flags().GetString("policy-query")
+	opaPath, _ := cmd.Flags().GetString("opa-path")
+
+	rawResults, err := results.LoadRawResults(filepath.Join(repositoryPath, config.AppConfig.PrivacyResultsPathSuffix))
+	if err != nil {
+		exitWithError(clierrors.ResultsRead.Errorf("Cannot read scan results: %s", err))
+	}
+	input := policyInput{
+		Repository:   summary.Repository,
+		BaseBranch:   summary.BaseBranch,
+		ChangedFiles: changedFiles,
+		Findings:     findings,
+		Results:      rawResults,
+	}
+
+	decision, err := policy.EvaluateRego(opaPath, fileutils.GetAbsolutePath(policyPath), query, input)
+	if err != nil {
+		exitWithError(clierrors.PolicyEvaluation.Wrap(err))
+	}
+
+	summary.Policy = filepath.Base(policyPath)
+	summary.FailOn = "none"
+	summary.PolicyViolations = decision.Violations
+	if summary.PolicyViolations == nil {
+		summary.PolicyViolations = []policy.Violation{}
+	}
+
+	// findings the violations refer to, e.g. for annotations
+	byId := map[string]results.Finding{}
+	for _, finding := range findings {
+		byId[finding.Id] = finding
+	}
+	summary.FailedFindings = []results.Finding{}
+	for _, violation := range decision.Violations {
+		if finding, ok := byId[violation.FindingId]; ok {
+			summary.FailedFindings = append(summary.FailedFindings, finding)
+		}
+	}
+	summary.Passed = decision.Pass
+}
@@ -0,0 +1,107 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/cloud"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+	"github.com/Privado-Inc/privado-cli/pkg/gitutils"
+	"github.com/spf13/cobra"
+)
+
+var cloudPullCmd = &cobra.Command{
+	Use:               "pull <repository>",
+	Short:             "Download results of previous scans synced to Privado Cloud",
+	Long:              "Download results of previous scans synced to Privado Cloud for the repository, in the same format as local scan results",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeDirectoryArgument,
+	Run:               cloudPull,
+}
+
+func cloudPull(cmd *cobra.Command, args []string) {
+	repositoryPath := fileutils.GetAbsolutePath(args[0])
+	scanId, _ := cmd.Flags().GetString("scan-id")
+	listScans, _ := cmd.Flags().GetBool("list")
+	outputPath, _ := cmd.Flags().GetString("output")
+
+	credentials := loadCredentialsOrExit()
+	client := cloud.NewClient(credentials.Token)
+
+	// prefer the remote url to identify the repository across machines
+	repository := gitutils.GetRemoteURL(repositoryPath)
+	if repository == "" {
+		repository = filepath.Base(repositoryPath)
+	}
+
+	if scanId == "" || listScans {
+		scans, err := client.ListScans(repository, credentials.OrganizationId)
+		if err != nil {
+			exitWithError(clierrors.CloudScans.Errorf("Cannot list synced scans for %s: %s", repository, err))
+		}
+		if len(scans) == 0 {
+			exitWithError(clierrors.CloudScans.Errorf("No synced scans found for %s", repository))
+		}
+
+		if listScans {
+			for _, scan := range scans {
+				fmt.Printf("%s\t%s\t%s\t%s\n", scan.Id, scan.CreatedAt.Local().Format("2006-01-02 15:04"), scan.Branch, scan.CommitId)
+			}
+			exit("", false)
+		}
+		scanId = scans[0].Id
+	}
+
+	fmt.Println("> Downloading results of scan:", scanId)
+	data, err := client.DownloadScanResults(scanId)
+	if err != nil {
+		exitWithError(clierrors.CloudDownload.Errorf("Cannot download scan results: %s", err))
+	}
+
+	if outputPath == "" {
+		outputPath = filepath.Join(repositoryPath, filepath.Dir(config.AppConfig.PrivacyResultsPathSuffix), "cloud", fmt.Sprintf("%s.json", scanId))
+	}
+	outputPath = fileutils.GetAbsolutePath(outputPath)
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), os.ModePerm); err != nil {
+		exitWithError(clierrors.ResultsWrite.Errorf("Cannot create output directory: %s", err))
+	}
+	if err := os.WriteFile(outputPath, data, 0644); err != nil {
+		exitWithError(clierrors.ResultsWrite.Errorf("Cannot write scan results: %s", err))
+	}
+
+	exit(fmt.Sprintf("> Scan results saved to: %s", outputPath), false)
+}
+
+func init() {
+	cloudPullCmd.Flags().String("scan-id", "", "Download results of the specified scan instead of the latest one")
+	cloudPullCmd.Flags().Bool("list", false, "List synced scans of the repository instead of downloading results")
+	cloudPullCmd.Flags().StringP("output", "o", "", "Path to save the results to (default: <repository>/.privado/cloud/<scan-id>.json)")
+
+	cloudCmd.AddCommand(cloudPullCmd)
+}
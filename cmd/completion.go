@@ -0,0 +1,121 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"strings"
+
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/queue"
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+	"github.com/Privado-Inc/privado-cli/pkg/schedule"
+	"github.com/spf13/cobra"
+)
+
+// Dynamic completion of arguments and flag values, used by the
+// generated shell completion scripts ('privado completion <shell>')
+
+// completes the first argument (repository or directory) with directories
+func completeDirectoryArgument(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return nil, cobra.ShellCompDirectiveFilterDirs
+}
+
+// completes the first argument with directories, and the
+// following arguments with (shard) result files
+func completeRepositoryAndResults(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return nil, cobra.ShellCompDirectiveFilterDirs
+	}
+	return []string{"json"}, cobra.ShellCompDirectiveFilterFileExt
+}
+
+// completes the first argument with archives (.tar, .tar.gz, .tgz, .tar.zst)
+func completeArchive(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return []string{"tar", "gz", "tgz", "zst"}, cobra.ShellCompDirectiveFilterFileExt
+}
+
+// completes the first argument with ids of scheduled scans
+func completeScheduleId(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	schedules, err := schedule.Load(config.AppConfig.SchedulesPath)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	completions := []string{}
+	for _, s := range schedules {
+		if strings.HasPrefix(s.Id, toComplete) {
+			// shells show the text after the tab as description
+			completions = append(completions, s.Id+"\t"+s.Repository)
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completes the first argument with ids of queued scans
+func completeQueueItemId(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	items, err := queue.Load(config.AppConfig.QueueDirectory)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	completions := []string{}
+	for _, item := range items {
+		if strings.HasPrefix(item.Id, toComplete) {
+			completions = append(completions, item.Id+"\t"+item.Repository)
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// returns a completion function for a flag accepting one of values
+func completeValues(values ...string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return values, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// returns a completion function for --fail-on style flags
+func completeSeverities(includeNone bool) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	severities := append([]string{}, results.Severities...)
+	if includeNone {
+		severities = append(severities, "none")
+	}
+	return completeValues(severities...)
+}
+
+// completes a flag with directories
+func completeDirectory(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return nil, cobra.ShellCompDirectiveFilterDirs
+}
@@ -0,0 +1,85 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var crashReportsCmd = &cobra.Command{
+	Use:   "crash-reports",
+	Short: "Show or set whether crash reports are shared with Privado",
+	Run:   configCrashReports,
+}
+
+func configCrashReports(cmd *cobra.Command, args []string) {
+	enableFlag, _ := cmd.Flags().GetBool("enable")
+	disableFlag, _ := cmd.Flags().GetBool("disable")
+	resetFlag, _ := cmd.Flags().GetBool("reset")
+
+	// if no flags are specified, show the current configuration
+	if !enableFlag && !disableFlag && !resetFlag {
+		exit(fmt.Sprint(
+			crashReportsConfigurationSummary(),
+			"\nYou can use `--enable`, `--disable` or `--reset` flag to update crash report preferences",
+		), false)
+	}
+
+	if enableFlag || disableFlag {
+		upload := enableFlag
+		config.UserConfig.ConfigFile.UploadCrashReports = &upload
+	} else if resetFlag {
+		config.UserConfig.ConfigFile.UploadCrashReports = nil
+	}
+
+	if err := config.SaveUserConfigurationFile(); err != nil {
+		exitWithError(clierrors.ConfigSave.Errorf("Cannot save configuration file: %s", err))
+	}
+
+	exit(crashReportsConfigurationSummary(), false)
+}
+
+func crashReportsConfigurationSummary() string {
+	preference := "ASK (interactive sessions only)"
+	if consent := config.UserConfig.ConfigFile.UploadCrashReports; consent != nil {
+		preference = map[bool]string{true: "ALWAYS", false: "NEVER"}[*consent]
+	}
+
+	return fmt.Sprint(
+		fmt.Sprintf("Share crash reports with Privado: %s\n", preference),
+		fmt.Sprintf("Crash reports are always saved locally to: %s", config.AppConfig.CrashReportsDirectory),
+	)
+}
+
+func init() {
+	crashReportsCmd.Flags().Bool("enable", false, "Always share crash reports with Privado without asking")
+	crashReportsCmd.Flags().Bool("disable", false, "Never share crash reports with Privado")
+	crashReportsCmd.Flags().Bool("reset", false, "Ask before sharing a crash report (default)")
+	crashReportsCmd.MarkFlagsMutuallyExclusive("enable", "disable", "reset")
+
+	configCmd.AddCommand(crashReportsCmd)
+}
@@ -0,0 +1,90 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var exitCodesCmd = &cobra.Command{
+	Use:   "exit-codes [mapping]",
+	Short: "Show or set exit codes for scan outcomes",
+	Long: fmt.Sprint(
+		"Show or set exit codes for scan outcomes, e.g. 'privado config exit-codes policy-violation=1,engine-error=2,infra-error=3'\n\n",
+		fmt.Sprintf("Outcomes: %s", strings.Join(config.Outcomes, ", ")),
+	),
+	Args: cobra.MaximumNArgs(1),
+	Run:  configExitCodes,
+}
+
+func configExitCodes(cmd *cobra.Command, args []string) {
+	resetFlag, _ := cmd.Flags().GetBool("reset")
+
+	// if no mapping is specified, show the current configuration
+	if len(args) == 0 && !resetFlag {
+		exit(fmt.Sprint(
+			exitCodesConfigurationSummary(),
+			"\nYou can specify a mapping (<outcome>=<code>,..) or use `--reset` flag to update exit codes",
+		), false)
+	}
+
+	if resetFlag {
+		config.UserConfig.ConfigFile.ExitCodes = nil
+	} else {
+		exitCodes, err := config.ParseExitCodeMapping(args[0])
+		if err != nil {
+			exitWithError(clierrors.ConfigInvalidValue.Wrap(err))
+		}
+		if config.UserConfig.ConfigFile.ExitCodes == nil {
+			config.UserConfig.ConfigFile.ExitCodes = map[string]int{}
+		}
+		for outcome, code := range exitCodes {
+			config.UserConfig.ConfigFile.ExitCodes[outcome] = code
+		}
+	}
+
+	if err := config.SaveUserConfigurationFile(); err != nil {
+		exitWithError(clierrors.ConfigSave.Errorf("Cannot save configuration file: %s", err))
+	}
+
+	exit(exitCodesConfigurationSummary(), false)
+}
+
+func exitCodesConfigurationSummary() string {
+	lines := []string{"Exit codes:"}
+	for _, outcome := range config.Outcomes {
+		lines = append(lines, fmt.Sprintf("  %s: %d", outcome, config.GetExitCode(outcome, nil)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func init() {
+	exitCodesCmd.Flags().Bool("reset", false, "Reset exit codes to defaults")
+
+	configCmd.AddCommand(exitCodesCmd)
+}
@@ -25,8 +25,10 @@ package cmd
 
 import (
 	"fmt"
+	"net/url"
 	"strings"
 
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
 	"github.com/Privado-Inc/privado-cli/pkg/config"
 	"github.com/spf13/cobra"
 )
@@ -41,14 +43,17 @@ var metricsCmd = &cobra.Command{
 func configMetrics(cmd *cobra.Command, args []string) {
 	enableFlag, _ := cmd.Flags().GetBool("enable")
 	disableFlag, _ := cmd.Flags().GetBool("disable")
-
-	metricsEnabledTextMap := (map[bool]string{true: "enabled", false: "disabled"})
+	endpoint, _ := cmd.Flags().GetString("endpoint")
+	resetEndpoint, _ := cmd.Flags().GetBool("reset-endpoint")
+	mode, _ := cmd.Flags().GetString("mode")
 
 	// if no flags are specified, show the current configuration
-	if !enableFlag && !disableFlag {
+	if !enableFlag && !disableFlag && endpoint == "" && !resetEndpoint && mode == "" {
 		exit(fmt.Sprint(
-			fmt.Sprintf("Telemetry for Privado CLI: %s\n", strings.ToUpper(metricsEnabledTextMap[config.UserConfig.ConfigFile.MetricsEnabled])),
-			"You can use `--enable` or `--disable` flag to update telemetry preferences",
+			telemetryConfigurationSummary(),
+			"\nYou can use `--enable` or `--disable` flag to update telemetry preferences\n",
+			"You can use `--endpoint` to send telemetry to your own collector\n",
+			"You can use `--mode anonymous` to only record aggregate, non-identifying metrics",
 		), false)
 	}
 
@@ -59,17 +64,53 @@ func configMetrics(cmd *cobra.Command, args []string) {
 		config.UserConfig.ConfigFile.MetricsEnabled = false
 	}
 
+	if endpoint != "" {
+		if parsedURL, err := url.ParseRequestURI(endpoint); err != nil || parsedURL.Host == "" {
+			exitWithError(clierrors.ConfigInvalidValue.Errorf("Invalid telemetry endpoint: %s", endpoint))
+		}
+		config.UserConfig.ConfigFile.TelemetryEndpoint = endpoint
+	} else if resetEndpoint {
+		config.UserConfig.ConfigFile.TelemetryEndpoint = ""
+	}
+
+	switch mode {
+	case "":
+	case config.TelemetryModeFull, config.TelemetryModeAnonymous:
+		config.UserConfig.ConfigFile.TelemetryMode = mode
+	default:
+		exitWithError(clierrors.ConfigInvalidValue.Errorf("Invalid telemetry mode: %s (supported: %s, %s)", mode, config.TelemetryModeFull, config.TelemetryModeAnonymous))
+	}
+
 	if err := config.SaveUserConfigurationFile(); err != nil {
-		exit(fmt.Sprintf("Cannot save configuration file: %s", err), true)
+		exitWithError(clierrors.ConfigSave.Errorf("Cannot save configuration file: %s", err))
+	}
+
+	exit(telemetryConfigurationSummary(), false)
+}
+
+func telemetryConfigurationSummary() string {
+	metricsEnabledTextMap := (map[bool]string{true: "enabled", false: "disabled"})
+
+	mode := config.TelemetryModeFull
+	if config.IsAnonymousTelemetryMode() {
+		mode = config.TelemetryModeAnonymous
 	}
 
-	exit(fmt.Sprintf("Telemetry for Privado CLI: %s", strings.ToUpper(metricsEnabledTextMap[config.UserConfig.ConfigFile.MetricsEnabled])), false)
+	return fmt.Sprint(
+		fmt.Sprintf("Telemetry for Privado CLI: %s\n", strings.ToUpper(metricsEnabledTextMap[config.UserConfig.ConfigFile.MetricsEnabled])),
+		fmt.Sprintf("Telemetry mode: %s\n", mode),
+		fmt.Sprintf("Telemetry endpoint: %s", config.GetTelemetryEndpoint()),
+	)
 }
 
 func init() {
 	metricsCmd.Flags().Bool("enable", false, "Enable telemetry events and performance metrics for Privado CLI")
 	metricsCmd.Flags().Bool("disable", false, "Disable telemetry events and performance metrics for Privado CLI")
 	metricsCmd.MarkFlagsMutuallyExclusive("enable", "disable")
+	metricsCmd.Flags().String("endpoint", "", "Send telemetry to the specified endpoint (for instance, an internal collector) instead of Privado")
+	metricsCmd.Flags().Bool("reset-endpoint", false, "Reset the telemetry endpoint to the Privado default")
+	metricsCmd.MarkFlagsMutuallyExclusive("endpoint", "reset-endpoint")
+	metricsCmd.Flags().String("mode", "", "Set the telemetry mode: 'full' or 'anonymous' (only version, duration bucket, success/failure and error codes are recorded)")
 	// [TODO]: Find a way to keep this and privacy.md in sync
 	// metricsCmd.Flags().Bool("list", false, "List down all telemetry events and metrics used by Privado CLI")
 
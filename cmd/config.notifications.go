@@ -0,0 +1,107 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var notificationsCmd = &cobra.Command{
+	Use:   "notifications",
+	Short: "Show or set the chat tools notified of scans and failed gates",
+	Long:  "Show or set the chat tools notified of failed gates (privado ci), or after each scan (--on always). The Teams webhook url can be set with PRIVADO_TEAMS_WEBHOOK_URL instead",
+	Args:  cobra.NoArgs,
+	Run:   configNotifications,
+}
+
+func configNotifications(cmd *cobra.Command, args []string) {
+	teamsWebhook, _ := cmd.Flags().GetString("teams-webhook")
+	on, _ := cmd.Flags().GetString("on")
+	resetFlag, _ := cmd.Flags().GetBool("reset")
+
+	// if no flags are specified, show the current configuration
+	if teamsWebhook == "" && on == "" && !resetFlag {
+		exit(fmt.Sprint(
+			notificationsConfigurationSummary(),
+			"\nYou can use `--teams-webhook`, `--on` or `--reset` flag to update notification preferences",
+		), false)
+	}
+
+	if resetFlag {
+		config.UserConfig.ConfigFile.Notifications = nil
+	} else {
+		if on != "" && on != config.NotifyOnAlways && on != config.NotifyOnFailure {
+			exitWithError(clierrors.InvalidFlagValue.Errorf("Invalid value for --on: %s (allowed: %s, %s)", on, config.NotifyOnAlways, config.NotifyOnFailure))
+		}
+		if config.UserConfig.ConfigFile.Notifications == nil {
+			config.UserConfig.ConfigFile.Notifications = &config.Notifications{}
+		}
+		if config.UserConfig.ConfigFile.Notifications.Teams == nil {
+			config.UserConfig.ConfigFile.Notifications.Teams = &config.TeamsNotifications{}
+		}
+		teams := config.UserConfig.ConfigFile.Notifications.Teams
+		if teamsWebhook != "" {
+			teams.WebhookURL = teamsWebhook
+		}
+		if on != "" {
+			teams.On = on
+		}
+	}
+
+	if err := config.SaveUserConfigurationFile(); err != nil {
+		exitWithError(clierrors.ConfigSave.Errorf("Cannot save configuration file: %s", err))
+	}
+
+	exit(notificationsConfigurationSummary(), false)
+}
+
+func notificationsConfigurationSummary() string {
+	notifications := config.UserConfig.ConfigFile.Notifications
+	if notifications == nil || notifications.Teams == nil {
+		return "Microsoft Teams: NOT CONFIGURED (unless PRIVADO_TEAMS_WEBHOOK_URL is set)"
+	}
+
+	webhook := "from PRIVADO_TEAMS_WEBHOOK_URL"
+	if notifications.Teams.WebhookURL != "" {
+		webhook = "configured"
+	}
+	if notifications.Teams.On == config.NotifyOnAlways {
+		return fmt.Sprintf("Microsoft Teams: AFTER EACH SCAN (webhook %s)", webhook)
+	}
+	return fmt.Sprintf("Microsoft Teams: FAILED GATES ONLY (webhook %s)", webhook)
+}
+
+func init() {
+	notificationsCmd.Flags().String("teams-webhook", "", "Microsoft Teams incoming webhook (or workflow) url notifications are posted to")
+	notificationsCmd.Flags().String("on", "", "When to notify: always (after each scan) or failure (failed gates of privado ci, default)")
+	notificationsCmd.Flags().Bool("reset", false, "Do not send notifications (default)")
+	notificationsCmd.MarkFlagsMutuallyExclusive("teams-webhook", "reset")
+	notificationsCmd.MarkFlagsMutuallyExclusive("on", "reset")
+	_ = notificationsCmd.RegisterFlagCompletionFunc("on", completeValues(config.NotifyOnAlways, config.NotifyOnFailure))
+
+	configCmd.AddCommand(notificationsCmd)
+}
@@ -0,0 +1,87 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var promptsCmd = &cobra.Command{
+	Use:   "prompts",
+	Short: "Show or set how prompts are answered",
+	Long:  "Show or set how prompts are answered by default: asked, answered yes, or answered with their default (non-interactive, e.g. on build agents). Overridden by the --yes and --non-interactive flags",
+	Args:  cobra.NoArgs,
+	Run:   configPrompts,
+}
+
+func configPrompts(cmd *cobra.Command, args []string) {
+	yesFlag, _ := cmd.Flags().GetBool("always-yes")
+	nonInteractiveFlag, _ := cmd.Flags().GetBool("never-ask")
+	resetFlag, _ := cmd.Flags().GetBool("reset")
+
+	// if no flags are specified, show the current configuration
+	if !yesFlag && !nonInteractiveFlag && !resetFlag {
+		exit(fmt.Sprint(
+			promptsConfigurationSummary(),
+			"\nYou can use `--always-yes`, `--never-ask` or `--reset` flag to update prompt preferences",
+		), false)
+	}
+
+	switch {
+	case yesFlag:
+		config.UserConfig.ConfigFile.Prompts = config.PromptsYes
+	case nonInteractiveFlag:
+		config.UserConfig.ConfigFile.Prompts = config.PromptsNonInteractive
+	default:
+		config.UserConfig.ConfigFile.Prompts = ""
+	}
+
+	if err := config.SaveUserConfigurationFile(); err != nil {
+		exitWithError(clierrors.ConfigSave.Errorf("Cannot save configuration file: %s", err))
+	}
+
+	exit(promptsConfigurationSummary(), false)
+}
+
+func promptsConfigurationSummary() string {
+	switch config.UserConfig.ConfigFile.Prompts {
+	case config.PromptsYes:
+		return "Prompts: ANSWER YES (consent prompts are declined)"
+	case config.PromptsNonInteractive:
+		return "Prompts: NEVER ASK (prompts are answered with their default)"
+	}
+	return "Prompts: ASK (in terminals only, else answered with their default)"
+}
+
+func init() {
+	promptsCmd.Flags().Bool("always-yes", false, "Answer yes to all confirmation prompts without asking")
+	promptsCmd.Flags().Bool("never-ask", false, "Answer all prompts with their default without asking")
+	promptsCmd.Flags().Bool("reset", false, "Ask (default)")
+	promptsCmd.MarkFlagsMutuallyExclusive("always-yes", "never-ask", "reset")
+
+	configCmd.AddCommand(promptsCmd)
+}
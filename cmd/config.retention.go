@@ -0,0 +1,109 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var retentionCmd = &cobra.Command{
+	Use:   "retention",
+	Short: "Show or set the retention of the scan history, logs and archived results",
+	Long: fmt.Sprint(
+		"Show or set how long scans of the local history, scheduled runs (with their archived results) and scan logs are kept. ",
+		"Scans beyond the retention are removed after every scan and by 'privado history prune'",
+	),
+	Args: cobra.NoArgs,
+	Run:  configRetention,
+}
+
+func configRetention(cmd *cobra.Command, args []string) {
+	resetFlag, _ := cmd.Flags().GetBool("reset")
+	keepLastChanged := cmd.Flags().Changed("keep-last")
+	maxAgeChanged := cmd.Flags().Changed("max-age")
+
+	// if no flag is specified, show the current configuration
+	if !keepLastChanged && !maxAgeChanged && !resetFlag {
+		exit(fmt.Sprint(
+			retentionConfigurationSummary(),
+			"\nYou can use the `--keep-last` and `--max-age` flags to change it, or `--reset` to use the defaults",
+		), false)
+	}
+
+	if resetFlag {
+		config.UserConfig.ConfigFile.Retention = nil
+	} else {
+		retention := config.Retention{}
+		if config.UserConfig.ConfigFile.Retention != nil {
+			retention = *config.UserConfig.ConfigFile.Retention
+		}
+		if keepLastChanged {
+			keepLast, _ := cmd.Flags().GetInt("keep-last")
+			if keepLast < 0 {
+				exitWithError(clierrors.InvalidFlagValue.Errorf("Invalid value for --keep-last: %d (0 to keep all scans)", keepLast))
+			}
+			retention.KeepLast = keepLast
+		}
+		if maxAgeChanged {
+			maxAge, _ := cmd.Flags().GetString("max-age")
+			if maxAge != "" {
+				if _, err := utils.ParseDuration(maxAge); err != nil {
+					exitWithError(clierrors.InvalidFlagValue.Errorf("Invalid value for --max-age: %s", err))
+				}
+			}
+			retention.MaxAge = maxAge
+		}
+		config.UserConfig.ConfigFile.Retention = &retention
+	}
+
+	if err := config.SaveUserConfigurationFile(); err != nil {
+		exitWithError(clierrors.ConfigSave.Errorf("Cannot save configuration file: %s", err))
+	}
+
+	exit(retentionConfigurationSummary(), false)
+}
+
+func retentionConfigurationSummary() string {
+	keepLast, historyMaxAge := getRetention(config.AppConfig.HistoryRetention)
+	_, logsMaxAge := getRetention(config.AppConfig.LogRetention)
+
+	kept := "all scans"
+	if keepLast > 0 {
+		kept = fmt.Sprintf("last %d scan(s) of each repository and schedule, last %d log(s)", keepLast, keepLast)
+	}
+	return fmt.Sprintf("Retention: %s; history and archived results for %s, logs for %s (at most %d MB)",
+		kept, formatDays(historyMaxAge), formatDays(logsMaxAge), config.AppConfig.LogsMaxTotalSize>>20)
+}
+
+func init() {
+	retentionCmd.Flags().Int("keep-last", 0, "Scans kept for each repository and schedule (0 to keep all within the max age)")
+	retentionCmd.Flags().String("max-age", "", "Age after which scans, runs and logs are removed, e.g. 90d or 2w (empty: 90 days for the history, 30 days for logs)")
+	retentionCmd.Flags().Bool("reset", false, "Use the default retention")
+
+	configCmd.AddCommand(retentionCmd)
+}
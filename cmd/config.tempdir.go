@@ -0,0 +1,82 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+	"github.com/spf13/cobra"
+)
+
+var tempDirCmd = &cobra.Command{
+	Use:   "temp-dir [directory]",
+	Short: "Show or set the directory for temporary workspaces and files",
+	Long:  "Show or set the directory for temporary workspaces and files (e.g. --copy-source workspaces), instead of the system default ($TMPDIR). Useful when /tmp is small, e.g. a tmpfs on CI agents",
+	Args:  cobra.MaximumNArgs(1),
+	Run:   configTempDir,
+}
+
+func configTempDir(cmd *cobra.Command, args []string) {
+	resetFlag, _ := cmd.Flags().GetBool("reset")
+
+	// if no directory is specified, show the current configuration
+	if len(args) == 0 && !resetFlag {
+		exit(fmt.Sprint(
+			tempDirConfigurationSummary(),
+			"\nYou can specify a directory or use `--reset` flag to use the system default",
+		), false)
+	}
+
+	if resetFlag {
+		config.UserConfig.ConfigFile.TempDirectory = ""
+	} else {
+		directory := fileutils.GetAbsolutePath(args[0])
+		if err := os.MkdirAll(directory, os.ModePerm); err != nil {
+			exitWithError(clierrors.TempDirectoryCreation.Errorf("Cannot create directory: %s", err))
+		}
+		config.UserConfig.ConfigFile.TempDirectory = directory
+	}
+
+	if err := config.SaveUserConfigurationFile(); err != nil {
+		exitWithError(clierrors.ConfigSave.Errorf("Cannot save configuration file: %s", err))
+	}
+
+	exit(tempDirConfigurationSummary(), false)
+}
+
+func tempDirConfigurationSummary() string {
+	if config.UserConfig.ConfigFile.TempDirectory == "" {
+		return "Temporary directory: system default ($TMPDIR)"
+	}
+	return fmt.Sprintf("Temporary directory: %s", config.UserConfig.ConfigFile.TempDirectory)
+}
+
+func init() {
+	tempDirCmd.Flags().Bool("reset", false, "Use the system default temporary directory")
+
+	configCmd.AddCommand(tempDirCmd)
+}
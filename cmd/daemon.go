@@ -0,0 +1,153 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+	"github.com/Privado-Inc/privado-cli/pkg/report"
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+	"github.com/Privado-Inc/privado-cli/pkg/schedule"
+	"github.com/spf13/cobra"
+)
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Run scheduled scans (see 'privado schedule')",
+	Long:  "Run scheduled scans (see 'privado schedule') until interrupted. Results of each run are kept in the history and configured notifications are sent",
+	Args:  cobra.ExactArgs(0),
+	Run:   daemon,
+}
+
+func daemon(cmd *cobra.Command, args []string) {
+	fmt.Println("> Running scheduled scans from:", config.AppConfig.SchedulesPath)
+	fmt.Println("> History:", config.AppConfig.HistoryDirectory)
+
+	// the next run of each schedule is evaluated from the time the daemon
+	// started (or its last run), so runs missed while down are skipped
+	lastChecked := time.Now()
+	for {
+		now := time.Now()
+		schedules, err := schedule.Load(config.AppConfig.SchedulesPath)
+		if err != nil {
+			fmt.Println("[WARN]: Cannot load schedules:", err)
+		}
+
+		for _, s := range schedules {
+			from := lastChecked
+			if s.LastRunAt.After(from) {
+				from = s.LastRunAt
+			}
+			if next := s.NextRun(from); !next.IsZero() && !next.After(now) {
+				runScheduledScan(s)
+			}
+		}
+		lastChecked = now
+
+		// wake up at the start of the next minute
+		time.Sleep(time.Until(time.Now().Truncate(time.Minute).Add(time.Minute)))
+	}
+}
+
+func runScheduledScan(s *schedule.Schedule) {
+	run := schedule.Run{
+		ScheduleId: s.Id,
+		Repository: s.Repository,
+		StartedAt:  time.Now(),
+		Status:     schedule.StatusFailed,
+	}
+	runDirectory := schedule.RunDirectory(config.AppConfig.HistoryDirectory, s.Id, run.StartedAt)
+	run.LogPath = filepath.Join(runDirectory, "scan.log")
+	fmt.Printf("> [%s] Scanning %s (schedule: %s)\n", run.StartedAt.Format("2006-01-02 15:04"), s.Repository, s.Id)
+
+	if err := os.MkdirAll(runDirectory, os.ModePerm); err != nil {
+		run.Error = err.Error()
+	} else if err := runScanProcess(s.Repository, run.LogPath, s.ScanArgs); err != nil {
+		run.Error = fmt.Sprintf("scan failed: %s", err)
+	} else {
+		// keep a copy of the results in the history
+		run.ResultsPath = filepath.Join(runDirectory, "privado.json")
+		if err := fileutils.CopyFile(filepath.Join(s.Repository, config.AppConfig.PrivacyResultsPathSuffix), run.ResultsPath); err != nil {
+			run.Error = fmt.Sprintf("cannot read results: %s", err)
+		} else if scanResults, err := results.LoadResults(run.ResultsPath); err != nil {
+			run.Error = err.Error()
+		} else {
+			run.FindingsBySeverity = results.CountFindingsBySeverity(scanResults.Findings())
+			run.Status = schedule.StatusSucceeded
+			emailScheduledReport(s, scanResults)
+		}
+	}
+	run.CompletedAt = time.Now()
+	fmt.Printf("> [%s] Scan of %s %s\n", run.CompletedAt.Format("2006-01-02 15:04"), s.Repository, run.Status)
+
+	if err := schedule.RecordRun(config.AppConfig.HistoryDirectory, run); err != nil {
+		fmt.Println("[WARN]: Cannot record run in history:", err)
+	}
+	if _, _, err := applyHistoryRetention(); err != nil {
+		fmt.Println("[WARN]: Cannot remove old runs from history:", err)
+	}
+	for _, webhook := range s.NotifyWebhooks {
+		if err := schedule.NotifyWebhook(webhook, run); err != nil {
+			fmt.Println("[WARN]: Cannot notify webhook:", err)
+		}
+	}
+
+	// update the schedule without overwriting concurrent changes to others
+	if schedules, err := schedule.Load(config.AppConfig.SchedulesPath); err == nil {
+		for _, current := range schedules {
+			if current.Id == s.Id {
+				current.LastRunAt = run.StartedAt
+				current.LastStatus = run.Status
+			}
+		}
+		if err := schedule.Save(config.AppConfig.SchedulesPath, schedules); err != nil {
+			fmt.Println("[WARN]: Cannot update schedule:", err)
+		}
+	}
+	s.LastRunAt = run.StartedAt
+}
+
+func emailScheduledReport(s *schedule.Schedule, scanResults *results.Results) {
+	if len(s.EmailReports) == 0 {
+		return
+	}
+	if scanResults.RepoName == "" {
+		scanResults.RepoName = filepath.Base(s.Repository)
+	}
+	format := s.ReportFormat
+	if _, ok := reportFormatExtensions[format]; !ok {
+		format = "pdf"
+	}
+	if err := emailReport(report.NewAudit(scanResults, time.Now()), format, s.EmailReports, ""); err != nil {
+		fmt.Println("[WARN]: Cannot email report:", err)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(daemonCmd)
+}
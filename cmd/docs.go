@@ -0,0 +1,120 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/docs"
+	"github.com/spf13/cobra"
+)
+
+var docsCmd = &cobra.Command{
+	Use:   "docs",
+	Short: "Generate reference documentation of the cli (man pages, markdown)",
+}
+
+var docsManCmd = &cobra.Command{
+	Use:   "man",
+	Short: "Generate man pages for all commands",
+	Long:  "Generate a man page for each command (e.g. privado-scan.1) including all flags and exit codes. Set SOURCE_DATE_EPOCH for reproducible pages",
+	Args:  cobra.ExactArgs(0),
+	Run:   docsMan,
+}
+
+var docsMarkdownCmd = &cobra.Command{
+	Use:   "markdown",
+	Short: "Generate a markdown reference for all commands",
+	Long:  "Generate a markdown reference page for each command (e.g. privado_scan.md) including all flags and exit codes",
+	Args:  cobra.ExactArgs(0),
+	Run:   docsMarkdown,
+}
+
+// Returns the default exit codes of scan outcomes, documented on each page
+// (user configured codes are local to the machine and not documented)
+func getDocumentedExitCodes() []docs.ExitCode {
+	exitCodes := []docs.ExitCode{}
+	for _, outcome := range config.Outcomes {
+		exitCodes = append(exitCodes, docs.ExitCode{
+			Code:        config.GetDefaultExitCode(outcome),
+			Outcome:     outcome,
+			Description: config.OutcomeDescriptions[outcome],
+		})
+	}
+	return exitCodes
+}
+
+// Returns SOURCE_DATE_EPOCH (if set) for reproducible builds, else now
+func getDocumentationDate() time.Time {
+	if epoch := os.Getenv("SOURCE_DATE_EPOCH"); epoch != "" {
+		if seconds, err := strconv.ParseInt(epoch, 10, 64); err == nil {
+			return time.Unix(seconds, 0).UTC()
+		}
+		fmt.Println("[WARN]: Invalid SOURCE_DATE_EPOCH, using the current date:", epoch)
+	}
+	return time.Now()
+}
+
+func docsMan(cmd *cobra.Command, args []string) {
+	directory, _ := cmd.Flags().GetString("dir")
+	section, _ := cmd.Flags().GetString("section")
+
+	header := docs.ManHeader{
+		Section: section,
+		Source:  fmt.Sprintf("Privado CLI %s", Version),
+		Manual:  "Privado Manual",
+		Date:    getDocumentationDate(),
+	}
+	paths, err := docs.GenerateManPages(rootCmd, directory, header, getDocumentedExitCodes())
+	if err != nil {
+		exitWithError(clierrors.DocsGeneration.Errorf("Cannot generate man pages: %s", err))
+	}
+	exit(fmt.Sprintf("> Generated %d man page(s) in: %s", len(paths), directory), false)
+}
+
+func docsMarkdown(cmd *cobra.Command, args []string) {
+	directory, _ := cmd.Flags().GetString("dir")
+
+	paths, err := docs.GenerateMarkdown(rootCmd, directory, getDocumentedExitCodes())
+	if err != nil {
+		exitWithError(clierrors.DocsGeneration.Errorf("Cannot generate markdown reference: %s", err))
+	}
+	exit(fmt.Sprintf("> Generated %d page(s) in: %s", len(paths), directory), false)
+}
+
+func init() {
+	docsManCmd.Flags().String("dir", "man", "Directory to write the man pages to")
+	docsManCmd.Flags().String("section", "1", "Manual section of the pages")
+	docsMarkdownCmd.Flags().String("dir", "docs", "Directory to write the markdown pages to")
+	_ = docsManCmd.RegisterFlagCompletionFunc("dir", completeDirectory)
+	_ = docsMarkdownCmd.RegisterFlagCompletionFunc("dir", completeDirectory)
+
+	docsCmd.AddCommand(docsManCmd)
+	docsCmd.AddCommand(docsMarkdownCmd)
+	rootCmd.AddCommand(docsCmd)
+}
@@ -0,0 +1,187 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/docker"
+	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+	"github.com/spf13/cobra"
+)
+
+var engineCmd = &cobra.Command{
+	Use:   "engine",
+	Short: "Experimental: manage a persistent engine container that scans are run in (scan --persistent-engine)",
+	Long: "Experimental: manage a long-lived engine container. Scans with --persistent-engine are run in it (docker exec) " +
+		"instead of a container of their own, which saves the container startup of each scan, e.g. when scanning many small repositories. " +
+		"Repositories must be in the root directory of the engine (--root); scans that need mounts of their own " +
+		"(excluded files, additional source roots, external rules) are run in a container of their own",
+}
+
+var engineStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Start the persistent engine container",
+	Args:  cobra.ExactArgs(0),
+	Run:   engineStart,
+}
+
+var engineStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop and remove the persistent engine container, including running scans",
+	Args:  cobra.ExactArgs(0),
+	Run:   engineStop,
+}
+
+var engineStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the status of the persistent engine container",
+	Args:  cobra.ExactArgs(0),
+	Run:   engineStatus,
+}
+
+func engineStart(cmd *cobra.Command, args []string) {
+	root, _ := cmd.Flags().GetString("root")
+	root = fileutils.GetAbsolutePath(root)
+	if exists, _ := fileutils.DoesFileExists(root); !exists {
+		exitWithError(clierrors.PathNotFound.Errorf("Root directory not found: %s", root))
+	}
+
+	if engine, err := docker.GetPersistentEngine(); err != nil {
+		exitWithError(clierrors.DockerRun.Errorf("Cannot inspect the persistent engine: %s", err))
+	} else if engine != nil {
+		if engine.Running {
+			exit(fmt.Sprintf("> The persistent engine is already running (root: %s), stop it with 'privado engine stop'", engine.Root), false)
+		}
+		// remove the stopped container, e.g. after a restart of docker
+		if err := docker.StopPersistentEngine(); err != nil {
+			exitWithError(clierrors.DockerRun.Errorf("Cannot remove the stopped persistent engine: %s", err))
+		}
+	}
+
+	if dockerAccessKey, err := docker.GetPrivadoDockerAccessKey(true); err != nil || dockerAccessKey == "" {
+		exitWithError(clierrors.DockerAccessKey.Errorf("Cannot fetch docker access key: %v \nPlease try again or raise an issue at %s", err, config.AppConfig.PrivadoRepository))
+	} else {
+		config.LoadUserDockerHash(dockerAccessKey)
+	}
+
+	engine, err := docker.StartPersistentEngine(root,
+		docker.OptionWithLatestImage(false), // because we already pull the image for access-key (with pullImage parameter)
+		docker.OptionWithUserConfigVolume(config.AppConfig.UserConfigurationFilePath),
+		docker.OptionWithUserKeyVolume(config.AppConfig.UserKeyPath),
+		docker.OptionWithPackageCacheVolumes(),
+		docker.OptionWithJVMClassArchive(config.GetJVMCacheDirectory(), getJVMClassArchiveName(), false),
+	)
+	if err != nil {
+		exitWithError(clierrors.DockerRun.Errorf("Cannot start the persistent engine: %s", err))
+	}
+	fmt.Println("> Persistent engine started:", engine.Id)
+	fmt.Println("> Root directory:", engine.Root)
+	exit("> Scan repositories in the root directory with 'privado scan <repository> --persistent-engine'", false)
+}
+
+func engineStop(cmd *cobra.Command, args []string) {
+	engine, err := docker.GetPersistentEngine()
+	if err != nil {
+		exitWithError(clierrors.DockerRun.Errorf("Cannot inspect the persistent engine: %s", err))
+	}
+	if engine == nil {
+		exit("> The persistent engine is not running", false)
+	}
+	if err := docker.StopPersistentEngine(); err != nil {
+		exitWithError(clierrors.DockerRun.Errorf("Cannot stop the persistent engine: %s", err))
+	}
+	exit("> Persistent engine stopped", false)
+}
+
+func engineStatus(cmd *cobra.Command, args []string) {
+	engine, err := docker.GetPersistentEngine()
+	if err != nil {
+		exitWithError(clierrors.DockerRun.Errorf("Cannot inspect the persistent engine: %s", err))
+	}
+	if engine == nil {
+		exit("> The persistent engine is not running (start it with 'privado engine start')", false)
+	}
+	fmt.Println("> Container ID:", engine.Id)
+	fmt.Println("> Status:", engine.Status)
+	fmt.Println("> Image:", engine.Image)
+	fmt.Println("> Root directory:", engine.Root)
+	if engine.Image != config.AppConfig.Container.ImageURL {
+		fmt.Println("[WARN]: The engine runs a different image than scans use, restart it with 'privado engine stop' and 'privado engine start'")
+	}
+}
+
+// Returns the persistent engine the scan of the directory is run in (scan
+// --persistent-engine), nil to run the scan in a container of its own:
+// if the engine is not running, the directory is not in its root, or the
+// scan needs mounts of its own
+func getPersistentEngineForScan(directory string, mounts []string) *docker.PersistentEngine {
+	engine, err := docker.GetPersistentEngine()
+	switch {
+	case err != nil:
+		fmt.Println("[WARN]: Cannot inspect the persistent engine, scanning in a new container:", err)
+	case engine == nil || !engine.Running:
+		fmt.Println("[WARN]: The persistent engine is not running (run 'privado engine start'), scanning in a new container")
+	case engine.Image != config.AppConfig.Container.ImageURL:
+		fmt.Printf("[WARN]: The persistent engine runs a different image (%s), scanning in a new container\n", engine.Image)
+	case len(mounts) > 0:
+		fmt.Printf("[WARN]: The scan needs mounts of its own (%s), scanning in a new container (--copy-source applies excluded files without mounts)\n", strings.Join(mounts, ", "))
+	default:
+		if _, ok := engine.GetContainerPath(directory); ok {
+			return engine
+		}
+		fmt.Printf("[WARN]: %s is not in the root directory of the persistent engine (%s), scanning in a new container\n", directory, engine.Root)
+	}
+	return nil
+}
+
+// Returns the mounts the scan needs besides the source code, by name
+func getScanMounts(ignoredDirectories, excludedFiles []string, additionalSourceRoots []docker.SourceRoot, sharedGitDirectory, externalRules string) []string {
+	mounts := []string{}
+	if len(ignoredDirectories) > 0 || len(excludedFiles) > 0 {
+		mounts = append(mounts, "excluded files")
+	}
+	if len(additionalSourceRoots) > 0 {
+		mounts = append(mounts, "additional source roots")
+	}
+	if sharedGitDirectory != "" {
+		mounts = append(mounts, "git directory")
+	}
+	if externalRules != "" {
+		mounts = append(mounts, "external rules")
+	}
+	return mounts
+}
+
+func init() {
+	engineStartCmd.Flags().String("root", "", "Directory mounted in the engine container; repositories scanned with --persistent-engine must be in it")
+	_ = engineStartCmd.MarkFlagRequired("root")
+	_ = engineStartCmd.RegisterFlagCompletionFunc("root", completeDirectory)
+
+	engineCmd.AddCommand(engineStartCmd)
+	engineCmd.AddCommand(engineStopCmd)
+	engineCmd.AddCommand(engineStatusCmd)
+	rootCmd.AddCommand(engineCmd)
+}
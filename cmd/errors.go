@@ -0,0 +1,85 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var errorsCmd = &cobra.Command{
+	Use:   "errors [code]",
+	Short: "List error codes, or describe an error code (e.g. PRV-DOCKER-001)",
+	Long:  "List the codes errors are reported with (e.g. '[PRV-DOCKER-001] ...'), or describe an error code. Codes are stable, so scripts can branch on them",
+	Args:  cobra.MaximumNArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		ids := []string{}
+		for _, code := range clierrors.All() {
+			ids = append(ids, code.Id+"\t"+code.Description)
+		}
+		return ids, cobra.ShellCompDirectiveNoFileComp
+	},
+	Run: listErrors,
+}
+
+// Returns the exit code of errors with the code (default configuration)
+func getErrorExitCode(code *clierrors.Code) int {
+	if code.Outcome == "" {
+		return 1
+	}
+	return config.GetExitCode(code.Outcome, nil)
+}
+
+func listErrors(cmd *cobra.Command, args []string) {
+	if len(args) > 0 {
+		code, ok := clierrors.Lookup(args[0])
+		if !ok {
+			exitWithError(clierrors.InvalidArguments.Errorf("Unknown error code: %s (run 'privado errors' to list all codes)", args[0]))
+		}
+		lines := []string{
+			fmt.Sprintf("%s: %s", code.Id, code.Description),
+			fmt.Sprintf("Exit code: %d", getErrorExitCode(code)),
+		}
+		if code.Outcome != "" {
+			lines = append(lines, fmt.Sprintf("Outcome: %s (see 'privado config exit-codes')", code.Outcome))
+		}
+		exit(strings.Join(lines, "\n"), false)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CODE\tEXIT CODE\tDESCRIPTION")
+	for _, code := range clierrors.All() {
+		fmt.Fprintf(w, "%s\t%d\t%s\n", code.Id, getErrorExitCode(code), code.Description)
+	}
+	w.Flush()
+}
+
+func init() {
+	rootCmd.AddCommand(errorsCmd)
+}
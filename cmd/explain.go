@@ -0,0 +1,209 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/docker"
+	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+	"github.com/Privado-Inc/privado-cli/pkg/rules"
+	"github.com/spf13/cobra"
+)
+
+var explainCmd = &cobra.Command{
+	Use:   "explain <finding-id> [repository]",
+	Short: "Explain why a finding was raised",
+	Long: fmt.Sprint(
+		"Explain why a finding of the last scan of the repository (default: current directory) was raised: ",
+		"the policy it violates, the rules of its data element and recipient with the identifiers they matched in the code, ",
+		"the flow semantics of the methods the data went through, and links to the definitions of the rules. ",
+		"Rules are read from the engine image (if pulled) and the external rules of the scan (-c)",
+	),
+	Args: cobra.RangeArgs(1, 2),
+	Run:  explain,
+}
+
+// Returns the directory of the default rules of the engine image, extracted
+// once per image digest to the cache directory. Empty if the image is not
+// pulled or the rules cannot be extracted
+func getDefaultRulesDirectory(image string) string {
+	digest := docker.GetImageDigest(image)
+	if digest == "" {
+		fmt.Println("[WARN]: The engine image is not pulled, the default rules are only linked (run 'privado scan' or 'privado preload' first)")
+		return ""
+	}
+	directory := filepath.Join(config.AppConfig.CacheDirectory, "rules", strings.ReplaceAll(digest, ":", "-"))
+	rulesDirectory := filepath.Join(directory, filepath.FromSlash(config.AppConfig.Container.InternalRulesVolumeDir))
+	if exists, _ := fileutils.DoesFileExists(rulesDirectory); exists {
+		return rulesDirectory
+	}
+
+	if _, err := docker.ExtractImageFiles(image, []string{config.AppConfig.Container.InternalRulesVolumeDir}, directory, nil); err != nil {
+		os.RemoveAll(directory)
+		fmt.Println("[WARN]: Could not read the default rules from the engine image:", err)
+		return ""
+	}
+	return rulesDirectory
+}
+
+func explain(cmd *cobra.Command, args []string) {
+	repository := "."
+	if len(args) > 1 {
+		repository = args[1]
+	}
+	repositoryPath := fileutils.GetAbsolutePath(repository)
+	externalRules, _ := cmd.Flags().GetString("config")
+	format, _ := cmd.Flags().GetString("format")
+	if format != "text" && format != "json" {
+		exitWithError(clierrors.InvalidFlagValue.Errorf("Invalid format '%s' (text, json)", format))
+	}
+
+	scanResults, err := results.LoadResults(filepath.Join(repositoryPath, config.AppConfig.PrivacyResultsPathSuffix))
+	if err != nil {
+		exitWithError(clierrors.ResultsRead.Errorf("Cannot read scan results (run 'privado scan' first): %s", err))
+	}
+	finding, ok := scanResults.FindFinding(args[0])
+	if !ok {
+		exitWithError(clierrors.InvalidArguments.Errorf("No finding with the id %s in the results (see 'privado results list')", args[0]))
+	}
+
+	externalDirectories := []string{}
+	if externalRules != "" {
+		externalRules = fileutils.GetAbsolutePath(externalRules)
+		if exists, _ := fileutils.DoesFileExists(externalRules); !exists {
+			exitWithError(clierrors.PathNotFound.Errorf("The rules directory does not exist: %s", externalRules))
+		}
+		externalDirectories = append(externalDirectories, externalRules)
+	}
+	ruleSet, err := rules.Load(getDefaultRulesDirectory(config.AppConfig.Container.ImageURL), externalDirectories...)
+	if err != nil {
+		fmt.Println("[WARN]: Could not read the rules, the explanation only links to them:", err)
+	}
+	explanation := rules.Explain(scanResults, finding, ruleSet)
+
+	if format == "json" {
+		data, _ := json.MarshalIndent(explanation, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+	printExplanation(explanation)
+}
+
+func printExplanation(explanation rules.Explanation) {
+	finding := explanation.Finding
+	fmt.Printf("> [%s] %s (%s)\n", strings.ToUpper(finding.Severity), finding.PolicyName, finding.Id)
+	if finding.Description != "" {
+		fmt.Println(" ", finding.Description)
+	}
+
+	fmt.Println("\n> Why it was raised:")
+	if finding.SinkId != "" {
+		fmt.Printf("  The policy %s matches dataflows of data elements to recipients:\n", finding.PolicyId)
+		fmt.Printf("  %s flows to %s in %d step(s)\n", getReferenceName(explanation.Source), getReferenceName(*explanation.Sink), explanation.Steps)
+	} else {
+		fmt.Printf("  The policy %s matches the processing of data elements:\n", finding.PolicyId)
+		fmt.Printf("  %s is processed in the code\n", getReferenceName(explanation.Source))
+	}
+	if policy := explanation.Policy.Rule; policy != nil && policy.DataFlow != nil {
+		if len(policy.DataFlow.Sources) > 0 {
+			fmt.Println("  Data elements of the policy:", strings.Join(policy.DataFlow.Sources, ", "))
+		}
+		if len(policy.DataFlow.Sinks) > 0 {
+			fmt.Println("  Recipients of the policy:   ", strings.Join(policy.DataFlow.Sinks, ", "))
+		}
+	}
+
+	fmt.Println("\n> Rules:")
+	references := []rules.RuleReference{explanation.Policy, explanation.Source}
+	if explanation.Sink != nil {
+		references = append(references, *explanation.Sink)
+	}
+	for _, reference := range references {
+		fmt.Printf("  [%s] %s\n", reference.Kind, getReferenceName(reference))
+		if rule := reference.Rule; rule != nil {
+			if len(rule.Patterns) > 0 {
+				fmt.Println("    Patterns:  ", strings.Join(rule.Patterns, ", "))
+			}
+			if len(rule.Domains) > 0 {
+				fmt.Println("    Domains:   ", strings.Join(rule.Domains, ", "))
+			}
+			if rule.Category != "" {
+				fmt.Println("    Category:  ", rule.Category)
+			}
+			if rule.Sensitivity != "" {
+				fmt.Println("    Sensitivity:", rule.Sensitivity)
+			}
+			fmt.Println("    Defined in:", rule.File)
+		}
+		if reference.URL != "" {
+			fmt.Println("    Source:    ", reference.URL)
+		}
+	}
+
+	if len(explanation.MatchedIdentifiers) > 0 {
+		fmt.Println("\n> Matched identifiers:")
+		for _, identifier := range explanation.MatchedIdentifiers {
+			fmt.Printf("  %s:%d  %s  (%s)\n", identifier.FileName, identifier.LineNumber, strings.TrimSpace(identifier.Sample), identifier.RuleId)
+		}
+	}
+
+	if finding.SinkId != "" {
+		fmt.Println("\n> Flow semantics:")
+		if len(explanation.Semantics) == 0 {
+			fmt.Println("  The data flows through assignments, arguments and return values (default semantics of the engine)")
+		}
+		for _, step := range explanation.Semantics {
+			fmt.Printf("  [step %d] %s:%d  %s\n", step.Step, step.FileName, step.LineNumber, strings.TrimSpace(step.Sample))
+			for _, semantic := range step.Semantics {
+				fmt.Printf("    %s  %s  (%s)\n", semantic.Signature, semantic.Flow, semantic.File)
+			}
+		}
+		fmt.Printf("\n  See the full path with 'privado results show %s'\n", finding.Id)
+	}
+
+	if explanation.Fix != "" {
+		fmt.Println("\n> Fix:")
+		fmt.Println(" ", explanation.Fix)
+	}
+}
+
+func getReferenceName(reference rules.RuleReference) string {
+	if reference.Name == "" || reference.Name == reference.Id {
+		return reference.Id
+	}
+	return fmt.Sprintf("%s (%s)", reference.Name, reference.Id)
+}
+
+func init() {
+	explainCmd.Flags().StringP("config", "c", "", "The config (with rules) directory the repository was scanned with (scan -c), its rules replacing the default rules")
+	explainCmd.Flags().String("format", "text", "Output format: text or json")
+	_ = explainCmd.RegisterFlagCompletionFunc("format", completeValues("text", "json"))
+	rootCmd.AddCommand(explainCmd)
+}
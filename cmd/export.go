@@ -0,0 +1,176 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/export"
+	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+	"github.com/Privado-Inc/privado-cli/pkg/gitutils"
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+	"github.com/spf13/cobra"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export <repository>",
+	Short: "Export the findings of the repository to Elasticsearch, Splunk or ServiceNow",
+	Long: `Export the findings of the repository (results of the last scan) to the destinations configured in ~/.privado/config.json, e.g.:
+
+  "exports": {
+    "elasticsearch": {"url": "https://elastic:9200", "index": "privado-findings", "apiKey": "..."},
+    "splunk": {"url": "https://splunk:8088", "token": "...", "index": "privacy"},
+    "servicenow": {"url": "https://company.service-now.com", "table": "incident", "username": "privado", "password": "...",
+                   "fields": {"short_description": "{{.PolicyName}} in {{.Repository}}", "assignment_group": "Privacy"}}
+  }
+
+Secrets can be set with PRIVADO_ELASTICSEARCH_API_KEY, PRIVADO_ELASTICSEARCH_PASSWORD, PRIVADO_SPLUNK_HEC_TOKEN,
+PRIVADO_SERVICENOW_PASSWORD and PRIVADO_SERVICENOW_TOKEN instead.
+Findings have stable ids (repository, branch and fingerprint), re-exports update the documents in Elasticsearch
+and the records in ServiceNow (matched by the correlation field, default: correlation_id)`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeDirectoryArgument,
+	Run: func(cmd *cobra.Command, args []string) {
+		destinations, _ := cmd.Flags().GetStringSlice("to")
+		if err := exportFindings(fileutils.GetAbsolutePath(args[0]), destinations); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+// Returns the configured exporters, only the destinations if specified
+func getExporters(destinations []string) ([]export.Exporter, error) {
+	exports := config.UserConfig.ConfigFile.Exports
+	if exports == nil {
+		exports = &config.Exports{}
+	}
+
+	exporters := []export.Exporter{}
+	if e := exports.Elasticsearch; e != nil {
+		exporters = append(exporters, &export.Elasticsearch{
+			URL:       e.URL,
+			Index:     e.Index,
+			APIKey:    valueOrEnvironment(e.APIKey, "PRIVADO_ELASTICSEARCH_API_KEY"),
+			Username:  e.Username,
+			Password:  valueOrEnvironment(e.Password, "PRIVADO_ELASTICSEARCH_PASSWORD"),
+			BatchSize: e.BatchSize,
+		})
+	}
+	if s := exports.Splunk; s != nil {
+		exporters = append(exporters, &export.Splunk{
+			URL:        s.URL,
+			Token:      valueOrEnvironment(s.Token, "PRIVADO_SPLUNK_HEC_TOKEN"),
+			Index:      s.Index,
+			Source:     s.Source,
+			SourceType: s.SourceType,
+			BatchSize:  s.BatchSize,
+		})
+	}
+	if s := exports.ServiceNow; s != nil {
+		exporters = append(exporters, &export.ServiceNow{
+			URL:              s.URL,
+			Table:            s.Table,
+			Username:         s.Username,
+			Password:         valueOrEnvironment(s.Password, "PRIVADO_SERVICENOW_PASSWORD"),
+			Token:            valueOrEnvironment(s.Token, "PRIVADO_SERVICENOW_TOKEN"),
+			CorrelationField: s.CorrelationField,
+			Fields:           s.Fields,
+		})
+	}
+
+	if len(destinations) == 0 {
+		if len(exporters) == 0 {
+			return nil, fmt.Errorf("no export destination is configured (exports in %s)", config.AppConfig.UserConfigurationFilePath)
+		}
+		return exporters, nil
+	}
+
+	selected := []export.Exporter{}
+	for _, destination := range destinations {
+		found := false
+		for _, exporter := range exporters {
+			if exporter.Name() == destination {
+				selected = append(selected, exporter)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("export destination '%s' is not configured (exports in %s)", destination, config.AppConfig.UserConfigurationFilePath)
+		}
+	}
+	return selected, nil
+}
+
+func valueOrEnvironment(value, envKey string) string {
+	if value != "" {
+		return value
+	}
+	return os.Getenv(envKey)
+}
+
+// Exports the findings in the results of the repository to the destinations
+// (all configured if empty)
+func exportFindings(repositoryPath string, destinations []string) *clierrors.Error {
+	exporters, err := getExporters(destinations)
+	if err != nil {
+		return clierrors.ExportFailed.Wrap(err)
+	}
+
+	resultsPath := filepath.Join(repositoryPath, config.AppConfig.PrivacyResultsPathSuffix)
+	scanResults, err := results.LoadResults(resultsPath)
+	if err != nil {
+		return clierrors.ResultsRead.Errorf("Cannot read results: %s", err)
+	}
+
+	scanTime := time.Now()
+	if info, err := os.Stat(resultsPath); err == nil {
+		scanTime = info.ModTime()
+	}
+	documents := export.NewDocuments(export.ScanInfo{
+		Repository: filepath.Base(repositoryPath),
+		RemoteURL:  gitutils.GetRemoteURL(repositoryPath),
+		Branch:     gitutils.GetCurrentBranch(repositoryPath),
+		Commit:     gitutils.GetCurrentCommit(repositoryPath),
+		Timestamp:  scanTime,
+		CLIVersion: Version,
+	}, scanResults.Findings())
+
+	for _, exporter := range exporters {
+		fmt.Printf("> Exporting %d finding(s) to %s\n", len(documents), exporter.Name())
+		if err := exporter.Export(documents); err != nil {
+			return clierrors.ExportFailed.Errorf("Cannot export findings to %s: %s", exporter.Name(), err)
+		}
+	}
+	return nil
+}
+
+func init() {
+	exportCmd.Flags().StringSlice("to", nil, "Destinations to export to: elasticsearch, splunk, servicenow (default: all configured)")
+	_ = exportCmd.RegisterFlagCompletionFunc("to", completeValues("elasticsearch", "splunk", "servicenow"))
+	rootCmd.AddCommand(exportCmd)
+}
@@ -0,0 +1,154 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/cloud"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/feedback"
+	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+	"github.com/Privado-Inc/privado-cli/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var feedbackCmd = &cobra.Command{
+	Use:   "feedback <finding-id> [repository]",
+	Short: "Report a finding as a false positive",
+	Long: fmt.Sprint(
+		"Report a finding of the last scan of the repository (default: current directory) as a false positive: ",
+		"the finding is excluded in <repository>/.privado/exclusions.json (commit it to exclude it for everyone), ",
+		"so it is no longer listed or failing ci, and, with consent, structured feedback is submitted to Privado Cloud ",
+		"so recurring false positives can drive rule improvements. The code of the finding is only submitted with --include-code",
+	),
+	Args: cobra.RangeArgs(1, 2),
+	Run:  submitFeedback,
+}
+
+func getExclusionsPath(repositoryPath string) string {
+	return filepath.Join(repositoryPath, filepath.Dir(config.AppConfig.PrivacyResultsPathSuffix), feedback.ExclusionsFileName)
+}
+
+// Returns the findings that are not excluded as false positives
+// (see 'privado feedback'), and the number of excluded findings
+func filterExcludedFindings(repositoryPath string, findings []results.Finding) ([]results.Finding, int) {
+	exclusions, err := feedback.LoadExclusions(getExclusionsPath(repositoryPath))
+	if err != nil {
+		fmt.Println("[WARN]: Could not read the exclusions of the repository:", err)
+		return findings, 0
+	}
+	kept, excluded := exclusions.Filter(findings)
+	return kept, len(excluded)
+}
+
+func submitFeedback(cmd *cobra.Command, args []string) {
+	repository := "."
+	if len(args) > 1 {
+		repository = args[1]
+	}
+	repositoryPath := fileutils.GetAbsolutePath(repository)
+	falsePositive, _ := cmd.Flags().GetBool("false-positive")
+	reason, _ := cmd.Flags().GetString("reason")
+	share, _ := cmd.Flags().GetBool("share")
+	noSubmit, _ := cmd.Flags().GetBool("no-submit")
+	includeCode, _ := cmd.Flags().GetBool("include-code")
+	reason = strings.TrimSpace(reason)
+	if !falsePositive {
+		exitWithError(clierrors.ConflictingOptions.New("The verdict on the finding is required: --false-positive"))
+	}
+	if reason == "" {
+		exitWithError(clierrors.ConflictingOptions.New("The reason the finding is a false positive is required: --reason <text>"))
+	}
+
+	scanResults, err := results.LoadResults(filepath.Join(repositoryPath, config.AppConfig.PrivacyResultsPathSuffix))
+	if err != nil {
+		exitWithError(clierrors.ResultsRead.Errorf("Cannot read scan results (run 'privado scan' first): %s", err))
+	}
+	finding, ok := scanResults.FindFinding(args[0])
+	if !ok {
+		exitWithError(clierrors.InvalidArguments.Errorf("No finding with the id %s in the results (see 'privado results list')", args[0]))
+	}
+	exclusionsPath := getExclusionsPath(repositoryPath)
+	exclusions, err := feedback.LoadExclusions(exclusionsPath)
+	if err != nil {
+		exitWithError(clierrors.ExclusionsInvalid.Errorf("Cannot read exclusions: %s", err))
+	}
+
+	submitted := false
+	if !noSubmit {
+		submitted = submitFindingFeedback(feedback.New(scanResults, finding, feedback.VerdictFalsePositive, reason, Version, includeCode), share)
+	}
+
+	exclusions.Add(finding, reason, submitted)
+	if err := exclusions.Save(exclusionsPath); err != nil {
+		exitWithError(clierrors.ExclusionsInvalid.Errorf("Cannot save exclusions: %s", err))
+	}
+	relativePath, _ := filepath.Rel(repositoryPath, exclusionsPath)
+	exit(fmt.Sprintf("> Finding %s (%s) excluded as a false positive in: %s", finding.Id, finding.PolicyName, relativePath), false)
+}
+
+// Submits the feedback to Privado Cloud, with consent (asked, unless given
+// with --share). Returns whether it was submitted
+func submitFindingFeedback(f feedback.Feedback, share bool) bool {
+	token := getAPIToken()
+	if token == "" {
+		fmt.Println("> Feedback not submitted: not logged in to Privado Cloud (run 'privado auth login'), the finding is only excluded locally")
+		return false
+	}
+	if !share {
+		fmt.Printf("> Feedback: %s on %s (policy %s, data element %s", f.Verdict, f.FindingId, f.PolicyId, f.SourceId)
+		if f.SinkId != "" {
+			fmt.Printf(", recipient %s", f.SinkId)
+		}
+		fmt.Println("), reason:", f.Reason)
+		if f.Sample != "" {
+			fmt.Println("  Code:", strings.TrimSpace(f.Sample))
+		}
+		consent, err := utils.ShowConsentPrompt("Share the feedback with Privado to improve the rules?")
+		if err != nil || !consent {
+			fmt.Println("> Feedback not submitted, the finding is only excluded locally")
+			return false
+		}
+	}
+
+	if err := cloud.NewClient(token).SubmitFeedback(f, getOrganizationId()); err != nil {
+		fmt.Println("[WARN]: Could not submit the feedback, the finding is only excluded locally:", err)
+		return false
+	}
+	fmt.Println("> Feedback submitted to Privado Cloud, thank you")
+	return true
+}
+
+func init() {
+	feedbackCmd.Flags().Bool("false-positive", false, "Report the finding as a false positive (required)")
+	feedbackCmd.Flags().String("reason", "", "Why the finding is a false positive, e.g. \"the field is a hash, not an email\" (required)")
+	feedbackCmd.Flags().Bool("share", false, "If specified, the feedback is submitted without asking for consent (e.g. in scripts)")
+	feedbackCmd.Flags().Bool("no-submit", false, "If specified, the finding is only excluded locally and no feedback is submitted")
+	feedbackCmd.Flags().Bool("include-code", false, "If specified, the code sample of the finding is included in the submitted feedback")
+	feedbackCmd.MarkFlagsMutuallyExclusive("share", "no-submit")
+	rootCmd.AddCommand(feedbackCmd)
+}
@@ -0,0 +1,112 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/history"
+	"github.com/Privado-Inc/privado-cli/pkg/logs"
+	"github.com/Privado-Inc/privado-cli/pkg/schedule"
+	"github.com/Privado-Inc/privado-cli/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Manage the local scan history",
+	Long:  fmt.Sprintf("Manage the local scan history (%s) and scan logs (%s)", config.AppConfig.HistoryDirectory, config.AppConfig.LogsDirectory),
+}
+
+var historyPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove scans, scheduled runs and logs beyond the retention",
+	Long: fmt.Sprint(
+		"Remove scans of the history, scheduled runs (with their archived results) and scan logs beyond the retention ",
+		"(see 'privado config retention'). The retention is also applied after every scan",
+	),
+	Args: cobra.NoArgs,
+	Run:  pruneHistory,
+}
+
+// Returns the scans kept for each repository (0 for all) and the age after
+// which they are removed: the configured retention, else the defaults
+func getRetention(defaultMaxAge time.Duration) (int, time.Duration) {
+	retention := config.UserConfig.ConfigFile.Retention
+	if retention == nil {
+		return 0, defaultMaxAge
+	}
+	maxAge := defaultMaxAge
+	if retention.MaxAge != "" {
+		if duration, err := utils.ParseDuration(retention.MaxAge); err == nil {
+			maxAge = duration
+		} else {
+			fmt.Printf("[WARN]: Invalid retention max age '%s' in the configuration, using %s\n", retention.MaxAge, formatDays(defaultMaxAge))
+		}
+	}
+	return retention.KeepLast, maxAge
+}
+
+func formatDays(duration time.Duration) string {
+	if duration%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", duration/(24*time.Hour))
+	}
+	return duration.String()
+}
+
+// Removes scans and scheduled runs of the history beyond the retention.
+// Returns the number of removed scans and runs
+func applyHistoryRetention() (int, int, error) {
+	keepLast, maxAge := getRetention(config.AppConfig.HistoryRetention)
+	now := time.Now()
+
+	scans, err := history.Prune(config.AppConfig.HistoryDirectory, keepLast, maxAge, now)
+	if err != nil {
+		return scans, 0, err
+	}
+	runs, err := schedule.PruneRuns(config.AppConfig.HistoryDirectory, keepLast, maxAge, now)
+	return scans, runs, err
+}
+
+func pruneHistory(cmd *cobra.Command, args []string) {
+	scans, runs, err := applyHistoryRetention()
+	if err != nil {
+		exitWithError(clierrors.HistoryPrune.Errorf("Cannot prune the scan history: %s", err))
+	}
+
+	keepLast, maxAge := getRetention(config.AppConfig.LogRetention)
+	removedLogs, err := logs.Rotate(config.AppConfig.LogsDirectory, keepLast, maxAge, config.AppConfig.LogsMaxTotalSize)
+	if err != nil {
+		exitWithError(clierrors.HistoryPrune.Errorf("Cannot remove old scan logs: %s", err))
+	}
+
+	exit(fmt.Sprintf("> Removed %d scan(s), %d scheduled run(s) and %d log(s)\n%s", scans, runs, removedLogs, retentionConfigurationSummary()), false)
+}
+
+func init() {
+	historyCmd.AddCommand(historyPruneCmd)
+	rootCmd.AddCommand(historyCmd)
+}
@@ -0,0 +1,261 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/Privado-Inc/privado-cli/pkg/baseline"
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/feedback"
+	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+	"github.com/Privado-Inc/privado-cli/pkg/gitutils"
+	"github.com/spf13/cobra"
+)
+
+const (
+	preCommitHook = "pre-commit"
+	prePushHook   = "pre-push"
+)
+
+var hookCmd = &cobra.Command{
+	Use:   "hook",
+	Short: "Manage git hooks that scan changes before they are committed or pushed",
+}
+
+var hookInstallCmd = &cobra.Command{
+	Use:               "install [repository]",
+	Short:             "Install a git hook that blocks changes introducing new privacy findings",
+	Long:              "Install a git hook (default: pre-commit) in the repository (default: current directory) that blocks commits or pushes introducing findings at or above the --fail-on severity",
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeDirectoryArgument,
+	Run:               hookInstall,
+}
+
+var hookRunCmd = &cobra.Command{
+	Use:   "run <pre-commit|pre-push> [repository]",
+	Short: "Scan changes to be committed or pushed (invoked by the installed hook)",
+	Long:  "Scan changes to be committed (the staged files, pre-commit) or pushed (the commit, pre-push) in a temporary workspace, so the results of the repository are not overwritten, and block findings in the changed files that are not in the baseline (.privado/baseline.json, if any)",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := cobra.RangeArgs(1, 2)(cmd, args); err != nil {
+			return err
+		}
+		if args[0] != preCommitHook && args[0] != prePushHook {
+			return fmt.Errorf("invalid hook %q (allowed: %s, %s)", args[0], preCommitHook, prePushHook)
+		}
+		return nil
+	},
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return []string{preCommitHook, prePushHook}, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completeDirectoryArgument(cmd, args[1:], toComplete)
+	},
+	PreRun: func(cmd *cobra.Command, args []string) {
+		telemetryPreRun(nil)
+	},
+	Run: hookRun,
+	PostRun: func(cmd *cobra.Command, args []string) {
+		telemetryPostRun(nil)
+	},
+}
+
+func hookInstall(cmd *cobra.Command, args []string) {
+	repository := "."
+	if len(args) > 0 {
+		repository = args[0]
+	}
+	repositoryPath := fileutils.GetAbsolutePath(repository)
+	prePush, _ := cmd.Flags().GetBool("pre-push")
+	failOn, _ := cmd.Flags().GetString("fail-on")
+	force, _ := cmd.Flags().GetBool("force")
+	failOn = validateFailOn(failOn)
+
+	hook := preCommitHook
+	if prePush {
+		hook = prePushHook
+	}
+
+	hooksDirectory, err := gitutils.GetHooksDirectory(repositoryPath)
+	if err != nil {
+		exitWithError(clierrors.NotAGitRepository.Errorf("Cannot find git hooks directory. Is %s a git repository?", repositoryPath))
+	}
+	hookPath := filepath.Join(hooksDirectory, hook)
+
+	if exists, _ := fileutils.DoesFileExists(hookPath); exists {
+		if !force {
+			exitWithError(clierrors.GitHookExists.Errorf("A %s hook already exists (%s)\nUse '--force' to replace it (a backup is kept), or use the pre-commit framework entrypoint in .pre-commit-hooks.yaml", hook, hookPath))
+		}
+		if err := os.Rename(hookPath, hookPath+".bak"); err != nil {
+			exitWithError(clierrors.GitHookWrite.Errorf("Cannot backup existing hook: %s", err))
+		}
+		fmt.Println("> Existing hook moved to:", hookPath+".bak")
+	}
+
+	// prefer privado from PATH, so the hook survives updates and reinstalls
+	executable := "privado"
+	if _, err := exec.LookPath(executable); err != nil {
+		if binaryPath, err := fileutils.GetPathToCurrentBinary(); err == nil {
+			executable = binaryPath
+		}
+	}
+
+	script := fmt.Sprint(
+		"#!/bin/sh\n",
+		"# Installed by Privado CLI: blocks changes introducing new privacy findings\n",
+		"# Bypass with --no-verify\n",
+		fmt.Sprintf("exec \"%s\" hook run %s --fail-on %s \"$(git rev-parse --show-toplevel)\"\n", executable, hook, failOn),
+	)
+
+	if err := os.MkdirAll(hooksDirectory, os.ModePerm); err != nil {
+		exitWithError(clierrors.GitHookWrite.Errorf("Cannot create hooks directory: %s", err))
+	}
+	if err := os.WriteFile(hookPath, []byte(script), 0755); err != nil {
+		exitWithError(clierrors.GitHookWrite.Errorf("Cannot write hook: %s", err))
+	}
+
+	exit(fmt.Sprintf("> Installed %s hook: %s", hook, hookPath), false)
+}
+
+func hookRun(cmd *cobra.Command, args []string) {
+	hook := args[0]
+	repository := "."
+	if len(args) > 1 {
+		repository = args[1]
+	}
+	repositoryPath := fileutils.GetAbsolutePath(repository)
+	failOn, _ := cmd.Flags().GetString("fail-on")
+	validateGatedScanFlags(cmd)
+
+	summary := ciSummary{Repository: filepath.Base(repositoryPath), FailOn: validateFailOn(failOn)}
+
+	var changedFiles []string
+	var err error
+	if hook == preCommitHook {
+		changedFiles, err = gitutils.GetStagedFiles(repositoryPath)
+	} else {
+		// changes not yet on the upstream (or default) branch
+		baseRef := ""
+		for _, ref := range []string{"@{upstream}", "origin/HEAD"} {
+			if gitutils.RefExists(repositoryPath, ref) {
+				baseRef = ref
+				break
+			}
+		}
+		if baseRef == "" {
+			exit("> No upstream branch to compare against, skipping scan", false)
+		}
+		changedFiles, err = gitutils.GetChangedFiles(repositoryPath, baseRef)
+	}
+	if err != nil {
+		exitWithError(clierrors.ChangedFiles.Errorf("Cannot determine changed files: %s", err))
+	}
+	if len(changedFiles) == 0 {
+		exit("> No changes to scan", false)
+	}
+	changedFilesCount := len(changedFiles)
+	summary.ChangedFiles = &changedFilesCount
+	if exists, _ := fileutils.DoesFileExists(getBaselinePath(repositoryPath)); exists {
+		summary.Baseline = getBaselinePath(repositoryPath)
+	}
+	fmt.Printf("> Scanning for new findings in %d changed file(s)\n", changedFilesCount)
+
+	// fast, non-interactive scan of the changes to be committed (or
+	// pushed), without the results of the repository being overwritten
+	workspace := exportHookSource(repositoryPath, hook)
+	_ = cmd.Flags().Set("overwrite", "true")
+	_ = cmd.Flags().Set("skip-update-check", "true")
+	_ = cmd.Flags().Set("skip-dependency-download", "true")
+	_ = cmd.Flags().Set("skip-hooks", "true")
+	_ = cmd.Flags().Set("no-sync", "true")
+	_ = cmd.Flags().Set("open", openNothing)
+
+	gatedScan(cmd, workspace, changedFiles, &summary)
+
+	if !summary.Passed {
+		exitWithOutcome(fmt.Sprintf("%s\n> Blocked by Privado (%s hook). Fix the findings or bypass with --no-verify", formatCISummary(summary, "text"), hook), config.OutcomePolicyViolation)
+	}
+	exitWithOutcome(formatCISummary(summary, "text"), summary.getOutcome())
+}
+
+// Writes the source code the hook scans to a temporary workspace (removed
+// on exit): the staged files (pre-commit), or the commit to push (pre-push),
+// with the privado configuration of the repository (baseline, exclusions)
+func exportHookSource(repositoryPath, hook string) string {
+	workspace, err := os.MkdirTemp("", "privado-hook-")
+	if err != nil {
+		exitWithError(clierrors.WorkspaceCreation.Errorf("Cannot create workspace for the changes to scan: %s", err))
+	}
+	registerExitHook(func(isError bool) {
+		_ = os.RemoveAll(workspace)
+	})
+
+	if hook == preCommitHook {
+		err = gitutils.ExportIndex(repositoryPath, workspace)
+	} else {
+		err = gitutils.ExportCommit(repositoryPath, "HEAD", workspace)
+	}
+	if err != nil {
+		exitWithError(clierrors.WorkspaceCopy.Errorf("Cannot write the changes to scan to the workspace: %s", err))
+	}
+
+	privadoDirectory := filepath.Dir(config.AppConfig.PrivacyResultsPathSuffix)
+	for _, name := range []string{config.ProjectConfigurationFileName, baseline.FileName, feedback.ExclusionsFileName} {
+		source := filepath.Join(repositoryPath, privadoDirectory, name)
+		destination := filepath.Join(workspace, privadoDirectory, name)
+		if exists, _ := fileutils.DoesFileExists(source); !exists {
+			continue
+		}
+		if exists, _ := fileutils.DoesFileExists(destination); exists {
+			continue
+		}
+		err := os.MkdirAll(filepath.Dir(destination), os.ModePerm)
+		if err == nil {
+			err = fileutils.CopyFile(source, destination)
+		}
+		if err != nil {
+			fmt.Printf("[WARN]: Could not copy %s to the workspace: %s\n", name, err)
+		}
+	}
+	return workspace
+}
+
+func init() {
+	hookInstallCmd.Flags().Bool("pre-commit", true, "Install a pre-commit hook (default)")
+	hookInstallCmd.Flags().Bool("pre-push", false, "Install a pre-push hook instead of a pre-commit hook")
+	hookInstallCmd.Flags().String("fail-on", "high", "Block changes introducing findings at or above the severity (high, medium, low, unknown)")
+	hookInstallCmd.Flags().Bool("force", false, "Replace an existing hook (a backup is kept)")
+
+	defineScanFlags(hookRunCmd)
+	hookRunCmd.Flags().String("fail-on", "high", "Block changes introducing findings at or above the severity (high, medium, low, unknown, none)")
+	_ = hookInstallCmd.RegisterFlagCompletionFunc("fail-on", completeSeverities(false))
+	_ = hookRunCmd.RegisterFlagCompletionFunc("fail-on", completeSeverities(true))
+
+	hookCmd.AddCommand(hookInstallCmd)
+	hookCmd.AddCommand(hookRunCmd)
+	rootCmd.AddCommand(hookCmd)
+}
@@ -0,0 +1,136 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+	"github.com/Privado-Inc/privado-cli/pkg/license"
+	"github.com/spf13/cobra"
+)
+
+var licenseCmd = &cobra.Command{
+	Use:   "license",
+	Short: "Manage the enterprise license of Privado CLI",
+}
+
+var licenseActivateCmd = &cobra.Command{
+	Use:   "activate <key-file>",
+	Short: "Activate an enterprise license from a license key file",
+	Long:  "Activate an enterprise license from a license key file. The license is verified offline and no request is made to Privado servers",
+	Args:  cobra.ExactArgs(1),
+	Run:   licenseActivate,
+}
+
+var licenseStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the activated enterprise license",
+	Args:  cobra.NoArgs,
+	Run:   licenseStatus,
+}
+
+var licenseDeactivateCmd = &cobra.Command{
+	Use:   "deactivate",
+	Short: "Remove the activated enterprise license",
+	Args:  cobra.NoArgs,
+	Run:   licenseDeactivate,
+}
+
+// Returns the activated license and its raw key if valid, else nil
+func getActiveLicense() (*license.License, []byte) {
+	activeLicense, key, err := license.Load(config.AppConfig.LicensePath)
+	if err != nil {
+		if err != license.ErrNoLicense {
+			fmt.Println("[WARN]: Ignoring enterprise license:", err)
+		}
+		return nil, nil
+	}
+	return activeLicense, key
+}
+
+// Returns the license key to be passed on to the engine (base64), empty if none
+func getEncodedLicenseKey(key []byte) string {
+	if len(key) == 0 {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+func printLicense(l *license.License) {
+	fmt.Println("> License:", l.Id)
+	fmt.Println("> Organization:", l.Organization)
+	fmt.Println("> Features:", strings.Join(l.Features, ", "))
+	if l.ExpiresAt.IsZero() {
+		fmt.Println("> Expires: never")
+	} else {
+		fmt.Println("> Expires:", l.ExpiresAt.Local().Format("2006-01-02"))
+	}
+}
+
+func licenseActivate(cmd *cobra.Command, args []string) {
+	keyFilePath := fileutils.GetAbsolutePath(args[0])
+	if exists, _ := fileutils.DoesFileExists(keyFilePath); !exists {
+		exitWithError(clierrors.PathNotFound.Errorf("Could not find license key file: %s", keyFilePath))
+	}
+
+	activatedLicense, err := license.Activate(keyFilePath, config.AppConfig.LicensePath)
+	if err != nil {
+		exitWithError(clierrors.LicenseActivation.Errorf("Cannot activate license: %s", err))
+	}
+
+	printLicense(activatedLicense)
+	exit("> License activated", false)
+}
+
+func licenseStatus(cmd *cobra.Command, args []string) {
+	activeLicense, _, err := license.Load(config.AppConfig.LicensePath)
+	if err == license.ErrNoLicense {
+		exit("> No license activated", false)
+	}
+	if activeLicense != nil {
+		printLicense(activeLicense)
+	}
+	if err != nil {
+		exitWithError(clierrors.LicenseInvalid.Errorf("License is not valid: %s", err))
+	}
+	exit("> License is valid", false)
+}
+
+func licenseDeactivate(cmd *cobra.Command, args []string) {
+	if err := license.Deactivate(config.AppConfig.LicensePath); err != nil {
+		exitWithError(clierrors.LicenseRemoval.Errorf("Cannot remove license: %s", err))
+	}
+	exit("> License deactivated", false)
+}
+
+func init() {
+	licenseCmd.AddCommand(licenseActivateCmd)
+	licenseCmd.AddCommand(licenseStatusCmd)
+	licenseCmd.AddCommand(licenseDeactivateCmd)
+	rootCmd.AddCommand(licenseCmd)
+}
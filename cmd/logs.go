@@ -0,0 +1,161 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+	"github.com/Privado-Inc/privado-cli/pkg/logs"
+	"github.com/spf13/cobra"
+)
+
+var logsCmd = &cobra.Command{
+	Use:   "logs [scan-id]",
+	Short: "List scan logs, or show the log of a scan",
+	Long: fmt.Sprint(
+		"List logs of previous scans, or show the log of a scan ('latest' for the last scan). ",
+		"The output of each scan (including the engine output) is written to a log file in ",
+		config.AppConfig.LogsDirectory,
+	),
+	Args: cobra.MaximumNArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		scanLogs, _ := logs.List(config.AppConfig.LogsDirectory)
+		ids := []string{"latest"}
+		for _, log := range scanLogs {
+			ids = append(ids, log.Id)
+		}
+		return ids, cobra.ShellCompDirectiveNoFileComp
+	},
+	Run: showLogs,
+}
+
+// Writes the output of the scan to a new log file (see 'privado logs'),
+// after removing logs beyond the retention
+func startScanLog(repositoryPath string) {
+	directory := config.AppConfig.LogsDirectory
+	keepLast, maxAge := getRetention(config.AppConfig.LogRetention)
+	if _, err := logs.Rotate(directory, keepLast, maxAge, config.AppConfig.LogsMaxTotalSize); err != nil {
+		fmt.Println("[WARN]: Could not remove old scan logs:", err)
+	}
+
+	id := logs.NewId(directory, filepath.Base(repositoryPath))
+	header := fmt.Sprintf("# privado %s, %s\n# %s\n", Version, time.Now().Format(time.RFC3339), strings.Join(os.Args, " "))
+	if _, err := logs.Start(directory, id, header, config.AppConfig.LogMaxFileSize); err != nil {
+		fmt.Println("[WARN]: Could not create scan log:", err)
+		return
+	}
+	fmt.Printf("> Scan log: %s (view with 'privado logs %s')\n", id, id)
+}
+
+func showLogs(cmd *cobra.Command, args []string) {
+	follow, _ := cmd.Flags().GetBool("follow")
+	printPath, _ := cmd.Flags().GetBool("path")
+	directory := config.AppConfig.LogsDirectory
+
+	if len(args) == 0 {
+		listLogs(directory)
+		return
+	}
+
+	log, err := findLog(directory, args[0])
+	if err != nil {
+		exitWithError(clierrors.LogNotFound.Errorf("Cannot find log '%s': %s (run 'privado logs' to list logs)", args[0], err))
+	}
+	if printPath {
+		fmt.Println(log.Path)
+		return
+	}
+
+	if follow {
+		stop := make(chan struct{})
+		interrupt := make(chan os.Signal, 1)
+		signal.Notify(interrupt, os.Interrupt)
+		go func() {
+			<-interrupt
+			close(stop)
+		}()
+		if err := logs.Follow(log.Path, os.Stdout, stop); err != nil {
+			exitWithError(clierrors.LogNotFound.Errorf("Cannot read log: %s", err))
+		}
+		return
+	}
+
+	file, err := os.Open(log.Path)
+	if err != nil {
+		exitWithError(clierrors.LogNotFound.Errorf("Cannot read log: %s", err))
+	}
+	defer file.Close()
+	_, _ = io.Copy(os.Stdout, file)
+}
+
+func findLog(directory, id string) (logs.Log, error) {
+	if id != "latest" {
+		return logs.Find(directory, id)
+	}
+	scanLogs, err := logs.List(directory)
+	if err != nil {
+		return logs.Log{}, err
+	}
+	if len(scanLogs) == 0 {
+		return logs.Log{}, errors.New("no scan logs")
+	}
+	return scanLogs[0], nil
+}
+
+func listLogs(directory string) {
+	scanLogs, err := logs.List(directory)
+	if err != nil {
+		exitWithError(clierrors.LogNotFound.Errorf("Cannot list logs: %s", err))
+	}
+	if len(scanLogs) == 0 {
+		exit("> No scan logs yet. Logs are written by 'privado scan'", false)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tLAST WRITTEN\tSIZE")
+	for _, log := range scanLogs {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", log.Id, log.ModTime.Local().Format("2006-01-02 15:04"), fileutils.FormatSize(log.Size))
+	}
+	w.Flush()
+}
+
+func init() {
+	logsCmd.Flags().BoolP("follow", "f", false, "Keep showing output as it is written to the log (e.g. of a running scan)")
+	logsCmd.Flags().Bool("path", false, "Print the path of the log file instead of its content")
+
+	rootCmd.AddCommand(logsCmd)
+}
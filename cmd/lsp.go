@@ -0,0 +1,59 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/lsp"
+	"github.com/spf13/cobra"
+)
+
+var lspCmd = &cobra.Command{
+	Use:   "lsp",
+	Short: "Run a language server publishing findings as diagnostics in editors",
+	Long: fmt.Sprint(
+		"Run a language server (Language Server Protocol over stdio) publishing findings of the workspace as diagnostics, for editor extensions. ",
+		"Findings of the last scan are published on startup; scans are run with the 'privado.scan' command, ",
+		"or on startup and save with the 'scanOnStartup' and 'scanOnSave' initialization options ('scanArgs' are passed to the scan)",
+	),
+	Args: cobra.ExactArgs(0),
+	Run:  runLanguageServer,
+}
+
+func runLanguageServer(cmd *cobra.Command, args []string) {
+	// stdout carries the protocol, anything else printed goes to stderr
+	protocolOutput := os.Stdout
+	os.Stdout = os.Stderr
+
+	if err := lsp.Serve(os.Stdin, protocolOutput, runScanProcess); err != nil {
+		exitWithError(clierrors.ServerStopped.Errorf("Language server stopped: %s", err))
+	}
+	exit("", false)
+}
+
+func init() {
+	rootCmd.AddCommand(lspCmd)
+}
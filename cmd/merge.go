@@ -0,0 +1,169 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+	"github.com/Privado-Inc/privado-cli/pkg/shard"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var mergeCmd = &cobra.Command{
+	Use:               "merge <repository> [shard-results...]",
+	Short:             "Combine results of sharded scans (scan --shard) into the results of the repository",
+	Long:              "Combine results of sharded scans (scan --shard) into the results of the repository. If no shard results are specified, all results in <repository>/.privado/shards are combined",
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeRepositoryAndResults,
+	Run:               merge,
+}
+
+// returns the directory shard results of the repository are saved to
+func getShardsDirectory(repositoryPath string) string {
+	return filepath.Join(repositoryPath, filepath.Dir(config.AppConfig.PrivacyResultsPathSuffix), "shards")
+}
+
+// Scans each module assigned to the shard and combines their results
+// into the shard results
+func shardScan(cmd *cobra.Command, repository, shardFlag string) {
+	repositoryPath := fileutils.GetAbsolutePath(repository)
+	currentShard, err := shard.Parse(shardFlag)
+	if err != nil {
+		exitWithError(clierrors.InvalidFlagValue.Wrap(err))
+	}
+
+	modules, err := shard.DiscoverModules(repositoryPath)
+	if err != nil {
+		exitWithError(clierrors.ShardModules.Errorf("Cannot discover modules: %s", err))
+	}
+	assigned := currentShard.Assign(modules)
+	fmt.Printf("> Shard %s: scanning %d of %d module(s)\n", currentShard, len(assigned), len(modules))
+	printShardDistribution(currentShard, modules)
+
+	shardResults := map[string]interface{}{}
+	if parallel, _ := cmd.Flags().GetInt("parallel"); parallel > 1 && len(assigned) > 1 {
+		shardResults = scanModulesInParallel(cmd, repositoryPath, assigned, modules, parallel)
+	} else {
+		// module scans run without prompts and are not sharded again
+		_ = cmd.Flags().Set("shard", "")
+		_ = cmd.Flags().Set("overwrite", "true")
+		_ = cmd.Flags().Set("parallel", "1")
+		excludePaths, _ := cmd.Flags().GetStringArray("exclude-path")
+		excludePathFlag, _ := cmd.Flags().Lookup("exclude-path").Value.(pflag.SliceValue)
+
+		for i, module := range assigned {
+			modulePath := filepath.Join(repositoryPath, filepath.FromSlash(module))
+			fmt.Printf("\n> [%d/%d] Scanning module: %s\n", i+1, len(assigned), module)
+
+			// modules nested in the module are scanned separately
+			if excludePathFlag != nil {
+				_ = excludePathFlag.Replace(getModuleExcludedPaths(module, modules, excludePaths))
+			}
+			scan(cmd, []string{modulePath})
+
+			moduleResults, err := results.LoadRawResults(filepath.Join(modulePath, config.AppConfig.PrivacyResultsPathSuffix))
+			if err != nil {
+				exitWithError(clierrors.ShardResultsRead.Errorf("Cannot read results of module %s: %s", module, err))
+			}
+			if module != "." {
+				results.PrefixFileNames(moduleResults, config.AppConfig.Container.SourceCodeVolumeDir, module)
+			}
+			results.MergeRawResults(shardResults, moduleResults)
+		}
+	}
+	shardResults["repoName"] = filepath.Base(repositoryPath)
+	shardResults["shard"] = currentShard.String()
+
+	shardsDirectory := getShardsDirectory(repositoryPath)
+	if err := os.MkdirAll(shardsDirectory, os.ModePerm); err != nil {
+		exitWithError(clierrors.ShardResultsWrite.Errorf("Cannot create shards directory: %s", err))
+	}
+	shardResultsPath := filepath.Join(shardsDirectory, currentShard.ResultsFileName())
+	if err := results.WriteRawResults(shardResultsPath, shardResults); err != nil {
+		exitWithError(clierrors.ShardResultsWrite.Errorf("Cannot write shard results: %s", err))
+	}
+
+	fmt.Println("\n> Shard results saved to:", shardResultsPath)
+}
+
+// Shows the number of modules assigned to each shard, and warns when the
+// shards are imbalanced (the slowest shard determines the scan time)
+func printShardDistribution(currentShard shard.Shard, modules []string) {
+	distribution := currentShard.Distribution(modules)
+	counts := []string{}
+	for i, count := range distribution {
+		counts = append(counts, fmt.Sprintf("%d/%d: %d", i+1, currentShard.Total, count))
+	}
+	fmt.Printf("> Modules per shard: %s\n", strings.Join(counts, ", "))
+	if shard.IsImbalanced(distribution) {
+		fmt.Println("[WARN]: Modules are distributed unevenly across the shards, some workers scan (much) more than others; consider fewer shards")
+	}
+}
+
+func merge(cmd *cobra.Command, args []string) {
+	repositoryPath := fileutils.GetAbsolutePath(args[0])
+
+	shardResultsPaths := []string{}
+	for _, path := range args[1:] {
+		shardResultsPaths = append(shardResultsPaths, fileutils.GetAbsolutePath(path))
+	}
+	if len(shardResultsPaths) == 0 {
+		shardResultsPaths, _ = filepath.Glob(filepath.Join(getShardsDirectory(repositoryPath), "shard-*.json"))
+	}
+	if len(shardResultsPaths) == 0 {
+		exitWithError(clierrors.NoShardResults.Errorf("No shard results found in %s", getShardsDirectory(repositoryPath)))
+	}
+
+	merged := map[string]interface{}{}
+	for _, path := range shardResultsPaths {
+		fmt.Println("> Merging:", path)
+		shardResults, err := results.LoadRawResults(path)
+		if err != nil {
+			exitWithError(clierrors.ShardResultsRead.Errorf("Cannot read shard results: %s", err))
+		}
+		delete(shardResults, "shard")
+		results.MergeRawResults(merged, shardResults)
+	}
+
+	resultsPath := filepath.Join(repositoryPath, config.AppConfig.PrivacyResultsPathSuffix)
+	if err := os.MkdirAll(filepath.Dir(resultsPath), os.ModePerm); err != nil {
+		exitWithError(clierrors.ResultsWrite.Errorf("Cannot create results directory: %s", err))
+	}
+	if err := results.WriteRawResults(resultsPath, merged); err != nil {
+		exitWithError(clierrors.ResultsWrite.Errorf("Cannot write results: %s", err))
+	}
+
+	exit(fmt.Sprintf("> Merged results of %d shard(s) saved to: %s", len(shardResultsPaths), resultsPath), false)
+}
+
+func init() {
+	rootCmd.AddCommand(mergeCmd)
+}
@@ -0,0 +1,91 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/notify"
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+)
+
+// Returns the configured notifiers that are notified of the notification:
+// of failed gates, and of completed scans when configured to always notify
+func getNotifiers(notification notify.Notification) []notify.Notifier {
+	notifications := config.UserConfig.ConfigFile.Notifications
+	if notifications == nil {
+		notifications = &config.Notifications{}
+	}
+
+	notifiers := []notify.Notifier{}
+	if teams := notifications.Teams; teams != nil || os.Getenv("PRIVADO_TEAMS_WEBHOOK_URL") != "" {
+		if teams == nil {
+			teams = &config.TeamsNotifications{}
+		}
+		webhookURL := valueOrEnvironment(teams.WebhookURL, "PRIVADO_TEAMS_WEBHOOK_URL")
+		if webhookURL != "" && (notification.Failed || teams.On == config.NotifyOnAlways) {
+			notifiers = append(notifiers, &notify.Teams{WebhookURL: webhookURL})
+		}
+	}
+	return notifiers
+}
+
+// Sends the notification to the configured notifiers; a failed notification
+// does not fail the command
+func sendNotifications(notification notify.Notification) {
+	for _, notifier := range getNotifiers(notification) {
+		if err := notifier.Notify(notification); err != nil {
+			fmt.Printf("[WARN]: Could not notify %s: %s\n", notifier.Name(), err)
+		} else {
+			fmt.Printf("> Notified %s\n", notifier.Name())
+		}
+	}
+}
+
+// Returns the notification of the scan of the repository, from its results
+func newScanNotification(repositoryPath string, findings []results.Finding) notify.Notification {
+	notification := notify.Notification{
+		Repository:         filepath.Base(repositoryPath),
+		Findings:           len(findings),
+		FindingsBySeverity: results.CountFindingsBySeverity(findings),
+	}
+	if scanResults, err := results.LoadResults(filepath.Join(repositoryPath, config.AppConfig.PrivacyResultsPathSuffix)); err == nil {
+		if scanResults.RepoName != "" {
+			notification.Repository = scanResults.RepoName
+		}
+		notification.Branch = scanResults.GitMetadata.BranchName
+		notification.Commit = scanResults.GitMetadata.CommitId
+		if scanResults.ScanMetadata != nil {
+			notification.ReportURL = scanResults.ScanMetadata.BuildURL
+		}
+		if findings == nil {
+			findings = scanResults.Findings()
+			notification.Findings = len(findings)
+			notification.FindingsBySeverity = results.CountFindingsBySeverity(findings)
+		}
+	}
+	return notification
+}
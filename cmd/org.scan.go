@@ -0,0 +1,262 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+	"github.com/Privado-Inc/privado-cli/pkg/gitutils"
+	"github.com/Privado-Inc/privado-cli/pkg/orgscan"
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+	"github.com/spf13/cobra"
+)
+
+var orgScanCmd = &cobra.Command{
+	Use:   "scan",
+	Short: "Clone and scan repositories of an organization, and generate an aggregated report",
+	Long:  "Enumerate repositories of an organization (GitHub organization, GitLab group or Bitbucket workspace), clone and scan each one, and generate an aggregated cross-repository report (data elements and findings per repository, and the data lineage between them: calls of a repository to the API endpoints of another one)",
+	Args:  cobra.ExactArgs(0),
+	PreRun: func(cmd *cobra.Command, args []string) {
+		telemetryPreRun(nil)
+	},
+	Run: orgScan,
+	PostRun: func(cmd *cobra.Command, args []string) {
+		telemetryPostRun(nil)
+	},
+}
+
+func orgScan(cmd *cobra.Command, args []string) {
+	include, _ := cmd.Flags().GetStringSlice("include")
+	exclude, _ := cmd.Flags().GetStringSlice("exclude")
+	languages, _ := cmd.Flags().GetStringSlice("language")
+	includeArchived, _ := cmd.Flags().GetBool("include-archived")
+	includeForks, _ := cmd.Flags().GetBool("include-forks")
+	limit, _ := cmd.Flags().GetInt("limit")
+	parallelism, _ := cmd.Flags().GetInt("parallelism")
+	workdir, _ := cmd.Flags().GetString("workdir")
+	output, _ := cmd.Flags().GetString("output")
+	keepClones, _ := cmd.Flags().GetBool("keep-clones")
+
+	provider := getRepoProvider(cmd)
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	fmt.Println("> Listing repositories of:", provider.Organization())
+	repositories, err := provider.ListRepositories()
+	if err != nil {
+		exitWithError(clierrors.OrgRepositories.Errorf("Cannot list repositories: %s", err))
+	}
+	repositories = orgscan.Filter{
+		Include:         include,
+		Exclude:         exclude,
+		Languages:       languages,
+		IncludeArchived: includeArchived,
+		IncludeForks:    includeForks,
+		Limit:           limit,
+	}.Apply(repositories)
+	if len(repositories) == 0 {
+		exitWithError(clierrors.OrgRepositories.New("No repositories to scan (after filters)"))
+	}
+
+	if workdir == "" {
+		workdir = filepath.Join(config.AppConfig.CacheDirectory, "org-scan", strings.ReplaceAll(provider.Organization(), "/", "_"))
+	}
+	workdir = fileutils.GetAbsolutePath(workdir)
+	if err := os.MkdirAll(filepath.Join(workdir, "logs"), os.ModePerm); err != nil {
+		exitWithError(clierrors.OrgReportWrite.Errorf("Cannot create working directory: %s", err))
+	}
+
+	authorizationHeader := provider.CloneAuthorizationHeader()
+
+	fmt.Printf("> Scanning %d repositories (parallelism: %d)\n", len(repositories), parallelism)
+
+	// repositories are scanned in separate processes, so that a
+	// failure in one of the scans does not affect the others
+	reports := make([]orgscan.RepositoryReport, len(repositories))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < parallelism; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				reports[i] = scanOrgRepository(repositories[i], workdir, authorizationHeader, keepClones)
+				fmt.Printf("> [%s] %s (%s)\n", reports[i].Status, repositories[i].FullName, reports[i].Duration)
+			}
+		}()
+	}
+	for i := range repositories {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	report := orgscan.NewReport(provider.Organization(), reports)
+	if output == "" {
+		output = filepath.Join(workdir, "org-report.json")
+	}
+	output = fileutils.GetAbsolutePath(output)
+	if err := report.Write(output); err != nil {
+		exitWithError(clierrors.OrgReportWrite.Errorf("Cannot write report: %s", err))
+	}
+
+	fmt.Println()
+	fmt.Printf("> Scanned: %d, Failed: %d\n", report.Scanned, report.Failed)
+	fmt.Printf("> Findings: high: %d, medium: %d, low: %d, unknown: %d\n",
+		report.FindingsBySeverity[results.SeverityHigh], report.FindingsBySeverity[results.SeverityMedium],
+		report.FindingsBySeverity[results.SeverityLow], report.FindingsBySeverity[results.SeverityUnknown])
+	fmt.Printf("> Data elements found across repositories: %d\n", len(report.DataElements))
+	printLineageSummary(report.Lineage)
+	exit(fmt.Sprintf("> Report saved to: %s", output), report.Failed > 0 && report.Scanned == 0)
+}
+
+// Returns the provider of the specified organization, group or workspace
+// tokens default to the environment variables of the provider
+func getRepoProvider(cmd *cobra.Command) orgscan.RepoProvider {
+	gitHubOrg, _ := cmd.Flags().GetString("github-org")
+	gitHubToken, _ := cmd.Flags().GetString("github-token")
+	gitLabGroup, _ := cmd.Flags().GetString("gitlab-group")
+	gitLabHost, _ := cmd.Flags().GetString("gitlab-host")
+	gitLabToken, _ := cmd.Flags().GetString("gitlab-token")
+	bitbucketWorkspace, _ := cmd.Flags().GetString("bitbucket-workspace")
+	bitbucketUsername, _ := cmd.Flags().GetString("bitbucket-username")
+	bitbucketToken, _ := cmd.Flags().GetString("bitbucket-token")
+
+	switch {
+	case gitHubOrg != "":
+		if gitHubToken == "" {
+			gitHubToken = os.Getenv("GITHUB_TOKEN")
+		}
+		return &orgscan.GitHubProvider{Org: gitHubOrg, Token: gitHubToken}
+	case gitLabGroup != "":
+		if gitLabToken == "" {
+			gitLabToken = os.Getenv("GITLAB_TOKEN")
+		}
+		return &orgscan.GitLabProvider{Host: gitLabHost, Group: gitLabGroup, Token: gitLabToken}
+	case bitbucketWorkspace != "":
+		if bitbucketUsername == "" {
+			bitbucketUsername = os.Getenv("BITBUCKET_USERNAME")
+		}
+		if bitbucketToken == "" {
+			bitbucketToken = os.Getenv("BITBUCKET_TOKEN")
+		}
+		return &orgscan.BitbucketProvider{Workspace: bitbucketWorkspace, Username: bitbucketUsername, Token: bitbucketToken}
+	}
+
+	exitWithError(clierrors.ConflictingOptions.New("An organization is required: --github-org, --gitlab-group or --bitbucket-workspace"))
+	return nil
+}
+
+// Prints the data flowing between the repositories of the aggregated report
+func printLineageSummary(lineage []orgscan.LineageEdge) {
+	if len(lineage) == 0 {
+		return
+	}
+	fmt.Printf("> Cross-service data flows: %d\n", len(lineage))
+	for _, edge := range lineage {
+		dataElements := "no data elements"
+		if len(edge.DataElements) > 0 {
+			dataElements = strings.Join(edge.DataElements, ", ")
+		}
+		fmt.Printf("  %s -> %s %s (%s)\n", edge.From, edge.To, edge.Endpoint, dataElements)
+	}
+}
+
+// Clones and scans the repository, logs are written to workdir/logs
+func scanOrgRepository(repository orgscan.Repository, workdir, authorizationHeader string, keepClones bool) orgscan.RepositoryReport {
+	startTime := time.Now()
+	report := orgscan.RepositoryReport{Repository: repository, Status: orgscan.StatusFailed}
+	report.LogPath = filepath.Join(workdir, "logs", strings.ReplaceAll(repository.FullName, "/", "_")+".log")
+	cloneDirectory := filepath.Join(workdir, "repositories", strings.ReplaceAll(repository.FullName, "/", "_"))
+	defer func() {
+		report.Duration = time.Since(startTime).Round(time.Second).String()
+	}()
+
+	// results are kept even if clones are not
+	resultsDirectory := filepath.Join(workdir, "results")
+	resultsPath := filepath.Join(resultsDirectory, strings.ReplaceAll(repository.FullName, "/", "_")+".json")
+
+	os.RemoveAll(cloneDirectory)
+	if err := gitutils.Clone(repository.CloneURL, cloneDirectory, authorizationHeader); err != nil {
+		report.Error = fmt.Sprintf("clone failed: %s", err)
+		return report
+	}
+	if !keepClones {
+		defer os.RemoveAll(cloneDirectory)
+	}
+
+	// hooks of the cloned repositories are not trusted to run
+	if err := runScanProcess(cloneDirectory, report.LogPath, []string{"--skip-hooks"}); err != nil {
+		report.Error = fmt.Sprintf("scan failed: %s (see %s)", err, report.LogPath)
+		return report
+	}
+
+	if err := os.MkdirAll(resultsDirectory, os.ModePerm); err != nil {
+		report.Error = err.Error()
+		return report
+	}
+	if err := fileutils.CopyFile(filepath.Join(cloneDirectory, config.AppConfig.PrivacyResultsPathSuffix), resultsPath); err != nil {
+		report.Error = fmt.Sprintf("cannot read results: %s", err)
+		return report
+	}
+	if err := report.LoadResults(resultsPath); err != nil {
+		report.Error = fmt.Sprintf("cannot read results: %s", err)
+		return report
+	}
+
+	report.Status = orgscan.StatusScanned
+	return report
+}
+
+func init() {
+	orgScanCmd.Flags().String("github-org", "", "GitHub organization to scan the repositories of")
+	orgScanCmd.Flags().String("github-token", "", "GitHub token to list and clone (private) repositories (default: $GITHUB_TOKEN)")
+	orgScanCmd.Flags().String("gitlab-group", "", "GitLab group (full path) to scan the projects of, including subgroups")
+	orgScanCmd.Flags().String("gitlab-host", orgscan.GitLabDefaultHost, "URL of the GitLab instance")
+	orgScanCmd.Flags().String("gitlab-token", "", "GitLab token to list and clone (private) projects (default: $GITLAB_TOKEN)")
+	orgScanCmd.Flags().String("bitbucket-workspace", "", "Bitbucket workspace to scan the repositories of")
+	orgScanCmd.Flags().String("bitbucket-username", "", "Bitbucket username, when using an app password as token (default: $BITBUCKET_USERNAME)")
+	orgScanCmd.Flags().String("bitbucket-token", "", "Bitbucket app password (with --bitbucket-username) or access token (default: $BITBUCKET_TOKEN)")
+	orgScanCmd.MarkFlagsMutuallyExclusive("github-org", "gitlab-group", "bitbucket-workspace")
+	orgScanCmd.Flags().StringSlice("include", []string{}, "Only scan repositories with names matching the glob patterns")
+	orgScanCmd.Flags().StringSlice("exclude", []string{}, "Skip repositories with names matching the glob patterns")
+	orgScanCmd.Flags().StringSlice("language", []string{}, "Only scan repositories with the primary language(s)")
+	orgScanCmd.Flags().Bool("include-archived", false, "Also scan archived repositories")
+	orgScanCmd.Flags().Bool("include-forks", false, "Also scan forked repositories")
+	orgScanCmd.Flags().Int("limit", 0, "Maximum number of repositories to scan")
+	orgScanCmd.Flags().IntP("parallelism", "p", 2, "Number of repositories scanned in parallel")
+	orgScanCmd.Flags().String("workdir", "", "Directory for clones, logs and results (default: <privado-cache>/org-scan/<org>)")
+	orgScanCmd.Flags().StringP("output", "o", "", "Path of the aggregated report (default: <workdir>/org-report.json)")
+	orgScanCmd.Flags().Bool("keep-clones", false, "Keep cloned repositories after they are scanned")
+
+	orgCmd.AddCommand(orgScanCmd)
+}
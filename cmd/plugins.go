@@ -0,0 +1,141 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"text/tabwriter"
+
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/plugins"
+	"github.com/spf13/cobra"
+)
+
+var pluginsCmd = &cobra.Command{
+	Use:   "plugins",
+	Short: "Manage plugins: executables named privado-<name> on PATH, run as 'privado <name>'",
+}
+
+var pluginsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List plugins found on PATH",
+	Args:  cobra.ExactArgs(0),
+	Run:   pluginsList,
+}
+
+// Returns whether the name is used by a built-in command
+func isBuiltInCommand(name string) bool {
+	for _, command := range rootCmd.Commands() {
+		if command.Name() == name || command.HasAlias(name) {
+			return true
+		}
+	}
+	// added by cobra on execution
+	return name == "help" || name == "completion"
+}
+
+// Adds the plugins on PATH as commands. Built-in commands
+// take precedence over plugins with the same name
+func registerPlugins() {
+	for _, plugin := range plugins.Discover(os.Getenv("PATH")) {
+		if isBuiltInCommand(plugin.Name) {
+			continue
+		}
+		plugin := plugin
+		rootCmd.AddCommand(&cobra.Command{
+			Use:                plugin.Name,
+			Short:              fmt.Sprintf("Plugin (%s)", plugin.Path),
+			DisableFlagParsing: true,
+			Run: func(cmd *cobra.Command, args []string) {
+				runPlugin(plugin, args)
+			},
+		})
+	}
+}
+
+// Returns the environment passed to plugins, with the resolved configuration
+// without secrets (plugins that need them read the configuration file)
+func getPluginEnvironment(plugin plugins.Plugin) []string {
+	executable, _ := os.Executable()
+	userConfiguration, _ := json.Marshal(config.GetSanitizedUserConfiguration())
+
+	return append(os.Environ(),
+		"PRIVADO_PLUGIN_NAME="+plugin.Name,
+		"PRIVADO_CLI_PATH="+executable,
+		"PRIVADO_CLI_VERSION="+Version,
+		"PRIVADO_CONFIG_DIRECTORY="+config.AppConfig.ConfigurationDirectory,
+		"PRIVADO_CONFIG_FILE="+config.AppConfig.UserConfigurationFilePath,
+		"PRIVADO_CONFIG="+string(userConfiguration),
+		"PRIVADO_CACHE_DIRECTORY="+config.AppConfig.CacheDirectory,
+		"PRIVADO_IMAGE="+config.AppConfig.Container.ImageURL,
+		"PRIVADO_CLOUD_API_HOST="+config.AppConfig.PrivadoCloudAPIHost,
+		"PRIVADO_TEMP_DIRECTORY="+os.TempDir(),
+	)
+}
+
+// Runs the plugin with the remaining arguments and exits with its exit code
+func runPlugin(plugin plugins.Plugin, args []string) {
+	pluginCmd := exec.Command(plugin.Path, args...)
+	pluginCmd.Stdin = os.Stdin
+	pluginCmd.Stdout = os.Stdout
+	pluginCmd.Stderr = os.Stderr
+	pluginCmd.Env = getPluginEnvironment(plugin)
+
+	err := pluginCmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		runExitHooks(true)
+		flushTraces(fmt.Errorf("plugin %s exited with code %d", plugin.Name, exitErr.ExitCode()))
+		os.Exit(exitErr.ExitCode())
+	} else if err != nil {
+		exitWithError(clierrors.PluginFailed.Errorf("Cannot run plugin %s: %s", plugin.Name, err))
+	}
+}
+
+func pluginsList(cmd *cobra.Command, args []string) {
+	discovered := plugins.Discover(os.Getenv("PATH"))
+	if len(discovered) == 0 {
+		exit(fmt.Sprintf("> No plugins found. Plugins are executables named %s<name> on PATH", plugins.Prefix), false)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tPATH\tSTATUS")
+	for _, plugin := range discovered {
+		status := "available"
+		if isBuiltInCommand(plugin.Name) {
+			status = "shadowed by built-in command"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", plugin.Name, plugin.Path, status)
+	}
+	w.Flush()
+}
+
+func init() {
+	pluginsCmd.AddCommand(pluginsListCmd)
+	rootCmd.AddCommand(pluginsCmd)
+}
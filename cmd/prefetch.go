@@ -0,0 +1,119 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Privado-Inc/privado-cli/pkg/ci"
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/docker"
+	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+	"github.com/spf13/cobra"
+)
+
+var prefetchCmd = &cobra.Command{
+	Use:   "prefetch <repository>",
+	Short: "Download the dependencies of a repository to the dependency caches, without scanning it",
+	Long: "Download the dependencies of a repository to the dependency caches (maven, gradle, npm), without scanning it. " +
+		"Use it to warm the caches ahead of scans (e.g. during off-hours), or to bake them into CI images with 'privado cache export'",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeDirectoryArgument,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		telemetryPreRun(nil)
+	},
+	Run: prefetch,
+	PostRun: func(cmd *cobra.Command, args []string) {
+		telemetryPostRun(nil)
+	},
+}
+
+func prefetch(cmd *cobra.Command, args []string) {
+	repositoryPath := fileutils.GetAbsolutePath(args[0])
+	debug, _ := cmd.Flags().GetBool("debug")
+	jvmArgs, _ := cmd.Flags().GetString("jvm-args")
+
+	if exists, _ := fileutils.DoesFileExists(repositoryPath); !exists {
+		exitWithError(clierrors.PathNotFound.Errorf("Repository not found: %s", repositoryPath))
+	}
+
+	if dockerAccessKey, err := docker.GetPrivadoDockerAccessKey(true); err != nil || dockerAccessKey == "" {
+		exitWithError(clierrors.DockerAccessKey.Errorf("Cannot fetch docker access key: %v \nPlease try again or raise an issue at %s", err, config.AppConfig.PrivadoRepository))
+	} else {
+		config.LoadUserDockerHash(dockerAccessKey)
+	}
+
+	fmt.Println("> Downloading dependencies of:", repositoryPath)
+	err := docker.RunImage(
+		docker.OptionWithLatestImage(false), // because we already pull the image for access-key (with pullImage parameter)
+		docker.OptionWithDependencyDownloadOnly(),
+		docker.OptionWithArgs([]string{config.AppConfig.Container.SourceCodeVolumeDir}),
+		docker.OptionWithAttachedOutput(),
+		docker.OptionWithSourceVolume(repositoryPath),
+		docker.OptionWithUserKeyVolume(config.AppConfig.UserKeyPath),
+		docker.OptionWithPackageCacheVolumes(),
+		docker.OptionWithDebug(debug),
+		docker.OptionWithEnvironmentVariables([]docker.EnvVar{
+			{Key: "CI", Value: strings.ToUpper(strconv.FormatBool(ci.CISessionConfig.IsCI))},
+			{Key: "PRIVADO_VERSION_CLI", Value: Version},
+			{Key: "PRIVADO_HOST_SCAN_DIR", Value: repositoryPath},
+			{Key: "PRIVADO_USER_HASH", Value: config.UserConfig.UserHash},
+			{Key: "PRIVADO_SESSION_ID", Value: config.UserConfig.SessionId},
+			{Key: "PRIVADO_METRICS_ENABLED", Value: strings.ToUpper(strconv.FormatBool(config.IsEngineMetricsEnabled()))},
+			{Key: "JAVA_TOOL_OPTIONS", Value: jvmArgs},
+		}),
+		docker.OptionWithInterrupt(),
+	)
+	if err != nil {
+		var containerExitError *docker.ContainerExitError
+		if errors.As(err, &containerExitError) {
+			exitWithError(clierrors.EngineFailed.Errorf("Dependency download failed: %s", err))
+		}
+		exitWithError(clierrors.DockerRun.Errorf("Received error: %s", err))
+	}
+
+	directories := getCacheDirectories()
+	names := []string{}
+	for name := range directories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Println("\n> Dependencies downloaded to the caches:")
+	for _, name := range names {
+		fmt.Printf("  %s: %s\n", name, directories[name])
+	}
+	exit("> Export them with 'privado cache export <archive>' to reuse them elsewhere", false)
+}
+
+func init() {
+	prefetchCmd.Flags().Bool("debug", false, "Enables privado-core image output in debug mode")
+	prefetchCmd.Flags().String("jvm-args", "", "Specifies the JVM arguments to be passed to the engine; sets the 'JAVA_TOOL_OPTIONS' environment variable")
+
+	rootCmd.AddCommand(prefetchCmd)
+}
@@ -0,0 +1,220 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Privado-Inc/privado-cli/pkg/auth"
+	"github.com/Privado-Inc/privado-cli/pkg/ci"
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/docker"
+	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+	"github.com/spf13/cobra"
+)
+
+var preloadCmd = &cobra.Command{
+	Use:   "preload",
+	Short: "Pull the engine image and warm its caches ahead of the first scan",
+	Long: "Pull the engine image and run a throwaway scan of a sample project, to create a class archive of the engine " +
+		"in the cache directory that later scans start faster with. Run it when provisioning CI runners or images " +
+		"(the archive is exported with 'privado cache export'), and after the image is updated",
+	Args: cobra.ExactArgs(0),
+	PreRun: func(cmd *cobra.Command, args []string) {
+		telemetryPreRun(nil)
+	},
+	Run: preload,
+	PostRun: func(cmd *cobra.Command, args []string) {
+		telemetryPostRun(nil)
+	},
+}
+
+// sample project scanned to load the classes of the engine: a data
+// element of each language flowing to a leakage and a third party
+var preloadSampleFiles = map[string]string{
+	filepath.Join("java", "src", "main", "java", "sample", "User.java"): `package sample;
+
+import java.util.logging.Logger;
+
+public class User {
+    private static final Logger logger = Logger.getLogger(User.class.getName());
+    private String email;
+    private String phoneNumber;
+
+    public void register(String email, String phoneNumber) {
+        this.email = email;
+        this.phoneNumber = phoneNumber;
+        logger.info("Registered user: " + email);
+    }
+}
+`,
+	filepath.Join("javascript", "user.js"): `const axios = require("axios");
+
+function register(user) {
+  console.log("Registered user: " + user.email);
+  return axios.post("https://api.example.com/users", { email: user.email, firstName: user.firstName });
+}
+
+module.exports = { register };
+`,
+	filepath.Join("python", "user.py"): `import logging
+
+import requests
+
+
+def register(email, first_name):
+    logging.info("Registered user: %s", email)
+    return requests.post("https://api.example.com/users", json={"email": email, "firstName": first_name})
+`,
+}
+
+// Returns the name of the class archive of the local engine image,
+// empty if the image is not present. Archives are specific to an image
+func getJVMClassArchiveName() string {
+	digest := docker.GetImageDigest(config.AppConfig.Container.ImageURL)
+	if digest == "" {
+		return ""
+	}
+	return fmt.Sprintf("privado-core-%s.jsa", auth.CalculateSHA256Hash(digest)[:12])
+}
+
+func preload(cmd *cobra.Command, args []string) {
+	debug, _ := cmd.Flags().GetBool("debug")
+	force, _ := cmd.Flags().GetBool("force")
+	skipWarmUp, _ := cmd.Flags().GetBool("skip-warm-up")
+
+	fmt.Println("> Pulling the engine image:", config.AppConfig.Container.ImageURL)
+	if dockerAccessKey, err := docker.GetPrivadoDockerAccessKey(true); err != nil || dockerAccessKey == "" {
+		exitWithError(clierrors.DockerAccessKey.Errorf("Cannot fetch docker access key: %v \nPlease try again or raise an issue at %s", err, config.AppConfig.PrivadoRepository))
+	} else {
+		config.LoadUserDockerHash(dockerAccessKey)
+	}
+	warnIfEmulated()
+	if skipWarmUp {
+		exit("> Image pulled", false)
+	}
+
+	cacheDirectory := config.GetJVMCacheDirectory()
+	archiveName := getJVMClassArchiveName()
+	if archiveName == "" {
+		exitWithError(clierrors.ImagePull.Errorf("Cannot find the engine image: %s", config.AppConfig.Container.ImageURL))
+	}
+	archivePath := filepath.Join(cacheDirectory, archiveName)
+	if exists, _ := fileutils.DoesFileExists(archivePath); exists && !force {
+		exit(fmt.Sprintf("> The engine is already warmed up for this image: %s (use --force to recreate it)", archivePath), false)
+	}
+	if err := os.MkdirAll(cacheDirectory, os.ModePerm); err != nil {
+		exitWithError(clierrors.CacheArchive.Errorf("Cannot create the cache directory: %s", err))
+	}
+	os.Remove(archivePath)
+
+	sampleDirectory, err := createPreloadSample()
+	if err != nil {
+		exitWithError(clierrors.TempDirectoryCreation.Errorf("Cannot create the sample project: %s", err))
+	}
+	defer os.RemoveAll(sampleDirectory)
+
+	fmt.Println("> Warming up the engine with a scan of a sample project")
+	options := []docker.RunImageOption{
+		docker.OptionWithLatestImage(false), // because we already pulled the image (with pullImage parameter)
+		docker.OptionWithArgs([]string{
+			config.AppConfig.Container.SourceCodeVolumeDir,
+			"-ic",
+			config.AppConfig.Container.InternalRulesVolumeDir,
+			"--skip-upload",
+		}),
+		docker.OptionWithSourceVolume(sampleDirectory),
+		docker.OptionWithUserKeyVolume(config.AppConfig.UserKeyPath),
+		docker.OptionWithSkipDependencyDownload(true),
+		docker.OptionWithJVMClassArchive(cacheDirectory, archiveName, true),
+		docker.OptionWithDebug(debug),
+		docker.OptionWithEnvironmentVariables([]docker.EnvVar{
+			{Key: "CI", Value: strings.ToUpper(strconv.FormatBool(ci.CISessionConfig.IsCI))},
+			{Key: "PRIVADO_VERSION_CLI", Value: Version},
+			{Key: "PRIVADO_USER_HASH", Value: config.UserConfig.UserHash},
+			{Key: "PRIVADO_SESSION_ID", Value: config.UserConfig.SessionId},
+			{Key: "PRIVADO_METRICS_ENABLED", Value: "FALSE"},
+		}),
+		docker.OptionWithInterrupt(),
+	}
+	if debug {
+		options = append(options, docker.OptionWithAttachedOutput())
+	}
+	if err := docker.RunImage(options...); err != nil {
+		var containerExitError *docker.ContainerExitError
+		if errors.As(err, &containerExitError) {
+			exitWithError(clierrors.EngineFailed.Errorf("Warm-up scan failed: %s", err))
+		}
+		exitWithError(clierrors.DockerRun.Errorf("Received error: %s", err))
+	}
+
+	if exists, _ := fileutils.DoesFileExists(archivePath); !exists {
+		exit("> Image pulled, but the engine does not support class archives: scans start without them", false)
+	}
+	removeStaleJVMClassArchives(cacheDirectory, archiveName)
+	exit(fmt.Sprintf("> Engine warmed up, scans start with: %s", archivePath), false)
+}
+
+func createPreloadSample() (string, error) {
+	directory, err := os.MkdirTemp("", "privado-preload-")
+	if err != nil {
+		return "", err
+	}
+	for name, contents := range preloadSampleFiles {
+		path := filepath.Join(directory, name)
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			os.RemoveAll(directory)
+			return "", err
+		}
+		if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+			os.RemoveAll(directory)
+			return "", err
+		}
+	}
+	return directory, nil
+}
+
+// removes the archives of other (previous) images
+func removeStaleJVMClassArchives(cacheDirectory, archiveName string) {
+	archives, _ := filepath.Glob(filepath.Join(cacheDirectory, "privado-core-*.jsa"))
+	for _, archive := range archives {
+		if filepath.Base(archive) != archiveName {
+			if err := os.Remove(archive); err != nil {
+				fmt.Println("[WARN]: Could not remove class archive of a previous image:", err)
+			}
+		}
+	}
+}
+
+func init() {
+	preloadCmd.Flags().Bool("debug", false, "Enables privado-core image output in debug mode")
+	preloadCmd.Flags().Bool("force", false, "Recreate the class archive, even if it exists for the image")
+	preloadCmd.Flags().Bool("skip-warm-up", false, "Pull the image only, without the warm-up scan")
+
+	rootCmd.AddCommand(preloadCmd)
+}
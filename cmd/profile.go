@@ -0,0 +1,129 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"time"
+
+	"github.com/Privado-Inc/privado-cli/pkg/tracing"
+	"github.com/spf13/cobra"
+)
+
+// --profile: time spent in each phase of the cli (the tracing spans)
+// is printed before exiting; --profile-output also writes pprof profiles
+
+var cpuProfileFile *os.File
+var profileOutputDirectory string
+var profileReported bool
+
+func startProfiling(cmd *cobra.Command) {
+	profile, _ := cmd.Flags().GetBool("profile")
+	profileOutput, _ := cmd.Flags().GetString("profile-output")
+	if !profile && profileOutput == "" {
+		return
+	}
+	tracing.EnableProfiling()
+
+	if profileOutput == "" {
+		return
+	}
+	profileOutputDirectory, _ = filepath.Abs(profileOutput)
+	if err := os.MkdirAll(profileOutputDirectory, os.ModePerm); err != nil {
+		fmt.Println("[WARN]: Cannot create profile output directory:", err)
+		profileOutputDirectory = ""
+		return
+	}
+	file, err := os.Create(filepath.Join(profileOutputDirectory, "cpu.pprof"))
+	if err != nil {
+		fmt.Println("[WARN]: Cannot create CPU profile:", err)
+		return
+	}
+	if err := pprof.StartCPUProfile(file); err != nil {
+		fmt.Println("[WARN]: Cannot start CPU profile:", err)
+		file.Close()
+		return
+	}
+	cpuProfileFile = file
+}
+
+// prints the timing breakdown and writes the pprof profiles (once)
+func reportProfile() {
+	if !tracing.IsProfilingEnabled() || profileReported {
+		return
+	}
+	profileReported = true
+
+	phases, total := tracing.Profile()
+	fmt.Printf("\n> Profile (total %s):\n", total.Round(time.Millisecond))
+	accounted := time.Duration(0)
+	for _, phase := range phases {
+		accounted += phase.Duration
+		name := phase.Name
+		if phase.Count > 1 {
+			name = fmt.Sprintf("%s (x%d)", phase.Name, phase.Count)
+		}
+		fmt.Printf("  %-28s %12s %6.1f%%\n", name, phase.Duration.Round(time.Millisecond), percentOf(phase.Duration, total))
+	}
+	if other := total - accounted; other > 0 {
+		fmt.Printf("  %-28s %12s %6.1f%%\n", "other", other.Round(time.Millisecond), percentOf(other, total))
+	}
+
+	if profileOutputDirectory == "" {
+		return
+	}
+	if cpuProfileFile != nil {
+		pprof.StopCPUProfile()
+		cpuProfileFile.Close()
+		fmt.Println("> CPU profile saved to:", cpuProfileFile.Name())
+	}
+	heapProfilePath := filepath.Join(profileOutputDirectory, "heap.pprof")
+	if file, err := os.Create(heapProfilePath); err != nil {
+		fmt.Println("[WARN]: Cannot create heap profile:", err)
+	} else {
+		runtime.GC()
+		if err := pprof.WriteHeapProfile(file); err != nil {
+			fmt.Println("[WARN]: Cannot write heap profile:", err)
+		} else {
+			fmt.Println("> Heap profile saved to:", heapProfilePath)
+		}
+		file.Close()
+	}
+}
+
+func percentOf(duration, total time.Duration) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return float64(duration) / float64(total) * 100
+}
+
+func init() {
+	rootCmd.PersistentFlags().Bool("profile", false, "Print the time spent in each phase (update check, access key fetch, image pull, container run, post-processing, telemetry flush) before exiting")
+	rootCmd.PersistentFlags().String("profile-output", "", "Also write CPU and heap profiles (pprof) of the cli to the directory; implies --profile")
+	_ = rootCmd.RegisterFlagCompletionFunc("profile-output", completeDirectory)
+}
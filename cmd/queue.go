@@ -0,0 +1,334 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+	"github.com/Privado-Inc/privado-cli/pkg/orgscan"
+	"github.com/Privado-Inc/privado-cli/pkg/queue"
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+	"github.com/spf13/cobra"
+)
+
+var queueCmd = &cobra.Command{
+	Use:   "queue",
+	Short: "Queue repositories and scan them in a batch",
+	Long:  "Queue local repositories with 'privado queue add' and scan them in a batch (e.g. overnight) with 'privado queue run', with retries and a consolidated report",
+}
+
+var queueAddCmd = &cobra.Command{
+	Use:               "add <repository>...",
+	Short:             "Add repositories to the scan queue",
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeDirectoryArgument,
+	Run:               queueAdd,
+}
+
+var queueListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List queued scans and their status",
+	Args:  cobra.ExactArgs(0),
+	Run:   queueList,
+}
+
+var queueRemoveCmd = &cobra.Command{
+	Use:               "remove <id>",
+	Short:             "Remove a scan from the queue",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeQueueItemId,
+	Run:               queueRemove,
+}
+
+var queueClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove finished scans from the queue",
+	Args:  cobra.ExactArgs(0),
+	Run:   queueClear,
+}
+
+var queueRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Scan the pending repositories of the queue",
+	Long: fmt.Sprint(
+		"Scan the pending repositories of the queue, each in a separate process so that a failed scan does not affect the others. ",
+		"Failed scans are retried (--retries). A consolidated report (data elements and findings per repository, and the data lineage between them) is written when all scans completed",
+	),
+	Args: cobra.ExactArgs(0),
+	PreRun: func(cmd *cobra.Command, args []string) {
+		telemetryPreRun(nil)
+	},
+	Run: queueRun,
+	PostRun: func(cmd *cobra.Command, args []string) {
+		telemetryPostRun(nil)
+	},
+}
+
+func loadQueueOrExit() []*queue.Item {
+	items, err := queue.Load(config.AppConfig.QueueDirectory)
+	if err != nil {
+		exitWithError(clierrors.QueueLoad.Errorf("Cannot load queue: %s", err))
+	}
+	return items
+}
+
+func saveQueueOrExit(items []*queue.Item) {
+	if err := queue.Save(config.AppConfig.QueueDirectory, items); err != nil {
+		exitWithError(clierrors.QueueSave.Errorf("Cannot save queue: %s", err))
+	}
+}
+
+func queueAdd(cmd *cobra.Command, args []string) {
+	scanArgs, _ := cmd.Flags().GetStringSlice("scan-args")
+
+	items := loadQueueOrExit()
+	added := []*queue.Item{}
+	for _, arg := range args {
+		repository := fileutils.GetAbsolutePath(arg)
+		if exists, _ := fileutils.DoesFileExists(repository); !exists {
+			exitWithError(clierrors.PathNotFound.Errorf("Could not find repository: %s", repository))
+		}
+		item := queue.NewItem(repository, scanArgs)
+		items = append(items, item)
+		added = append(added, item)
+	}
+	saveQueueOrExit(items)
+
+	for _, item := range added {
+		fmt.Printf("> Queued scan %s: %s\n", item.Id, item.Repository)
+	}
+	exit("> Run 'privado queue run' to scan the queued repositories", false)
+}
+
+func queueList(cmd *cobra.Command, args []string) {
+	items := loadQueueOrExit()
+	if len(items) == 0 {
+		exit("> No queued scans. Use 'privado queue add' to queue repositories", false)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tREPOSITORY\tSTATUS\tATTEMPTS\tCOMPLETED\tERROR")
+	for _, item := range items {
+		completed := "-"
+		if !item.CompletedAt.IsZero() {
+			completed = item.CompletedAt.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n", item.Id, item.Repository, item.Status, item.Attempts, completed, item.Error)
+	}
+	w.Flush()
+}
+
+func queueRemove(cmd *cobra.Command, args []string) {
+	items := loadQueueOrExit()
+
+	remaining := []*queue.Item{}
+	for _, item := range items {
+		if item.Id != args[0] {
+			remaining = append(remaining, item)
+		}
+	}
+	if len(remaining) == len(items) {
+		exitWithError(clierrors.QueueItemNotFound.Errorf("No queued scan with id: %s", args[0]))
+	}
+
+	saveQueueOrExit(remaining)
+	exit(fmt.Sprintf("> Removed queued scan: %s", args[0]), false)
+}
+
+func queueClear(cmd *cobra.Command, args []string) {
+	all, _ := cmd.Flags().GetBool("all")
+	items := loadQueueOrExit()
+
+	remaining := []*queue.Item{}
+	for _, item := range items {
+		if !all && !item.IsFinished() {
+			remaining = append(remaining, item)
+		}
+	}
+
+	saveQueueOrExit(remaining)
+	exit(fmt.Sprintf("> Removed %d scan(s) from the queue", len(items)-len(remaining)), false)
+}
+
+func queueRun(cmd *cobra.Command, args []string) {
+	parallel, _ := cmd.Flags().GetInt("parallel")
+	retries, _ := cmd.Flags().GetInt("retries")
+	retryFailed, _ := cmd.Flags().GetBool("retry-failed")
+	output, _ := cmd.Flags().GetString("output")
+
+	if parallel < 1 {
+		parallel = 1
+	}
+	if retries < 0 {
+		retries = 0
+	}
+
+	// scans left running by an interrupted run are scanned again
+	items := loadQueueOrExit()
+	pending := []*queue.Item{}
+	for _, item := range items {
+		if item.Status == queue.StatusRunning || (retryFailed && item.Status == queue.StatusFailed) {
+			item.Status = queue.StatusPending
+			item.Attempts = 0
+		}
+		if item.Status == queue.StatusPending {
+			pending = append(pending, item)
+		}
+	}
+	if len(pending) == 0 {
+		exit("> No pending scans in the queue. Use 'privado queue add' to queue repositories", false)
+	}
+	saveQueueOrExit(items)
+
+	directory := config.AppConfig.QueueDirectory
+	for _, subdirectory := range []string{"logs", "results"} {
+		if err := os.MkdirAll(filepath.Join(directory, subdirectory), os.ModePerm); err != nil {
+			exitWithError(clierrors.QueueSave.Errorf("Cannot create queue directory: %s", err))
+		}
+	}
+
+	fmt.Printf("> Scanning %d queued repositories (parallel: %d, retries: %d)\n", len(pending), parallel, retries)
+
+	// updates of the queue file are serialized
+	var mu sync.Mutex
+	updateItem := func(item *queue.Item) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err := queue.Update(directory, *item); err != nil {
+			fmt.Println("[WARN]: Could not update the queue:", err)
+		}
+	}
+
+	reports := make([]orgscan.RepositoryReport, len(pending))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < parallel; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				reports[i] = scanQueueItem(pending[i], retries, updateItem)
+				fmt.Printf("> [%s] %s (%s, %d attempt(s))\n", pending[i].Status, pending[i].Repository, reports[i].Duration, pending[i].Attempts)
+			}
+		}()
+	}
+	for i := range pending {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	report := orgscan.NewReport("queue", reports)
+	if output == "" {
+		output = filepath.Join(directory, "report.json")
+	}
+	output = fileutils.GetAbsolutePath(output)
+	if err := report.Write(output); err != nil {
+		exitWithError(clierrors.OrgReportWrite.Errorf("Cannot write report: %s", err))
+	}
+
+	fmt.Println()
+	fmt.Printf("> Scanned: %d, Failed: %d\n", report.Scanned, report.Failed)
+	fmt.Printf("> Findings: high: %d, medium: %d, low: %d, unknown: %d\n",
+		report.FindingsBySeverity[results.SeverityHigh], report.FindingsBySeverity[results.SeverityMedium],
+		report.FindingsBySeverity[results.SeverityLow], report.FindingsBySeverity[results.SeverityUnknown])
+	fmt.Printf("> Data elements found across repositories: %d\n", len(report.DataElements))
+	printLineageSummary(report.Lineage)
+	exit(fmt.Sprintf("> Report saved to: %s", output), report.Failed > 0 && report.Scanned == 0)
+}
+
+// Scans the repository of the item, retrying failed scans. Logs and a copy
+// of the results are written to the queue directory
+func scanQueueItem(item *queue.Item, retries int, updateItem func(*queue.Item)) orgscan.RepositoryReport {
+	startTime := time.Now()
+	report := orgscan.RepositoryReport{
+		Repository: orgscan.Repository{Name: filepath.Base(item.Repository), FullName: item.Repository},
+		Status:     orgscan.StatusFailed,
+	}
+	defer func() {
+		report.Duration = time.Since(startTime).Round(time.Second).String()
+	}()
+
+	item.Status = queue.StatusRunning
+	item.StartedAt = startTime
+	item.LogPath = filepath.Join(config.AppConfig.QueueDirectory, "logs", item.Id+".log")
+	updateItem(item)
+
+	var err error
+	for item.Attempts < retries+1 {
+		item.Attempts++
+		if err = runScanProcess(item.Repository, item.LogPath, item.ScanArgs); err == nil {
+			break
+		}
+		err = fmt.Errorf("scan failed: %s (see %s)", err, item.LogPath)
+	}
+	if err == nil {
+		resultsPath := filepath.Join(config.AppConfig.QueueDirectory, "results", item.Id+".json")
+		if err = fileutils.CopyFile(filepath.Join(item.Repository, config.AppConfig.PrivacyResultsPathSuffix), resultsPath); err == nil {
+			err = report.LoadResults(resultsPath)
+		}
+		if err != nil {
+			err = fmt.Errorf("cannot read results: %s", err)
+		} else {
+			item.ResultsPath = resultsPath
+		}
+	}
+
+	item.CompletedAt = time.Now()
+	if err != nil {
+		item.Status = queue.StatusFailed
+		item.Error = err.Error()
+		report.Error = item.Error
+	} else {
+		item.Status = queue.StatusSucceeded
+		item.Error = ""
+		report.Status = orgscan.StatusScanned
+	}
+	report.LogPath = item.LogPath
+	updateItem(item)
+	return report
+}
+
+func init() {
+	queueAddCmd.Flags().StringSlice("scan-args", []string{}, "Additional flags passed to the scans, e.g. --scan-args=--skip-dependency-download")
+	queueClearCmd.Flags().Bool("all", false, "Remove all scans from the queue, including pending ones")
+	queueRunCmd.Flags().IntP("parallel", "p", 1, "Number of repositories scanned in parallel")
+	queueRunCmd.Flags().Int("retries", 1, "Number of times a failed scan is retried")
+	queueRunCmd.Flags().Bool("retry-failed", false, "Also scan repositories whose scans failed in previous runs")
+	queueRunCmd.Flags().StringP("output", "o", "", "Path of the consolidated report (default: ~/.privado/queue/report.json)")
+
+	queueCmd.AddCommand(queueAddCmd)
+	queueCmd.AddCommand(queueListCmd)
+	queueCmd.AddCommand(queueRemoveCmd)
+	queueCmd.AddCommand(queueClearCmd)
+	queueCmd.AddCommand(queueRunCmd)
+	rootCmd.AddCommand(queueCmd)
+}
@@ -0,0 +1,410 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+	"github.com/Privado-Inc/privado-cli/pkg/owners"
+	"github.com/Privado-Inc/privado-cli/pkg/report"
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+	"github.com/Privado-Inc/privado-cli/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report [repository]",
+	Short: "Generate a report of the scan results for audits",
+	Long: fmt.Sprint(
+		"Generate a report of the last scan of the repository (default: current directory) with a cover page, ",
+		"an executive summary (data elements, data recipients, findings by severity) and the details of every finding, ",
+		"as a PDF document, markdown or an HTML page, or of its findings for vulnerability management and code quality tools (defectdojo: ",
+		"Generic Findings Import, sonar: Generic Issue Import for sonar.externalIssuesReportPaths). With --email, the report is sent to the recipients with the SMTP server ",
+		"configured in ~/.privado/config.json (smtp) or a file (--smtp-config) instead. With --split-by, a report is written for each ",
+		"owning team (CODEOWNERS) or top-level directory, to distribute the findings to the teams. With --template dsr, the report lists instead, ",
+		"for each data element, every storage and third-party recipient it flows to with the code sending it there, to help respond to ",
+		"data subject access and deletion requests (pdf, markdown or html)",
+	),
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeDirectoryArgument,
+	Run:               generateReport,
+}
+
+var reportFormats = []string{"pdf", "markdown", "html", "defectdojo", "sonar"}
+
+const (
+	reportTemplateAudit = "audit"
+	reportTemplateDSR   = "dsr"
+)
+
+// formats of the data subject request report (--template dsr)
+var dsrReportFormats = map[string]bool{"pdf": true, "markdown": true, "html": true}
+
+var reportFormatExtensions = map[string]string{
+	"pdf":        ".pdf",
+	"markdown":   ".md",
+	"html":       ".html",
+	"defectdojo": ".defectdojo.json",
+	"sonar":      ".sonar.json",
+}
+
+var reportFormatContentTypes = map[string]string{
+	"pdf":        "application/pdf",
+	"markdown":   "text/markdown; charset=utf-8",
+	"html":       "text/html; charset=utf-8",
+	"defectdojo": "application/json",
+	"sonar":      "application/json",
+}
+
+func generateReport(cmd *cobra.Command, args []string) {
+	repository := "."
+	if len(args) > 0 {
+		repository = args[0]
+	}
+	repositoryPath := fileutils.GetAbsolutePath(repository)
+	format, _ := cmd.Flags().GetString("format")
+	output, _ := cmd.Flags().GetString("out")
+	recipients, _ := cmd.Flags().GetStringSlice("email")
+	smtpConfigPath, _ := cmd.Flags().GetString("smtp-config")
+	owner, _ := cmd.Flags().GetString("owner")
+	splitBy, _ := cmd.Flags().GetString("split-by")
+	reportTemplate, _ := cmd.Flags().GetString("template")
+
+	extension, ok := reportFormatExtensions[format]
+	if !ok {
+		exitWithError(clierrors.InvalidFlagValue.Errorf("Invalid value for --format: %s (allowed: %s)", format, strings.Join(reportFormats, ", ")))
+	}
+	if splitBy != "" && splitBy != reportSplitByOwner && splitBy != reportSplitByDirectory {
+		exitWithError(clierrors.InvalidFlagValue.Errorf("Invalid value for --split-by: %s (allowed: %s, %s)", splitBy, reportSplitByOwner, reportSplitByDirectory))
+	}
+	if splitBy != "" && len(recipients) > 0 {
+		exitWithError(clierrors.ConflictingOptions.New("--split-by cannot be used with --email"))
+	}
+	if smtpConfigPath != "" && len(recipients) == 0 {
+		exitWithError(clierrors.ConflictingOptions.New("--smtp-config requires recipients: --email <address>"))
+	}
+	if reportTemplate != reportTemplateAudit && reportTemplate != reportTemplateDSR {
+		exitWithError(clierrors.InvalidFlagValue.Errorf("Invalid value for --template: %s (allowed: %s, %s)", reportTemplate, reportTemplateAudit, reportTemplateDSR))
+	}
+	if reportTemplate == reportTemplateDSR {
+		if !dsrReportFormats[format] {
+			exitWithError(clierrors.InvalidFlagValue.Errorf("Invalid value for --format with --template dsr: %s (allowed: pdf, markdown, html)", format))
+		}
+		if owner != "" || splitBy != "" || len(recipients) > 0 {
+			exitWithError(clierrors.ConflictingOptions.New("--template dsr cannot be used with --owner, --split-by or --email"))
+		}
+	}
+
+	resultsPath := filepath.Join(repositoryPath, config.AppConfig.PrivacyResultsPathSuffix)
+	scanResults, err := results.LoadResults(resultsPath)
+	if err != nil {
+		exitWithError(clierrors.ResultsRead.Errorf("Cannot read scan results (run 'privado scan' first): %s", err))
+	}
+	if scanResults.RepoName == "" {
+		scanResults.RepoName = filepath.Base(repositoryPath)
+	}
+	if reportTemplate == reportTemplateDSR {
+		writeDSRReport(report.NewDSR(scanResults, time.Now()), format, repositoryPath, output)
+		return
+	}
+
+	audit := report.NewAudit(scanResults, time.Now())
+	loadFindingOwners(repositoryPath).Assign(audit.Findings)
+	if owner != "" {
+		audit = audit.WithFindings(owners.FilterFindings(audit.Findings, owner))
+		audit.Scope = fmt.Sprintf("owned by %s", owner)
+	}
+	if splitBy != "" {
+		if output == "" {
+			output = filepath.Join(repositoryPath, getPrivadoDirectoryName(), "reports")
+		}
+		writeSplitReports(audit, format, splitBy, fileutils.GetAbsolutePath(output))
+		return
+	}
+	if len(recipients) > 0 {
+		if err := emailReport(audit, format, recipients, smtpConfigPath); err != nil {
+			exitWithError(clierrors.ReportEmail.Errorf("Cannot email report: %s", err))
+		}
+		fmt.Println("> Report emailed to:", strings.Join(recipients, ", "))
+		// the report is written as well only if requested
+		if output == "" {
+			return
+		}
+	}
+
+	if output == "" {
+		output = filepath.Join(repositoryPath, getPrivadoDirectoryName(), "privado-report"+extension)
+	}
+	output = fileutils.GetAbsolutePath(output)
+	data, err := renderReport(audit, format)
+	if err != nil {
+		exitWithError(clierrors.ReportWrite.Errorf("Cannot render report: %s", err))
+	}
+	if err := os.MkdirAll(filepath.Dir(output), os.ModePerm); err != nil {
+		exitWithError(clierrors.ReportWrite.Errorf("Cannot write report: %s", err))
+	}
+	if err := os.WriteFile(output, data, 0644); err != nil {
+		exitWithError(clierrors.ReportWrite.Errorf("Cannot write report: %s", err))
+	}
+	exit(fmt.Sprintf("> Report written to: %s", output), false)
+}
+
+// Writes the data subject request report to the output (default:
+// <repository>/.privado/privado-dsr-report.<extension>)
+func writeDSRReport(dsr report.DSR, format, repositoryPath, output string) {
+	if output == "" {
+		output = filepath.Join(repositoryPath, getPrivadoDirectoryName(), "privado-dsr-report"+reportFormatExtensions[format])
+	}
+	output = fileutils.GetAbsolutePath(output)
+
+	var data []byte
+	var err error
+	switch format {
+	case "pdf":
+		data = dsr.RenderPDF()
+	case "html":
+		data, err = dsr.RenderHTML()
+	default:
+		data = []byte(dsr.RenderMarkdown() + "\n")
+	}
+	if err != nil {
+		exitWithError(clierrors.ReportWrite.Errorf("Cannot render report: %s", err))
+	}
+	if err := os.MkdirAll(filepath.Dir(output), os.ModePerm); err != nil {
+		exitWithError(clierrors.ReportWrite.Errorf("Cannot write report: %s", err))
+	}
+	if err := os.WriteFile(output, data, 0644); err != nil {
+		exitWithError(clierrors.ReportWrite.Errorf("Cannot write report: %s", err))
+	}
+	exit(fmt.Sprintf("> Data subject request report (%d data element(s) stored or shared) written to: %s", len(dsr.DataElements), output), false)
+}
+
+// Writes a report for each owner or top-level directory of the findings to
+// the directory. Findings with several owners are in the report of each
+func writeSplitReports(audit report.Audit, format, splitBy, directory string) {
+	groups := map[string][]results.Finding{}
+	for _, finding := range audit.Findings {
+		for _, key := range getReportSplitKeys(finding, splitBy) {
+			groups[key] = append(groups[key], finding)
+		}
+	}
+	if len(groups) == 0 {
+		exit("> No findings to report", false)
+	}
+	keys := []string{}
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	if err := os.MkdirAll(directory, os.ModePerm); err != nil {
+		exitWithError(clierrors.ReportWrite.Errorf("Cannot write reports: %s", err))
+	}
+	fmt.Printf("> %d report(s) written to: %s\n", len(keys), directory)
+	for _, key := range keys {
+		groupAudit := audit.WithFindings(groups[key])
+		groupAudit.Scope = getReportScope(key, splitBy)
+		data, err := renderReport(groupAudit, format)
+		if err != nil {
+			exitWithError(clierrors.ReportWrite.Errorf("Cannot render report (%s): %s", key, err))
+		}
+		name := fmt.Sprintf("privado-report-%s%s", getReportFileSlug(key), reportFormatExtensions[format])
+		if err := os.WriteFile(filepath.Join(directory, name), data, 0644); err != nil {
+			exitWithError(clierrors.ReportWrite.Errorf("Cannot write report: %s", err))
+		}
+		fmt.Printf("  %s: %s (%d finding(s))\n", key, name, len(groups[key]))
+	}
+}
+
+const (
+	reportSplitByOwner     = "owner"
+	reportSplitByDirectory = "directory"
+
+	// findings without owners, or not located in a directory
+	reportUnowned = "unowned"
+	reportRoot    = "root"
+)
+
+func getReportSplitKeys(finding results.Finding, splitBy string) []string {
+	if splitBy == reportSplitByOwner {
+		if len(finding.Owners) == 0 {
+			return []string{reportUnowned}
+		}
+		return finding.Owners
+	}
+	relativePath := filepath.ToSlash(finding.RelativeFileName())
+	if finding.FileName == "" || !strings.Contains(relativePath, "/") {
+		return []string{reportRoot}
+	}
+	return []string{strings.SplitN(relativePath, "/", 2)[0] + "/"}
+}
+
+func getReportScope(key, splitBy string) string {
+	switch {
+	case key == reportUnowned:
+		return "without owners"
+	case key == reportRoot:
+		return "in the root directory"
+	case splitBy == reportSplitByOwner:
+		return fmt.Sprintf("owned by %s", key)
+	}
+	return fmt.Sprintf("in %s", key)
+}
+
+// Returns the key as a part of a file name, e.g. @org/payments-team: org-payments-team
+func getReportFileSlug(key string) string {
+	slug := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '-'
+	}, strings.TrimPrefix(key, "@"))
+	return strings.Trim(slug, "-.")
+}
+
+func renderReport(audit report.Audit, format string) ([]byte, error) {
+	switch format {
+	case "pdf":
+		return audit.RenderPDF(), nil
+	case "html":
+		return audit.RenderHTML()
+	case "defectdojo":
+		return audit.RenderDefectDojo()
+	case "sonar":
+		return audit.RenderSonar()
+	default:
+		return []byte(audit.RenderMarkdown() + "\n"), nil
+	}
+}
+
+// Sends the report to the recipients, with the SMTP server of the file
+// (--smtp-config) if any, else of the user configuration
+func emailReport(audit report.Audit, format string, recipients []string, smtpConfigPath string) error {
+	server, err := getSMTPServer(smtpConfigPath)
+	if err != nil {
+		return err
+	}
+	data, err := renderReport(audit, format)
+	if err != nil {
+		return err
+	}
+	return server.Send(report.Email{
+		To:      recipients,
+		Subject: audit.EmailSubject(),
+		Body:    audit.RenderEmailBody(),
+		Attachments: []report.Attachment{{
+			Name:        "privado-report" + reportFormatExtensions[format],
+			ContentType: reportFormatContentTypes[format],
+			Data:        data,
+		}},
+	})
+}
+
+func getSMTPServer(smtpConfigPath string) (report.SMTPServer, error) {
+	smtpConfig := config.UserConfig.ConfigFile.SMTP
+	if smtpConfigPath != "" {
+		data, err := os.ReadFile(smtpConfigPath)
+		if err != nil {
+			return report.SMTPServer{}, err
+		}
+		smtpConfig = &config.SMTP{}
+		if err := json.Unmarshal(data, smtpConfig); err != nil {
+			return report.SMTPServer{}, fmt.Errorf("invalid SMTP configuration (%s): %w", smtpConfigPath, err)
+		}
+	}
+	if smtpConfig == nil || smtpConfig.Host == "" {
+		return report.SMTPServer{}, fmt.Errorf("no SMTP server is configured")
+	}
+
+	password := smtpConfig.Password
+	if value := os.Getenv("PRIVADO_SMTP_PASSWORD"); value != "" {
+		password = value
+	}
+	return report.SMTPServer{
+		Host:     smtpConfig.Host,
+		Port:     smtpConfig.Port,
+		Username: smtpConfig.Username,
+		Password: password,
+		From:     smtpConfig.From,
+		TLS:      smtpConfig.TLS,
+	}, nil
+}
+
+func init() {
+	reportCmd.Flags().String("format", "pdf", fmt.Sprintf("Format of the report (%s)", strings.Join(reportFormats, ", ")))
+	reportCmd.Flags().StringP("out", "o", "", "Path of the report (default: <repository>/.privado/privado-report.<pdf|md|html|defectdojo.json|sonar.json>)")
+	reportCmd.Flags().StringSlice("email", []string{}, "Email the report to the recipients instead of writing it (unless --out is set), e.g. --email dpo@company.com")
+	reportCmd.Flags().String("owner", "", "Report only the findings owned by the team or user, e.g. @payments-team (CODEOWNERS, or owners in .privado/config.json)")
+	reportCmd.Flags().String("split-by", "", "Write a report for each owner (CODEOWNERS) or top-level directory of the findings instead, to the directory --out (default: <repository>/.privado/reports)")
+	reportCmd.Flags().String("template", reportTemplateAudit, "Contents of the report: audit (summary and findings) or dsr (storages and recipients of each data element, for data subject requests)")
+	reportCmd.Flags().String("smtp-config", "", "Path of a JSON file with the SMTP server (host, port, username, password, from, tls), instead of smtp in ~/.privado/config.json")
+	_ = reportCmd.RegisterFlagCompletionFunc("format", completeValues(reportFormats...))
+	_ = reportCmd.RegisterFlagCompletionFunc("template", completeValues(reportTemplateAudit, reportTemplateDSR))
+	_ = reportCmd.RegisterFlagCompletionFunc("split-by", completeValues(reportSplitByOwner, reportSplitByDirectory))
+
+	rootCmd.AddCommand(reportCmd)
+}
+
+// Renders the HTML report of the results of the repository and opens it in
+// the browser (privado scan --open local). The scan succeeded, so failures
+// are warnings
+func openHTMLReport(repositoryPath string) {
+	scanResults, err := results.LoadResults(filepath.Join(repositoryPath, config.AppConfig.PrivacyResultsPathSuffix))
+	if err != nil {
+		fmt.Println("[WARN]: Could not read results for the HTML report:", err)
+		return
+	}
+	if scanResults.RepoName == "" {
+		scanResults.RepoName = filepath.Base(repositoryPath)
+	}
+	audit := report.NewAudit(scanResults, time.Now())
+	loadFindingOwners(repositoryPath).Assign(audit.Findings)
+	data, err := audit.RenderHTML()
+	if err != nil {
+		fmt.Println("[WARN]: Could not render the HTML report:", err)
+		return
+	}
+	output := filepath.Join(repositoryPath, getPrivadoDirectoryName(), "privado-report"+reportFormatExtensions["html"])
+	if err := os.WriteFile(output, data, 0644); err != nil {
+		fmt.Println("[WARN]: Could not write the HTML report:", err)
+		return
+	}
+	fmt.Println("> HTML report written to:", output)
+	// file:///C:/... on windows
+	fileURL := filepath.ToSlash(output)
+	if !strings.HasPrefix(fileURL, "/") {
+		fileURL = "/" + fileURL
+	}
+	if err := utils.OpenURLInBrowser("file://" + fileURL); err != nil {
+		fmt.Println("[WARN]: Could not open the HTML report in the browser:", err)
+	}
+}
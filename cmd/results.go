@@ -0,0 +1,320 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+	"github.com/Privado-Inc/privado-cli/pkg/owners"
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+	"github.com/spf13/cobra"
+)
+
+var resultsCmd = &cobra.Command{
+	Use:   "results",
+	Short: "Manage the scan results of a repository",
+}
+
+var resultsListCmd = &cobra.Command{
+	Use:               "list [repository]",
+	Short:             "List the findings of the last scan of a repository",
+	Long:              "List the findings of the last scan of the repository (default: current directory) with their owners, from the CODEOWNERS file of the repository and the mapping file of its configuration (owners in .privado/config.json)",
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeDirectoryArgument,
+	Run:               listResults,
+}
+
+var resultsShowCmd = &cobra.Command{
+	Use:   "show <finding-id> [repository]",
+	Short: "Show a finding with its dataflow path",
+	Long: fmt.Sprint(
+		"Show a finding of the last scan of the repository (default: current directory) with its full dataflow path: ",
+		"the source, every intermediate step and the sink, with their locations and the code around them from the ",
+		"local repository (see 'privado results list' for the ids of the findings)",
+	),
+	Args: cobra.RangeArgs(1, 2),
+	Run:  showResult,
+}
+
+var resultsDecryptCmd = &cobra.Command{
+	Use:               "decrypt [repository]",
+	Short:             "Decrypt scan results encrypted with --encrypt-results",
+	Long:              "Decrypt the results, engine log and HTML report of the last scan of the repository (default: current directory), encrypted with 'privado scan --encrypt-results', with the private key of the recipient. The files are decrypted next to the encrypted files, or to --out",
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeDirectoryArgument,
+	Run:               decryptResults,
+}
+
+// Returns the owners of the files of the repository (see owners.Load),
+// none if they cannot be read
+func loadFindingOwners(repositoryPath string) *owners.Owners {
+	mappingFile := ""
+	if projectConfig, err := config.LoadProjectConfiguration(repositoryPath); err == nil {
+		mappingFile = projectConfig.Owners
+	}
+	findingOwners, err := owners.Load(repositoryPath, mappingFile)
+	if err != nil {
+		fmt.Println("[WARN]: Could not read the owners of the repository (CODEOWNERS):", err)
+		return &owners.Owners{}
+	}
+	return findingOwners
+}
+
+func listResults(cmd *cobra.Command, args []string) {
+	repository := "."
+	if len(args) > 0 {
+		repository = args[0]
+	}
+	repositoryPath := fileutils.GetAbsolutePath(repository)
+	owner, _ := cmd.Flags().GetString("owner")
+	severity, _ := cmd.Flags().GetString("severity")
+	format, _ := cmd.Flags().GetString("format")
+	if format != "table" && format != "json" {
+		exitWithError(clierrors.InvalidFlagValue.Errorf("Invalid format '%s' (table, json)", format))
+	}
+	if severity != "" && !results.IsValidSeverity(severity) {
+		exitWithError(clierrors.InvalidFlagValue.Errorf("Invalid value for --severity: %s (allowed: %s)", severity, strings.Join(results.Severities, ", ")))
+	}
+
+	scanResults, err := results.LoadResults(filepath.Join(repositoryPath, config.AppConfig.PrivacyResultsPathSuffix))
+	if err != nil {
+		exitWithError(clierrors.ResultsRead.Errorf("Cannot read scan results (run 'privado scan' first): %s", err))
+	}
+	findings, excluded := scanResults.Findings(), 0
+	if includeExcluded, _ := cmd.Flags().GetBool("include-excluded"); !includeExcluded {
+		findings, excluded = filterExcludedFindings(repositoryPath, findings)
+	}
+	loadFindingOwners(repositoryPath).Assign(findings)
+	if cmd.Flags().Changed("owner") {
+		findings = owners.FilterFindings(findings, owner)
+	}
+	if severity != "" {
+		findings = results.FilterFindingsAtOrAbove(findings, severity)
+	}
+
+	if format == "json" {
+		data, _ := json.MarshalIndent(findings, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+	if len(findings) == 0 {
+		exit("> No findings", false)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSEVERITY\tPOLICY\tLOCATION\tOWNERS")
+	for _, finding := range findings {
+		location := "-"
+		if finding.FileName != "" {
+			location = fmt.Sprintf("%s:%d", filepath.ToSlash(finding.RelativeFileName()), finding.LineNumber)
+		}
+		findingOwners := "-"
+		if len(finding.Owners) > 0 {
+			findingOwners = strings.Join(finding.Owners, " ")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", finding.Id, finding.Severity, finding.PolicyName, location, findingOwners)
+	}
+	w.Flush()
+	fmt.Printf("\n> %d finding(s)\n", len(findings))
+	if excluded > 0 {
+		fmt.Printf("> %d finding(s) excluded as false positives (--include-excluded to list them)\n", excluded)
+	}
+}
+
+func showResult(cmd *cobra.Command, args []string) {
+	repository := "."
+	if len(args) > 1 {
+		repository = args[1]
+	}
+	repositoryPath := fileutils.GetAbsolutePath(repository)
+	contextLines, _ := cmd.Flags().GetInt("context")
+
+	scanResults, err := results.LoadResults(filepath.Join(repositoryPath, config.AppConfig.PrivacyResultsPathSuffix))
+	if err != nil {
+		exitWithError(clierrors.ResultsRead.Errorf("Cannot read scan results (run 'privado scan' first): %s", err))
+	}
+	finding, ok := scanResults.FindFinding(args[0])
+	if !ok {
+		exitWithError(clierrors.InvalidArguments.Errorf("No finding with the id %s in the results (see 'privado results list')", args[0]))
+	}
+	loadFindingOwners(repositoryPath).Assign([]results.Finding{finding})
+
+	sourceNames, sinkNames := map[string]string{}, map[string]string{}
+	for _, source := range scanResults.Sources {
+		sourceNames[source.Id] = source.Name
+	}
+	for _, sink := range scanResults.Sinks {
+		sinkNames[sink.Id] = sink.Name
+	}
+	getName := func(names map[string]string, id string) string {
+		if name := names[id]; name != "" {
+			return name
+		}
+		return id
+	}
+
+	fmt.Printf("> [%s] %s\n", strings.ToUpper(finding.Severity), finding.PolicyName)
+	if finding.Description != "" {
+		fmt.Println(" ", finding.Description)
+	}
+	fmt.Println()
+	fmt.Println("  Finding id:  ", finding.Id)
+	fmt.Println("  Policy:      ", finding.PolicyId)
+	fmt.Println("  Data element:", getName(sourceNames, finding.SourceId))
+	if finding.SinkId != "" {
+		fmt.Println("  Recipient:   ", getName(sinkNames, finding.SinkId))
+	}
+	if len(finding.Owners) > 0 {
+		fmt.Println("  Owners:      ", strings.Join(finding.Owners, ", "))
+	}
+
+	path := scanResults.FindingPath(finding)
+	if len(path) == 0 {
+		exit("\n> The finding has no location in the code", false)
+	}
+	if finding.SinkId != "" {
+		fmt.Printf("\n> Dataflow path (%d step(s)):\n", len(path))
+	} else {
+		fmt.Println("\n> Location:")
+	}
+	for i, occurrence := range path {
+		step := fmt.Sprintf("step %d", i)
+		switch {
+		case finding.SinkId == "":
+			step = "processing"
+		case i == 0:
+			step = "source"
+		case i == len(path)-1:
+			step = "sink"
+		}
+		if i > 0 {
+			fmt.Println("      |")
+			fmt.Println("      v")
+		}
+		fmt.Printf("\n  [%s] %s:%d\n", step, filepath.ToSlash(occurrence.RelativeFileName()), occurrence.LineNumber)
+		printOccurrenceCode(repositoryPath, occurrence, contextLines)
+	}
+}
+
+// Prints the code of the occurrence with the lines around it from the local
+// repository, else (e.g. the file changed since the scan) its sample
+func printOccurrenceCode(repositoryPath string, occurrence results.Occurrence, contextLines int) {
+	lines, err := readFileLines(filepath.Join(repositoryPath, occurrence.RelativeFileName()))
+	line := occurrence.LineNumber
+	if err != nil || line < 1 || line > len(lines) || (occurrence.Sample != "" && !strings.Contains(lines[line-1], strings.TrimSpace(occurrence.Sample))) {
+		if occurrence.Sample != "" {
+			fmt.Printf("    %6d > %s\n", line, occurrence.Sample)
+		}
+		return
+	}
+	for n := line - contextLines; n <= line+contextLines; n++ {
+		if n < 1 || n > len(lines) {
+			continue
+		}
+		marker := "|"
+		if n == line {
+			marker = ">"
+		}
+		fmt.Printf("    %6d %s %s\n", n, marker, lines[n-1])
+	}
+}
+
+func readFileLines(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n"), nil
+}
+
+func decryptResults(cmd *cobra.Command, args []string) {
+	repository := "."
+	if len(args) > 0 {
+		repository = args[0]
+	}
+	repositoryPath := fileutils.GetAbsolutePath(repository)
+	keyPath, _ := cmd.Flags().GetString("key")
+	output, _ := cmd.Flags().GetString("out")
+	keep, _ := cmd.Flags().GetBool("keep-encrypted")
+	if keyPath == "" {
+		exitWithError(clierrors.ConflictingOptions.New("The private key to decrypt results with is required: --key <file>"))
+	}
+
+	identity, err := results.LoadIdentityKey(fileutils.GetAbsolutePath(keyPath))
+	if err != nil {
+		exitWithError(clierrors.ResultsEncrypt.Errorf("Cannot read the private key (--key): %s", err))
+	}
+	if output != "" {
+		output = fileutils.GetAbsolutePath(output)
+		if err := os.MkdirAll(output, os.ModePerm); err != nil {
+			exitWithError(clierrors.ResultsWrite.Errorf("Cannot create the output directory: %s", err))
+		}
+	}
+
+	decrypted := []string{}
+	for _, path := range getEncryptedScanOutputs(repositoryPath, filepath.Join(repositoryPath, getPrivadoDirectoryName(), "engine.log")) {
+		encryptedPath := path + results.EncryptedFileExtension
+		if exists, _ := fileutils.DoesFileExists(encryptedPath); !exists {
+			continue
+		}
+		target := path
+		if output != "" {
+			target = filepath.Join(output, filepath.Base(path))
+		}
+		if err := results.DecryptFile(encryptedPath, target, identity); err != nil {
+			exitWithError(clierrors.ResultsEncrypt.Errorf("Cannot decrypt %s: %s", encryptedPath, err))
+		}
+		// the encrypted file is kept when decrypting elsewhere
+		if output == "" && !keep {
+			os.Remove(encryptedPath)
+		}
+		decrypted = append(decrypted, target)
+	}
+	if len(decrypted) == 0 {
+		exitWithError(clierrors.ResultsRead.Errorf("No encrypted results found in %s", filepath.Join(repositoryPath, getPrivadoDirectoryName())))
+	}
+	exit(fmt.Sprintf("> Decrypted:\n  %s", strings.Join(decrypted, "\n  ")), false)
+}
+
+func init() {
+	resultsListCmd.Flags().String("owner", "", "List only the findings owned by the team or user, e.g. @payments-team (CODEOWNERS); empty for findings without owners")
+	resultsListCmd.Flags().String("severity", "", "List only the findings at or above the severity")
+	resultsListCmd.Flags().String("format", "table", "Output format: table or json")
+	resultsListCmd.Flags().Bool("include-excluded", false, "If specified, findings excluded as false positives (see 'privado feedback') are listed too")
+	_ = resultsListCmd.RegisterFlagCompletionFunc("severity", completeSeverities(false))
+	_ = resultsListCmd.RegisterFlagCompletionFunc("format", completeValues("table", "json"))
+	resultsShowCmd.Flags().Int("context", 2, "Lines of code shown before and after each step of the path")
+	resultsDecryptCmd.Flags().String("key", "", "PEM file with the RSA private key of the recipient the results were encrypted for")
+	resultsDecryptCmd.Flags().StringP("out", "o", "", "Directory the decrypted files are written to (default: next to the encrypted files, which are removed)")
+	resultsDecryptCmd.Flags().Bool("keep-encrypted", false, "If specified, the encrypted files are kept when decrypting next to them")
+	resultsCmd.AddCommand(resultsListCmd)
+	resultsCmd.AddCommand(resultsShowCmd)
+	resultsCmd.AddCommand(resultsDecryptCmd)
+	rootCmd.AddCommand(resultsCmd)
+}
@@ -26,29 +26,79 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"runtime/debug"
 	"strings"
 
 	// homedir "github.com/mitchellh/go-homedir"
 
 	"github.com/Privado-Inc/privado-cli/pkg/ci"
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
 	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/diagnostics"
+	"github.com/Privado-Inc/privado-cli/pkg/license"
+	"github.com/Privado-Inc/privado-cli/pkg/logs"
+	"github.com/Privado-Inc/privado-cli/pkg/progress"
 	"github.com/Privado-Inc/privado-cli/pkg/telemetry"
+	"github.com/Privado-Inc/privado-cli/pkg/tracing"
+	"github.com/Privado-Inc/privado-cli/pkg/utils"
 	"github.com/spf13/cobra"
 )
 
 var Version = "dev"
 
+// set at build time (ldflags), like Version
+var Commit = ""
+var BuildDate = ""
+
+// functions to run before the cli exits through exit()
+// used by commands to finalize state (e.g. write reports) on failure
+var exitHooks []func(isError bool)
+
+func registerExitHook(hook func(isError bool)) {
+	exitHooks = append(exitHooks, hook)
+}
+
+func runExitHooks(isError bool) {
+	hooks := exitHooks
+	exitHooks = nil
+	for _, hook := range hooks {
+		hook(isError)
+	}
+}
+
 var rootCmd = &cobra.Command{
 	Use:   "privado",
 	Short: "Privado is a CLI tool that scans & monitors your repositories to build privacy, transparency reports & finds privacy issues",
 	Long:  "Privado is a CLI tool that scans & monitors your repositories to build privacy, transparency reports & finds privacy issues. \nFind more at: https://github.com/Privado-Inc/privado",
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		tempDirectory, _ := cmd.Flags().GetString("temp-dir")
+		if _, err := config.ApplyTempDirectory(tempDirectory); err != nil {
+			fmt.Println("[WARN]: Cannot use temporary directory, using the system default:", err)
+		}
+		applyPromptAnswer(cmd)
+		startProfiling(cmd)
+	},
 }
 
-func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		exit(fmt.Sprintln(err), true)
+// Answers prompts without asking with --yes (yes) and --non-interactive
+// (the default, no), else as configured ('privado config prompts')
+func applyPromptAnswer(cmd *cobra.Command) {
+	yes, _ := cmd.Flags().GetBool("yes")
+	nonInteractive, _ := cmd.Flags().GetBool("non-interactive")
+
+	switch {
+	case yes:
+		utils.SetPromptAnswer(true)
+	case nonInteractive:
+		utils.SetPromptAnswer(false)
+	case config.UserConfig.ConfigFile.Prompts == config.PromptsYes:
+		utils.SetPromptAnswer(true)
+	case config.UserConfig.ConfigFile.Prompts == config.PromptsNonInteractive:
+		utils.SetPromptAnswer(false)
 	}
+}
 
+func Execute() {
 	defer func() {
 		// if panic occurred
 		if err := recover(); err != nil {
@@ -65,8 +115,64 @@ func Execute() {
 					telemetryPostRun(t)
 				}
 			}
+
+			handleCrash(err, debug.Stack())
+			flushTraces(fmt.Errorf("panic: %v", err))
+			logs.Stop()
+			os.Exit(1)
 		}
 	}()
+
+	rootSpan := tracing.StartRootSpan("privado")
+	rootSpan.SetAttribute("privado.version", Version)
+	if len(os.Args) > 1 {
+		rootSpan.SetAttribute("privado.command", os.Args[1])
+	}
+
+	registerPlugins()
+	if err := rootCmd.Execute(); err != nil {
+		exitWithError(clierrors.InvalidArguments.Wrap(err))
+	}
+	flushTraces(nil)
+	logs.Stop()
+}
+
+// writes a local crash report and uploads it when the user
+// has opted in, or agrees to when asked (interactive sessions only)
+func handleCrash(recovered interface{}, stack []byte) {
+	command := ""
+	if len(os.Args) > 1 {
+		command = os.Args[1]
+	}
+
+	report := diagnostics.NewCrashReport(Version, command, config.AppConfig.Container.ImageURL, recovered, stack, config.GetSanitizedUserConfiguration())
+	reportPath, err := report.Write(config.AppConfig.CrashReportsDirectory)
+
+	fmt.Println("\n> Privado CLI crashed unexpectedly:", recovered)
+	if err != nil {
+		fmt.Println("> Could not write crash report:", err)
+		fmt.Println(string(stack))
+		return
+	}
+	fmt.Println("> Crash report saved to:", reportPath)
+
+	upload := false
+	if consent := config.UserConfig.ConfigFile.UploadCrashReports; consent != nil {
+		upload = *consent
+	} else if !ci.CISessionConfig.IsCI {
+		fmt.Println("> The report contains the stack trace, versions and your (sanitized) cli settings. It does not contain any code or paths")
+		upload, _ = utils.ShowConsentPrompt("Share the crash report with Privado to help fix the issue?")
+	}
+
+	if upload {
+		if err := telemetry.PostCrashReport(config.GetTelemetryEndpoint(), config.UserConfig.DockerAccessHash, report.JSON()); err != nil {
+			fmt.Println("> Could not upload crash report:", err)
+		} else {
+			fmt.Println("> Crash report shared. Thank you!")
+		}
+	}
+
+	fmt.Println("> Please raise an issue at", config.AppConfig.PrivadoRepository, "and attach the crash report")
 }
 
 func telemetryPreRun(t *telemetry.Telemetry) {
@@ -87,16 +193,86 @@ func telemetryPostRun(t *telemetry.Telemetry) {
 		t = telemetry.DefaultInstance
 	}
 
-	t.PostRecordedTelemetry(telemetry.TelemetryRequestConfig{
-		Url:                   config.AppConfig.PrivadoTelemetryEndpoint,
+	// licensed offline installations never send telemetry
+	if activeLicense, _, err := license.Load(config.AppConfig.LicensePath); err == nil && activeLicense.HasFeature(license.FeatureOffline) {
+		return
+	}
+
+	span := tracing.StartSpan("telemetry-flush")
+	defer span.End(nil)
+
+	timeout, err := rootCmd.PersistentFlags().GetDuration("telemetry-timeout")
+	if err != nil || timeout <= 0 {
+		timeout = config.AppConfig.TelemetryTimeout
+	}
+
+	// a slow telemetry endpoint should not delay the exit
+	t.PostRecordedTelemetryWithTimeout(telemetry.TelemetryRequestConfig{
+		Url:                   config.GetTelemetryEndpoint(),
 		UserHash:              config.UserConfig.UserHash,
 		SessionId:             config.UserConfig.SessionId,
 		AuthenticationKeyHash: config.UserConfig.DockerAccessHash,
-	})
+		SpoolFilePath:         config.AppConfig.TelemetrySpoolFilePath,
+	}, timeout)
+}
+
+// ends the root span and exports the recorded spans (if tracing is configured)
+func flushTraces(err error) {
+	reportProfile()
+	if !tracing.IsEnabled() {
+		return
+	}
+	if err != nil {
+		tracing.StartSpan("error").End(err)
+	}
+	if exportErr := tracing.Flush(); exportErr != nil {
+		fmt.Println("[WARN]: Could not export traces:", exportErr)
+	}
+}
+
+func init() {
+	rootCmd.PersistentFlags().String("exit-codes", "", fmt.Sprintf("Exit code for each outcome, overriding the configuration; e.g. 'policy-violation=1,engine-error=2,infra-error=3' (outcomes: %s)", strings.Join(config.Outcomes, ", ")))
+	rootCmd.PersistentFlags().String("temp-dir", "", "Directory for temporary workspaces and files (default: configured with 'privado config temp-dir', else $TMPDIR)")
+	_ = rootCmd.RegisterFlagCompletionFunc("temp-dir", completeDirectory)
+	rootCmd.PersistentFlags().BoolP("yes", "y", false, "Answer yes to all confirmation prompts (e.g. overwriting existing results); consent prompts are declined")
+	rootCmd.PersistentFlags().Bool("non-interactive", false, "Never prompt: answer all prompts with their default (no). Without it, answers are read from stdin, also when it is not a terminal")
+	rootCmd.MarkFlagsMutuallyExclusive("yes", "non-interactive")
+	rootCmd.PersistentFlags().Duration("telemetry-timeout", config.AppConfig.TelemetryTimeout, "Maximum time to wait for telemetry to be sent before exiting; undelivered telemetry is retried on the next run")
+}
+
+// exits with the coded error: with the exit code configured for its
+// outcome class (see --exit-codes), or 1 if it has none
+func exitWithError(err *clierrors.Error) {
+	telemetry.DefaultInstance.RecordArrayMetric("errorCode", err.Code.Id)
+	progress.Error(err.Code.Id, err.Message)
+	if err.Code.Outcome == "" {
+		exit(err.Error(), true)
+	}
+	exitWithOutcome(err.Error(), err.Code.Outcome)
+}
+
+// exits with the code configured for the outcome class (see --exit-codes)
+func exitWithOutcome(msg string, outcome string) {
+	exitCodes, _ := rootCmd.PersistentFlags().GetString("exit-codes")
+	overrides, err := config.ParseExitCodeMapping(exitCodes)
+	if err != nil {
+		fmt.Println("[WARN]: Ignoring --exit-codes:", err)
+	}
+	terminate(msg, config.IsErrorOutcome(outcome), config.GetExitCode(outcome, overrides))
 }
 
 func exit(msg string, error bool) {
+	if error {
+		terminate(msg, error, 1)
+	} else {
+		terminate(msg, error, 0)
+	}
+}
+
+func terminate(msg string, error bool, exitCode int) {
 	fmt.Println(msg)
+	runExitHooks(error)
+
 	if error {
 		telemetry.DefaultInstance.RecordArrayMetric("error", msg)
 	}
@@ -106,8 +282,11 @@ func exit(msg string, error bool) {
 	}
 
 	if error {
-		os.Exit(1)
+		flushTraces(fmt.Errorf("%s", strings.TrimSpace(msg)))
 	} else {
-		os.Exit(0)
+		flushTraces(nil)
 	}
+
+	logs.Stop()
+	os.Exit(exitCode)
 }
@@ -0,0 +1,103 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Privado-Inc/privado-cli/pkg/apispec"
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+)
+
+// Loads the api specifications (--api-spec) and registers their fields as
+// data elements: returns the config directory to scan with (a temporary
+// copy of externalRules with the generated rules, removed on exit)
+func prepareAPISpecRules(repositoryPath string, specPaths []string, externalRules string) (string, []*apispec.Spec) {
+	specs := []*apispec.Spec{}
+	for _, specPath := range specPaths {
+		spec, err := apispec.Load(fileutils.GetAbsolutePath(specPath))
+		if err != nil {
+			exitWithError(clierrors.APISpecInvalid.Wrap(err))
+		}
+		if relativePath, err := filepath.Rel(repositoryPath, spec.Path); err == nil && !strings.HasPrefix(relativePath, "..") {
+			spec.Path = filepath.ToSlash(relativePath)
+		}
+		specs = append(specs, spec)
+	}
+
+	configDirectory, err := os.MkdirTemp("", "privado-rules-")
+	if err != nil {
+		exitWithError(clierrors.TempDirectoryCreation.Errorf("Cannot create directory for the rules of the api specifications: %s", err))
+	}
+	registerExitHook(func(isError bool) {
+		_ = os.RemoveAll(configDirectory)
+	})
+	if externalRules != "" {
+		if err := fileutils.CopyDirectory(externalRules, configDirectory, nil); err != nil {
+			exitWithError(clierrors.APISpecInvalid.Errorf("Cannot copy the config directory: %s", err))
+		}
+	}
+
+	fields := apispec.DataElementFields(specs)
+	if err := apispec.WriteRules(configDirectory, fields); err != nil {
+		exitWithError(clierrors.APISpecInvalid.Errorf("Cannot write rules for the api specifications: %s", err))
+	}
+
+	endpoints := 0
+	for _, spec := range specs {
+		endpoints += len(spec.Endpoints)
+	}
+	fmt.Printf("> Registered %d data element(s) of %d endpoint(s) from %d api specification(s)\n", len(fields), endpoints, len(specs))
+	return configDirectory, specs
+}
+
+// Adds the mapping of the endpoints to their data elements to the results
+// (apiEndpoints in privado.json) and reports how many were found in the code
+func reportAPIEndpoints(repositoryPath string, specs []*apispec.Spec) {
+	resultsPath := filepath.Join(repositoryPath, config.AppConfig.PrivacyResultsPathSuffix)
+	scanResults, err := results.LoadResults(resultsPath)
+	if err != nil {
+		fmt.Println("[WARN]: Could not map api endpoints to data elements:", err)
+		return
+	}
+	mappings := apispec.Map(specs, scanResults)
+
+	raw, err := results.LoadRawResults(resultsPath)
+	if err == nil {
+		raw["apiEndpoints"] = mappings
+		err = results.WriteRawResults(resultsPath, raw)
+	}
+	if err != nil {
+		fmt.Println("[WARN]: Could not add api endpoints to the results:", err)
+		return
+	}
+
+	found, total := apispec.CountFoundInCode(mappings)
+	fmt.Printf("> API endpoints: %d, mapped to %d data element(s); %d found in the code (apiEndpoints in %s)\n", len(mappings), total, found, config.AppConfig.PrivacyResultsPathSuffix)
+}
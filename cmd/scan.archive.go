@@ -0,0 +1,66 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/Privado-Inc/privado-cli/pkg/baseline"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/feedback"
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+	"github.com/spf13/cobra"
+)
+
+// Returns whether the results of each scan are kept (--keep-results, or
+// keepResults in the configuration of the repository)
+func isKeepResultsEnabled(cmd *cobra.Command, repositoryPath string) bool {
+	if keepResults, _ := cmd.Flags().GetBool("keep-results"); keepResults {
+		return true
+	}
+	projectConfig, err := config.LoadProjectConfiguration(repositoryPath)
+	return err == nil && projectConfig.KeepResults
+}
+
+// Keeps the results of the scan in their own directory (.privado/scans/<id>),
+// and removes the directories beyond the retention of the history
+func archiveScanResults(repositoryPath, commitId string) {
+	privadoDirectory := filepath.Join(repositoryPath, getPrivadoDirectoryName())
+	excludedNames := []string{config.ProjectConfigurationFileName, baseline.FileName, feedback.ExclusionsFileName}
+	scanDirectory, err := results.ArchiveScan(privadoDirectory, results.NewScanDirectoryId(time.Now(), commitId), excludedNames)
+	if err != nil {
+		fmt.Println("[WARN]: Could not keep the results of the scan:", err)
+		return
+	}
+	relativePath, _ := filepath.Rel(repositoryPath, scanDirectory)
+	fmt.Println("> Results of the scan kept in:", relativePath)
+
+	keepLast, maxAge := getRetention(config.AppConfig.HistoryRetention)
+	if removed, err := results.PruneScanDirectories(privadoDirectory, keepLast, maxAge, time.Now()); err != nil {
+		fmt.Println("[WARN]: Could not remove results of scans beyond the retention:", err)
+	} else if removed > 0 {
+		fmt.Printf("> Removed results of %d scan(s) beyond the retention\n", removed)
+	}
+}
@@ -0,0 +1,237 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/docker"
+	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+	"github.com/Privado-Inc/privado-cli/pkg/gitutils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// The checksum of the inputs of a scan (source files, external rules,
+// engine image and options) is stored with the results; when the inputs of
+// the next scan have the same checksum, the image is not pulled, the engine
+// is not run and the results of the last scan are reused (unless --force).
+// They are still synced, exported and notified like the results of a scan
+
+const scanInputsFileName = "scan-inputs.json"
+
+// flags that do not change the results of the scan, including the flags
+// inherited from the root command
+var scanChecksumExcludedFlags = map[string]bool{
+	"force":             true,
+	"yes":               true,
+	"non-interactive":   true,
+	"temp-dir":          true,
+	"exit-codes":        true,
+	"profile":           true,
+	"profile-output":    true,
+	"telemetry-timeout": true,
+	"skip-update-check": true,
+	"no-log-file":       true,
+	"skip-hooks":        true,
+	"sync":              true,
+	"no-sync":           true,
+	"full-sync":         true,
+	"upload":            true,
+	"skip-upload":       true,
+	"persistent-engine": true,
+	"engine-memory":     true,
+	"open":              true,
+	"debug":             true,
+	"debug-docker":      true,
+	"debug-artifacts":   true,
+	"stats":             true,
+	"hang-timeout":      true,
+	"progress-format":   true,
+	"progress-output":   true,
+	"metrics-file":      true,
+	"syslog":            true,
+	"engine-log":        true,
+	"overwrite":         true,
+	"keep-results":      true,
+	"export":            true,
+	"skip-disk-check":   true,
+	"commit":            true,
+	"branch":            true,
+	"build-id":          true,
+	"build-url":         true,
+}
+
+type scanInputs struct {
+	Checksum  string    `json:"checksum"`
+	ScannedAt time.Time `json:"scannedAt"`
+}
+
+func getScanInputsPath(repositoryPath string) string {
+	return filepath.Join(repositoryPath, getPrivadoDirectoryName(), scanInputsFileName)
+}
+
+// Returns the checksum of the inputs of the scan: the engine image, the
+// options (changed flags and engine arguments), the external rules, the
+// configuration of the repository and its source files. With git, these
+// are the tracked files (by their object ids) and the contents of modified
+// and untracked files not ignored by git, else (or with --include-ignored)
+// all files
+// The engine image is the local image, as last pulled
+func getScanInputsChecksum(cmd *cobra.Command, repositoryPath string, commandArgs []string, externalRules string, includeIgnored bool) (string, error) {
+	digest := docker.GetImageDigest(config.AppConfig.Container.ImageURL)
+	if digest == "" {
+		return "", errors.New("the digest of the engine image is not known")
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "cli %s\nimage %s\n", Version, digest)
+	for _, arg := range commandArgs {
+		fmt.Fprintf(h, "arg %s\n", arg)
+	}
+	flags := []string{}
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		if !scanChecksumExcludedFlags[flag.Name] {
+			flags = append(flags, fmt.Sprintf("flag %s=%s\n", flag.Name, flag.Value.String()))
+		}
+	})
+	sort.Strings(flags)
+	fmt.Fprint(h, strings.Join(flags, ""))
+
+	if externalRules != "" {
+		if err := hashDirectory(h, "rules", externalRules, nil); err != nil {
+			return "", err
+		}
+	}
+
+	// e.g. the dependency policies of the repository
+	if data, err := os.ReadFile(config.GetProjectConfigurationPath(repositoryPath)); err == nil {
+		fmt.Fprintf(h, "project-config %x\n", sha256.Sum256(data))
+	}
+
+	privadoDirectory := getPrivadoDirectoryName()
+	if !gitutils.IsRepository(repositoryPath) || includeIgnored {
+		err := hashDirectory(h, "source", repositoryPath, func(relativePath string) bool {
+			return relativePath == ".git" || relativePath == privadoDirectory
+		})
+		return hex.EncodeToString(h.Sum(nil)), err
+	}
+
+	entries, err := gitutils.GetIndexEntries(repositoryPath)
+	if err != nil {
+		return "", err
+	}
+	for _, entry := range entries {
+		fmt.Fprintf(h, "tracked %s\n", entry)
+	}
+	changes, err := gitutils.GetWorktreeChanges(repositoryPath)
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(changes)
+	for i, relativePath := range changes {
+		// deleted files are listed as modified and untracked files may be listed again
+		if (i > 0 && changes[i-1] == relativePath) || strings.HasPrefix(relativePath, filepath.ToSlash(privadoDirectory)+"/") {
+			continue
+		}
+		if err := hashFile(h, "changed "+relativePath, filepath.Join(repositoryPath, filepath.FromSlash(relativePath))); err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Hashes the paths and contents of the files of the directory, except the
+// paths (relative to the directory) skip returns true for
+func hashDirectory(h hash.Hash, label, directory string, skip func(relativePath string) bool) error {
+	return filepath.WalkDir(directory, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relativePath, err := filepath.Rel(directory, path)
+		if err != nil {
+			return err
+		}
+		if skip != nil && relativePath != "." && skip(relativePath) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		return hashFile(h, fmt.Sprintf("%s %s", label, filepath.ToSlash(relativePath)), path)
+	})
+}
+
+func hashFile(h hash.Hash, label, path string) error {
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		fmt.Fprintf(h, "%s deleted\n", label)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	fmt.Fprintf(h, "%s\n", label)
+	_, err = io.Copy(h, file)
+	return err
+}
+
+// Returns whether the inputs of the last scan of the repository had the
+// checksum, and its results are still present
+func isScanUnchanged(repositoryPath, checksum string) (bool, time.Time) {
+	data, err := os.ReadFile(getScanInputsPath(repositoryPath))
+	if err != nil {
+		return false, time.Time{}
+	}
+	inputs := scanInputs{}
+	if err := json.Unmarshal(data, &inputs); err != nil || inputs.Checksum != checksum {
+		return false, time.Time{}
+	}
+	if exists, _ := fileutils.DoesFileExists(filepath.Join(repositoryPath, config.AppConfig.PrivacyResultsPathSuffix)); !exists {
+		return false, time.Time{}
+	}
+	return true, inputs.ScannedAt
+}
+
+func writeScanInputs(repositoryPath, checksum string) {
+	data, _ := json.MarshalIndent(scanInputs{Checksum: checksum, ScannedAt: time.Now().UTC()}, "", "  ")
+	if err := os.WriteFile(getScanInputsPath(repositoryPath), data, 0644); err != nil {
+		fmt.Println("[WARN]: Could not write the checksum of the scan inputs:", err)
+	}
+}
@@ -0,0 +1,100 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+)
+
+// below this percentage of analyzed source files, a scan without
+// findings is reported as inconclusive
+const lowCoveragePercentage = 50
+
+// Computes the coverage of the scan, writes it next to the results
+// (.privado/coverage.json) and prints it
+func reportScanCoverage(repositoryPath string, excludedPaths []string, warnings *engineWarnings, javascriptEnabled bool) {
+	analyzedLanguages := append([]string{}, results.DefaultAnalyzedLanguages...)
+	if javascriptEnabled {
+		analyzedLanguages = append(analyzedLanguages, "javascript", "typescript")
+	}
+	unparseableFiles := results.FindFilesInOutput(repositoryPath, config.AppConfig.Container.SourceCodeVolumeDir, warnings.getLines("parse errors", "skipped files"))
+
+	coverage, err := results.ComputeCoverage(repositoryPath, analyzedLanguages, excludedPaths, unparseableFiles)
+	if err != nil {
+		fmt.Println("[WARN]: Could not compute the coverage of the scan:", err)
+		return
+	}
+	if err := coverage.Write(filepath.Join(repositoryPath, getPrivadoDirectoryName(), results.CoverageFileName)); err != nil {
+		fmt.Println("[WARN]: Could not write the coverage of the scan:", err)
+	}
+	fmt.Println(formatCoverage(coverage))
+}
+
+func formatCoverage(coverage *results.Coverage) string {
+	if coverage.SourceFiles == 0 {
+		return "> Coverage: no source files found"
+	}
+
+	languages := []string{}
+	unsupported := []string{}
+	for _, language := range coverage.Languages {
+		if !language.Analyzed {
+			unsupported = append(unsupported, language.Language)
+			continue
+		}
+		languages = append(languages, fmt.Sprintf("%s: %d of %d", language.Language, language.Files-language.Excluded-language.Unparseable, language.Files))
+	}
+
+	line := fmt.Sprintf("> Coverage: %.1f%% of %d source file(s) analyzed", coverage.Percentage, coverage.SourceFiles)
+	if len(languages) > 0 {
+		line += fmt.Sprintf(" (%s)", strings.Join(languages, ", "))
+	}
+	skipped := []string{}
+	if coverage.ExcludedFiles > 0 {
+		skipped = append(skipped, fmt.Sprintf("%d excluded", coverage.ExcludedFiles))
+	}
+	if coverage.UnparseableFiles > 0 {
+		skipped = append(skipped, fmt.Sprintf("%d unparseable", coverage.UnparseableFiles))
+	}
+	if coverage.UnsupportedFiles > 0 {
+		skipped = append(skipped, fmt.Sprintf("%d in unsupported languages (%s)", coverage.UnsupportedFiles, strings.Join(unsupported, ", ")))
+	}
+	if len(skipped) > 0 {
+		line += fmt.Sprintf("; skipped: %s", strings.Join(skipped, ", "))
+	}
+	return line
+}
+
+// Loads the coverage of the last scan of the repository, nil if unavailable
+func loadScanCoverage(repositoryPath string) *results.Coverage {
+	coverage, err := results.LoadCoverage(filepath.Join(repositoryPath, getPrivadoDirectoryName(), results.CoverageFileName))
+	if err != nil {
+		return nil
+	}
+	return coverage
+}
@@ -0,0 +1,101 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/dbschema"
+	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+)
+
+// Loads the database schemas (--db-schema [storage=]path), to map storage
+// sinks to the tables and columns holding personal data after the scan
+func loadDatabaseSchemas(repositoryPath string, values []string) []dbschema.AttributedSchema {
+	schemas := []dbschema.AttributedSchema{}
+	for _, value := range values {
+		storage, schemaPath := "", value
+		if index := strings.Index(value, "="); index > 0 && !strings.ContainsAny(value[:index], `/\`) {
+			storage, schemaPath = value[:index], value[index+1:]
+		}
+
+		schema, err := dbschema.Load(fileutils.GetAbsolutePath(schemaPath), "")
+		if err != nil {
+			exitWithError(clierrors.DBSchemaInvalid.Wrap(err))
+		}
+		if relativePath, err := filepath.Rel(repositoryPath, schema.Path); err == nil && !strings.HasPrefix(relativePath, "..") {
+			schema.Path = filepath.ToSlash(relativePath)
+		}
+		schemas = append(schemas, dbschema.AttributedSchema{Schema: schema, Storage: storage})
+	}
+	return schemas
+}
+
+// Adds the storage inventory to the results (storageInventory in
+// privado.json) and prints it
+func reportStorageInventory(repositoryPath string, schemas []dbschema.AttributedSchema) {
+	resultsPath := filepath.Join(repositoryPath, config.AppConfig.PrivacyResultsPathSuffix)
+	scanResults, err := results.LoadResults(resultsPath)
+	if err != nil {
+		fmt.Println("[WARN]: Could not build the storage inventory:", err)
+		return
+	}
+	inventories := dbschema.BuildInventory(schemas, scanResults)
+
+	raw, err := results.LoadRawResults(resultsPath)
+	if err == nil {
+		raw["storageInventory"] = inventories
+		err = results.WriteRawResults(resultsPath, raw)
+	}
+	if err != nil {
+		fmt.Println("[WARN]: Could not add the storage inventory to the results:", err)
+		return
+	}
+
+	tables, columns := dbschema.CountPersonalData(inventories)
+	fmt.Printf("> Storage inventory: %d table(s), %d column(s) with personal data (storageInventory in %s)\n", tables, columns, config.AppConfig.PrivacyResultsPathSuffix)
+	for _, inventory := range inventories {
+		storage := inventory.Storage
+		switch {
+		case inventory.StorageId != "":
+			storage = fmt.Sprintf("%s (%s)", inventory.Storage, inventory.StorageId)
+		case storage != "":
+			storage = fmt.Sprintf("%s (not found by the scan)", storage)
+		default:
+			storage = "Unattributed (use --db-schema <storage>=<schema> to attribute it to a storage)"
+		}
+		fmt.Println(" ", storage)
+		for _, table := range inventory.Tables {
+			columnNames := []string{}
+			for _, column := range table.Columns {
+				columnNames = append(columnNames, fmt.Sprintf("%s (%s)", column.Column, column.DataElement))
+			}
+			fmt.Printf("    %s: %s\n", table.Table, strings.Join(columnNames, ", "))
+		}
+	}
+}
@@ -0,0 +1,70 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+package cmd
+
+import (
+	"crypto/rsa"
+	"fmt"
+	"path/filepath"
+
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+)
+
+// Outputs of a scan in the repository that are encrypted at rest
+// (--encrypt-results): results, the engine log and the HTML report
+func getEncryptedScanOutputs(repositoryPath, engineLogPath string) []string {
+	outputs := []string{
+		filepath.Join(repositoryPath, config.AppConfig.PrivacyResultsPathSuffix),
+		filepath.Join(repositoryPath, getPrivadoDirectoryName(), "privado-report"+reportFormatExtensions["html"]),
+	}
+	if engineLogPath != "" {
+		outputs = append(outputs, engineLogPath)
+	}
+	return outputs
+}
+
+func loadResultsRecipientKey(path string) *rsa.PublicKey {
+	recipient, err := results.LoadRecipientKey(fileutils.GetAbsolutePath(path))
+	if err != nil {
+		exitWithError(clierrors.ResultsEncrypt.Errorf("Cannot read the public key to encrypt results with (--encrypt-results): %s", err))
+	}
+	return recipient
+}
+
+// Encrypts the outputs of the scan for the recipient and removes them in
+// plaintext. Results must not be left readable, so a failure fails the scan
+func encryptScanOutputs(repositoryPath, engineLogPath string, recipient *rsa.PublicKey) {
+	encrypted := 0
+	for _, output := range getEncryptedScanOutputs(repositoryPath, engineLogPath) {
+		if exists, _ := fileutils.DoesFileExists(output); !exists {
+			continue
+		}
+		if err := results.EncryptFile(output, recipient); err != nil {
+			exitWithError(clierrors.ResultsEncrypt.Errorf("Cannot encrypt %s: %s", output, err))
+		}
+		encrypted++
+	}
+	fmt.Printf("> Encrypted %d scan output(s) in %s (see 'privado results decrypt')\n", encrypted, filepath.Join(repositoryPath, getPrivadoDirectoryName()))
+}
@@ -24,24 +24,55 @@
 package cmd
 
 import (
+	"crypto/rsa"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/Privado-Inc/privado-cli/pkg/apispec"
+	"github.com/Privado-Inc/privado-cli/pkg/auth"
 	"github.com/Privado-Inc/privado-cli/pkg/ci"
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
 	"github.com/Privado-Inc/privado-cli/pkg/config"
 	"github.com/Privado-Inc/privado-cli/pkg/docker"
 	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+	"github.com/Privado-Inc/privado-cli/pkg/gitutils"
+	"github.com/Privado-Inc/privado-cli/pkg/license"
+	"github.com/Privado-Inc/privado-cli/pkg/metrics"
+	"github.com/Privado-Inc/privado-cli/pkg/progress"
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+	"github.com/Privado-Inc/privado-cli/pkg/tracing"
 	"github.com/Privado-Inc/privado-cli/pkg/utils"
 	"github.com/spf13/cobra"
 )
 
+// log levels of privado-core (--engine-log-level)
+var engineLogLevels = []string{"error", "warn", "info", "debug", "trace"}
+
+// what is opened in the browser after a scan (--open)
+const (
+	openLocalReport  = "local"
+	openCloudResults = "cloud"
+	openNothing      = "none"
+)
+
+func isEngineLogLevel(level string) bool {
+	for _, engineLogLevel := range engineLogLevels {
+		if strings.EqualFold(level, engineLogLevel) {
+			return true
+		}
+	}
+	return false
+}
+
 var scanCmd = &cobra.Command{
-	Use:   "scan <repository>",
-	Short: "Scan a codebase or repository to identify privacy issues and generate compliance reports",
-	Args:  cobra.ExactArgs(1),
+	Use:               "scan <repository>",
+	Short:             "Scan a codebase or repository to identify privacy issues and generate compliance reports",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeDirectoryArgument,
 	PreRun: func(cmd *cobra.Command, args []string) {
 		telemetryPreRun(nil)
 	},
@@ -52,39 +83,124 @@ var scanCmd = &cobra.Command{
 }
 
 func defineScanFlags(cmd *cobra.Command) {
-	scanCmd.Flags().StringP("config", "c", "", "Specifies the config (with rules) directory to be passed to privado-core for scanning. These external rules and configurations are merged with the default set that Privado defines")
-	scanCmd.Flags().BoolP("ignore-default-rules", "i", false, "If specified, the default rules are ignored and only the specified rule configurations (-c) are considered")
-	scanCmd.Flags().Bool("skip-dependency-download", false, "When specified, the engine skips downloading all locally unavailable dependencies. Skipping dependency download can yield incomplete results")
-	scanCmd.Flags().Bool("disable-deduplication", false, "When specified, the engine does not remove duplicate and subset dataflows. This option is useful if you wish to review all flows (including duplicates) manually")
-
-	scanCmd.Flags().Bool("upload", false, "If specified, will automatically attempt to upload the scan result to Privado Dashboard")
-	scanCmd.Flags().Bool("skip-upload", false, "If specified, the result artifacts will not be uploaded to Privado Dashboard")
-	scanCmd.MarkFlagsMutuallyExclusive("upload", "skip-upload")
-
-	scanCmd.Flags().Bool("overwrite", false, "If specified, the warning prompt for existing scan results is disabled and any existing results are overwritten")
-	scanCmd.Flags().Bool("debug", false, "Enables privado-core image output in debug mode")
-	scanCmd.Flags().String("jvm-args", "", "Specifies the JVM arguments to be passed to the scan engine; sets the 'JAVA_TOOL_OPTIONS' environment variable")
-	scanCmd.Flags().Bool("enable-experiments", false, "Flag to enable experimental features")
-	scanCmd.Flags().Bool("enable-javascript", false, "Experimental: When specified, enables the beta code scanner for javascript. Use with '--enable-experiments'")
-	scanCmd.Flags().Bool("disable-runtime-semantics", false, "Experimental: If specified, the semantics engine won't generate semantic at runtime")
-	scanCmd.Flags().Bool("disable-this-filtering", false, "Experimental: If specified, filtering of flow using 'this filtering algorithm' will be avoided")
-	scanCmd.Flags().Bool("disable-flow-separation-by-data-element", false, "Experimental: If specified, filtering of flow using 'flow separation by data element algorithm' will be avoided")
-	scanCmd.Flags().Bool("disable-2nd-level-closure", false, "Experimental: If specified, 2nd level source derivation will be turned on")
-	scanCmd.Flags().Bool("disable-read-dataflow", false, "Experimental: If specified, read dataflow will be skipped")
-	scanCmd.Flags().Bool("enable-api-display", false, "Experimental: If specified, API display without domain for brute API tagger will be turned on")
-	scanCmd.Flags().Bool("generate-unresolved-name-report", false, "Flag to enable generation unresolved method name reports")
-	scanCmd.Flags().Bool("generate-unfiltered-report", false, "If specified, additionally generates an unfiltered flow report")
-	scanCmd.Flags().Bool("generate-audit-report", false, "If specified, audit report will be generated")
-	scanCmd.Flags().Bool("enable-audit-semantic", false, "Flag to enable semantic filtering in audit report")
-	scanCmd.Flags().Bool("enable-lambda-flows", false, "Flag to enable lambda flows")
-	scanCmd.Flags().Bool("monolith", false, "Flag to divide a monolith repo into subProjects")
+	cmd.Flags().StringP("config", "c", "", "Specifies the config (with rules) directory to be passed to privado-core for scanning. These external rules and configurations are merged with the default set that Privado defines")
+	cmd.Flags().BoolP("ignore-default-rules", "i", false, "If specified, the default rules are ignored and only the specified rule configurations (-c) are considered")
+	cmd.Flags().Bool("skip-dependency-download", false, "When specified, the engine skips downloading all locally unavailable dependencies. Skipping dependency download can yield incomplete results. Overrides the per package manager policies of the repository (dependencies in .privado/config.json): =false downloads all")
+	cmd.Flags().Bool("disable-deduplication", false, "When specified, the engine does not remove duplicate and subset dataflows. This option is useful if you wish to review all flows (including duplicates) manually")
+
+	cmd.Flags().Bool("upload", false, "If specified, will automatically attempt to upload the scan result to Privado Dashboard")
+	cmd.Flags().Bool("skip-upload", false, "If specified, the result artifacts will not be uploaded to Privado Dashboard")
+	cmd.MarkFlagsMutuallyExclusive("upload", "skip-upload")
+	cmd.Flags().Bool("sync", false, "If specified, results of this scan are synced to Privado Cloud regardless of the sync configuration")
+	cmd.Flags().Bool("no-sync", false, "If specified, results of this scan are not synced to Privado Cloud regardless of the sync configuration")
+	cmd.MarkFlagsMutuallyExclusive("sync", "no-sync")
+	cmd.Flags().Bool("full-sync", false, "If specified, all results are synced instead of the changes since the last synced scan of the repository")
+
+	cmd.Flags().Bool("skip-update-check", false, "If specified, does not check for a newer version of Privado CLI before scanning")
+	cmd.Flags().StringSlice("open", []string{openCloudResults}, "What is opened in the browser after the scan: the results on Privado Cloud (cloud), a local HTML report of the results (local, written to <repository>/.privado/privado-report.html), both (local,cloud) or nothing (none)")
+	cmd.Flags().Bool("force", false, "If specified, the repository is scanned even if nothing changed since the last scan (source files, rules, engine image and options); by default its results are reused")
+	cmd.Flags().Bool("overwrite", false, "If specified, the warning prompt for existing scan results is disabled and any existing results are overwritten")
+	cmd.Flags().String("encrypt-results", "", "PEM file with the RSA public key of the recipient the results, engine log and HTML report in .privado are encrypted for (<file>.enc); decrypt them with the private key: 'privado results decrypt'. No plaintext copies are kept: the output is not written to a scan log, and all results are synced instead of the changes")
+	cmd.Flags().Bool("keep-results", false, "If specified, the results of each scan are also kept in .privado/scans/<time>-<commit> (with a 'latest' pointer), so existing results are never lost and no prompt is shown (default: keepResults in .privado/config.json)")
+	cmd.Flags().Bool("debug", false, "Enables privado-core image output in debug mode")
+	cmd.Flags().String("debug-artifacts", "", "Directory the debug artifacts of the engine (code property graph, logs and intermediate results) are copied to after a scan with --debug (default: <repository>/.privado/debug/<time>)")
+	cmd.Flags().String("commit", "", "Commit recorded in the results and synced to Privado Cloud (default: detected from git)")
+	cmd.Flags().String("branch", "", "Branch recorded in the results and synced to Privado Cloud (default: detected from the CI environment, else git)")
+	cmd.Flags().String("build-id", "", "CI build id recorded in the results and synced to Privado Cloud (default: detected from the CI environment)")
+	cmd.Flags().String("build-url", "", "CI build (pipeline) url recorded in the results and synced to Privado Cloud (default: detected from the CI environment)")
+	cmd.Flags().String("engine-log-level", "", "Log level of privado-core: error, warn, info, debug or trace (default: the engine default)")
+	cmd.Flags().String("engine-log", "", "File the full privado-core output (including its logs) is written to; only progress is shown in the terminal unless --debug is specified (default: <repository>/.privado/engine.log)")
+	cmd.Flags().Bool("include-ignored", false, "If specified, directories ignored by git (.gitignore) are scanned as well; by default they are excluded from the scan")
+	cmd.Flags().Bool("recurse-submodules", false, "If specified, git submodules are initialized and updated before scanning, so they are scanned as well")
+	cmd.Flags().String("additional-roots", additionalRootsAsk, "Directories outside of the repository it references (go.work, relative path dependencies) are mounted for the scan after confirmation (ask), always (mount) or never (skip)")
+	cmd.Flags().StringArray("exclude-path", nil, "Directory (relative to the repository) excluded from the scan, e.g. a module scanned separately (repeatable)")
+	cmd.Flags().Bool("include-vendored", false, "If specified, vendored dependencies (vendor/, node_modules/) and generated code (protobuf, openapi) are scanned as well; by default they are excluded from the scan (patterns: 'vendored' in .privado/config.json)")
+	cmd.Flags().Bool("copy-source", false, "If specified, the repository is copied to a local temporary directory and scanned from there. Recommended for repositories on network or cloud-synced filesystems")
+	cmd.Flags().Bool("follow-symlinks", false, "If specified, targets of symbolic links are scanned (including targets outside of the repository, cycles are skipped). Implies --copy-source")
+	cmd.Flags().Bool("no-follow-symlinks", false, "If specified, symbolic links are excluded from the scan. Implies --copy-source")
+	cmd.MarkFlagsMutuallyExclusive("follow-symlinks", "no-follow-symlinks")
+	cmd.Flags().String("max-file-size", "50MB", "Files larger than the size (e.g. 10MB, 1GB) are excluded from the scan; 0 to scan files of any size")
+	cmd.Flags().Bool("include-binary-files", false, "If specified, binary files are scanned as well; by default they are excluded from the scan (except dependency archives, e.g. .jar)")
+	cmd.Flags().Bool("skip-disk-check", false, "If specified, does not check for enough free disk space before scanning")
+	cmd.Flags().Bool("no-log-file", false, "If specified, the output of the scan is not written to a log file (see 'privado logs')")
+	cmd.Flags().Bool("skip-hooks", false, "If specified, the pre-scan and post-scan hooks of the repository (hooks in .privado/config.json) are not run")
+	cmd.Flags().Bool("export", false, "If specified, the findings are exported to the destinations configured in the configuration file (see 'privado export')")
+	cmd.Flags().String("syslog", "", "Send scan events (phases, finding summary, warnings and errors) to the syslog target (RFC5424): udp://host:port, tcp://host:port or unix:///dev/log, optionally with ?facility=local0 (default: 'syslog' in the configuration file)")
+	cmd.Flags().StringArray("api-spec", nil, "OpenAPI/Swagger (yaml, json) or GraphQL (.graphql) specification of the api of the repository; its request and response fields are registered as data elements and endpoints are mapped to them in the results (repeatable)")
+	cmd.Flags().StringArray("db-schema", nil, "Database schema (SQL DDL, .prisma or liquibase changelog) of a storage, optionally prefixed with the storage sink (e.g. postgres=schema.sql); tables and columns with personal data are added to the results as the storage inventory (repeatable)")
+	cmd.Flags().String("sbom", "", "CycloneDX or SPDX SBOM (json, xml, tag-value) of the repository; third parties found by the scan are matched to its components, and their versions and suppliers are added to the results")
+	cmd.Flags().Bool("skip-iac", false, "If specified, infrastructure files (terraform, cloudformation, kubernetes manifests, docker-compose) are not scanned for data stores and third-party services")
+	cmd.Flags().Bool("strict", false, "If specified, the scan fails when the engine reports dependency resolution failures, parse errors or skipped files, with a summary of what was not scanned")
+	cmd.Flags().Bool("persistent-engine", false, "Experimental: If specified, the scan is run in the persistent engine container (see 'privado engine start') instead of a new container, if possible")
+	cmd.Flags().Bool("debug-docker", false, "If specified, every docker api call (image pull, container create, start, wait), the resolved mounts and their timings are logged")
+	cmd.Flags().Bool("stats", false, "If specified, the cpu and memory usage of the engine and the elapsed time are shown every 15 seconds during the scan")
+	cmd.Flags().Duration("hang-timeout", 20*time.Minute, "If the engine produces no output for this long, a thread dump of the engine is saved (to ~/.privado/diagnostics) to report the hang; 0 to disable")
+	cmd.Flags().String("engine-memory", "", "Memory limit of the engine container, e.g. 16GB (default: no limit, the memory of docker)")
+	cmd.Flags().String("jvm-args", "", "Specifies the JVM arguments to be passed to the scan engine; sets the 'JAVA_TOOL_OPTIONS' environment variable")
+	cmd.Flags().Bool("enable-experiments", false, "Flag to enable experimental features")
+	cmd.Flags().Bool("enable-javascript", false, "Experimental: When specified, enables the beta code scanner for javascript. Use with '--enable-experiments'")
+	cmd.Flags().Bool("disable-runtime-semantics", false, "Experimental: If specified, the semantics engine won't generate semantic at runtime")
+	cmd.Flags().Bool("disable-this-filtering", false, "Experimental: If specified, filtering of flow using 'this filtering algorithm' will be avoided")
+	cmd.Flags().Bool("disable-flow-separation-by-data-element", false, "Experimental: If specified, filtering of flow using 'flow separation by data element algorithm' will be avoided")
+	cmd.Flags().Bool("disable-2nd-level-closure", false, "Experimental: If specified, 2nd level source derivation will be turned on")
+	cmd.Flags().Bool("disable-read-dataflow", false, "Experimental: If specified, read dataflow will be skipped")
+	cmd.Flags().Bool("enable-api-display", false, "Experimental: If specified, API display without domain for brute API tagger will be turned on")
+	cmd.Flags().Bool("generate-unresolved-name-report", false, "Flag to enable generation unresolved method name reports")
+	cmd.Flags().Bool("generate-unfiltered-report", false, "If specified, additionally generates an unfiltered flow report")
+	cmd.Flags().Bool("generate-audit-report", false, "If specified, audit report will be generated")
+	cmd.Flags().Bool("enable-audit-semantic", false, "Flag to enable semantic filtering in audit report")
+	cmd.Flags().Bool("enable-lambda-flows", false, "Flag to enable lambda flows")
+	cmd.Flags().Bool("monolith", false, "Flag to divide a monolith repo into subProjects")
+
+	cmd.Flags().Int("parallel", 1, "Scan the modules of a multi-module repository with up to <n> scans (engine containers) in parallel and combine their results; each scan needs the memory of a full scan")
+	cmd.Flags().String("shard", "", "Scan only the modules assigned to the shard '<index>/<total>' (e.g. 2/5) to distribute a scan across parallel workers. Combine shard results with 'privado merge'")
+	cmd.Flags().String("progress-format", "text", "Format of progress reporting: 'text' (default) or 'ndjson' to additionally emit structured progress events")
+	cmd.Flags().String("progress-output", "stderr", "Destination of ndjson progress events: stdout, stderr, fd:<n> or a file path")
+	cmd.Flags().String("metrics-file", "", "If specified, writes a metrics snapshot of the scan (prometheus textfile collector format) to the file")
+
+	_ = cmd.RegisterFlagCompletionFunc("config", completeDirectory)
+	_ = cmd.RegisterFlagCompletionFunc("debug-artifacts", completeDirectory)
+	_ = cmd.RegisterFlagCompletionFunc("progress-format", completeValues("text", "ndjson"))
+	_ = cmd.RegisterFlagCompletionFunc("progress-output", completeValues("stdout", "stderr"))
+	_ = cmd.RegisterFlagCompletionFunc("additional-roots", completeValues(additionalRootsAsk, additionalRootsMount, additionalRootsSkip))
+	_ = cmd.RegisterFlagCompletionFunc("engine-log-level", completeValues(engineLogLevels...))
+	_ = cmd.RegisterFlagCompletionFunc("open", completeValues(openLocalReport, openCloudResults, openNothing))
 }
 
 func scan(cmd *cobra.Command, args []string) {
+	scanStartTime := time.Now()
 	repository := args[0]
+	if shardFlag, _ := cmd.Flags().GetString("shard"); shardFlag != "" {
+		if encryptResults, _ := cmd.Flags().GetString("encrypt-results"); encryptResults != "" {
+			exitWithError(clierrors.ConflictingOptions.New("--encrypt-results cannot be used with --shard"))
+		}
+		shardScan(cmd, repository, shardFlag)
+		return
+	}
+	parallel, _ := cmd.Flags().GetInt("parallel")
+	if parallel < 1 {
+		exitWithError(clierrors.InvalidFlagValue.Errorf("Invalid value for --parallel: %d (must be at least 1)", parallel))
+	}
+	// modules scanned in parallel instead of the repository (--parallel),
+	// their combined results are processed as the results of the scan
+	var parallelModules []string
+	if parallel > 1 {
+		parallelModules = getParallelScanModules(fileutils.GetAbsolutePath(repository))
+	}
 	debug, _ := cmd.Flags().GetBool("debug")
+	engineLogLevel, _ := cmd.Flags().GetString("engine-log-level")
+	engineLogPath, _ := cmd.Flags().GetString("engine-log")
 	overwriteResults, _ := cmd.Flags().GetBool("overwrite")
-	skipDependencyDownload, _ := cmd.Flags().GetBool("skip-dependency-download")
+	force, _ := cmd.Flags().GetBool("force")
+	openLocal, openCloud := getOpenFlag(cmd)
+	var resultsRecipient *rsa.PublicKey
+	if encryptResults, _ := cmd.Flags().GetString("encrypt-results"); encryptResults != "" {
+		if openLocal {
+			exitWithError(clierrors.ConflictingOptions.New("--encrypt-results cannot be used with --open local: the report is encrypted"))
+		}
+		resultsRecipient = loadResultsRecipientKey(encryptResults)
+	}
+	skipUpdateCheck, _ := cmd.Flags().GetBool("skip-update-check")
 	disableDeduplication, _ := cmd.Flags().GetBool("disable-deduplication")
 	explicitUpload, _ := cmd.Flags().GetBool("upload")
 	explicitSkipUpload, _ := cmd.Flags().GetBool("skip-upload")
@@ -103,26 +219,145 @@ func scan(cmd *cobra.Command, args []string) {
 	enableAuditSemantic, _ := cmd.Flags().GetBool("enable-audit-semantic")
 	enableLambdaFlows, _ := cmd.Flags().GetBool("enable-lambda-flows")
 	isMonolith, _ := cmd.Flags().GetBool("monolith")
+	metricsFile, _ := cmd.Flags().GetString("metrics-file")
+	progressFormat, _ := cmd.Flags().GetString("progress-format")
+	progressOutput, _ := cmd.Flags().GetString("progress-output")
+	explicitSync, _ := cmd.Flags().GetBool("sync")
+	explicitNoSync, _ := cmd.Flags().GetBool("no-sync")
+	fullSync, _ := cmd.Flags().GetBool("full-sync")
+	includeIgnored, _ := cmd.Flags().GetBool("include-ignored")
+	includeVendored, _ := cmd.Flags().GetBool("include-vendored")
+	excludePaths, _ := cmd.Flags().GetStringArray("exclude-path")
+	recurseSubmodules, _ := cmd.Flags().GetBool("recurse-submodules")
+	additionalRootsMode, _ := cmd.Flags().GetString("additional-roots")
+	copySource, _ := cmd.Flags().GetBool("copy-source")
+	followSymlinks, _ := cmd.Flags().GetBool("follow-symlinks")
+	noFollowSymlinks, _ := cmd.Flags().GetBool("no-follow-symlinks")
+	maxFileSizeFlag, _ := cmd.Flags().GetString("max-file-size")
+	includeBinaryFiles, _ := cmd.Flags().GetBool("include-binary-files")
+	skipDiskCheck, _ := cmd.Flags().GetBool("skip-disk-check")
+	skipHooks, _ := cmd.Flags().GetBool("skip-hooks")
+	noLogFile, _ := cmd.Flags().GetBool("no-log-file")
+	debugDocker, _ := cmd.Flags().GetBool("debug-docker")
+	usePersistentEngine, _ := cmd.Flags().GetBool("persistent-engine")
+	exportResults, _ := cmd.Flags().GetBool("export")
+	syslogTarget, _ := cmd.Flags().GetString("syslog")
+	strict, _ := cmd.Flags().GetBool("strict")
+	skipIaC, _ := cmd.Flags().GetBool("skip-iac")
+	apiSpecPaths, _ := cmd.Flags().GetStringArray("api-spec")
+	databaseSchemaValues, _ := cmd.Flags().GetStringArray("db-schema")
+	sbomPath, _ := cmd.Flags().GetString("sbom")
+
+	if resultsRecipient != nil && !noLogFile {
+		// the scan log is a copy of the output in plaintext
+		fmt.Println("> The output of the scan is not written to a log file, results are encrypted (--encrypt-results)")
+	} else if !noLogFile {
+		startScanLog(fileutils.GetAbsolutePath(repository))
+	}
+	docker.EnableAPIDebugLogging(debugDocker)
+	startScanSyslog(syslogTarget, fileutils.GetAbsolutePath(repository))
+
+	if engineLogLevel != "" && !isEngineLogLevel(engineLogLevel) {
+		exitWithError(clierrors.InvalidFlagValue.Errorf("Invalid value for --engine-log-level: %s (allowed: %s)", engineLogLevel, strings.Join(engineLogLevels, ", ")))
+	}
+	if engineLogPath == "" {
+		engineLogPath = filepath.Join(fileutils.GetAbsolutePath(repository), ".privado", "engine.log")
+	}
+
+	maxFileSize, err := fileutils.ParseSize(maxFileSizeFlag)
+	if err != nil {
+		exitWithError(clierrors.InvalidFlagValue.Errorf("Invalid value for --max-file-size: %s", err))
+	}
+	engineMemory := int64(0)
+	if engineMemoryFlag, _ := cmd.Flags().GetString("engine-memory"); engineMemoryFlag != "" {
+		if engineMemory, err = fileutils.ParseSize(engineMemoryFlag); err != nil {
+			exitWithError(clierrors.InvalidFlagValue.Errorf("Invalid value for --engine-memory: %s", err))
+		}
+	}
+
+	skipDependencyDownload, skippedDependencyManagers := getSkippedDependencyManagers(cmd, fileutils.GetAbsolutePath(repository))
+
+	debugArtifactsDirectory := ""
+	if debug {
+		debugArtifactsDirectory, _ = cmd.Flags().GetString("debug-artifacts")
+		if debugArtifactsDirectory == "" {
+			debugArtifactsDirectory = filepath.Join(fileutils.GetAbsolutePath(repository), getPrivadoDirectoryName(), "debug", scanStartTime.Format("20060102-150405"))
+		}
+		debugArtifactsDirectory = fileutils.GetAbsolutePath(debugArtifactsDirectory)
+	}
+
+	hangTimeout, _ := cmd.Flags().GetDuration("hang-timeout")
+	if hangTimeout < 0 {
+		exitWithError(clierrors.InvalidFlagValue.Errorf("Invalid value for --hang-timeout: %s", hangTimeout))
+	}
+	statsInterval := time.Duration(0)
+	if showStats, _ := cmd.Flags().GetBool("stats"); showStats {
+		statsInterval = 15 * time.Second
+	}
+
+	scanMetrics := metrics.ScanMetrics{Repository: filepath.Base(fileutils.GetAbsolutePath(repository))}
+	switch progressFormat {
+	case "text":
+	case "ndjson":
+		if err := progress.Start(progressOutput); err != nil {
+			exitWithError(clierrors.ProgressOutput.Errorf("Cannot open progress output: %s", err))
+		}
+		registerExitHook(func(isError bool) {
+			progress.Stop()
+		})
+	default:
+		exitWithError(clierrors.InvalidFlagValue.Errorf("Invalid value for --progress-format: %s (allowed: text, ndjson)", progressFormat))
+	}
+
+	// metrics of a completed scan are written once it completes
+	scanCompleted := false
+	if metricsFile != "" {
+		registerExitHook(func(isError bool) {
+			if !scanCompleted {
+				writeScanMetrics(metricsFile, scanMetrics, repository, scanStartTime, !isError)
+			}
+		})
+	}
 
 	externalRules, _ := cmd.Flags().GetString("config")
 	if externalRules != "" {
 		externalRules = fileutils.GetAbsolutePath(externalRules)
 		externalRulesExists, _ := fileutils.DoesFileExists(externalRules)
 		if !externalRulesExists {
-			exit(fmt.Sprintf("Could not validate the config directory: %s", externalRules), true)
+			exitWithError(clierrors.PathNotFound.Errorf("Could not validate the config directory: %s", externalRules))
 		}
 	}
 
 	ignoreDefaultRules, _ := cmd.Flags().GetBool("ignore-default-rules")
 	if ignoreDefaultRules && externalRules == "" {
-		exit(fmt.Sprint(
+		exitWithError(clierrors.ConflictingOptions.New(fmt.Sprint(
 			"Default rules cannot be ignored without any external config.\n",
 			"You can specify your own rules and config using the `-c or --config` option.\n\n",
 			"For more info, run: 'privado help'\n",
-		), true)
+		)))
 	}
 
-	hasUpdate, updateMessage, err := checkForUpdate()
+	var apiSpecs []*apispec.Spec
+	if len(apiSpecPaths) > 0 {
+		externalRules, apiSpecs = prepareAPISpecRules(fileutils.GetAbsolutePath(repository), apiSpecPaths, externalRules)
+	}
+	databaseSchemas := loadDatabaseSchemas(fileutils.GetAbsolutePath(repository), databaseSchemaValues)
+	bom := loadSBOM(sbomPath)
+
+	// licensed offline scans do not check for updates
+	activeLicense, licenseKey := getActiveLicense()
+	offline := activeLicense != nil && activeLicense.HasFeature(license.FeatureOffline)
+	if offline {
+		// the image is not pulled: the access key cannot be fetched again
+		docker.SetAccessKeyCacheNeverExpires()
+	}
+
+	hasUpdate, updateMessage, err := false, "", error(nil)
+	if !offline && !skipUpdateCheck {
+		progress.PhaseStarted(progress.PhaseUpdateCheck)
+		hasUpdate, updateMessage, err = checkForUpdate()
+		progress.PhaseCompleted(progress.PhaseUpdateCheck, err)
+	}
 	if err == nil && hasUpdate {
 		fmt.Println(updateMessage)
 		time.Sleep(config.AppConfig.SlowdownTime)
@@ -132,9 +367,18 @@ func scan(cmd *cobra.Command, args []string) {
 	}
 
 	// if overwrite flag is not specified, check for existing results
-	if !overwriteResults {
+	// (which are kept when the results of each scan are kept)
+	keepResults := isKeepResultsEnabled(cmd, fileutils.GetAbsolutePath(repository))
+	if keepResults && resultsRecipient != nil {
+		exitWithError(clierrors.ConflictingOptions.New("--encrypt-results cannot be used with --keep-results (keepResults in .privado/config.json): kept results are not encrypted"))
+	}
+	if !overwriteResults && !keepResults {
 		resultsPath := filepath.Join(fileutils.GetAbsolutePath(repository), config.AppConfig.PrivacyResultsPathSuffix)
-		if exists, _ := fileutils.DoesFileExists(resultsPath); exists {
+		exists, _ := fileutils.DoesFileExists(resultsPath)
+		if encryptedExists, _ := fileutils.DoesFileExists(resultsPath + results.EncryptedFileExtension); encryptedExists {
+			exists = true
+		}
+		if exists {
 			fmt.Printf("> Scan report already exists (%s)\n", config.AppConfig.PrivacyResultsPathSuffix)
 			fmt.Println("\n> Rescan will overwrite existing results")
 			confirm, _ := utils.ShowConfirmationPrompt("Continue?")
@@ -146,18 +390,41 @@ func scan(cmd *cobra.Command, args []string) {
 	}
 
 	if !experimentalEnabled && (experimentalJavascriptEnabled || disableRunTimeSemantics || disableThisFiltering || disableFlowSeperationByDataElement || disable2ndLevelClosure || enableAPIDisplay || disableReadDataflow) {
-		exit(fmt.Sprint(
+		exitWithError(clierrors.ConflictingOptions.New(fmt.Sprint(
 			"Experimental features cannot be used without the `--enable-experiments` flag.\n\n",
 			"For more info, run: 'privado help'\n",
-		), true)
+		)))
 	}
 
+	warnOnTokenExpiry()
+
 	fmt.Println("> Scanning directory:", fileutils.GetAbsolutePath(repository))
 
-	if dockerAccessKey, err := docker.GetPrivadoDockerAccessKey(true); err != nil || dockerAccessKey == "" {
-		exit(fmt.Sprintf("Cannot fetch docker access key: %v \nPlease try again or raise an issue at %s", err, config.AppConfig.PrivadoRepository), true)
-	} else {
-		config.LoadUserDockerHash(dockerAccessKey)
+	// the branch of the scan (--branch, else the ci environment, else git)
+	// decides the sync, ci checkouts are generally detached
+	scanMetadata := getScanMetadata(cmd, fileutils.GetAbsolutePath(repository))
+	syncDecision := config.ResolveSyncToCloud(
+		explicitSync, explicitNoSync,
+		filepath.Base(fileutils.GetAbsolutePath(repository)),
+		gitutils.GetRemoteURL(fileutils.GetAbsolutePath(repository)),
+		scanMetadata.Branch,
+	)
+	if config.UserConfig.ConfigFile.SyncToPrivadoCloud || explicitSync {
+		fmt.Printf("> Sync to Privado Cloud: %t (%s)\n", syncDecision.Sync, syncDecision.Reason)
+	}
+
+	// sync the changes since the last synced scan after the scan, instead
+	// of all results by the engine. The sync state is a copy of the synced
+	// results in plaintext: it is not kept when results are encrypted
+	keepSyncState := resultsRecipient == nil
+	deltaSync := syncDecision.Sync && !fullSync && keepSyncState && hasSyncState(fileutils.GetAbsolutePath(repository), syncDecision.StripSnippets)
+	if deltaSync {
+		fmt.Println("> Syncing the changes since the last synced scan only (use --full-sync to sync all results)")
+	}
+
+	runPostScanHook := func() {}
+	if !skipHooks {
+		runPostScanHook = runScanHooks(fileutils.GetAbsolutePath(repository))
 	}
 
 	// "always pass -ic: even when internal rules are ignored (-i)"
@@ -167,12 +434,6 @@ func scan(cmd *cobra.Command, args []string) {
 		config.AppConfig.Container.InternalRulesVolumeDir,
 	}
 
-	if explicitUpload {
-		commandArgs = append(commandArgs, "--upload")
-	} else if explicitSkipUpload {
-		commandArgs = append(commandArgs, "--skip-upload")
-	}
-
 	if experimentalJavascriptEnabled {
 		commandArgs = append(commandArgs, "--enablejs")
 	}
@@ -225,38 +486,363 @@ func scan(cmd *cobra.Command, args []string) {
 		commandArgs = append(commandArgs, "--monolith")
 	}
 
-	// run image with options
-	err = docker.RunImage(
-		docker.OptionWithLatestImage(false), // because we already pull the image for access-key (with pullImage parameter)
-		docker.OptionWithArgs(commandArgs),
-		docker.OptionWithAttachedOutput(),
-		docker.OptionWithSourceVolume(fileutils.GetAbsolutePath(repository)),
-		docker.OptionWithUserConfigVolume(config.AppConfig.UserConfigurationFilePath),
-		docker.OptionWithUserKeyVolume(config.AppConfig.UserKeyPath),
-		docker.OptionWithPackageCacheVolumes(),
-		docker.OptionWithExternalRulesVolume(externalRules),
-		docker.OptionWithIgnoreDefaultRules(ignoreDefaultRules),
-		docker.OptionWithSkipDependencyDownload(skipDependencyDownload),
-		docker.OptionWithDisabledDeduplication(disableDeduplication),
-
-		docker.OptionWithDebug(debug),
-		docker.OptionWithEnvironmentVariables([]docker.EnvVar{
-			{Key: "CI", Value: strings.ToUpper(strconv.FormatBool(ci.CISessionConfig.IsCI))},
-			{Key: "PRIVADO_VERSION_CLI", Value: Version},
-			{Key: "PRIVADO_HOST_SCAN_DIR", Value: fileutils.GetAbsolutePath(repository)},
-			{Key: "PRIVADO_USER_HASH", Value: config.UserConfig.UserHash},
-			{Key: "PRIVADO_SESSION_ID", Value: config.UserConfig.SessionId},
-			{Key: "PRIVADO_SYNC_TO_CLOUD", Value: strings.ToUpper(strconv.FormatBool(config.UserConfig.ConfigFile.SyncToPrivadoCloud))},
-			{Key: "PRIVADO_METRICS_ENABLED", Value: strings.ToUpper(strconv.FormatBool(config.UserConfig.ConfigFile.MetricsEnabled))},
-			{Key: "JAVA_TOOL_OPTIONS", Value: jvmArgs},
-		}),
-		docker.OptionWithAutoSpawnBrowserOnURLMessages([]string{
-			"> Continue to view results on:",
-		}),
-		docker.OptionWithInterrupt(),
-	)
+	// nothing changed since the last scan: its results are reused, and
+	// processed like the results of a scan (sync, exports, notifications).
+	// The checksum is of the local image, which is not pulled
+	reuseResults := false
+	scanInputsChecksum, err := getScanInputsChecksum(cmd, fileutils.GetAbsolutePath(repository), commandArgs, externalRules, includeIgnored)
 	if err != nil {
-		exit(fmt.Sprintf("Received error: %s", err), true)
+		fmt.Println("[WARN]: Could not compute the checksum of the scan inputs:", err)
+	} else if unchanged, scannedAt := isScanUnchanged(fileutils.GetAbsolutePath(repository), scanInputsChecksum); unchanged && !force {
+		fmt.Printf("\n> Nothing changed since the last scan (%s): reusing its results (use --force to scan anyway)\n", scannedAt.Local().Format(time.RFC1123))
+		reuseResults = true
+	}
+
+	// the upload does not change the results
+	if explicitUpload {
+		commandArgs = append(commandArgs, "--upload")
+	} else if explicitSkipUpload {
+		commandArgs = append(commandArgs, "--skip-upload")
+	}
+
+	if !reuseResults {
+		if !skipDiskCheck {
+			checkDiskSpace(fileutils.GetAbsolutePath(repository), copySource || followSymlinks || noFollowSymlinks,
+				getDiskCheckExcludedPaths(fileutils.GetAbsolutePath(repository), excludePaths, includeIgnored, includeVendored))
+		}
+
+		imagePullStartTime := time.Now()
+		progress.PhaseStarted(progress.PhaseImagePull)
+		// licensed offline scans use the local image
+		if dockerAccessKey, err := docker.GetPrivadoDockerAccessKey(!offline); err != nil || dockerAccessKey == "" {
+			progress.PhaseCompleted(progress.PhaseImagePull, fmt.Errorf("cannot fetch docker access key: %v", err))
+			exitWithError(clierrors.DockerAccessKey.Errorf("Cannot fetch docker access key: %v \nPlease try again or raise an issue at %s", err, config.AppConfig.PrivadoRepository))
+		} else {
+			config.LoadUserDockerHash(dockerAccessKey)
+		}
+		scanMetrics.ImagePullDuration = time.Since(imagePullStartTime)
+		progress.PhaseCompleted(progress.PhaseImagePull, nil)
+		warnIfEmulated()
+	} else if syncDecision.Sync {
+		// the results are synced with the (local) engine image
+		if dockerAccessKey, err := docker.GetPrivadoDockerAccessKey(false); err == nil && dockerAccessKey != "" {
+			config.LoadUserDockerHash(dockerAccessKey)
+		}
+	}
+
+	// paths not scanned, for the coverage of the scan
+	var coverageExcludedPaths []string
+	warnings := newEngineWarnings()
+	syncedScan := &syncedScanListener{}
+	// the source code scanned by the engine: the repository or its copy
+	sourceDirectory := fileutils.GetAbsolutePath(repository)
+	if reuseResults {
+		// the results of the last scan are processed as they are
+	} else if len(parallelModules) > 0 {
+		parallelScan(cmd, fileutils.GetAbsolutePath(repository), parallelModules, parallel)
+	} else {
+		// symbolic links are resolved (or skipped) in a copy of the source code
+		symlinkPolicy := symlinksKeep
+		if followSymlinks {
+			symlinkPolicy = symlinksFollow
+		} else if noFollowSymlinks {
+			symlinkPolicy = symlinksSkip
+		}
+		if symlinkPolicy != symlinksKeep && !copySource {
+			fmt.Println("> Symbolic links are handled in a copy of the source code, enabling --copy-source")
+			copySource = true
+		}
+		if fileutils.IsNetworkPath(fileutils.GetAbsolutePath(repository)) && !copySource {
+			fmt.Println("> Docker cannot mount network shares (UNC paths), the source code is copied: enabling --copy-source")
+			copySource = true
+		}
+
+		// build output and local files ignored by git are not scanned: they are
+		// not copied to the workspace (--copy-source), or hidden from the scan
+		sourcePreparationSpan := tracing.StartSpan("source-preparation")
+		sharedGitDirectory := prepareGitCheckout(fileutils.GetAbsolutePath(repository), recurseSubmodules)
+		additionalSourceRoots := getAdditionalSourceRoots(fileutils.GetAbsolutePath(repository), additionalRootsMode)
+		var ignoredDirectories, excludedFiles []string
+		maskFile := ""
+		if copySource {
+			excludedPaths := getExcludedDirectories(excludePaths)
+			if !includeIgnored {
+				ignoredPaths := getIgnoredPaths(sourceDirectory)
+				if len(ignoredPaths) > 0 {
+					fmt.Printf("> Excluding %d path(s) ignored by git (use --include-ignored to scan them)\n", len(ignoredPaths))
+				}
+				excludedPaths = append(excludedPaths, ignoredPaths...)
+			}
+			if !includeVendored {
+				excludedPaths = append(excludedPaths, getVendoredPaths(sourceDirectory, excludedPaths)...)
+			}
+			auditSymlinks(sourceDirectory, excludedPaths, symlinkPolicy)
+			excludedPaths = append(excludedPaths, getExcludedFiles(sourceDirectory, excludedPaths, maxFileSize, !includeBinaryFiles)...)
+			coverageExcludedPaths = excludedPaths
+			sourceDirectory = copySourceToWorkspace(sourceDirectory, excludedPaths, symlinkPolicy)
+		} else {
+			if !includeIgnored {
+				ignoredDirectories = getIgnoredDirectories(sourceDirectory)
+				if len(ignoredDirectories) > 0 {
+					fmt.Printf("> Excluding %d director(ies) ignored by git (use --include-ignored to scan them)\n", len(ignoredDirectories))
+				}
+			}
+			ignoredDirectories = append(getExcludedDirectories(excludePaths), ignoredDirectories...)
+			var vendoredFiles []string
+			if !includeVendored {
+				unmasked := 0
+				for _, vendoredPath := range getVendoredPaths(sourceDirectory, ignoredDirectories) {
+					if !strings.HasSuffix(vendoredPath, "/") {
+						vendoredFiles = append(vendoredFiles, vendoredPath)
+					} else if len(ignoredDirectories) < maxMaskedDirectories {
+						ignoredDirectories = append(ignoredDirectories, strings.TrimSuffix(vendoredPath, "/"))
+					} else {
+						unmasked++
+					}
+				}
+				if unmasked > 0 {
+					fmt.Printf("[WARN]: Only %d directories can be excluded when mounting the repository, %d vendored director(ies) are scanned; use --copy-source to exclude all\n", maxMaskedDirectories, unmasked)
+				}
+			}
+			auditSymlinks(sourceDirectory, ignoredDirectories, symlinkPolicy)
+
+			excludedPaths := append(append([]string{}, ignoredDirectories...), vendoredFiles...)
+			excludedFiles = append(getExcludedFiles(sourceDirectory, excludedPaths, maxFileSize, !includeBinaryFiles), vendoredFiles...)
+			if len(excludedFiles) > maxMaskedFiles {
+				fmt.Printf("[WARN]: Only the first %d excluded files are excluded when mounting the repository, use --copy-source to exclude all\n", maxMaskedFiles)
+				excludedFiles = excludedFiles[:maxMaskedFiles]
+			}
+			if len(excludedFiles) > 0 {
+				if maskFile, err = createMaskFile(); err != nil {
+					fmt.Println("[WARN]: Could not exclude large and binary files:", err)
+					excludedFiles = nil
+				}
+			}
+		}
+
+		if !copySource {
+			coverageExcludedPaths = append(append([]string{}, ignoredDirectories...), excludedFiles...)
+		}
+		sourcePreparationSpan.End(nil)
+
+		runImage := docker.RunImage
+		if usePersistentEngine {
+			if engine := getPersistentEngineForScan(sourceDirectory, getScanMounts(ignoredDirectories, excludedFiles, additionalSourceRoots, sharedGitDirectory, externalRules)); engine != nil {
+				runImage = func(opts ...docker.RunImageOption) error {
+					return docker.RunInPersistentEngine(engine, sourceDirectory, opts...)
+				}
+			}
+		}
+
+		// the engine prints the url of the results on Privado Cloud
+		var cloudURLMessages []string
+		if openCloud {
+			cloudURLMessages = []string{syncedScanMessage}
+		}
+
+		// run image with options
+		progress.PhaseStarted(progress.PhaseScan)
+		err = runImage(
+			docker.OptionWithLatestImage(false), // because we already pull the image for access-key (with pullImage parameter)
+			docker.OptionWithArgs(commandArgs),
+			docker.OptionWithAttachedOutput(),
+			docker.OptionWithSourceVolume(sourceDirectory),
+			docker.OptionWithMaskedSourceDirectories(ignoredDirectories),
+			docker.OptionWithMaskedSourceFiles(excludedFiles, maskFile),
+			docker.OptionWithSharedDirectory(sharedGitDirectory),
+			docker.OptionWithAdditionalSourceRoots(additionalSourceRoots),
+			docker.OptionWithUserConfigVolume(config.AppConfig.UserConfigurationFilePath),
+			docker.OptionWithUserKeyVolume(config.AppConfig.UserKeyPath),
+			docker.OptionWithPackageCacheVolumes(),
+			docker.OptionWithJVMClassArchive(config.GetJVMCacheDirectory(), getJVMClassArchiveName(), false),
+			docker.OptionWithExternalRulesVolume(externalRules),
+			docker.OptionWithIgnoreDefaultRules(ignoreDefaultRules),
+			docker.OptionWithSkipDependencyDownload(skipDependencyDownload),
+			docker.OptionWithSkippedDependencyManagers(skippedDependencyManagers),
+			docker.OptionWithDisabledDeduplication(disableDeduplication),
+
+			docker.OptionWithDebug(debug),
+			docker.OptionWithDebugArtifacts(debugArtifactsDirectory),
+			docker.OptionWithMemoryLimit(engineMemory),
+			docker.OptionWithMemoryWatchdog(),
+			docker.OptionWithStats(statsInterval),
+			docker.OptionWithHangDetection(hangTimeout, config.AppConfig.DiagnosticsDirectory),
+			docker.OptionWithEngineLogLevel(strings.ToLower(engineLogLevel)),
+			docker.OptionWithEngineLog(fileutils.GetAbsolutePath(engineLogPath), debug),
+			docker.OptionWithEnvironmentVariables(append([]docker.EnvVar{
+				{Key: "CI", Value: strings.ToUpper(strconv.FormatBool(ci.CISessionConfig.IsCI))},
+				{Key: "PRIVADO_VERSION_CLI", Value: Version},
+				{Key: "PRIVADO_HOST_SCAN_DIR", Value: fileutils.GetAbsolutePath(repository)},
+				{Key: "PRIVADO_USER_HASH", Value: config.UserConfig.UserHash},
+				{Key: "PRIVADO_SESSION_ID", Value: config.UserConfig.SessionId},
+				{Key: "PRIVADO_SYNC_TO_CLOUD", Value: strings.ToUpper(strconv.FormatBool(syncDecision.Sync && !deltaSync && !syncDecision.StripSnippets))},
+				{Key: "PRIVADO_METRICS_ENABLED", Value: strings.ToUpper(strconv.FormatBool(config.IsEngineMetricsEnabled()))},
+				{Key: auth.TokenEnvKey, Value: getAPIToken(), Secret: true},
+				{Key: auth.OrganizationEnvKey, Value: getOrganizationId()},
+				{Key: "PRIVADO_LICENSE_KEY", Value: getEncodedLicenseKey(licenseKey), Secret: true},
+				{Key: "JAVA_TOOL_OPTIONS", Value: jvmArgs},
+			}, getScanMetadataEnvVars(scanMetadata)...)),
+			docker.OptionWithAutoSpawnBrowserOnURLMessages(cloudURLMessages),
+			docker.OptionWithInterrupt(),
+			warnings.runImageOption(),
+			syncedScan.runImageOption(),
+		)
+		progress.PhaseCompleted(progress.PhaseScan, err)
+		fmt.Println("> Engine logs:", fileutils.GetAbsolutePath(engineLogPath))
+		if err != nil {
+			var containerExitError *docker.ContainerExitError
+			if errors.As(err, &containerExitError) && containerExitError.OutOfMemory {
+				exitWithError(clierrors.EngineMemory.Errorf("Scan failed, the engine ran out of memory: retry with more memory (--engine-memory, or the memory of docker)"))
+			}
+			if errors.As(err, &containerExitError) {
+				exitWithError(clierrors.EngineFailed.Errorf("Scan failed: %s", err))
+			}
+			exitWithError(clierrors.DockerRun.Errorf("Received error: %s", err))
+		}
+	}
+
+	postProcessingSpan := tracing.StartSpan("post-processing")
+	if sourceDirectory != fileutils.GetAbsolutePath(repository) {
+		if err := copyResultsFromWorkspace(sourceDirectory, fileutils.GetAbsolutePath(repository)); err != nil {
+			exitWithError(clierrors.WorkspaceCopy.Errorf("Cannot copy results from the workspace: %s", err))
+		}
+	}
+
+	addScanMetadata(fileutils.GetAbsolutePath(repository), scanMetadata)
+	// the scans of the modules add these to their results themselves, and
+	// reused results have them already
+	if len(parallelModules) == 0 && !reuseResults {
+		if !skipIaC {
+			scanInfrastructure(fileutils.GetAbsolutePath(repository), coverageExcludedPaths)
+		}
+		if len(apiSpecs) > 0 {
+			reportAPIEndpoints(fileutils.GetAbsolutePath(repository), apiSpecs)
+		}
+		if len(databaseSchemas) > 0 {
+			reportStorageInventory(fileutils.GetAbsolutePath(repository), databaseSchemas)
+		}
+		if bom != nil {
+			reportSBOMComponents(fileutils.GetAbsolutePath(repository), bom)
+		}
+	}
+	runPostScanHook()
+	if len(parallelModules) == 0 && !reuseResults {
+		reportScanCoverage(fileutils.GetAbsolutePath(repository), coverageExcludedPaths, warnings, experimentalJavascriptEnabled)
+	}
+	if keepResults && !reuseResults {
+		archiveScanResults(fileutils.GetAbsolutePath(repository), scanMetadata.CommitId)
+	}
+	if !reuseResults {
+		recordScanHistory(fileutils.GetAbsolutePath(repository))
+	}
+	recordSync := func(scanId string) {
+		if !keepSyncState {
+			// removes the state of previous scans as well
+			scanId = ""
+		}
+		recordSyncedScan(fileutils.GetAbsolutePath(repository), syncDecision.StripSnippets, scanId)
+	}
+	if deltaSync {
+		if err := syncResultsDelta(fileutils.GetAbsolutePath(repository), syncDecision.StripSnippets); err != nil {
+			fmt.Println("[WARN]: Could not sync the changes only, syncing all results:", err)
+			if scanId, err := runEngineUpload(fileutils.GetAbsolutePath(repository), debug, true, syncDecision.StripSnippets); err != nil {
+				fmt.Println("[WARN]: Could not sync results:", err)
+			} else {
+				recordSync(scanId)
+			}
+		}
+	} else if syncDecision.Sync && (len(parallelModules) > 0 || reuseResults || syncDecision.StripSnippets) {
+		// the engine did not sync: the scans of the modules are not synced
+		// (their combined results are), the results are reused, or snippets
+		// are stripped from the results before they are uploaded
+		if scanId, err := runEngineUpload(fileutils.GetAbsolutePath(repository), debug, true, syncDecision.StripSnippets); err != nil {
+			fmt.Println("[WARN]: Could not sync results:", err)
+		} else {
+			recordSync(scanId)
+		}
+	} else if syncDecision.Sync {
+		recordSync(syncedScan.getScanId())
+	}
+
+	scanCompleted = true
+	if progress.IsEnabled() {
+		reportFindingCount(repository)
+	}
+	if metricsFile != "" {
+		writeScanMetrics(metricsFile, scanMetrics, repository, scanStartTime, true)
+	}
+	if exportResults {
+		// the scan succeeded, a failed export does not fail it
+		if err := exportFindings(fileutils.GetAbsolutePath(repository), nil); err != nil {
+			fmt.Println("[WARN]:", err)
+		}
+	}
+	if cmd.Name() == "scan" {
+		// privado ci notifies of failed gates itself, hooks do not notify
+		sendNotifications(newScanNotification(fileutils.GetAbsolutePath(repository), nil))
+	}
+	postProcessingSpan.End(nil)
+	if openLocal {
+		openHTMLReport(fileutils.GetAbsolutePath(repository))
+	}
+	if resultsRecipient != nil {
+		encryptScanOutputs(fileutils.GetAbsolutePath(repository), fileutils.GetAbsolutePath(engineLogPath), resultsRecipient)
+		if len(parallelModules) > 0 {
+			encryptModuleScanOutputs(fileutils.GetAbsolutePath(repository), parallelModules, resultsRecipient)
+		}
+	}
+
+	if strict {
+		checkEngineWarnings(warnings)
+	}
+	if scanInputsChecksum != "" && !reuseResults {
+		writeScanInputs(fileutils.GetAbsolutePath(repository), scanInputsChecksum)
+	}
+}
+
+// Returns whether the local HTML report and the results on Privado Cloud
+// are opened after the scan (--open)
+func getOpenFlag(cmd *cobra.Command) (local, cloud bool) {
+	values, _ := cmd.Flags().GetStringSlice("open")
+	for _, value := range values {
+		switch strings.ToLower(strings.TrimSpace(value)) {
+		case openLocalReport:
+			local = true
+		case openCloudResults:
+			cloud = true
+		case openNothing:
+		default:
+			exitWithError(clierrors.InvalidFlagValue.Errorf("Invalid value for --open: %s (allowed: %s, %s, %s)", value, openLocalReport, openCloudResults, openNothing))
+		}
+	}
+	return local, cloud
+}
+
+func reportFindingCount(repository string) {
+	progress.PhaseStarted(progress.PhaseResults)
+	scanResults, err := results.LoadResults(filepath.Join(fileutils.GetAbsolutePath(repository), config.AppConfig.PrivacyResultsPathSuffix))
+	if err == nil {
+		findings := scanResults.Findings()
+		progress.FindingCount(len(findings), results.CountFindingsBySeverity(findings))
+	}
+	progress.PhaseCompleted(progress.PhaseResults, err)
+}
+
+func writeScanMetrics(metricsFile string, scanMetrics metrics.ScanMetrics, repository string, scanStartTime time.Time, success bool) {
+	scanMetrics.ScanDuration = time.Since(scanStartTime)
+	scanMetrics.Timestamp = time.Now()
+	scanMetrics.Success = success
+	scanMetrics.FindingsBySeverity = map[string]int{}
+
+	resultsPath := filepath.Join(fileutils.GetAbsolutePath(repository), config.AppConfig.PrivacyResultsPathSuffix)
+	if success {
+		if scanResults, err := results.LoadResults(resultsPath); err == nil {
+			scanMetrics.FindingsBySeverity = results.CountFindingsBySeverity(scanResults.Findings())
+		}
+	}
+
+	if err := scanMetrics.WriteTextfile(fileutils.GetAbsolutePath(metricsFile)); err != nil {
+		fmt.Println("[WARN]: Could not write metrics file:", err)
 	}
 }
 
@@ -0,0 +1,51 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/history"
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+)
+
+// Appends the completed scan of the repository to the local scan history
+// (see 'privado summary'), and removes scans beyond the retention. A
+// failure does not fail the scan
+func recordScanHistory(repositoryPath string) {
+	scanResults, err := results.LoadResults(filepath.Join(repositoryPath, config.AppConfig.PrivacyResultsPathSuffix))
+	if err != nil {
+		fmt.Println("[WARN]: Could not record the scan in the history:", err)
+		return
+	}
+	scan := history.NewScan(repositoryPath, scanResults, time.Now())
+	if err := history.RecordScan(config.AppConfig.HistoryDirectory, scan); err != nil {
+		fmt.Println("[WARN]: Could not record the scan in the history:", err)
+	}
+	if _, _, err := applyHistoryRetention(); err != nil {
+		fmt.Println("[WARN]: Could not remove old scans from the history:", err)
+	}
+}
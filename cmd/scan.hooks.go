@@ -0,0 +1,109 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/gitutils"
+	"github.com/Privado-Inc/privado-cli/pkg/hooks"
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+)
+
+// Runs the pre-scan hook of the repository (if configured) and returns a
+// function running its post-scan hook once the scan succeeded. If the scan
+// fails, the post-scan hook is run on exit (with status "failed")
+func runScanHooks(repositoryPath string) func() {
+	projectConfig, err := config.LoadProjectConfiguration(repositoryPath)
+	if err != nil {
+		exitWithError(clierrors.ProjectConfigInvalid.Errorf("Cannot load project configuration: %s", err))
+	}
+	projectHooks := projectConfig.Hooks
+	if projectHooks == nil || (projectHooks.PreScan == "" && projectHooks.PostScan == "") {
+		return func() {}
+	}
+
+	timeout := hooks.DefaultTimeout
+	if projectHooks.Timeout != "" {
+		if timeout, err = time.ParseDuration(projectHooks.Timeout); err != nil {
+			exitWithError(clierrors.ProjectConfigInvalid.Errorf("Invalid hooks timeout in %s: %s", config.GetProjectConfigurationPath(repositoryPath), projectHooks.Timeout))
+		}
+	}
+
+	metadata := hooks.Metadata{
+		Repository:     filepath.Base(repositoryPath),
+		RepositoryPath: repositoryPath,
+		ResultsPath:    filepath.Join(repositoryPath, config.AppConfig.PrivacyResultsPathSuffix),
+		Branch:         gitutils.GetCurrentBranch(repositoryPath),
+		Commit:         gitutils.GetCurrentCommit(repositoryPath),
+		CLIVersion:     Version,
+		StartedAt:      time.Now().UTC(),
+	}
+
+	if projectHooks.PreScan != "" {
+		fmt.Println("> Running pre-scan hook")
+		metadata.Hook = hooks.PreScan
+		if err := hooks.Run(projectHooks.PreScan, repositoryPath, metadata, timeout, os.Stdout); err != nil {
+			exitWithError(clierrors.PreScanHookFailed.Errorf("Scan aborted: %s", err))
+		}
+	}
+
+	if projectHooks.PostScan == "" {
+		return func() {}
+	}
+
+	postScanHookRun := false
+	runPostScanHook := func(status string) {
+		if postScanHookRun {
+			return
+		}
+		postScanHookRun = true
+
+		metadata.Hook = hooks.PostScan
+		metadata.Status = status
+		if status == hooks.StatusSucceeded {
+			if scanResults, err := results.LoadResults(metadata.ResultsPath); err == nil {
+				metadata.Findings = results.CountFindingsBySeverity(scanResults.Findings())
+			}
+		}
+
+		fmt.Println("> Running post-scan hook")
+		if err := hooks.Run(projectHooks.PostScan, repositoryPath, metadata, timeout, os.Stdout); err != nil {
+			fmt.Println("[WARN]:", err)
+		}
+	}
+	registerExitHook(func(isError bool) {
+		if isError {
+			runPostScanHook(hooks.StatusFailed)
+		}
+	})
+
+	return func() {
+		runPostScanHook(hooks.StatusSucceeded)
+	}
+}
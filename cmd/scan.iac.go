@@ -0,0 +1,72 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/iac"
+)
+
+// Adds data stores and third-party services defined in infrastructure
+// files (terraform, cloudformation, kubernetes, docker-compose) of the
+// repository to the results, outside of the excluded paths
+func scanInfrastructure(repositoryPath string, excludedPaths []string) {
+	isExcluded := getExclusionFilter(excludedPaths)
+	resources, err := iac.Scan(repositoryPath, func(relativePath string, entry fs.DirEntry) bool {
+		return isExcluded(relativePath, entry) || relativePath == filepath.ToSlash(getPrivadoDirectoryName())
+	})
+	if err != nil {
+		fmt.Println("[WARN]: Could not scan infrastructure files:", err)
+		return
+	}
+	if len(resources) == 0 {
+		return
+	}
+
+	resultsPath := filepath.Join(repositoryPath, config.AppConfig.PrivacyResultsPathSuffix)
+	if err := iac.MergeIntoResults(resultsPath, config.AppConfig.Container.SourceCodeVolumeDir, resources); err != nil {
+		fmt.Println("[WARN]: Could not add infrastructure sinks to the results:", err)
+		return
+	}
+
+	counts := iac.CountBySource(resources)
+	sources := []string{}
+	for source, count := range counts {
+		sources = append(sources, fmt.Sprintf("%s: %d", source, count))
+	}
+	sort.Strings(sources)
+	fmt.Printf("> Found %d data store(s) and third-party service(s) defined in infrastructure files (%s)\n", len(resources), strings.Join(sources, ", "))
+	for i, resource := range resources {
+		if i == maxListedFiles {
+			fmt.Printf("  ..and %d more\n", len(resources)-maxListedFiles)
+			break
+		}
+		fmt.Printf("  %s (%s): %s:%d\n", resource.Name, resource.Definition, resource.File, resource.Line)
+	}
+}
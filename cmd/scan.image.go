@@ -0,0 +1,160 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/docker"
+	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+	"github.com/spf13/cobra"
+)
+
+var scanImageCmd = &cobra.Command{
+	Use:   "image <image-ref>",
+	Short: "Scan the application code and archives (jars) of a built container image",
+	Long: "Scan the application code and archives (jars) of a built container image, when the exact source code it was built from is not at hand. " +
+		"The files are extracted (without running the image) from its working directory, or the paths given with --path, to the output directory, which is then scanned",
+	Args: cobra.ExactArgs(1),
+	PreRun: func(cmd *cobra.Command, args []string) {
+		telemetryPreRun(nil)
+	},
+	Run: scanImage,
+	PostRun: func(cmd *cobra.Command, args []string) {
+		telemetryPostRun(nil)
+	},
+}
+
+// directories of the operating system and runtimes in an image, not
+// extracted when the whole filesystem is
+var imageSystemDirectories = []string{
+	"/bin", "/boot", "/dev", "/etc", "/lib", "/lib32", "/lib64", "/libx32", "/media", "/mnt", "/proc",
+	"/run", "/sbin", "/srv", "/sys", "/tmp", "/usr", "/var", "/root/.cache", "/opt/java", "/opt/conda",
+}
+
+// written to the output directory, so a later scan of the image can replace it
+const imageManifestFileName = ".privado-image.json"
+
+type imageManifest struct {
+	Image       string    `json:"image"`
+	Digest      string    `json:"digest,omitempty"`
+	Paths       []string  `json:"paths"`
+	Files       int       `json:"files"`
+	ExtractedAt time.Time `json:"extractedAt"`
+}
+
+var imageNameSanitizer = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+func scanImage(cmd *cobra.Command, args []string) {
+	image := args[0]
+	paths, _ := cmd.Flags().GetStringSlice("path")
+	outputDirectory, _ := cmd.Flags().GetString("output")
+	if outputDirectory == "" {
+		outputDirectory = "privado-image-" + strings.Trim(imageNameSanitizer.ReplaceAllString(image, "-"), "-")
+	}
+	outputDirectory = fileutils.GetAbsolutePath(outputDirectory)
+	prepareImageOutputDirectory(outputDirectory)
+
+	workingDirectory, err := docker.GetImageWorkingDirectory(image)
+	if err != nil {
+		exitWithError(clierrors.ImagePull.Errorf("Cannot inspect or pull the image %s: %s", image, err))
+	}
+
+	// the whole filesystem (without system directories), unless the image
+	// has a working directory the application is in
+	var include func(imagePath string) bool
+	if len(paths) == 0 {
+		if workingDirectory != "" && workingDirectory != "/" {
+			paths = []string{workingDirectory}
+		} else {
+			paths = []string{"/"}
+			include = func(imagePath string) bool {
+				for _, directory := range imageSystemDirectories {
+					if imagePath == directory || strings.HasPrefix(imagePath, directory+"/") {
+						return false
+					}
+				}
+				return true
+			}
+		}
+	}
+	for i, imagePath := range paths {
+		paths[i] = path.Clean("/" + imagePath)
+	}
+
+	fmt.Printf("> Extracting %s from %s to: %s\n", strings.Join(paths, ", "), image, outputDirectory)
+	extracted, err := docker.ExtractImageFiles(image, paths, outputDirectory, include)
+	if err != nil {
+		exitWithError(clierrors.ImageExtraction.Errorf("Cannot extract files from the image %s: %s", image, err))
+	}
+	if extracted == 0 {
+		exitWithError(clierrors.ImageExtraction.Errorf("No files found in %s of the image %s (use --path to choose the directories of the application)", strings.Join(paths, ", "), image))
+	}
+	fmt.Printf("> Extracted %d file(s)\n", extracted)
+
+	manifest := imageManifest{Image: image, Digest: docker.GetImageDigest(image), Paths: paths, Files: extracted, ExtractedAt: time.Now().UTC()}
+	if data, err := json.MarshalIndent(manifest, "", "  "); err == nil {
+		_ = os.WriteFile(filepath.Join(outputDirectory, imageManifestFileName), data, 0644)
+	}
+
+	// no prompt for results of an earlier extraction, and hooks of the
+	// extracted files are not trusted to run on this machine
+	_ = cmd.Flags().Set("overwrite", "true")
+	_ = cmd.Flags().Set("skip-hooks", "true")
+	scan(cmd, []string{outputDirectory})
+	fmt.Printf("\n> Scanned image %s, results: %s\n", image, filepath.Join(outputDirectory, config.AppConfig.PrivacyResultsPathSuffix))
+}
+
+// Creates the output directory, replacing an earlier extraction of an
+// image. Exits if it is another, non-empty directory
+func prepareImageOutputDirectory(outputDirectory string) {
+	entries, err := os.ReadDir(outputDirectory)
+	if err == nil && len(entries) > 0 {
+		if exists, _ := fileutils.DoesFileExists(filepath.Join(outputDirectory, imageManifestFileName)); !exists {
+			exitWithError(clierrors.ConflictingOptions.Errorf("The output directory %s is not empty and was not created by 'privado scan image' (use --output to choose another)", outputDirectory))
+		}
+		if err := os.RemoveAll(outputDirectory); err != nil {
+			exitWithError(clierrors.ImageExtraction.Errorf("Cannot remove the earlier extraction in %s: %s", outputDirectory, err))
+		}
+	}
+	if err := os.MkdirAll(outputDirectory, os.ModePerm); err != nil {
+		exitWithError(clierrors.ImageExtraction.Errorf("Cannot create the output directory %s: %s", outputDirectory, err))
+	}
+}
+
+func init() {
+	defineScanFlags(scanImageCmd)
+	scanImageCmd.Flags().StringSlice("path", nil, "Directories of the image to extract and scan, e.g. /app (default: the working directory of the image, else the whole filesystem without system directories)")
+	scanImageCmd.Flags().StringP("output", "o", "", "Directory the files are extracted to and the results are written to (default: ./privado-image-<image>)")
+	_ = scanImageCmd.RegisterFlagCompletionFunc("output", completeDirectory)
+	scanCmd.AddCommand(scanImageCmd)
+}
@@ -0,0 +1,110 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/Privado-Inc/privado-cli/pkg/ci"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/docker"
+	"github.com/Privado-Inc/privado-cli/pkg/gitutils"
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+	"github.com/spf13/cobra"
+)
+
+// Returns the metadata of the scan: the values of --commit, --branch,
+// --build-id and --build-url, else detected from the CI environment and git
+func getScanMetadata(cmd *cobra.Command, repositoryPath string) results.ScanMetadata {
+	commitId, _ := cmd.Flags().GetString("commit")
+	branch, _ := cmd.Flags().GetString("branch")
+	buildId, _ := cmd.Flags().GetString("build-id")
+	buildURL, _ := cmd.Flags().GetString("build-url")
+
+	metadata := results.ScanMetadata{CommitId: commitId, Branch: branch, BuildId: buildId, BuildURL: buildURL}
+	if provider := ci.CISessionConfig.Provider; provider != nil {
+		metadata.CIProvider = provider.Name
+		// ci checkouts are generally detached, the branch is in the environment
+		if metadata.Branch == "" {
+			metadata.Branch = provider.GetBranch()
+		}
+		if metadata.BuildId == "" {
+			metadata.BuildId = provider.GetBuildId()
+		}
+		if metadata.BuildURL == "" {
+			metadata.BuildURL = provider.GetBuildURL()
+		}
+	}
+	if gitutils.IsRepository(repositoryPath) {
+		if metadata.CommitId == "" {
+			metadata.CommitId = gitutils.GetCurrentCommit(repositoryPath)
+		}
+		if metadata.Branch == "" {
+			metadata.Branch = gitutils.GetCurrentBranch(repositoryPath)
+		}
+	}
+	return metadata
+}
+
+// Returns the environment variables passing the metadata to the engine,
+// which includes them in the results it syncs to Privado Cloud
+func getScanMetadataEnvVars(metadata results.ScanMetadata) []docker.EnvVar {
+	return []docker.EnvVar{
+		{Key: "PRIVADO_SCAN_COMMIT", Value: metadata.CommitId},
+		{Key: "PRIVADO_SCAN_BRANCH", Value: metadata.Branch},
+		{Key: "PRIVADO_CI_PROVIDER", Value: metadata.CIProvider},
+		{Key: "PRIVADO_BUILD_ID", Value: metadata.BuildId},
+		{Key: "PRIVADO_BUILD_URL", Value: metadata.BuildURL},
+	}
+}
+
+// Adds the metadata to the results (scanMetadata in privado.json), and
+// its commit and branch to the git metadata (which they override)
+func addScanMetadata(repositoryPath string, metadata results.ScanMetadata) {
+	resultsPath := filepath.Join(repositoryPath, config.AppConfig.PrivacyResultsPathSuffix)
+	raw, err := results.LoadRawResults(resultsPath)
+	if err == nil {
+		raw["scanMetadata"] = metadata
+		gitMetadata, ok := raw["gitMetadata"].(map[string]interface{})
+		if !ok {
+			gitMetadata = map[string]interface{}{}
+			raw["gitMetadata"] = gitMetadata
+		}
+		if metadata.CommitId != "" {
+			gitMetadata["commitId"] = metadata.CommitId
+		}
+		if metadata.Branch != "" {
+			gitMetadata["branchName"] = metadata.Branch
+		}
+		err = results.WriteRawResults(resultsPath, raw)
+	}
+	if err != nil {
+		fmt.Println("[WARN]: Could not add the scan metadata to the results:", err)
+		return
+	}
+
+	if metadata.BuildId != "" || metadata.BuildURL != "" {
+		fmt.Printf("> Build: %s %s\n", metadata.BuildId, metadata.BuildURL)
+	}
+}
@@ -0,0 +1,244 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+package cmd
+
+import (
+	"crypto/rsa"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+	"github.com/Privado-Inc/privado-cli/pkg/shard"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// flags of the scan that are not passed to the scans of modules: they
+// apply to the scan of the repository, or are set for each module
+var parallelScanExcludedFlags = map[string]bool{
+	"sync":            true,
+	"no-sync":         true,
+	"full-sync":       true,
+	"open":            true,
+	"skip-hooks":      true,
+	"encrypt-results": true,
+	"shard":           true,
+	"parallel":        true,
+	"overwrite":       true,
+	"keep-results":    true,
+	"export":          true,
+	"syslog":          true,
+	"metrics-file":    true,
+	"progress-format": true,
+	"progress-output": true,
+	"engine-log":      true,
+	"exclude-path":    true,
+}
+
+var unsafeModuleNameCharacters = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+type moduleScan struct {
+	module   string
+	logPath  string
+	duration time.Duration
+	results  map[string]interface{}
+	err      error
+}
+
+// Returns the modules of the repository to scan in parallel (scan
+// --parallel), none if the repository has a single module
+func getParallelScanModules(repositoryPath string) []string {
+	if exists, _ := fileutils.DoesFileExists(repositoryPath); !exists {
+		exitWithError(clierrors.PathNotFound.Errorf("Repository not found: %s", repositoryPath))
+	}
+	modules, err := shard.DiscoverModules(repositoryPath)
+	if err != nil {
+		exitWithError(clierrors.ShardModules.Errorf("Cannot discover modules: %s", err))
+	}
+	if len(modules) < 2 {
+		fmt.Println("> No modules found to scan in parallel, scanning the repository")
+		return nil
+	}
+	return modules
+}
+
+// Scans the modules of the repository in parallel (scan --parallel), each
+// in a privado process of its own, and writes their combined results as
+// the results of the repository
+func parallelScan(cmd *cobra.Command, repositoryPath string, modules []string, parallel int) {
+	startTime := time.Now()
+	merged := scanModulesInParallel(cmd, repositoryPath, modules, modules, parallel)
+	merged["repoName"] = filepath.Base(repositoryPath)
+
+	resultsPath := filepath.Join(repositoryPath, config.AppConfig.PrivacyResultsPathSuffix)
+	if err := os.MkdirAll(filepath.Dir(resultsPath), os.ModePerm); err != nil {
+		exitWithError(clierrors.ResultsWrite.Errorf("Cannot create results directory: %s", err))
+	}
+	if err := results.WriteRawResults(resultsPath, merged); err != nil {
+		exitWithError(clierrors.ResultsWrite.Errorf("Cannot write results: %s", err))
+	}
+
+	fmt.Printf("\n> Scanned %d module(s) in %s\n", len(modules), time.Since(startTime).Round(time.Second))
+	fmt.Println("> Merged results saved to:", resultsPath)
+}
+
+// Encrypts the outputs of the scans of the modules (results, engine logs)
+// and their logs, like the outputs of the repository (--encrypt-results)
+func encryptModuleScanOutputs(repositoryPath string, modules []string, recipient *rsa.PublicKey) {
+	for _, module := range modules {
+		if module == shard.RootModule {
+			continue
+		}
+		modulePath := filepath.Join(repositoryPath, filepath.FromSlash(module))
+		encryptScanOutputs(modulePath, filepath.Join(modulePath, getPrivadoDirectoryName(), "engine.log"), recipient)
+	}
+
+	logs, _ := filepath.Glob(filepath.Join(repositoryPath, getPrivadoDirectoryName(), "modules", "*.log"))
+	for _, logPath := range logs {
+		if err := results.EncryptFile(logPath, recipient); err != nil {
+			exitWithError(clierrors.ResultsEncrypt.Errorf("Cannot encrypt %s: %s", logPath, err))
+		}
+	}
+}
+
+// Scans the modules (relative to the repository) with up to parallel scans
+// at a time, and returns their combined results. Modules nested in a
+// module (of all discovered modules) are excluded from its scan. Exits if
+// a scan fails
+func scanModulesInParallel(cmd *cobra.Command, repositoryPath string, modules, discoveredModules []string, parallel int) map[string]interface{} {
+	logsDirectory := filepath.Join(repositoryPath, getPrivadoDirectoryName(), "modules")
+	if err := os.MkdirAll(logsDirectory, os.ModePerm); err != nil {
+		exitWithError(clierrors.ShardResultsWrite.Errorf("Cannot create modules directory: %s", err))
+	}
+	if parallel > len(modules) {
+		parallel = len(modules)
+	}
+	fmt.Printf("> Scanning %d module(s), %d at a time (logs: %s)\n", len(modules), parallel, logsDirectory)
+	fmt.Println("> Each scan runs an engine container of its own: make sure docker has enough memory for all of them")
+
+	extraArgs := getModuleScanArgs(cmd)
+	excludePaths, _ := cmd.Flags().GetStringArray("exclude-path")
+	scans := make([]*moduleScan, len(modules))
+	slots := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	completed := 0
+	for i, module := range modules {
+		scans[i] = &moduleScan{
+			module:  module,
+			logPath: filepath.Join(logsDirectory, unsafeModuleNameCharacters.ReplaceAllString(module, "_")+".log"),
+		}
+		wg.Add(1)
+		go func(s *moduleScan) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			mu.Lock()
+			fmt.Println("> Scanning module:", s.module)
+			mu.Unlock()
+
+			moduleStartTime := time.Now()
+			modulePath := filepath.Join(repositoryPath, filepath.FromSlash(s.module))
+			// the results of the modules are synced and processed (hooks, exports)
+			// combined, by the scan of the repository
+			moduleArgs := append([]string{"--no-sync", "--skip-hooks", "--open=" + openNothing}, extraArgs...)
+			for _, path := range getModuleExcludedPaths(s.module, discoveredModules, excludePaths) {
+				moduleArgs = append(moduleArgs, "--exclude-path="+path)
+			}
+			if s.err = runScanProcess(modulePath, s.logPath, moduleArgs); s.err == nil {
+				s.results, s.err = results.LoadRawResults(filepath.Join(modulePath, config.AppConfig.PrivacyResultsPathSuffix))
+			}
+			s.duration = time.Since(moduleStartTime)
+
+			mu.Lock()
+			completed++
+			status := "done"
+			if s.err != nil {
+				status = "failed"
+			}
+			fmt.Printf("> [%d/%d] Module %s %s in %s\n", completed, len(modules), s.module, status, s.duration.Round(time.Second))
+			mu.Unlock()
+		}(scans[i])
+	}
+	wg.Wait()
+
+	merged := map[string]interface{}{}
+	for _, s := range scans {
+		if s.err != nil {
+			exitWithError(clierrors.ShardResultsRead.Errorf("Scan of module %s failed: %s (log: %s)", s.module, s.err, s.logPath))
+		}
+		if s.module != "." {
+			results.PrefixFileNames(s.results, config.AppConfig.Container.SourceCodeVolumeDir, s.module)
+		}
+		results.MergeRawResults(merged, s.results)
+	}
+	return merged
+}
+
+// Returns the paths (relative to the module) excluded from the scan of the
+// module: the modules nested in it, scanned separately, and the excluded
+// paths of the repository (--exclude-path) in it
+func getModuleExcludedPaths(module string, modules, excludePaths []string) []string {
+	paths := []string{}
+	for _, path := range append(shard.NestedModules(module, modules), getExcludedDirectories(excludePaths)...) {
+		if module == shard.RootModule {
+			paths = append(paths, path)
+		} else if strings.HasPrefix(path, module+"/") {
+			paths = append(paths, strings.TrimPrefix(path, module+"/"))
+		}
+	}
+	return paths
+}
+
+// Returns the flags of the scan set by the user, to scan the modules with
+func getModuleScanArgs(cmd *cobra.Command) []string {
+	args := []string{}
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		if parallelScanExcludedFlags[flag.Name] {
+			return
+		}
+		switch flag.Value.Type() {
+		case "stringArray":
+			values, _ := cmd.Flags().GetStringArray(flag.Name)
+			for _, value := range values {
+				args = append(args, fmt.Sprintf("--%s=%s", flag.Name, value))
+			}
+		case "stringSlice":
+			values, _ := cmd.Flags().GetStringSlice(flag.Name)
+			for _, value := range values {
+				args = append(args, fmt.Sprintf("--%s=%s", flag.Name, value))
+			}
+		default:
+			args = append(args, fmt.Sprintf("--%s=%s", flag.Name, flag.Value.String()))
+		}
+	})
+	return args
+}
@@ -0,0 +1,136 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/docker"
+	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+	"github.com/Privado-Inc/privado-cli/pkg/gitutils"
+)
+
+// Estimates of the disk space a scan requires
+const (
+	// compressed image layers and the extracted image
+	imageSpaceEstimate = 4 << 30
+
+	// results and reports written to the repository
+	resultsSpaceEstimate = 100 << 20
+
+	// the engine keeps its working data (code property graph, dependencies)
+	// in the container, relative to the size of the source code
+	engineSpaceFactor = 2
+)
+
+type diskSpaceRequirement struct {
+	location string
+	path     string
+	required uint64
+}
+
+// Verifies there is enough disk space for the scan, before pulling the
+// image. The size of the source code is the size of the paths scanned,
+// without the excluded paths (relative to the repository). Locations that
+// cannot be checked are skipped
+func checkDiskSpace(repositoryPath string, copySource bool, excludedPaths []string) {
+	isExcluded := getExclusionFilter(excludedPaths)
+	sourceSize, err := fileutils.GetDirectorySize(repositoryPath, func(relativePath string, entry fs.DirEntry) bool {
+		return isExcluded(relativePath, entry) || relativePath == ".git" || relativePath == filepath.ToSlash(getPrivadoDirectoryName())
+	})
+	if err != nil {
+		fmt.Println("[WARN]: Could not check available disk space:", err)
+		return
+	}
+
+	requirements := []diskSpaceRequirement{
+		{location: "repository (results)", path: repositoryPath, required: resultsSpaceEstimate},
+	}
+	if copySource {
+		requirements = append(requirements, diskSpaceRequirement{
+			location: "temporary directory (--copy-source)",
+			path:     os.TempDir(),
+			required: uint64(sourceSize),
+		})
+	}
+	if storageInfo, err := docker.GetStorageInfo(config.AppConfig.Container.ImageURL); err == nil && storageInfo.RootDirectory != "" {
+		required := uint64(sourceSize) * engineSpaceFactor
+		if !storageInfo.ImagePresent {
+			required += imageSpaceEstimate
+		}
+		requirements = append(requirements, diskSpaceRequirement{
+			location: "docker data root",
+			path:     storageInfo.RootDirectory,
+			required: required,
+		})
+	}
+
+	failures := []string{}
+	for _, requirement := range requirements {
+		available, err := fileutils.GetAvailableDiskSpace(requirement.path)
+		if err != nil {
+			continue
+		}
+		if available < requirement.required {
+			failures = append(failures, fmt.Sprintf("  %s (%s): %s available, about %s required",
+				requirement.location, requirement.path,
+				fileutils.FormatSize(int64(available)), fileutils.FormatSize(int64(requirement.required))))
+		}
+	}
+
+	if len(failures) > 0 {
+		exitWithError(clierrors.DiskSpace.New(fmt.Sprint(
+			"Not enough disk space to scan the repository:\n",
+			strings.Join(failures, "\n"),
+			"\n\nFree up disk space and try again, or skip this check with --skip-disk-check",
+		)))
+	}
+}
+
+// Returns the paths excluded from the scan, for the disk space check:
+// excluded paths (--exclude-path), paths ignored by git (e.g. build output)
+// and vendored paths (e.g. node_modules), unless they are scanned
+func getDiskCheckExcludedPaths(repositoryPath string, excludePaths []string, includeIgnored, includeVendored bool) []string {
+	excludedPaths := getExcludedDirectories(excludePaths)
+	if !includeIgnored && gitutils.IsRepository(repositoryPath) {
+		if ignoredPaths, err := gitutils.GetIgnoredPaths(repositoryPath); err == nil {
+			excludedPaths = append(excludedPaths, ignoredPaths...)
+		}
+	}
+	if !includeVendored {
+		isExcluded := getExclusionFilter(excludedPaths)
+		vendoredPaths, err := fileutils.FindVendoredPaths(repositoryPath, getVendoredPatterns(repositoryPath), func(relativePath string, entry fs.DirEntry) bool {
+			return isExcluded(relativePath, entry) || relativePath == ".git"
+		})
+		if err == nil {
+			excludedPaths = append(excludedPaths, vendoredPaths...)
+		}
+	}
+	return excludedPaths
+}
@@ -0,0 +1,52 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"os"
+	"os/exec"
+
+	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+)
+
+// Scans the directory in a separate privado process (non-interactive),
+// writing its output to logPath. Used to run scans concurrently or
+// unattended, where a failing scan must not terminate the caller
+func runScanProcess(directory, logPath string, extraArgs []string) error {
+	logFile, err := os.Create(logPath)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	binaryPath, err := fileutils.GetPathToCurrentBinary()
+	if err != nil {
+		return err
+	}
+
+	args := append([]string{"scan", directory, "--overwrite", "--skip-update-check", "--skip-upload"}, extraArgs...)
+	scanProcess := exec.Command(binaryPath, args...)
+	scanProcess.Stdout = logFile
+	scanProcess.Stderr = logFile
+	return scanProcess.Run()
+}
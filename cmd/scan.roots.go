@@ -0,0 +1,86 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/docker"
+	"github.com/Privado-Inc/privado-cli/pkg/roots"
+	"github.com/Privado-Inc/privado-cli/pkg/utils"
+)
+
+// handling of directories outside of the repository it references (--additional-roots)
+const (
+	additionalRootsAsk   = "ask"
+	additionalRootsMount = "mount"
+	additionalRootsSkip  = "skip"
+)
+
+// Returns the directories outside of the repository it references (go.work,
+// relative path dependencies) to mount for the scan: after confirmation,
+// all of them or none (--additional-roots)
+func getAdditionalSourceRoots(repositoryPath, mode string) []docker.SourceRoot {
+	switch mode {
+	case additionalRootsAsk, additionalRootsMount:
+	case additionalRootsSkip:
+		return nil
+	default:
+		exitWithError(clierrors.InvalidFlagValue.Errorf("Invalid value for --additional-roots: %s (allowed: %s, %s, %s)", mode, additionalRootsAsk, additionalRootsMount, additionalRootsSkip))
+	}
+
+	detectedRoots, err := roots.Detect(repositoryPath)
+	if err != nil {
+		fmt.Println("[WARN]: Could not detect directories referenced by the repository:", err)
+		return nil
+	}
+	if len(detectedRoots) == 0 {
+		return nil
+	}
+
+	sourceRoots := []docker.SourceRoot{}
+	fmt.Printf("> The repository references %d director(y/ies) outside of it:\n", len(detectedRoots))
+	for _, root := range detectedRoots {
+		if _, ok := docker.GetSourceRootTarget(root.RelativePath); !ok {
+			fmt.Printf("  %s (%s): cannot be mounted, skipping\n", root.RelativePath, strings.Join(root.ReferencedBy, ", "))
+			continue
+		}
+		fmt.Printf("  %s (%s)\n", root.RelativePath, strings.Join(root.ReferencedBy, ", "))
+		sourceRoots = append(sourceRoots, docker.SourceRoot{HostPath: root.Path, RelativePath: root.RelativePath})
+	}
+	if len(sourceRoots) == 0 {
+		return nil
+	}
+
+	if mode == additionalRootsAsk {
+		confirm, _ := utils.ShowConfirmationPrompt("Mount them (read only) so dependencies across them are resolved?")
+		if !confirm {
+			fmt.Println("> Not mounting additional directories, flows through them may be incomplete (use --additional-roots=mount to mount them without asking)")
+			return nil
+		}
+	}
+	fmt.Printf("> Mounting %d additional director(y/ies)\n", len(sourceRoots))
+	return sourceRoots
+}
@@ -0,0 +1,114 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Privado-Inc/privado-cli/pkg/baseline"
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+	"github.com/Privado-Inc/privado-cli/pkg/sbom"
+)
+
+// Loads the SBOM (--sbom), nil if none is given
+func loadSBOM(sbomPath string) *sbom.SBOM {
+	if sbomPath == "" {
+		return nil
+	}
+	bom, err := sbom.Load(fileutils.GetAbsolutePath(sbomPath))
+	if err != nil {
+		exitWithError(clierrors.SBOMInvalid.Errorf("Cannot read the SBOM %s: %s", sbomPath, err))
+	}
+	fmt.Printf("> Loaded %d component(s) from the %s SBOM\n", len(bom.Components), bom.Format)
+	return bom
+}
+
+// Adds the SBOM components (with versions and suppliers) of the third-party
+// sinks of the results to them (components of the sinks in privado.json)
+func reportSBOMComponents(repositoryPath string, bom *sbom.SBOM) {
+	resultsPath := filepath.Join(repositoryPath, config.AppConfig.PrivacyResultsPathSuffix)
+	scanResults, err := results.LoadResults(resultsPath)
+	if err != nil {
+		fmt.Println("[WARN]: Could not correlate third parties with the SBOM:", err)
+		return
+	}
+	thirdParties := []results.Sink{}
+	for _, sink := range scanResults.Sinks {
+		if baseline.GetSinkCategory(sink) == "third-party-sharing" {
+			thirdParties = append(thirdParties, sink)
+		}
+	}
+	if len(thirdParties) == 0 {
+		return
+	}
+	matches := sbom.Correlate(thirdParties, bom.Components)
+
+	raw, err := results.LoadRawResults(resultsPath)
+	if err == nil {
+		addSinkComponents(raw, matches)
+		err = results.WriteRawResults(resultsPath, raw)
+	}
+	if err != nil {
+		fmt.Println("[WARN]: Could not add SBOM components to the results:", err)
+		return
+	}
+
+	fmt.Printf("> Third parties matched to SBOM components: %d of %d\n", len(matches), len(thirdParties))
+	for _, match := range matches {
+		components := []string{}
+		for _, component := range match.Components {
+			description := component.FullName()
+			if component.Version != "" {
+				description += " " + component.Version
+			}
+			if component.Supplier != "" {
+				description += fmt.Sprintf(" (%s)", component.Supplier)
+			}
+			components = append(components, description)
+		}
+		fmt.Printf("  %s: %s\n", match.SinkName, strings.Join(components, ", "))
+	}
+}
+
+func addSinkComponents(raw map[string]interface{}, matches []sbom.Match) {
+	components := map[string][]sbom.Component{}
+	for _, match := range matches {
+		components[match.SinkId] = match.Components
+	}
+
+	sinks, _ := raw["sinks"].([]interface{})
+	for _, sink := range sinks {
+		sink, ok := sink.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if id, _ := sink["id"].(string); components[id] != nil {
+			sink["components"] = components[id]
+		}
+	}
+}
@@ -0,0 +1,354 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+	"github.com/Privado-Inc/privado-cli/pkg/gitutils"
+	"github.com/spf13/cobra"
+)
+
+// handling of symbolic links in the scanned tree
+const (
+	// links are scanned as they are: links pointing outside of the
+	// repository are broken (or point to other files) in the container
+	symlinksKeep = ""
+
+	// targets of links are copied to the workspace (--follow-symlinks)
+	symlinksFollow = "follow"
+
+	// links are not copied to the workspace (--no-follow-symlinks)
+	symlinksSkip = "skip"
+)
+
+// symlinks listed in warnings, for each kind of issue
+const maxListedSymlinks = 10
+
+// excluded files listed in the report of the scan
+const maxListedFiles = 20
+
+// every masked directory (or file) is a mount, too many mounts slow
+// down (or fail) container creation
+const (
+	maxMaskedDirectories = 256
+	maxMaskedFiles       = 256
+)
+
+// directory results are written to, relative to the repository
+func getPrivadoDirectoryName() string {
+	return filepath.Dir(config.AppConfig.PrivacyResultsPathSuffix)
+}
+
+// Prepares the git checkout of the repository for the scan: initializes
+// the submodules if recurseSubmodules, else warns about the ones that are
+// not. Returns the git directory to share with the container, if outside of
+// the repository (worktrees), so the engine can read the git metadata
+func prepareGitCheckout(repositoryPath string, recurseSubmodules bool) string {
+	if !gitutils.IsRepository(repositoryPath) {
+		if gitutils.IsBareRepository(repositoryPath) {
+			exitWithError(clierrors.BareRepository.Errorf("%s is a bare repository: scan a checkout of it (git clone, or git worktree add)", repositoryPath))
+		}
+		return ""
+	}
+
+	if recurseSubmodules {
+		fmt.Println("> Initializing submodules")
+		if err := gitutils.UpdateSubmodules(repositoryPath); err != nil {
+			exitWithError(clierrors.SubmoduleUpdate.Errorf("Cannot initialize submodules: %s", err))
+		}
+	} else if submodules, err := gitutils.GetUninitializedSubmodules(repositoryPath); err == nil && len(submodules) > 0 {
+		fmt.Printf("[WARN]: %d submodule(s) are not initialized and are not scanned (use --recurse-submodules to scan them): %s\n", len(submodules), strings.Join(submodules, ", "))
+	}
+
+	gitDirectory := gitutils.GetExternalGitDirectory(repositoryPath)
+	if gitDirectory != "" && runtime.GOOS == "windows" {
+		// windows paths cannot be mounted at the same path in the container
+		fmt.Println("[WARN]: The git metadata of worktree checkouts is not available to the scan on Windows")
+		return ""
+	}
+	return gitDirectory
+}
+
+// Returns paths of the repository ignored by git (directories with a
+// trailing slash), except the privado directory results are written to
+func getIgnoredPaths(repositoryPath string) []string {
+	if !gitutils.IsRepository(repositoryPath) {
+		return nil
+	}
+	paths, err := gitutils.GetIgnoredPaths(repositoryPath)
+	if err != nil {
+		fmt.Println("[WARN]: Could not determine files ignored by git, scanning all files:", err)
+		return nil
+	}
+
+	// the privado directory is often ignored
+	privadoDirectory := filepath.ToSlash(getPrivadoDirectoryName()) + "/"
+	ignoredPaths := []string{}
+	for _, path := range paths {
+		if path != privadoDirectory {
+			ignoredPaths = append(ignoredPaths, path)
+		}
+	}
+	return ignoredPaths
+}
+
+// Returns the directories excluded from the scan (--exclude-path), as
+// paths relative to the repository
+func getExcludedDirectories(excludePaths []string) []string {
+	directories := []string{}
+	for _, path := range excludePaths {
+		if path = strings.Trim(filepath.ToSlash(filepath.Clean(path)), "/"); path != "" && path != "." {
+			directories = append(directories, path)
+		}
+	}
+	return directories
+}
+
+// Returns directories of the repository ignored by git, to be excluded
+// from the scan. Ignored files in tracked directories are not excluded
+func getIgnoredDirectories(repositoryPath string) []string {
+	directories := []string{}
+	for _, path := range getIgnoredPaths(repositoryPath) {
+		if !strings.HasSuffix(path, "/") {
+			continue
+		}
+		if len(directories) == maxMaskedDirectories {
+			fmt.Printf("[WARN]: More than %d directories are ignored by git, excluding the first %d only\n", maxMaskedDirectories, maxMaskedDirectories)
+			break
+		}
+		directories = append(directories, strings.TrimSuffix(path, "/"))
+	}
+	return directories
+}
+
+func getExclusionFilter(excludedPaths []string) func(relativePath string, entry fs.DirEntry) bool {
+	excluded := map[string]bool{}
+	for _, path := range excludedPaths {
+		excluded[strings.TrimSuffix(path, "/")] = true
+	}
+	return func(relativePath string, entry fs.DirEntry) bool {
+		return excluded[relativePath]
+	}
+}
+
+// Returns large and binary files of the repository (outside of excluded
+// paths) to be excluded from the scan, and reports them
+func getExcludedFiles(repositoryPath string, excludedPaths []string, maxFileSize int64, excludeBinary bool) []string {
+	if maxFileSize <= 0 && !excludeBinary {
+		return nil
+	}
+
+	isExcluded := getExclusionFilter(excludedPaths)
+	excludedFiles, err := fileutils.FindExcludedFiles(repositoryPath, maxFileSize, excludeBinary, func(relativePath string, entry fs.DirEntry) bool {
+		return isExcluded(relativePath, entry) || relativePath == ".git" || relativePath == filepath.ToSlash(getPrivadoDirectoryName())
+	})
+	if err != nil {
+		fmt.Println("[WARN]: Could not check for large and binary files, scanning all files:", err)
+		return nil
+	}
+	if len(excludedFiles) == 0 {
+		return nil
+	}
+
+	paths := []string{}
+	totalSize := int64(0)
+	for _, excludedFile := range excludedFiles {
+		paths = append(paths, excludedFile.Path)
+		totalSize += excludedFile.Size
+	}
+	fmt.Printf("> Excluding %d large or binary file(s) (%s) from the scan (see --max-file-size, --include-binary-files):\n", len(excludedFiles), fileutils.FormatSize(totalSize))
+	for i, excludedFile := range excludedFiles {
+		if i == maxListedFiles {
+			fmt.Printf("  ..and %d more\n", len(excludedFiles)-maxListedFiles)
+			break
+		}
+		fmt.Printf("  %s (%s, %s)\n", excludedFile.Path, fileutils.FormatSize(excludedFile.Size), excludedFile.Reason)
+	}
+	return paths
+}
+
+// Returns the patterns of vendored and generated code of the repository:
+// from its configuration (.privado/config.json), else the defaults
+func getVendoredPatterns(repositoryPath string) []string {
+	projectConfig, err := config.LoadProjectConfiguration(repositoryPath)
+	if err != nil {
+		exitWithError(clierrors.ProjectConfigInvalid.Errorf("Cannot load project configuration: %s", err))
+	}
+	if len(projectConfig.Vendored) > 0 {
+		return projectConfig.Vendored
+	}
+	return fileutils.DefaultVendoredPatterns
+}
+
+// Returns whether the dependency download is skipped, else the package
+// managers it is skipped for: --skip-dependency-download if specified,
+// else the dependency policies of the repository (.privado/config.json)
+func getSkippedDependencyManagers(cmd *cobra.Command, repositoryPath string) (bool, []string) {
+	if cmd.Flags().Changed("skip-dependency-download") {
+		skipDependencyDownload, _ := cmd.Flags().GetBool("skip-dependency-download")
+		return skipDependencyDownload, nil
+	}
+	projectConfig, err := config.LoadProjectConfiguration(repositoryPath)
+	if err != nil {
+		exitWithError(clierrors.ProjectConfigInvalid.Errorf("Cannot load project configuration: %s", err))
+	}
+	all, managers, err := projectConfig.GetSkippedDependencyManagers()
+	if err != nil {
+		exitWithError(clierrors.ProjectConfigInvalid.Errorf("Invalid dependency policies in %s: %s", config.GetProjectConfigurationPath(repositoryPath), err))
+	}
+	if all {
+		fmt.Println("> Dependency download is skipped (dependencies in .privado/config.json)")
+		return true, nil
+	}
+	if len(managers) > 0 {
+		fmt.Println("> Dependency download is skipped for:", strings.Join(managers, ", "))
+	}
+	return false, managers
+}
+
+// Returns vendored dependencies and generated code of the repository
+// (outside of excluded paths) to be excluded from the scan, and reports them.
+// Directories have a trailing slash
+func getVendoredPaths(repositoryPath string, excludedPaths []string) []string {
+	isExcluded := getExclusionFilter(excludedPaths)
+	vendoredPaths, err := fileutils.FindVendoredPaths(repositoryPath, getVendoredPatterns(repositoryPath), func(relativePath string, entry fs.DirEntry) bool {
+		return isExcluded(relativePath, entry) || relativePath == ".git" || relativePath == filepath.ToSlash(getPrivadoDirectoryName())
+	})
+	if err != nil {
+		fmt.Println("[WARN]: Could not check for vendored and generated code, scanning all files:", err)
+		return nil
+	}
+	if len(vendoredPaths) == 0 {
+		return nil
+	}
+
+	fmt.Printf("> Excluding %d vendored or generated path(s) from the scan (use --include-vendored to scan them):\n", len(vendoredPaths))
+	for i, vendoredPath := range vendoredPaths {
+		if i == maxListedFiles {
+			fmt.Printf("  ..and %d more\n", len(vendoredPaths)-maxListedFiles)
+			break
+		}
+		fmt.Printf("  %s\n", vendoredPath)
+	}
+	return vendoredPaths
+}
+
+// Returns an empty file (removed on exit) to be mounted over excluded files
+func createMaskFile() (string, error) {
+	file, err := os.CreateTemp("", "privado-excluded-")
+	if err != nil {
+		return "", err
+	}
+	registerExitHook(func(isError bool) {
+		_ = os.Remove(file.Name())
+	})
+	return file.Name(), file.Close()
+}
+
+// Warns about symbolic links (outside of excluded paths) that point outside
+// of the repository, are broken or create cycles
+func auditSymlinks(repositoryPath string, excludedPaths []string, symlinkPolicy string) {
+	isExcluded := getExclusionFilter(excludedPaths)
+	symlinks, err := fileutils.AuditSymlinks(repositoryPath, func(relativePath string, entry fs.DirEntry) bool {
+		return isExcluded(relativePath, entry) || relativePath == ".git"
+	})
+	if err != nil {
+		fmt.Println("[WARN]: Could not audit symbolic links:", err)
+		return
+	}
+
+	warn := func(message string, matches func(fileutils.Symlink) bool) {
+		matching := []string{}
+		for _, symlink := range symlinks {
+			if matches(symlink) {
+				matching = append(matching, fmt.Sprintf("  %s -> %s", symlink.Path, symlink.Target))
+			}
+		}
+		if len(matching) == 0 {
+			return
+		}
+		fmt.Printf("[WARN]: %d symbolic link(s) %s:\n", len(matching), message)
+		if len(matching) > maxListedSymlinks {
+			matching = append(matching[:maxListedSymlinks], fmt.Sprintf("  ..and %d more", len(matching)-maxListedSymlinks))
+		}
+		fmt.Println(strings.Join(matching, "\n"))
+	}
+
+	switch symlinkPolicy {
+	case symlinksFollow:
+		warn("point outside of the repository, their targets are scanned", func(s fileutils.Symlink) bool { return s.EscapesRoot })
+		warn("create cycles and are not followed", func(s fileutils.Symlink) bool { return s.Cycle })
+	case symlinksKeep:
+		warn("point outside of the repository and cannot be resolved in the scan (see --follow-symlinks)", func(s fileutils.Symlink) bool { return s.EscapesRoot })
+	}
+	warn("are broken", func(s fileutils.Symlink) bool { return s.Broken })
+}
+
+// Copies the repository (without excluded paths) to a temporary workspace
+// to be scanned instead of the repository. Returns the workspace, which is
+// removed on exit
+func copySourceToWorkspace(repositoryPath string, excludedPaths []string, symlinkPolicy string) string {
+	isExcluded := getExclusionFilter(excludedPaths)
+
+	workspace, err := os.MkdirTemp("", "privado-source-")
+	if err != nil {
+		exitWithError(clierrors.WorkspaceCreation.Errorf("Cannot create workspace to copy the source code: %s", err))
+	}
+	registerExitHook(func(isError bool) {
+		_ = os.RemoveAll(workspace)
+	})
+
+	fmt.Println("> Copying source code to:", workspace)
+	switch symlinkPolicy {
+	case symlinksFollow:
+		err = fileutils.CopyDirectoryFollowingSymlinks(repositoryPath, workspace, isExcluded)
+	case symlinksSkip:
+		err = fileutils.CopyDirectory(repositoryPath, workspace, func(relativePath string, entry fs.DirEntry) bool {
+			return isExcluded(relativePath, entry) || entry.Type()&fs.ModeSymlink != 0
+		})
+	default:
+		err = fileutils.CopyDirectory(repositoryPath, workspace, isExcluded)
+	}
+	if err != nil {
+		exitWithError(clierrors.WorkspaceCopy.Errorf("Cannot copy the source code: %s", err))
+	}
+	return workspace
+}
+
+// Copies results (and reports) of the scan from the workspace to the repository
+func copyResultsFromWorkspace(workspace, repositoryPath string) error {
+	return fileutils.CopyDirectory(
+		filepath.Join(workspace, getPrivadoDirectoryName()),
+		filepath.Join(repositoryPath, getPrivadoDirectoryName()),
+		nil,
+	)
+}
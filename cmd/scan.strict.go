@@ -0,0 +1,129 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/docker"
+)
+
+// output of the engine for problems that do not fail the scan, but leave
+// parts of the repository unscanned (failing the scan with --strict)
+var engineWarningCategories = []struct {
+	name     string
+	messages []string
+}{
+	{"dependency resolution failures", []string{"Could not resolve dependencies", "Failed to resolve dependency", "Dependency resolution failed", "Failed to download dependencies"}},
+	{"parse errors", []string{"Failed to parse", "Error while parsing", "Unable to parse", "Parse error"}},
+	{"skipped files", []string{"Skipping file", "Skipped file"}},
+}
+
+// number of lines shown per category in the summary
+const maxEngineWarningLines = 5
+
+type engineWarnings struct {
+	mu    sync.Mutex
+	lines map[string][]string
+}
+
+func newEngineWarnings() *engineWarnings {
+	return &engineWarnings{lines: map[string][]string{}}
+}
+
+// returns the option collecting the warnings from the engine output
+func (w *engineWarnings) runImageOption() docker.RunImageOption {
+	messages := []string{}
+	for _, category := range engineWarningCategories {
+		messages = append(messages, category.messages...)
+	}
+	return docker.OptionWithOutputListener(messages, w.add)
+}
+
+func (w *engineWarnings) add(line string) {
+	line = strings.TrimSpace(line)
+	for _, category := range engineWarningCategories {
+		for _, message := range category.messages {
+			if strings.Contains(line, message) {
+				w.mu.Lock()
+				w.lines[category.name] = append(w.lines[category.name], line)
+				w.mu.Unlock()
+				return
+			}
+		}
+	}
+}
+
+// returns the collected lines of the categories
+func (w *engineWarnings) getLines(categories ...string) []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	lines := []string{}
+	for _, category := range categories {
+		lines = append(lines, w.lines[category]...)
+	}
+	return lines
+}
+
+func (w *engineWarnings) count() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	count := 0
+	for _, lines := range w.lines {
+		count += len(lines)
+	}
+	return count
+}
+
+func (w *engineWarnings) printSummary() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fmt.Println("\n> The engine reported problems, parts of the repository may not have been scanned:")
+	for _, category := range engineWarningCategories {
+		lines := w.lines[category.name]
+		if len(lines) == 0 {
+			continue
+		}
+		fmt.Printf("> %d %s\n", len(lines), category.name)
+		for i, line := range lines {
+			if i == maxEngineWarningLines {
+				fmt.Printf("\t... and %d more (see 'privado logs')\n", len(lines)-maxEngineWarningLines)
+				break
+			}
+			fmt.Println("\t" + line)
+		}
+	}
+}
+
+// fails the scan (--strict) if the engine reported any problems
+func checkEngineWarnings(warnings *engineWarnings) {
+	count := warnings.count()
+	if count == 0 {
+		return
+	}
+	warnings.printSummary()
+	exitWithError(clierrors.EngineWarnings.Errorf("Scan failed in strict mode: the engine reported %d problem(s)", count))
+}
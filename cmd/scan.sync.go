@@ -0,0 +1,158 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Privado-Inc/privado-cli/pkg/cloud"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/docker"
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+	"github.com/Privado-Inc/privado-cli/pkg/utils"
+)
+
+// Returns whether changes of the results of the repository can be synced
+// instead of all results: a scan of the repository was synced before, with
+// the same snippet stripping, and credentials are available
+func hasSyncState(repositoryPath string, stripSnippets bool) bool {
+	if getAPIToken() == "" {
+		return false
+	}
+	state, _, err := cloud.LoadSyncState(config.AppConfig.SyncStateDirectory, repositoryPath)
+	return err == nil && state.StripSnippets == stripSnippets
+}
+
+// Returns a directory (removed on exit) with a copy of the results of the
+// repository without source snippets, to be uploaded instead of the
+// results: the snippets never leave the machine
+func getStrippedResultsDirectory(repositoryPath string) (string, error) {
+	raw, err := results.LoadRawResults(filepath.Join(repositoryPath, config.AppConfig.PrivacyResultsPathSuffix))
+	if err != nil {
+		return "", err
+	}
+	cloud.StripSnippets(raw)
+
+	directory, err := os.MkdirTemp("", "privado-sync-")
+	if err != nil {
+		return "", err
+	}
+	registerExitHook(func(isError bool) {
+		_ = os.RemoveAll(directory)
+	})
+	resultsPath := filepath.Join(directory, config.AppConfig.PrivacyResultsPathSuffix)
+	if err := os.MkdirAll(filepath.Dir(resultsPath), os.ModePerm); err != nil {
+		return "", err
+	}
+	return directory, results.WriteRawResults(resultsPath, raw)
+}
+
+// Uploads the changes of the results of the repository since the last
+// synced scan, and records the new synced scan
+func syncResultsDelta(repositoryPath string, stripSnippets bool) error {
+	state, syncedResults, err := cloud.LoadSyncState(config.AppConfig.SyncStateDirectory, repositoryPath)
+	if err != nil {
+		return err
+	}
+	resultsPath := filepath.Join(repositoryPath, config.AppConfig.PrivacyResultsPathSuffix)
+	currentResults, err := results.LoadRawResults(resultsPath)
+	if err != nil {
+		return err
+	}
+	if stripSnippets {
+		cloud.StripSnippets(syncedResults)
+		cloud.StripSnippets(currentResults)
+	}
+
+	delta := cloud.ComputeDelta(state.ScanId, syncedResults, currentResults)
+	if delta.IsEmpty() {
+		fmt.Printf("> Results did not change since the last synced scan (%s), nothing to upload\n", state.ScanId)
+		return nil
+	}
+
+	fmt.Printf("> Uploading %d change(s) since the last synced scan (%s)\n", delta.CountChanges(), state.ScanId)
+	scan, err := cloud.NewClient(getAPIToken()).UploadScanDelta(delta, getOrganizationId())
+	if err != nil {
+		var apiError *cloud.APIError
+		if errors.As(err, &apiError) && (apiError.StatusCode == 404 || apiError.StatusCode == 409) {
+			// the synced scan is gone or outdated, the next sync uploads all results
+			_ = cloud.RemoveSyncState(config.AppConfig.SyncStateDirectory, repositoryPath)
+		}
+		return err
+	}
+	fmt.Println("> Changes synced to Privado Cloud, scan:", scan.Id)
+
+	newState := cloud.SyncState{ScanId: scan.Id, SyncedAt: time.Now().UTC(), StripSnippets: stripSnippets}
+	if err := cloud.SaveSyncState(config.AppConfig.SyncStateDirectory, repositoryPath, newState, resultsPath); err != nil {
+		fmt.Println("[WARN]: Could not record the synced scan, the next sync uploads all results:", err)
+		_ = cloud.RemoveSyncState(config.AppConfig.SyncStateDirectory, repositoryPath)
+	}
+	return nil
+}
+
+// the engine prints the url of the scan it synced when the upload succeeded
+const syncedScanMessage = "> Continue to view results on:"
+
+// Collects the id of the scan the engine synced from its output (the
+// response of its upload)
+type syncedScanListener struct {
+	mu     sync.Mutex
+	scanId string
+}
+
+func (l *syncedScanListener) runImageOption() docker.RunImageOption {
+	return docker.OptionWithOutputListener([]string{syncedScanMessage}, func(line string) {
+		if scanId := cloud.GetScanIdFromURL(utils.ExtractURLFromString(line)); scanId != "" {
+			l.mu.Lock()
+			l.scanId = scanId
+			l.mu.Unlock()
+		}
+	})
+}
+
+func (l *syncedScanListener) getScanId() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.scanId
+}
+
+// Records the scan the engine synced after all results were uploaded, so
+// that the next sync uploads changes only. Without the id of the scan (not
+// in the output of the engine) the next sync uploads all results again
+func recordSyncedScan(repositoryPath string, stripSnippets bool, scanId string) {
+	if getAPIToken() == "" || scanId == "" {
+		_ = cloud.RemoveSyncState(config.AppConfig.SyncStateDirectory, repositoryPath)
+		return
+	}
+
+	state := cloud.SyncState{ScanId: scanId, SyncedAt: time.Now().UTC(), StripSnippets: stripSnippets}
+	resultsPath := filepath.Join(repositoryPath, config.AppConfig.PrivacyResultsPathSuffix)
+	if err := cloud.SaveSyncState(config.AppConfig.SyncStateDirectory, repositoryPath, state, resultsPath); err != nil {
+		fmt.Println("[WARN]: Could not record the synced scan, the next sync uploads all results:", err)
+	}
+}
@@ -0,0 +1,114 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/progress"
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+	"github.com/Privado-Inc/privado-cli/pkg/syslog"
+)
+
+// Sends the progress events of the scan (phases, finding summary, warnings
+// and errors) to the syslog target (--syslog, else the configured one)
+func startScanSyslog(target, repositoryPath string) {
+	if target == "" {
+		target = config.UserConfig.ConfigFile.Syslog
+	}
+	if target == "" {
+		return
+	}
+
+	writer, err := syslog.Dial(target)
+	if err != nil {
+		fmt.Println("[WARN]: Cannot connect to syslog, events are not sent:", err)
+		return
+	}
+	registerExitHook(func(isError bool) {
+		writer.Close()
+	})
+
+	repository := filepath.Base(repositoryPath)
+	warned := false
+	progress.AddListener(func(event progress.Event) {
+		severity, params, message := formatSyslogEvent(event)
+		params["repository"] = repository
+		if err := writer.Send(severity, event.Type, params, message); err != nil && !warned {
+			warned = true
+			fmt.Println("[WARN]: Cannot send event to syslog:", err)
+		}
+	})
+}
+
+func formatSyslogEvent(event progress.Event) (severity int, params map[string]string, message string) {
+	params = map[string]string{}
+	if event.Phase != "" {
+		params["phase"] = event.Phase
+	}
+
+	switch event.Type {
+	case progress.EventPhaseStarted:
+		return syslog.SeverityInfo, params, fmt.Sprintf("Phase %s started", event.Phase)
+
+	case progress.EventPhaseCompleted:
+		if event.DurationMs != nil {
+			params["durationMs"] = strconv.FormatInt(*event.DurationMs, 10)
+		}
+		if event.Success != nil && !*event.Success {
+			params["success"] = "false"
+			return syslog.SeverityError, params, fmt.Sprintf("Phase %s failed: %s", event.Phase, event.Error)
+		}
+		params["success"] = "true"
+		return syslog.SeverityInfo, params, fmt.Sprintf("Phase %s completed", event.Phase)
+
+	case progress.EventFindingCount:
+		total := 0
+		if event.Total != nil {
+			total = *event.Total
+		}
+		params["total"] = strconv.Itoa(total)
+		counts := []string{}
+		for _, severity := range results.Severities {
+			params[severity] = strconv.Itoa(event.BySeverity[severity])
+			counts = append(counts, fmt.Sprintf("%s: %d", severity, event.BySeverity[severity]))
+		}
+		message = fmt.Sprintf("Scan reported %d finding(s) (%s)", total, strings.Join(counts, ", "))
+		if total > 0 {
+			return syslog.SeverityNotice, params, message
+		}
+		return syslog.SeverityInfo, params, message
+
+	case progress.EventWarning:
+		return syslog.SeverityWarning, params, event.Message
+
+	case progress.EventError:
+		params["code"] = event.Code
+		return syslog.SeverityError, params, fmt.Sprintf("[%s] %s", event.Code, event.Message)
+	}
+	return syslog.SeverityInfo, params, event.Message
+}
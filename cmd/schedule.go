@@ -0,0 +1,167 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+	"github.com/Privado-Inc/privado-cli/pkg/schedule"
+	"github.com/spf13/cobra"
+)
+
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Manage recurring scans run by 'privado daemon'",
+}
+
+var scheduleAddCmd = &cobra.Command{
+	Use:               "add <repository>",
+	Short:             "Schedule recurring scans of a repository",
+	Long:              "Schedule recurring scans of a repository with a cron expression, e.g. 'privado schedule add ./repo --cron \"0 3 * * 1\"' to scan every monday at 03:00. Scheduled scans are run by 'privado daemon'",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeDirectoryArgument,
+	Run:               scheduleAdd,
+}
+
+var scheduleListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List scheduled scans",
+	Args:  cobra.ExactArgs(0),
+	Run:   scheduleList,
+}
+
+var scheduleRemoveCmd = &cobra.Command{
+	Use:               "remove <id>",
+	Short:             "Remove a scheduled scan",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeScheduleId,
+	Run:               scheduleRemove,
+}
+
+func loadSchedulesOrExit() []*schedule.Schedule {
+	schedules, err := schedule.Load(config.AppConfig.SchedulesPath)
+	if err != nil {
+		exitWithError(clierrors.SchedulesLoad.Errorf("Cannot load schedules: %s", err))
+	}
+	return schedules
+}
+
+func scheduleAdd(cmd *cobra.Command, args []string) {
+	repository := fileutils.GetAbsolutePath(args[0])
+	cron, _ := cmd.Flags().GetString("cron")
+	scanArgs, _ := cmd.Flags().GetStringSlice("scan-args")
+	webhooks, _ := cmd.Flags().GetStringSlice("notify-webhook")
+	emailReports, _ := cmd.Flags().GetStringSlice("email-report")
+	reportFormat, _ := cmd.Flags().GetString("report-format")
+
+	if exists, _ := fileutils.DoesFileExists(repository); !exists {
+		exitWithError(clierrors.PathNotFound.Errorf("Could not find repository: %s", repository))
+	}
+	if cron == "" {
+		exitWithError(clierrors.ConflictingOptions.New("A cron expression is required: --cron \"<minute> <hour> <day-of-month> <month> <day-of-week>\""))
+	}
+	if _, ok := reportFormatExtensions[reportFormat]; !ok {
+		exitWithError(clierrors.InvalidFlagValue.Errorf("Invalid value for --report-format: %s (allowed: %s)", reportFormat, strings.Join(reportFormats, ", ")))
+	}
+	if len(emailReports) > 0 && config.UserConfig.ConfigFile.SMTP == nil {
+		fmt.Println("[WARN]: No SMTP server is configured (smtp in ~/.privado/config.json), reports cannot be emailed until it is")
+	}
+
+	newSchedule, err := schedule.NewSchedule(repository, cron)
+	if err != nil {
+		exitWithError(clierrors.InvalidFlagValue.Wrap(err))
+	}
+	newSchedule.ScanArgs = scanArgs
+	newSchedule.NotifyWebhooks = webhooks
+	if len(emailReports) > 0 {
+		newSchedule.EmailReports = emailReports
+		newSchedule.ReportFormat = reportFormat
+	}
+
+	schedules := append(loadSchedulesOrExit(), newSchedule)
+	if err := schedule.Save(config.AppConfig.SchedulesPath, schedules); err != nil {
+		exitWithError(clierrors.SchedulesSave.Errorf("Cannot save schedules: %s", err))
+	}
+
+	fmt.Println("> Scheduled scan:", newSchedule.Id)
+	fmt.Println("> Next run:", newSchedule.NextRun(time.Now()).Format("2006-01-02 15:04"))
+	exit("> Scheduled scans are run while 'privado daemon' is running", false)
+}
+
+func scheduleList(cmd *cobra.Command, args []string) {
+	schedules := loadSchedulesOrExit()
+	if len(schedules) == 0 {
+		exit("> No scheduled scans. Use 'privado schedule add' to schedule one", false)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tCRON\tREPOSITORY\tNEXT RUN\tLAST RUN\tLAST STATUS")
+	for _, s := range schedules {
+		lastRun := "-"
+		if !s.LastRunAt.IsZero() {
+			lastRun = s.LastRunAt.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", s.Id, s.Cron, s.Repository, s.NextRun(time.Now()).Format("2006-01-02 15:04"), lastRun, s.LastStatus)
+	}
+	w.Flush()
+}
+
+func scheduleRemove(cmd *cobra.Command, args []string) {
+	schedules := loadSchedulesOrExit()
+
+	remaining := []*schedule.Schedule{}
+	for _, s := range schedules {
+		if s.Id != args[0] {
+			remaining = append(remaining, s)
+		}
+	}
+	if len(remaining) == len(schedules) {
+		exitWithError(clierrors.ScheduleNotFound.Errorf("No scheduled scan with id: %s", args[0]))
+	}
+
+	if err := schedule.Save(config.AppConfig.SchedulesPath, remaining); err != nil {
+		exitWithError(clierrors.SchedulesSave.Errorf("Cannot save schedules: %s", err))
+	}
+	exit(fmt.Sprintf("> Removed scheduled scan: %s", args[0]), false)
+}
+
+func init() {
+	scheduleAddCmd.Flags().String("cron", "", "Cron expression (minute hour day-of-month month day-of-week) of the schedule, in local time")
+	scheduleAddCmd.Flags().StringSlice("scan-args", []string{}, "Additional flags passed to each scheduled scan, e.g. --scan-args=--skip-dependency-download")
+	scheduleAddCmd.Flags().StringSlice("notify-webhook", []string{}, "URL notified (POST, JSON) after each scheduled scan")
+	scheduleAddCmd.Flags().StringSlice("email-report", []string{}, "Email the report of each successful scheduled scan to the recipients, with the SMTP server in ~/.privado/config.json (smtp)")
+	scheduleAddCmd.Flags().String("report-format", "pdf", fmt.Sprintf("Format of the emailed report (%s)", strings.Join(reportFormats, ", ")))
+	_ = scheduleAddCmd.RegisterFlagCompletionFunc("report-format", completeValues(reportFormats...))
+
+	scheduleCmd.AddCommand(scheduleAddCmd)
+	scheduleCmd.AddCommand(scheduleListCmd)
+	scheduleCmd.AddCommand(scheduleRemoveCmd)
+	rootCmd.AddCommand(scheduleCmd)
+}
@@ -0,0 +1,127 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+	"github.com/Privado-Inc/privado-cli/pkg/server"
+	"github.com/spf13/cobra"
+)
+
+var serverCmd = &cobra.Command{
+	Use:   "server",
+	Short: "Run Privado CLI as a scan service with an HTTP API",
+	Long: fmt.Sprint(
+		"Run Privado CLI as a scan service with an HTTP API to trigger scans, query their status and progress, fetch results and manage baselines. ",
+		"With --grpc-address, scans can also be triggered, followed (streaming their output) and their results fetched with a gRPC API (api/proto/privado/v1/scan_service.proto). ",
+		"Push and pull/merge request webhooks of GitHub (/webhooks/github) and GitLab (/webhooks/gitlab) trigger scans of the commit when ",
+		"$PRIVADO_GITHUB_WEBHOOK_SECRET or $PRIVADO_GITLAB_WEBHOOK_SECRET is set; results are commented on the pull/merge request using $GITHUB_TOKEN or $GITLAB_TOKEN",
+	),
+	Args: cobra.ExactArgs(0),
+	Run:  runServer,
+}
+
+func runServer(cmd *cobra.Command, args []string) {
+	address, _ := cmd.Flags().GetString("address")
+	grpcAddress, _ := cmd.Flags().GetString("grpc-address")
+	token, _ := cmd.Flags().GetString("token")
+	roots, _ := cmd.Flags().GetStringSlice("root")
+	maxConcurrentScans, _ := cmd.Flags().GetInt("max-concurrent-scans")
+	maxQueuedScans, _ := cmd.Flags().GetInt("max-queued-scans")
+	jobRetention, _ := cmd.Flags().GetDuration("job-retention")
+	webhookRepositories, _ := cmd.Flags().GetStringSlice("webhook-repository")
+	gitLabHost, _ := cmd.Flags().GetString("gitlab-host")
+
+	if maxConcurrentScans < 1 {
+		exitWithError(clierrors.InvalidFlagValue.New("Invalid value for --max-concurrent-scans: must be at least 1"))
+	}
+
+	if token == "" {
+		token = os.Getenv("PRIVADO_SERVER_TOKEN")
+	}
+	if token == "" {
+		fmt.Println("[WARN]: No --token specified, the API is not authenticated")
+	}
+
+	if len(roots) == 0 {
+		cwd, _ := os.Getwd()
+		roots = []string{cwd}
+	}
+	for i, root := range roots {
+		roots[i] = fileutils.GetAbsolutePath(root)
+		fmt.Println("> Repositories can be scanned under:", roots[i])
+	}
+
+	s := server.New(server.Config{
+		Address:            address,
+		GRPCAddress:        grpcAddress,
+		Token:              token,
+		AllowedRoots:       roots,
+		JobsDirectory:      config.AppConfig.ServerJobsDirectory,
+		MaxConcurrentScans: maxConcurrentScans,
+		MaxQueuedScans:     maxQueuedScans,
+		JobRetention:       jobRetention,
+		Webhooks: server.WebhookConfig{
+			GitHubSecret: os.Getenv("PRIVADO_GITHUB_WEBHOOK_SECRET"),
+			GitHubToken:  os.Getenv("GITHUB_TOKEN"),
+			GitLabSecret: os.Getenv("PRIVADO_GITLAB_WEBHOOK_SECRET"),
+			GitLabToken:  os.Getenv("GITLAB_TOKEN"),
+			GitLabHost:   gitLabHost,
+			Repositories: webhookRepositories,
+		},
+		// hooks of scanned repositories are not trusted to run on the server
+		Scan: func(directory, logPath string, extraArgs []string) error {
+			return runScanProcess(directory, logPath, append(extraArgs, "--skip-hooks"))
+		},
+	})
+
+	fmt.Println("> Listening on:", address)
+	if grpcAddress != "" {
+		fmt.Println("> gRPC API listening on:", grpcAddress)
+	}
+	if err := s.ListenAndServe(); err != nil {
+		exitWithError(clierrors.ServerStopped.Errorf("Server stopped: %s", err))
+	}
+}
+
+func init() {
+	serverCmd.Flags().String("address", "127.0.0.1:8440", "Address to listen on")
+	serverCmd.Flags().String("grpc-address", "", "Address to serve the gRPC API (ScanService) on, e.g. 127.0.0.1:8441 (default: not served)")
+	serverCmd.Flags().String("token", "", "Bearer token required to call the API (default: $PRIVADO_SERVER_TOKEN)")
+	serverCmd.Flags().StringSlice("root", []string{}, "Directory repositories can be scanned under; can be repeated (default: current directory)")
+
+	serverCmd.Flags().Int("max-concurrent-scans", 1, "Scans running at the same time, further scans are queued; scans of the same repository run one at a time")
+	serverCmd.Flags().Int("max-queued-scans", 100, "Scans waiting in the queue, further requests are rejected (0: unlimited)")
+	serverCmd.Flags().Duration("job-retention", 7*24*time.Hour, "How long completed scans, their logs and results are kept (per scan: \"retention\" in the request)")
+
+	serverCmd.Flags().StringSlice("webhook-repository", []string{}, "Glob pattern of repositories (owner/name) webhooks can trigger scans for; can be repeated (default: all)")
+	serverCmd.Flags().String("gitlab-host", "https://gitlab.com", "GitLab instance sending webhooks")
+
+	rootCmd.AddCommand(serverCmd)
+}
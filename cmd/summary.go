@@ -0,0 +1,145 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/history"
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+	"github.com/Privado-Inc/privado-cli/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var summaryCmd = &cobra.Command{
+	Use:   "summary",
+	Short: "Summarize local scans of all repositories",
+	Long: fmt.Sprint(
+		"Aggregate the local scan history of all repositories over a period: findings of each repository, ",
+		"their trend, new and resolved findings and the most common data elements. Every completed scan ",
+		"is recorded in ", config.AppConfig.HistoryDirectory,
+	),
+	Args: cobra.NoArgs,
+	Run:  summary,
+}
+
+// Parses the start of the period: a duration before now (see
+// utils.ParseDuration, e.g. 30d) or a date (YYYY-MM-DD)
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if date, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return date, nil
+	}
+	duration, err := utils.ParseDuration(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid period '%s' (e.g. 30d, 2w, 12h or 2006-01-02)", value)
+	}
+	return now.Add(-duration), nil
+}
+
+func summary(cmd *cobra.Command, args []string) {
+	sinceValue, _ := cmd.Flags().GetString("since")
+	format, _ := cmd.Flags().GetString("format")
+
+	if format != "table" && format != "json" {
+		exitWithError(clierrors.InvalidFlagValue.Errorf("Invalid format '%s' (table, json)", format))
+	}
+	now := time.Now()
+	since, err := parseSince(sinceValue, now)
+	if err != nil {
+		exitWithError(clierrors.InvalidFlagValue.Errorf("Invalid --since: %s", err))
+	}
+
+	scans, err := history.LoadScans(config.AppConfig.HistoryDirectory)
+	if err != nil {
+		exitWithError(clierrors.HistoryRead.Errorf("Cannot read the scan history: %s", err))
+	}
+	scanSummary := history.Summarize(scans, since, now)
+
+	if format == "json" {
+		data, _ := json.MarshalIndent(scanSummary, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+
+	if len(scanSummary.Repositories) == 0 {
+		exit(fmt.Sprintf("> No scans since %s. Scans are recorded by 'privado scan'", since.Format("2006-01-02 15:04")), false)
+	}
+	printSummary(scanSummary)
+}
+
+// Formats a change in the number of findings, e.g. +3, -2, 0
+func formatChange(change int) string {
+	if change > 0 {
+		return fmt.Sprintf("+%d", change)
+	}
+	return strconv.Itoa(change)
+}
+
+func printSummary(s history.Summary) {
+	fmt.Printf("> %d scan(s) of %d repositories since %s\n", s.Scans, len(s.Repositories), s.Since.Local().Format("2006-01-02 15:04"))
+	fmt.Printf("> %d finding(s): %d new, %d resolved\n\n", s.Findings, s.New, s.Resolved)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "REPOSITORY\tSCANS\tLAST SCAN\tFINDINGS\tHIGH\tMEDIUM\tLOW\tCHANGE\tNEW\tRESOLVED")
+	for _, repository := range s.Repositories {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%d\t%d\t%d\t%d\t%s\t%d\t%d\n",
+			repository.RepoName, repository.Scans, repository.LastScanAt.Local().Format("2006-01-02 15:04"),
+			repository.Findings, repository.FindingsBySeverity[results.SeverityHigh],
+			repository.FindingsBySeverity[results.SeverityMedium], repository.FindingsBySeverity[results.SeverityLow],
+			formatChange(repository.Change), repository.New, repository.Resolved)
+	}
+	w.Flush()
+
+	if len(s.Trend) > 1 {
+		fmt.Println("\n> Findings trend:")
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, point := range s.Trend {
+			fmt.Fprintf(w, "  %s\t%d\n", point.Date.Local().Format("2006-01-02"), point.Findings)
+		}
+		w.Flush()
+	}
+
+	if len(s.TopDataElements) > 0 {
+		fmt.Println("\n> Top data elements:")
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, dataElement := range s.TopDataElements {
+			fmt.Fprintf(w, "  %s\t%d repositories\n", dataElement.Name, dataElement.Repositories)
+		}
+		w.Flush()
+	}
+}
+
+func init() {
+	summaryCmd.Flags().String("since", "30d", "Start of the period to summarize: a duration before now (e.g. 30d, 2w, 12h) or a date (YYYY-MM-DD)")
+	summaryCmd.Flags().String("format", "table", "Format of the summary (table, json)")
+	_ = summaryCmd.RegisterFlagCompletionFunc("format", completeValues("table", "json"))
+
+	rootCmd.AddCommand(summaryCmd)
+}
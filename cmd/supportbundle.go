@@ -0,0 +1,304 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Privado-Inc/privado-cli/pkg/ci"
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/diagnostics"
+	"github.com/Privado-Inc/privado-cli/pkg/docker"
+	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+	"github.com/Privado-Inc/privado-cli/pkg/logs"
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+	"github.com/Privado-Inc/privado-cli/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var supportBundleCmd = &cobra.Command{
+	Use:               "support-bundle [repository]",
+	Short:             "Collect diagnostics into an archive to attach to support tickets",
+	Long:              "Collect the sanitized configuration, recent scan logs, thread dumps and crash reports, docker details, image digests, the manifest of the last scan of the repository (default: current directory) and environment details into a single archive to attach to support tickets. Secrets, emails, paths and the terms of --redact are redacted, and files can be reviewed before the archive is written. Source code and scan results are never included",
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeDirectoryArgument,
+	Run:               createSupportBundle,
+}
+
+// recent thread dumps and crash reports in the bundle
+const supportBundleMaxDiagnostics = 5
+
+type supportEnvironment struct {
+	OS         string `json:"os"`
+	Arch       string `json:"arch"`
+	GoVersion  string `json:"goVersion"`
+	CPUs       int    `json:"cpus"`
+	CI         bool   `json:"ci"`
+	CIProvider string `json:"ciProvider,omitempty"`
+	// names of the privado environment variables that are set, never their values
+	PrivadoVariables []string `json:"privadoVariables"`
+	Docker           struct {
+		DataRoot     string `json:"dataRoot,omitempty"`
+		ImagePresent bool   `json:"imagePresent"`
+		ImageSize    int64  `json:"imageSize,omitempty"`
+		Error        string `json:"error,omitempty"`
+	} `json:"docker"`
+	Caches map[string]string `json:"caches"`
+}
+
+func getSupportEnvironment() supportEnvironment {
+	environment := supportEnvironment{
+		OS:               runtime.GOOS,
+		Arch:             runtime.GOARCH,
+		GoVersion:        runtime.Version(),
+		CPUs:             runtime.NumCPU(),
+		CI:               ci.CISessionConfig.IsCI,
+		PrivadoVariables: []string{},
+		Caches:           map[string]string{},
+	}
+	if provider := ci.CISessionConfig.Provider; provider != nil {
+		environment.CIProvider = provider.Name
+	}
+	for _, variable := range os.Environ() {
+		if name := strings.SplitN(variable, "=", 2)[0]; strings.HasPrefix(name, "PRIVADO_") {
+			environment.PrivadoVariables = append(environment.PrivadoVariables, name)
+		}
+	}
+	sort.Strings(environment.PrivadoVariables)
+
+	if storage, err := docker.GetStorageInfo(config.AppConfig.Container.ImageURL); err != nil {
+		environment.Docker.Error = err.Error()
+	} else {
+		environment.Docker.DataRoot = storage.RootDirectory
+		environment.Docker.ImagePresent = storage.ImagePresent
+		environment.Docker.ImageSize = storage.ImageSize
+	}
+
+	for _, packageManager := range []string{"m2", "gradle", "npm"} {
+		if directory, err := config.GetPackageCacheDirectory(packageManager); err == nil {
+			environment.Caches[packageManager] = directory
+		}
+	}
+	environment.Caches["jvm"] = config.GetJVMCacheDirectory()
+	return environment
+}
+
+// Returns the paths of the newest count files of the directory with the
+// suffix, newest first
+func getNewestFiles(directory, suffix string, count int) []string {
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		return nil
+	}
+
+	type file struct {
+		path    string
+		modTime time.Time
+	}
+	files := []file{}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() || !strings.HasSuffix(entry.Name(), suffix) {
+			continue
+		}
+		files = append(files, file{filepath.Join(directory, entry.Name()), info.ModTime()})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.After(files[j].modTime) })
+
+	paths := []string{}
+	for i := 0; i < len(files) && i < count; i++ {
+		paths = append(paths, files[i].path)
+	}
+	return paths
+}
+
+// Collects the files of the support bundle (not yet redacted)
+func collectSupportFiles(repositoryPath string, logCount int) []diagnostics.SupportFile {
+	files := []diagnostics.SupportFile{}
+	addJSON := func(bundlePath string, value interface{}) {
+		data, _ := json.MarshalIndent(value, "", "  ")
+		files = append(files, diagnostics.SupportFile{Path: bundlePath, Data: data})
+	}
+	addHostFile := func(bundleDirectory, hostPath string) {
+		if data, err := os.ReadFile(hostPath); err == nil {
+			files = append(files, diagnostics.SupportFile{Path: path.Join(bundleDirectory, filepath.Base(hostPath)), Data: data})
+		}
+	}
+
+	fmt.Println("> Collecting versions, environment and docker details")
+	addJSON("version.json", getVersionInfo())
+	addJSON("environment.json", getSupportEnvironment())
+	addJSON("config.json", config.GetSanitizedUserConfiguration())
+
+	// the manifest and engine log of the last scan, never its results
+	if scanResults, err := results.LoadResults(filepath.Join(repositoryPath, config.AppConfig.PrivacyResultsPathSuffix)); err == nil {
+		addJSON("scan/manifest.json", getBundleManifest(scanResults, repositoryPath))
+	}
+	addHostFile("scan", filepath.Join(repositoryPath, ".privado", "engine.log"))
+
+	if scanLogs, err := logs.List(config.AppConfig.LogsDirectory); err == nil {
+		for i := 0; i < len(scanLogs) && i < logCount; i++ {
+			addHostFile("logs", scanLogs[i].Path)
+		}
+	}
+	for _, dumpPath := range getNewestFiles(config.AppConfig.DiagnosticsDirectory, ".txt", supportBundleMaxDiagnostics) {
+		addHostFile("diagnostics", dumpPath)
+	}
+	for _, reportPath := range getNewestFiles(config.AppConfig.CrashReportsDirectory, ".json", supportBundleMaxDiagnostics) {
+		addHostFile("crash-reports", reportPath)
+	}
+	return files
+}
+
+// Returns the redactor of the paths, names and identifiers of the user
+func getSupportRedactor(repositoryPath string, terms []string) *diagnostics.Redactor {
+	redactor := diagnostics.NewRedactor()
+	redactor.AddTerm(repositoryPath, "<repository>")
+	redactor.AddTerm(config.AppConfig.HomeDirectory, "~")
+	if hostname, err := os.Hostname(); err == nil {
+		redactor.AddTerm(hostname, "<host>")
+	}
+	// short names would redact common words
+	if currentUser, err := user.Current(); err == nil && len(currentUser.Username) > 3 {
+		redactor.AddTerm(currentUser.Username, "<user>")
+	}
+	redactor.AddTerm(config.UserConfig.UserHash, "<user-hash>")
+	redactor.AddTerm(config.UserConfig.DockerAccessHash, "<user-hash>")
+	redactor.AddTerm(os.Getenv(config.AppConfig.Container.DockerAccessKeyEnv), "<redacted>")
+	for _, term := range terms {
+		redactor.AddTerm(strings.TrimSpace(term), "<redacted>")
+	}
+	return redactor
+}
+
+func printSupportFiles(files []diagnostics.SupportFile) {
+	for i, file := range files {
+		fmt.Printf("  %2d. %-50s %10s  %d redaction(s)\n", i+1, file.Path, fileutils.FormatSize(int64(len(file.Data))), file.Redactions)
+	}
+}
+
+// Shows the files and their redactions, and asks for more terms to redact
+// and files to exclude. Returns the files to bundle
+func reviewSupportFiles(files []diagnostics.SupportFile) []diagnostics.SupportFile {
+	fmt.Println("\n> Files of the support bundle (secrets, emails, paths, host and user names are redacted):")
+	printSupportFiles(files)
+	fmt.Println()
+
+	if answer, _ := utils.ShowInputPrompt("Additional terms to redact, e.g. project or host names (comma separated, empty for none)"); answer != "" {
+		redactor := diagnostics.NewRedactor()
+		for _, term := range strings.Split(answer, ",") {
+			redactor.AddTerm(strings.TrimSpace(term), "<redacted>")
+		}
+		for i := range files {
+			redactor.RedactFile(&files[i])
+		}
+		fmt.Println("\n> Files of the support bundle:")
+		printSupportFiles(files)
+		fmt.Println()
+	}
+
+	answer, _ := utils.ShowInputPrompt("Files to exclude (numbers, comma separated, empty for none)")
+	if answer == "" {
+		return files
+	}
+	excluded := map[int]bool{}
+	for _, number := range strings.Split(answer, ",") {
+		index, err := strconv.Atoi(strings.TrimSpace(number))
+		if err != nil || index < 1 || index > len(files) {
+			fmt.Println("[WARN]: Ignoring invalid file number:", strings.TrimSpace(number))
+			continue
+		}
+		excluded[index-1] = true
+	}
+	included := []diagnostics.SupportFile{}
+	for i, file := range files {
+		if excluded[i] {
+			fmt.Println("> Excluded:", file.Path)
+			continue
+		}
+		included = append(included, file)
+	}
+	return included
+}
+
+func createSupportBundle(cmd *cobra.Command, args []string) {
+	repository := "."
+	if len(args) > 0 {
+		repository = args[0]
+	}
+	repositoryPath := fileutils.GetAbsolutePath(repository)
+	output, _ := cmd.Flags().GetString("out")
+	logCount, _ := cmd.Flags().GetInt("logs")
+	terms, _ := cmd.Flags().GetStringSlice("redact")
+	noReview, _ := cmd.Flags().GetBool("no-review")
+
+	if logCount < 0 {
+		exitWithError(clierrors.InvalidFlagValue.Errorf("Invalid value for --logs: %d", logCount))
+	}
+	if output == "" {
+		output = fmt.Sprintf("privado-support-%s.tar.gz", time.Now().Format("20060102-150405"))
+	}
+
+	files := collectSupportFiles(repositoryPath, logCount)
+	redactor := getSupportRedactor(repositoryPath, terms)
+	for i := range files {
+		redactor.RedactFile(&files[i])
+	}
+
+	if !noReview && utils.IsInteractive() {
+		files = reviewSupportFiles(files)
+		if confirmed, _ := utils.ShowConfirmationPrompt(fmt.Sprintf("Write the support bundle (%d files) to %s?", len(files), output)); !confirmed {
+			exit("> Support bundle not written", false)
+		}
+	} else {
+		fmt.Println("\n> Files of the support bundle (secrets, emails, paths, host and user names are redacted):")
+		printSupportFiles(files)
+	}
+
+	if err := diagnostics.WriteSupportBundle(output, files); err != nil {
+		exitWithError(clierrors.SupportBundle.Errorf("Cannot write support bundle: %s", err))
+	}
+	exit(fmt.Sprint(
+		fmt.Sprintf("\n> Support bundle created: %s\n", fileutils.GetAbsolutePath(output)),
+		fmt.Sprintf("> Review it before sharing, and attach it to your issue or support ticket: %s", config.AppConfig.PrivadoRepository),
+	), false)
+}
+
+func init() {
+	supportBundleCmd.Flags().StringP("out", "o", "", "Path of the archive (default: privado-support-<timestamp>.tar.gz)")
+	supportBundleCmd.Flags().Int("logs", 3, "Number of recent scan logs to include")
+	supportBundleCmd.Flags().StringSlice("redact", nil, "Additional terms to redact from all files, e.g. project or host names (comma separated)")
+	supportBundleCmd.Flags().Bool("no-review", false, "If specified, files are not reviewed before the archive is written")
+
+	rootCmd.AddCommand(supportBundleCmd)
+}
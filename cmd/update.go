@@ -31,8 +31,10 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
 	"github.com/Privado-Inc/privado-cli/pkg/config"
 	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+	"github.com/Privado-Inc/privado-cli/pkg/tracing"
 	"github.com/Privado-Inc/privado-cli/pkg/utils"
 	"github.com/spf13/cobra"
 	"golang.org/x/mod/semver"
@@ -47,6 +49,12 @@ var updateCmd = &cobra.Command{
 }
 
 func checkForUpdate() (hasUpdate bool, updateMessage string, err error) {
+	span := tracing.StartSpan("update-check")
+	defer func() {
+		span.SetAttribute("privado.update.available", hasUpdate)
+		span.End(err)
+	}()
+
 	if Version == "dev" {
 		return false, "", nil
 	}
@@ -108,7 +116,7 @@ func update(cmd *cobra.Command, args []string) {
 		fmt.Println("> Error: Permission denied")
 		fmt.Printf("> The identified installation (%s) requires privileged permissions\n", currentExecPath)
 		fmt.Println()
-		exit("Try again with a privileged user (sudo)?", true)
+		exitWithError(clierrors.UpdatePermission.New("Try again with a privileged user (sudo)?"))
 	}
 
 	// check for release info
@@ -117,7 +125,10 @@ func update(cmd *cobra.Command, args []string) {
 	if err != nil {
 		exitUpdate("Could not fetch latest release. Some error occurred", true)
 	}
-	if !hasUpdate {
+	if !hasUpdate && config.IsTranslated() {
+		// same version, native build
+		updateMessage = "Privado CLI runs translated by Rosetta on Apple Silicon: installing the native (arm64) build"
+	} else if !hasUpdate {
 		exit(fmt.Sprint("You are already using the latest version of Privado CLI: ", Version), false)
 	}
 	fmt.Println(updateMessage)
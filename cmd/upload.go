@@ -30,7 +30,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Privado-Inc/privado-cli/pkg/auth"
 	"github.com/Privado-Inc/privado-cli/pkg/ci"
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
 	"github.com/Privado-Inc/privado-cli/pkg/config"
 	"github.com/Privado-Inc/privado-cli/pkg/docker"
 	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
@@ -38,9 +40,10 @@ import (
 )
 
 var uploadCmd = &cobra.Command{
-	Use:   "upload <repository>",
-	Short: "Sync scan results with Privado Dashboard",
-	Args:  cobra.ExactArgs(1),
+	Use:               "upload <repository>",
+	Short:             "Sync scan results with Privado Dashboard",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeDirectoryArgument,
 	PreRun: func(cmd *cobra.Command, args []string) {
 		telemetryPreRun(nil)
 	},
@@ -68,19 +71,48 @@ func upload(cmd *cobra.Command, args []string) {
 
 	resultsPath := filepath.Join(fileutils.GetAbsolutePath(repository), config.AppConfig.PrivacyResultsPathSuffix)
 	if exists, _ := fileutils.DoesFileExists(resultsPath); !exists {
-		exit(fmt.Sprint(
+		exitWithError(clierrors.ResultsRead.New(fmt.Sprint(
 			fmt.Sprintf("Cannot find scan results (%s) in the specified directory\n", config.AppConfig.PrivacyResultsPathSuffix),
 			"Run 'privado scan <dir>' instead\n\n",
 			"Run 'privado help' for more information.",
-		), true)
+		)))
 	}
 
 	if dockerAccessKey, err := docker.GetPrivadoDockerAccessKey(true); err != nil || dockerAccessKey == "" {
-		exit(fmt.Sprintf("Cannot fetch docker access key: %v \nPlease try again or raise an issue at %s", err, config.AppConfig.PrivadoRepository), true)
+		exitWithError(clierrors.DockerAccessKey.Errorf("Cannot fetch docker access key: %v \nPlease try again or raise an issue at %s", err, config.AppConfig.PrivadoRepository))
 	} else {
 		config.LoadUserDockerHash(dockerAccessKey)
 	}
 
+	// upload the changes since the last synced scan only, if any
+	repositoryPath := fileutils.GetAbsolutePath(repository)
+	stripSnippets := config.UserConfig.ConfigFile.SyncRules != nil && config.UserConfig.ConfigFile.SyncRules.StripSnippets
+	if fullSync, _ := cmd.Flags().GetBool("full-sync"); !fullSync && hasSyncState(repositoryPath, stripSnippets) {
+		if err = syncResultsDelta(repositoryPath, stripSnippets); err == nil {
+			return
+		}
+		fmt.Println("[WARN]: Could not upload the changes only, uploading all results:", err)
+	}
+
+	scanId, err := runEngineUpload(repositoryPath, debug, config.UserConfig.ConfigFile.SyncToPrivadoCloud, stripSnippets)
+	if err != nil {
+		exitWithError(clierrors.ScanSyncFailed.Errorf("Received error: %s", err))
+	}
+	recordSyncedScan(repositoryPath, stripSnippets, scanId)
+}
+
+// Uploads the results of the repository with the engine (privado-core
+// upload). With stripSnippets, a copy of the results without snippets is
+// uploaded instead. Returns the id of the synced scan, empty if unknown
+func runEngineUpload(repositoryPath string, debug, syncToCloud, stripSnippets bool) (string, error) {
+	sourceDirectory := repositoryPath
+	if stripSnippets {
+		var err error
+		if sourceDirectory, err = getStrippedResultsDirectory(repositoryPath); err != nil {
+			return "", fmt.Errorf("cannot strip snippets from the results: %s", err)
+		}
+	}
+
 	command := []string{
 		config.AppConfig.Container.PrivadoCoreBinPath,
 		"upload",
@@ -89,33 +121,37 @@ func upload(cmd *cobra.Command, args []string) {
 	commandArgs := []string{config.AppConfig.Container.SourceCodeVolumeDir}
 
 	// run image with options
-	err = docker.RunImage(
+	syncedScan := &syncedScanListener{}
+	err := docker.RunImage(
 		docker.OptionWithLatestImage(false), // because we already pull the image for access-key (with pullImage parameter)
 		docker.OptionWithEntrypoint(command),
 		docker.OptionWithArgs(commandArgs),
 		docker.OptionWithAttachedOutput(),
-		docker.OptionWithSourceVolume(fileutils.GetAbsolutePath(repository)),
+		docker.OptionWithSourceVolume(sourceDirectory),
 		docker.OptionWithUserKeyVolume(config.AppConfig.UserKeyPath),
 		docker.OptionWithDebug(debug),
 		docker.OptionWithEnvironmentVariables([]docker.EnvVar{
 			{Key: "CI", Value: strings.ToUpper(strconv.FormatBool(ci.CISessionConfig.IsCI))},
 			{Key: "PRIVADO_VERSION_CLI", Value: Version},
-			{Key: "PRIVADO_HOST_SCAN_DIR", Value: fileutils.GetAbsolutePath(repository)},
+			{Key: "PRIVADO_HOST_SCAN_DIR", Value: repositoryPath},
 			{Key: "PRIVADO_USER_HASH", Value: config.UserConfig.UserHash},
 			{Key: "PRIVADO_SESSION_ID", Value: config.UserConfig.SessionId},
-			{Key: "PRIVADO_SYNC_TO_CLOUD", Value: strings.ToUpper(strconv.FormatBool(config.UserConfig.ConfigFile.SyncToPrivadoCloud))},
-			{Key: "PRIVADO_METRICS_ENABLED", Value: strings.ToUpper(strconv.FormatBool(config.UserConfig.ConfigFile.MetricsEnabled))},
+			{Key: "PRIVADO_SYNC_TO_CLOUD", Value: strings.ToUpper(strconv.FormatBool(syncToCloud))},
+			{Key: "PRIVADO_METRICS_ENABLED", Value: strings.ToUpper(strconv.FormatBool(config.IsEngineMetricsEnabled()))},
+			{Key: auth.TokenEnvKey, Value: getAPIToken(), Secret: true},
+			{Key: auth.OrganizationEnvKey, Value: getOrganizationId()},
 		}),
 		docker.OptionWithAutoSpawnBrowserOnURLMessages([]string{
-			"> Continue to view results on:",
+			syncedScanMessage,
 		}),
 		docker.OptionWithInterrupt(),
+		syncedScan.runImageOption(),
 	)
-	if err != nil {
-		exit(fmt.Sprintf("Received error: %s", err), true)
-	}
+	return syncedScan.getScanId(), err
 }
 
 func init() {
+	uploadCmd.Flags().Bool("full-sync", false, "Upload all results, instead of the changes since the last synced scan of the repository")
+
 	rootCmd.AddCommand(uploadCmd)
 }
@@ -30,6 +30,7 @@ import (
 	"time"
 
 	"github.com/Privado-Inc/privado-cli/pkg/ci"
+	"github.com/Privado-Inc/privado-cli/pkg/clierrors"
 	"github.com/Privado-Inc/privado-cli/pkg/config"
 	"github.com/Privado-Inc/privado-cli/pkg/docker"
 	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
@@ -37,9 +38,10 @@ import (
 )
 
 var validateCmd = &cobra.Command{
-	Use:   "validate <rules-directory>",
-	Short: "Validate rule structure for custome rules",
-	Args:  cobra.ExactArgs(1),
+	Use:               "validate <rules-directory>",
+	Short:             "Validate rule structure for custome rules",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeDirectoryArgument,
 	PreRun: func(cmd *cobra.Command, args []string) {
 		telemetryPreRun(nil)
 	},
@@ -65,15 +67,15 @@ func validate(cmd *cobra.Command, args []string) {
 	time.Sleep(config.AppConfig.SlowdownTime)
 
 	if exists, _ := fileutils.DoesFileExists(externalRules); !exists {
-		exit(fmt.Sprint(
+		exitWithError(clierrors.PathNotFound.New(fmt.Sprint(
 			"Cannot find the find directory mentioned on disk\n",
 			"Use correct path for running Privado rule validation",
 			"Run 'privado scan <dir>' for scanning without custom rules\n\n",
-		), true)
+		)))
 	}
 
 	if dockerAccessKey, err := docker.GetPrivadoDockerAccessKey(true); err != nil || dockerAccessKey == "" {
-		exit(fmt.Sprintf("Cannot fetch docker access key: %v \nPlease try again or raise an issue at %s", err, config.AppConfig.PrivadoRepository), true)
+		exitWithError(clierrors.DockerAccessKey.Errorf("Cannot fetch docker access key: %v \nPlease try again or raise an issue at %s", err, config.AppConfig.PrivadoRepository))
 	} else {
 		config.LoadUserDockerHash(dockerAccessKey)
 	}
@@ -102,7 +104,7 @@ func validate(cmd *cobra.Command, args []string) {
 			{Key: "PRIVADO_USER_HASH", Value: config.UserConfig.UserHash},
 			{Key: "PRIVADO_SESSION_ID", Value: config.UserConfig.SessionId},
 			{Key: "PRIVADO_SYNC_TO_CLOUD", Value: strings.ToUpper(strconv.FormatBool(config.UserConfig.ConfigFile.SyncToPrivadoCloud))},
-			{Key: "PRIVADO_METRICS_ENABLED", Value: strings.ToUpper(strconv.FormatBool(config.UserConfig.ConfigFile.MetricsEnabled))},
+			{Key: "PRIVADO_METRICS_ENABLED", Value: strings.ToUpper(strconv.FormatBool(config.IsEngineMetricsEnabled()))},
 		}),
 		docker.OptionWithInterrupt(),
 	)
@@ -110,7 +112,7 @@ func validate(cmd *cobra.Command, args []string) {
 	time.Sleep(config.AppConfig.SlowdownTime)
 
 	if err != nil {
-		exit(fmt.Sprintf("Received error: %s", err), true)
+		exitWithError(clierrors.ValidationFailed.Errorf("Received error: %s", err))
 	}
 }
 
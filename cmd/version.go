@@ -24,23 +24,128 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/docker"
+	"github.com/Privado-Inc/privado-cli/pkg/progress"
 	"github.com/spf13/cobra"
 )
 
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Print the current version of Privado CLI",
-	Long:  "Print the current version of Privado CLI",
+	Long:  "Print the current version of Privado CLI. With --json, prints versions of all components (cli, engine image, container runtime) to attach to bug reports",
 	Args:  cobra.ExactArgs(0),
 	Run:   version,
 }
 
+type versionInfo struct {
+	CLI struct {
+		Version   string `json:"version"`
+		Commit    string `json:"commit,omitempty"`
+		BuildDate string `json:"buildDate,omitempty"`
+		GoVersion string `json:"goVersion"`
+		Platform  string `json:"platform"`
+		// an amd64 build runs on Apple Silicon (Rosetta)
+		Translated bool `json:"translated,omitempty"`
+	} `json:"cli"`
+	Engine struct {
+		Image   string `json:"image"`
+		Tag     string `json:"tag"`
+		Present bool   `json:"present"`
+		Digest  string `json:"digest,omitempty"`
+		Version string `json:"version,omitempty"`
+		Created string `json:"created,omitempty"`
+		Arch    string `json:"arch,omitempty"`
+		// the image does not match the architecture of the docker host
+		Emulated bool `json:"emulated,omitempty"`
+	} `json:"engine"`
+	// default rules are part of the engine image, and versioned with it
+	Rules struct {
+		Source  string `json:"source"`
+		Version string `json:"version,omitempty"`
+	} `json:"rules"`
+	Runtime struct {
+		Name       string `json:"name,omitempty"`
+		Version    string `json:"version,omitempty"`
+		APIVersion string `json:"apiVersion,omitempty"`
+		Arch       string `json:"arch,omitempty"`
+		Error      string `json:"error,omitempty"`
+	} `json:"runtime"`
+}
+
+func getVersionInfo() versionInfo {
+	info := versionInfo{}
+	info.CLI.Version = Version
+	info.CLI.Commit = Commit
+	info.CLI.BuildDate = BuildDate
+	info.CLI.GoVersion = runtime.Version()
+	info.CLI.Platform = fmt.Sprintf("%s/%s", runtime.GOOS, runtime.GOARCH)
+	info.CLI.Translated = config.IsTranslated()
+
+	image := config.AppConfig.Container.ImageURL
+	info.Engine.Image = image
+	if index := strings.LastIndex(image, ":"); index > strings.LastIndex(image, "/") {
+		info.Engine.Tag = image[index+1:]
+	}
+	if details := docker.GetImageDetails(image); details != nil {
+		info.Engine.Present = true
+		info.Engine.Digest = details.Digest
+		info.Engine.Version = details.Version
+		info.Engine.Created = details.Created
+	}
+
+	info.Rules.Source = fmt.Sprintf("engine image (%s)", info.Engine.Tag)
+	info.Rules.Version = info.Engine.Version
+	if info.Rules.Version == "" {
+		info.Rules.Version = info.Engine.Digest
+	}
+
+	if runtimeVersion, err := docker.GetRuntimeVersion(); err != nil {
+		info.Runtime.Error = err.Error()
+	} else {
+		info.Runtime.Name = runtimeVersion.Name
+		info.Runtime.Version = runtimeVersion.Version
+		info.Runtime.APIVersion = runtimeVersion.APIVersion
+	}
+	if platform, err := docker.GetPlatformInfo(image); err == nil {
+		info.Engine.Arch = platform.ImageArch
+		info.Engine.Emulated = platform.IsEmulated()
+		info.Runtime.Arch = platform.HostArch
+	}
+	return info
+}
+
+// Warns when the cli or the engine image run emulated: an amd64 build
+// under Rosetta, or an image of another architecture than the docker
+// host (under QEMU), which makes scans several times slower
+func warnIfEmulated() {
+	if config.IsTranslated() {
+		fmt.Println("[WARN]: Privado CLI (amd64) runs translated by Rosetta on Apple Silicon: run 'privado update' to install the native (arm64) build")
+	}
+
+	platform, err := docker.GetPlatformInfo(config.AppConfig.Container.ImageURL)
+	if err != nil || !platform.IsEmulated() {
+		return
+	}
+	warning := fmt.Sprintf("The engine image (linux/%s) runs emulated on the %s docker host, scans are several times slower: use a linux/%s image if one is published (PRIVADO_IMAGE)",
+		platform.ImageArch, platform.HostArch, platform.HostArch)
+	fmt.Println("[WARN]:", warning)
+	progress.Warning(warning)
+}
+
 func version(cmd *cobra.Command, args []string) {
+	if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput && cmd.Name() == "version" {
+		data, _ := json.MarshalIndent(getVersionInfo(), "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+
 	printVersion := Version
 	if Version == "dev" {
 		printVersion = "Nightly"
@@ -64,5 +169,6 @@ func version(cmd *cobra.Command, args []string) {
 }
 
 func init() {
+	versionCmd.Flags().Bool("json", false, "Print versions of all components (cli, engine image, rules, container runtime) as json, without checking for updates")
 	rootCmd.AddCommand(versionCmd)
 }
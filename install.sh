@@ -20,9 +20,13 @@ function findOS {
 
 function findArch {
     ARCH_STR=$(uname -m)
-    if [[ "$ARCH_STR" == "x86_64" ]]; then
+    # shells translated by Rosetta report x86_64 on Apple Silicon: install the native build
+    if [[ "$OS" == "darwin" && "$(sysctl -n sysctl.proc_translated 2>/dev/null)" == "1" ]]; then
+	ARCH_STR="arm64"
+    fi
+    if [[ "$ARCH_STR" == "x86_64" || "$ARCH_STR" == "amd64" ]]; then
 	ARCH="amd64"
-    elif [[ "$ARCH_STR" == "arm64" ]]; then
+    elif [[ "$ARCH_STR" == "arm64" || "$ARCH_STR" == "aarch64" ]]; then
 	ARCH="arm64"
     else
 	echo "Unsupported Architecture"
@@ -30,6 +30,7 @@ import (
 	"github.com/Privado-Inc/privado-cli/pkg/auth"
 	"github.com/Privado-Inc/privado-cli/pkg/ci"
 	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/telemetry"
 )
 
 func bootstrap() {
@@ -52,6 +53,7 @@ func bootstrap() {
 	}
 
 	config.LoadUserConfiguration()
+	telemetry.SetAnonymousMode(config.IsAnonymousTelemetryMode())
 }
 
 func main() {
@@ -0,0 +1,125 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package apispec
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// API specifications (OpenAPI/Swagger and GraphQL schemas) of the
+// repository: the endpoints and the fields they accept and return, which
+// are registered with the engine as data elements
+
+type Endpoint struct {
+	// e.g. "POST /users", or "Mutation.createUser" in a graphql schema
+	Name           string   `json:"name"`
+	RequestFields  []string `json:"requestFields"`
+	ResponseFields []string `json:"responseFields"`
+}
+
+type Spec struct {
+	Path      string     `json:"path"`
+	Format    string     `json:"format"`
+	Endpoints []Endpoint `json:"endpoints"`
+}
+
+// Loads the specification: graphql schemas by their extension (.graphql,
+// .gql), else an OpenAPI 3 or Swagger 2 document (yaml or json)
+func Load(specPath string) (*Spec, error) {
+	data, err := os.ReadFile(specPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var spec *Spec
+	switch strings.ToLower(filepath.Ext(specPath)) {
+	case ".graphql", ".graphqls", ".gql":
+		spec, err = parseGraphQL(data)
+	default:
+		spec, err = parseOpenAPI(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %v", specPath, err)
+	}
+	spec.Path = specPath
+	sort.Slice(spec.Endpoints, func(i, j int) bool { return spec.Endpoints[i].Name < spec.Endpoints[j].Name })
+	return spec, nil
+}
+
+// names of fields too generic to be data elements
+var genericFieldNames = map[string]bool{
+	"id": true, "type": true, "kind": true, "name": true, "status": true, "code": true, "message": true,
+	"data": true, "items": true, "results": true, "page": true, "limit": true, "offset": true, "cursor": true,
+	"count": true, "total": true, "size": true, "sort": true, "order": true, "version": true,
+	"createdat": true, "updatedat": true, "deletedat": true, "error": true, "errors": true,
+	"input": true, "filter": true, "where": true, "query": true, "first": true, "last": true, "after": true,
+	"before": true, "skip": true, "take": true,
+}
+
+// Returns the distinct fields of the endpoints of the specs that are data
+// elements (without generic fields like id or page), sorted
+func DataElementFields(specs []*Spec) []string {
+	fields := map[string]bool{}
+	for _, spec := range specs {
+		for _, endpoint := range spec.Endpoints {
+			for _, field := range append(append([]string{}, endpoint.RequestFields...), endpoint.ResponseFields...) {
+				if isDataElementField(field) {
+					fields[field] = true
+				}
+			}
+		}
+	}
+
+	sorted := []string{}
+	for field := range fields {
+		sorted = append(sorted, field)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+func isDataElementField(field string) bool {
+	normalized := strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(field))
+	return normalized != "" && !genericFieldNames[normalized]
+}
+
+// collects unique field names, in order of appearance
+type fieldSet struct {
+	seen   map[string]bool
+	fields []string
+}
+
+func newFieldSet() *fieldSet {
+	return &fieldSet{seen: map[string]bool{}, fields: []string{}}
+}
+
+func (s *fieldSet) add(field string) {
+	if field != "" && !s.seen[field] {
+		s.seen[field] = true
+		s.fields = append(s.fields, field)
+	}
+}
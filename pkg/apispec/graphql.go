@@ -0,0 +1,222 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package apispec
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// GraphQL schemas (SDL): each field of the Query, Mutation and Subscription
+// types is an endpoint, with its arguments (and fields of their input
+// types) as request fields and the fields of its type as response fields
+
+type graphQLField struct {
+	name      string
+	fieldType string
+	arguments []graphQLField
+}
+
+var graphQLRootTypes = []string{"Query", "Mutation", "Subscription"}
+
+func parseGraphQL(data []byte) (*Spec, error) {
+	types := parseGraphQLTypes(tokenizeGraphQL(string(data)))
+
+	spec := &Spec{Format: "graphql", Endpoints: []Endpoint{}}
+	found := false
+	for _, rootType := range graphQLRootTypes {
+		fields, ok := types[rootType]
+		if !ok {
+			continue
+		}
+		found = true
+		for _, field := range fields {
+			requestFields := newFieldSet()
+			for _, argument := range field.arguments {
+				requestFields.add(argument.name)
+				collectGraphQLFields(types, argument.fieldType, requestFields, 0, map[string]bool{})
+			}
+			responseFields := newFieldSet()
+			collectGraphQLFields(types, field.fieldType, responseFields, 0, map[string]bool{})
+
+			spec.Endpoints = append(spec.Endpoints, Endpoint{
+				Name:           rootType + "." + field.name,
+				RequestFields:  requestFields.fields,
+				ResponseFields: responseFields.fields,
+			})
+		}
+	}
+	if !found {
+		return nil, errors.New("no Query, Mutation or Subscription type in the graphql schema")
+	}
+	return spec, nil
+}
+
+func collectGraphQLFields(types map[string][]graphQLField, typeName string, fields *fieldSet, depth int, visiting map[string]bool) {
+	typeFields, ok := types[typeName]
+	if !ok || depth > maxSchemaDepth || visiting[typeName] {
+		return
+	}
+	visiting[typeName] = true
+	defer delete(visiting, typeName)
+
+	for _, field := range typeFields {
+		fields.add(field.name)
+		collectGraphQLFields(types, field.fieldType, fields, depth+1, visiting)
+	}
+}
+
+// Splits the schema into names and punctuators, without comments,
+// descriptions and other strings
+func tokenizeGraphQL(schema string) []string {
+	tokens := []string{}
+	runes := []rune(schema)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '#':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '"':
+			if i+2 < len(runes) && runes[i+1] == '"' && runes[i+2] == '"' {
+				for i += 3; i+2 < len(runes) && !(runes[i] == '"' && runes[i+1] == '"' && runes[i+2] == '"'); i++ {
+				}
+				i += 2
+				continue
+			}
+			for i++; i < len(runes) && runes[i] != '"' && runes[i] != '\n'; i++ {
+				if runes[i] == '\\' {
+					i++
+				}
+			}
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, string(runes[start:i]))
+			i--
+		case strings.ContainsRune("{}()[]:!=@|", r):
+			tokens = append(tokens, string(r))
+		}
+	}
+	return tokens
+}
+
+// Returns the fields of the object, input and interface types (including
+// extensions), by type name
+func parseGraphQLTypes(tokens []string) map[string][]graphQLField {
+	types := map[string][]graphQLField{}
+	for i := 0; i < len(tokens); i++ {
+		if tokens[i] != "type" && tokens[i] != "input" && tokens[i] != "interface" {
+			continue
+		}
+		if i+1 >= len(tokens) {
+			break
+		}
+		typeName := tokens[i+1]
+
+		// the fields start at the next brace, unless another definition
+		// starts first (e.g. a type without fields)
+		start := i + 2
+		for start < len(tokens) && tokens[start] != "{" && tokens[start] != "type" && tokens[start] != "input" && tokens[start] != "interface" {
+			start++
+		}
+		if start >= len(tokens) || tokens[start] != "{" {
+			continue
+		}
+		fields, end := parseGraphQLFields(tokens, start+1, "}")
+		types[typeName] = append(types[typeName], fields...)
+		i = end
+	}
+	return types
+}
+
+// Parses fields (or arguments) up to the closing token, returns them and
+// the index of the closing token
+func parseGraphQLFields(tokens []string, i int, closing string) ([]graphQLField, int) {
+	fields := []graphQLField{}
+	for i < len(tokens) && tokens[i] != closing {
+		if tokens[i] == "@" {
+			i = skipGraphQLDirective(tokens, i)
+			continue
+		}
+		if !isGraphQLName(tokens[i]) || i+1 >= len(tokens) || (tokens[i+1] != ":" && tokens[i+1] != "(") {
+			i++
+			continue
+		}
+
+		field := graphQLField{name: tokens[i]}
+		i++
+		if tokens[i] == "(" {
+			field.arguments, i = parseGraphQLFields(tokens, i+1, ")")
+			i++
+		}
+		if i < len(tokens) && tokens[i] == ":" {
+			i++
+			for i < len(tokens) && tokens[i] == "[" {
+				i++
+			}
+			if i < len(tokens) && isGraphQLName(tokens[i]) {
+				field.fieldType = tokens[i]
+				i++
+			}
+		}
+		fields = append(fields, field)
+
+		// the rest of the type (wrappers, default values) up to the next field
+		for i < len(tokens) && (tokens[i] == "]" || tokens[i] == "!" || tokens[i] == "=") {
+			if tokens[i] == "=" && i+1 < len(tokens) && isGraphQLName(tokens[i+1]) {
+				i++
+			}
+			i++
+		}
+	}
+	return fields, i
+}
+
+// returns the index after the directive (with its arguments)
+func skipGraphQLDirective(tokens []string, i int) int {
+	i += 2
+	if i < len(tokens) && tokens[i] == "(" {
+		depth := 0
+		for ; i < len(tokens); i++ {
+			if tokens[i] == "(" {
+				depth++
+			} else if tokens[i] == ")" {
+				depth--
+				if depth == 0 {
+					return i + 1
+				}
+			}
+		}
+	}
+	return i
+}
+
+func isGraphQLName(token string) bool {
+	r := []rune(token)[0]
+	return unicode.IsLetter(r) || r == '_'
+}
@@ -0,0 +1,170 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package apispec
+
+import (
+	"errors"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var httpMethods = []string{"get", "put", "post", "delete", "patch", "head", "options"}
+
+// nesting of schemas followed at most
+const maxSchemaDepth = 10
+
+// OpenAPI 3 and Swagger 2: request fields are the parameters and the
+// properties of the request body, response fields the properties of the
+// successful (2xx and default) responses
+func parseOpenAPI(data []byte) (*Spec, error) {
+	document := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+
+	spec := &Spec{Format: "openapi", Endpoints: []Endpoint{}}
+	if _, ok := document["swagger"]; ok {
+		spec.Format = "swagger"
+	} else if _, ok := document["openapi"]; !ok {
+		return nil, errors.New("not an OpenAPI or Swagger document")
+	}
+
+	paths, _ := document["paths"].(map[string]interface{})
+	for path, item := range paths {
+		item, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, method := range httpMethods {
+			operation, ok := item[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			requestFields := newFieldSet()
+			for _, parameters := range []interface{}{item["parameters"], operation["parameters"]} {
+				parameters, _ := parameters.([]interface{})
+				for _, parameter := range parameters {
+					parameter := resolve(document, parameter)
+					if getString(parameter, "in") == "header" {
+						continue
+					}
+					if getString(parameter, "in") == "body" {
+						collectSchemaFields(document, parameter["schema"], requestFields, 0, map[string]bool{})
+						continue
+					}
+					requestFields.add(getString(parameter, "name"))
+				}
+			}
+			collectContentFields(document, resolve(document, operation["requestBody"]), requestFields)
+
+			responseFields := newFieldSet()
+			responses, _ := operation["responses"].(map[string]interface{})
+			for status, response := range responses {
+				if !strings.HasPrefix(status, "2") && status != "default" {
+					continue
+				}
+				response := resolve(document, response)
+				collectSchemaFields(document, response["schema"], responseFields, 0, map[string]bool{})
+				collectContentFields(document, response, responseFields)
+			}
+
+			spec.Endpoints = append(spec.Endpoints, Endpoint{
+				Name:           strings.ToUpper(method) + " " + path,
+				RequestFields:  requestFields.fields,
+				ResponseFields: responseFields.fields,
+			})
+		}
+	}
+	return spec, nil
+}
+
+// fields of the schemas of all media types of a request body or response
+func collectContentFields(document, value map[string]interface{}, fields *fieldSet) {
+	content, _ := value["content"].(map[string]interface{})
+	for _, mediaType := range content {
+		if mediaType, ok := mediaType.(map[string]interface{}); ok {
+			collectSchemaFields(document, mediaType["schema"], fields, 0, map[string]bool{})
+		}
+	}
+}
+
+func collectSchemaFields(document map[string]interface{}, schema interface{}, fields *fieldSet, depth int, visiting map[string]bool) {
+	if depth > maxSchemaDepth {
+		return
+	}
+	schemaMap, ok := schema.(map[string]interface{})
+	if !ok {
+		return
+	}
+	if ref := getString(schemaMap, "$ref"); ref != "" {
+		if visiting[ref] {
+			return
+		}
+		visiting[ref] = true
+		defer delete(visiting, ref)
+		schemaMap = resolve(document, schemaMap)
+	}
+
+	properties, _ := schemaMap["properties"].(map[string]interface{})
+	for name, property := range properties {
+		fields.add(name)
+		collectSchemaFields(document, property, fields, depth+1, visiting)
+	}
+	collectSchemaFields(document, schemaMap["items"], fields, depth+1, visiting)
+	collectSchemaFields(document, schemaMap["additionalProperties"], fields, depth+1, visiting)
+	for _, key := range []string{"allOf", "oneOf", "anyOf"} {
+		schemas, _ := schemaMap[key].([]interface{})
+		for _, child := range schemas {
+			collectSchemaFields(document, child, fields, depth+1, visiting)
+		}
+	}
+}
+
+// Returns the value, or the value its local reference ("$ref": "#/...")
+// refers to
+func resolve(document map[string]interface{}, value interface{}) map[string]interface{} {
+	valueMap, _ := value.(map[string]interface{})
+	ref := getString(valueMap, "$ref")
+	if !strings.HasPrefix(ref, "#/") {
+		return valueMap
+	}
+
+	var current interface{} = document
+	for _, key := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		key = strings.NewReplacer("~1", "/", "~0", "~").Replace(key)
+		currentMap, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = currentMap[key]
+	}
+	resolved, _ := current.(map[string]interface{})
+	return resolved
+}
+
+func getString(value map[string]interface{}, key string) string {
+	child, _ := value[key].(string)
+	return child
+}
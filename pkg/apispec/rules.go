@@ -0,0 +1,192 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package apispec
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+	"gopkg.in/yaml.v3"
+)
+
+// Data elements of api fields are registered with the engine as source
+// rules in an external rules (config) directory
+
+const SourceIdPrefix = "Data.APISpec."
+
+var nonIdentifierPattern = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// Returns the id of the data element of the field: the same for all
+// spellings (firstName, first_name)
+func SourceId(field string) string {
+	return SourceIdPrefix + strings.ToLower(nonIdentifierPattern.ReplaceAllString(field, ""))
+}
+
+type sourceRule struct {
+	Id          string            `yaml:"id"`
+	Name        string            `yaml:"name"`
+	Category    string            `yaml:"category"`
+	IsSensitive bool              `yaml:"isSensitive"`
+	Sensitivity string            `yaml:"sensitivity"`
+	Patterns    []string          `yaml:"patterns"`
+	Tags        map[string]string `yaml:"tags"`
+}
+
+// Writes source rules for the fields to rules/sources/api-spec.yaml in the
+// config directory
+func WriteRules(configDirectory string, fields []string) error {
+	rules := []sourceRule{}
+	seen := map[string]bool{}
+	for _, field := range fields {
+		id := SourceId(field)
+		if id == SourceIdPrefix || seen[id] {
+			continue
+		}
+		seen[id] = true
+		rules = append(rules, sourceRule{
+			Id:          id,
+			Name:        field,
+			Category:    "API Fields",
+			Sensitivity: "medium",
+			Patterns:    []string{getFieldPattern(field)},
+			Tags:        map[string]string{"origin": "api-spec"},
+		})
+	}
+
+	data, err := yaml.Marshal(map[string]interface{}{"sources": rules})
+	if err != nil {
+		return err
+	}
+	rulesDirectory := filepath.Join(configDirectory, "rules", "sources")
+	if err := os.MkdirAll(rulesDirectory, os.ModePerm); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(rulesDirectory, "api-spec.yaml"), data, 0644)
+}
+
+// Matches the field in any case, with or without separators between
+// its words (firstName, first_name, FIRST-NAME)
+func getFieldPattern(field string) string {
+	words := []string{}
+	word := []rune{}
+	runes := []rune(field)
+	for i, r := range runes {
+		boundary := !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		if boundary || (i > 0 && unicode.IsUpper(r) && unicode.IsLower(runes[i-1])) {
+			if len(word) > 0 {
+				words = append(words, regexp.QuoteMeta(string(word)))
+			}
+			word = []rune{}
+		}
+		if !boundary {
+			word = append(word, r)
+		}
+	}
+	if len(word) > 0 {
+		words = append(words, regexp.QuoteMeta(string(word)))
+	}
+	return "(?i)" + strings.Join(words, "[_-]?")
+}
+
+type DataElement struct {
+	Field string `json:"field"`
+	Id    string `json:"id"`
+	// request, response or both
+	Direction string `json:"direction"`
+	// whether the engine found the data element in the code
+	FoundInCode bool `json:"foundInCode"`
+}
+
+type EndpointMapping struct {
+	Spec         string        `json:"spec"`
+	Endpoint     string        `json:"endpoint"`
+	DataElements []DataElement `json:"dataElements"`
+}
+
+// Maps the endpoints of the specs to the data elements they accept and
+// return, and whether the scan found them in the code
+func Map(specs []*Spec, scanResults *results.Results) []EndpointMapping {
+	found := map[string]bool{}
+	if scanResults != nil {
+		for _, source := range scanResults.Sources {
+			found[source.Id] = true
+		}
+	}
+
+	mappings := []EndpointMapping{}
+	for _, spec := range specs {
+		for _, endpoint := range spec.Endpoints {
+			directions := map[string]string{}
+			order := []string{}
+			for _, fields := range []struct {
+				direction string
+				names     []string
+			}{{"request", endpoint.RequestFields}, {"response", endpoint.ResponseFields}} {
+				for _, field := range fields.names {
+					if !isDataElementField(field) {
+						continue
+					}
+					switch directions[field] {
+					case "":
+						order = append(order, field)
+						directions[field] = fields.direction
+					case "request":
+						if fields.direction == "response" {
+							directions[field] = "both"
+						}
+					}
+				}
+			}
+
+			mapping := EndpointMapping{Spec: spec.Path, Endpoint: endpoint.Name, DataElements: []DataElement{}}
+			for _, field := range order {
+				id := SourceId(field)
+				mapping.DataElements = append(mapping.DataElements, DataElement{Field: field, Id: id, Direction: directions[field], FoundInCode: found[id]})
+			}
+			mappings = append(mappings, mapping)
+		}
+	}
+	return mappings
+}
+
+// Returns the number of data elements found in the code
+func CountFoundInCode(mappings []EndpointMapping) (found, total int) {
+	seen := map[string]bool{}
+	for _, mapping := range mappings {
+		for _, element := range mapping.DataElements {
+			if seen[element.Id] {
+				continue
+			}
+			seen[element.Id] = true
+			total++
+			if element.FoundInCode {
+				found++
+			}
+		}
+	}
+	return found, total
+}
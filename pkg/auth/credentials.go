@@ -0,0 +1,120 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Credentials for Privado Cloud, obtained with `privado auth login`
+// The file is only readable by the current user
+
+type Credentials struct {
+	Token     string    `json:"token"`
+	UserId    string    `json:"userId,omitempty"`
+	Email     string    `json:"email,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt,omitempty"`
+
+	// organization (tenant) scans are synced to
+	OrganizationId string `json:"organizationId,omitempty"`
+}
+
+// environment variables to provide the token and organization
+// without storing them (CI)
+const (
+	TokenEnvKey        = "PRIVADO_API_TOKEN"
+	OrganizationEnvKey = "PRIVADO_ORGANIZATION_ID"
+)
+
+// period before token expiry from which scans warn about it
+const TokenExpiryWarningPeriod = 7 * 24 * time.Hour
+
+var ErrNotLoggedIn = errors.New("not logged in")
+
+// Credentials provided using the environment cannot be updated
+func (c *Credentials) IsFromEnvironment() bool {
+	return os.Getenv(TokenEnvKey) != "" && c.Token == os.Getenv(TokenEnvKey)
+}
+
+func (c *Credentials) IsExpired() bool {
+	return !c.ExpiresAt.IsZero() && time.Now().After(c.ExpiresAt)
+}
+
+// Returns true if the token expires within the duration (or has expired)
+func (c *Credentials) ExpiresWithin(d time.Duration) bool {
+	return !c.ExpiresAt.IsZero() && time.Now().Add(d).After(c.ExpiresAt)
+}
+
+func SaveCredentials(credentialsPath string, credentials *Credentials) error {
+	if err := os.MkdirAll(filepath.Dir(credentialsPath), 0700); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(credentials, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(credentialsPath, data, 0600)
+}
+
+// Loads credentials from the environment (takes precedence) or the
+// credentials file. Returns ErrNotLoggedIn when none are available
+func LoadCredentials(credentialsPath string) (*Credentials, error) {
+	if token := os.Getenv(TokenEnvKey); token != "" {
+		return &Credentials{Token: token, OrganizationId: os.Getenv(OrganizationEnvKey)}, nil
+	}
+
+	data, err := os.ReadFile(credentialsPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNotLoggedIn
+		}
+		return nil, err
+	}
+
+	credentials := &Credentials{}
+	if err := json.Unmarshal(data, credentials); err != nil {
+		return nil, err
+	}
+	if credentials.Token == "" {
+		return nil, ErrNotLoggedIn
+	}
+	if organizationId := os.Getenv(OrganizationEnvKey); organizationId != "" {
+		credentials.OrganizationId = organizationId
+	}
+
+	return credentials, nil
+}
+
+func RemoveCredentials(credentialsPath string) error {
+	if err := os.Remove(credentialsPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
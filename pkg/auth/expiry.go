@@ -0,0 +1,71 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+package auth
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// The expiry of tokens provided using the environment is not known
+// locally: it is looked up (whoami) and cached by the hash of the token
+// (~/.privado/keys/token-expiry.json), so scans do not look it up each time
+
+type cachedTokenExpiry struct {
+	TokenHash string    `json:"tokenHash"`
+	ExpiresAt time.Time `json:"expiresAt,omitempty"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// Returns the cached expiry of the token, if it was looked up within the
+// TTL. A zero expiry means the token does not expire
+func LoadCachedTokenExpiry(cachePath, token string, ttl time.Duration) (time.Time, bool) {
+	data, err := os.ReadFile(cachePath)
+	if err != nil {
+		return time.Time{}, false
+	}
+	cached := cachedTokenExpiry{}
+	if err := json.Unmarshal(data, &cached); err != nil || cached.TokenHash != CalculateSHA256Hash(token) {
+		return time.Time{}, false
+	}
+	if time.Since(cached.CheckedAt) > ttl {
+		return time.Time{}, false
+	}
+	return cached.ExpiresAt, true
+}
+
+func SaveCachedTokenExpiry(cachePath, token string, expiresAt time.Time) error {
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(cachedTokenExpiry{
+		TokenHash: CalculateSHA256Hash(token),
+		ExpiresAt: expiresAt,
+		CheckedAt: time.Now().UTC(),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(cachePath, data, 0600)
+}
@@ -0,0 +1,144 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package azuredevops
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+)
+
+// Azure Pipelines logging commands, so that findings show up as build
+// issues and the results are published with the build
+// ref: https://learn.microsoft.com/en-us/azure/devops/pipelines/scripts/logging-commands
+
+const ArtifactName = "privado"
+
+func IsAzurePipelines() bool {
+	isAzurePipelines, _ := strconv.ParseBool(os.Getenv("TF_BUILD"))
+	return isAzurePipelines
+}
+
+func escapeMessage(s string) string {
+	s = strings.ReplaceAll(s, "%", "%AZP25")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	return strings.ReplaceAll(s, "\n", "%0A")
+}
+
+func escapeProperty(s string) string {
+	s = escapeMessage(s)
+	s = strings.ReplaceAll(s, ";", "%3B")
+	return strings.ReplaceAll(s, "]", "%5D")
+}
+
+// Returns path of the finding relative to the sources directory
+// scanDirectory is the absolute scanned path
+func sourcePath(scanDirectory string, finding results.Finding) string {
+	path := filepath.Join(scanDirectory, finding.RelativeFileName())
+	if sourcesDirectory := os.Getenv("BUILD_SOURCESDIRECTORY"); sourcesDirectory != "" {
+		if relativePath, err := filepath.Rel(sourcesDirectory, path); err == nil && !strings.HasPrefix(relativePath, "..") {
+			path = relativePath
+		}
+	}
+	return filepath.ToSlash(path)
+}
+
+// Logs an issue for each finding: "error" for failed findings,
+// "warning" for the rest
+func WriteIssues(w io.Writer, scanDirectory string, findings []results.Finding, failed map[string]bool) {
+	for _, finding := range findings {
+		issueType := "warning"
+		if failed[finding.Id] {
+			issueType = "error"
+		}
+
+		properties := []string{fmt.Sprintf("type=%s", issueType)}
+		if finding.FileName != "" {
+			properties = append(properties, fmt.Sprintf("sourcepath=%s", escapeProperty(sourcePath(scanDirectory, finding))))
+			if finding.LineNumber > 0 {
+				properties = append(properties, fmt.Sprintf("linenumber=%d", finding.LineNumber))
+			}
+		}
+		properties = append(properties, fmt.Sprintf("code=%s", escapeProperty(finding.PolicyId)))
+
+		message := fmt.Sprintf("Privado [%s] %s", finding.Severity, finding.PolicyName)
+		fmt.Fprintf(w, "##vso[task.logissue %s;]%s\n", strings.Join(properties, ";"), escapeMessage(message))
+	}
+}
+
+func escapeMarkdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
+// Returns the build summary for the findings as markdown
+func RenderBuildSummary(scanDirectory string, findings []results.Finding, failed map[string]bool, passed bool) string {
+	var b strings.Builder
+	counts := results.CountFindingsBySeverity(findings)
+
+	status := "Passed"
+	if !passed {
+		status = fmt.Sprintf("Failed (%d finding(s) at or above the threshold)", len(failed))
+	}
+	fmt.Fprintf(&b, "**Status:** %s\n\n", status)
+	fmt.Fprintf(&b, "| High | Medium | Low | Unknown |\n|---|---|---|---|\n| %d | %d | %d | %d |\n\n",
+		counts[results.SeverityHigh], counts[results.SeverityMedium], counts[results.SeverityLow], counts[results.SeverityUnknown])
+
+	if len(findings) == 0 {
+		return b.String()
+	}
+
+	b.WriteString("| Status | Severity | Policy | Location |\n|---|---|---|---|\n")
+	for _, finding := range findings {
+		findingStatus := "warning"
+		if failed[finding.Id] {
+			findingStatus = "**error**"
+		}
+		location := ""
+		if finding.FileName != "" {
+			location = fmt.Sprintf("`%s:%d`", sourcePath(scanDirectory, finding), finding.LineNumber)
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", findingStatus, finding.Severity, escapeMarkdownCell(finding.PolicyName), location)
+	}
+
+	return b.String()
+}
+
+// Writes the summary to a file and attaches it to the build summary page
+func UploadBuildSummary(w io.Writer, summaryPath, markdown string) error {
+	if err := os.WriteFile(summaryPath, []byte(markdown), 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "##vso[task.uploadsummary]%s\n", summaryPath)
+	return nil
+}
+
+// Publishes the file as a build artifact
+func UploadArtifact(w io.Writer, path string) {
+	fmt.Fprintf(w, "##vso[artifact.upload containerfolder=%s;artifactname=%s;]%s\n", ArtifactName, ArtifactName, path)
+}
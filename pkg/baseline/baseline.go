@@ -0,0 +1,134 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package baseline
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+)
+
+// A baseline is the set of accepted (known) findings of a repository;
+// findings not in the baseline are new. Findings are identified by
+// their stable fingerprints (results.Finding.Id)
+
+const FileName = "baseline.json"
+
+var ErrNoBaseline = errors.New("no baseline")
+
+type Baseline struct {
+	CreatedAt  time.Time `json:"createdAt"`
+	FindingIds []string  `json:"findingIds"`
+
+	// ids of the sinks data flowed to, nil in baselines created
+	// before sinks were recorded
+	SinkIds []string `json:"sinkIds,omitempty"`
+}
+
+func New(findings []results.Finding) *Baseline {
+	b := &Baseline{CreatedAt: time.Now().UTC(), FindingIds: []string{}}
+	for _, finding := range findings {
+		b.FindingIds = append(b.FindingIds, finding.Id)
+	}
+	return b
+}
+
+// Returns the baseline of the findings and data flow sinks of the results
+func NewFromResults(r *results.Results) *Baseline {
+	b := New(r.Findings())
+	b.SinkIds = []string{}
+	for _, sink := range r.DataFlowSinks() {
+		b.SinkIds = append(b.SinkIds, sink.Id)
+	}
+	return b
+}
+
+func Load(baselinePath string) (*Baseline, error) {
+	data, err := os.ReadFile(baselinePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNoBaseline
+		}
+		return nil, err
+	}
+
+	b := &Baseline{}
+	if err := json.Unmarshal(data, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (b *Baseline) Save(baselinePath string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(baselinePath), os.ModePerm); err != nil {
+		return err
+	}
+	return os.WriteFile(baselinePath, data, 0644)
+}
+
+func Remove(baselinePath string) error {
+	if err := os.Remove(baselinePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Returns findings not in the baseline
+func (b *Baseline) NewFindings(findings []results.Finding) []results.Finding {
+	known := map[string]bool{}
+	for _, id := range b.FindingIds {
+		known[id] = true
+	}
+
+	newFindings := []results.Finding{}
+	for _, finding := range findings {
+		if !known[finding.Id] {
+			newFindings = append(newFindings, finding)
+		}
+	}
+	return newFindings
+}
+
+// Returns data flow sinks not in the baseline
+func (b *Baseline) NewSinks(sinks []results.Sink) []results.Sink {
+	known := map[string]bool{}
+	for _, id := range b.SinkIds {
+		known[id] = true
+	}
+
+	newSinks := []results.Sink{}
+	for _, sink := range sinks {
+		if !known[sink.Id] {
+			newSinks = append(newSinks, sink)
+		}
+	}
+	return newSinks
+}
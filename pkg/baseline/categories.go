@@ -0,0 +1,65 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package baseline
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+)
+
+// Categories of sinks new data flows can be blocked for (ci --block-new),
+// matched by the sink type or id prefix of the rules
+var sinkCategories = map[string]struct {
+	sinkType string
+	idPrefix string
+}{
+	"third-party-sharing": {sinkType: "third_parties", idPrefix: "ThirdParties."},
+	"external-storage":    {sinkType: "storages", idPrefix: "Storages."},
+	"leakage":             {sinkType: "leakages", idPrefix: "Leakages."},
+}
+
+func SinkCategories() []string {
+	categories := []string{}
+	for category := range sinkCategories {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	return categories
+}
+
+func IsValidSinkCategory(category string) bool {
+	_, ok := sinkCategories[category]
+	return ok
+}
+
+// Returns the category of the sink, empty if it is in none
+func GetSinkCategory(sink results.Sink) string {
+	for category, match := range sinkCategories {
+		if strings.EqualFold(sink.SinkType, match.sinkType) || strings.HasPrefix(sink.Id, match.idPrefix) {
+			return category
+		}
+	}
+	return ""
+}
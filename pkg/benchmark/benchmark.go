@@ -0,0 +1,151 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package benchmark
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Repeated scans of a repository across images and scan flags (variants),
+// summarized to compare durations, memory and the stability of findings
+
+type Configuration struct {
+	Name  string   `json:"name"`
+	Image string   `json:"image"`
+	Args  []string `json:"args"`
+}
+
+type Run struct {
+	Configuration string        `json:"configuration"`
+	Iteration     int           `json:"iteration"`
+	Duration      time.Duration `json:"durationNs"`
+	// peak memory of the scan container in bytes, 0 if it could not be sampled
+	PeakMemory uint64   `json:"peakMemoryBytes"`
+	Findings   int      `json:"findings"`
+	FindingIds []string `json:"-"`
+	LogPath    string   `json:"logPath"`
+	Error      string   `json:"error,omitempty"`
+}
+
+type Summary struct {
+	Configuration
+	Runs         int           `json:"runs"`
+	Failed       int           `json:"failed"`
+	MeanDuration time.Duration `json:"meanDurationNs"`
+	MinDuration  time.Duration `json:"minDurationNs"`
+	MaxDuration  time.Duration `json:"maxDurationNs"`
+	PeakMemory   uint64        `json:"peakMemoryBytes"`
+	MinFindings  int           `json:"minFindings"`
+	MaxFindings  int           `json:"maxFindings"`
+	// findings not reported by every successful run
+	UnstableFindings int `json:"unstableFindings"`
+}
+
+func (s Summary) IsStable() bool {
+	return s.UnstableFindings == 0
+}
+
+type Report struct {
+	Repository string    `json:"repository"`
+	Timestamp  time.Time `json:"timestamp"`
+	Runs       []Run     `json:"runs"`
+	Summaries  []Summary `json:"summary"`
+}
+
+// Parses a variant: "<name>=<scan flags>", e.g. "no-deps=--skip-dependency-download"
+func ParseVariant(variant string) (Configuration, error) {
+	parts := strings.SplitN(variant, "=", 2)
+	name := strings.TrimSpace(parts[0])
+	if len(parts) != 2 || name == "" {
+		return Configuration{}, fmt.Errorf("invalid variant '%s', expected <name>=<scan flags>", variant)
+	}
+	return Configuration{Name: name, Args: strings.Fields(parts[1])}, nil
+}
+
+// Returns a configuration for each variant with each image
+func Matrix(images []string, variants []Configuration) []Configuration {
+	configurations := []Configuration{}
+	for _, image := range images {
+		for _, variant := range variants {
+			configuration := Configuration{Name: variant.Name, Image: image, Args: variant.Args}
+			if len(images) > 1 {
+				configuration.Name = fmt.Sprintf("%s (%s)", variant.Name, image)
+			}
+			configurations = append(configurations, configuration)
+		}
+	}
+	return configurations
+}
+
+// Summarizes the runs of the configuration; durations, memory and
+// findings are of successful runs only
+func Summarize(configuration Configuration, runs []Run) Summary {
+	summary := Summary{Configuration: configuration}
+	total := time.Duration(0)
+	succeeded := 0
+	reportedBy := map[string]int{}
+
+	for _, run := range runs {
+		if run.Configuration != configuration.Name {
+			continue
+		}
+		summary.Runs++
+		if run.Error != "" {
+			summary.Failed++
+			continue
+		}
+
+		if succeeded == 0 || run.Duration < summary.MinDuration {
+			summary.MinDuration = run.Duration
+		}
+		if run.Duration > summary.MaxDuration {
+			summary.MaxDuration = run.Duration
+		}
+		if succeeded == 0 || run.Findings < summary.MinFindings {
+			summary.MinFindings = run.Findings
+		}
+		if run.Findings > summary.MaxFindings {
+			summary.MaxFindings = run.Findings
+		}
+		if run.PeakMemory > summary.PeakMemory {
+			summary.PeakMemory = run.PeakMemory
+		}
+		for _, id := range run.FindingIds {
+			reportedBy[id]++
+		}
+		total += run.Duration
+		succeeded++
+	}
+
+	if succeeded > 0 {
+		summary.MeanDuration = total / time.Duration(succeeded)
+	}
+	for _, count := range reportedBy {
+		if count < succeeded {
+			summary.UnstableFindings++
+		}
+	}
+	return summary
+}
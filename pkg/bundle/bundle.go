@@ -0,0 +1,238 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// A bundle is a tar.gz archive of the results of a scan and everything
+// needed to audit them: the manifest (versions, repository state and
+// checksums of all files) and the rules that were in effect
+
+const (
+	ManifestFileName = "manifest.json"
+	FormatVersion    = 1
+)
+
+type File struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+type Manifest struct {
+	FormatVersion int       `json:"formatVersion"`
+	CreatedAt     time.Time `json:"createdAt"`
+
+	Repository string    `json:"repository"`
+	Branch     string    `json:"branch,omitempty"`
+	Commit     string    `json:"commit,omitempty"`
+	RemoteURL  string    `json:"remoteUrl,omitempty"`
+	ScannedAt  time.Time `json:"scannedAt,omitempty"`
+
+	// version of the cli that created the bundle
+	CLIVersion string `json:"cliVersion"`
+	// versions the scan was run with
+	ScanCLIVersion string `json:"scanCliVersion,omitempty"`
+	EngineVersion  string `json:"engineVersion,omitempty"`
+	Image          string `json:"image,omitempty"`
+	ImageDigest    string `json:"imageDigest,omitempty"`
+
+	Findings           int            `json:"findings"`
+	FindingsBySeverity map[string]int `json:"findingsBySeverity"`
+	ExternalRules      bool           `json:"externalRules"`
+
+	// all files of the bundle, except the manifest
+	Files []File `json:"files"`
+}
+
+// Entry of the bundle: a file or a directory (added recursively)
+type Entry struct {
+	// path in the bundle, slash separated
+	Path string
+	// path on the host, or Data for generated files
+	HostPath string
+	Data     []byte
+}
+
+// Writes the entries and the manifest (completed with the files) to the bundle
+func Create(bundlePath string, manifest Manifest, entries []Entry) error {
+	file, err := os.Create(bundlePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	absoluteBundlePath, _ := filepath.Abs(bundlePath)
+	gzipWriter := gzip.NewWriter(file)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	manifest.FormatVersion = FormatVersion
+	manifest.Files = []File{}
+	addFile := func(name string, reader io.Reader, size int64, modTime time.Time) error {
+		if err := tarWriter.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: size, ModTime: modTime}); err != nil {
+			return err
+		}
+		hash := sha256.New()
+		if _, err := io.Copy(io.MultiWriter(tarWriter, hash), reader); err != nil {
+			return err
+		}
+		manifest.Files = append(manifest.Files, File{Path: name, Size: size, SHA256: hex.EncodeToString(hash.Sum(nil))})
+		return nil
+	}
+
+	for _, entry := range entries {
+		if entry.HostPath == "" {
+			if err := addFile(entry.Path, bytes.NewReader(entry.Data), int64(len(entry.Data)), time.Now()); err != nil {
+				return err
+			}
+			continue
+		}
+
+		err := filepath.WalkDir(entry.HostPath, func(hostPath string, dirEntry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			// the bundle may be written to a directory being bundled
+			if !dirEntry.Type().IsRegular() || hostPath == absoluteBundlePath {
+				return nil
+			}
+			relativePath, err := filepath.Rel(entry.HostPath, hostPath)
+			if err != nil {
+				return err
+			}
+			info, err := dirEntry.Info()
+			if err != nil {
+				return err
+			}
+			source, err := os.Open(hostPath)
+			if err != nil {
+				return err
+			}
+			defer source.Close()
+			return addFile(path.Join(entry.Path, filepath.ToSlash(relativePath)), source, info.Size(), info.ModTime())
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := tarWriter.WriteHeader(&tar.Header{Name: ManifestFileName, Mode: 0644, Size: int64(len(data)), ModTime: time.Now()}); err != nil {
+		return err
+	}
+	if _, err := tarWriter.Write(data); err != nil {
+		return err
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return err
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// Reads the manifest of the bundle and verifies the files of the bundle
+// against it. Returns the manifest and the problems found (if any)
+func Inspect(bundlePath string) (*Manifest, []string, error) {
+	file, err := os.Open(bundlePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	gzipReader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, nil, fmt.Errorf("not a bundle: %v", err)
+	}
+	tarReader := tar.NewReader(gzipReader)
+
+	checksums := map[string]string{}
+	var manifest *Manifest
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("not a bundle: %v", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		if header.Name == ManifestFileName {
+			manifest = &Manifest{}
+			if err := json.NewDecoder(tarReader).Decode(manifest); err != nil {
+				return nil, nil, fmt.Errorf("invalid manifest: %v", err)
+			}
+			continue
+		}
+		hash := sha256.New()
+		if _, err := io.Copy(hash, tarReader); err != nil {
+			return nil, nil, err
+		}
+		checksums[header.Name] = hex.EncodeToString(hash.Sum(nil))
+	}
+	if manifest == nil {
+		return nil, nil, fmt.Errorf("not a bundle: %s not found", ManifestFileName)
+	}
+
+	problems := []string{}
+	listed := map[string]bool{}
+	for _, f := range manifest.Files {
+		listed[f.Path] = true
+		checksum, ok := checksums[f.Path]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("missing file: %s", f.Path))
+		} else if checksum != f.SHA256 {
+			problems = append(problems, fmt.Sprintf("checksum mismatch: %s", f.Path))
+		}
+	}
+	for name := range checksums {
+		if !listed[name] {
+			problems = append(problems, fmt.Sprintf("file not in manifest: %s", name))
+		}
+	}
+	sort.Strings(problems)
+	return manifest, problems, nil
+}
@@ -0,0 +1,231 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cache
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Export and import of the caches mounted into the engine (dependency
+// packages), so that CI systems with ephemeral runners can persist them
+// between jobs. Each cache is stored under its name in the archive
+
+// Returns compression of the archive from its extension
+// ".tar.zst"/".zst" requires the zstd executable
+func compressionFromPath(archivePath string) string {
+	switch {
+	case strings.HasSuffix(archivePath, ".zst"):
+		return "zstd"
+	case strings.HasSuffix(archivePath, ".gz"), strings.HasSuffix(archivePath, ".tgz"):
+		return "gzip"
+	}
+	return ""
+}
+
+// Writes the directories (name -> path) to the archive
+// Returns the number of files exported
+func Export(archivePath string, directories map[string]string) (int, error) {
+	file, err := os.Create(archivePath)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	var writer io.WriteCloser = file
+	var zstdCmd *exec.Cmd
+	switch compressionFromPath(archivePath) {
+	case "gzip":
+		writer = gzip.NewWriter(file)
+	case "zstd":
+		zstdCmd = exec.Command("zstd", "-q", "-c", "-T0")
+		zstdCmd.Stdout = file
+		zstdCmd.Stderr = os.Stderr
+		if writer, err = zstdCmd.StdinPipe(); err != nil {
+			return 0, err
+		}
+		if err := zstdCmd.Start(); err != nil {
+			return 0, fmt.Errorf("cannot run zstd (is it installed?): %w", err)
+		}
+	}
+
+	tarWriter := tar.NewWriter(writer)
+	count := 0
+	for name, directory := range directories {
+		n, err := addDirectory(tarWriter, name, directory)
+		count += n
+		if err != nil {
+			return count, err
+		}
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return count, err
+	}
+	if writer != io.WriteCloser(file) {
+		if err := writer.Close(); err != nil {
+			return count, err
+		}
+	}
+	if zstdCmd != nil {
+		if err := zstdCmd.Wait(); err != nil {
+			return count, fmt.Errorf("zstd failed: %w", err)
+		}
+	}
+
+	return count, nil
+}
+
+func addDirectory(tarWriter *tar.Writer, name, directory string) (int, error) {
+	count := 0
+	err := filepath.Walk(directory, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		// only regular files and directories are cached
+		if !info.Mode().IsRegular() && !info.IsDir() {
+			return nil
+		}
+
+		relativePath, err := filepath.Rel(directory, path)
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(filepath.Join(name, relativePath))
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		if _, err := io.Copy(tarWriter, file); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	return count, err
+}
+
+// Extracts the archive: entries under each name are extracted to the
+// corresponding directory (name -> path), others are ignored
+// Returns the number of files imported
+func Import(archivePath string, directories map[string]string) (int, error) {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	switch compressionFromPath(archivePath) {
+	case "gzip":
+		gzipReader, err := gzip.NewReader(file)
+		if err != nil {
+			return 0, err
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	case "zstd":
+		zstdCmd := exec.Command("zstd", "-d", "-q", "-c")
+		zstdCmd.Stdin = file
+		zstdCmd.Stderr = os.Stderr
+		if reader, err = zstdCmd.StdoutPipe(); err != nil {
+			return 0, err
+		}
+		if err := zstdCmd.Start(); err != nil {
+			return 0, fmt.Errorf("cannot run zstd (is it installed?): %w", err)
+		}
+		defer zstdCmd.Wait()
+	}
+
+	tarReader := tar.NewReader(reader)
+	count := 0
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return count, err
+		}
+
+		parts := strings.SplitN(strings.TrimPrefix(header.Name, "/"), "/", 2)
+		directory, ok := directories[parts[0]]
+		if !ok || len(parts) < 2 || parts[1] == "" {
+			continue
+		}
+
+		// never write outside of the target directory
+		target := filepath.Join(directory, filepath.FromSlash(parts[1]))
+		if !strings.HasPrefix(target, filepath.Clean(directory)+string(os.PathSeparator)) {
+			return count, fmt.Errorf("invalid path in archive: %s", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, os.ModePerm); err != nil {
+				return count, err
+			}
+		case tar.TypeReg:
+			if err := writeFile(target, tarReader, os.FileMode(header.Mode).Perm()); err != nil {
+				return count, err
+			}
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func writeFile(target string, reader io.Reader, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(file, reader)
+	return err
+}
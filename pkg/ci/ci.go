@@ -67,6 +67,19 @@ type Provider struct {
 	// defines the env keys that can be used to
 	// identify the user in the ci environment
 	UserKeys []string `json:"keys"`
+
+	// defines the env keys that carry the target (base) branch
+	// of the pull/merge request being built, if any
+	BaseBranchKeys []string `json:"baseBranchKeys"`
+
+	// defines the env keys that carry the branch being built
+	BranchKeys []string `json:"branchKeys"`
+
+	// defines the env keys that carry the build (pipeline run) id
+	BuildIdKeys []string `json:"buildIdKeys"`
+
+	// url of the build, with ${KEY} replaced by the env values
+	BuildURL string `json:"buildUrl"`
 }
 
 type Identifier struct {
@@ -175,3 +188,50 @@ func (provider *Provider) GetUserIdentifierFromCIEnvironment() string {
 
 	return strings.Join(values, "/")
 }
+
+// Returns the target branch of the pull request being built
+// empty if the build is not for a pull request
+func (provider *Provider) GetPullRequestBaseBranch() string {
+	for _, key := range provider.BaseBranchKeys {
+		if val := os.Getenv(key); val != "" {
+			return strings.TrimPrefix(val, "refs/heads/")
+		}
+	}
+	return ""
+}
+
+// Returns the branch being built, empty if not defined
+func (provider *Provider) GetBranch() string {
+	for _, key := range provider.BranchKeys {
+		if val := os.Getenv(key); val != "" {
+			return strings.TrimPrefix(strings.TrimPrefix(val, "refs/heads/"), "origin/")
+		}
+	}
+	return ""
+}
+
+// Returns the id of the build, empty if not defined
+func (provider *Provider) GetBuildId() string {
+	for _, key := range provider.BuildIdKeys {
+		if val := os.Getenv(key); val != "" {
+			return val
+		}
+	}
+	return ""
+}
+
+// Returns the url of the build, empty if any of its env keys is not defined
+func (provider *Provider) GetBuildURL() string {
+	missing := false
+	url := os.Expand(provider.BuildURL, func(key string) string {
+		val := os.Getenv(key)
+		if val == "" {
+			missing = true
+		}
+		return val
+	})
+	if missing {
+		return ""
+	}
+	return url
+}
@@ -0,0 +1,212 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package clierrors
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+)
+
+// Errors with stable codes (e.g. PRV-DOCKER-001), so users and support can
+// refer to a failure mode and scripts can branch on it. Codes are never
+// reused or renumbered: remove a code by leaving it unused
+
+type Code struct {
+	Id          string
+	Description string
+
+	// outcome class the error exits with (see config.Outcomes),
+	// empty for errors before or outside of a scan (exit code 1)
+	Outcome string
+}
+
+type Error struct {
+	Code    *Code
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("[%s] %s", e.Code.Id, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (c *Code) New(message string) *Error {
+	return &Error{Code: c, Message: message}
+}
+
+func (c *Code) Errorf(format string, args ...interface{}) *Error {
+	err := fmt.Errorf(format, args...)
+	return &Error{Code: c, Message: err.Error(), Err: errors.Unwrap(err)}
+}
+
+func (c *Code) Wrap(err error) *Error {
+	return &Error{Code: c, Message: err.Error(), Err: err}
+}
+
+var codes = map[string]*Code{}
+
+func register(id, outcome, description string) *Code {
+	if _, exists := codes[id]; exists {
+		panic(fmt.Sprintf("duplicate error code: %s", id))
+	}
+	code := &Code{Id: id, Description: description, Outcome: outcome}
+	codes[id] = code
+	return code
+}
+
+// Returns the code with the id (case insensitive)
+func Lookup(id string) (*Code, bool) {
+	code, ok := codes[strings.ToUpper(strings.TrimSpace(id))]
+	return code, ok
+}
+
+// Returns all codes, sorted by id
+func All() []*Code {
+	all := []*Code{}
+	for _, code := range codes {
+		all = append(all, code)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Id < all[j].Id })
+	return all
+}
+
+// usage
+var (
+	InvalidArguments   = register("PRV-ARGS-001", "", "The command, its arguments or flags are invalid (see 'privado help <command>')")
+	InvalidFlagValue   = register("PRV-ARGS-002", "", "A flag has an invalid value")
+	ConflictingOptions = register("PRV-ARGS-003", "", "Options are missing or cannot be used together")
+	PathNotFound       = register("PRV-ARGS-004", "", "A file or directory passed to the command does not exist")
+)
+
+// configuration
+var (
+	ConfigSave            = register("PRV-CONFIG-001", "", "The configuration file (~/.privado/config.json) cannot be written")
+	ConfigInvalidValue    = register("PRV-CONFIG-002", "", "A configuration value is invalid")
+	ProjectConfigInvalid  = register("PRV-CONFIG-003", "", "The configuration of the repository (.privado/config.json) cannot be read or is invalid")
+	SchedulesLoad         = register("PRV-CONFIG-004", "", "Scheduled scans (~/.privado/schedules.json) cannot be read")
+	SchedulesSave         = register("PRV-CONFIG-005", "", "Scheduled scans (~/.privado/schedules.json) cannot be written")
+	ScheduleNotFound      = register("PRV-CONFIG-006", "", "No scheduled scan with the id exists (see 'privado schedule list')")
+	TempDirectoryCreation = register("PRV-CONFIG-007", "", "The temporary directory cannot be created")
+	QueueLoad             = register("PRV-CONFIG-008", "", "The scan queue (~/.privado/queue/queue.json) cannot be read")
+	QueueSave             = register("PRV-CONFIG-009", "", "The scan queue (~/.privado/queue/queue.json) cannot be written")
+	QueueItemNotFound     = register("PRV-CONFIG-010", "", "No queued scan with the id exists (see 'privado queue list')")
+)
+
+// authentication and licensing
+var (
+	NotLoggedIn             = register("PRV-AUTH-001", "", "Not logged in to Privado Cloud (run 'privado auth login')")
+	CredentialsLoad         = register("PRV-AUTH-002", "", "Stored credentials cannot be read")
+	CredentialsSave         = register("PRV-AUTH-003", "", "Credentials cannot be stored or removed")
+	LoginFailed             = register("PRV-AUTH-004", "", "Login to Privado Cloud failed")
+	CredentialsVerification = register("PRV-AUTH-005", "", "Credentials cannot be verified with Privado Cloud")
+	OrganizationUnavailable = register("PRV-AUTH-006", "", "Organizations cannot be listed or selected")
+	TokenRotation           = register("PRV-AUTH-007", "", "The token cannot be rotated")
+	LicenseActivation       = register("PRV-LICENSE-001", "", "The license cannot be activated")
+	LicenseInvalid          = register("PRV-LICENSE-002", "", "The license is invalid or has expired")
+	LicenseRemoval          = register("PRV-LICENSE-003", "", "The license cannot be removed")
+)
+
+// docker and the scan engine
+var (
+	DockerAccessKey = register("PRV-DOCKER-001", config.OutcomeInfraError, "The docker access key cannot be fetched: the engine image cannot be pulled or authenticated. Check that docker is running and the registry is reachable")
+	DockerRun       = register("PRV-DOCKER-002", config.OutcomeInfraError, "The engine container cannot be created, started or attached to")
+	ImagePull       = register("PRV-DOCKER-003", config.OutcomeInfraError, "The engine image cannot be pulled. Check that docker is running, the registry is reachable and the image exists")
+	ImageExtraction = register("PRV-DOCKER-004", config.OutcomeInfraError, "Files cannot be extracted from the image to scan (privado scan image)")
+	EngineFailed    = register("PRV-ENGINE-001", config.OutcomeEngineError, "The scan engine exited with an error (run with --debug for details)")
+	EngineMemory    = register("PRV-ENGINE-002", config.OutcomeInfraError, "The scan engine ran out of memory. Retry with more memory: --engine-memory (e.g. 16g), or the memory of docker (Docker Desktop: Settings > Resources)")
+	ResultsRead     = register("PRV-RESULTS-001", config.OutcomeEngineError, "Scan results (.privado/privado.json) cannot be found or read")
+	ResultsWrite    = register("PRV-RESULTS-002", config.OutcomeInfraError, "Scan results cannot be written")
+	ResultsEncrypt  = register("PRV-RESULTS-003", config.OutcomeInfraError, "Scan results cannot be encrypted (--encrypt-results) or decrypted: the key cannot be read, or the results were not encrypted for it")
+)
+
+// scan preparation
+var (
+	DiskSpace         = register("PRV-SCAN-001", config.OutcomeInfraError, "Not enough free disk space for the image, the engine or the results")
+	WorkspaceCreation = register("PRV-SCAN-002", config.OutcomeInfraError, "The workspace for the copy of the source code (--copy-source) cannot be created")
+	WorkspaceCopy     = register("PRV-SCAN-003", config.OutcomeInfraError, "The source code cannot be copied to or from the workspace (--copy-source)")
+	PreScanHookFailed = register("PRV-SCAN-004", config.OutcomeInfraError, "The pre-scan hook of the repository failed or timed out")
+	ProgressOutput    = register("PRV-SCAN-005", "", "The progress output (--progress-output) cannot be opened")
+	EngineWarnings    = register("PRV-SCAN-006", config.OutcomeEngineError, "The engine reported dependency resolution failures, parse errors or skipped files (--strict)")
+)
+
+// git
+var (
+	NotAGitRepository = register("PRV-GIT-001", "", "The directory is not a git repository")
+	GitHookExists     = register("PRV-GIT-002", "", "A git hook already exists (use --force to replace it)")
+	GitHookWrite      = register("PRV-GIT-003", "", "The git hook cannot be written")
+	ChangedFiles      = register("PRV-GIT-004", "", "Changed files cannot be determined with git")
+	BareRepository    = register("PRV-GIT-005", "", "The repository is bare and has no files to scan (scan a checkout or worktree of it)")
+	SubmoduleUpdate   = register("PRV-GIT-006", config.OutcomeInfraError, "Submodules cannot be initialized or updated (--recurse-submodules)")
+)
+
+// sharded scans
+var (
+	ShardModules      = register("PRV-SHARD-001", "", "Modules of the repository cannot be discovered")
+	ShardResultsRead  = register("PRV-SHARD-002", config.OutcomeEngineError, "Results of a module or shard cannot be read")
+	NoShardResults    = register("PRV-SHARD-003", "", "No shard results to merge were found")
+	ShardResultsWrite = register("PRV-SHARD-004", "", "Shard results cannot be written")
+)
+
+// privado cloud and organizations
+var (
+	CloudScans       = register("PRV-CLOUD-001", "", "Synced scans cannot be listed, or none were found")
+	CloudDownload    = register("PRV-CLOUD-002", "", "Scan results cannot be downloaded from Privado Cloud")
+	OrgRepositories  = register("PRV-ORG-001", "", "Repositories of the organization cannot be listed, or none matched the filters")
+	OrgReportWrite   = register("PRV-ORG-002", "", "The organization scan report or working directory cannot be written")
+	ScanSyncFailed   = register("PRV-UPLOAD-001", config.OutcomeInfraError, "Results cannot be uploaded to Privado Dashboard")
+	ValidationFailed = register("PRV-VALIDATE-001", config.OutcomeInfraError, "Rules cannot be validated")
+)
+
+// other commands
+var (
+	CacheArchive       = register("PRV-CACHE-001", "", "Dependency caches cannot be exported or imported")
+	BundleCreation     = register("PRV-BUNDLE-001", "", "The results bundle cannot be created")
+	BundleVerification = register("PRV-BUNDLE-002", "", "The results bundle cannot be read or does not match its manifest")
+	SupportBundle      = register("PRV-BUNDLE-003", "", "The support bundle cannot be created")
+	ReportWrite        = register("PRV-REPORT-001", "", "The report of the scan results cannot be written")
+	ReportEmail        = register("PRV-REPORT-002", "", "The report cannot be emailed: no SMTP server is configured (smtp in ~/.privado/config.json or --smtp-config) or it rejected the message")
+	DocsGeneration     = register("PRV-DOCS-001", "", "Reference documentation cannot be written")
+	UpdatePermission   = register("PRV-UPDATE-001", "", "The installation cannot be updated without privileged permissions")
+	PluginFailed       = register("PRV-PLUGIN-001", "", "The plugin cannot be run")
+	ServerStopped      = register("PRV-SERVER-001", "", "The server or language server stopped with an error")
+	LogNotFound        = register("PRV-LOGS-001", "", "The scan log cannot be found or read")
+	HistoryRead        = register("PRV-HISTORY-001", "", "The local scan history (~/.privado/history) cannot be read")
+	HistoryPrune       = register("PRV-HISTORY-002", "", "Scans, scheduled runs or logs beyond the retention cannot be removed")
+	BenchmarkFailed    = register("PRV-BENCHMARK-001", "", "The benchmark cannot be run or its report cannot be written")
+	ExportFailed       = register("PRV-EXPORT-001", "", "Findings cannot be exported: no destination is configured (exports in ~/.privado/config.json) or it rejected them")
+	BaselineInvalid    = register("PRV-BASELINE-001", "", "The baseline of the repository (.privado/baseline.json) cannot be read or written")
+	PolicyEvaluation   = register("PRV-POLICY-001", "", "The rego policy (--policy-rego) cannot be evaluated or returned an invalid decision")
+	PolicyOPANotFound  = register("PRV-POLICY-002", "", "The opa executable, required to evaluate rego policies (--policy-rego), is not installed or cannot be run (--opa-path)")
+	APISpecInvalid     = register("PRV-APISPEC-001", "", "An api specification (--api-spec) cannot be read or is not an OpenAPI, Swagger or GraphQL schema")
+	DBSchemaInvalid    = register("PRV-DBSCHEMA-001", "", "A database schema (--db-schema) cannot be read or has no tables")
+	SBOMInvalid        = register("PRV-SBOM-001", "", "The SBOM (--sbom) cannot be read or is not a CycloneDX or SPDX document")
+	ExclusionsInvalid  = register("PRV-FEEDBACK-001", "", "The exclusions of the repository (.privado/exclusions.json) cannot be read or written")
+)
@@ -0,0 +1,177 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cloud
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+)
+
+// Client for the Privado Cloud API used by the cli
+
+const (
+	whoAmIEndpoint      = "/cli/v1/auth/whoami"
+	rotateTokenEndpoint = "/cli/v1/auth/token/rotate"
+)
+
+type Client struct {
+	host       string
+	token      string
+	httpClient *http.Client
+}
+
+type Organization struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type Identity struct {
+	UserId        string         `json:"userId"`
+	Email         string         `json:"email"`
+	Name          string         `json:"name"`
+	Organizations []Organization `json:"organizations"`
+
+	// expiry of the token used for the request, zero if it does not expire
+	TokenExpiresAt time.Time `json:"tokenExpiresAt,omitempty"`
+}
+
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("privado cloud: %s (status %d)", e.Message, e.StatusCode)
+	}
+	return fmt.Sprintf("privado cloud: received status %d", e.StatusCode)
+}
+
+func NewClient(token string) *Client {
+	return &Client{
+		host:       config.AppConfig.PrivadoCloudAPIHost,
+		token:      token,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Sets the timeout of the requests of the client, e.g. for lookups that
+// should not hold up a scan
+func (c *Client) WithTimeout(timeout time.Duration) *Client {
+	c.httpClient.Timeout = timeout
+	return c
+}
+
+func (c *Client) do(method, endpoint string, requestBody, response interface{}) error {
+	data, err := c.doRaw(method, endpoint, requestBody, response)
+	if err != nil {
+		return err
+	}
+
+	if response != nil && len(data) > 0 {
+		return json.Unmarshal(data, response)
+	}
+
+	return nil
+}
+
+// performs the request and returns the raw response body
+// errorResponse (if not nil) is populated from the body of non-ok responses
+func (c *Client) doRaw(method, endpoint string, requestBody, errorResponse interface{}) ([]byte, error) {
+	var body io.Reader
+	if requestBody != nil {
+		data, err := json.Marshal(requestBody)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewBuffer(data)
+	}
+
+	req, err := http.NewRequest(method, c.host+endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if requestBody != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.token))
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		apiError := &APIError{StatusCode: res.StatusCode}
+		errorBody := struct {
+			Message string `json:"message"`
+		}{}
+		if json.Unmarshal(data, &errorBody) == nil {
+			apiError.Message = errorBody.Message
+		}
+		// some endpoints carry details in the response (e.g. oauth errors)
+		if errorResponse != nil {
+			_ = json.Unmarshal(data, errorResponse)
+		}
+		return nil, apiError
+	}
+
+	return data, nil
+}
+
+// Returns the identity the client token belongs to
+func (c *Client) WhoAmI() (*Identity, error) {
+	identity := &Identity{}
+	if err := c.do("GET", whoAmIEndpoint, nil, identity); err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+
+// Exchanges the client token for a fresh one
+// The current token is revoked once the new token is issued
+func (c *Client) RotateToken() (*Token, error) {
+	token := &Token{}
+	if err := c.do("POST", rotateTokenEndpoint, nil, token); err != nil {
+		return nil, err
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("no token received")
+	}
+	return token, nil
+}
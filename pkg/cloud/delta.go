@@ -0,0 +1,184 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/Privado-Inc/privado-cli/pkg/auth"
+)
+
+const scanDeltaEndpoint = "/cli/v1/scans/%s/delta"
+
+// Changes of results (privado.json) against the results of a synced scan,
+// so that only changes are uploaded. Values are addressed by JSON pointers
+// (RFC 6901); the base results with removed keys and array elements
+// removed, then changed values set and array elements added, are the
+// new results
+type ScanDelta struct {
+	BaseScanId string `json:"baseScanId"`
+
+	// values added or changed (other than arrays of both results)
+	Changed map[string]interface{} `json:"changed,omitempty"`
+
+	// keys removed
+	Removed []string `json:"removed,omitempty"`
+
+	// elements added to arrays, and hashes (see ElementHash) of the
+	// elements removed from arrays
+	AddedElements   map[string][]interface{} `json:"addedElements,omitempty"`
+	RemovedElements map[string][]string      `json:"removedElements,omitempty"`
+}
+
+// Returns the hash identifying an array element: the hash of its JSON
+// encoding (object keys are encoded sorted)
+func ElementHash(element interface{}) string {
+	data, _ := json.Marshal(element)
+	return auth.CalculateSHA256Hash(string(data))
+}
+
+func escapePointerToken(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+// Returns the changes of current against previous, raw results of the
+// scan baseScanId
+func ComputeDelta(baseScanId string, previous, current map[string]interface{}) *ScanDelta {
+	delta := &ScanDelta{
+		BaseScanId:      baseScanId,
+		Changed:         map[string]interface{}{},
+		Removed:         []string{},
+		AddedElements:   map[string][]interface{}{},
+		RemovedElements: map[string][]string{},
+	}
+	delta.diff("", previous, current)
+	sort.Strings(delta.Removed)
+	return delta
+}
+
+func (d *ScanDelta) diff(pointer string, previous, current interface{}) {
+	switch currentValue := current.(type) {
+	case map[string]interface{}:
+		previousValue, ok := previous.(map[string]interface{})
+		if !ok {
+			break
+		}
+		for key, value := range currentValue {
+			keyPointer := pointer + "/" + escapePointerToken(key)
+			if previousKeyValue, exists := previousValue[key]; exists {
+				d.diff(keyPointer, previousKeyValue, value)
+			} else {
+				d.Changed[keyPointer] = value
+			}
+		}
+		for key := range previousValue {
+			if _, exists := currentValue[key]; !exists {
+				d.Removed = append(d.Removed, pointer+"/"+escapePointerToken(key))
+			}
+		}
+		return
+
+	case []interface{}:
+		previousValue, ok := previous.([]interface{})
+		if !ok {
+			break
+		}
+		previousHashes := map[string]bool{}
+		for _, element := range previousValue {
+			previousHashes[ElementHash(element)] = true
+		}
+		currentHashes := map[string]bool{}
+		for _, element := range currentValue {
+			hash := ElementHash(element)
+			currentHashes[hash] = true
+			if !previousHashes[hash] {
+				d.AddedElements[pointer] = append(d.AddedElements[pointer], element)
+			}
+		}
+		for _, element := range previousValue {
+			if hash := ElementHash(element); !currentHashes[hash] {
+				d.RemovedElements[pointer] = append(d.RemovedElements[pointer], hash)
+			}
+		}
+		return
+	}
+
+	if ElementHash(previous) != ElementHash(current) {
+		d.Changed[pointer] = current
+	}
+}
+
+// Returns whether the results did not change
+func (d *ScanDelta) IsEmpty() bool {
+	return len(d.Changed) == 0 && len(d.Removed) == 0 && len(d.AddedElements) == 0 && len(d.RemovedElements) == 0
+}
+
+// Returns the number of added, changed and removed values and elements
+func (d *ScanDelta) CountChanges() int {
+	count := len(d.Changed) + len(d.Removed)
+	for _, elements := range d.AddedElements {
+		count += len(elements)
+	}
+	for _, hashes := range d.RemovedElements {
+		count += len(hashes)
+	}
+	return count
+}
+
+// Removes source code snippets (samples and excerpts) from the raw results
+func StripSnippets(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		delete(v, "sample")
+		delete(v, "excerpt")
+		for _, child := range v {
+			StripSnippets(child)
+		}
+	case []interface{}:
+		for _, child := range v {
+			StripSnippets(child)
+		}
+	}
+}
+
+// Uploads the changes of the results against the base scan. Returns the
+// new scan; fails with a 404 or 409 APIError when the base scan is unknown
+// or not the latest scan of the repository
+func (c *Client) UploadScanDelta(delta *ScanDelta, organizationId string) (*ScanSummary, error) {
+	endpoint := fmt.Sprintf(scanDeltaEndpoint, url.PathEscape(delta.BaseScanId))
+	if organizationId != "" {
+		query := url.Values{}
+		query.Set("organizationId", organizationId)
+		endpoint = fmt.Sprintf("%s?%s", endpoint, query.Encode())
+	}
+
+	scan := &ScanSummary{}
+	if err := c.do("POST", endpoint, delta, scan); err != nil {
+		return nil, err
+	}
+	return scan, nil
+}
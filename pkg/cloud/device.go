@@ -0,0 +1,133 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cloud
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// OAuth 2.0 device authorization grant (RFC 8628) to login from
+// the cli using a browser
+
+const (
+	deviceCodeEndpoint  = "/cli/v1/auth/device/code"
+	deviceTokenEndpoint = "/cli/v1/auth/device/token"
+	deviceGrantType     = "urn:ietf:params:oauth:grant-type:device_code"
+
+	// lifetime of the authorization request when the server does not tell
+	defaultDeviceAuthorizationExpiry = 15 * 60
+)
+
+type DeviceAuthorization struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+type Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	Error       string `json:"error"`
+}
+
+// Returns expiry time of the token, zero if the token does not expire
+func (t *Token) GetExpiry() time.Time {
+	if t.ExpiresIn <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(time.Duration(t.ExpiresIn) * time.Second)
+}
+
+var ErrDeviceAuthorizationDenied = errors.New("authorization request was denied")
+var ErrDeviceAuthorizationExpired = errors.New("authorization request expired, please try again")
+
+func (c *Client) StartDeviceAuthorization() (*DeviceAuthorization, error) {
+	authorization := &DeviceAuthorization{}
+	if err := c.do("POST", deviceCodeEndpoint, map[string]string{"client_id": "privado-cli"}, authorization); err != nil {
+		return nil, err
+	}
+	if authorization.Interval <= 0 {
+		authorization.Interval = 5
+	}
+	if authorization.ExpiresIn <= 0 {
+		authorization.ExpiresIn = defaultDeviceAuthorizationExpiry
+	}
+	return authorization, nil
+}
+
+// Polls until the user completes (or denies) the authorization in the
+// browser, or the authorization request expires. Responses that are not
+// a pending state end the polling
+func (c *Client) WaitForDeviceToken(authorization *DeviceAuthorization) (*Token, error) {
+	interval := time.Duration(authorization.Interval) * time.Second
+	expiresIn := authorization.ExpiresIn
+	if expiresIn <= 0 {
+		expiresIn = defaultDeviceAuthorizationExpiry
+	}
+	deadline := time.Now().Add(time.Duration(expiresIn) * time.Second)
+
+	for time.Now().Before(deadline) {
+		time.Sleep(interval)
+
+		token := &Token{}
+		err := c.do("POST", deviceTokenEndpoint, map[string]string{
+			"client_id":   "privado-cli",
+			"device_code": authorization.DeviceCode,
+			"grant_type":  deviceGrantType,
+		}, token)
+
+		// pending states are returned as 400 with an error code
+		var apiError *APIError
+		if err != nil && !(errors.As(err, &apiError) && apiError.StatusCode == 400) {
+			return nil, err
+		}
+
+		switch token.Error {
+		case "":
+			if token.AccessToken != "" {
+				return token, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("authorization failed: no token received")
+		case "authorization_pending":
+		case "slow_down":
+			interval += 5 * time.Second
+		case "access_denied":
+			return nil, ErrDeviceAuthorizationDenied
+		case "expired_token":
+			return nil, ErrDeviceAuthorizationExpired
+		default:
+			return nil, fmt.Errorf("authorization failed: %s", token.Error)
+		}
+	}
+
+	return nil, ErrDeviceAuthorizationExpired
+}
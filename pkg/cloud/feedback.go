@@ -0,0 +1,43 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cloud
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/Privado-Inc/privado-cli/pkg/feedback"
+)
+
+const feedbackEndpoint = "/cli/v1/feedback"
+
+// Submits feedback on a finding to the authors of the rules
+func (c *Client) SubmitFeedback(f feedback.Feedback, organizationId string) error {
+	endpoint := feedbackEndpoint
+	if organizationId != "" {
+		query := url.Values{}
+		query.Set("organizationId", organizationId)
+		endpoint = fmt.Sprintf("%s?%s", endpoint, query.Encode())
+	}
+	return c.do("POST", endpoint, f, nil)
+}
@@ -0,0 +1,83 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cloud
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	scansEndpoint       = "/cli/v1/scans"
+	scanResultsEndpoint = "/cli/v1/scans/%s/results"
+)
+
+type ScanSummary struct {
+	Id         string    `json:"id"`
+	Repository string    `json:"repository"`
+	Branch     string    `json:"branch"`
+	CommitId   string    `json:"commitId"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// Lists scans synced for the repository (latest first)
+// repository is matched by remote url or repository name
+func (c *Client) ListScans(repository, organizationId string) ([]ScanSummary, error) {
+	query := url.Values{}
+	query.Set("repository", repository)
+	if organizationId != "" {
+		query.Set("organizationId", organizationId)
+	}
+
+	scans := []ScanSummary{}
+	if err := c.do("GET", fmt.Sprintf("%s?%s", scansEndpoint, query.Encode()), nil, &scans); err != nil {
+		return nil, err
+	}
+	return scans, nil
+}
+
+// Downloads the results of a synced scan, in the same format as
+// results generated by a local scan (privado.json)
+func (c *Client) DownloadScanResults(scanId string) ([]byte, error) {
+	return c.doRaw("GET", fmt.Sprintf(scanResultsEndpoint, url.PathEscape(scanId)), nil, nil)
+}
+
+// Returns the id of the scan of its url on Privado Cloud (printed by the
+// engine after an upload): the scanId query parameter, else the last
+// segment of the path. Empty if the url is not one of a scan
+func GetScanIdFromURL(rawURL string) string {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil || rawURL == "" {
+		return ""
+	}
+	if scanId := parsedURL.Query().Get("scanId"); scanId != "" {
+		return scanId
+	}
+	segments := strings.Split(strings.Trim(parsedURL.Path, "/"), "/")
+	if len(segments) < 2 {
+		return ""
+	}
+	return segments[len(segments)-1]
+}
@@ -0,0 +1,106 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package cloud
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Privado-Inc/privado-cli/pkg/auth"
+	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+)
+
+// The last scan of a repository synced to Privado Cloud, with a copy of
+// its results, to upload only the changes of the next scan (ScanDelta)
+
+var ErrNoSyncState = errors.New("no synced scan")
+
+type SyncState struct {
+	Repository string    `json:"repository"`
+	ScanId     string    `json:"scanId"`
+	SyncedAt   time.Time `json:"syncedAt"`
+
+	// results were synced without source code snippets
+	StripSnippets bool `json:"stripSnippets,omitempty"`
+}
+
+// Returns the directory of the sync state of the repository:
+// <sync-state>/<hash of the repository path>
+func getSyncStateDirectory(directory, repositoryPath string) string {
+	return filepath.Join(directory, auth.CalculateSHA256Hash(repositoryPath)[:16])
+}
+
+// Returns the sync state of the repository and the results synced
+func LoadSyncState(directory, repositoryPath string) (*SyncState, map[string]interface{}, error) {
+	stateDirectory := getSyncStateDirectory(directory, repositoryPath)
+	data, err := os.ReadFile(filepath.Join(stateDirectory, "state.json"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil, ErrNoSyncState
+		}
+		return nil, nil, err
+	}
+	state := &SyncState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, nil, err
+	}
+
+	data, err = os.ReadFile(filepath.Join(stateDirectory, "results.json"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil, ErrNoSyncState
+		}
+		return nil, nil, err
+	}
+	syncedResults := map[string]interface{}{}
+	if err := json.Unmarshal(data, &syncedResults); err != nil {
+		return nil, nil, err
+	}
+	return state, syncedResults, nil
+}
+
+// Saves the state of the repository with a copy of the synced results
+func SaveSyncState(directory, repositoryPath string, state SyncState, resultsPath string) error {
+	stateDirectory := getSyncStateDirectory(directory, repositoryPath)
+	if err := os.MkdirAll(stateDirectory, os.ModePerm); err != nil {
+		return err
+	}
+	if err := fileutils.CopyFile(resultsPath, filepath.Join(stateDirectory, "results.json")); err != nil {
+		return err
+	}
+
+	state.Repository = repositoryPath
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(stateDirectory, "state.json"), data, 0644)
+}
+
+// Removes the sync state of the repository, the next sync uploads all results
+func RemoveSyncState(directory, repositoryPath string) error {
+	return os.RemoveAll(getSyncStateDirectory(directory, repositoryPath))
+}
@@ -44,15 +44,39 @@ type Configuration struct {
 	UserConfigurationFilePath        string
 	UserKeyDirectory                 string
 	UserKeyPath                      string
+	CredentialsPath                  string
+	LicensePath                      string
+	AccessKeyCachePath               string
+	AccessKeyCacheTTL                time.Duration
+	TokenExpiryCachePath             string
+	TokenExpiryCacheTTL              time.Duration
+	CrashReportsDirectory            string
+	DiagnosticsDirectory             string
+	SchedulesPath                    string
+	HistoryDirectory                 string
+	HistoryRetention                 time.Duration
+	SyncStateDirectory               string
+	QueueDirectory                   string
+	ServerJobsDirectory              string
+	LogsDirectory                    string
+	LogRetention                     time.Duration
+	LogsMaxTotalSize                 int64
+	LogMaxFileSize                   int64
 	CIUserIdentifierEnvKey           string
 	M2CacheDirectoryName             string
 	GradleCacheDirectoryName         string
+	NpmCacheDirectoryName            string
+	JVMCacheDirectoryName            string
 	PrivacyResultsPathSuffix         string
 	PrivacyReportsDirectorySuffix    string
 	PrivadoRepository                string
 	PrivadoRepositoryName            string
 	PrivadoRepositoryReleaseFilename string
+	PrivadoRulesRepositoryName       string
 	PrivadoTelemetryEndpoint         string
+	PrivadoCloudAPIHost              string
+	TelemetrySpoolFilePath           string
+	TelemetryTimeout                 time.Duration
 	SlowdownTime                     time.Duration
 	Container                        *ContainerConfiguration
 }
@@ -69,7 +93,12 @@ type ContainerConfiguration struct {
 	ExternalRulesVolumeDir      string
 	M2PackageCacheVolumeDir     string
 	GradlePackageCacheVolumeDir string
+	NpmPackageCacheVolumeDir    string
+	JVMCacheVolumeDir           string
 	PrivadoCoreBinPath          string
+	// directories of the engine with debug artifacts: the code property
+	// graph (workspace), logs and intermediate results
+	DebugArtifactDirs []string
 }
 
 // init function for AppConfig
@@ -78,6 +107,7 @@ func init() {
 
 	imageTag := "latest"
 	telemetryHost := "cli.privado.ai"
+	cloudAPIHost := "api.privado.ai"
 
 	// if PRIVADO_DEV is set, use developer env settings
 	isDev, _ := strconv.ParseBool(os.Getenv("PRIVADO_DEV"))
@@ -90,6 +120,7 @@ func init() {
 
 	if isDev {
 		telemetryHost = "t.cli.privado.ai"
+		cloudAPIHost = "t.api.privado.ai"
 		// if PRIVADO_TAG is set, use the specified cli image tag
 		imageTag = os.Getenv("PRIVADO_TAG")
 		if imageTag == "" {
@@ -103,14 +134,38 @@ func init() {
 		UserConfigurationFilePath:        filepath.Join(home, ".privado", "config.json"),
 		UserKeyDirectory:                 filepath.Join(home, ".privado", "keys"),
 		UserKeyPath:                      filepath.Join(home, ".privado", "keys", "user.key"),
+		CredentialsPath:                  filepath.Join(home, ".privado", "keys", "credentials.json"),
+		LicensePath:                      filepath.Join(home, ".privado", "keys", "license.json"),
+		AccessKeyCachePath:               filepath.Join(home, ".privado", "keys", "access-key.json"),
+		AccessKeyCacheTTL:                3 * 24 * time.Hour,
+		TokenExpiryCachePath:             filepath.Join(home, ".privado", "keys", "token-expiry.json"),
+		TokenExpiryCacheTTL:              24 * time.Hour,
+		CrashReportsDirectory:            filepath.Join(home, ".privado", "crash-reports"),
+		DiagnosticsDirectory:             filepath.Join(home, ".privado", "diagnostics"),
+		SchedulesPath:                    filepath.Join(home, ".privado", "schedules.json"),
+		HistoryDirectory:                 filepath.Join(home, ".privado", "history"),
+		HistoryRetention:                 90 * 24 * time.Hour,
+		SyncStateDirectory:               filepath.Join(home, ".privado", "sync"),
+		QueueDirectory:                   filepath.Join(home, ".privado", "queue"),
+		ServerJobsDirectory:              filepath.Join(home, ".privado", "server", "jobs"),
+		LogsDirectory:                    filepath.Join(home, ".privado", "logs"),
+		LogRetention:                     30 * 24 * time.Hour,
+		LogsMaxTotalSize:                 500 << 20,
+		LogMaxFileSize:                   50 << 20,
 		CIUserIdentifierEnvKey:           "PRIVADO_CI_USER_ID",
 		M2CacheDirectoryName:             ".m2",
 		GradleCacheDirectoryName:         ".gradle",
+		NpmCacheDirectoryName:            ".npm",
+		JVMCacheDirectoryName:            ".jvm",
 		PrivacyResultsPathSuffix:         filepath.Join(".privado", "privado.json"),
 		PrivadoRepository:                "https://github.com/Privado-Inc/privado-cli",
 		PrivadoRepositoryName:            "Privado-Inc/privado-cli",
-		PrivadoRepositoryReleaseFilename: fmt.Sprintf("privado-%s-%s.tar.gz", runtime.GOOS, runtime.GOARCH),
+		PrivadoRepositoryReleaseFilename: fmt.Sprintf("privado-%s-%s.tar.gz", runtime.GOOS, GetHostArch()),
+		PrivadoRulesRepositoryName:       "Privado-Inc/privado",
 		PrivadoTelemetryEndpoint:         fmt.Sprintf("https://%s/api/event?version=2", telemetryHost),
+		PrivadoCloudAPIHost:              fmt.Sprintf("https://%s", cloudAPIHost),
+		TelemetrySpoolFilePath:           filepath.Join(home, ".privado", "telemetry.spool"),
+		TelemetryTimeout:                 3 * time.Second,
 		SlowdownTime:                     600 * time.Millisecond,
 		Container: &ContainerConfiguration{
 			ImageURL:                    fmt.Sprintf("public.ecr.aws/privado/privado:%s", imageTag),
@@ -124,10 +179,19 @@ func init() {
 			ExternalRulesVolumeDir:      "/app/external-rules",
 			M2PackageCacheVolumeDir:     "/root/.m2",
 			GradlePackageCacheVolumeDir: "/root/.gradle",
+			NpmPackageCacheVolumeDir:    "/root/.npm",
+			JVMCacheVolumeDir:           "/root/.privado-jvm",
 			PrivadoCoreBinPath:          "/usr/local/bin/core",
+			DebugArtifactDirs:           []string{"/app/workspace", "/app/logs", "/tmp/privado"},
 		},
 	}
 
+	// if PRIVADO_IMAGE is set, use the specified image instead
+	// (e.g. to benchmark or compare engine versions)
+	if image := os.Getenv("PRIVADO_IMAGE"); image != "" {
+		AppConfig.Container.ImageURL = image
+	}
+
 	privadoCacheDir, _ := initPrivadoCacheDirectory()
 	AppConfig.CacheDirectory = privadoCacheDir
 }
@@ -176,6 +240,24 @@ func getPrivadoCacheDirectory() string {
 	return ""
 }
 
+// Returns the architecture of the host, as GOARCH: arm64 on Apple Silicon
+// even when the cli runs translated, so updates install the native build
+func GetHostArch() string {
+	if runtime.GOARCH == "amd64" && IsTranslated() {
+		return "arm64"
+	}
+	return runtime.GOARCH
+}
+
+// Returns the directory of the class archives of the engine (privado preload)
+func GetJVMCacheDirectory() string {
+	cacheDir := AppConfig.CacheDirectory
+	if cacheDir == "" {
+		cacheDir = filepath.Join(AppConfig.ConfigurationDirectory, ".cache")
+	}
+	return filepath.Join(cacheDir, AppConfig.JVMCacheDirectoryName)
+}
+
 func GetPackageCacheDirectory(packageManager string) (string, error) {
 	var packageCacheDir string
 	switch packageManager {
@@ -183,6 +265,8 @@ func GetPackageCacheDirectory(packageManager string) (string, error) {
 		packageCacheDir = AppConfig.M2CacheDirectoryName
 	case "gradle":
 		packageCacheDir = AppConfig.GradleCacheDirectoryName
+	case "npm":
+		packageCacheDir = AppConfig.NpmCacheDirectoryName
 	default:
 		packageCacheDir = AppConfig.GradleCacheDirectoryName
 	}
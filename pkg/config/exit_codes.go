@@ -0,0 +1,115 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Outcome classes of a scan, mapped to exit codes so pipelines can
+// distinguish a failed scan from a scan that found problems
+const (
+	OutcomeClean                  = "clean"
+	OutcomeFindingsBelowThreshold = "findings-below-threshold"
+	OutcomePolicyViolation        = "policy-violation"
+	OutcomeEngineError            = "engine-error"
+	OutcomeInfraError             = "infra-error"
+)
+
+var Outcomes = []string{OutcomeClean, OutcomeFindingsBelowThreshold, OutcomePolicyViolation, OutcomeEngineError, OutcomeInfraError}
+
+var OutcomeDescriptions = map[string]string{
+	OutcomeClean:                  "The scan completed without findings",
+	OutcomeFindingsBelowThreshold: "The scan completed with findings below the failure threshold (e.g. --fail-on)",
+	OutcomePolicyViolation:        "The scan completed with findings at or above the failure threshold",
+	OutcomeEngineError:            "The scan engine failed or its results could not be read",
+	OutcomeInfraError:             "The scan could not be run (e.g. docker, network or disk errors)",
+}
+
+var defaultExitCodes = map[string]int{
+	OutcomeClean:                  0,
+	OutcomeFindingsBelowThreshold: 0,
+	OutcomePolicyViolation:        1,
+	OutcomeEngineError:            1,
+	OutcomeInfraError:             1,
+}
+
+func IsErrorOutcome(outcome string) bool {
+	return outcome != OutcomeClean && outcome != OutcomeFindingsBelowThreshold
+}
+
+// Parses a mapping in the form "policy-violation=1,engine-error=2"
+func ParseExitCodeMapping(mapping string) (map[string]int, error) {
+	exitCodes := map[string]int{}
+	if strings.TrimSpace(mapping) == "" {
+		return exitCodes, nil
+	}
+
+	for _, pair := range strings.Split(mapping, ",") {
+		keyValue := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(keyValue) != 2 {
+			return nil, fmt.Errorf("invalid exit code mapping %q (expected <outcome>=<code>)", pair)
+		}
+
+		outcome := strings.TrimSpace(keyValue[0])
+		if _, ok := defaultExitCodes[outcome]; !ok {
+			return nil, fmt.Errorf("invalid outcome %q (allowed: %s)", outcome, strings.Join(Outcomes, ", "))
+		}
+		code, err := strconv.Atoi(strings.TrimSpace(keyValue[1]))
+		if err != nil || code < 0 || code > 255 {
+			return nil, fmt.Errorf("invalid exit code %q for %s (expected 0-255)", keyValue[1], outcome)
+		}
+		exitCodes[outcome] = code
+	}
+
+	return exitCodes, nil
+}
+
+// Returns the mapping as "outcome=code" pairs, sorted by outcome
+func FormatExitCodeMapping(exitCodes map[string]int) string {
+	pairs := []string{}
+	for outcome, code := range exitCodes {
+		pairs = append(pairs, fmt.Sprintf("%s=%d", outcome, code))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// Returns the exit code for the outcome: overrides (flag) take precedence
+// over the user configuration, which takes precedence over the defaults
+func GetExitCode(outcome string, overrides map[string]int) int {
+	if code, ok := overrides[outcome]; ok {
+		return code
+	}
+	if code, ok := UserConfig.ConfigFile.ExitCodes[outcome]; ok {
+		return code
+	}
+	return defaultExitCodes[outcome]
+}
+
+func GetDefaultExitCode(outcome string) int {
+	return defaultExitCodes[outcome]
+}
@@ -0,0 +1,130 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Configuration of a repository, committed with it (.privado/config.json)
+// so every scan of the repository uses the same settings
+
+const ProjectConfigurationFileName = "config.json"
+
+type ProjectConfiguration struct {
+	Hooks *ProjectHooks `json:"hooks,omitempty"`
+
+	// patterns of vendored and generated code excluded from scans, replacing
+	// the defaults (fileutils.DefaultVendoredPatterns), e.g. ["vendor/", "*.pb.go"]
+	Vendored []string `json:"vendored,omitempty"`
+
+	// keep the results of each scan in .privado/scans/<id> (see scan --keep-results)
+	KeepResults bool `json:"keepResults,omitempty"`
+
+	// dependency download of each package manager (DependencyManagers, "*"
+	// for the others): "skip" or "download", e.g. {"npm": "skip", "maven":
+	// "download"}. Overridden by scan --skip-dependency-download
+	Dependencies map[string]string `json:"dependencies,omitempty"`
+
+	// file mapping paths to owners (CODEOWNERS syntax, relative to the
+	// repository), taking precedence over the CODEOWNERS file
+	Owners string `json:"owners,omitempty"`
+}
+
+const (
+	DependencyPolicySkip     = "skip"
+	DependencyPolicyDownload = "download"
+)
+
+// package managers the engine downloads dependencies with
+var DependencyManagers = []string{"maven", "gradle", "npm", "yarn", "pip", "poetry", "go", "sbt"}
+
+// shell commands run before and after each scan of the repository
+type ProjectHooks struct {
+	PreScan  string `json:"pre-scan,omitempty"`
+	PostScan string `json:"post-scan,omitempty"`
+
+	// maximum duration of each hook (e.g. "5m"), 10 minutes if not set
+	Timeout string `json:"timeout,omitempty"`
+}
+
+func GetProjectConfigurationPath(repositoryPath string) string {
+	return filepath.Join(repositoryPath, filepath.Dir(AppConfig.PrivacyResultsPathSuffix), ProjectConfigurationFileName)
+}
+
+// Loads the configuration of the repository, empty if it has none
+func LoadProjectConfiguration(repositoryPath string) (*ProjectConfiguration, error) {
+	projectConfig := &ProjectConfiguration{}
+	data, err := os.ReadFile(GetProjectConfigurationPath(repositoryPath))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return projectConfig, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, projectConfig); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", ProjectConfigurationFileName, err)
+	}
+	return projectConfig, nil
+}
+
+// Returns the package managers whose dependencies are not downloaded,
+// following the dependency policies; all is true if none are downloaded
+func (p *ProjectConfiguration) GetSkippedDependencyManagers() (all bool, managers []string, err error) {
+	defaultPolicy := DependencyPolicyDownload
+	for manager, policy := range p.Dependencies {
+		if policy != DependencyPolicySkip && policy != DependencyPolicyDownload {
+			return false, nil, fmt.Errorf("invalid dependency policy '%s' for %s (allowed: %s, %s)", policy, manager, DependencyPolicySkip, DependencyPolicyDownload)
+		}
+		if manager == "*" {
+			defaultPolicy = policy
+		} else if !isDependencyManager(manager) {
+			return false, nil, fmt.Errorf("unknown package manager '%s' in dependencies (allowed: %s, or * for the others)", manager, strings.Join(DependencyManagers, ", "))
+		}
+	}
+
+	for _, manager := range DependencyManagers {
+		policy, ok := p.Dependencies[manager]
+		if !ok {
+			policy = defaultPolicy
+		}
+		if policy == DependencyPolicySkip {
+			managers = append(managers, manager)
+		}
+	}
+	return len(managers) == len(DependencyManagers), managers, nil
+}
+
+func isDependencyManager(manager string) bool {
+	for _, m := range DependencyManagers {
+		if m == manager {
+			return true
+		}
+	}
+	return false
+}
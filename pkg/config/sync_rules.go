@@ -0,0 +1,79 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package config
+
+import (
+	"path"
+	"strings"
+)
+
+type SyncDecision struct {
+	Sync          bool
+	StripSnippets bool
+	Reason        string
+}
+
+func matchesAnyPattern(patterns []string, values ...string) bool {
+	for _, pattern := range patterns {
+		for _, value := range values {
+			if value == "" {
+				continue
+			}
+			if matched, _ := path.Match(pattern, value); matched || pattern == value {
+				return true
+			}
+			// allow patterns like "*/my-repo" to match remote urls
+			if strings.HasSuffix(value, ".git") {
+				if matched, _ := path.Match(pattern, strings.TrimSuffix(value, ".git")); matched {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// Decides whether scan results for the repository are synced to Privado Cloud
+// An explicit per-scan override (--sync, --no-sync) always takes precedence,
+// else the global preference is narrowed down by configured sync rules
+func ResolveSyncToCloud(explicitSync, explicitNoSync bool, repositoryName, remoteURL, branch string) SyncDecision {
+	rules := UserConfig.ConfigFile.SyncRules
+	if rules == nil {
+		rules = &SyncRules{}
+	}
+
+	switch {
+	case explicitSync:
+		return SyncDecision{Sync: true, StripSnippets: rules.StripSnippets, Reason: "--sync"}
+	case explicitNoSync:
+		return SyncDecision{Sync: false, Reason: "--no-sync"}
+	case !UserConfig.ConfigFile.SyncToPrivadoCloud:
+		return SyncDecision{Sync: false, Reason: "sync disabled in configuration"}
+	case len(rules.Repositories) > 0 && !matchesAnyPattern(rules.Repositories, repositoryName, remoteURL):
+		return SyncDecision{Sync: false, Reason: "repository does not match sync rules"}
+	case len(rules.Branches) > 0 && !matchesAnyPattern(rules.Branches, branch):
+		return SyncDecision{Sync: false, Reason: "branch does not match sync rules"}
+	}
+
+	return SyncDecision{Sync: true, StripSnippets: rules.StripSnippets, Reason: "sync enabled in configuration"}
+}
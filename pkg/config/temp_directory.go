@@ -0,0 +1,65 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+)
+
+// Sets the directory temporary workspaces and files are created in, from
+// the override (--temp-dir) or the configuration. The environment is
+// updated, so that it also applies to scans run in separate processes.
+// Without either, the system default ($TMPDIR) is used.
+// Returns the temporary directory in use
+func ApplyTempDirectory(override string) (string, error) {
+	directory := override
+	if directory == "" {
+		directory = UserConfig.ConfigFile.TempDirectory
+	}
+	if directory == "" {
+		return os.TempDir(), nil
+	}
+
+	directory = fileutils.GetAbsolutePath(directory)
+	if err := os.MkdirAll(directory, os.ModePerm); err != nil {
+		return os.TempDir(), err
+	}
+	if writable, _ := fileutils.HasWritePermissionToFile(directory); !writable {
+		return os.TempDir(), fmt.Errorf("%s is not writable", directory)
+	}
+
+	envKeys := []string{"TMPDIR"}
+	if runtime.GOOS == "windows" {
+		envKeys = []string{"TMP", "TEMP"}
+	}
+	for _, key := range envKeys {
+		if err := os.Setenv(key, directory); err != nil {
+			return os.TempDir(), err
+		}
+	}
+	return directory, nil
+}
@@ -49,8 +49,156 @@ type UserConfiguration struct {
 type UserConfigurationFromFile struct {
 	MetricsEnabled     bool `json:"metrics"`
 	SyncToPrivadoCloud bool `json:"syncToPrivadoCloud"`
+
+	// overrides AppConfig.PrivadoTelemetryEndpoint, for instance to
+	// point telemetry to an internal collector
+	TelemetryEndpoint string `json:"telemetryEndpoint,omitempty"`
+
+	// "full" (default) or "anonymous"
+	TelemetryMode string `json:"telemetryMode,omitempty"`
+
+	// nil: ask on crash, true/false: (do not) upload crash reports
+	UploadCrashReports *bool `json:"uploadCrashReports,omitempty"`
+
+	// fine-grained rules applied when SyncToPrivadoCloud is enabled
+	SyncRules *SyncRules `json:"syncRules,omitempty"`
+
+	// exit code for each outcome class, overriding the defaults
+	ExitCodes map[string]int `json:"exitCodes,omitempty"`
+
+	// directory for temporary workspaces and files, instead of the system default
+	TempDirectory string `json:"tempDirectory,omitempty"`
+
+	// destinations findings are exported to (privado export, scan --export)
+	Exports *Exports `json:"exports,omitempty"`
+
+	// syslog target scan events are sent to, e.g. udp://host:514 (scan --syslog)
+	Syslog string `json:"syslog,omitempty"`
+
+	// retention of the scan history, logs and archived results (privado history prune)
+	Retention *Retention `json:"retention,omitempty"`
+
+	// chat tools notified after scans (or failed gates, see privado config notifications)
+	Notifications *Notifications `json:"notifications,omitempty"`
+
+	// server reports are emailed with (privado report --email)
+	SMTP *SMTP `json:"smtp,omitempty"`
+
+	// answer of prompts without asking: yes, non-interactive (default
+	// answers), empty to ask (see --yes, --non-interactive)
+	Prompts string `json:"prompts,omitempty"`
+
+	// how long the last docker access key is used when it cannot be
+	// fetched (e.g. the registry is unreachable), e.g. 12h or 7d, 0 to
+	// never use it (default: AppConfig.AccessKeyCacheTTL)
+	AccessKeyCacheTTL string `json:"accessKeyCacheTTL,omitempty"`
+}
+
+type Notifications struct {
+	Teams *TeamsNotifications `json:"teams,omitempty"`
 }
 
+type TeamsNotifications struct {
+	// incoming webhook (or workflow) url; can be set with
+	// PRIVADO_TEAMS_WEBHOOK_URL instead
+	WebhookURL string `json:"webhookUrl,omitempty"`
+	// "always" (after each scan) or "failure" (default: failed gates only)
+	On string `json:"on,omitempty"`
+}
+
+const (
+	NotifyOnAlways  = "always"
+	NotifyOnFailure = "failure"
+)
+
+type SMTP struct {
+	Host string `json:"host"`
+	// default: 587, or 465 with TLS
+	Port int `json:"port,omitempty"`
+	// the password can be set with PRIVADO_SMTP_PASSWORD instead
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	// sender address, e.g. privado@company.com
+	From string `json:"from"`
+	// implicit TLS (e.g. port 465), instead of STARTTLS when the server supports it
+	TLS bool `json:"tls,omitempty"`
+}
+
+type Retention struct {
+	// scans kept for each repository and schedule (logs: in total), 0 for all
+	KeepLast int `json:"keepLast,omitempty"`
+
+	// age after which scans are removed, e.g. 90d (default: 90d, logs: 30d)
+	MaxAge string `json:"maxAge,omitempty"`
+}
+
+type Exports struct {
+	Elasticsearch *ElasticsearchExport `json:"elasticsearch,omitempty"`
+	Splunk        *SplunkExport        `json:"splunk,omitempty"`
+	ServiceNow    *ServiceNowExport    `json:"servicenow,omitempty"`
+}
+
+type ElasticsearchExport struct {
+	URL   string `json:"url"`
+	Index string `json:"index"`
+	// api key (preferred) or basic authentication; the secrets can be
+	// set with PRIVADO_ELASTICSEARCH_API_KEY, PRIVADO_ELASTICSEARCH_PASSWORD instead
+	APIKey    string `json:"apiKey,omitempty"`
+	Username  string `json:"username,omitempty"`
+	Password  string `json:"password,omitempty"`
+	BatchSize int    `json:"batchSize,omitempty"`
+}
+
+type SplunkExport struct {
+	// HTTP Event Collector url, e.g. https://splunk:8088
+	URL string `json:"url"`
+	// can be set with PRIVADO_SPLUNK_HEC_TOKEN instead
+	Token      string `json:"token,omitempty"`
+	Index      string `json:"index,omitempty"`
+	Source     string `json:"source,omitempty"`
+	SourceType string `json:"sourceType,omitempty"`
+	BatchSize  int    `json:"batchSize,omitempty"`
+}
+
+type ServiceNowExport struct {
+	// instance url, e.g. https://company.service-now.com
+	URL string `json:"url"`
+	// table records are filed in (default: incident)
+	Table string `json:"table,omitempty"`
+	// basic authentication or an OAuth token; the secrets can be set with
+	// PRIVADO_SERVICENOW_PASSWORD, PRIVADO_SERVICENOW_TOKEN instead
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Token    string `json:"token,omitempty"`
+	// field identifying the record of a finding (default: correlation_id)
+	CorrelationField string `json:"correlationField,omitempty"`
+	// templates of the fields of the records, e.g. {"short_description": "{{.PolicyName}}"}
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+type SyncRules struct {
+	// glob patterns matched against the repository name or remote url
+	// results are synced only for matching repositories (all if empty)
+	Repositories []string `json:"repositories,omitempty"`
+
+	// glob patterns matched against the current branch
+	// results are synced only for matching branches (all if empty)
+	Branches []string `json:"branches,omitempty"`
+
+	// remove source code snippets from results before they are synced
+	StripSnippets bool `json:"stripSnippets,omitempty"`
+}
+
+const (
+	PromptsYes            = "yes"
+	PromptsNonInteractive = "non-interactive"
+)
+
+const (
+	TelemetryModeFull      = "full"
+	TelemetryModeAnonymous = "anonymous"
+)
+
 // Bootstraps user configuration file
 // checks for and creates default configuration file if required
 func BootstrapUserConfiguration(resetConfig bool) error {
@@ -65,6 +213,18 @@ func BootstrapUserConfiguration(resetConfig bool) error {
 	if resetConfig {
 		UserConfig.ConfigFile.MetricsEnabled = true
 		UserConfig.ConfigFile.SyncToPrivadoCloud = false
+		UserConfig.ConfigFile.TelemetryEndpoint = ""
+		UserConfig.ConfigFile.TelemetryMode = ""
+		UserConfig.ConfigFile.UploadCrashReports = nil
+		UserConfig.ConfigFile.SyncRules = nil
+		UserConfig.ConfigFile.ExitCodes = nil
+		UserConfig.ConfigFile.TempDirectory = ""
+		UserConfig.ConfigFile.Exports = nil
+		UserConfig.ConfigFile.Syslog = ""
+		UserConfig.ConfigFile.Retention = nil
+		UserConfig.ConfigFile.Notifications = nil
+		UserConfig.ConfigFile.SMTP = nil
+		UserConfig.ConfigFile.Prompts = ""
 	}
 
 	// if not, create directory and file
@@ -95,6 +255,57 @@ func LoadUserConfiguration() {
 	UserConfig.UserHash = auth.GetUserHash(AppConfig.UserKeyPath)
 }
 
+// Returns the telemetry endpoint from user configuration if
+// overridden, else the default Privado telemetry endpoint
+func GetTelemetryEndpoint() string {
+	if UserConfig.ConfigFile.TelemetryEndpoint != "" {
+		return UserConfig.ConfigFile.TelemetryEndpoint
+	}
+	return AppConfig.PrivadoTelemetryEndpoint
+}
+
+// Returns a copy of the user configuration without values that
+// can identify the user or their infrastructure
+func GetSanitizedUserConfiguration() UserConfigurationFromFile {
+	sanitized := *UserConfig.ConfigFile
+	if sanitized.TelemetryEndpoint != "" {
+		sanitized.TelemetryEndpoint = "<redacted>"
+	}
+	if sanitized.Syslog != "" {
+		sanitized.Syslog = "<redacted>"
+	}
+	if sanitized.Notifications != nil && sanitized.Notifications.Teams != nil {
+		sanitized.Notifications = &Notifications{Teams: &TeamsNotifications{WebhookURL: "<redacted>", On: sanitized.Notifications.Teams.On}}
+	}
+	if sanitized.SMTP != nil {
+		sanitized.SMTP = &SMTP{Host: "<redacted>", Port: sanitized.SMTP.Port, TLS: sanitized.SMTP.TLS}
+	}
+	if sanitized.Exports != nil {
+		exports := Exports{}
+		if sanitized.Exports.Elasticsearch != nil {
+			exports.Elasticsearch = &ElasticsearchExport{URL: "<redacted>", Index: sanitized.Exports.Elasticsearch.Index}
+		}
+		if sanitized.Exports.Splunk != nil {
+			exports.Splunk = &SplunkExport{URL: "<redacted>", Index: sanitized.Exports.Splunk.Index}
+		}
+		if sanitized.Exports.ServiceNow != nil {
+			exports.ServiceNow = &ServiceNowExport{URL: "<redacted>", Table: sanitized.Exports.ServiceNow.Table}
+		}
+		sanitized.Exports = &exports
+	}
+	return sanitized
+}
+
+func IsAnonymousTelemetryMode() bool {
+	return UserConfig.ConfigFile.TelemetryMode == TelemetryModeAnonymous
+}
+
+// Engine metrics are detailed and not restricted to aggregate
+// metrics, hence they are disabled in anonymous telemetry mode
+func IsEngineMetricsEnabled() bool {
+	return UserConfig.ConfigFile.MetricsEnabled && !IsAnonymousTelemetryMode()
+}
+
 func LoadUserDockerHash(key string) {
 	UserConfig.DockerAccessHash = auth.CalculateSHA256Hash(key)
 }
@@ -0,0 +1,131 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package dbschema
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Database schemas (SQL DDL, prisma schemas and liquibase changelogs) of
+// the storages of the repository, to map the storage sinks found by the
+// scan to the tables and columns that hold personal data
+
+const (
+	FormatSQL       = "sql"
+	FormatPrisma    = "prisma"
+	FormatLiquibase = "liquibase"
+)
+
+var Formats = []string{FormatSQL, FormatPrisma, FormatLiquibase}
+
+type Column struct {
+	Name string `json:"name"`
+	Type string `json:"type,omitempty"`
+}
+
+type Table struct {
+	Name    string   `json:"name"`
+	Columns []Column `json:"columns"`
+}
+
+type Schema struct {
+	Path   string  `json:"path"`
+	Format string  `json:"format"`
+	Tables []Table `json:"tables"`
+}
+
+// Returns the format of the schema by its extension, empty if unknown
+func DetectFormat(schemaPath string) string {
+	switch strings.ToLower(filepath.Ext(schemaPath)) {
+	case ".sql", ".ddl":
+		return FormatSQL
+	case ".prisma":
+		return FormatPrisma
+	case ".xml", ".yaml", ".yml", ".json":
+		return FormatLiquibase
+	}
+	return ""
+}
+
+// Loads the schema in the format (detected from the extension if empty)
+func Load(schemaPath, format string) (*Schema, error) {
+	if format == "" {
+		format = DetectFormat(schemaPath)
+	}
+	data, err := os.ReadFile(schemaPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []Table
+	switch format {
+	case FormatSQL:
+		tables = parseSQL(string(data))
+	case FormatPrisma:
+		tables = parsePrisma(string(data))
+	case FormatLiquibase:
+		tables, err = parseLiquibase(schemaPath, data)
+	default:
+		return nil, fmt.Errorf("unknown format of %s (formats: %s)", schemaPath, strings.Join(Formats, ", "))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %v", schemaPath, err)
+	}
+	if len(tables) == 0 {
+		return nil, fmt.Errorf("no tables found in %s", schemaPath)
+	}
+	return &Schema{Path: schemaPath, Format: format, Tables: tables}, nil
+}
+
+// collects tables in order of definition, merging columns added later
+type tableSet struct {
+	index  map[string]int
+	tables []Table
+}
+
+func newTableSet() *tableSet {
+	return &tableSet{index: map[string]int{}, tables: []Table{}}
+}
+
+func (s *tableSet) addColumns(tableName string, columns ...Column) {
+	key := strings.ToLower(tableName)
+	i, ok := s.index[key]
+	if !ok {
+		i = len(s.tables)
+		s.index[key] = i
+		s.tables = append(s.tables, Table{Name: tableName, Columns: []Column{}})
+	}
+	s.tables[i].Columns = append(s.tables[i].Columns, columns...)
+}
+
+// removes quoting of identifiers ("name", `name`, [name]) and schema
+// qualifiers (public.users)
+func unquoteIdentifier(identifier string) string {
+	identifier = strings.TrimSpace(identifier)
+	parts := strings.Split(identifier, ".")
+	identifier = parts[len(parts)-1]
+	return strings.Trim(identifier, "\"`[]")
+}
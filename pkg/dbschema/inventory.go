@@ -0,0 +1,251 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package dbschema
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Privado-Inc/privado-cli/pkg/baseline"
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+)
+
+// Storage inventory: for each storage, the tables and columns holding
+// personal data, classified by the data elements of the scan (else by
+// common column names), and whether the scan found data flows of them
+// to the storage
+
+type PersonalDataColumn struct {
+	Column        string `json:"column"`
+	Type          string `json:"type,omitempty"`
+	DataElement   string `json:"dataElement"`
+	DataElementId string `json:"dataElementId,omitempty"`
+	// whether the scan found data flows of the data element to the storage
+	FlowsFound bool `json:"flowsFound"`
+}
+
+type TableInventory struct {
+	Schema  string               `json:"schema"`
+	Table   string               `json:"table"`
+	Columns []PersonalDataColumn `json:"columns"`
+}
+
+type StorageInventory struct {
+	// storage sink of the scan, empty if the schema could not be
+	// attributed to one
+	StorageId string           `json:"storageId,omitempty"`
+	Storage   string           `json:"storage"`
+	Tables    []TableInventory `json:"tables"`
+}
+
+// A schema and the storage it belongs to: the id or name of a storage sink,
+// empty to attribute it to the only storage of the scan
+type AttributedSchema struct {
+	Schema  *Schema
+	Storage string
+}
+
+// common names of columns with personal data, matched against column names
+// without separators: exactly, or contained for names of 5+ characters
+var personalDataColumns = []struct {
+	dataElement string
+	names       []string
+}{
+	{"Email Address", []string{"email", "emailaddress", "mail"}},
+	{"Phone Number", []string{"phone", "phonenumber", "mobile", "telephone", "msisdn"}},
+	{"First Name", []string{"firstname", "givenname"}},
+	{"Last Name", []string{"lastname", "surname", "familyname"}},
+	{"Full Name", []string{"fullname"}},
+	{"Date of Birth", []string{"dob", "dateofbirth", "birthdate", "birthday"}},
+	{"IP Address", []string{"ip", "ipaddress", "ipaddr"}},
+	{"Address", []string{"address", "street", "city", "zip", "zipcode", "postalcode", "postcode"}},
+	{"Social Security Number", []string{"ssn", "socialsecurity", "nationalid", "taxid"}},
+	{"Passport Number", []string{"passport"}},
+	{"Payment Card", []string{"cardnumber", "creditcard", "pan", "cvv"}},
+	{"Bank Account", []string{"iban", "accountnumber", "bankaccount"}},
+	{"Gender", []string{"gender", "sex"}},
+	{"Location", []string{"latitude", "longitude", "geolocation"}},
+	{"Password", []string{"password", "passwordhash"}},
+}
+
+var nonAlphanumericPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+func normalize(name string) string {
+	return nonAlphanumericPattern.ReplaceAllString(strings.ToLower(name), "")
+}
+
+// Builds the inventory of the schemas with the results of the scan
+func BuildInventory(schemas []AttributedSchema, scanResults *results.Results) []StorageInventory {
+	storages := []results.Sink{}
+	for _, sink := range scanResults.Sinks {
+		if baseline.GetSinkCategory(sink) == "external-storage" {
+			storages = append(storages, sink)
+		}
+	}
+
+	// data elements of the scan, by their normalized name and the last
+	// part of their id (Data.Sensitive.ContactData.EmailAddress)
+	dataElements := map[string]results.Source{}
+	for _, source := range scanResults.Sources {
+		dataElements[normalize(source.Name)] = source
+		idParts := strings.Split(source.Id, ".")
+		dataElements[normalize(idParts[len(idParts)-1])] = source
+	}
+
+	inventories := []StorageInventory{}
+	byStorage := map[string]int{}
+	for _, attributed := range schemas {
+		storage := findStorage(storages, attributed.Storage)
+		key := storage.Id
+		if key == "" {
+			storage.Name = attributed.Storage
+			key = "name:" + attributed.Storage
+		}
+		i, ok := byStorage[key]
+		if !ok {
+			i = len(inventories)
+			byStorage[key] = i
+			inventories = append(inventories, StorageInventory{StorageId: storage.Id, Storage: storage.Name, Tables: []TableInventory{}})
+		}
+
+		for _, table := range attributed.Schema.Tables {
+			tableInventory := TableInventory{Schema: attributed.Schema.Path, Table: table.Name, Columns: []PersonalDataColumn{}}
+			for _, column := range table.Columns {
+				personalData, ok := classifyColumn(column, dataElements)
+				if !ok {
+					continue
+				}
+				if personalData.DataElementId != "" {
+					personalData.FlowsFound = hasFlowTo(scanResults, personalData.DataElementId, storage.Id, storages)
+				}
+				tableInventory.Columns = append(tableInventory.Columns, personalData)
+			}
+			if len(tableInventory.Columns) > 0 {
+				inventories[i].Tables = append(inventories[i].Tables, tableInventory)
+			}
+		}
+	}
+	return inventories
+}
+
+// Returns the storage sink with the id or name (the only one if empty)
+func findStorage(storages []results.Sink, storage string) results.Sink {
+	if storage == "" {
+		if len(storages) == 1 {
+			return storages[0]
+		}
+		return results.Sink{}
+	}
+	for _, sink := range storages {
+		if strings.EqualFold(sink.Id, storage) || strings.EqualFold(sink.Name, storage) {
+			return sink
+		}
+	}
+	for _, sink := range storages {
+		if strings.Contains(strings.ToLower(sink.Id), strings.ToLower(storage)) || strings.Contains(strings.ToLower(sink.Name), strings.ToLower(storage)) {
+			return sink
+		}
+	}
+	return results.Sink{}
+}
+
+func classifyColumn(column Column, dataElements map[string]results.Source) (PersonalDataColumn, bool) {
+	name := normalize(column.Name)
+	personalData := PersonalDataColumn{Column: column.Name, Type: column.Type}
+	if source, ok := dataElements[name]; ok {
+		personalData.DataElement = source.Name
+		personalData.DataElementId = source.Id
+		return personalData, true
+	}
+
+	for _, exact := range []bool{true, false} {
+		for _, known := range personalDataColumns {
+			for _, knownName := range known.names {
+				if name == knownName || (!exact && len(knownName) >= 5 && strings.Contains(name, knownName)) {
+					personalData.DataElement = known.dataElement
+					// the data element of the scan with the same name, if any
+					if source, ok := dataElements[normalize(known.dataElement)]; ok {
+						personalData.DataElementId = source.Id
+					}
+					return personalData, true
+				}
+			}
+		}
+	}
+	return personalData, false
+}
+
+// whether the scan found data flows of the data element to the storage
+// (to any storage if storageId is empty)
+func hasFlowTo(scanResults *results.Results, dataElementId, storageId string, storages []results.Sink) bool {
+	storageIds := map[string]bool{}
+	if storageId != "" {
+		storageIds[storageId] = true
+	} else {
+		for _, storage := range storages {
+			storageIds[storage.Id] = true
+		}
+	}
+
+	for _, flows := range scanResults.DataFlow {
+		for _, flow := range flows {
+			if flow.SourceId != dataElementId {
+				continue
+			}
+			for _, sink := range flow.Sinks {
+				if storageIds[sink.Id] {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// Returns the number of tables and columns with personal data
+func CountPersonalData(inventories []StorageInventory) (tables, columns int) {
+	for _, inventory := range inventories {
+		tables += len(inventory.Tables)
+		for _, table := range inventory.Tables {
+			columns += len(table.Columns)
+		}
+	}
+	return tables, columns
+}
+
+// Returns the data elements of the inventory, sorted
+func (i StorageInventory) DataElements() []string {
+	seen := map[string]bool{}
+	for _, table := range i.Tables {
+		for _, column := range table.Columns {
+			seen[column.DataElement] = true
+		}
+	}
+	dataElements := []string{}
+	for dataElement := range seen {
+		dataElements = append(dataElements, dataElement)
+	}
+	sort.Strings(dataElements)
+	return dataElements
+}
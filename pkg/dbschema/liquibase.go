@@ -0,0 +1,175 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package dbschema
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Liquibase changelogs (xml, yaml or json): createTable and addColumn
+// changes, following included changelogs
+
+func parseLiquibase(changelogPath string, data []byte) ([]Table, error) {
+	tables := newTableSet()
+	if err := parseLiquibaseChangelog(changelogPath, data, tables, map[string]bool{}); err != nil {
+		return nil, err
+	}
+	return tables.tables, nil
+}
+
+func parseLiquibaseChangelog(changelogPath string, data []byte, tables *tableSet, visited map[string]bool) error {
+	absolutePath, _ := filepath.Abs(changelogPath)
+	if visited[absolutePath] {
+		return nil
+	}
+	visited[absolutePath] = true
+
+	var includes []string
+	var err error
+	if strings.EqualFold(filepath.Ext(changelogPath), ".xml") {
+		includes, err = parseLiquibaseXML(data, tables)
+	} else {
+		includes, err = parseLiquibaseYAML(data, tables)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, include := range includes {
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(changelogPath), include)
+		}
+		includedData, err := os.ReadFile(include)
+		if err != nil {
+			// included from the classpath or another root, not resolvable here
+			continue
+		}
+		if err := parseLiquibaseChangelog(include, includedData, tables, visited); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseLiquibaseXML(data []byte, tables *tableSet) ([]string, error) {
+	includes := []string{}
+	currentTable := ""
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return includes, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			switch element.Name.Local {
+			case "createTable", "addColumn":
+				currentTable = getXMLAttribute(element, "tableName")
+				if element.Name.Local == "createTable" && currentTable != "" {
+					tables.addColumns(currentTable)
+				}
+			case "column":
+				if currentTable != "" {
+					tables.addColumns(currentTable, Column{Name: getXMLAttribute(element, "name"), Type: strings.ToLower(getXMLAttribute(element, "type"))})
+				}
+			case "include":
+				if file := getXMLAttribute(element, "file"); file != "" {
+					includes = append(includes, file)
+				}
+			}
+		case xml.EndElement:
+			if element.Name.Local == "createTable" || element.Name.Local == "addColumn" {
+				currentTable = ""
+			}
+		}
+	}
+}
+
+func getXMLAttribute(element xml.StartElement, name string) string {
+	for _, attribute := range element.Attr {
+		if attribute.Name.Local == name {
+			return attribute.Value
+		}
+	}
+	return ""
+}
+
+func parseLiquibaseYAML(data []byte, tables *tableSet) ([]string, error) {
+	changelog := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &changelog); err != nil {
+		return nil, err
+	}
+	entries, ok := changelog["databaseChangeLog"].([]interface{})
+	if !ok {
+		return nil, errors.New("not a liquibase changelog (no databaseChangeLog)")
+	}
+
+	includes := []string{}
+	for _, entry := range entries {
+		entry, _ := entry.(map[string]interface{})
+		if include, ok := entry["include"].(map[string]interface{}); ok {
+			if file, ok := include["file"].(string); ok {
+				includes = append(includes, file)
+			}
+		}
+		changeSet, _ := entry["changeSet"].(map[string]interface{})
+		changes, _ := changeSet["changes"].([]interface{})
+		for _, change := range changes {
+			change, _ := change.(map[string]interface{})
+			for _, changeType := range []string{"createTable", "addColumn"} {
+				definition, ok := change[changeType].(map[string]interface{})
+				if !ok {
+					continue
+				}
+				tableName, _ := definition["tableName"].(string)
+				if tableName == "" {
+					continue
+				}
+				tables.addColumns(tableName)
+				columns, _ := definition["columns"].([]interface{})
+				for _, column := range columns {
+					column, _ := column.(map[string]interface{})
+					column, _ = column["column"].(map[string]interface{})
+					name, _ := column["name"].(string)
+					columnType, _ := column["type"].(string)
+					if name != "" {
+						tables.addColumns(tableName, Column{Name: name, Type: strings.ToLower(columnType)})
+					}
+				}
+			}
+		}
+	}
+	return includes, nil
+}
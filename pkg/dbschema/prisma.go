@@ -0,0 +1,80 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package dbschema
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	prismaModelPattern = regexp.MustCompile(`(?m)^\s*model\s+(\w+)\s*\{`)
+	prismaMapPattern   = regexp.MustCompile(`@@?map\(\s*(?:name:\s*)?"([^"]+)"`)
+)
+
+// Models of a prisma schema: relation fields (of other models) are not
+// columns, @map and @@map rename columns and tables
+func parsePrisma(schema string) []Table {
+	models := map[string]bool{}
+	for _, match := range prismaModelPattern.FindAllStringSubmatch(schema, -1) {
+		models[match[1]] = true
+	}
+
+	tables := newTableSet()
+	for _, match := range prismaModelPattern.FindAllStringSubmatchIndex(schema, -1) {
+		tableName := schema[match[2]:match[3]]
+		body := schema[match[1]:]
+		if end := strings.Index(body, "}"); end >= 0 {
+			body = body[:end]
+		}
+
+		columns := []Column{}
+		for _, line := range strings.Split(body, "\n") {
+			line = strings.TrimSpace(line)
+			if index := strings.Index(line, "//"); index >= 0 {
+				line = strings.TrimSpace(line[:index])
+			}
+			if strings.HasPrefix(line, "@@") {
+				if mapped := prismaMapPattern.FindStringSubmatch(line); mapped != nil {
+					tableName = mapped[1]
+				}
+				continue
+			}
+			fields := strings.Fields(line)
+			if len(fields) < 2 {
+				continue
+			}
+			fieldType := strings.TrimRight(fields[1], "?[]")
+			if models[fieldType] {
+				continue
+			}
+			column := Column{Name: fields[0], Type: strings.ToLower(fieldType)}
+			if mapped := prismaMapPattern.FindStringSubmatch(line); mapped != nil {
+				column.Name = mapped[1]
+			}
+			columns = append(columns, column)
+		}
+		tables.addColumns(tableName, columns...)
+	}
+	return tables.tables
+}
@@ -0,0 +1,111 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package dbschema
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	sqlCommentPattern     = regexp.MustCompile(`(?s)--[^\n]*|/\*.*?\*/`)
+	sqlCreateTablePattern = regexp.MustCompile(`(?i)create\s+(?:(?:global\s+|local\s+)?(?:temporary|temp)\s+)?table\s+(?:if\s+not\s+exists\s+)?([^\s(]+)\s*\(`)
+	sqlAddColumnPattern   = regexp.MustCompile(`(?i)alter\s+table\s+(?:if\s+exists\s+)?(?:only\s+)?([^\s]+)\s+add\s+(?:column\s+)?(?:if\s+not\s+exists\s+)?([^\s,;]+)\s+([^\s,;]+)`)
+)
+
+// keywords starting table constraints instead of column definitions
+var sqlConstraintKeywords = map[string]bool{
+	"constraint": true, "primary": true, "foreign": true, "unique": true, "check": true,
+	"index": true, "key": true, "exclude": true, "fulltext": true, "spatial": true, "period": true,
+}
+
+// CREATE TABLE and ALTER TABLE .. ADD COLUMN statements of SQL DDL
+func parseSQL(ddl string) []Table {
+	ddl = sqlCommentPattern.ReplaceAllString(ddl, "")
+	tables := newTableSet()
+
+	for _, match := range sqlCreateTablePattern.FindAllStringSubmatchIndex(ddl, -1) {
+		tableName := unquoteIdentifier(ddl[match[2]:match[3]])
+		body := getParenthesized(ddl[match[1]:])
+		columns := []Column{}
+		for _, definition := range splitTopLevel(body) {
+			fields := strings.Fields(definition)
+			if len(fields) == 0 || sqlConstraintKeywords[strings.ToLower(fields[0])] {
+				continue
+			}
+			column := Column{Name: unquoteIdentifier(fields[0])}
+			if len(fields) > 1 {
+				// the type up to its closing parenthesis (numeric(10, 2))
+				columnType := fields[1]
+				for i := 2; i < len(fields) && strings.Count(columnType, "(") > strings.Count(columnType, ")"); i++ {
+					columnType += " " + fields[i]
+				}
+				column.Type = strings.ToLower(columnType)
+			}
+			columns = append(columns, column)
+		}
+		tables.addColumns(tableName, columns...)
+	}
+
+	for _, match := range sqlAddColumnPattern.FindAllStringSubmatch(ddl, -1) {
+		tables.addColumns(unquoteIdentifier(match[1]), Column{Name: unquoteIdentifier(match[2]), Type: strings.ToLower(match[3])})
+	}
+	return tables.tables
+}
+
+// Returns the text up to the parenthesis closing the one before it
+func getParenthesized(text string) string {
+	depth := 1
+	for i, r := range text {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return text[:i]
+			}
+		}
+	}
+	return text
+}
+
+// Splits at commas outside of parentheses (e.g. not in numeric(10, 2))
+func splitTopLevel(text string) []string {
+	parts := []string{}
+	depth, start := 0, 0
+	for i, r := range text {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, text[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, text[start:])
+}
@@ -0,0 +1,88 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package diagnostics
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+// Local diagnostic bundle written when the cli panics. The report never
+// contains the full command line, paths or user identifiers, so it can
+// be shared (with consent) as is
+
+type CrashReport struct {
+	Time         time.Time   `json:"time"`
+	Version      string      `json:"version"`
+	GoVersion    string      `json:"goVersion"`
+	OS           string      `json:"os"`
+	Arch         string      `json:"arch"`
+	Command      string      `json:"command"`
+	Image        string      `json:"image"`
+	Panic        string      `json:"panic"`
+	Stack        string      `json:"stack"`
+	UserSettings interface{} `json:"userSettings"`
+}
+
+func NewCrashReport(version, command, image string, recovered interface{}, stack []byte, userSettings interface{}) CrashReport {
+	return CrashReport{
+		Time:         time.Now().UTC(),
+		Version:      version,
+		GoVersion:    runtime.Version(),
+		OS:           runtime.GOOS,
+		Arch:         runtime.GOARCH,
+		Command:      command,
+		Image:        image,
+		Panic:        fmt.Sprintf("%v", recovered),
+		Stack:        string(stack),
+		UserSettings: userSettings,
+	}
+}
+
+// Writes the report to the directory and returns the path to the report
+func (r CrashReport) Write(directory string) (string, error) {
+	if err := os.MkdirAll(directory, os.ModePerm); err != nil {
+		return "", err
+	}
+
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	reportPath := filepath.Join(directory, fmt.Sprintf("crash-%s.json", r.Time.Format("20060102-150405")))
+	if err := os.WriteFile(reportPath, data, 0600); err != nil {
+		return "", err
+	}
+
+	return reportPath, nil
+}
+
+func (r CrashReport) JSON() string {
+	data, _ := json.Marshal(r)
+	return string(data)
+}
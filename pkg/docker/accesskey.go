@@ -0,0 +1,108 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+package docker
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/utils"
+)
+
+// The last docker access key is cached (~/.privado/keys/access-key.json),
+// so a scan can proceed with the local image when the latest image cannot
+// be pulled, e.g. the registry is unreachable, within the TTL of the cache
+
+type cachedAccessKey struct {
+	Image     string    `json:"image"`
+	Key       string    `json:"key"`
+	FetchedAt time.Time `json:"fetchedAt"`
+}
+
+// set for licensed offline installations, which cannot fetch a new key
+var accessKeyCacheNeverExpires = false
+
+// Keeps using the cached access key however long ago it was fetched
+func SetAccessKeyCacheNeverExpires() {
+	accessKeyCacheNeverExpires = true
+}
+
+// Returns how long the cached access key is used, 0 if never: the
+// configured TTL, else the default. Without expiry, it is always used
+func getAccessKeyCacheTTL() time.Duration {
+	if accessKeyCacheNeverExpires {
+		return time.Duration(math.MaxInt64)
+	}
+	ttl := config.AppConfig.AccessKeyCacheTTL
+	if config.UserConfig.ConfigFile == nil || config.UserConfig.ConfigFile.AccessKeyCacheTTL == "" {
+		return ttl
+	}
+	value := config.UserConfig.ConfigFile.AccessKeyCacheTTL
+	if value == "0" {
+		return 0
+	}
+	duration, err := utils.ParseDuration(value)
+	if err != nil {
+		fmt.Printf("[WARN]: Invalid accessKeyCacheTTL '%s' in the configuration, using %s\n", value, ttl)
+		return ttl
+	}
+	return duration
+}
+
+func saveCachedAccessKey(image, key string) {
+	if key == "" || getAccessKeyCacheTTL() == 0 {
+		return
+	}
+	data, _ := json.MarshalIndent(cachedAccessKey{Image: image, Key: key, FetchedAt: time.Now().UTC()}, "", "  ")
+	if err := os.MkdirAll(filepath.Dir(config.AppConfig.AccessKeyCachePath), 0700); err != nil {
+		debugf("cannot cache the access key: %s", err)
+		return
+	}
+	if err := os.WriteFile(config.AppConfig.AccessKeyCachePath, data, 0600); err != nil {
+		debugf("cannot cache the access key: %s", err)
+	}
+}
+
+// Returns the cached access key of the image, if it was fetched within the TTL
+func getCachedAccessKey(image string) (cachedAccessKey, bool) {
+	cached := cachedAccessKey{}
+	ttl := getAccessKeyCacheTTL()
+	if ttl == 0 {
+		return cached, false
+	}
+	data, err := os.ReadFile(config.AppConfig.AccessKeyCachePath)
+	if err != nil {
+		return cached, false
+	}
+	if err := json.Unmarshal(data, &cached); err != nil || cached.Image != image || cached.Key == "" {
+		return cached, false
+	}
+	if time.Since(cached.FetchedAt) > ttl {
+		return cached, false
+	}
+	return cached, true
+}
@@ -0,0 +1,60 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+package docker
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/docker/docker/client"
+)
+
+// Copies the debug artifacts of the engine from the (exited) container to
+// the directory, keeping their paths in the container. Directories that
+// the engine did not create are skipped
+func collectDebugArtifacts(dockerClient *client.Client, ctx context.Context, containerId, directory string) {
+	collected := 0
+	for _, containerPath := range config.AppConfig.Container.DebugArtifactDirs {
+		done := debugCall("CopyFromContainer", containerPath)
+		reader, _, err := dockerClient.CopyFromContainer(ctx, containerId, containerPath)
+		done(err)
+		if err != nil {
+			if !client.IsErrNotFound(err) {
+				fmt.Printf("[WARN]: Could not collect debug artifacts (%s): %s\n", containerPath, err)
+			}
+			continue
+		}
+		count, err := extractTar(reader, directory, path.Dir(path.Clean(containerPath)), nil)
+		reader.Close()
+		collected += count
+		if err != nil {
+			fmt.Printf("[WARN]: Could not collect debug artifacts (%s): %s\n", containerPath, err)
+		}
+	}
+	if collected == 0 {
+		fmt.Println("> No debug artifacts of the engine found")
+		return
+	}
+	fmt.Printf("> Debug artifacts of the engine (%d file(s)) collected to: %s\n", collected, directory)
+}
@@ -0,0 +1,127 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package docker
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// Verbose logging of docker api calls (--debug-docker) with their timings,
+// to diagnose containers that never start and scans hanging at the pull.
+// Lines are written to stdout, and hence to the scan log
+
+var apiDebugLogging bool
+
+// the endpoint is logged with the first client created
+var clientLogged bool
+
+func EnableAPIDebugLogging(enabled bool) {
+	apiDebugLogging = enabled
+}
+
+func debugf(format string, args ...interface{}) {
+	if apiDebugLogging {
+		fmt.Printf("[DOCKER %s] %s\n", time.Now().Format("15:04:05.000"), fmt.Sprintf(format, args...))
+	}
+}
+
+// Logs the api call, and returns a function logging its result and duration
+//
+//	done := debugCall("ContainerStart", id)
+//	err := client.ContainerStart(...)
+//	done(err)
+func debugCall(call, details string) func(err error) {
+	if !apiDebugLogging {
+		return func(error) {}
+	}
+	debugf("%s %s", call, details)
+	started := time.Now()
+	return func(err error) {
+		duration := time.Since(started).Round(time.Millisecond)
+		if err != nil {
+			debugf("%s failed after %s: %v", call, duration, err)
+		} else {
+			debugf("%s completed in %s", call, duration)
+		}
+	}
+}
+
+// Logs the docker endpoint the client connects to
+func debugClient() {
+	if !apiDebugLogging || clientLogged {
+		return
+	}
+	clientLogged = true
+	host := os.Getenv("DOCKER_HOST")
+	if host == "" {
+		host = "default socket"
+	}
+	debugf("client: host=%s, api version=%s (negotiated), tls verify=%s", host, valueOrDefault(os.Getenv("DOCKER_API_VERSION"), "auto"), valueOrDefault(os.Getenv("DOCKER_TLS_VERIFY"), "0"))
+}
+
+// Logs the resolved mounts of the container, and whether their sources exist
+func debugMounts(hostConfig *container.HostConfig) {
+	if !apiDebugLogging {
+		return
+	}
+	for _, mount := range hostConfig.Mounts {
+		mode := "rw"
+		if mount.ReadOnly {
+			mode = "ro"
+		}
+		if mount.Type == "bind" {
+			status := "ok"
+			if _, err := os.Stat(mount.Source); err != nil {
+				status = fmt.Sprintf("source not accessible: %v", err)
+			}
+			debugf("mount: %s %s -> %s (%s, %s)", mount.Type, mount.Source, mount.Target, mode, status)
+		} else {
+			debugf("mount: %s -> %s (%s)", mount.Type, mount.Target, mode)
+		}
+	}
+}
+
+// Logs the container configuration (environment variable names only, values may be secrets)
+func debugContainerConfig(containerConfig *container.Config) {
+	if !apiDebugLogging {
+		return
+	}
+	names := []string{}
+	for _, env := range containerConfig.Env {
+		names = append(names, strings.SplitN(env, "=", 2)[0])
+	}
+	debugf("container: image=%s, entrypoint=%v, cmd=%v", containerConfig.Image, containerConfig.Entrypoint, containerConfig.Cmd)
+	debugf("container: env=%s", strings.Join(names, ","))
+}
+
+func valueOrDefault(value, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
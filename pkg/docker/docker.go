@@ -25,19 +25,30 @@ package docker
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path"
+	"path/filepath"
+	"runtime"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+	"github.com/Privado-Inc/privado-cli/pkg/logs"
+	"github.com/Privado-Inc/privado-cli/pkg/progress"
 	"github.com/Privado-Inc/privado-cli/pkg/telemetry"
+	"github.com/Privado-Inc/privado-cli/pkg/tracing"
 	"github.com/Privado-Inc/privado-cli/pkg/utils"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/client"
 	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/moby/term"
 )
 
@@ -51,6 +62,7 @@ func getDefaultDockerClient() (*client.Client, error) {
 	if err != nil {
 		return nil, err
 	}
+	debugClient()
 
 	return client, nil
 }
@@ -75,7 +87,7 @@ func getContainerHostConfig(volumes containerVolumes) *container.HostConfig {
 			hostConfig.Mounts,
 			mount.Mount{
 				Type:     "bind",
-				Source:   volumes.userKeyVolumeHost,
+				Source:   fileutils.GetMountSource(volumes.userKeyVolumeHost),
 				Target:   config.AppConfig.Container.UserKeyVolumeDir,
 				ReadOnly: true,
 			},
@@ -86,7 +98,7 @@ func getContainerHostConfig(volumes containerVolumes) *container.HostConfig {
 			hostConfig.Mounts,
 			mount.Mount{
 				Type:     "bind",
-				Source:   volumes.dockerKeyVolumeHost,
+				Source:   fileutils.GetMountSource(volumes.dockerKeyVolumeHost),
 				Target:   config.AppConfig.Container.DockerKeyVolumeDir,
 				ReadOnly: true,
 			},
@@ -97,7 +109,7 @@ func getContainerHostConfig(volumes containerVolumes) *container.HostConfig {
 			hostConfig.Mounts,
 			mount.Mount{
 				Type:   "bind",
-				Source: volumes.userConfigVolumeHost,
+				Source: fileutils.GetMountSource(volumes.userConfigVolumeHost),
 				Target: config.AppConfig.Container.UserConfigVolumeDir,
 			},
 		)
@@ -107,17 +119,61 @@ func getContainerHostConfig(volumes containerVolumes) *container.HostConfig {
 			hostConfig.Mounts,
 			mount.Mount{
 				Type:   "bind",
-				Source: volumes.sourceCodeVolumeHost,
+				Source: fileutils.GetMountSource(volumes.sourceCodeVolumeHost),
 				Target: config.AppConfig.Container.SourceCodeVolumeDir,
 			},
 		)
+		for _, directory := range volumes.maskedSourceDirectories {
+			hostConfig.Mounts = append(
+				hostConfig.Mounts,
+				mount.Mount{
+					Type:   "tmpfs",
+					Target: path.Join(config.AppConfig.Container.SourceCodeVolumeDir, filepath.ToSlash(directory)),
+				},
+			)
+		}
+		for _, file := range volumes.maskedSourceFiles {
+			hostConfig.Mounts = append(
+				hostConfig.Mounts,
+				mount.Mount{
+					Type:     "bind",
+					Source:   fileutils.GetMountSource(volumes.maskedSourceFileReplacement),
+					Target:   path.Join(config.AppConfig.Container.SourceCodeVolumeDir, filepath.ToSlash(file)),
+					ReadOnly: true,
+				},
+			)
+		}
+	}
+	for _, sourceRoot := range volumes.additionalSourceRoots {
+		if target, ok := GetSourceRootTarget(sourceRoot.RelativePath); ok {
+			hostConfig.Mounts = append(
+				hostConfig.Mounts,
+				mount.Mount{
+					Type:     "bind",
+					Source:   fileutils.GetMountSource(sourceRoot.HostPath),
+					Target:   target,
+					ReadOnly: true,
+				},
+			)
+		}
+	}
+	for _, directory := range volumes.sharedDirectories {
+		hostConfig.Mounts = append(
+			hostConfig.Mounts,
+			mount.Mount{
+				Type:     "bind",
+				Source:   fileutils.GetMountSource(directory),
+				Target:   fileutils.GetContainerPath(directory),
+				ReadOnly: true,
+			},
+		)
 	}
 	if volumes.externalRulesVolumeEnabled {
 		hostConfig.Mounts = append(
 			hostConfig.Mounts,
 			mount.Mount{
 				Type:   "bind",
-				Source: volumes.externalRulesVolumeHost,
+				Source: fileutils.GetMountSource(volumes.externalRulesVolumeHost),
 				Target: config.AppConfig.Container.ExternalRulesVolumeDir,
 			},
 		)
@@ -127,7 +183,7 @@ func getContainerHostConfig(volumes containerVolumes) *container.HostConfig {
 			hostConfig.Mounts,
 			mount.Mount{
 				Type:   "bind",
-				Source: volumes.m2PackageCacheVolumeHost,
+				Source: fileutils.GetMountSource(volumes.m2PackageCacheVolumeHost),
 				Target: config.AppConfig.Container.M2PackageCacheVolumeDir,
 			},
 		)
@@ -137,22 +193,62 @@ func getContainerHostConfig(volumes containerVolumes) *container.HostConfig {
 			hostConfig.Mounts,
 			mount.Mount{
 				Type:   "bind",
-				Source: volumes.gradlePackageCacheVolumeHost,
+				Source: fileutils.GetMountSource(volumes.gradlePackageCacheVolumeHost),
 				Target: config.AppConfig.Container.GradlePackageCacheVolumeDir,
 			},
 		)
 	}
+	if volumes.jvmCacheVolumeEnabled {
+		hostConfig.Mounts = append(
+			hostConfig.Mounts,
+			mount.Mount{
+				Type:     "bind",
+				Source:   fileutils.GetMountSource(volumes.jvmCacheVolumeHost),
+				Target:   config.AppConfig.Container.JVMCacheVolumeDir,
+				ReadOnly: volumes.jvmCacheVolumeReadOnly,
+			},
+		)
+	}
+	if volumes.npmPackageCacheVolumeEnabled {
+		hostConfig.Mounts = append(
+			hostConfig.Mounts,
+			mount.Mount{
+				Type:   "bind",
+				Source: fileutils.GetMountSource(volumes.npmPackageCacheVolumeHost),
+				Target: config.AppConfig.Container.NpmPackageCacheVolumeDir,
+			},
+		)
+	}
 
 	return hostConfig
 }
 
+// Returns the environment with the options appended to JAVA_TOOL_OPTIONS
+func withJVMOptions(environmentVars, jvmOptions []string) []string {
+	if len(jvmOptions) == 0 {
+		return environmentVars
+	}
+	value := strings.Join(jvmOptions, " ")
+	merged := []string{}
+	for _, variable := range environmentVars {
+		if existing := strings.TrimPrefix(variable, "JAVA_TOOL_OPTIONS="); existing != variable {
+			value = existing + " " + value
+			continue
+		}
+		merged = append(merged, variable)
+	}
+	return append(merged, "JAVA_TOOL_OPTIONS="+value)
+}
+
 func GetEnvsFromDockerImage(imageURL string) ([]EnvVar, error) {
 	client, err := getDefaultDockerClient()
 	if err != nil {
 		return nil, err
 	}
 
+	done := debugCall("ImageInspect", imageURL)
 	imageInfo, _, err := client.ImageInspectWithRaw(context.Background(), imageURL)
+	done(err)
 	if err != nil {
 		return nil, err
 	}
@@ -167,6 +263,171 @@ func GetEnvsFromDockerImage(imageURL string) ([]EnvVar, error) {
 	return sanitizedEnvs, nil
 }
 
+type StorageInfo struct {
+	// data root of the docker daemon, empty if it is not on this host's
+	// filesystem (e.g. Docker Desktop runs the daemon in a virtual machine)
+	RootDirectory string
+
+	ImagePresent bool
+	ImageSize    int64
+}
+
+// Returns where the daemon stores images and containers, and whether the image is present
+func GetStorageInfo(image string) (StorageInfo, error) {
+	storageInfo := StorageInfo{}
+	client, err := getDefaultDockerClient()
+	if err != nil {
+		return storageInfo, err
+	}
+
+	done := debugCall("Info", "")
+	info, err := client.Info(context.Background())
+	done(err)
+	if err != nil {
+		return storageInfo, err
+	}
+	if runtime.GOOS == "linux" && !strings.Contains(info.OperatingSystem, "Docker Desktop") {
+		if _, err := os.Stat(info.DockerRootDir); err == nil {
+			storageInfo.RootDirectory = info.DockerRootDir
+		}
+	}
+
+	done = debugCall("ImageInspect", image)
+	imageInfo, _, err := client.ImageInspectWithRaw(context.Background(), image)
+	done(err)
+	if err == nil {
+		storageInfo.ImagePresent = true
+		storageInfo.ImageSize = imageInfo.Size
+	}
+	return storageInfo, nil
+}
+
+// Returns the digest of the local image (its id if it has no digest),
+// empty if the image is not present
+func GetImageDigest(image string) string {
+	if details := GetImageDetails(image); details != nil {
+		return details.Digest
+	}
+	return ""
+}
+
+type ImageDetails struct {
+	Digest  string
+	Created string
+	// from the standard image label (org.opencontainers.image.version), if set
+	Version string
+}
+
+// Returns details of the local image, nil if the image is not present
+func GetImageDetails(image string) *ImageDetails {
+	client, err := getDefaultDockerClient()
+	if err != nil {
+		return nil
+	}
+	done := debugCall("ImageInspect", image)
+	imageInfo, _, err := client.ImageInspectWithRaw(context.Background(), image)
+	done(err)
+	if err != nil {
+		return nil
+	}
+
+	details := &ImageDetails{Digest: imageInfo.ID, Created: imageInfo.Created}
+	if len(imageInfo.RepoDigests) > 0 {
+		details.Digest = imageInfo.RepoDigests[0]
+	}
+	if imageInfo.Config != nil {
+		details.Version = imageInfo.Config.Labels["org.opencontainers.image.version"]
+	}
+	return details
+}
+
+// Architectures of the docker host and the local image, as GOARCH
+// (amd64, arm64); empty if unknown
+type PlatformInfo struct {
+	HostArch  string
+	ImageArch string
+}
+
+// Returns whether the image runs emulated (QEMU, Rosetta) on the docker
+// host, which makes scans several times slower
+func (p PlatformInfo) IsEmulated() bool {
+	return p.HostArch != "" && p.ImageArch != "" && p.HostArch != p.ImageArch
+}
+
+// Returns the architectures of the docker host and the image, if present
+func GetPlatformInfo(image string) (PlatformInfo, error) {
+	platform := PlatformInfo{}
+	client, err := getDefaultDockerClient()
+	if err != nil {
+		return platform, err
+	}
+	defer client.Close()
+
+	done := debugCall("ServerVersion", "")
+	version, err := client.ServerVersion(context.Background())
+	done(err)
+	if err != nil {
+		return platform, err
+	}
+	platform.HostArch = normalizeArch(version.Arch)
+
+	done = debugCall("ImageInspect", image)
+	imageInfo, _, err := client.ImageInspectWithRaw(context.Background(), image)
+	done(err)
+	if err == nil {
+		platform.ImageArch = normalizeArch(imageInfo.Architecture)
+	}
+	return platform, nil
+}
+
+// Returns the architecture as GOARCH, e.g. x86_64 is amd64
+func normalizeArch(arch string) string {
+	switch strings.ToLower(arch) {
+	case "x86_64", "x86-64":
+		return "amd64"
+	case "aarch64":
+		return "arm64"
+	}
+	return strings.ToLower(arch)
+}
+
+type RuntimeVersion struct {
+	Name       string
+	Version    string
+	APIVersion string
+}
+
+// Returns the name (Docker, Podman) and version of the container
+// runtime the docker api is served by
+func GetRuntimeVersion() (RuntimeVersion, error) {
+	runtimeVersion := RuntimeVersion{}
+	client, err := getDefaultDockerClient()
+	if err != nil {
+		return runtimeVersion, err
+	}
+	done := debugCall("ServerVersion", "")
+	version, err := client.ServerVersion(context.Background())
+	done(err)
+	if err != nil {
+		return runtimeVersion, err
+	}
+
+	runtimeVersion.Name = "Docker"
+	runtimeVersion.Version = version.Version
+	runtimeVersion.APIVersion = version.APIVersion
+	// podman serves a docker compatible api, reporting itself as a component
+	for _, component := range version.Components {
+		if strings.HasPrefix(component.Name, "Podman") {
+			runtimeVersion.Name = "Podman"
+			runtimeVersion.Version = component.Version
+		}
+	}
+	if version.Platform.Name != "" && runtimeVersion.Name == "Docker" {
+		runtimeVersion.Name = version.Platform.Name
+	}
+	return runtimeVersion, nil
+}
+
 func GetPrivadoDockerAccessKey(pullImage bool) (string, error) {
 	imageURL := config.AppConfig.Container.ImageURL
 
@@ -176,17 +437,31 @@ func GetPrivadoDockerAccessKey(pullImage bool) (string, error) {
 			return "", err
 		}
 		if err := PullLatestImage(imageURL, client); err != nil {
+			// e.g. a transient network error: continue with the local image
+			if cached, ok := getCachedAccessKey(imageURL); ok {
+				fmt.Println("[WARN]: Could not pull the latest image:", err)
+				fmt.Printf("[WARN]: Continuing with the local image and the access key cached %s ago\n", time.Since(cached.FetchedAt).Round(time.Minute))
+				progress.Warning("could not pull the latest image, using the cached access key")
+				return cached.Key, nil
+			}
 			return "", err
 		}
 	}
 
+	span := tracing.StartSpan("access-key-fetch")
 	envs, err := GetEnvsFromDockerImage(imageURL)
+	span.End(err)
 	if err != nil {
+		if cached, ok := getCachedAccessKey(imageURL); ok {
+			fmt.Println("[WARN]: Could not fetch the docker access key, using the cached key:", err)
+			return cached.Key, nil
+		}
 		return "", err
 	}
 
 	for _, env := range envs {
 		if env.Key == config.AppConfig.Container.DockerAccessKeyEnv {
+			saveCachedAccessKey(imageURL, env.Value)
 			return env.Value, nil
 		}
 	}
@@ -204,14 +479,23 @@ func PullLatestImage(image string, client *client.Client) (err error) {
 
 	ctx := context.Background()
 
+	span := tracing.StartSpan("image-pull")
+	span.SetAttribute("container.image.name", image)
+	defer func() { span.End(err) }()
+
 	fmt.Println("\n> Pulling the latest image:", image)
+	done := debugCall("ImagePull", image)
 	reader, err := client.ImagePull(ctx, image, types.ImagePullOptions{})
+	done(err)
 	if err != nil {
 		return err
 	}
 
-	id, isTerm := term.GetFdInfo(os.Stdout)
-	_ = jsonmessage.DisplayJSONMessagesStream(reader, os.Stdout, id, isTerm, nil)
+	// pull progress is shown on the terminal, but not written to scan logs
+	terminal := logs.Terminal()
+	id, isTerm := term.GetFdInfo(terminal)
+	streamDone := debugCall("ImagePull stream", image)
+	streamDone(jsonmessage.DisplayJSONMessagesStream(reader, terminal, id, isTerm, nil))
 
 	defer reader.Close()
 	io.Copy(os.Stdout, reader)
@@ -220,6 +504,7 @@ func PullLatestImage(image string, client *client.Client) (err error) {
 }
 
 func attachContainerOutput(client *client.Client, ctx context.Context, containerId string) (*bufio.Reader, error) {
+	done := debugCall("ContainerAttach", containerId)
 	waiter, err := client.ContainerAttach(ctx, containerId, types.ContainerAttachOptions{
 		Stderr: true,
 		Stdout: true,
@@ -227,6 +512,7 @@ func attachContainerOutput(client *client.Client, ctx context.Context, container
 		Stream: true,
 		Logs:   true,
 	})
+	done(err)
 
 	if err != nil {
 		return nil, err
@@ -237,6 +523,57 @@ func attachContainerOutput(client *client.Client, ctx context.Context, container
 	return waiter.Reader, err
 }
 
+// Processes the attached output (of a container without tty) like
+// processAttachedContainerOutput, with stdout and stderr separated: both
+// are written to the log file and processed, stderr is only shown if
+// showStderr
+func processSeparatedContainerOutput(reader *bufio.Reader, attachStdOut bool, outputProcessors []containerOutputProcessor, logPath string, showStderr bool) {
+	logFile := &engineLogWriter{}
+	if err := os.MkdirAll(filepath.Dir(logPath), os.ModePerm); err != nil {
+		fmt.Println("[WARN]: Cannot write engine logs:", err)
+	} else if logFile.file, err = os.Create(logPath); err != nil {
+		fmt.Println("[WARN]: Cannot write engine logs:", err)
+	}
+
+	// the pipes are always read, so the output never blocks
+	if len(outputProcessors) == 0 {
+		outputProcessors = []containerOutputProcessor{{}}
+	}
+	stdoutReader, stdoutWriter := io.Pipe()
+	stderrReader, stderrWriter := io.Pipe()
+	processAttachedContainerOutput(bufio.NewReader(stdoutReader), attachStdOut, outputProcessors)
+	processAttachedContainerOutput(bufio.NewReader(stderrReader), attachStdOut && showStderr, outputProcessors)
+
+	go func() {
+		_, err := stdcopy.StdCopy(io.MultiWriter(stdoutWriter, logFile), io.MultiWriter(stderrWriter, logFile), reader)
+		stdoutWriter.CloseWithError(err)
+		stderrWriter.CloseWithError(err)
+		logFile.Close()
+	}()
+}
+
+// writes to the engine log file, if any; a failing log file must not
+// fail the output to the terminal
+type engineLogWriter struct {
+	file *os.File
+}
+
+func (w *engineLogWriter) Write(p []byte) (int, error) {
+	if w.file != nil {
+		if _, err := w.file.Write(p); err != nil {
+			w.file.Close()
+			w.file = nil
+		}
+	}
+	return len(p), nil
+}
+
+func (w *engineLogWriter) Close() {
+	if w.file != nil {
+		w.file.Close()
+	}
+}
+
 func processAttachedContainerOutput(reader *bufio.Reader, attachStdOut bool, outputProcessors []containerOutputProcessor) {
 	// noticed we are missing output due to
 	// this kind of usage
@@ -252,7 +589,11 @@ func processAttachedContainerOutput(reader *bufio.Reader, attachStdOut bool, out
 
 	go func() {
 		for {
-			outputLine, _ := reader.ReadString('\n')
+			outputLine, err := reader.ReadString('\n')
+			if err != nil && outputLine == "" {
+				// the output ended (the container exited)
+				return
+			}
 			if attachStdOut {
 				fmt.Print(outputLine)
 			}
@@ -276,21 +617,46 @@ func processAttachedContainerOutput(reader *bufio.Reader, attachStdOut bool, out
 	}()
 }
 
-func WaitForContainer(client *client.Client, ctx context.Context, containerId string) error {
+// returned when the container exits with a non-zero status
+type ContainerExitError struct {
+	StatusCode int64
+	// the container was killed or the engine failed for lack of memory
+	// (detected with OptionWithMemoryWatchdog)
+	OutOfMemory bool
+}
+
+func (e *ContainerExitError) Error() string {
+	if e.OutOfMemory {
+		return fmt.Sprintf("process exited with status %d: out of memory", e.StatusCode)
+	}
+	return fmt.Sprintf("process exited with status %d", e.StatusCode)
+}
+
+// output of the engine when its heap is exhausted
+const javaOutOfMemoryMessage = "java.lang.OutOfMemoryError"
+
+func WaitForContainer(client *client.Client, ctx context.Context, containerId string) (err error) {
+	done := debugCall("ContainerWait", containerId)
+	defer func() { done(err) }()
+
 	statusCh, errCh := client.ContainerWait(ctx, containerId, container.WaitConditionNotRunning)
 	select {
 	case err := <-errCh:
 		if err != nil {
 			return err
 		}
-	case <-statusCh:
+	case status := <-statusCh:
+		if status.StatusCode != 0 {
+			return &ContainerExitError{StatusCode: status.StatusCode}
+		}
 	}
 
 	return nil
 }
 
 func RemoveContainerForcefully(client *client.Client, ctx context.Context, containerId string) error {
-	return client.ContainerRemove(
+	done := debugCall("ContainerRemove", containerId)
+	err := client.ContainerRemove(
 		ctx,
 		containerId,
 		types.ContainerRemoveOptions{
@@ -298,13 +664,18 @@ func RemoveContainerForcefully(client *client.Client, ctx context.Context, conta
 			Force:         true,
 		},
 	)
+	done(err)
+	return err
 }
 
 func StopContainer(client *client.Client, ctx context.Context, containerId string) error {
-	return client.ContainerStop(ctx, containerId, nil)
+	done := debugCall("ContainerStop", containerId)
+	err := client.ContainerStop(ctx, containerId, nil)
+	done(err)
+	return err
 }
 
-func RunImage(opts ...RunImageOption) error {
+func RunImage(opts ...RunImageOption) (err error) {
 	runOptions := newRunImageHandler(opts)
 	ctx := context.Background()
 
@@ -321,25 +692,44 @@ func RunImage(opts ...RunImageOption) error {
 		}
 	}
 
+	span := tracing.StartSpan("container-run")
+	span.SetAttribute("container.image.name", image)
+	defer func() { span.End(err) }()
+
 	// Generate container configurations
 	containerConfig := getBaseContainerConfig(image)
 	containerConfig.Entrypoint = runOptions.entrypoint
 	containerConfig.Cmd = runOptions.args
-	containerConfig.Env = runOptions.environmentVars
+	containerConfig.Env = withJVMOptions(runOptions.environmentVars, runOptions.jvmOptions)
+	if runOptions.engineLogPath != "" {
+		// without a tty, stdout and stderr are attached separately
+		containerConfig.Tty = false
+	}
+	if tracing.IsEnabled() {
+		// propagate the trace context to the engine
+		containerConfig.Env = append(containerConfig.Env, fmt.Sprintf("TRACEPARENT=%s", span.TraceParent()))
+	}
 	hostConfig := getContainerHostConfig(runOptions.volumes)
+	hostConfig.Resources.Memory = runOptions.memoryLimit
 
 	telemetry.DefaultInstance.RecordAtomicMetric("dockerCmd", strings.Join(containerConfig.Cmd, " "))
 
 	// Create container
+	debugContainerConfig(containerConfig)
+	debugMounts(hostConfig)
+	done := debugCall("ContainerCreate", image)
 	creationResponse, err := client.ContainerCreate(ctx, containerConfig, hostConfig, nil, nil, "")
+	done(err)
 	if err != nil {
 		return err
 	}
+	debugf("container created: %s", creationResponse.ID)
 	if len(creationResponse.Warnings) > 0 {
 		fmt.Println("\n> Encountered warnings:")
 		for i, warn := range creationResponse.Warnings {
 			fmt.Println(i+1, warn)
 			telemetry.DefaultInstance.RecordArrayMetric("warning", warn)
+			progress.Warning(warn)
 		}
 	}
 
@@ -347,7 +737,7 @@ func RunImage(opts ...RunImageOption) error {
 	defer RemoveContainerForcefully(client, ctx, creationResponse.ID)
 
 	// Attach input/output streams with container
-	containerOutputProcessors := []containerOutputProcessor{}
+	containerOutputProcessors := append([]containerOutputProcessor{}, runOptions.outputListeners...)
 	if runOptions.spawnWebBrowserOnURLMessage {
 		containerOutputProcessors = append(containerOutputProcessors, containerOutputProcessor{
 			messages: runOptions.spawnWebBrowserOnURLTriggerMessages,
@@ -383,19 +773,47 @@ func RunImage(opts ...RunImageOption) error {
 		})
 	}
 
+	var javaOutOfMemory int32
+	if runOptions.memoryWatchdog {
+		containerOutputProcessors = append(containerOutputProcessors, containerOutputProcessor{
+			messages: []string{javaOutOfMemoryMessage},
+			matchFn: func(message string) {
+				atomic.StoreInt32(&javaOutOfMemory, 1)
+			},
+		})
+	}
+
+	lastOutput := time.Now().UnixNano()
+	if runOptions.hangTimeout > 0 {
+		// every line of output is activity of the engine
+		containerOutputProcessors = append(containerOutputProcessors, containerOutputProcessor{
+			messages: []string{""},
+			matchFn: func(message string) {
+				atomic.StoreInt64(&lastOutput, time.Now().UnixNano())
+			},
+		})
+	}
+
 	if runOptions.attachOutput || len(containerOutputProcessors) > 0 {
 		reader, err := attachContainerOutput(client, ctx, creationResponse.ID)
 		if err != nil {
 			return err
 		}
 
-		processAttachedContainerOutput(reader, runOptions.attachOutput, containerOutputProcessors)
+		if runOptions.engineLogPath != "" {
+			processSeparatedContainerOutput(reader, runOptions.attachOutput, containerOutputProcessors, runOptions.engineLogPath, runOptions.showEngineStderr)
+		} else {
+			processAttachedContainerOutput(reader, runOptions.attachOutput, containerOutputProcessors)
+		}
 	}
 
 	// Start container
 	fmt.Println("\n> Starting container with the latest image")
 	fmt.Println("> Container ID:", creationResponse.ID)
-	if err := client.ContainerStart(ctx, creationResponse.ID, types.ContainerStartOptions{}); err != nil {
+	done = debugCall("ContainerStart", creationResponse.ID)
+	err = client.ContainerStart(ctx, creationResponse.ID, types.ContainerStartOptions{})
+	done(err)
+	if err != nil {
 		return err
 	}
 
@@ -416,10 +834,46 @@ func RunImage(opts ...RunImageOption) error {
 	// Image output after this point
 	fmt.Println("\n> Waiting for process to complete:")
 
+	if runOptions.memoryWatchdog {
+		stopWatchdog := make(chan struct{})
+		defer close(stopWatchdog)
+		go watchMemory(client, creationResponse.ID, 5*time.Second, stopWatchdog)
+	}
+
+	if runOptions.statsInterval > 0 {
+		stopStats := make(chan struct{})
+		defer close(stopStats)
+		go showStats(client, creationResponse.ID, runOptions.statsInterval, stopStats)
+	}
+
+	if runOptions.hangTimeout > 0 {
+		atomic.StoreInt64(&lastOutput, time.Now().UnixNano())
+		stopHangDetection := make(chan struct{})
+		defer close(stopHangDetection)
+		go watchForHang(client, creationResponse.ID, &lastOutput, runOptions.hangTimeout, runOptions.threadDumpDirectory, stopHangDetection)
+	}
+
 	// wait for container to stop (automatically or by interrupt)
-	if err := WaitForContainer(client, ctx, creationResponse.ID); err != nil {
+	err = WaitForContainer(client, ctx, creationResponse.ID)
+	if runOptions.debugArtifactsDirectory != "" {
+		// artifacts of failed runs are the most useful
+		collectDebugArtifacts(client, ctx, creationResponse.ID, runOptions.debugArtifactsDirectory)
+	}
+	if err != nil {
+		var containerExitError *ContainerExitError
+		if runOptions.memoryWatchdog && errors.As(err, &containerExitError) {
+			containerExitError.OutOfMemory = atomic.LoadInt32(&javaOutOfMemory) == 1 || isOOMKilled(client, ctx, creationResponse.ID)
+		}
 		return err
 	}
 
 	return nil
 }
+
+// Returns whether the (exited) container was killed for exceeding its memory limit
+func isOOMKilled(client *client.Client, ctx context.Context, containerId string) bool {
+	done := debugCall("ContainerInspect", containerId)
+	info, err := client.ContainerInspect(ctx, containerId)
+	done(err)
+	return err == nil && info.ContainerJSONBase != nil && info.State != nil && info.State.OOMKilled
+}
@@ -0,0 +1,297 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/utils"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/client"
+)
+
+// Experimental: a long-lived engine container (privado engine start) that
+// scans are run in with docker exec, instead of creating a container for
+// each scan. Repositories are scanned from the root directory mounted in
+// the container, so scans that need mounts of their own (masked files,
+// additional source roots, external rules) cannot be run in it
+
+const (
+	PersistentEngineName = "privado-engine"
+	persistentEngineRoot = "/app/roots"
+	persistentRootLabel  = "ai.privado.engine.root"
+)
+
+var ErrPersistentEngineNotRunning = errors.New("the persistent engine is not running (run 'privado engine start')")
+
+type PersistentEngine struct {
+	Id      string
+	Image   string
+	Root    string
+	Running bool
+	Status  string
+}
+
+// Returns the path of the directory in the engine container, if it is
+// in the root directory of the engine
+func (e *PersistentEngine) GetContainerPath(directory string) (string, bool) {
+	relativePath, err := filepath.Rel(e.Root, directory)
+	if err != nil || relativePath == ".." || strings.HasPrefix(relativePath, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return path.Join(persistentEngineRoot, filepath.ToSlash(relativePath)), true
+}
+
+// Returns the persistent engine container, nil if it does not exist
+func GetPersistentEngine() (*PersistentEngine, error) {
+	dockerClient, err := getDefaultDockerClient()
+	if err != nil {
+		return nil, err
+	}
+	done := debugCall("ContainerInspect", PersistentEngineName)
+	info, err := dockerClient.ContainerInspect(context.Background(), PersistentEngineName)
+	done(err)
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	engine := &PersistentEngine{Id: info.ID}
+	if info.Config != nil {
+		engine.Image = info.Config.Image
+		engine.Root = info.Config.Labels[persistentRootLabel]
+	}
+	if info.State != nil {
+		engine.Running = info.State.Running
+		engine.Status = info.State.Status
+	}
+	return engine, nil
+}
+
+// Starts the persistent engine container with the root directory and the
+// volumes of the options (caches, user key and configuration) mounted
+func StartPersistentEngine(root string, opts ...RunImageOption) (*PersistentEngine, error) {
+	runOptions := newRunImageHandler(opts)
+	ctx := context.Background()
+	client, err := getDefaultDockerClient()
+	if err != nil {
+		return nil, err
+	}
+
+	image := config.AppConfig.Container.ImageURL
+	if runOptions.pullLatestImage {
+		if err := PullLatestImage(image, client); err != nil {
+			return nil, err
+		}
+	}
+
+	// the container idles until it is stopped, scans are run with exec
+	containerConfig := &container.Config{
+		Image:      image,
+		Entrypoint: []string{"tail", "-f", "/dev/null"},
+		Labels:     map[string]string{persistentRootLabel: root},
+	}
+	hostConfig := getContainerHostConfig(runOptions.volumes)
+	hostConfig.Mounts = append(hostConfig.Mounts, mount.Mount{
+		Type:   "bind",
+		Source: root,
+		Target: persistentEngineRoot,
+	})
+
+	debugContainerConfig(containerConfig)
+	debugMounts(hostConfig)
+	done := debugCall("ContainerCreate", image)
+	creationResponse, err := client.ContainerCreate(ctx, containerConfig, hostConfig, nil, nil, PersistentEngineName)
+	done(err)
+	if err != nil {
+		return nil, err
+	}
+	done = debugCall("ContainerStart", creationResponse.ID)
+	err = client.ContainerStart(ctx, creationResponse.ID, types.ContainerStartOptions{})
+	done(err)
+	if err != nil {
+		RemoveContainerForcefully(client, ctx, creationResponse.ID)
+		return nil, err
+	}
+	return &PersistentEngine{Id: creationResponse.ID, Image: image, Root: root, Running: true, Status: "running"}, nil
+}
+
+// Stops and removes the persistent engine container, and the scans running in it
+func StopPersistentEngine() error {
+	client, err := getDefaultDockerClient()
+	if err != nil {
+		return err
+	}
+	return RemoveContainerForcefully(client, context.Background(), PersistentEngineName)
+}
+
+// Runs the engine in the persistent engine container, with the arguments
+// and environment of the options, for the directory (in the root directory
+// of the engine). Volume options are ignored: the volumes of the engine
+// container are used. Like RunImage, returns a ContainerExitError if the
+// engine exits with a non-zero status
+func RunInPersistentEngine(engine *PersistentEngine, directory string, opts ...RunImageOption) (err error) {
+	runOptions := newRunImageHandler(opts)
+	ctx := context.Background()
+	if engine == nil || !engine.Running {
+		return ErrPersistentEngineNotRunning
+	}
+	containerPath, ok := engine.GetContainerPath(directory)
+	if !ok {
+		return fmt.Errorf("%s is not in the root directory of the persistent engine (%s)", directory, engine.Root)
+	}
+
+	client, err := getDefaultDockerClient()
+	if err != nil {
+		return err
+	}
+
+	// the engine is run with the entrypoint of the image, unless overridden
+	entrypoint := runOptions.entrypoint
+	if len(entrypoint) == 0 {
+		done := debugCall("ImageInspect", engine.Image)
+		imageInfo, _, err := client.ImageInspectWithRaw(ctx, engine.Image)
+		done(err)
+		if err != nil {
+			return err
+		}
+		if imageInfo.Config != nil {
+			entrypoint = imageInfo.Config.Entrypoint
+		}
+	}
+	// paths of the source code volume are paths in the root directory instead.
+	// The process records its pid, to be stopped on its own (see killExec)
+	pidFile := fmt.Sprintf("/tmp/privado-exec-%d.pid", time.Now().UnixNano())
+	command := []string{"sh", "-c", fmt.Sprintf(`echo $$ > %s && exec "$@"`, pidFile), "sh"}
+	command = append(command, entrypoint...)
+	for _, arg := range runOptions.args {
+		command = append(command, strings.ReplaceAll(arg, config.AppConfig.Container.SourceCodeVolumeDir, containerPath))
+	}
+
+	execConfig := types.ExecConfig{
+		AttachStdout: true,
+		AttachStderr: true,
+		Tty:          runOptions.engineLogPath == "",
+		Env:          withJVMOptions(runOptions.environmentVars, runOptions.jvmOptions),
+		WorkingDir:   containerPath,
+		Cmd:          command,
+	}
+	debugf("exec in %s: %s", engine.Id, strings.Join(command, " "))
+	done := debugCall("ContainerExecCreate", engine.Id)
+	execResponse, err := client.ContainerExecCreate(ctx, engine.Id, execConfig)
+	done(err)
+	if err != nil {
+		return err
+	}
+
+	done = debugCall("ContainerExecAttach", execResponse.ID)
+	attachment, err := client.ContainerExecAttach(ctx, execResponse.ID, types.ExecStartCheck{Tty: execConfig.Tty})
+	done(err)
+	if err != nil {
+		return err
+	}
+	defer attachment.Close()
+
+	if runOptions.engineLogPath != "" {
+		processSeparatedContainerOutput(attachment.Reader, runOptions.attachOutput, runOptions.outputListeners, runOptions.engineLogPath, runOptions.showEngineStderr)
+	} else {
+		processAttachedContainerOutput(attachment.Reader, runOptions.attachOutput, runOptions.outputListeners)
+	}
+
+	if runOptions.setupInterrupt {
+		// only the process of the scan is stopped, other scans in the engine
+		// keep running
+		sgn := utils.RunOnCtrlC(func() {
+			fmt.Println("\n> Received interrupt signal")
+			fmt.Println("> Terminating..")
+			if err := killExec(client, ctx, engine.Id, pidFile); err != nil {
+				fmt.Println("[WARN]: Could not stop the scan in the persistent engine:", err)
+			}
+		})
+		defer utils.ClearSignals(sgn)
+	}
+
+	fmt.Println("\n> Running in the persistent engine:", engine.Id)
+	fmt.Println("\n> Waiting for process to complete:")
+	if runOptions.statsInterval > 0 {
+		// usage of the whole engine container, the scan is its only workload
+		stopStats := make(chan struct{})
+		defer close(stopStats)
+		go showStats(client, engine.Id, runOptions.statsInterval, stopStats)
+	}
+	err = waitForExec(client, ctx, execResponse.ID)
+	if runOptions.debugArtifactsDirectory != "" {
+		collectDebugArtifacts(client, ctx, engine.Id, runOptions.debugArtifactsDirectory)
+	}
+	return err
+}
+
+// Stops the process of an exec in the container, by the pid it recorded
+// in the pid file, with a second exec (exec processes cannot be stopped
+// with the docker api)
+func killExec(client *client.Client, ctx context.Context, containerId, pidFile string) error {
+	execConfig := types.ExecConfig{
+		Cmd: []string{"sh", "-c", fmt.Sprintf("kill -TERM $(cat %s) && rm -f %s", pidFile, pidFile)},
+	}
+	done := debugCall("ContainerExecCreate", containerId)
+	execResponse, err := client.ContainerExecCreate(ctx, containerId, execConfig)
+	done(err)
+	if err != nil {
+		return err
+	}
+	done = debugCall("ContainerExecStart", execResponse.ID)
+	err = client.ContainerExecStart(ctx, execResponse.ID, types.ExecStartCheck{})
+	done(err)
+	if err != nil {
+		return err
+	}
+	return waitForExec(client, ctx, execResponse.ID)
+}
+
+func waitForExec(client *client.Client, ctx context.Context, execId string) error {
+	for {
+		done := debugCall("ContainerExecInspect", execId)
+		info, err := client.ContainerExecInspect(ctx, execId)
+		done(err)
+		if err != nil {
+			return err
+		}
+		if !info.Running {
+			if info.ExitCode != 0 {
+				return &ContainerExitError{StatusCode: int64(info.ExitCode)}
+			}
+			return nil
+		}
+		time.Sleep(time.Second)
+	}
+}
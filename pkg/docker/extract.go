@@ -0,0 +1,148 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package docker
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/Privado-Inc/privado-cli/pkg/tracing"
+	"github.com/docker/docker/api/types/container"
+)
+
+// Extraction of files of an image (e.g. a built application image) without
+// running it: the files are copied from a container created, but never
+// started, from the image
+
+// Returns the working directory of the image, pulling the image if it is
+// not present
+func GetImageWorkingDirectory(image string) (string, error) {
+	client, err := getDefaultDockerClient()
+	if err != nil {
+		return "", err
+	}
+
+	done := debugCall("ImageInspect", image)
+	imageInfo, _, err := client.ImageInspectWithRaw(context.Background(), image)
+	done(err)
+	if err != nil {
+		if err := PullLatestImage(image, client); err != nil {
+			return "", err
+		}
+		done = debugCall("ImageInspect", image)
+		imageInfo, _, err = client.ImageInspectWithRaw(context.Background(), image)
+		done(err)
+		if err != nil {
+			return "", err
+		}
+	}
+	if imageInfo.Config == nil {
+		return "", nil
+	}
+	return imageInfo.Config.WorkingDir, nil
+}
+
+// Extracts the regular files under the paths of the image (absolute in the
+// image) that include accepts to the directory, keeping their paths in the
+// image (/app/a.js is extracted to directory/app/a.js). Returns the number
+// of extracted files
+func ExtractImageFiles(image string, paths []string, directory string, include func(imagePath string) bool) (extracted int, err error) {
+	client, err := getDefaultDockerClient()
+	if err != nil {
+		return 0, err
+	}
+	ctx := context.Background()
+
+	span := tracing.StartSpan("image-extraction")
+	span.SetAttribute("container.image.name", image)
+	defer func() { span.End(err) }()
+
+	done := debugCall("ContainerCreate", image)
+	creationResponse, err := client.ContainerCreate(ctx, &container.Config{Image: image}, nil, nil, nil, "")
+	done(err)
+	if err != nil {
+		return 0, err
+	}
+	defer RemoveContainerForcefully(client, ctx, creationResponse.ID)
+
+	for _, imagePath := range paths {
+		done := debugCall("CopyFromContainer", imagePath)
+		reader, _, err := client.CopyFromContainer(ctx, creationResponse.ID, imagePath)
+		done(err)
+		if err != nil {
+			return extracted, err
+		}
+
+		// entries are relative to the parent of the copied path
+		count, err := extractTar(reader, directory, path.Dir(path.Clean("/"+imagePath)), include)
+		reader.Close()
+		extracted += count
+		if err != nil {
+			return extracted, err
+		}
+	}
+	return extracted, nil
+}
+
+func extractTar(reader io.Reader, directory, parent string, include func(imagePath string) bool) (int, error) {
+	extracted := 0
+	tarReader := tar.NewReader(reader)
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			return extracted, nil
+		}
+		if err != nil {
+			return extracted, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		imagePath := path.Join(parent, path.Clean("/"+header.Name))
+		if include != nil && !include(imagePath) {
+			continue
+		}
+
+		target := filepath.Join(directory, filepath.FromSlash(strings.TrimPrefix(imagePath, "/")))
+		if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+			return extracted, err
+		}
+		file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+		if err != nil {
+			return extracted, err
+		}
+		_, err = io.Copy(file, tarReader)
+		file.Close()
+		if err != nil {
+			return extracted, err
+		}
+		extracted++
+	}
+}
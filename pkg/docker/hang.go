@@ -0,0 +1,130 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+package docker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/progress"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// prints the threads of every JVM in the container (pid 0: all of them)
+var threadDumpCommand = []string{"jcmd", "0", "Thread.print"}
+
+const threadDumpTimeout = time.Minute
+
+// Watches the output of the container until stop is closed: when the engine
+// produces no output (lastOutput, unix nanoseconds) for timeout, a thread
+// dump of the engine is saved to dumpDirectory, so the hang can be reported
+// and debugged. Once per period without output
+func watchForHang(client *client.Client, containerId string, lastOutput *int64, timeout time.Duration, dumpDirectory string, stop <-chan struct{}) {
+	interval := timeout / 10
+	if interval < time.Second {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	dumpedAt := int64(0)
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		last := atomic.LoadInt64(lastOutput)
+		if last == dumpedAt || time.Since(time.Unix(0, last)) < timeout {
+			continue
+		}
+		dumpedAt = last
+
+		warning := fmt.Sprintf("The engine has produced no output for %s and may be hung", timeout)
+		fmt.Println("\n[WARN]:", warning)
+		progress.Warning(warning)
+
+		if dumpPath, err := saveThreadDump(client, containerId, dumpDirectory); err != nil {
+			fmt.Println("[WARN]: Cannot capture a thread dump of the engine:", err)
+		} else {
+			fmt.Println("> Thread dump of the engine saved to:", dumpPath)
+			fmt.Println("> Attach it when reporting the issue here:", config.AppConfig.PrivadoRepository)
+		}
+		fmt.Println("> Press Ctrl+C to abort the scan, or wait if the scan is expected to take longer (--hang-timeout)")
+	}
+}
+
+// Captures the threads of the engine and writes them to a new file in the
+// directory, returns its path
+func saveThreadDump(client *client.Client, containerId string, directory string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), threadDumpTimeout)
+	defer cancel()
+
+	done := debugCall("ContainerExecCreate", containerId)
+	execResponse, err := client.ContainerExecCreate(ctx, containerId, types.ExecConfig{
+		Cmd:          threadDumpCommand,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	done(err)
+	if err != nil {
+		return "", err
+	}
+
+	done = debugCall("ContainerExecAttach", execResponse.ID)
+	attachment, err := client.ContainerExecAttach(ctx, execResponse.ID, types.ExecStartCheck{})
+	done(err)
+	if err != nil {
+		return "", err
+	}
+	defer attachment.Close()
+
+	dump := bytes.Buffer{}
+	if _, err := stdcopy.StdCopy(&dump, &dump, attachment.Reader); err != nil {
+		return "", err
+	}
+	if err := waitForExec(client, ctx, execResponse.ID); err != nil {
+		return "", fmt.Errorf("%s: %s", err, bytes.TrimSpace(dump.Bytes()))
+	}
+
+	if err := os.MkdirAll(directory, os.ModePerm); err != nil {
+		return "", err
+	}
+	shortId := containerId
+	if len(shortId) > 12 {
+		shortId = shortId[:12]
+	}
+	dumpPath := filepath.Join(directory, fmt.Sprintf("thread-dump-%s-%s.txt", time.Now().Format("20060102-150405"), shortId))
+	if err := os.WriteFile(dumpPath, dump.Bytes(), 0644); err != nil {
+		return "", err
+	}
+	return dumpPath, nil
+}
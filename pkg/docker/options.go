@@ -24,24 +24,72 @@ package docker
 
 import (
 	"fmt"
+	"path"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+	"github.com/Privado-Inc/privado-cli/pkg/progress"
 	"github.com/Privado-Inc/privado-cli/pkg/telemetry"
 )
 
 type containerVolumes struct {
 	userKeyVolumeEnabled, dockerKeyVolumeEnabled, sourceCodeVolumeEnabled,
 	externalRulesVolumeEnabled, userConfigVolumeEnabled, m2PackageCacheVolumeEnabled,
-	gradlePackageCacheVolumeEnabled bool
+	gradlePackageCacheVolumeEnabled, npmPackageCacheVolumeEnabled,
+	jvmCacheVolumeEnabled, jvmCacheVolumeReadOnly bool
 
 	userKeyVolumeHost, dockerKeyVolumeHost, sourceCodeVolumeHost,
 	externalRulesVolumeHost, userConfigVolumeHost, m2PackageCacheVolumeHost,
-	gradlePackageCacheVolumeHost string
+	gradlePackageCacheVolumeHost, npmPackageCacheVolumeHost,
+	jvmCacheVolumeHost string
+
+	// directories of the source code (relative) hidden from the container
+	maskedSourceDirectories []string
+
+	// files of the source code (relative) replaced by an empty file (host)
+	maskedSourceFiles           []string
+	maskedSourceFileReplacement string
+
+	// host directories mounted (read only) at the same path, e.g. the
+	// git directory of a worktree checkout
+	sharedDirectories []string
+
+	// directories outside of the source code it references (read only)
+	additionalSourceRoots []SourceRoot
+}
+
+// A directory outside of the source code, mounted at its path relative to
+// the source code, e.g. ../shared
+type SourceRoot struct {
+	HostPath, RelativePath string
+}
+
+// Returns the path the additional source root is mounted at in the
+// container; false if it is not next to the source code (in its parent
+// directory) or conflicts with a directory of the container
+func GetSourceRootTarget(relativePath string) (string, bool) {
+	containerConfig := config.AppConfig.Container
+	target := path.Join(containerConfig.SourceCodeVolumeDir, relativePath)
+	parent := path.Dir(containerConfig.SourceCodeVolumeDir)
+	if !strings.HasPrefix(target, parent+"/") {
+		return "", false
+	}
+	for _, directory := range []string{containerConfig.SourceCodeVolumeDir, containerConfig.InternalRulesVolumeDir, containerConfig.ExternalRulesVolumeDir, path.Dir(containerConfig.UserKeyVolumeDir), path.Dir(containerConfig.UserConfigVolumeDir)} {
+		if target == directory || strings.HasPrefix(target, directory+"/") || strings.HasPrefix(directory, target+"/") {
+			return "", false
+		}
+	}
+	return target, true
 }
 
 type EnvVar struct {
 	Key, Value string
+
+	// secret values are passed to the container but never recorded
+	Secret bool
 }
 
 type RunImageOption func(opts *runImageHandler)
@@ -52,12 +100,22 @@ type runImageHandler struct {
 	args                                []string
 	volumes                             containerVolumes
 	environmentVars                     []string
+	jvmOptions                          []string
 	setupInterrupt                      bool
 	attachOutput                        bool
 	spawnWebBrowserOnURLMessage         bool
 	spawnWebBrowserOnURLTriggerMessages []string
 	exitOnError                         bool
 	exitOnErrorTriggerMessages          []string
+	outputListeners                     []containerOutputProcessor
+	engineLogPath                       string
+	showEngineStderr                    bool
+	memoryLimit                         int64
+	memoryWatchdog                      bool
+	statsInterval                       time.Duration
+	hangTimeout                         time.Duration
+	threadDumpDirectory                 string
+	debugArtifactsDirectory             string
 }
 
 func newRunImageHandler(opts []RunImageOption) runImageHandler {
@@ -111,6 +169,41 @@ func OptionWithSourceVolume(volumeHost string) RunImageOption {
 	}
 }
 
+// Hides the directories (relative to the source code volume) from the
+// scan by mounting empty directories over them
+func OptionWithMaskedSourceDirectories(directories []string) RunImageOption {
+	return func(rh *runImageHandler) {
+		rh.volumes.maskedSourceDirectories = directories
+	}
+}
+
+// Hides the files (relative to the source code volume) from the scan by
+// mounting the (empty) replacement file over them
+func OptionWithMaskedSourceFiles(files []string, replacementHost string) RunImageOption {
+	return func(rh *runImageHandler) {
+		rh.volumes.maskedSourceFiles = files
+		rh.volumes.maskedSourceFileReplacement = replacementHost
+	}
+}
+
+// Mounts directories outside of the source code it references (read only),
+// at their paths relative to the source code (see GetSourceRootTarget)
+func OptionWithAdditionalSourceRoots(sourceRoots []SourceRoot) RunImageOption {
+	return func(rh *runImageHandler) {
+		rh.volumes.additionalSourceRoots = sourceRoots
+	}
+}
+
+// Mounts the host directory at the same path in the container (read only),
+// so absolute references to it (e.g. the .git file of a worktree) resolve
+func OptionWithSharedDirectory(directory string) RunImageOption {
+	return func(rh *runImageHandler) {
+		if directory != "" {
+			rh.volumes.sharedDirectories = append(rh.volumes.sharedDirectories, directory)
+		}
+	}
+}
+
 func OptionWithExternalRulesVolume(volumeHost string) RunImageOption {
 	return func(rh *runImageHandler) {
 		if volumeHost != "" {
@@ -126,7 +219,7 @@ func OptionWithExternalRulesVolume(volumeHost string) RunImageOption {
 // make any specific changes related to M2 package volume cache
 func OptionWithPackageCacheVolumes() RunImageOption {
 	return func(rh *runImageHandler) {
-		for _, pkg := range []string{"m2", "gradle"} {
+		for _, pkg := range []string{"m2", "gradle", "npm"} {
 			if hostVolumeForCache, err := config.GetPackageCacheDirectory(pkg); err == nil {
 				if pkg == "m2" {
 					rh.volumes.m2PackageCacheVolumeEnabled = true
@@ -134,16 +227,43 @@ func OptionWithPackageCacheVolumes() RunImageOption {
 				} else if pkg == "gradle" {
 					rh.volumes.gradlePackageCacheVolumeEnabled = true
 					rh.volumes.gradlePackageCacheVolumeHost = hostVolumeForCache
+				} else if pkg == "npm" {
+					rh.volumes.npmPackageCacheVolumeEnabled = true
+					rh.volumes.npmPackageCacheVolumeHost = hostVolumeForCache
 				}
 			} else {
 				warningMsg := fmt.Sprintf("Could not get package cache directory for pkg %s. skipping volume mount: %v", pkg, err)
 				fmt.Println("[WARN]: ", warningMsg)
 				telemetry.DefaultInstance.RecordArrayMetric("warning", warningMsg)
+				progress.Warning(warningMsg)
 			}
 		}
 	}
 }
 
+// Starts the engine with its class archive (class data sharing) in the
+// cache directory, if it exists, or creates it when the engine exits
+// (privado preload). The options are added to JAVA_TOOL_OPTIONS and
+// ignored by JVMs that do not support them
+func OptionWithJVMClassArchive(cacheDirectory, archiveName string, create bool) RunImageOption {
+	return func(rh *runImageHandler) {
+		if archiveName == "" {
+			return
+		}
+		archivePath := path.Join(config.AppConfig.Container.JVMCacheVolumeDir, archiveName)
+		if create {
+			rh.jvmOptions = append(rh.jvmOptions, "-XX:+IgnoreUnrecognizedVMOptions", "-XX:ArchiveClassesAtExit="+archivePath)
+		} else if exists, _ := fileutils.DoesFileExists(filepath.Join(cacheDirectory, archiveName)); exists {
+			rh.jvmOptions = append(rh.jvmOptions, "-XX:+IgnoreUnrecognizedVMOptions", "-Xshare:auto", "-XX:SharedArchiveFile="+archivePath)
+		} else {
+			return
+		}
+		rh.volumes.jvmCacheVolumeEnabled = true
+		rh.volumes.jvmCacheVolumeHost = cacheDirectory
+		rh.volumes.jvmCacheVolumeReadOnly = !create
+	}
+}
+
 func OptionWithIgnoreDefaultRules(ignoreDefaultRules bool) RunImageOption {
 	return func(rh *runImageHandler) {
 		if ignoreDefaultRules {
@@ -160,6 +280,22 @@ func OptionWithSkipDependencyDownload(skipDependencyDownload bool) RunImageOptio
 	}
 }
 
+// Skips the dependency download of the package managers only
+func OptionWithSkippedDependencyManagers(managers []string) RunImageOption {
+	return func(rh *runImageHandler) {
+		if len(managers) > 0 {
+			rh.args = append(rh.args, "--skip-dependency-download-for", strings.Join(managers, ","))
+		}
+	}
+}
+
+// Runs the dependency download phase only, without analysis (privado prefetch)
+func OptionWithDependencyDownloadOnly() RunImageOption {
+	return func(rh *runImageHandler) {
+		rh.entrypoint = []string{config.AppConfig.Container.PrivadoCoreBinPath, "prefetch"}
+	}
+}
+
 func OptionWithDisabledDeduplication(disableDeduplication bool) RunImageOption {
 	return func(rh *runImageHandler) {
 		if disableDeduplication {
@@ -172,13 +308,19 @@ func OptionWithEnvironmentVariables(envVars []EnvVar) RunImageOption {
 	return func(rh *runImageHandler) {
 		if len(envVars) > 0 {
 			processedEnvStrings := []string{}
+			recordedEnvStrings := []string{}
 			for _, envVar := range envVars {
 				if envVar.Key != "" && envVar.Value != "" {
 					processedEnvStrings = append(processedEnvStrings, fmt.Sprintf("%s=%s", envVar.Key, envVar.Value))
+					if envVar.Secret {
+						recordedEnvStrings = append(recordedEnvStrings, fmt.Sprintf("%s=<redacted>", envVar.Key))
+					} else {
+						recordedEnvStrings = append(recordedEnvStrings, fmt.Sprintf("%s=%s", envVar.Key, envVar.Value))
+					}
 				}
 			}
 			rh.environmentVars = processedEnvStrings
-			telemetry.DefaultInstance.RecordAtomicMetric("env", processedEnvStrings)
+			telemetry.DefaultInstance.RecordAtomicMetric("env", recordedEnvStrings)
 		}
 	}
 }
@@ -198,10 +340,10 @@ func OptionWithAttachedOutput() RunImageOption {
 // listens for these message (we use strings.Contains)
 // and spawns a browser with url in the message
 // the messagePrefix must contain a URL for autospawn
-// or this is silently ignored
+// or this is silently ignored; no messages to not spawn a browser
 func OptionWithAutoSpawnBrowserOnURLMessages(messages []string) RunImageOption {
 	return func(rh *runImageHandler) {
-		rh.spawnWebBrowserOnURLMessage = true
+		rh.spawnWebBrowserOnURLMessage = len(messages) > 0
 		rh.spawnWebBrowserOnURLTriggerMessages = messages
 	}
 }
@@ -213,6 +355,14 @@ func OptionWithExitErrorMessages(messages []string) RunImageOption {
 	}
 }
 
+// calls the listener with each output line containing one of the
+// messages (strings.Contains); lines are processed concurrently
+func OptionWithOutputListener(messages []string, listener func(line string)) RunImageOption {
+	return func(rh *runImageHandler) {
+		rh.outputListeners = append(rh.outputListeners, containerOutputProcessor{messages: messages, matchFn: listener})
+	}
+}
+
 func OptionWithDebug(isDebug bool) RunImageOption {
 	return func(rh *runImageHandler) {
 		// currently only enable output in debug mode
@@ -223,6 +373,65 @@ func OptionWithDebug(isDebug bool) RunImageOption {
 	}
 }
 
+// Forwards the log level (error, warn, info, debug, trace) to privado-core
+func OptionWithEngineLogLevel(level string) RunImageOption {
+	return func(rh *runImageHandler) {
+		if level != "" {
+			rh.args = append(rh.args, fmt.Sprintf("-Dlog4j2.level=%s", strings.ToUpper(level)))
+		}
+	}
+}
+
+// Separates the engine output: stdout is shown as usual, stderr (engine
+// logs) only when showStderr; both are processed and written to the log
+// file at logPath
+func OptionWithEngineLog(logPath string, showStderr bool) RunImageOption {
+	return func(rh *runImageHandler) {
+		rh.engineLogPath = logPath
+		rh.showEngineStderr = showStderr
+	}
+}
+
+// Limits the memory of the container (bytes), 0 for no limit
+func OptionWithMemoryLimit(limit int64) RunImageOption {
+	return func(rh *runImageHandler) {
+		rh.memoryLimit = limit
+	}
+}
+
+// Warns when the memory usage of the container approaches its limit, and
+// detects when the engine runs out of memory (see ContainerExitError)
+func OptionWithMemoryWatchdog() RunImageOption {
+	return func(rh *runImageHandler) {
+		rh.memoryWatchdog = true
+	}
+}
+
+// Prints the cpu and memory usage of the container and the elapsed
+// time every interval while waiting for it
+func OptionWithStats(interval time.Duration) RunImageOption {
+	return func(rh *runImageHandler) {
+		rh.statsInterval = interval
+	}
+}
+
+// Captures a thread dump of the engine to the directory when the container
+// produces no output for timeout (0 to disable)
+func OptionWithHangDetection(timeout time.Duration, threadDumpDirectory string) RunImageOption {
+	return func(rh *runImageHandler) {
+		rh.hangTimeout = timeout
+		rh.threadDumpDirectory = threadDumpDirectory
+	}
+}
+
+// Copies the debug artifacts of the engine (see DebugArtifactDirs) from
+// the container to the directory after it exits, empty to not copy them
+func OptionWithDebugArtifacts(directory string) RunImageOption {
+	return func(rh *runImageHandler) {
+		rh.debugArtifactsDirectory = directory
+	}
+}
+
 func OptionWithEntrypoint(entrypoint []string) RunImageOption {
 	return func(rh *runImageHandler) {
 		rh.entrypoint = entrypoint
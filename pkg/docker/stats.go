@@ -0,0 +1,178 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+	"github.com/Privado-Inc/privado-cli/pkg/progress"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+)
+
+// Samples the memory usage of the running containers of the image created
+// after since (e.g. by a scan in another process) until stop is closed, and
+// returns the peak usage in bytes. Usage excludes the page cache, like 'docker stats'
+func MonitorPeakMemory(image string, since time.Time, interval time.Duration, stop <-chan struct{}) (uint64, error) {
+	client, err := getDefaultDockerClient()
+	if err != nil {
+		return 0, err
+	}
+	defer client.Close()
+
+	peak := uint64(0)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		containers, err := client.ContainerList(context.Background(), types.ContainerListOptions{})
+		if err != nil {
+			return peak, err
+		}
+		for _, container := range containers {
+			if container.Image != image || time.Unix(container.Created, 0).Before(since.Truncate(time.Second)) {
+				continue
+			}
+			if usage, _, err := getMemoryUsage(client, container.ID); err == nil && usage > peak {
+				peak = usage
+			}
+		}
+
+		select {
+		case <-stop:
+			return peak, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Resource usage of a container: cpu in percent of one core (like
+// 'docker stats', e.g. 400 for four busy cores), memory in bytes
+type resourceUsage struct {
+	CPUPercent  float64
+	Memory      uint64
+	MemoryLimit uint64
+}
+
+// Returns the memory usage and limit of the container (the memory
+// available to docker if it has no limit) in bytes
+func getMemoryUsage(client *client.Client, containerId string) (uint64, uint64, error) {
+	usage, err := getResourceUsage(client, containerId)
+	if err != nil {
+		return 0, 0, err
+	}
+	return usage.Memory, usage.MemoryLimit, nil
+}
+
+func getResourceUsage(client *client.Client, containerId string) (resourceUsage, error) {
+	stats, err := client.ContainerStats(context.Background(), containerId, false)
+	if err != nil {
+		return resourceUsage{}, err
+	}
+	defer stats.Body.Close()
+
+	sample := types.Stats{}
+	if err := json.NewDecoder(stats.Body).Decode(&sample); err != nil {
+		return resourceUsage{}, err
+	}
+
+	// the cpu usage is the share of the system cpu time used by the
+	// container since the previous sample (taken by docker a second before)
+	cpuPercent := 0.0
+	cpuDelta := float64(sample.CPUStats.CPUUsage.TotalUsage) - float64(sample.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(sample.CPUStats.SystemUsage) - float64(sample.PreCPUStats.SystemUsage)
+	onlineCPUs := float64(sample.CPUStats.OnlineCPUs)
+	if onlineCPUs == 0 {
+		onlineCPUs = float64(len(sample.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if cpuDelta > 0 && systemDelta > 0 {
+		cpuPercent = cpuDelta / systemDelta * onlineCPUs * 100
+	}
+
+	usage := sample.MemoryStats.Usage
+	// cgroup v1 reports the cache as total_inactive_file, v2 as inactive_file
+	cache := sample.MemoryStats.Stats["total_inactive_file"]
+	if cache == 0 {
+		cache = sample.MemoryStats.Stats["inactive_file"]
+	}
+	if cache < usage {
+		usage -= cache
+	}
+	return resourceUsage{CPUPercent: cpuPercent, Memory: usage, MemoryLimit: sample.MemoryStats.Limit}, nil
+}
+
+// Prints the cpu and memory usage of the container and the elapsed time
+// every interval until stop is closed, so long scans show whether the
+// engine is still working
+func showStats(client *client.Client, containerId string, interval time.Duration, stop <-chan struct{}) {
+	start := time.Now()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		usage, err := getResourceUsage(client, containerId)
+		if err != nil {
+			continue
+		}
+		memory := fileutils.FormatSize(int64(usage.Memory))
+		if usage.MemoryLimit > 0 {
+			memory = fmt.Sprintf("%s / %s", memory, fileutils.FormatSize(int64(usage.MemoryLimit)))
+		}
+		fmt.Printf("> [stats] elapsed %s, cpu %.0f%%, memory %s\n", time.Since(start).Truncate(time.Second), usage.CPUPercent, memory)
+	}
+}
+
+// share of the memory limit above which the memory watchdog warns
+const memoryWarningRatio = 0.9
+
+// Samples the memory usage of the container until stop is closed, and
+// warns once when it exceeds memoryWarningRatio of the limit
+func watchMemory(client *client.Client, containerId string, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		usage, limit, err := getMemoryUsage(client, containerId)
+		if err != nil || limit == 0 || float64(usage) < memoryWarningRatio*float64(limit) {
+			continue
+		}
+		warning := fmt.Sprintf("The engine uses %s of %s of memory (%.0f%%) and may run out of memory; if it fails, retry with more memory (--engine-memory, or the memory of docker)",
+			fileutils.FormatSize(int64(usage)), fileutils.FormatSize(int64(limit)), 100*float64(usage)/float64(limit))
+		fmt.Println("\n[WARN]:", warning)
+		progress.Warning(warning)
+		return
+	}
+}
@@ -0,0 +1,95 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+package docs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
+)
+
+type ManHeader struct {
+	Section string
+	Source  string
+	Manual  string
+	Date    time.Time
+}
+
+// Writes a man page per command (e.g. privado-scan.1) to directory.
+// Returns the paths of the written pages
+func GenerateManPages(root *cobra.Command, directory string, header ManHeader, exitCodes []ExitCode) ([]string, error) {
+	if err := os.MkdirAll(directory, os.ModePerm); err != nil {
+		return nil, err
+	}
+	if header.Section == "" {
+		header.Section = "1"
+	}
+	commands := documentedCommands(root)
+	if err := doc.GenManTree(root, &doc.GenManHeader{
+		Section: header.Section,
+		Source:  header.Source,
+		Manual:  header.Manual,
+		Date:    &header.Date,
+	}, directory); err != nil {
+		return nil, err
+	}
+
+	paths := []string{}
+	for _, command := range commands {
+		path := filepath.Join(directory, fmt.Sprintf("%s.%s", baseName(command, "-"), header.Section))
+		if command.Runnable() && len(exitCodes) > 0 {
+			if err := appendToFile(path, renderManExitCodes(exitCodes)); err != nil {
+				return paths, err
+			}
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// escapes text for troff: backslashes, dashes, and control characters
+// (. and ') at the start of a line
+func escapeTroff(text string) string {
+	text = strings.ReplaceAll(text, `\`, `\e`)
+	text = strings.ReplaceAll(text, "-", `\-`)
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = `\&` + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func renderManExitCodes(exitCodes []ExitCode) string {
+	builder := &strings.Builder{}
+	builder.WriteString(".SH EXIT STATUS\n")
+	for _, exitCode := range exitCodes {
+		fmt.Fprintf(builder, ".TP\n\\fB%d\\fP (%s)\n%s\n", exitCode.Code, escapeTroff(exitCode.Outcome), escapeTroff(exitCode.Description))
+	}
+	return builder.String()
+}
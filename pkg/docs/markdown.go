@@ -0,0 +1,103 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+package docs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
+)
+
+// Reference documentation (man pages, markdown) generated from the command
+// tree with cobra, so flags and descriptions never drift from the cli
+// itself. Pages of runnable commands get a section on exit codes
+
+type ExitCode struct {
+	Code        int
+	Outcome     string
+	Description string
+}
+
+// Returns the commands of the tree pages are generated for, like cobra
+// (depth first, parents first). The pages are generated without the
+// "auto generated" footer, so they are reproducible
+func documentedCommands(root *cobra.Command) []*cobra.Command {
+	root.DisableAutoGenTag = true
+	commands := []*cobra.Command{root}
+	for _, child := range root.Commands() {
+		if child.IsAvailableCommand() && !child.IsAdditionalHelpTopicCommand() {
+			commands = append(commands, documentedCommands(child)...)
+		}
+	}
+	return commands
+}
+
+func baseName(command *cobra.Command, separator string) string {
+	return strings.ReplaceAll(command.CommandPath(), " ", separator)
+}
+
+func appendToFile(path, content string) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.WriteString(content)
+	return err
+}
+
+// Writes a markdown reference page per command (e.g. privado_scan.md) to
+// directory. Returns the paths of the written pages
+func GenerateMarkdown(root *cobra.Command, directory string, exitCodes []ExitCode) ([]string, error) {
+	if err := os.MkdirAll(directory, os.ModePerm); err != nil {
+		return nil, err
+	}
+	commands := documentedCommands(root)
+	if err := doc.GenMarkdownTree(root, directory); err != nil {
+		return nil, err
+	}
+
+	paths := []string{}
+	for _, command := range commands {
+		path := filepath.Join(directory, baseName(command, "_")+".md")
+		if command.Runnable() && len(exitCodes) > 0 {
+			if err := appendToFile(path, renderMarkdownExitCodes(exitCodes)); err != nil {
+				return paths, err
+			}
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+func renderMarkdownExitCodes(exitCodes []ExitCode) string {
+	builder := &strings.Builder{}
+	builder.WriteString("### Exit codes\n\n| Code | Outcome | Description |\n| --- | --- | --- |\n")
+	for _, exitCode := range exitCodes {
+		fmt.Fprintf(builder, "| %d | %s | %s |\n", exitCode.Code, exitCode.Outcome, strings.ReplaceAll(exitCode.Description, "|", `\|`))
+	}
+	return builder.String()
+}
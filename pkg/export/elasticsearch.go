@@ -0,0 +1,116 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package export
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type Elasticsearch struct {
+	URL       string
+	Index     string
+	APIKey    string
+	Username  string
+	Password  string
+	BatchSize int
+}
+
+func (e *Elasticsearch) Name() string {
+	return "elasticsearch"
+}
+
+// Indexes the documents with the bulk api; documents with the same id are replaced
+func (e *Elasticsearch) Export(documents []Document) error {
+	for _, batch := range batches(documents, e.BatchSize) {
+		body := &bytes.Buffer{}
+		encoder := json.NewEncoder(body)
+		for _, document := range batch {
+			action := map[string]interface{}{"index": map[string]string{"_index": e.Index, "_id": document.Id}}
+			if err := encoder.Encode(action); err != nil {
+				return err
+			}
+			if err := encoder.Encode(document); err != nil {
+				return err
+			}
+		}
+
+		request, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(e.URL, "/")+"/_bulk", body)
+		if err != nil {
+			return err
+		}
+		request.Header.Set("Content-Type", "application/x-ndjson")
+		if e.APIKey != "" {
+			request.Header.Set("Authorization", "ApiKey "+e.APIKey)
+		} else if e.Username != "" {
+			request.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(e.Username+":"+e.Password)))
+		}
+
+		responseBody, err := post(request)
+		if err != nil {
+			return err
+		}
+		if err := getBulkError(responseBody); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// The bulk api responds with 200 even if documents were not indexed
+func getBulkError(responseBody []byte) error {
+	response := struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			Status int `json:"status"`
+			Error  struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"items"`
+	}{}
+	if err := json.Unmarshal(responseBody, &response); err != nil {
+		return fmt.Errorf("cannot parse bulk response: %v", err)
+	}
+	if !response.Errors {
+		return nil
+	}
+
+	failed := 0
+	reason := ""
+	for _, item := range response.Items {
+		for _, result := range item {
+			if result.Status > 299 {
+				failed++
+				if reason == "" {
+					reason = fmt.Sprintf("%s: %s", result.Error.Type, result.Error.Reason)
+				}
+			}
+		}
+	}
+	return fmt.Errorf("%d document(s) not indexed (%s)", failed, reason)
+}
@@ -0,0 +1,138 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package export
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Privado-Inc/privado-cli/pkg/auth"
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+)
+
+// Exports findings as documents to the systems security teams aggregate
+// events in (SIEM). Documents have stable ids, derived from the repository,
+// branch and finding fingerprint, so re-exporting a scan updates documents
+// instead of duplicating them (where the destination supports it)
+
+const defaultBatchSize = 500
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+type Exporter interface {
+	Name() string
+	Export(documents []Document) error
+}
+
+// Scan the findings were reported by
+type ScanInfo struct {
+	Repository string
+	RemoteURL  string
+	Branch     string
+	Commit     string
+	Timestamp  time.Time
+	CLIVersion string
+}
+
+// A finding with the scan it was reported by; code snippets are not exported
+type Document struct {
+	Id          string    `json:"id"`
+	Timestamp   time.Time `json:"@timestamp"`
+	FindingId   string    `json:"findingId"`
+	Repository  string    `json:"repository"`
+	RemoteURL   string    `json:"remoteUrl,omitempty"`
+	Branch      string    `json:"branch,omitempty"`
+	Commit      string    `json:"commit,omitempty"`
+	PolicyId    string    `json:"policyId"`
+	PolicyName  string    `json:"policyName"`
+	PolicyType  string    `json:"policyType"`
+	Description string    `json:"description"`
+	Severity    string    `json:"severity"`
+	SourceId    string    `json:"sourceId"`
+	SinkId      string    `json:"sinkId,omitempty"`
+	FileName    string    `json:"fileName,omitempty"`
+	LineNumber  int       `json:"lineNumber,omitempty"`
+	CLIVersion  string    `json:"cliVersion"`
+}
+
+func NewDocuments(scan ScanInfo, findings []results.Finding) []Document {
+	documents := []Document{}
+	for _, finding := range findings {
+		documents = append(documents, Document{
+			Id:          auth.CalculateSHA256Hash(strings.Join([]string{scan.Repository, scan.Branch, finding.Id}, "|"))[:32],
+			Timestamp:   scan.Timestamp,
+			FindingId:   finding.Id,
+			Repository:  scan.Repository,
+			RemoteURL:   scan.RemoteURL,
+			Branch:      scan.Branch,
+			Commit:      scan.Commit,
+			PolicyId:    finding.PolicyId,
+			PolicyName:  finding.PolicyName,
+			PolicyType:  finding.PolicyType,
+			Description: finding.Description,
+			Severity:    finding.Severity,
+			SourceId:    finding.SourceId,
+			SinkId:      finding.SinkId,
+			FileName:    filepath.ToSlash(finding.RelativeFileName()),
+			LineNumber:  finding.LineNumber,
+			CLIVersion:  scan.CLIVersion,
+		})
+	}
+	return documents
+}
+
+func batches(documents []Document, size int) [][]Document {
+	if size <= 0 {
+		size = defaultBatchSize
+	}
+	all := [][]Document{}
+	for start := 0; start < len(documents); start += size {
+		end := start + size
+		if end > len(documents) {
+			end = len(documents)
+		}
+		all = append(all, documents[start:end])
+	}
+	return all
+}
+
+func post(request *http.Request) ([]byte, error) {
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, 10<<20))
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return body, fmt.Errorf("received status %d: %s", response.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
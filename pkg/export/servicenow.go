@@ -0,0 +1,193 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+)
+
+// Files a record in a ServiceNow table for each finding, and updates it on
+// re-exports: records are matched by the document id, stored in the
+// correlation field. Values of the fields are templates over the document
+// (e.g. "{{.PolicyName}} in {{.Repository}}")
+
+const (
+	defaultServiceNowTable            = "incident"
+	defaultServiceNowCorrelationField = "correlation_id"
+)
+
+var defaultServiceNowFields = map[string]string{
+	"short_description": "Privado: {{.PolicyName}} ({{.Repository}})",
+	"description": "{{.Description}}\n\nPolicy: {{.PolicyName}} ({{.PolicyId}})\nSeverity: {{.Severity}}\nRepository: {{.Repository}}" +
+		"{{if .Branch}} ({{.Branch}}){{end}}\n{{if .FileName}}File: {{.FileName}}:{{.LineNumber}}\n{{end}}Finding: {{.FindingId}}",
+	"urgency": "{{urgency .Severity}}",
+	"impact":  "{{urgency .Severity}}",
+}
+
+var serviceNowTemplateFunctions = template.FuncMap{
+	// ServiceNow urgency (and impact): 1 (high) to 3 (low)
+	"urgency": func(severity string) string {
+		switch severity {
+		case "high":
+			return "1"
+		case "medium":
+			return "2"
+		}
+		return "3"
+	},
+}
+
+type ServiceNow struct {
+	// instance url, e.g. https://company.service-now.com
+	URL   string
+	Table string
+	// basic authentication, or an OAuth token
+	Username string
+	Password string
+	Token    string
+	// field storing the document id, to find the records of re-exported findings
+	CorrelationField string
+	// templates of the fields of the records, replacing the defaults
+	Fields map[string]string
+}
+
+func (s *ServiceNow) Name() string {
+	return "servicenow"
+}
+
+func (s *ServiceNow) Export(documents []Document) error {
+	fieldTemplates := s.Fields
+	if len(fieldTemplates) == 0 {
+		fieldTemplates = defaultServiceNowFields
+	}
+	templates := map[string]*template.Template{}
+	for field, text := range fieldTemplates {
+		t, err := template.New(field).Funcs(serviceNowTemplateFunctions).Parse(text)
+		if err != nil {
+			return fmt.Errorf("invalid template of the field %s: %v", field, err)
+		}
+		templates[field] = t
+	}
+
+	for _, document := range documents {
+		record := map[string]string{s.getCorrelationField(): document.Id}
+		for field, t := range templates {
+			value := &strings.Builder{}
+			if err := t.Execute(value, document); err != nil {
+				return fmt.Errorf("cannot render the field %s: %v", field, err)
+			}
+			record[field] = value.String()
+		}
+
+		sysId, err := s.findRecord(document.Id)
+		if err != nil {
+			return err
+		}
+		if sysId == "" {
+			err = s.send(http.MethodPost, s.getTableURL(), record)
+		} else {
+			err = s.send(http.MethodPatch, s.getTableURL()+"/"+url.PathEscape(sysId), record)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *ServiceNow) getTableURL() string {
+	table := s.Table
+	if table == "" {
+		table = defaultServiceNowTable
+	}
+	return fmt.Sprintf("%s/api/now/table/%s", strings.TrimSuffix(s.URL, "/"), url.PathEscape(table))
+}
+
+func (s *ServiceNow) getCorrelationField() string {
+	if s.CorrelationField != "" {
+		return s.CorrelationField
+	}
+	return defaultServiceNowCorrelationField
+}
+
+// Returns the sys_id of the record of the document, empty if there is none
+func (s *ServiceNow) findRecord(documentId string) (string, error) {
+	query := url.Values{}
+	query.Set("sysparm_query", fmt.Sprintf("%s=%s", s.getCorrelationField(), documentId))
+	query.Set("sysparm_fields", "sys_id")
+	query.Set("sysparm_limit", "1")
+
+	request, err := http.NewRequest(http.MethodGet, s.getTableURL()+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	s.authorize(request)
+
+	body, err := post(request)
+	if err != nil {
+		return "", err
+	}
+	response := struct {
+		Result []struct {
+			SysId string `json:"sys_id"`
+		} `json:"result"`
+	}{}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", fmt.Errorf("cannot parse records: %v", err)
+	}
+	if len(response.Result) == 0 {
+		return "", nil
+	}
+	return response.Result[0].SysId, nil
+}
+
+func (s *ServiceNow) send(method, endpoint string, record map[string]string) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequest(method, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	s.authorize(request)
+
+	_, err = post(request)
+	return err
+}
+
+func (s *ServiceNow) authorize(request *http.Request) {
+	request.Header.Set("Accept", "application/json")
+	if s.Token != "" {
+		request.Header.Set("Authorization", "Bearer "+s.Token)
+	} else {
+		request.SetBasicAuth(s.Username, s.Password)
+	}
+}
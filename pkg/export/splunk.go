@@ -0,0 +1,89 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+type Splunk struct {
+	URL        string
+	Token      string
+	Index      string
+	Source     string
+	SourceType string
+	BatchSize  int
+}
+
+func (s *Splunk) Name() string {
+	return "splunk"
+}
+
+type splunkEvent struct {
+	Time       float64  `json:"time"`
+	Index      string   `json:"index,omitempty"`
+	Source     string   `json:"source,omitempty"`
+	SourceType string   `json:"sourcetype,omitempty"`
+	Event      Document `json:"event"`
+}
+
+// Sends the documents as events to the HTTP Event Collector. Splunk does not
+// replace events, re-exported findings can be deduplicated by their id (| dedup id)
+func (s *Splunk) Export(documents []Document) error {
+	sourceType := s.SourceType
+	if sourceType == "" {
+		sourceType = "privado:finding"
+	}
+
+	for _, batch := range batches(documents, s.BatchSize) {
+		body := &bytes.Buffer{}
+		encoder := json.NewEncoder(body)
+		for _, document := range batch {
+			event := splunkEvent{
+				Time:       float64(document.Timestamp.UnixNano()) / 1e9,
+				Index:      s.Index,
+				Source:     s.Source,
+				SourceType: sourceType,
+				Event:      document,
+			}
+			if err := encoder.Encode(event); err != nil {
+				return err
+			}
+		}
+
+		request, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(s.URL, "/")+"/services/collector/event", body)
+		if err != nil {
+			return err
+		}
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("Authorization", "Splunk "+s.Token)
+
+		if _, err := post(request); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package feedback
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+)
+
+// Feedback on findings: false positives are recorded as exclusions of the
+// repository (.privado/exclusions.json, meant to be committed), so they are
+// not reported again, and (with consent) submitted to Privado so recurring
+// false positive patterns can drive rule improvements
+
+const (
+	ExclusionsFileName = "exclusions.json"
+
+	VerdictFalsePositive = "false-positive"
+)
+
+type Exclusion struct {
+	FindingId  string    `json:"findingId"`
+	PolicyId   string    `json:"policyId"`
+	SourceId   string    `json:"sourceId"`
+	SinkId     string    `json:"sinkId,omitempty"`
+	FileName   string    `json:"fileName,omitempty"`
+	Reason     string    `json:"reason"`
+	ExcludedAt time.Time `json:"excludedAt"`
+	// whether the feedback was submitted to Privado
+	Submitted bool `json:"submitted"`
+}
+
+type Exclusions struct {
+	Exclusions []Exclusion `json:"exclusions"`
+}
+
+func LoadExclusions(exclusionsPath string) (*Exclusions, error) {
+	data, err := os.ReadFile(exclusionsPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &Exclusions{Exclusions: []Exclusion{}}, nil
+		}
+		return nil, err
+	}
+
+	e := &Exclusions{}
+	if err := json.Unmarshal(data, e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *Exclusions) Save(exclusionsPath string) error {
+	sort.SliceStable(e.Exclusions, func(i, j int) bool { return e.Exclusions[i].FindingId < e.Exclusions[j].FindingId })
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(exclusionsPath), os.ModePerm); err != nil {
+		return err
+	}
+	return os.WriteFile(exclusionsPath, data, 0644)
+}
+
+// Records the exclusion of the finding, replacing an earlier exclusion of it
+func (e *Exclusions) Add(finding results.Finding, reason string, submitted bool) Exclusion {
+	exclusion := Exclusion{
+		FindingId:  finding.Id,
+		PolicyId:   finding.PolicyId,
+		SourceId:   finding.SourceId,
+		SinkId:     finding.SinkId,
+		FileName:   filepath.ToSlash(finding.RelativeFileName()),
+		Reason:     reason,
+		ExcludedAt: time.Now().UTC(),
+		Submitted:  submitted,
+	}
+	for i := range e.Exclusions {
+		if e.Exclusions[i].FindingId == finding.Id {
+			e.Exclusions[i] = exclusion
+			return exclusion
+		}
+	}
+	e.Exclusions = append(e.Exclusions, exclusion)
+	return exclusion
+}
+
+// Returns the findings that are not excluded, and the excluded ones
+func (e *Exclusions) Filter(findings []results.Finding) (kept, excluded []results.Finding) {
+	ids := map[string]bool{}
+	for _, exclusion := range e.Exclusions {
+		ids[exclusion.FindingId] = true
+	}
+
+	kept, excluded = []results.Finding{}, []results.Finding{}
+	for _, finding := range findings {
+		if ids[finding.Id] {
+			excluded = append(excluded, finding)
+		} else {
+			kept = append(kept, finding)
+		}
+	}
+	return kept, excluded
+}
+
+// Structured feedback on a finding submitted to Privado. The code of the
+// finding is only included when explicitly shared
+type Feedback struct {
+	Verdict            string `json:"verdict"`
+	Reason             string `json:"reason"`
+	FindingId          string `json:"findingId"`
+	PolicyId           string `json:"policyId"`
+	PolicyType         string `json:"policyType"`
+	SourceId           string `json:"sourceId"`
+	SinkId             string `json:"sinkId,omitempty"`
+	FileExtension      string `json:"fileExtension,omitempty"`
+	Sample             string `json:"sample,omitempty"`
+	PrivadoCoreVersion string `json:"privadoCoreVersion,omitempty"`
+	PrivadoCLIVersion  string `json:"privadoCLIVersion"`
+}
+
+func New(scanResults *results.Results, finding results.Finding, verdict, reason, cliVersion string, includeCode bool) Feedback {
+	feedback := Feedback{
+		Verdict:            verdict,
+		Reason:             reason,
+		FindingId:          finding.Id,
+		PolicyId:           finding.PolicyId,
+		PolicyType:         finding.PolicyType,
+		SourceId:           finding.SourceId,
+		SinkId:             finding.SinkId,
+		FileExtension:      filepath.Ext(finding.FileName),
+		PrivadoCoreVersion: scanResults.PrivadoCoreVersion,
+		PrivadoCLIVersion:  cliVersion,
+	}
+	if includeCode {
+		feedback.Sample = finding.Sample
+	}
+	return feedback
+}
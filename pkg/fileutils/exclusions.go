@@ -0,0 +1,166 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package fileutils
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Detection of files that should not be scanned: huge files (data dumps,
+// model weights) and binary files, which exhaust the engine memory
+
+const (
+	ExclusionReasonSize   = "size"
+	ExclusionReasonBinary = "binary"
+)
+
+// bytes inspected to detect binary files (same heuristic as git)
+const binaryDetectionLength = 8000
+
+// binary files the engine resolves dependencies from, never excluded as binary
+var dependencyArchiveExtensions = []string{".jar", ".war", ".ear", ".aar"}
+
+type ExcludedFile struct {
+	// relative to the root, slash separated
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	Reason string `json:"reason"`
+}
+
+// Returns whether the file contains a NUL byte in its first bytes
+func IsBinaryFile(path string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	buffer := make([]byte, binaryDetectionLength)
+	n, err := io.ReadFull(file, buffer)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return false, err
+	}
+	return bytes.IndexByte(buffer[:n], 0) != -1, nil
+}
+
+func isDependencyArchive(path string) bool {
+	extension := strings.ToLower(filepath.Ext(path))
+	for _, archiveExtension := range dependencyArchiveExtensions {
+		if extension == archiveExtension {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns regular files in the directory tree of root larger than maxSize
+// (if > 0) and, if excludeBinary, binary files. Largest files first
+func FindExcludedFiles(root string, maxSize int64, excludeBinary bool, skip func(relativePath string, entry fs.DirEntry) bool) ([]ExcludedFile, error) {
+	excludedFiles := []ExcludedFile{}
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relativePath, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if relativePath != "." && skip != nil && skip(filepath.ToSlash(relativePath), entry) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+		excludedFile := ExcludedFile{Path: filepath.ToSlash(relativePath), Size: info.Size()}
+		if maxSize > 0 && info.Size() > maxSize {
+			excludedFile.Reason = ExclusionReasonSize
+		} else if excludeBinary && info.Size() > 0 && !isDependencyArchive(path) {
+			if isBinary, _ := IsBinaryFile(path); isBinary {
+				excludedFile.Reason = ExclusionReasonBinary
+			}
+		}
+		if excludedFile.Reason != "" {
+			excludedFiles = append(excludedFiles, excludedFile)
+		}
+		return nil
+	})
+
+	sort.SliceStable(excludedFiles, func(i, j int) bool {
+		return excludedFiles[i].Size > excludedFiles[j].Size
+	})
+	return excludedFiles, err
+}
+
+var sizeUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// Parses sizes like "50MB", "1.5GB" or "1024" (bytes)
+func ParseSize(size string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(size))
+	multiplier := int64(1)
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(value, unit.suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix))
+			multiplier = unit.multiplier
+			break
+		}
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("invalid size: %s", size)
+	}
+	return int64(number * float64(multiplier)), nil
+}
+
+func FormatSize(size int64) string {
+	for _, unit := range sizeUnits {
+		if size >= unit.multiplier && unit.multiplier > 1 {
+			return fmt.Sprintf("%.1f%s", float64(size)/float64(unit.multiplier), unit.suffix)
+		}
+	}
+	return fmt.Sprintf("%dB", size)
+}
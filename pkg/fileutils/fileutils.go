@@ -28,9 +28,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/codeclysm/extract/v3"
 )
@@ -70,6 +72,71 @@ func CopyFile(src, dst string) error {
 	return out.Close()
 }
 
+// Copies the directory tree src into dst. Paths (relative to src, slash
+// separated) for which skip returns true are not copied. Symbolic links
+// are copied as links, other special files are skipped
+func CopyDirectory(src, dst string, skip func(relativePath string, entry fs.DirEntry) bool) error {
+	return filepath.WalkDir(src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relativePath, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if relativePath != "." && skip != nil && skip(filepath.ToSlash(relativePath), entry) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		target := filepath.Join(dst, relativePath)
+		switch {
+		case entry.IsDir():
+			return os.MkdirAll(target, os.ModePerm)
+		case entry.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			_ = os.Remove(target)
+			return os.Symlink(link, target)
+		case entry.Type().IsRegular():
+			return CopyFile(path, target)
+		}
+		return nil
+	})
+}
+
+// Returns the total size of regular files in the directory tree of root.
+// Paths for which skip returns true are not counted
+func GetDirectorySize(root string, skip func(relativePath string, entry fs.DirEntry) bool) (int64, error) {
+	size := int64(0)
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relativePath, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if relativePath != "." && skip != nil && skip(filepath.ToSlash(relativePath), entry) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.Type().IsRegular() {
+			if info, err := entry.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size, err
+}
+
 func DoesFileExists(name string) (bool, error) {
 	_, err := os.Stat(name)
 	if err == nil {
@@ -81,13 +148,21 @@ func DoesFileExists(name string) (bool, error) {
 	return false, err
 }
 
+// Returns the absolute path, normalized for the os (see normalizePath)
 func GetAbsolutePath(relativePath string) string {
 
-	fullPath, err := filepath.Abs(relativePath)
+	fullPath, err := filepath.Abs(normalizePath(relativePath))
 	if err != nil {
 		panic(err)
 	}
-	return fullPath
+	return normalizePath(fullPath)
+}
+
+// Returns whether path is directory or within directory; on windows, paths
+// are compared case-insensitively
+func IsWithinDirectory(directory, path string) bool {
+	relativePath, err := filepath.Rel(normalizePath(directory), normalizePath(path))
+	return err == nil && relativePath != ".." && !strings.HasPrefix(relativePath, ".."+string(os.PathSeparator))
 }
 
 func GetPathToCurrentBinary() (string, error) {
@@ -42,3 +42,32 @@ func HasWritePermissionToFile(filePath string) (bool, error) {
 
 	return true, nil
 }
+
+// Returns the space available to the user on the filesystem of path
+func GetAvailableDiskSpace(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
+
+func normalizePath(path string) string {
+	return path
+}
+
+// Returns whether the path is on a network share (UNC path, windows only)
+func IsNetworkPath(path string) bool {
+	return false
+}
+
+// Returns the host path as a bind mount source for the docker daemon
+func GetMountSource(hostPath string) string {
+	return hostPath
+}
+
+// Returns the path a host path is mounted at in a linux container, when
+// it is mounted at "the same path"
+func GetContainerPath(hostPath string) string {
+	return hostPath
+}
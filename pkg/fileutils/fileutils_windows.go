@@ -27,6 +27,11 @@ import (
 	"errors"
 	"io/fs"
 	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
 )
 
 // yields error on unix-based systems after upgrades
@@ -44,3 +49,75 @@ func HasWritePermissionToFile(filePath string) (bool, error) {
 
 	return true, nil
 }
+
+var getDiskFreeSpaceEx = syscall.NewLazyDLL("kernel32.dll").NewProc("GetDiskFreeSpaceExW")
+
+// Returns the space available to the user on the volume of path
+func GetAvailableDiskSpace(path string) (uint64, error) {
+	pathPointer, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var available uint64
+	result, _, err := getDiskFreeSpaceEx.Call(uintptr(unsafe.Pointer(pathPointer)), uintptr(unsafe.Pointer(&available)), 0, 0)
+	if result == 0 {
+		return 0, err
+	}
+	return available, nil
+}
+
+// prefix of extended-length paths, e.g. \\?\C:\Users or \\?\UNC\server\share
+const longPathPrefix = `\\?\`
+
+// Normalizes windows path shapes so paths of the same file compare equal and
+// can be mounted: the extended-length prefix is removed (docker does not
+// support it, go handles long paths itself), msys paths (/c/Users, from git
+// bash) are converted to drive paths and drive letters are upper cased
+func normalizePath(path string) string {
+	if strings.HasPrefix(path, longPathPrefix+`UNC\`) {
+		path = `\\` + strings.TrimPrefix(path, longPathPrefix+`UNC\`)
+	} else {
+		path = strings.TrimPrefix(path, longPathPrefix)
+	}
+
+	if len(path) >= 2 && path[0] == '/' && isDriveLetter(path[1]) && (len(path) == 2 || path[2] == '/') {
+		path = string(path[1]) + ":" + filepath.FromSlash(path[2:])
+		if len(path) == 2 {
+			path += `\`
+		}
+	}
+	if len(path) >= 2 && path[1] == ':' && isDriveLetter(path[0]) {
+		path = strings.ToUpper(path[:1]) + path[1:]
+	}
+	return path
+}
+
+func isDriveLetter(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// Returns whether the path is on a network share (UNC path, \\server\share)
+func IsNetworkPath(path string) bool {
+	return strings.HasPrefix(normalizePath(path), `\\`)
+}
+
+// Returns the host path as a bind mount source for the docker daemon.
+// Docker Desktop translates drive paths (C:\Users) to its host mounts itself
+func GetMountSource(hostPath string) string {
+	return normalizePath(hostPath)
+}
+
+// Returns the path a host path is mounted at in a linux container, when
+// it is mounted at "the same path": C:\Users\x is /c/Users/x (as in git
+// bash and Docker Desktop), \\server\share\x is /unc/server/share/x
+func GetContainerPath(hostPath string) string {
+	hostPath = normalizePath(hostPath)
+	if strings.HasPrefix(hostPath, `\\`) {
+		return "/unc/" + filepath.ToSlash(strings.TrimPrefix(hostPath, `\\`))
+	}
+	if volume := filepath.VolumeName(hostPath); len(volume) == 2 && volume[1] == ':' {
+		return path.Join("/", strings.ToLower(volume[:1]), filepath.ToSlash(hostPath[2:]))
+	}
+	return filepath.ToSlash(hostPath)
+}
@@ -0,0 +1,145 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package fileutils
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+type Symlink struct {
+	// relative to the root, slash separated
+	Path string `json:"path"`
+
+	// as stored in the link
+	Target string `json:"target"`
+
+	Broken      bool `json:"broken,omitempty"`
+	EscapesRoot bool `json:"escapesRoot,omitempty"`
+
+	// the link points to a directory containing the link
+	Cycle bool `json:"cycle,omitempty"`
+}
+
+// Returns symbolic links in the directory tree of root. Paths for which
+// skip returns true are not audited
+func AuditSymlinks(root string, skip func(relativePath string, entry fs.DirEntry) bool) ([]Symlink, error) {
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, err
+	}
+
+	symlinks := []Symlink{}
+	err = filepath.WalkDir(root, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relativePath, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		if relativePath != "." && skip != nil && skip(filepath.ToSlash(relativePath), entry) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.Type()&fs.ModeSymlink == 0 {
+			return nil
+		}
+
+		symlink := Symlink{Path: filepath.ToSlash(relativePath)}
+		symlink.Target, _ = os.Readlink(p)
+		resolved, err := filepath.EvalSymlinks(p)
+		if err != nil {
+			// includes loops of links
+			symlink.Broken = true
+		} else {
+			symlink.EscapesRoot = !IsWithinDirectory(realRoot, resolved)
+			if info, err := os.Stat(resolved); err == nil && info.IsDir() {
+				realParent, _ := filepath.EvalSymlinks(filepath.Dir(p))
+				symlink.Cycle = IsWithinDirectory(resolved, realParent)
+			}
+		}
+		symlinks = append(symlinks, symlink)
+		return nil
+	})
+	return symlinks, err
+}
+
+// Copies the directory tree src into dst like CopyDirectory, but copies
+// the targets of symbolic links instead of the links. Broken links and
+// links to a directory being copied (cycles) are skipped
+func CopyDirectoryFollowingSymlinks(src, dst string, skip func(relativePath string, entry fs.DirEntry) bool) error {
+	realSrc, err := filepath.EvalSymlinks(src)
+	if err != nil {
+		return err
+	}
+	return copyDirectoryFollowingSymlinks(src, dst, "", skip, map[string]bool{realSrc: true})
+}
+
+func copyDirectoryFollowingSymlinks(src, dst, relativeDirectory string, skip func(string, fs.DirEntry) bool, ancestors map[string]bool) error {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dst, os.ModePerm); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		relativePath := path.Join(relativeDirectory, entry.Name())
+		if skip != nil && skip(relativePath, entry) {
+			continue
+		}
+
+		source := filepath.Join(src, entry.Name())
+		target := filepath.Join(dst, entry.Name())
+		realSource, err := filepath.EvalSymlinks(source)
+		if err != nil {
+			continue
+		}
+		info, err := os.Stat(realSource)
+		if err != nil {
+			continue
+		}
+
+		switch {
+		case info.IsDir():
+			if ancestors[realSource] {
+				continue
+			}
+			ancestors[realSource] = true
+			err = copyDirectoryFollowingSymlinks(source, target, relativePath, skip, ancestors)
+			delete(ancestors, realSource)
+		case info.Mode().IsRegular():
+			err = CopyFile(realSource, target)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
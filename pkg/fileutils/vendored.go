@@ -0,0 +1,116 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package fileutils
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Detection of vendored dependencies and generated code, which dominate
+// scan time and produce findings that cannot be acted upon. Patterns:
+//
+//	"vendor/"    directories named vendor, at any depth
+//	"*.pb.go"    files whose name matches the glob, at any depth
+//	"gen/api/*"  paths (relative to the root) matching the glob
+
+var DefaultVendoredPatterns = []string{
+	// dependencies
+	"vendor/", "node_modules/", "bower_components/", "jspm_packages/", "Pods/", "Carthage/",
+	// protocol buffers and grpc
+	"*.pb.go", "*_grpc.pb.go", "*.pb.cc", "*.pb.h", "*_pb2.py", "*_pb2_grpc.py", "*_pb.js", "*_grpc_pb.js", "*.pb.swift",
+	// minified bundles
+	"*.min.js",
+}
+
+// directories of openapi-generator and swagger-codegen outputs contain these
+var generatorMarkers = []string{".openapi-generator", ".swagger-codegen"}
+
+func matchesVendoredPattern(relativePath string, isDir bool, patterns []string) bool {
+	name := path.Base(relativePath)
+	for _, pattern := range patterns {
+		switch {
+		case strings.HasSuffix(pattern, "/"):
+			if isDir && name == strings.TrimSuffix(pattern, "/") {
+				return true
+			}
+		case strings.Contains(pattern, "/"):
+			if matched, _ := path.Match(strings.TrimPrefix(pattern, "/"), relativePath); matched {
+				return true
+			}
+		default:
+			if matched, _ := path.Match(pattern, name); matched && !isDir {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isGeneratedDirectory(directory string) bool {
+	for _, marker := range generatorMarkers {
+		if _, err := os.Stat(filepath.Join(directory, marker)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns paths in the directory tree of root (slash separated, directories
+// with a trailing slash) matching the patterns or generated by openapi
+// generators. Matching directories are not descended into
+func FindVendoredPaths(root string, patterns []string, skip func(relativePath string, entry fs.DirEntry) bool) ([]string, error) {
+	vendoredPaths := []string{}
+	err := filepath.WalkDir(root, func(walkedPath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relativePath, err := filepath.Rel(root, walkedPath)
+		if err != nil || relativePath == "." {
+			return err
+		}
+		relativePath = filepath.ToSlash(relativePath)
+		if skip != nil && skip(relativePath, entry) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if entry.IsDir() {
+			if matchesVendoredPattern(relativePath, true, patterns) || isGeneratedDirectory(walkedPath) {
+				vendoredPaths = append(vendoredPaths, relativePath+"/")
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if matchesVendoredPattern(relativePath, false, patterns) {
+			vendoredPaths = append(vendoredPaths, relativePath)
+		}
+		return nil
+	})
+	return vendoredPaths, err
+}
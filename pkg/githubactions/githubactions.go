@@ -0,0 +1,144 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package githubactions
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+)
+
+// GitHub Actions workflow commands and job summary, so that findings
+// show up in the Actions UI (and pull request diffs) natively
+// ref: https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions
+
+func IsGitHubActions() bool {
+	isGitHubActions, _ := strconv.ParseBool(os.Getenv("GITHUB_ACTIONS"))
+	return isGitHubActions
+}
+
+func escapeData(s string) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	return strings.ReplaceAll(s, "\n", "%0A")
+}
+
+func escapeProperty(s string) string {
+	s = escapeData(s)
+	s = strings.ReplaceAll(s, ":", "%3A")
+	return strings.ReplaceAll(s, ",", "%2C")
+}
+
+// Returns path of the finding relative to the workspace (repository root),
+// as expected by annotations. scanDirectory is the absolute scanned path
+func workspacePath(scanDirectory string, finding results.Finding) string {
+	path := filepath.Join(scanDirectory, finding.RelativeFileName())
+	if workspace := os.Getenv("GITHUB_WORKSPACE"); workspace != "" {
+		if relativePath, err := filepath.Rel(workspace, path); err == nil && !strings.HasPrefix(relativePath, "..") {
+			path = relativePath
+		}
+	}
+	return filepath.ToSlash(path)
+}
+
+// Writes an annotation for each finding: "error" for failed findings,
+// "warning" for the rest
+func WriteAnnotations(w io.Writer, scanDirectory string, findings []results.Finding, failed map[string]bool) {
+	for _, finding := range findings {
+		level := "warning"
+		if failed[finding.Id] {
+			level = "error"
+		}
+
+		properties := []string{fmt.Sprintf("title=%s", escapeProperty(fmt.Sprintf("Privado: %s", finding.PolicyName)))}
+		if finding.FileName != "" {
+			properties = append(properties, fmt.Sprintf("file=%s", escapeProperty(workspacePath(scanDirectory, finding))))
+			if finding.LineNumber > 0 {
+				properties = append(properties, fmt.Sprintf("line=%d", finding.LineNumber))
+			}
+		}
+
+		message := fmt.Sprintf("[%s] %s", finding.Severity, finding.Description)
+		if finding.Description == "" {
+			message = fmt.Sprintf("[%s] %s", finding.Severity, finding.PolicyName)
+		}
+		fmt.Fprintf(w, "::%s %s::%s\n", level, strings.Join(properties, ","), escapeData(message))
+	}
+}
+
+func escapeMarkdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
+// Returns the job summary for the findings as markdown
+func RenderJobSummary(title, scanDirectory string, findings []results.Finding, failed map[string]bool) string {
+	var b strings.Builder
+	counts := results.CountFindingsBySeverity(findings)
+
+	fmt.Fprintf(&b, "## %s\n\n", escapeMarkdownCell(title))
+	fmt.Fprintf(&b, "| High | Medium | Low | Unknown |\n|---|---|---|---|\n| %d | %d | %d | %d |\n\n",
+		counts[results.SeverityHigh], counts[results.SeverityMedium], counts[results.SeverityLow], counts[results.SeverityUnknown])
+
+	if len(findings) == 0 {
+		b.WriteString("No findings :tada:\n")
+		return b.String()
+	}
+
+	b.WriteString("| | Severity | Policy | Location |\n|---|---|---|---|\n")
+	for _, finding := range findings {
+		status := ":warning:"
+		if failed[finding.Id] {
+			status = ":x:"
+		}
+		location := ""
+		if finding.FileName != "" {
+			location = fmt.Sprintf("`%s:%d`", workspacePath(scanDirectory, finding), finding.LineNumber)
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", status, finding.Severity, escapeMarkdownCell(finding.PolicyName), location)
+	}
+
+	return b.String()
+}
+
+// Appends the markdown to the job summary ($GITHUB_STEP_SUMMARY)
+func AppendJobSummary(markdown string) error {
+	summaryPath := os.Getenv("GITHUB_STEP_SUMMARY")
+	if summaryPath == "" {
+		return nil
+	}
+
+	file, err := os.OpenFile(summaryPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.WriteString(markdown + "\n")
+	return err
+}
@@ -0,0 +1,304 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package gitutils
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Thin wrappers over the git executable. All functions return empty
+// values when git is not installed or the directory is not a repository
+
+func runGit(directory string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", directory}, args...)...)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+func IsRepository(directory string) bool {
+	output, err := runGit(directory, "rev-parse", "--is-inside-work-tree")
+	return err == nil && output == "true"
+}
+
+func GetCurrentBranch(directory string) string {
+	branch, err := runGit(directory, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil || branch == "HEAD" {
+		return ""
+	}
+	return branch
+}
+
+func GetCurrentCommit(directory string) string {
+	commit, _ := runGit(directory, "rev-parse", "HEAD")
+	return commit
+}
+
+// Returns the url of the origin remote, without credentials, as it is
+// sent to Privado Cloud and included in exports
+func GetRemoteURL(directory string) string {
+	remoteURL, _ := runGit(directory, "config", "--get", "remote.origin.url")
+	return RemoveCredentialsFromURL(remoteURL)
+}
+
+// Removes credentials (e.g. tokens in https remotes) from the url
+func RemoveCredentialsFromURL(rawURL string) string {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil || parsedURL.User == nil {
+		return rawURL
+	}
+	parsedURL.User = nil
+	return parsedURL.String()
+}
+
+func IsBareRepository(directory string) bool {
+	output, err := runGit(directory, "rev-parse", "--is-bare-repository")
+	return err == nil && output == "true"
+}
+
+// Shallow clones (e.g. ci checkouts) lack the history to compare branches
+func IsShallowRepository(directory string) bool {
+	output, err := runGit(directory, "rev-parse", "--is-shallow-repository")
+	return err == nil && output == "true"
+}
+
+// Returns the (absolute) git directory shared by the checkout, if outside
+// of directory: the main repository of a worktree (git worktree add) or a
+// separate git directory (git clone --separate-git-dir), else empty
+func GetExternalGitDirectory(directory string) string {
+	gitDirectory, err := runGit(directory, "rev-parse", "--git-common-dir")
+	if err != nil || gitDirectory == "" {
+		return ""
+	}
+	if !filepath.IsAbs(gitDirectory) {
+		gitDirectory = filepath.Join(directory, gitDirectory)
+	}
+	gitDirectory = filepath.Clean(gitDirectory)
+
+	topLevel, err := runGit(directory, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return ""
+	}
+	if relative, err := filepath.Rel(topLevel, gitDirectory); err == nil && !strings.HasPrefix(relative, "..") {
+		return ""
+	}
+	return gitDirectory
+}
+
+// Returns the paths of the submodules that are not initialized
+func GetUninitializedSubmodules(directory string) ([]string, error) {
+	output, err := runGit(directory, "submodule", "status", "--recursive")
+	if err != nil {
+		return nil, err
+	}
+	submodules := []string{}
+	for _, line := range strings.Split(output, "\n") {
+		// -<commit> <path>: not initialized
+		if fields := strings.Fields(line); len(fields) >= 2 && strings.HasPrefix(fields[0], "-") {
+			submodules = append(submodules, fields[1])
+		}
+	}
+	return submodules, nil
+}
+
+// Initializes and checks out the submodules (recursively)
+func UpdateSubmodules(directory string) error {
+	return runGitWithAuthorization(directory, "", "submodule", "update", "--init", "--recursive")
+}
+
+// Returns files changed on HEAD since it diverged from baseRef
+// (relative to directory, files outside of it are excluded)
+func GetChangedFiles(directory, baseRef string) ([]string, error) {
+	output, err := runGit(directory, "diff", "--name-only", "--relative", "--diff-filter=ACMR", baseRef+"...HEAD")
+	if err != nil {
+		return nil, err
+	}
+	if output == "" {
+		return []string{}, nil
+	}
+	return strings.Split(output, "\n"), nil
+}
+
+// Returns the ref for the branch: remote tracking ref if available
+// (ci checkouts generally do not have local branches), else branch
+func ResolveBranchRef(directory, branch string) string {
+	for _, ref := range []string{"origin/" + branch, branch} {
+		if RefExists(directory, ref) {
+			return ref
+		}
+	}
+	return ""
+}
+
+func RefExists(directory, ref string) bool {
+	_, err := runGit(directory, "rev-parse", "--verify", "--quiet", ref)
+	return err == nil
+}
+
+// Returns staged files (relative to directory, files outside of it are excluded)
+func GetStagedFiles(directory string) ([]string, error) {
+	output, err := runGit(directory, "diff", "--cached", "--name-only", "--relative", "--diff-filter=ACMR")
+	if err != nil {
+		return nil, err
+	}
+	if output == "" {
+		return []string{}, nil
+	}
+	return strings.Split(output, "\n"), nil
+}
+
+// Writes the files of the index (the staged changes) to destination,
+// without the unstaged changes of the worktree
+func ExportIndex(directory, destination string) error {
+	_, err := runGit(directory, "checkout-index", "--all", "--prefix="+destination+string(filepath.Separator))
+	return err
+}
+
+// Writes the files of the commit to destination. The commit is read into a
+// temporary index, the index of the repository is not changed
+func ExportCommit(directory, ref, destination string) error {
+	indexDirectory, err := os.MkdirTemp("", "privado-index-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(indexDirectory)
+
+	env := append(os.Environ(), "GIT_INDEX_FILE="+filepath.Join(indexDirectory, "index"))
+	for _, args := range [][]string{
+		{"read-tree", ref},
+		{"checkout-index", "--all", "--prefix=" + destination + string(filepath.Separator)},
+	} {
+		cmd := exec.Command("git", append([]string{"-C", directory}, args...)...)
+		cmd.Env = env
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(output)))
+		}
+	}
+	return nil
+}
+
+// Returns untracked paths ignored by git (.gitignore, .git/info/exclude and
+// the global excludes file), relative to directory. Directories that are
+// ignored as a whole are returned once, with a trailing slash
+func GetIgnoredPaths(directory string) ([]string, error) {
+	output, err := runGit(directory, "ls-files", "--others", "--ignored", "--exclude-standard", "--directory", "-z")
+	if err != nil {
+		return nil, err
+	}
+	paths := []string{}
+	for _, path := range strings.Split(output, "\x00") {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+// Returns the index entries of the tracked files under directory: mode,
+// object id, stage and path (relative to directory)
+func GetIndexEntries(directory string) ([]string, error) {
+	return listFiles(directory, "--stage")
+}
+
+// Returns tracked files modified (or deleted) in the worktree and untracked
+// files not ignored by git, relative to directory
+func GetWorktreeChanges(directory string) ([]string, error) {
+	return listFiles(directory, "--modified", "--others", "--exclude-standard")
+}
+
+func listFiles(directory string, args ...string) ([]string, error) {
+	output, err := runGit(directory, append(append([]string{"ls-files"}, args...), "-z")...)
+	if err != nil {
+		return nil, err
+	}
+	entries := []string{}
+	for _, entry := range strings.Split(output, "\x00") {
+		if entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// Returns the directory git hooks are run from (respects core.hooksPath)
+func GetHooksDirectory(directory string) (string, error) {
+	hooksDirectory, err := runGit(directory, "rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(hooksDirectory) {
+		hooksDirectory = filepath.Join(directory, hooksDirectory)
+	}
+	return hooksDirectory, nil
+}
+
+// Shallow clones the repository into directory. The authorization
+// header (if any) is passed using the environment, so it is neither
+// visible in the process list nor stored in the cloned repository
+func Clone(url, directory, authorizationHeader string) error {
+	return runGitWithAuthorization("", authorizationHeader, "clone", "--quiet", "--depth", "1", url, directory)
+}
+
+// Checks out a single commit of the remote repository into directory,
+// without cloning its history
+func FetchCommit(url, directory, commit, authorizationHeader string) error {
+	if err := os.MkdirAll(directory, os.ModePerm); err != nil {
+		return err
+	}
+	if err := runGitWithAuthorization(directory, "", "init", "--quiet"); err != nil {
+		return err
+	}
+	if err := runGitWithAuthorization(directory, authorizationHeader, "fetch", "--quiet", "--depth", "1", url, commit); err != nil {
+		return err
+	}
+	return runGitWithAuthorization(directory, "", "checkout", "--quiet", "FETCH_HEAD")
+}
+
+// runs git without prompts, with the authorization header (if any) as http.extraHeader
+func runGitWithAuthorization(directory, authorizationHeader string, args ...string) error {
+	if directory != "" {
+		args = append([]string{"-C", directory}, args...)
+	}
+	cmd := exec.Command("git", args...)
+	cmd.Env = os.Environ()
+	if authorizationHeader != "" {
+		cmd.Env = append(cmd.Env,
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http.extraHeader",
+			fmt.Sprintf("GIT_CONFIG_VALUE_0=Authorization: %s", authorizationHeader),
+		)
+	}
+	cmd.Env = append(cmd.Env, "GIT_TERMINAL_PROMPT=0")
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+)
+
+// file of the history directory completed scans are appended to
+const ScansFileName = "scans.jsonl"
+
+// Record of a completed scan in the local history, with what is needed to
+// compare scans of the repository over time (see 'privado summary')
+type Scan struct {
+	Repository         string         `json:"repository"`
+	RepoName           string         `json:"repoName"`
+	Branch             string         `json:"branch,omitempty"`
+	CommitId           string         `json:"commitId,omitempty"`
+	CompletedAt        time.Time      `json:"completedAt"`
+	FindingsBySeverity map[string]int `json:"findingsBySeverity"`
+	FindingIds         []string       `json:"findingIds"`
+	DataElements       []string       `json:"dataElements"`
+}
+
+// Returns the record of a completed scan of the repository from its results
+func NewScan(repositoryPath string, scanResults *results.Results, completedAt time.Time) Scan {
+	findings := scanResults.Findings()
+	findingIds := []string{}
+	for _, finding := range findings {
+		findingIds = append(findingIds, finding.Id)
+	}
+
+	dataElements := []string{}
+	for _, source := range scanResults.Sources {
+		name := source.Name
+		if name == "" {
+			name = source.Id
+		}
+		dataElements = append(dataElements, name)
+	}
+	sort.Strings(dataElements)
+
+	repoName := scanResults.RepoName
+	if repoName == "" {
+		repoName = filepath.Base(repositoryPath)
+	}
+
+	return Scan{
+		Repository:         repositoryPath,
+		RepoName:           repoName,
+		Branch:             scanResults.GitMetadata.BranchName,
+		CommitId:           scanResults.GitMetadata.CommitId,
+		CompletedAt:        completedAt.UTC(),
+		FindingsBySeverity: results.CountFindingsBySeverity(findings),
+		FindingIds:         findingIds,
+		DataElements:       dataElements,
+	}
+}
+
+// Returns the total number of findings of the scan
+func (s Scan) FindingCount() int {
+	return len(s.FindingIds)
+}
+
+// Appends the scan to the history (scans.jsonl)
+func RecordScan(historyDirectory string, scan Scan) error {
+	if err := os.MkdirAll(historyDirectory, os.ModePerm); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(scan)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(filepath.Join(historyDirectory, ScansFileName), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
+
+// Returns scans of the history, oldest first. Lines that cannot be parsed
+// (e.g. partially written) are skipped
+func LoadScans(historyDirectory string) ([]Scan, error) {
+	file, err := os.Open(filepath.Join(historyDirectory, ScansFileName))
+	if os.IsNotExist(err) {
+		return []Scan{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scans := []Scan{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		scan := Scan{}
+		if err := json.Unmarshal(scanner.Bytes(), &scan); err != nil || scan.Repository == "" {
+			continue
+		}
+		scans = append(scans, scan)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(scans, func(i, j int) bool { return scans[i].CompletedAt.Before(scans[j].CompletedAt) })
+	return scans, nil
+}
+
+// Removes scans completed more than maxAge before now and, if keepLast is
+// not 0, all but the keepLast most recent scans of each repository.
+// Returns the number of removed scans
+func Prune(historyDirectory string, keepLast int, maxAge time.Duration, now time.Time) (int, error) {
+	scans, err := LoadScans(historyDirectory)
+	if err != nil || len(scans) == 0 {
+		return 0, err
+	}
+
+	kept := []Scan{}
+	keptByRepository := map[string]int{}
+	for i := len(scans) - 1; i >= 0; i-- {
+		scan := scans[i]
+		if now.Sub(scan.CompletedAt) > maxAge || (keepLast > 0 && keptByRepository[scan.Repository] >= keepLast) {
+			continue
+		}
+		keptByRepository[scan.Repository]++
+		kept = append([]Scan{scan}, kept...)
+	}
+	removed := len(scans) - len(kept)
+	if removed == 0 {
+		return 0, nil
+	}
+
+	// replace the history at once, so it is never left partially written
+	file, err := os.CreateTemp(historyDirectory, ScansFileName+".")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(file.Name())
+	writer := bufio.NewWriter(file)
+	for _, scan := range kept {
+		data, err := json.Marshal(scan)
+		if err != nil {
+			file.Close()
+			return 0, err
+		}
+		_, _ = writer.Write(append(data, '\n'))
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return 0, err
+	}
+	if err := file.Close(); err != nil {
+		return 0, err
+	}
+	return removed, os.Rename(file.Name(), filepath.Join(historyDirectory, ScansFileName))
+}
@@ -0,0 +1,223 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package history
+
+import (
+	"sort"
+	"time"
+)
+
+// data elements listed in the summary
+const maxTopDataElements = 10
+
+// windows longer than this are trended by week instead of by day
+const dailyTrendWindow = 31 * 24 * time.Hour
+
+type RepositorySummary struct {
+	Repository         string         `json:"repository"`
+	RepoName           string         `json:"repoName"`
+	Scans              int            `json:"scans"`
+	LastScanAt         time.Time      `json:"lastScanAt"`
+	Findings           int            `json:"findings"`
+	FindingsBySeverity map[string]int `json:"findingsBySeverity"`
+	Change             int            `json:"change"`
+	New                int            `json:"new"`
+	Resolved           int            `json:"resolved"`
+}
+
+type TrendPoint struct {
+	Date     time.Time `json:"date"`
+	Findings int       `json:"findings"`
+}
+
+type DataElementCount struct {
+	Name         string `json:"name"`
+	Repositories int    `json:"repositories"`
+}
+
+// Aggregate of the local scan history of all repositories over a window.
+// Changes of a repository are relative to its last scan before the window,
+// else its first scan in the window
+type Summary struct {
+	Since           time.Time           `json:"since"`
+	Until           time.Time           `json:"until"`
+	Scans           int                 `json:"scans"`
+	Findings        int                 `json:"findings"`
+	New             int                 `json:"new"`
+	Resolved        int                 `json:"resolved"`
+	Repositories    []RepositorySummary `json:"repositories"`
+	Trend           []TrendPoint        `json:"trend"`
+	TopDataElements []DataElementCount  `json:"topDataElements"`
+}
+
+// Summarizes scans (oldest first) completed between since and until.
+// Repositories without scans in the window are left out
+func Summarize(scans []Scan, since, until time.Time) Summary {
+	summary := Summary{
+		Since:           since,
+		Until:           until,
+		Repositories:    []RepositorySummary{},
+		Trend:           []TrendPoint{},
+		TopDataElements: []DataElementCount{},
+	}
+
+	byRepository := map[string][]Scan{}
+	for _, scan := range scans {
+		if scan.CompletedAt.After(until) {
+			continue
+		}
+		byRepository[scan.Repository] = append(byRepository[scan.Repository], scan)
+	}
+
+	dataElementRepositories := map[string]int{}
+	included := map[string][]Scan{}
+	for repository, repositoryScans := range byRepository {
+		var baseline *Scan
+		inWindow := []Scan{}
+		for i, scan := range repositoryScans {
+			if scan.CompletedAt.Before(since) {
+				baseline = &repositoryScans[i]
+			} else {
+				inWindow = append(inWindow, scan)
+			}
+		}
+		if len(inWindow) == 0 {
+			continue
+		}
+		included[repository] = repositoryScans
+		if baseline == nil {
+			baseline = &inWindow[0]
+		}
+		latest := inWindow[len(inWindow)-1]
+
+		newIds, resolvedIds := diffFindingIds(baseline.FindingIds, latest.FindingIds)
+		summary.Repositories = append(summary.Repositories, RepositorySummary{
+			Repository:         repository,
+			RepoName:           latest.RepoName,
+			Scans:              len(inWindow),
+			LastScanAt:         latest.CompletedAt,
+			Findings:           latest.FindingCount(),
+			FindingsBySeverity: latest.FindingsBySeverity,
+			Change:             latest.FindingCount() - baseline.FindingCount(),
+			New:                newIds,
+			Resolved:           resolvedIds,
+		})
+		summary.Scans += len(inWindow)
+		summary.Findings += latest.FindingCount()
+		summary.New += newIds
+		summary.Resolved += resolvedIds
+
+		for _, name := range unique(latest.DataElements) {
+			dataElementRepositories[name]++
+		}
+	}
+
+	sort.Slice(summary.Repositories, func(i, j int) bool {
+		if summary.Repositories[i].Findings != summary.Repositories[j].Findings {
+			return summary.Repositories[i].Findings > summary.Repositories[j].Findings
+		}
+		return summary.Repositories[i].Repository < summary.Repositories[j].Repository
+	})
+
+	for name, count := range dataElementRepositories {
+		summary.TopDataElements = append(summary.TopDataElements, DataElementCount{Name: name, Repositories: count})
+	}
+	sort.Slice(summary.TopDataElements, func(i, j int) bool {
+		if summary.TopDataElements[i].Repositories != summary.TopDataElements[j].Repositories {
+			return summary.TopDataElements[i].Repositories > summary.TopDataElements[j].Repositories
+		}
+		return summary.TopDataElements[i].Name < summary.TopDataElements[j].Name
+	})
+	if len(summary.TopDataElements) > maxTopDataElements {
+		summary.TopDataElements = summary.TopDataElements[:maxTopDataElements]
+	}
+
+	summary.Trend = computeTrend(included, since, until)
+	return summary
+}
+
+// Returns the total findings at the end of each day (or week) of the window:
+// the sum of the last scan of each repository completed by then
+func computeTrend(scansByRepository map[string][]Scan, since, until time.Time) []TrendPoint {
+	step := 24 * time.Hour
+	if until.Sub(since) > dailyTrendWindow {
+		step = 7 * step
+	}
+
+	trend := []TrendPoint{}
+	for start := since; start.Before(until); start = start.Add(step) {
+		end := start.Add(step)
+		if end.After(until) {
+			end = until
+		}
+		total := 0
+		for _, scans := range scansByRepository {
+			for i := len(scans) - 1; i >= 0; i-- {
+				if !scans[i].CompletedAt.After(end) {
+					total += scans[i].FindingCount()
+					break
+				}
+			}
+		}
+		trend = append(trend, TrendPoint{Date: start, Findings: total})
+	}
+	return trend
+}
+
+// Returns the number of findings of current not in previous (new), and of
+// previous not in current (resolved)
+func diffFindingIds(previous, current []string) (int, int) {
+	previousIds := map[string]bool{}
+	for _, id := range previous {
+		previousIds[id] = true
+	}
+	currentIds := map[string]bool{}
+	for _, id := range current {
+		currentIds[id] = true
+	}
+
+	newIds, resolvedIds := 0, 0
+	for id := range currentIds {
+		if !previousIds[id] {
+			newIds++
+		}
+	}
+	for id := range previousIds {
+		if !currentIds[id] {
+			resolvedIds++
+		}
+	}
+	return newIds, resolvedIds
+}
+
+func unique(values []string) []string {
+	seen := map[string]bool{}
+	uniqueValues := []string{}
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			uniqueValues = append(uniqueValues, value)
+		}
+	}
+	return uniqueValues
+}
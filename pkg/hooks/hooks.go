@@ -0,0 +1,124 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// Hooks are user defined shell commands run before and after a scan. Scan
+// metadata is passed as PRIVADO_* environment variables and as json on stdin
+
+const (
+	PreScan  = "pre-scan"
+	PostScan = "post-scan"
+
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+
+	DefaultTimeout = 10 * time.Minute
+)
+
+type Metadata struct {
+	Hook           string    `json:"hook"`
+	Repository     string    `json:"repository"`
+	RepositoryPath string    `json:"repositoryPath"`
+	ResultsPath    string    `json:"resultsPath"`
+	Branch         string    `json:"branch,omitempty"`
+	Commit         string    `json:"commit,omitempty"`
+	CLIVersion     string    `json:"cliVersion"`
+	StartedAt      time.Time `json:"startedAt"`
+
+	// post-scan only
+	Status   string         `json:"status,omitempty"`
+	Findings map[string]int `json:"findingsBySeverity,omitempty"`
+}
+
+func (m Metadata) environment() []string {
+	env := []string{
+		"PRIVADO_HOOK=" + m.Hook,
+		"PRIVADO_REPOSITORY=" + m.Repository,
+		"PRIVADO_REPOSITORY_PATH=" + m.RepositoryPath,
+		"PRIVADO_RESULTS_PATH=" + m.ResultsPath,
+		"PRIVADO_BRANCH=" + m.Branch,
+		"PRIVADO_COMMIT=" + m.Commit,
+		"PRIVADO_CLI_VERSION=" + m.CLIVersion,
+	}
+	if m.Status != "" {
+		env = append(env, "PRIVADO_SCAN_STATUS="+m.Status)
+	}
+	if m.Findings != nil {
+		total := 0
+		for _, count := range m.Findings {
+			total += count
+		}
+		env = append(env, "PRIVADO_SCAN_FINDINGS="+strconv.Itoa(total))
+	}
+	return env
+}
+
+func getShellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
+
+// Runs the hook command in directory, with its output written to output.
+// Returns an error if the command fails or does not complete within timeout
+func Run(command, directory string, metadata Metadata, timeout time.Duration, output io.Writer) error {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	input, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+
+	hookCmd := getShellCommand(ctx, command)
+	hookCmd.Dir = directory
+	hookCmd.Env = append(os.Environ(), metadata.environment()...)
+	hookCmd.Stdin = bytes.NewReader(input)
+	hookCmd.Stdout = output
+	hookCmd.Stderr = output
+
+	if err := hookCmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("%s hook timed out after %s", metadata.Hook, timeout)
+		}
+		return fmt.Errorf("%s hook failed: %w", metadata.Hook, err)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package iac
+
+// Known data stores and third-party services, by how infrastructure
+// files define them
+
+type knownResource struct {
+	kind string
+	name string
+}
+
+// terraform resource types (exact, or prefix with a trailing "*")
+var terraformResources = map[string]knownResource{
+	"aws_db_instance":                      {KindStorage, "Amazon RDS"},
+	"aws_rds_cluster":                      {KindStorage, "Amazon RDS"},
+	"aws_dynamodb_table":                   {KindStorage, "Amazon DynamoDB"},
+	"aws_s3_bucket":                        {KindStorage, "Amazon S3"},
+	"aws_elasticache_cluster":              {KindStorage, "Amazon ElastiCache"},
+	"aws_elasticache_replication_group":    {KindStorage, "Amazon ElastiCache"},
+	"aws_redshift_cluster":                 {KindStorage, "Amazon Redshift"},
+	"aws_docdb_cluster":                    {KindStorage, "Amazon DocumentDB"},
+	"aws_neptune_cluster":                  {KindStorage, "Amazon Neptune"},
+	"aws_opensearch_domain":                {KindStorage, "Amazon OpenSearch"},
+	"aws_elasticsearch_domain":             {KindStorage, "Amazon OpenSearch"},
+	"aws_kinesis_stream":                   {KindStorage, "Amazon Kinesis"},
+	"aws_kinesis_firehose_delivery_stream": {KindStorage, "Amazon Kinesis"},
+	"aws_sqs_queue":                        {KindStorage, "Amazon SQS"},
+	"aws_sns_topic":                        {KindStorage, "Amazon SNS"},
+	"aws_msk_cluster":                      {KindStorage, "Amazon MSK"},
+	"google_sql_database_instance":         {KindStorage, "Google Cloud SQL"},
+	"google_storage_bucket":                {KindStorage, "Google Cloud Storage"},
+	"google_bigquery_dataset":              {KindStorage, "Google BigQuery"},
+	"google_bigquery_table":                {KindStorage, "Google BigQuery"},
+	"google_spanner_instance":              {KindStorage, "Google Cloud Spanner"},
+	"google_bigtable_instance":             {KindStorage, "Google Bigtable"},
+	"google_firestore_database":            {KindStorage, "Google Firestore"},
+	"google_redis_instance":                {KindStorage, "Google Memorystore"},
+	"google_pubsub_topic":                  {KindStorage, "Google Pub/Sub"},
+	"azurerm_mssql_database":               {KindStorage, "Azure SQL Database"},
+	"azurerm_sql_database":                 {KindStorage, "Azure SQL Database"},
+	"azurerm_postgresql_server":            {KindStorage, "Azure Database for PostgreSQL"},
+	"azurerm_postgresql_flexible_server":   {KindStorage, "Azure Database for PostgreSQL"},
+	"azurerm_mysql_server":                 {KindStorage, "Azure Database for MySQL"},
+	"azurerm_mysql_flexible_server":        {KindStorage, "Azure Database for MySQL"},
+	"azurerm_cosmosdb_account":             {KindStorage, "Azure Cosmos DB"},
+	"azurerm_storage_account":              {KindStorage, "Azure Storage"},
+	"azurerm_redis_cache":                  {KindStorage, "Azure Cache for Redis"},
+	"azurerm_eventhub":                     {KindStorage, "Azure Event Hubs"},
+	"azurerm_servicebus_queue":             {KindStorage, "Azure Service Bus"},
+	"mongodbatlas_cluster":                 {KindStorage, "MongoDB Atlas"},
+	"snowflake_database":                   {KindStorage, "Snowflake"},
+	"snowflake_table":                      {KindStorage, "Snowflake"},
+	"datadog_*":                            {KindThirdParty, "Datadog"},
+	"newrelic_*":                           {KindThirdParty, "New Relic"},
+	"sentry_*":                             {KindThirdParty, "Sentry"},
+	"pagerduty_*":                          {KindThirdParty, "PagerDuty"},
+	"okta_*":                               {KindThirdParty, "Okta"},
+	"auth0_*":                              {KindThirdParty, "Auth0"},
+	"segment_*":                            {KindThirdParty, "Segment"},
+	"stripe_*":                             {KindThirdParty, "Stripe"},
+	"twilio_*":                             {KindThirdParty, "Twilio"},
+	"sendgrid_*":                           {KindThirdParty, "SendGrid"},
+	"mailgun_*":                            {KindThirdParty, "Mailgun"},
+	"algolia_*":                            {KindThirdParty, "Algolia"},
+	"launchdarkly_*":                       {KindThirdParty, "LaunchDarkly"},
+	"cloudflare_*":                         {KindThirdParty, "Cloudflare"},
+}
+
+// cloudformation resource types
+var cloudFormationResources = map[string]knownResource{
+	"AWS::RDS::DBInstance":                 {KindStorage, "Amazon RDS"},
+	"AWS::RDS::DBCluster":                  {KindStorage, "Amazon RDS"},
+	"AWS::DynamoDB::Table":                 {KindStorage, "Amazon DynamoDB"},
+	"AWS::DynamoDB::GlobalTable":           {KindStorage, "Amazon DynamoDB"},
+	"AWS::S3::Bucket":                      {KindStorage, "Amazon S3"},
+	"AWS::ElastiCache::CacheCluster":       {KindStorage, "Amazon ElastiCache"},
+	"AWS::ElastiCache::ReplicationGroup":   {KindStorage, "Amazon ElastiCache"},
+	"AWS::Redshift::Cluster":               {KindStorage, "Amazon Redshift"},
+	"AWS::DocDB::DBCluster":                {KindStorage, "Amazon DocumentDB"},
+	"AWS::Neptune::DBCluster":              {KindStorage, "Amazon Neptune"},
+	"AWS::OpenSearchService::Domain":       {KindStorage, "Amazon OpenSearch"},
+	"AWS::Elasticsearch::Domain":           {KindStorage, "Amazon OpenSearch"},
+	"AWS::Kinesis::Stream":                 {KindStorage, "Amazon Kinesis"},
+	"AWS::KinesisFirehose::DeliveryStream": {KindStorage, "Amazon Kinesis"},
+	"AWS::SQS::Queue":                      {KindStorage, "Amazon SQS"},
+	"AWS::SNS::Topic":                      {KindStorage, "Amazon SNS"},
+	"AWS::MSK::Cluster":                    {KindStorage, "Amazon MSK"},
+	"AWS::Serverless::SimpleTable":         {KindStorage, "Amazon DynamoDB"},
+}
+
+// container images, without tag: the last path element, or the path suffix
+// for qualified names (e.g. "datadog/agent")
+var knownImages = []struct {
+	kind   string
+	name   string
+	images []string
+}{
+	{KindStorage, "PostgreSQL", []string{"postgres", "postgresql", "postgis", "timescaledb"}},
+	{KindStorage, "MySQL", []string{"mysql", "mariadb", "percona-server"}},
+	{KindStorage, "MongoDB", []string{"mongo", "mongodb", "mongodb-community-server"}},
+	{KindStorage, "Redis", []string{"redis", "redis-stack", "valkey"}},
+	{KindStorage, "Memcached", []string{"memcached"}},
+	{KindStorage, "Elasticsearch", []string{"elasticsearch", "opensearch"}},
+	{KindStorage, "Cassandra", []string{"cassandra", "scylla"}},
+	{KindStorage, "Kafka", []string{"kafka", "cp-kafka", "redpanda"}},
+	{KindStorage, "RabbitMQ", []string{"rabbitmq"}},
+	{KindStorage, "Microsoft SQL Server", []string{"mssql-server", "mssql"}},
+	{KindStorage, "MinIO", []string{"minio"}},
+	{KindStorage, "ClickHouse", []string{"clickhouse-server", "clickhouse"}},
+	{KindStorage, "Neo4j", []string{"neo4j"}},
+	{KindStorage, "CockroachDB", []string{"cockroach"}},
+	{KindStorage, "InfluxDB", []string{"influxdb"}},
+	{KindThirdParty, "Datadog", []string{"datadog/agent", "datadoghq/agent", "datadog/dogstatsd", "datadog-agent"}},
+	{KindThirdParty, "New Relic", []string{"newrelic/infrastructure", "newrelic/infrastructure-bundle"}},
+}
@@ -0,0 +1,161 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package iac
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Detection of data stores and third-party services defined in
+// infrastructure as code (terraform, cloudformation, kubernetes manifests
+// and docker-compose files), which the engine does not see in the code
+
+const (
+	KindStorage    = "storage"
+	KindThirdParty = "third-party"
+)
+
+// A data store or third-party service defined in an infrastructure file
+type Resource struct {
+	Kind string `json:"kind"`
+	// e.g. "Amazon RDS", "PostgreSQL", "Datadog"
+	Name string `json:"name"`
+	// terraform, cloudformation, kubernetes or docker-compose
+	Source string `json:"source"`
+	// name of the resource, service or container defining it
+	Definition string `json:"definition"`
+	// relative to the scanned directory, slash separated
+	File string `json:"file"`
+	Line int    `json:"line,omitempty"`
+}
+
+func (r Resource) SinkId() string {
+	prefix := "Storages."
+	if r.Kind == KindThirdParty {
+		prefix = "ThirdParties."
+	}
+	return prefix + "Infrastructure." + strings.ReplaceAll(r.Name, " ", "")
+}
+
+func (r Resource) SinkType() string {
+	if r.Kind == KindThirdParty {
+		return "third_parties"
+	}
+	return "storages"
+}
+
+// files larger than this are not infrastructure definitions
+const maxFileSize = 2 << 20
+
+// Returns the data stores and third-party services defined in the
+// infrastructure files of the directory tree
+func Scan(root string, skip func(relativePath string, entry fs.DirEntry) bool) ([]Resource, error) {
+	resources := []Resource{}
+	err := filepath.WalkDir(root, func(walkedPath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relativePath, err := filepath.Rel(root, walkedPath)
+		if err != nil || relativePath == "." {
+			return err
+		}
+		relativePath = filepath.ToSlash(relativePath)
+		if entry.IsDir() {
+			if entry.Name() == ".git" || entry.Name() == ".terraform" || (skip != nil && skip(relativePath, entry)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() || (skip != nil && skip(relativePath, entry)) {
+			return nil
+		}
+
+		var parse func(relativePath string, data []byte) []Resource
+		switch strings.ToLower(path.Ext(relativePath)) {
+		case ".tf":
+			parse = parseTerraform
+		case ".yaml", ".yml", ".json", ".template":
+			parse = parseManifest
+		default:
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil || info.Size() > maxFileSize {
+			return nil
+		}
+		data, err := os.ReadFile(walkedPath)
+		if err != nil {
+			return nil
+		}
+		resources = append(resources, parse(relativePath, data)...)
+		return nil
+	})
+
+	sort.SliceStable(resources, func(i, j int) bool {
+		if resources[i].File != resources[j].File {
+			return resources[i].File < resources[j].File
+		}
+		if resources[i].Line != resources[j].Line {
+			return resources[i].Line < resources[j].Line
+		}
+		return resources[i].Definition < resources[j].Definition
+	})
+	return resources, err
+}
+
+// Returns the number of resources by source (e.g. terraform)
+func CountBySource(resources []Resource) map[string]int {
+	counts := map[string]int{}
+	for _, resource := range resources {
+		counts[resource.Source]++
+	}
+	return counts
+}
+
+// Returns the known data store or service the container image (e.g.
+// "bitnami/postgresql:14") runs, empty if unknown
+func getImageResource(image string) (kind, name string) {
+	repository := strings.ToLower(image)
+	if index := strings.LastIndex(repository, "@"); index >= 0 {
+		repository = repository[:index]
+	}
+	if index := strings.LastIndex(repository, ":"); index > strings.LastIndex(repository, "/") {
+		repository = repository[:index]
+	}
+	imageName := path.Base(repository)
+
+	for _, known := range knownImages {
+		for _, candidate := range known.images {
+			if imageName == candidate || (strings.Contains(candidate, "/") && (repository == candidate || strings.HasSuffix(repository, "/"+candidate))) {
+				return known.kind, known.name
+			}
+		}
+	}
+	return "", ""
+}
@@ -0,0 +1,163 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package iac
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// kubernetes workloads, by the path of their containers in the manifest
+var kubernetesContainerPaths = map[string][]string{
+	"Pod":         {"spec"},
+	"Deployment":  {"spec", "template", "spec"},
+	"StatefulSet": {"spec", "template", "spec"},
+	"DaemonSet":   {"spec", "template", "spec"},
+	"ReplicaSet":  {"spec", "template", "spec"},
+	"Job":         {"spec", "template", "spec"},
+	"CronJob":     {"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+// CloudFormation templates, kubernetes manifests and docker-compose files
+// (yaml or json, possibly with several documents)
+func parseManifest(relativePath string, data []byte) []Resource {
+	resources := []Resource{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var document map[string]interface{}
+		if err := decoder.Decode(&document); err != nil {
+			// not a manifest (or not yaml at all), keep what was found
+			if !errors.Is(err, io.EOF) {
+				return resources
+			}
+			break
+		}
+
+		switch {
+		case isCloudFormationTemplate(document):
+			resources = append(resources, parseCloudFormation(relativePath, data, document)...)
+		case getString(document, "apiVersion") != "" && getString(document, "kind") != "":
+			resources = append(resources, parseKubernetes(relativePath, data, document)...)
+		case getMap(document, "services") != nil:
+			resources = append(resources, parseCompose(relativePath, data, document)...)
+		}
+	}
+	return resources
+}
+
+func isCloudFormationTemplate(document map[string]interface{}) bool {
+	if _, ok := document["AWSTemplateFormatVersion"]; ok {
+		return true
+	}
+	for _, resource := range getMap(document, "Resources") {
+		if resource, ok := resource.(map[string]interface{}); ok && strings.HasPrefix(getString(resource, "Type"), "AWS::") {
+			return true
+		}
+	}
+	return false
+}
+
+func parseCloudFormation(relativePath string, data []byte, document map[string]interface{}) []Resource {
+	resources := []Resource{}
+	for logicalId, resource := range getMap(document, "Resources") {
+		resource, ok := resource.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if known, ok := cloudFormationResources[getString(resource, "Type")]; ok {
+			resources = append(resources, Resource{Kind: known.kind, Name: known.name, Source: "cloudformation", Definition: logicalId, File: relativePath, Line: findLine(data, logicalId)})
+		}
+	}
+	return resources
+}
+
+func parseKubernetes(relativePath string, data []byte, document map[string]interface{}) []Resource {
+	containerPath, ok := kubernetesContainerPaths[getString(document, "kind")]
+	if !ok {
+		return nil
+	}
+	podSpec := document
+	for _, key := range containerPath {
+		if podSpec = getMap(podSpec, key); podSpec == nil {
+			return nil
+		}
+	}
+
+	workload := getString(document, "kind") + "/" + getString(getMap(document, "metadata"), "name")
+	resources := []Resource{}
+	for _, key := range []string{"initContainers", "containers"} {
+		containers, _ := podSpec[key].([]interface{})
+		for _, container := range containers {
+			container, ok := container.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			image := getString(container, "image")
+			if kind, name := getImageResource(image); kind != "" {
+				resources = append(resources, Resource{Kind: kind, Name: name, Source: "kubernetes", Definition: workload, File: relativePath, Line: findLine(data, image)})
+			}
+		}
+	}
+	return resources
+}
+
+func parseCompose(relativePath string, data []byte, document map[string]interface{}) []Resource {
+	resources := []Resource{}
+	for service, definition := range getMap(document, "services") {
+		definition, ok := definition.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		image := getString(definition, "image")
+		if kind, name := getImageResource(image); kind != "" {
+			resources = append(resources, Resource{Kind: kind, Name: name, Source: "docker-compose", Definition: service, File: relativePath, Line: findLine(data, image)})
+		}
+	}
+	return resources
+}
+
+func getMap(value map[string]interface{}, key string) map[string]interface{} {
+	child, _ := value[key].(map[string]interface{})
+	return child
+}
+
+func getString(value map[string]interface{}, key string) string {
+	child, _ := value[key].(string)
+	return child
+}
+
+// Returns the first line (1-based) containing the text, 0 if none
+func findLine(data []byte, text string) int {
+	if text == "" {
+		return 0
+	}
+	index := bytes.Index(data, []byte(text))
+	if index < 0 {
+		return 0
+	}
+	return bytes.Count(data[:index], []byte("\n")) + 1
+}
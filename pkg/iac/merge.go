@@ -0,0 +1,82 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package iac
+
+import (
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+)
+
+// Adds the resources as sinks to the results (privado.json), next to the
+// sinks found in the code. Each sink lists where it is defined
+// (occurrences), file names are in sourceDirectory like the engine's.
+// Sinks already in the results are kept as they are
+func MergeIntoResults(resultsPath, sourceDirectory string, resources []Resource) error {
+	raw, err := results.LoadRawResults(resultsPath)
+	if err != nil {
+		return err
+	}
+
+	sinks, _ := raw["sinks"].([]interface{})
+	existing := map[string]bool{}
+	for _, sink := range sinks {
+		if sink, ok := sink.(map[string]interface{}); ok {
+			if id, ok := sink["id"].(string); ok {
+				existing[id] = true
+			}
+		}
+	}
+
+	added := map[string]map[string]interface{}{}
+	for _, resource := range resources {
+		id := resource.SinkId()
+		if existing[id] {
+			continue
+		}
+		sink, ok := added[id]
+		if !ok {
+			sink = map[string]interface{}{
+				"sinkType":    resource.SinkType(),
+				"id":          id,
+				"name":        resource.Name,
+				"domains":     []interface{}{},
+				"apiUrl":      []interface{}{},
+				"definedIn":   "infrastructure",
+				"occurrences": []interface{}{},
+			}
+			added[id] = sink
+			sinks = append(sinks, sink)
+		}
+		sink["occurrences"] = append(sink["occurrences"].([]interface{}), map[string]interface{}{
+			"sample":     resource.Definition,
+			"lineNumber": resource.Line,
+			"fileName":   sourceDirectory + "/" + resource.File,
+			"definedIn":  resource.Source,
+		})
+	}
+	if len(added) == 0 {
+		return nil
+	}
+
+	raw["sinks"] = sinks
+	return results.WriteRawResults(resultsPath, raw)
+}
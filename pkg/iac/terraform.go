@@ -0,0 +1,65 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package iac
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	terraformResourcePattern = regexp.MustCompile(`^\s*resource\s+"([^"]+)"\s+"([^"]+)"`)
+	terraformProviderPattern = regexp.MustCompile(`^\s*provider\s+"([^"]+)"`)
+)
+
+// Terraform (.tf) resources of known data stores, and resources or
+// providers of known third-party services
+func parseTerraform(relativePath string, data []byte) []Resource {
+	resources := []Resource{}
+	for i, line := range strings.Split(string(data), "\n") {
+		if match := terraformResourcePattern.FindStringSubmatch(line); match != nil {
+			if known, ok := getTerraformResource(match[1]); ok {
+				resources = append(resources, Resource{Kind: known.kind, Name: known.name, Source: "terraform", Definition: match[1] + "." + match[2], File: relativePath, Line: i + 1})
+			}
+		} else if match := terraformProviderPattern.FindStringSubmatch(line); match != nil {
+			// a provider of a service is configured even if none of its
+			// resources are managed here
+			if known, ok := getTerraformResource(match[1] + "_"); ok && known.kind == KindThirdParty {
+				resources = append(resources, Resource{Kind: known.kind, Name: known.name, Source: "terraform", Definition: "provider." + match[1], File: relativePath, Line: i + 1})
+			}
+		}
+	}
+	return resources
+}
+
+func getTerraformResource(resourceType string) (knownResource, bool) {
+	if known, ok := terraformResources[resourceType]; ok {
+		return known, true
+	}
+	for pattern, known := range terraformResources {
+		if strings.HasSuffix(pattern, "*") && strings.HasPrefix(resourceType, strings.TrimSuffix(pattern, "*")) {
+			return known, true
+		}
+	}
+	return knownResource{}, false
+}
@@ -0,0 +1,159 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package license
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Enterprise licenses are signed offline by Privado and verified locally
+// with the embedded public key; no callback is made to Privado servers
+
+// base64 encoded ed25519 public key used to verify licenses
+// set at build time: -ldflags "-X .../pkg/license.PublicKey=<key>"; releases
+// use the PRIVADO_LICENSE_PUBLIC_KEY secret (see .github/workflows/release.yaml)
+var PublicKey = ""
+
+// features that can be unlocked by a license
+const (
+	// scans do not contact Privado servers (update checks, telemetry)
+	FeatureOffline = "offline"
+)
+
+var (
+	ErrNoLicense        = errors.New("no license activated")
+	ErrInvalidSignature = errors.New("license signature is invalid")
+	ErrExpired          = errors.New("license has expired")
+	ErrNoPublicKey      = errors.New("license verification is not available in this build")
+)
+
+type License struct {
+	Id           string    `json:"id"`
+	Organization string    `json:"organization"`
+	Features     []string  `json:"features"`
+	IssuedAt     time.Time `json:"issuedAt"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+}
+
+// format of the license key file
+type signedLicense struct {
+	License   json.RawMessage `json:"license"`
+	Signature string          `json:"signature"`
+}
+
+func (l *License) HasFeature(feature string) bool {
+	for _, f := range l.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *License) IsExpired() bool {
+	return !l.ExpiresAt.IsZero() && time.Now().After(l.ExpiresAt)
+}
+
+// Parses the license key file and verifies its signature and expiry
+func Parse(data []byte) (*License, error) {
+	if PublicKey == "" {
+		return nil, ErrNoPublicKey
+	}
+	publicKey, err := base64.StdEncoding.DecodeString(PublicKey)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		return nil, ErrNoPublicKey
+	}
+
+	signed := signedLicense{}
+	if err := json.Unmarshal(data, &signed); err != nil {
+		return nil, fmt.Errorf("malformed license key: %w", err)
+	}
+	signature, err := base64.StdEncoding.DecodeString(signed.Signature)
+	if err != nil || len(signed.License) == 0 {
+		return nil, ErrInvalidSignature
+	}
+	if !ed25519.Verify(ed25519.PublicKey(publicKey), signed.License, signature) {
+		return nil, ErrInvalidSignature
+	}
+
+	license := &License{}
+	if err := json.Unmarshal(signed.License, license); err != nil {
+		return nil, fmt.Errorf("malformed license key: %w", err)
+	}
+	if license.IsExpired() {
+		return license, ErrExpired
+	}
+
+	return license, nil
+}
+
+// Verifies the license key file and stores it at licensePath
+func Activate(keyFilePath, licensePath string) (*License, error) {
+	data, err := os.ReadFile(keyFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	license, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(licensePath), 0700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(licensePath, data, 0600); err != nil {
+		return nil, err
+	}
+
+	return license, nil
+}
+
+// Loads and verifies the activated license
+// Returns the raw key (to pass on to the engine) along with the license
+func Load(licensePath string) (*License, []byte, error) {
+	data, err := os.ReadFile(licensePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil, ErrNoLicense
+		}
+		return nil, nil, err
+	}
+
+	license, err := Parse(data)
+	return license, data, err
+}
+
+func Deactivate(licensePath string) error {
+	if err := os.Remove(licensePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,272 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package logs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Per scan log files: output written to stdout while a log is started is
+// also written to the log file, so scans can be debugged after the fact.
+// Old logs are removed when a new log is started (by age and total size)
+
+const extension = ".log"
+
+var ErrLogNotFound = errors.New("log not found")
+
+type Log struct {
+	Id      string
+	Path    string
+	ModTime time.Time
+	Size    int64
+}
+
+type capture struct {
+	terminal *os.File
+	pipe     *os.File
+	done     chan struct{}
+}
+
+var (
+	mu     sync.Mutex
+	active *capture
+)
+
+var unsafeCharacters = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Returns a new log id for a scan of the repository, e.g. 20221016-153012-repo
+func NewId(directory, repository string) string {
+	baseId := fmt.Sprintf("%s-%s", time.Now().Format("20060102-150405"), unsafeCharacters.ReplaceAllString(repository, "_"))
+	id := baseId
+	for i := 2; exists(filepath.Join(directory, id+extension)); i++ {
+		id = fmt.Sprintf("%s-%d", baseId, i)
+	}
+	return id
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// Starts writing stdout to the log file with the id, in addition to the
+// terminal. At most maxSize bytes are written to the file. Returns its path
+func Start(directory, id, header string, maxSize int64) (string, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if active != nil {
+		return "", errors.New("a log is already started")
+	}
+
+	if err := os.MkdirAll(directory, os.ModePerm); err != nil {
+		return "", err
+	}
+	path := filepath.Join(directory, id+extension)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return "", err
+	}
+	if header != "" {
+		fmt.Fprintln(file, header)
+	}
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		file.Close()
+		return "", err
+	}
+
+	c := &capture{terminal: os.Stdout, pipe: writer, done: make(chan struct{})}
+	go func() {
+		defer close(c.done)
+		defer file.Close()
+		limited := &limitedWriter{writer: file, remaining: maxSize}
+		_, _ = io.Copy(io.MultiWriter(c.terminal, limited), reader)
+		reader.Close()
+	}()
+
+	os.Stdout = writer
+	active = c
+	return path, nil
+}
+
+// Stops writing to the log file, once all output is written
+func Stop() {
+	mu.Lock()
+	c := active
+	active = nil
+	mu.Unlock()
+	if c == nil {
+		return
+	}
+
+	os.Stdout = c.terminal
+	c.pipe.Close()
+	<-c.done
+}
+
+// Returns the terminal (stdout), also while stdout is written to a log,
+// for output that should not be logged (e.g. progress bars)
+func Terminal() *os.File {
+	mu.Lock()
+	defer mu.Unlock()
+	if active != nil {
+		return active.terminal
+	}
+	return os.Stdout
+}
+
+// writes up to remaining bytes, and discards (without failing) the rest
+type limitedWriter struct {
+	writer    io.Writer
+	remaining int64
+	truncated bool
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if w.truncated {
+		return len(p), nil
+	}
+	data := p
+	if int64(len(data)) > w.remaining {
+		data = data[:w.remaining]
+		w.truncated = true
+	}
+	if _, err := w.writer.Write(data); err != nil {
+		// a failing log file must not fail the output to the terminal
+		w.truncated = true
+		return len(p), nil
+	}
+	w.remaining -= int64(len(data))
+	if w.truncated {
+		_, _ = fmt.Fprintln(w.writer, "\n[log truncated: maximum log file size reached]")
+	}
+	return len(p), nil
+}
+
+// Returns the logs in directory, newest first
+func List(directory string) ([]Log, error) {
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []Log{}, nil
+		}
+		return nil, err
+	}
+
+	logs := []Log{}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != extension {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		logs = append(logs, Log{
+			Id:      strings.TrimSuffix(entry.Name(), extension),
+			Path:    filepath.Join(directory, entry.Name()),
+			ModTime: info.ModTime(),
+			Size:    info.Size(),
+		})
+	}
+	sort.Slice(logs, func(i, j int) bool { return logs[i].ModTime.After(logs[j].ModTime) })
+	return logs, nil
+}
+
+// Returns the log with the id, or with the unique id starting with it
+func Find(directory, id string) (Log, error) {
+	logs, err := List(directory)
+	if err != nil {
+		return Log{}, err
+	}
+	matches := []Log{}
+	for _, log := range logs {
+		if log.Id == id {
+			return log, nil
+		}
+		if strings.HasPrefix(log.Id, id) {
+			matches = append(matches, log)
+		}
+	}
+	if len(matches) == 1 {
+		return matches[0], nil
+	}
+	if len(matches) > 1 {
+		return Log{}, fmt.Errorf("%d logs match '%s', specify the full id", len(matches), id)
+	}
+	return Log{}, ErrLogNotFound
+}
+
+// Removes logs older than maxAge and beyond the keepLast most recent (if
+// not 0), then the oldest logs until all logs fit in maxTotalSize.
+// Returns the number of removed logs
+func Rotate(directory string, keepLast int, maxAge time.Duration, maxTotalSize int64) (int, error) {
+	logs, err := List(directory)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	totalSize := int64(0)
+	for i, log := range logs {
+		if time.Since(log.ModTime) > maxAge || (keepLast > 0 && i >= keepLast) || totalSize+log.Size > maxTotalSize {
+			if err := os.Remove(log.Path); err != nil {
+				return removed, err
+			}
+			removed++
+			continue
+		}
+		totalSize += log.Size
+	}
+	return removed, nil
+}
+
+// Writes the log to w as it grows, until stop is closed
+func Follow(path string, w io.Writer, stop <-chan struct{}) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	for {
+		if _, err := io.Copy(w, file); err != nil {
+			return err
+		}
+		select {
+		case <-stop:
+			return nil
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
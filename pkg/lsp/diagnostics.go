@@ -0,0 +1,139 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package lsp
+
+import (
+	"net/url"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+)
+
+// Diagnostics are findings keyed by file and line, published to the
+// editor for each file of the workspace
+
+const diagnosticSource = "privado"
+
+const (
+	severityError       = 1
+	severityWarning     = 2
+	severityInformation = 3
+	severityHint        = 4
+)
+
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+type Diagnostic struct {
+	Range    Range  `json:"range"`
+	Severity int    `json:"severity"`
+	Code     string `json:"code,omitempty"`
+	Source   string `json:"source"`
+	Message  string `json:"message"`
+
+	// finding id, stable across scans
+	Data map[string]string `json:"data,omitempty"`
+}
+
+type publishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+func diagnosticSeverity(severity string) int {
+	switch severity {
+	case results.SeverityHigh:
+		return severityError
+	case results.SeverityMedium:
+		return severityWarning
+	case results.SeverityLow:
+		return severityInformation
+	}
+	return severityHint
+}
+
+func NewDiagnostic(finding results.Finding) Diagnostic {
+	// lines are 1-based in findings, 0-based in the protocol;
+	// the diagnostic spans the whole line
+	line := finding.LineNumber - 1
+	if line < 0 {
+		line = 0
+	}
+
+	message := finding.PolicyName
+	if finding.Description != "" {
+		message = finding.PolicyName + ": " + finding.Description
+	}
+
+	return Diagnostic{
+		Range:    Range{Start: Position{Line: line}, End: Position{Line: line + 1}},
+		Severity: diagnosticSeverity(finding.Severity),
+		Code:     finding.PolicyId,
+		Source:   diagnosticSource,
+		Message:  message,
+		Data:     map[string]string{"findingId": finding.Id},
+	}
+}
+
+// Groups diagnostics of the findings by file uri
+func DiagnosticsByFile(workspaceRoot string, findings []results.Finding) map[string][]Diagnostic {
+	diagnostics := map[string][]Diagnostic{}
+	for _, finding := range findings {
+		if finding.FileName == "" {
+			continue
+		}
+		uri := PathToURI(filepath.Join(workspaceRoot, filepath.FromSlash(finding.RelativeFileName())))
+		diagnostics[uri] = append(diagnostics[uri], NewDiagnostic(finding))
+	}
+	return diagnostics
+}
+
+func PathToURI(path string) string {
+	path = filepath.ToSlash(path)
+	// windows paths (C:/...) need a leading slash
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
+
+func URIToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return ""
+	}
+	path := u.Path
+	if runtime.GOOS == "windows" {
+		path = strings.TrimPrefix(path, "/")
+	}
+	return filepath.FromSlash(path)
+}
@@ -0,0 +1,216 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+)
+
+// Language server publishing findings of the workspace as diagnostics.
+// Scans are run on request (command "privado.scan") and, if enabled in
+// the initialization options, on startup and when a file is saved
+
+const CommandScan = "privado.scan"
+
+const (
+	messageTypeError = 1
+	messageTypeInfo  = 3
+)
+
+// Runs a scan of the directory writing the output to logPath
+type ScanFunc func(directory, logPath string, args []string) error
+
+type initializationOptions struct {
+	ScanOnStartup bool     `json:"scanOnStartup"`
+	ScanOnSave    bool     `json:"scanOnSave"`
+	ScanArgs      []string `json:"scanArgs"`
+}
+
+type Server struct {
+	conn    *connection
+	scan    ScanFunc
+	root    string
+	options initializationOptions
+
+	mu        sync.Mutex
+	scanning  bool
+	rescan    bool
+	published map[string]bool
+}
+
+// Serves the protocol on r and w until the client exits
+func Serve(r io.Reader, w io.Writer, scan ScanFunc) error {
+	s := &Server{conn: newConnection(r, w), scan: scan, published: map[string]bool{}}
+
+	for {
+		msg, err := s.conn.read()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		switch msg.Method {
+		case "initialize":
+			if err := s.initialize(msg); err != nil {
+				_ = s.conn.replyError(msg.Id, errorCodeInvalidParams, err.Error())
+			}
+		case "initialized":
+			s.publishResults()
+			if s.options.ScanOnStartup {
+				s.triggerScan()
+			}
+		case "textDocument/didSave":
+			if s.options.ScanOnSave {
+				s.triggerScan()
+			}
+		case "workspace/executeCommand":
+			params := struct {
+				Command string `json:"command"`
+			}{}
+			_ = json.Unmarshal(msg.Params, &params)
+			if params.Command != CommandScan {
+				_ = s.conn.replyError(msg.Id, errorCodeInvalidParams, fmt.Sprintf("unknown command: %s", params.Command))
+				continue
+			}
+			s.triggerScan()
+			_ = s.conn.reply(msg.Id, nil)
+		case "shutdown":
+			_ = s.conn.reply(msg.Id, nil)
+		case "exit":
+			return nil
+		default:
+			// notifications that are not handled are ignored
+			if msg.Id != nil {
+				_ = s.conn.replyError(msg.Id, errorCodeMethodNotFound, fmt.Sprintf("method not supported: %s", msg.Method))
+			}
+		}
+	}
+}
+
+func (s *Server) initialize(msg *message) error {
+	params := struct {
+		RootURI               string                `json:"rootUri"`
+		RootPath              string                `json:"rootPath"`
+		InitializationOptions initializationOptions `json:"initializationOptions"`
+	}{}
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		return err
+	}
+
+	s.root = URIToPath(params.RootURI)
+	if s.root == "" {
+		s.root = params.RootPath
+	}
+	if s.root == "" {
+		return fmt.Errorf("a workspace folder is required")
+	}
+	s.options = params.InitializationOptions
+
+	return s.conn.reply(msg.Id, map[string]interface{}{
+		"capabilities": map[string]interface{}{
+			"textDocumentSync": map[string]interface{}{
+				"openClose": true,
+				"save":      true,
+			},
+			"executeCommandProvider": map[string]interface{}{
+				"commands": []string{CommandScan},
+			},
+		},
+		"serverInfo": map[string]string{"name": "privado"},
+	})
+}
+
+func (s *Server) logMessage(messageType int, text string) {
+	_ = s.conn.notify("window/logMessage", map[string]interface{}{"type": messageType, "message": text})
+}
+
+// Scans the workspace in the background; a scan requested while
+// scanning runs once the current scan completes
+func (s *Server) triggerScan() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.scanning {
+		s.rescan = true
+		return
+	}
+	s.scanning = true
+
+	go func() {
+		for {
+			s.logMessage(messageTypeInfo, fmt.Sprintf("Scanning %s", s.root))
+			logPath := filepath.Join(os.TempDir(), "privado-lsp-scan.log")
+			if err := s.scan(s.root, logPath, s.options.ScanArgs); err != nil {
+				s.logMessage(messageTypeError, fmt.Sprintf("Scan failed (%s), see %s", err, logPath))
+				_ = s.conn.notify("window/showMessage", map[string]interface{}{"type": messageTypeError, "message": "Privado scan failed"})
+			} else {
+				s.publishResults()
+			}
+
+			s.mu.Lock()
+			if !s.rescan {
+				s.scanning = false
+				s.mu.Unlock()
+				return
+			}
+			s.rescan = false
+			s.mu.Unlock()
+		}
+	}()
+}
+
+// Publishes diagnostics from the results of the last scan, and clears
+// diagnostics of files without findings
+func (s *Server) publishResults() {
+	scanResults, err := results.LoadResults(filepath.Join(s.root, config.AppConfig.PrivacyResultsPathSuffix))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			s.logMessage(messageTypeError, fmt.Sprintf("Cannot read scan results: %s", err))
+		}
+		return
+	}
+
+	diagnostics := DiagnosticsByFile(s.root, scanResults.Findings())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for uri := range s.published {
+		if _, ok := diagnostics[uri]; !ok {
+			_ = s.conn.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: uri, Diagnostics: []Diagnostic{}})
+			delete(s.published, uri)
+		}
+	}
+	for uri, fileDiagnostics := range diagnostics {
+		_ = s.conn.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: uri, Diagnostics: fileDiagnostics})
+		s.published[uri] = true
+	}
+}
@@ -0,0 +1,118 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Scan metrics snapshot in the prometheus text exposition format, intended
+// for the node_exporter textfile collector (or any CI metric scraper)
+
+type ScanMetrics struct {
+	Repository         string
+	ScanDuration       time.Duration
+	ImagePullDuration  time.Duration
+	FindingsBySeverity map[string]int
+	Success            bool
+	Timestamp          time.Time
+}
+
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(value)
+}
+
+func writeMetric(buffer *bytes.Buffer, name, help, metricType string, samples map[string]float64) {
+	fmt.Fprintf(buffer, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(buffer, "# TYPE %s %s\n", name, metricType)
+
+	labels := []string{}
+	for label := range samples {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	for _, label := range labels {
+		fmt.Fprintf(buffer, "%s{%s} %v\n", name, label, samples[label])
+	}
+}
+
+func (m ScanMetrics) Render() []byte {
+	buffer := &bytes.Buffer{}
+	repoLabel := fmt.Sprintf(`repository="%s"`, escapeLabelValue(m.Repository))
+
+	writeMetric(buffer, "privado_scan_duration_seconds", "Duration of the last privado scan", "gauge",
+		map[string]float64{repoLabel: m.ScanDuration.Seconds()})
+	writeMetric(buffer, "privado_scan_image_pull_duration_seconds", "Time taken to pull the privado image for the last scan", "gauge",
+		map[string]float64{repoLabel: m.ImagePullDuration.Seconds()})
+
+	findings := map[string]float64{}
+	for severity, count := range m.FindingsBySeverity {
+		findings[fmt.Sprintf(`%s,severity="%s"`, repoLabel, escapeLabelValue(severity))] = float64(count)
+	}
+	writeMetric(buffer, "privado_scan_findings", "Number of findings in the last privado scan by severity", "gauge", findings)
+
+	exitStatus := 1.0
+	if m.Success {
+		exitStatus = 0
+	}
+	writeMetric(buffer, "privado_scan_exit_status", "Exit status of the last privado scan (0 for success)", "gauge",
+		map[string]float64{repoLabel: exitStatus})
+	writeMetric(buffer, "privado_scan_last_run_timestamp_seconds", "Unix timestamp of the last privado scan", "gauge",
+		map[string]float64{repoLabel: float64(m.Timestamp.Unix())})
+
+	return buffer.Bytes()
+}
+
+// Writes the metrics atomically (temp file + rename), as the
+// textfile collector may read the file at any point in time
+func (m ScanMetrics) WriteTextfile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+
+	tempFile, err := os.CreateTemp(filepath.Dir(path), ".privado-metrics-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+
+	if _, err := tempFile.Write(m.Render()); err != nil {
+		tempFile.Close()
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tempFile.Name(), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tempFile.Name(), path)
+}
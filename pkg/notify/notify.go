@@ -0,0 +1,85 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Notifications of completed scans and failed gates sent to chat tools
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+type Notifier interface {
+	Name() string
+	Notify(notification Notification) error
+}
+
+// A completed scan, or a failed gate (privado ci)
+type Notification struct {
+	Repository string
+	Branch     string
+	Commit     string
+
+	// the scan was gated (privado ci) and failed
+	Failed bool
+	// reason of the failure, e.g. 2 finding(s) at or above high
+	Reason string
+
+	Findings           int
+	FindingsBySeverity map[string]int
+
+	// link to the report (e.g. the CI build), if any
+	ReportURL string
+}
+
+func (n Notification) Title() string {
+	if n.Failed {
+		return fmt.Sprintf("Privado: privacy gate failed for %s", n.Repository)
+	}
+	return fmt.Sprintf("Privado: scan of %s completed", n.Repository)
+}
+
+func postJSON(url string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	response, err := httpClient.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(response.Body, 4096))
+		return fmt.Errorf("received status %d: %s", response.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
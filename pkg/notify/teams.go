@@ -0,0 +1,100 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package notify
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+)
+
+// Posts notifications as adaptive cards to a Microsoft Teams incoming
+// webhook (or a Teams workflow accepting webhook requests)
+type Teams struct {
+	WebhookURL string
+}
+
+func (t *Teams) Name() string {
+	return "teams"
+}
+
+func (t *Teams) Notify(notification Notification) error {
+	return postJSON(t.WebhookURL, newTeamsMessage(notification))
+}
+
+func newTeamsMessage(notification Notification) map[string]interface{} {
+	titleColor := "Good"
+	if notification.Failed {
+		titleColor = "Attention"
+	}
+
+	body := []map[string]interface{}{
+		{"type": "TextBlock", "size": "Large", "weight": "Bolder", "wrap": true, "color": titleColor, "text": notification.Title()},
+	}
+	if notification.Reason != "" {
+		body = append(body, map[string]interface{}{"type": "TextBlock", "wrap": true, "text": notification.Reason})
+	}
+
+	facts := []map[string]string{{"title": "Repository", "value": notification.Repository}}
+	if notification.Branch != "" {
+		facts = append(facts, map[string]string{"title": "Branch", "value": notification.Branch})
+	}
+	if notification.Commit != "" {
+		commit := notification.Commit
+		if len(commit) > 7 {
+			commit = commit[:7]
+		}
+		facts = append(facts, map[string]string{"title": "Commit", "value": commit})
+	}
+	facts = append(facts, map[string]string{"title": "Findings", "value": fmt.Sprint(notification.Findings)})
+	body = append(body, map[string]interface{}{"type": "FactSet", "facts": facts})
+
+	severities := []map[string]string{}
+	for _, severity := range results.Severities {
+		if count := notification.FindingsBySeverity[severity]; count > 0 || severity != results.SeverityUnknown {
+			severities = append(severities, map[string]string{"title": strings.ToUpper(severity[:1]) + severity[1:], "value": fmt.Sprint(count)})
+		}
+	}
+	body = append(body,
+		map[string]interface{}{"type": "TextBlock", "weight": "Bolder", "spacing": "Medium", "text": "Findings by severity"},
+		map[string]interface{}{"type": "FactSet", "facts": severities},
+	)
+
+	card := map[string]interface{}{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": "1.4",
+		"body":    body,
+	}
+	if notification.ReportURL != "" {
+		card["actions"] = []map[string]string{{"type": "Action.OpenUrl", "title": "View report", "url": notification.ReportURL}}
+	}
+
+	return map[string]interface{}{
+		"type": "message",
+		"attachments": []map[string]interface{}{
+			{"contentType": "application/vnd.microsoft.card.adaptive", "contentUrl": nil, "content": card},
+		},
+	}
+}
@@ -0,0 +1,122 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package orgscan
+
+import (
+	"fmt"
+	"net/url"
+)
+
+const bitbucketAPIHost = "https://api.bitbucket.org/2.0"
+
+type BitbucketProvider struct {
+	Workspace string
+
+	// with a username, the token is an app password; without
+	// a username, it is a (workspace/repository) access token
+	Username string
+	Token    string
+}
+
+type bitbucketPage struct {
+	Next   string                `json:"next"`
+	Values []bitbucketRepository `json:"values"`
+}
+
+type bitbucketRepository struct {
+	Slug       string `json:"slug"`
+	FullName   string `json:"full_name"`
+	Language   string `json:"language"`
+	MainBranch *struct {
+		Name string `json:"name"`
+	} `json:"mainbranch"`
+	Parent *struct{} `json:"parent"`
+	Links  struct {
+		Clone []struct {
+			Name string `json:"name"`
+			Href string `json:"href"`
+		} `json:"clone"`
+	} `json:"links"`
+}
+
+func (p *BitbucketProvider) Organization() string {
+	return p.Workspace
+}
+
+func (p *BitbucketProvider) authorizationHeader() string {
+	if p.Token == "" {
+		return ""
+	}
+	if p.Username != "" {
+		return basicAuthorization(p.Username, p.Token)
+	}
+	return fmt.Sprintf("Bearer %s", p.Token)
+}
+
+func (p *BitbucketProvider) ListRepositories() ([]Repository, error) {
+	headers := map[string]string{}
+	if authorization := p.authorizationHeader(); authorization != "" {
+		headers["Authorization"] = authorization
+	}
+
+	repositories := []Repository{}
+	for next := fmt.Sprintf("%s/repositories/%s?pagelen=100", bitbucketAPIHost, url.PathEscape(p.Workspace)); next != ""; {
+		page := bitbucketPage{}
+		if _, err := getJSON(next, headers, &page); err != nil {
+			return nil, fmt.Errorf("bitbucket: cannot list repositories of %s: %w", p.Workspace, err)
+		}
+
+		for _, r := range page.Values {
+			repository := Repository{
+				Name:     r.Slug,
+				FullName: r.FullName,
+				Language: r.Language,
+				Fork:     r.Parent != nil,
+			}
+			if r.MainBranch != nil {
+				repository.DefaultBranch = r.MainBranch.Name
+			}
+			for _, link := range r.Links.Clone {
+				if link.Name == "https" {
+					repository.CloneURL = link.Href
+				}
+			}
+			repositories = append(repositories, repository)
+		}
+
+		// absent on the last page
+		next = page.Next
+	}
+
+	return repositories, nil
+}
+
+func (p *BitbucketProvider) CloneAuthorizationHeader() string {
+	if p.Token == "" {
+		return ""
+	}
+	if p.Username != "" {
+		return basicAuthorization(p.Username, p.Token)
+	}
+	return basicAuthorization("x-token-auth", p.Token)
+}
@@ -0,0 +1,86 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package orgscan
+
+import (
+	"path"
+	"strings"
+)
+
+type Filter struct {
+	// glob patterns matched against the repository name
+	// all repositories are included if empty
+	Include []string
+	Exclude []string
+
+	// only include repositories with the primary language (any if empty)
+	Languages []string
+
+	IncludeArchived bool
+	IncludeForks    bool
+
+	// maximum number of repositories, no limit if 0
+	Limit int
+}
+
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (f Filter) Apply(repositories []Repository) []Repository {
+	filtered := []Repository{}
+	for _, repository := range repositories {
+		if (repository.Archived && !f.IncludeArchived) || (repository.Fork && !f.IncludeForks) {
+			continue
+		}
+		if len(f.Include) > 0 && !matchesAny(f.Include, repository.Name) {
+			continue
+		}
+		if matchesAny(f.Exclude, repository.Name) {
+			continue
+		}
+		if len(f.Languages) > 0 && !containsFold(f.Languages, repository.Language) {
+			continue
+		}
+
+		filtered = append(filtered, repository)
+		if f.Limit > 0 && len(filtered) >= f.Limit {
+			break
+		}
+	}
+	return filtered
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
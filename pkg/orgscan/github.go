@@ -0,0 +1,92 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package orgscan
+
+import (
+	"fmt"
+)
+
+const (
+	gitHubAPIHost = "https://api.github.com"
+	gitHubPerPage = 100
+)
+
+type GitHubProvider struct {
+	Org   string
+	Token string
+}
+
+type gitHubRepository struct {
+	Name          string `json:"name"`
+	FullName      string `json:"full_name"`
+	CloneURL      string `json:"clone_url"`
+	DefaultBranch string `json:"default_branch"`
+	Language      string `json:"language"`
+	Archived      bool   `json:"archived"`
+	Fork          bool   `json:"fork"`
+}
+
+func (p *GitHubProvider) Organization() string {
+	return p.Org
+}
+
+func (p *GitHubProvider) ListRepositories() ([]Repository, error) {
+	headers := map[string]string{"Accept": "application/vnd.github+json"}
+	if p.Token != "" {
+		headers["Authorization"] = fmt.Sprintf("Bearer %s", p.Token)
+	}
+
+	repositories := []Repository{}
+	for page := 1; ; page++ {
+		url := fmt.Sprintf("%s/orgs/%s/repos?type=all&per_page=%d&page=%d", gitHubAPIHost, p.Org, gitHubPerPage, page)
+		pageRepositories := []gitHubRepository{}
+		if _, err := getJSON(url, headers, &pageRepositories); err != nil {
+			return nil, fmt.Errorf("github: cannot list repositories of %s: %w", p.Org, err)
+		}
+
+		for _, r := range pageRepositories {
+			repositories = append(repositories, Repository{
+				Name:          r.Name,
+				FullName:      r.FullName,
+				CloneURL:      r.CloneURL,
+				DefaultBranch: r.DefaultBranch,
+				Language:      r.Language,
+				Archived:      r.Archived,
+				Fork:          r.Fork,
+			})
+		}
+
+		if len(pageRepositories) < gitHubPerPage {
+			break
+		}
+	}
+
+	return repositories, nil
+}
+
+func (p *GitHubProvider) CloneAuthorizationHeader() string {
+	if p.Token == "" {
+		return ""
+	}
+	return basicAuthorization("x-access-token", p.Token)
+}
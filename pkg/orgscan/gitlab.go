@@ -0,0 +1,102 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package orgscan
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const (
+	GitLabDefaultHost = "https://gitlab.com"
+	gitLabPerPage     = 100
+)
+
+type GitLabProvider struct {
+	// url of the GitLab instance, default: https://gitlab.com
+	Host  string
+	Group string
+	Token string
+}
+
+type gitLabProject struct {
+	Path              string      `json:"path"`
+	PathWithNamespace string      `json:"path_with_namespace"`
+	HTTPURLToRepo     string      `json:"http_url_to_repo"`
+	DefaultBranch     string      `json:"default_branch"`
+	Archived          bool        `json:"archived"`
+	ForkedFromProject interface{} `json:"forked_from_project"`
+}
+
+func (p *GitLabProvider) Organization() string {
+	return p.Group
+}
+
+func (p *GitLabProvider) host() string {
+	if p.Host == "" {
+		return GitLabDefaultHost
+	}
+	return strings.TrimSuffix(p.Host, "/")
+}
+
+// Lists projects of the group, including projects of subgroups
+func (p *GitLabProvider) ListRepositories() ([]Repository, error) {
+	headers := map[string]string{}
+	if p.Token != "" {
+		headers["PRIVATE-TOKEN"] = p.Token
+	}
+
+	repositories := []Repository{}
+	for page := "1"; page != ""; {
+		endpoint := fmt.Sprintf("%s/api/v4/groups/%s/projects?include_subgroups=true&per_page=%d&page=%s", p.host(), url.PathEscape(p.Group), gitLabPerPage, page)
+		projects := []gitLabProject{}
+		responseHeaders, err := getJSON(endpoint, headers, &projects)
+		if err != nil {
+			return nil, fmt.Errorf("gitlab: cannot list projects of %s: %w", p.Group, err)
+		}
+
+		for _, project := range projects {
+			repositories = append(repositories, Repository{
+				Name:          project.Path,
+				FullName:      project.PathWithNamespace,
+				CloneURL:      project.HTTPURLToRepo,
+				DefaultBranch: project.DefaultBranch,
+				Archived:      project.Archived,
+				Fork:          project.ForkedFromProject != nil,
+			})
+		}
+
+		// empty on the last page
+		page = responseHeaders.Get("X-Next-Page")
+	}
+
+	return repositories, nil
+}
+
+func (p *GitLabProvider) CloneAuthorizationHeader() string {
+	if p.Token == "" {
+		return ""
+	}
+	return basicAuthorization("oauth2", p.Token)
+}
@@ -0,0 +1,219 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package orgscan
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+)
+
+// Cross-service data lineage: the API endpoints exposed by one scanned
+// repository are matched with the HTTP calls (API sinks) of the others, so
+// data elements can be followed from service to service
+
+// An API endpoint of the service and the data elements it collects
+type Endpoint struct {
+	Path         string   `json:"path"`
+	DataElements []string `json:"dataElements"`
+}
+
+// An HTTP call of the service to an url (an API sink) and the data
+// elements flowing to it
+type APICall struct {
+	URL          string   `json:"url"`
+	SinkId       string   `json:"sinkId"`
+	DataElements []string `json:"dataElements"`
+}
+
+// Data flowing from the caller repository to the endpoint of the callee
+type LineageEdge struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	URL      string `json:"url"`
+	Endpoint string `json:"endpoint"`
+	// data elements the caller sends to the url
+	DataElements []string `json:"dataElements"`
+	// data elements the endpoint collects
+	CollectedDataElements []string `json:"collectedDataElements"`
+}
+
+// Returns the endpoints exposed by the service of the results, sorted by path
+func getEndpoints(scanResults *results.Results, sourceNames map[string]string) []Endpoint {
+	dataElements := map[string][]string{}
+	for _, collection := range scanResults.Collections {
+		for _, source := range collection.Collections {
+			for _, occurrence := range source.Occurrences {
+				if occurrence.EndPoint == "" {
+					continue
+				}
+				dataElements[occurrence.EndPoint] = appendUnique(dataElements[occurrence.EndPoint], getName(sourceNames, source.SourceId))
+			}
+		}
+	}
+
+	endpoints := []Endpoint{}
+	for path, names := range dataElements {
+		sort.Strings(names)
+		endpoints = append(endpoints, Endpoint{Path: path, DataElements: names})
+	}
+	sort.Slice(endpoints, func(i, j int) bool { return endpoints[i].Path < endpoints[j].Path })
+	return endpoints
+}
+
+// Returns the HTTP calls of the service of the results, sorted by url
+func getAPICalls(scanResults *results.Results, sourceNames map[string]string) []APICall {
+	calls := map[string]*APICall{}
+	for _, flows := range scanResults.DataFlow {
+		for _, flow := range flows {
+			for _, sink := range flow.Sinks {
+				for _, url := range sink.ApiUrl {
+					if url == "" {
+						continue
+					}
+					key := sink.Id + "|" + url
+					if calls[key] == nil {
+						calls[key] = &APICall{URL: url, SinkId: sink.Id, DataElements: []string{}}
+					}
+					calls[key].DataElements = appendUnique(calls[key].DataElements, getName(sourceNames, flow.SourceId))
+				}
+			}
+		}
+	}
+
+	sorted := []APICall{}
+	for _, call := range calls {
+		sort.Strings(call.DataElements)
+		sorted = append(sorted, *call)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].URL != sorted[j].URL {
+			return sorted[i].URL < sorted[j].URL
+		}
+		return sorted[i].SinkId < sorted[j].SinkId
+	})
+	return sorted
+}
+
+// Returns the edges of the data lineage between the repositories: each
+// call of a repository to an endpoint of another one
+func StitchLineage(repositories []RepositoryReport) []LineageEdge {
+	edges := []LineageEdge{}
+	for _, caller := range repositories {
+		for _, call := range caller.APICalls {
+			callPath := getPathSegments(call.URL)
+			for _, callee := range repositories {
+				if callee.Repository.FullName == caller.Repository.FullName {
+					continue
+				}
+				for _, endpoint := range callee.Endpoints {
+					if !matchesEndpoint(callPath, getPathSegments(endpoint.Path)) {
+						continue
+					}
+					edges = append(edges, LineageEdge{
+						From:                  caller.Repository.FullName,
+						To:                    callee.Repository.FullName,
+						URL:                   call.URL,
+						Endpoint:              endpoint.Path,
+						DataElements:          call.DataElements,
+						CollectedDataElements: endpoint.DataElements,
+					})
+				}
+			}
+		}
+	}
+	sort.SliceStable(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		return edges[i].To < edges[j].To
+	})
+	return edges
+}
+
+// Returns the segments of the path of the url or endpoint, parameters
+// ({id}, :id, <id>, ${id}) and interpolations replaced with "*"
+func getPathSegments(url string) []string {
+	if i := strings.Index(url, "://"); i >= 0 {
+		url = url[i+3:]
+		if j := strings.Index(url, "/"); j >= 0 {
+			url = url[j:]
+		} else {
+			url = ""
+		}
+	}
+	if i := strings.IndexAny(url, "?#"); i >= 0 {
+		url = url[:i]
+	}
+
+	segments := []string{}
+	for _, segment := range strings.Split(url, "/") {
+		switch {
+		case segment == "":
+			continue
+		case strings.ContainsAny(segment[:1], "{:<$*") || strings.Contains(segment, "${"):
+			segments = append(segments, "*")
+		default:
+			segments = append(segments, strings.ToLower(segment))
+		}
+	}
+	return segments
+}
+
+// Whether the path of the call ends with the path of the endpoint: calls
+// may go through a gateway adding a prefix. Endpoints without a literal
+// segment (e.g. /{id}) match nothing, as they would match any call
+func matchesEndpoint(callPath, endpointPath []string) bool {
+	if len(endpointPath) == 0 || len(callPath) < len(endpointPath) {
+		return false
+	}
+	literal := false
+	offset := len(callPath) - len(endpointPath)
+	for i, segment := range endpointPath {
+		if segment == "*" || callPath[offset+i] == "*" {
+			continue
+		}
+		if segment != callPath[offset+i] {
+			return false
+		}
+		literal = true
+	}
+	return literal
+}
+
+func getName(names map[string]string, id string) string {
+	if name := names[id]; name != "" {
+		return name
+	}
+	return id
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -0,0 +1,91 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package orgscan
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Repository providers enumerate repositories of an organization
+// (GitHub organization, GitLab group, Bitbucket workspace) to be scanned
+
+type Repository struct {
+	Name          string `json:"name"`
+	FullName      string `json:"fullName"`
+	CloneURL      string `json:"cloneUrl"`
+	DefaultBranch string `json:"defaultBranch"`
+	Language      string `json:"language,omitempty"`
+	Archived      bool   `json:"archived"`
+	Fork          bool   `json:"fork"`
+}
+
+type RepoProvider interface {
+	// name of the organization (group, workspace) as shown in reports
+	Organization() string
+
+	// lists all repositories visible to the provider credentials
+	ListRepositories() ([]Repository, error)
+
+	// authorization header to clone repositories, empty without credentials
+	CloneAuthorizationHeader() string
+}
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// Gets the url and decodes the json response into response
+// Returns the response headers (for pagination)
+func getJSON(url string, headers map[string]string, response interface{}) (http.Header, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("received status %d from %s", res.StatusCode, req.URL.Host)
+	}
+
+	return res.Header, json.Unmarshal(data, response)
+}
+
+func basicAuthorization(username, password string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))
+}
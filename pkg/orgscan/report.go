@@ -0,0 +1,140 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package orgscan
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+)
+
+// Aggregated report of the scans of an organization
+
+const (
+	StatusScanned = "scanned"
+	StatusFailed  = "failed"
+)
+
+type RepositoryReport struct {
+	Repository         Repository     `json:"repository"`
+	Status             string         `json:"status"`
+	Error              string         `json:"error,omitempty"`
+	LogPath            string         `json:"logPath,omitempty"`
+	ResultsPath        string         `json:"resultsPath,omitempty"`
+	Duration           string         `json:"duration"`
+	DataElements       []string       `json:"dataElements"`
+	Findings           int            `json:"findings"`
+	FindingsBySeverity map[string]int `json:"findingsBySeverity"`
+
+	// API endpoints exposed and HTTP calls made by the repository, to
+	// stitch the data lineage across repositories
+	Endpoints []Endpoint `json:"endpoints,omitempty"`
+	APICalls  []APICall  `json:"apiCalls,omitempty"`
+}
+
+type Report struct {
+	Organization       string             `json:"organization"`
+	CreatedAt          time.Time          `json:"createdAt"`
+	Repositories       []RepositoryReport `json:"repositories"`
+	Scanned            int                `json:"scanned"`
+	Failed             int                `json:"failed"`
+	FindingsBySeverity map[string]int     `json:"findingsBySeverity"`
+
+	// data element (source) name -> repositories it is found in
+	DataElements map[string][]string `json:"dataElements"`
+
+	// data flowing between repositories: calls of a repository to the
+	// API endpoints of another one
+	Lineage []LineageEdge `json:"lineage"`
+}
+
+// Populates data elements and findings of the repository from results
+func (r *RepositoryReport) LoadResults(resultsPath string) error {
+	scanResults, err := results.LoadResults(resultsPath)
+	if err != nil {
+		return err
+	}
+
+	r.ResultsPath = resultsPath
+	r.DataElements = []string{}
+	for _, source := range scanResults.Sources {
+		r.DataElements = append(r.DataElements, source.Name)
+	}
+	sort.Strings(r.DataElements)
+
+	sourceNames := map[string]string{}
+	for _, source := range scanResults.Sources {
+		sourceNames[source.Id] = source.Name
+	}
+	r.Endpoints = getEndpoints(scanResults, sourceNames)
+	r.APICalls = getAPICalls(scanResults, sourceNames)
+
+	findings := scanResults.Findings()
+	r.Findings = len(findings)
+	r.FindingsBySeverity = results.CountFindingsBySeverity(findings)
+	return nil
+}
+
+func NewReport(organization string, repositories []RepositoryReport) *Report {
+	report := &Report{
+		Organization:       organization,
+		CreatedAt:          time.Now().UTC(),
+		Repositories:       repositories,
+		FindingsBySeverity: map[string]int{},
+		DataElements:       map[string][]string{},
+	}
+
+	sort.Slice(report.Repositories, func(i, j int) bool {
+		return report.Repositories[i].Repository.Name < report.Repositories[j].Repository.Name
+	})
+
+	scanned := []RepositoryReport{}
+	for _, repository := range report.Repositories {
+		if repository.Status != StatusScanned {
+			report.Failed++
+			continue
+		}
+		scanned = append(scanned, repository)
+		report.Scanned++
+		for severity, count := range repository.FindingsBySeverity {
+			report.FindingsBySeverity[severity] += count
+		}
+		for _, dataElement := range repository.DataElements {
+			report.DataElements[dataElement] = append(report.DataElements[dataElement], repository.Repository.Name)
+		}
+	}
+	report.Lineage = StitchLineage(scanned)
+
+	return report
+}
+
+func (r *Report) Write(reportPath string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(reportPath, data, 0644)
+}
@@ -0,0 +1,202 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+package owners
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+)
+
+// Owners of the files of a repository, from its CODEOWNERS file and an
+// optional mapping file with the same syntax (rules of the mapping take
+// precedence). As with CODEOWNERS, the last matching rule of a file wins
+
+// locations of the CODEOWNERS file in a repository, by precedence
+var CodeownersPaths = []string{
+	filepath.Join(".github", "CODEOWNERS"),
+	"CODEOWNERS",
+	filepath.Join("docs", "CODEOWNERS"),
+	filepath.Join(".gitlab", "CODEOWNERS"),
+}
+
+type rule struct {
+	pattern string
+	regexp  *regexp.Regexp
+	owners  []string
+}
+
+type Owners struct {
+	rules []rule
+}
+
+// Loads the owners of the repository: its CODEOWNERS file (if any), then
+// the mapping file (if not empty, relative to the repository)
+func Load(repositoryPath, mappingFile string) (*Owners, error) {
+	o := &Owners{}
+	for _, path := range CodeownersPaths {
+		err := o.parseFile(filepath.Join(repositoryPath, path))
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+	if mappingFile != "" {
+		if !filepath.IsAbs(mappingFile) {
+			mappingFile = filepath.Join(repositoryPath, mappingFile)
+		}
+		if err := o.parseFile(mappingFile); err != nil {
+			return nil, err
+		}
+	}
+	return o, nil
+}
+
+// Returns whether no owners are defined
+func (o *Owners) IsEmpty() bool {
+	return len(o.rules) == 0
+}
+
+func (o *Owners) parseFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		// comments, empty lines and sections (gitlab: [Section])
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "[") || strings.HasPrefix(line, "^[") {
+			continue
+		}
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		pattern, err := compilePattern(fields[0])
+		if err != nil {
+			return fmt.Errorf("%s:%d: invalid pattern '%s': %w", path, lineNumber, fields[0], err)
+		}
+		// a pattern without owners removes the owners of the files
+		o.rules = append(o.rules, rule{pattern: fields[0], regexp: pattern, owners: fields[1:]})
+	}
+	return scanner.Err()
+}
+
+// Compiles a CODEOWNERS (gitignore style) pattern: patterns without a slash
+// (but a trailing one) match at any depth, others relative to the root;
+// a matching directory matches all files under it, unless the pattern ends
+// with /*
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	anchored := strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	directoryOnly := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					// **/ matches zero or more directories
+					i++
+					b.WriteString("(?:.*/)?")
+				} else {
+					b.WriteString(".*")
+				}
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	if directoryOnly {
+		b.WriteString("/.*$")
+	} else if strings.HasSuffix(pattern, "/*") {
+		// files directly in the directory only (docs/* does not match docs/a/b)
+		b.WriteString("$")
+	} else {
+		b.WriteString("(?:/.*)?$")
+	}
+	return regexp.Compile(b.String())
+}
+
+// Returns the owners of the file (relative to the repository), nil if none
+func (o *Owners) Lookup(relativePath string) []string {
+	relativePath = strings.TrimPrefix(filepath.ToSlash(relativePath), "/")
+	for i := len(o.rules) - 1; i >= 0; i-- {
+		if o.rules[i].regexp.MatchString(relativePath) {
+			return o.rules[i].owners
+		}
+	}
+	return nil
+}
+
+// Sets the owners of the findings, by the files they are located in
+func (o *Owners) Assign(findings []results.Finding) {
+	for i := range findings {
+		if findings[i].FileName != "" {
+			findings[i].Owners = o.Lookup(findings[i].RelativeFileName())
+		}
+	}
+}
+
+// Returns the findings owned by the owner (case insensitive, with or
+// without a leading @), or without owners for an empty owner
+func FilterFindings(findings []results.Finding, owner string) []results.Finding {
+	owner = strings.ToLower(strings.TrimPrefix(owner, "@"))
+	filtered := []results.Finding{}
+	for _, finding := range findings {
+		if owner == "" && len(finding.Owners) == 0 {
+			filtered = append(filtered, finding)
+			continue
+		}
+		for _, findingOwner := range finding.Owners {
+			if owner != "" && strings.ToLower(strings.TrimPrefix(findingOwner, "@")) == owner {
+				filtered = append(filtered, finding)
+				break
+			}
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,124 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package plugins
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Plugins are executables named privado-<name> on PATH, exposed as
+// 'privado <name>' (like git subcommands)
+
+const Prefix = "privado-"
+
+type Plugin struct {
+	Name string
+	Path string
+}
+
+// returns the executable extensions on windows (PATHEXT), else nil
+func getExecutableExtensions() []string {
+	if runtime.GOOS != "windows" {
+		return nil
+	}
+	pathExt := os.Getenv("PATHEXT")
+	if pathExt == "" {
+		pathExt = ".COM;.EXE;.BAT;.CMD"
+	}
+	return strings.Split(strings.ToLower(pathExt), ";")
+}
+
+// Returns the plugin name of the file, empty if it is not a plugin
+func getPluginName(fileName string, extensions []string) string {
+	if !strings.HasPrefix(fileName, Prefix) {
+		return ""
+	}
+	name := strings.TrimPrefix(fileName, Prefix)
+	if extensions != nil {
+		extension := strings.ToLower(filepath.Ext(name))
+		isExecutable := false
+		for _, executableExtension := range extensions {
+			if extension != "" && extension == executableExtension {
+				isExecutable = true
+			}
+		}
+		if !isExecutable {
+			return ""
+		}
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	// names must be usable as commands
+	if name == "" || strings.ContainsAny(name, " \t.") {
+		return ""
+	}
+	return name
+}
+
+func isExecutable(info os.FileInfo) bool {
+	if info.IsDir() {
+		return false
+	}
+	return runtime.GOOS == "windows" || info.Mode().Perm()&0111 != 0
+}
+
+// Returns plugins found in the directories of pathList (PATH), sorted by
+// name. As with commands, the first plugin found for a name takes precedence
+func Discover(pathList string) []Plugin {
+	extensions := getExecutableExtensions()
+	found := map[string]Plugin{}
+
+	for _, directory := range filepath.SplitList(pathList) {
+		if directory == "" {
+			continue
+		}
+		entries, err := os.ReadDir(directory)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := getPluginName(entry.Name(), extensions)
+			if name == "" {
+				continue
+			}
+			if _, exists := found[name]; exists {
+				continue
+			}
+			path := filepath.Join(directory, entry.Name())
+			// stat follows symbolic links (e.g. installed by package managers)
+			if info, err := os.Stat(path); err == nil && isExecutable(info) {
+				found[name] = Plugin{Name: name, Path: path}
+			}
+		}
+	}
+
+	plugins := []Plugin{}
+	for _, plugin := range found {
+		plugins = append(plugins, plugin)
+	}
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
+	return plugins
+}
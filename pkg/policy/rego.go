@@ -0,0 +1,133 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Gating decisions by Rego policies, evaluated with an installed opa
+// executable (https://www.openpolicyagent.org), OPA is not embedded. The
+// query must return a decision:
+//
+//	package privado
+//
+//	decision := {"pass": count(violations) == 0, "violations": violations}
+//	violations := [{"message": msg, "findingId": f.id} | f := input.findings[_]; f.severity == "high"; msg := ...]
+
+const DefaultQuery = "data.privado.decision"
+
+type Decision struct {
+	Pass       bool        `json:"pass"`
+	Violations []Violation `json:"violations"`
+}
+
+type Violation struct {
+	Message   string `json:"message"`
+	FindingId string `json:"findingId,omitempty"`
+	Severity  string `json:"severity,omitempty"`
+}
+
+// Returns the path of the opa executable: opaPath if set, else opa on PATH
+// Returns an error if it is not found (or not executable)
+func FindOPA(opaPath string) (string, error) {
+	if opaPath == "" {
+		path, err := exec.LookPath("opa")
+		if err != nil {
+			return "", errors.New("the opa executable is required to evaluate rego policies (https://www.openpolicyagent.org/docs/latest/#running-opa), install it or set --opa-path")
+		}
+		return path, nil
+	}
+	path, err := exec.LookPath(opaPath)
+	if err != nil {
+		return "", fmt.Errorf("the opa executable (--opa-path) cannot be run: %v", err)
+	}
+	return path, nil
+}
+
+// Evaluates the query of the policy (a .rego file or a directory of
+// policies and data) with the input document
+func EvaluateRego(opaPath, policyPath, query string, input interface{}) (*Decision, error) {
+	opa, err := FindOPA(opaPath)
+	if err != nil {
+		return nil, err
+	}
+	if query == "" {
+		query = DefaultQuery
+	}
+
+	inputFile, err := os.CreateTemp("", "privado-policy-input-*.json")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(inputFile.Name())
+	if err := json.NewEncoder(inputFile).Encode(input); err != nil {
+		inputFile.Close()
+		return nil, err
+	}
+	inputFile.Close()
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	evaluation := exec.Command(opa, "eval", "--format", "json", "--data", policyPath, "--input", inputFile.Name(), query)
+	evaluation.Stdout = stdout
+	evaluation.Stderr = stderr
+	if err := evaluation.Run(); err != nil {
+		// opa reports compilation errors as json on stdout
+		message := strings.TrimSpace(stderr.String() + stdout.String())
+		return nil, fmt.Errorf("cannot evaluate policy: %v: %s", err, message)
+	}
+
+	output := struct {
+		Result []struct {
+			Expressions []struct {
+				Value json.RawMessage `json:"value"`
+			} `json:"expressions"`
+		} `json:"result"`
+	}{}
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		return nil, fmt.Errorf("cannot parse opa output: %v", err)
+	}
+	if len(output.Result) == 0 || len(output.Result[0].Expressions) == 0 {
+		return nil, fmt.Errorf("the policy returned no decision for '%s' (is the package and rule defined?)", query)
+	}
+
+	value := output.Result[0].Expressions[0].Value
+	decision := struct {
+		Pass *bool `json:"pass"`
+		Decision
+	}{}
+	if err := json.Unmarshal(value, &decision); err != nil {
+		return nil, fmt.Errorf("invalid decision (expected {\"pass\": bool, \"violations\": [...]}): %v", err)
+	}
+	if decision.Pass == nil {
+		return nil, fmt.Errorf("invalid decision, 'pass' is missing: %s", string(value))
+	}
+	decision.Decision.Pass = *decision.Pass
+	return &decision.Decision, nil
+}
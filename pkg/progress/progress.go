@@ -0,0 +1,204 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package progress
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Structured progress events (newline delimited JSON) emitted as the
+// scan runs, so wrapper tools and UIs can show live progress without
+// scraping the output. Events are a no-op unless a reporter is started
+// or a listener (e.g. syslog) is added
+
+const (
+	EventPhaseStarted   = "phase-started"
+	EventPhaseCompleted = "phase-completed"
+	EventFindingCount   = "finding-count"
+	EventWarning        = "warning"
+	EventError          = "error"
+)
+
+// phases of a scan
+const (
+	PhaseUpdateCheck = "update-check"
+	PhaseImagePull   = "image-pull"
+	PhaseScan        = "scan"
+	PhaseResults     = "results"
+)
+
+type Event struct {
+	Type      string    `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+	Phase     string    `json:"phase,omitempty"`
+
+	// phase-completed
+	DurationMs *int64 `json:"durationMs,omitempty"`
+	Success    *bool  `json:"success,omitempty"`
+	Error      string `json:"error,omitempty"`
+
+	// finding-count
+	Total      *int           `json:"total,omitempty"`
+	BySeverity map[string]int `json:"bySeverity,omitempty"`
+
+	// warning, error
+	Message string `json:"message,omitempty"`
+
+	// error
+	Code string `json:"code,omitempty"`
+}
+
+type reporter struct {
+	mu     sync.Mutex
+	writer io.WriteCloser
+}
+
+var defaultReporter *reporter
+
+var (
+	mu        sync.Mutex
+	listeners []func(Event)
+	started   = map[string]time.Time{}
+)
+
+// Calls the listener with each event, in addition to the reporter
+func AddListener(listener func(Event)) {
+	mu.Lock()
+	defer mu.Unlock()
+	listeners = append(listeners, listener)
+}
+
+// Starts emitting events to the target: "stdout", "stderr",
+// "fd:<n>" (an inherited file descriptor) or a file path
+func Start(target string) error {
+	writer, err := openTarget(target)
+	if err != nil {
+		return err
+	}
+	defaultReporter = &reporter{writer: writer}
+	return nil
+}
+
+func openTarget(target string) (io.WriteCloser, error) {
+	switch {
+	case target == "stdout":
+		return os.Stdout, nil
+	case target == "" || target == "stderr":
+		return os.Stderr, nil
+	case strings.HasPrefix(target, "fd:"):
+		fd, err := strconv.Atoi(strings.TrimPrefix(target, "fd:"))
+		if err != nil || fd < 0 {
+			return nil, fmt.Errorf("invalid file descriptor: %s", target)
+		}
+		return os.NewFile(uintptr(fd), target), nil
+	}
+	return os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+}
+
+func IsEnabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return defaultReporter != nil || len(listeners) > 0
+}
+
+func emit(event Event) {
+	if !IsEnabled() {
+		return
+	}
+	event.Timestamp = time.Now().UTC()
+
+	mu.Lock()
+	if event.Type == EventPhaseStarted {
+		started[event.Phase] = event.Timestamp
+	}
+	eventListeners := listeners
+	mu.Unlock()
+
+	if r := defaultReporter; r != nil {
+		if data, err := json.Marshal(event); err == nil {
+			r.mu.Lock()
+			_, _ = r.writer.Write(append(data, '\n'))
+			r.mu.Unlock()
+		}
+	}
+	for _, listener := range eventListeners {
+		listener(event)
+	}
+}
+
+func PhaseStarted(phase string) {
+	emit(Event{Type: EventPhaseStarted, Phase: phase})
+}
+
+func PhaseCompleted(phase string, err error) {
+	if !IsEnabled() {
+		return
+	}
+
+	event := Event{Type: EventPhaseCompleted, Phase: phase}
+	mu.Lock()
+	if start, ok := started[phase]; ok {
+		durationMs := time.Since(start).Milliseconds()
+		event.DurationMs = &durationMs
+	}
+	mu.Unlock()
+
+	success := err == nil
+	event.Success = &success
+	if err != nil {
+		event.Error = err.Error()
+	}
+	emit(event)
+}
+
+func FindingCount(total int, bySeverity map[string]int) {
+	emit(Event{Type: EventFindingCount, Phase: PhaseResults, Total: &total, BySeverity: bySeverity})
+}
+
+func Warning(message string) {
+	emit(Event{Type: EventWarning, Message: message})
+}
+
+// Reports the error the cli exits with
+func Error(code, message string) {
+	emit(Event{Type: EventError, Code: code, Message: message})
+}
+
+// Closes the target (if it is not a standard stream)
+func Stop() {
+	r := defaultReporter
+	if r == nil {
+		return
+	}
+	defaultReporter = nil
+	if r.writer != os.Stdout && r.writer != os.Stderr {
+		_ = r.writer.Close()
+	}
+}
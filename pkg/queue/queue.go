@@ -0,0 +1,138 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package queue
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Repositories queued to be scanned in a batch (privado queue run)
+
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+type Item struct {
+	Id         string    `json:"id"`
+	Repository string    `json:"repository"`
+	AddedAt    time.Time `json:"addedAt"`
+
+	// additional flags passed to the scan
+	ScanArgs []string `json:"scanArgs,omitempty"`
+
+	Status      string    `json:"status"`
+	Attempts    int       `json:"attempts"`
+	Error       string    `json:"error,omitempty"`
+	StartedAt   time.Time `json:"startedAt,omitempty"`
+	CompletedAt time.Time `json:"completedAt,omitempty"`
+	LogPath     string    `json:"logPath,omitempty"`
+	ResultsPath string    `json:"resultsPath,omitempty"`
+}
+
+func NewItem(repository string, scanArgs []string) *Item {
+	return &Item{
+		Id:         strings.Split(uuid.NewString(), "-")[0],
+		Repository: repository,
+		AddedAt:    time.Now(),
+		ScanArgs:   scanArgs,
+		Status:     StatusPending,
+	}
+}
+
+// Returns whether the item was scanned, successfully or not
+func (i *Item) IsFinished() bool {
+	return i.Status == StatusSucceeded || i.Status == StatusFailed
+}
+
+// Returns the path of the queue file in the queue directory
+func GetQueuePath(directory string) string {
+	return filepath.Join(directory, "queue.json")
+}
+
+func Load(directory string) ([]*Item, error) {
+	queuePath := GetQueuePath(directory)
+	data, err := os.ReadFile(queuePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []*Item{}, nil
+		}
+		return nil, err
+	}
+
+	items := []*Item{}
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("cannot parse queue (%s): %v", queuePath, err)
+	}
+	return items, nil
+}
+
+func Save(directory string, items []*Item) error {
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].AddedAt.Before(items[j].AddedAt)
+	})
+
+	data, err := json.MarshalIndent(items, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(directory, os.ModePerm); err != nil {
+		return err
+	}
+
+	// write atomically, a running queue may be reading the file
+	queuePath := GetQueuePath(directory)
+	tmpPath := queuePath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, queuePath)
+}
+
+// Replaces the item with the same id in the queue, without overwriting
+// concurrent changes to other items. Items removed meanwhile are not added back
+func Update(directory string, item Item) error {
+	items, err := Load(directory)
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		if items[i].Id == item.Id {
+			updated := item
+			items[i] = &updated
+			return Save(directory, items)
+		}
+	}
+	return nil
+}
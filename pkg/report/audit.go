@@ -0,0 +1,328 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package report
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Privado-Inc/privado-cli/pkg/baseline"
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+)
+
+// policies listed in the executive summary
+const maxTopPolicies = 10
+
+// Count of findings of a policy
+type PolicyCount struct {
+	Name     string
+	Severity string
+	Findings int
+}
+
+// Contents of the scan report for audits (privado report): an executive
+// summary of the data elements, data recipients and findings of the scan,
+// and the details of every finding
+type Audit struct {
+	Repository  string
+	Branch      string
+	CommitId    string
+	ScannedAt   time.Time
+	GeneratedAt time.Time
+	CLIVersion  string
+	CoreVersion string
+
+	// findings the report is limited to, e.g. "owned by @payments-team" or
+	// "in services/", empty for all
+	Scope string
+
+	Findings           []results.Finding
+	FindingsBySeverity map[string]int
+	TopPolicies        []PolicyCount
+
+	DataElements          []results.Source
+	SensitiveDataElements int
+
+	// names of the sinks personal data flows to, by sink category
+	DataRecipients map[string][]string
+
+	sourceNames map[string]string
+	sinkNames   map[string]string
+}
+
+func NewAudit(r *results.Results, generatedAt time.Time) Audit {
+	a := Audit{
+		Repository:     r.RepoName,
+		Branch:         r.GitMetadata.BranchName,
+		CommitId:       r.GitMetadata.CommitId,
+		GeneratedAt:    generatedAt,
+		CLIVersion:     r.PrivadoCLIVersion,
+		CoreVersion:    r.PrivadoCoreVersion,
+		DataElements:   r.Sources,
+		DataRecipients: map[string][]string{},
+		sourceNames:    map[string]string{},
+		sinkNames:      map[string]string{},
+	}
+	if r.CreatedAt > 0 {
+		a.ScannedAt = time.UnixMilli(r.CreatedAt)
+	}
+
+	for _, source := range r.Sources {
+		a.sourceNames[source.Id] = source.Name
+		if source.IsSensitive {
+			a.SensitiveDataElements++
+		}
+	}
+	for _, sink := range r.Sinks {
+		a.sinkNames[sink.Id] = sink.Name
+	}
+	for _, sink := range r.DataFlowSinks() {
+		category := baseline.GetSinkCategory(sink)
+		if category == "" {
+			category = "other"
+		}
+		a.DataRecipients[category] = append(a.DataRecipients[category], a.getSinkName(sink.Id))
+	}
+	return a.WithFindings(r.Findings())
+}
+
+// Returns the audit of the findings only (e.g. of an owner), with their
+// counts. Data elements and recipients are those of the whole scan
+func (a Audit) WithFindings(findings []results.Finding) Audit {
+	a.Findings = findings
+	a.FindingsBySeverity = results.CountFindingsBySeverity(findings)
+	a.TopPolicies = nil
+
+	policies := map[string]*PolicyCount{}
+	for _, finding := range a.Findings {
+		if policies[finding.PolicyId] == nil {
+			policies[finding.PolicyId] = &PolicyCount{Name: finding.PolicyName, Severity: finding.Severity}
+		}
+		policies[finding.PolicyId].Findings++
+	}
+	for _, policy := range policies {
+		a.TopPolicies = append(a.TopPolicies, *policy)
+	}
+	sort.Slice(a.TopPolicies, func(i, j int) bool {
+		if a.TopPolicies[i].Findings != a.TopPolicies[j].Findings {
+			return a.TopPolicies[i].Findings > a.TopPolicies[j].Findings
+		}
+		return a.TopPolicies[i].Name < a.TopPolicies[j].Name
+	})
+	if len(a.TopPolicies) > maxTopPolicies {
+		a.TopPolicies = a.TopPolicies[:maxTopPolicies]
+	}
+	return a
+}
+
+func (a Audit) getSourceName(id string) string {
+	if name := a.sourceNames[id]; name != "" {
+		return name
+	}
+	return id
+}
+
+func (a Audit) getSinkName(id string) string {
+	if name := a.sinkNames[id]; name != "" {
+		return name
+	}
+	return id
+}
+
+// Returns the sink categories with data recipients, sorted
+func (a Audit) recipientCategories() []string {
+	categories := []string{}
+	for category := range a.DataRecipients {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	return categories
+}
+
+func (a Audit) countRecipients() int {
+	count := 0
+	for _, names := range a.DataRecipients {
+		count += len(names)
+	}
+	return count
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return "unknown"
+	}
+	return t.Local().Format("2006-01-02 15:04 MST")
+}
+
+// Returns the executive summary paragraph of the report
+func (a Audit) overview() string {
+	return fmt.Sprintf(
+		"The scan of %s found %d data element(s) processed in the code, %d of them sensitive, flowing to %d data recipient(s) (third parties, storages and leakages). "+
+			"%d finding(s) violate privacy policies: %d high, %d medium, %d low and %d of unknown severity.",
+		a.Repository, len(a.DataElements), a.SensitiveDataElements, a.countRecipients(), len(a.Findings),
+		a.FindingsBySeverity[results.SeverityHigh], a.FindingsBySeverity[results.SeverityMedium],
+		a.FindingsBySeverity[results.SeverityLow], a.FindingsBySeverity[results.SeverityUnknown],
+	)
+}
+
+// Renders the report as a PDF document: cover page, executive summary and
+// an appendix with the details of every finding
+func (a Audit) RenderPDF() []byte {
+	d := newPDFDocument()
+
+	// cover page
+	d.space(180)
+	d.text("Privacy Code Scan Report", fontBold, 26, 0)
+	d.space(12)
+	d.text(a.Repository, fontRegular, 16, 0)
+	if a.Scope != "" {
+		d.text(fmt.Sprintf("Findings %s", a.Scope), fontRegular, 13, 0)
+	}
+	d.space(24)
+	d.rule()
+	if a.Branch != "" {
+		d.text(fmt.Sprintf("Branch: %s", a.Branch), fontRegular, 11, 0)
+	}
+	if a.CommitId != "" {
+		d.text(fmt.Sprintf("Commit: %s", a.CommitId), fontRegular, 11, 0)
+	}
+	d.text(fmt.Sprintf("Scanned: %s", formatDate(a.ScannedAt)), fontRegular, 11, 0)
+	d.text(fmt.Sprintf("Generated: %s", formatDate(a.GeneratedAt)), fontRegular, 11, 0)
+	d.text(fmt.Sprintf("Privado CLI %s, engine %s", a.CLIVersion, a.CoreVersion), fontRegular, 11, 0)
+
+	// executive summary
+	d.newPage()
+	d.text("Executive summary", fontBold, 18, 0)
+	d.space(8)
+	d.text(a.overview(), fontRegular, 11, 0)
+	d.space(12)
+
+	d.text("Findings by severity", fontBold, 13, 0)
+	d.rule()
+	for _, severity := range results.Severities {
+		d.text(fmt.Sprintf("%s: %d", severity, a.FindingsBySeverity[severity]), fontRegular, 11, 12)
+	}
+	d.space(12)
+
+	if len(a.TopPolicies) > 0 {
+		d.text("Most violated policies", fontBold, 13, 0)
+		d.rule()
+		for _, policy := range a.TopPolicies {
+			d.text(fmt.Sprintf("%s (%s): %d finding(s)", policy.Name, policy.Severity, policy.Findings), fontRegular, 11, 12)
+		}
+		d.space(12)
+	}
+
+	d.text("Data elements", fontBold, 13, 0)
+	d.rule()
+	if len(a.DataElements) == 0 {
+		d.text("No data elements found", fontRegular, 11, 12)
+	}
+	for _, source := range a.DataElements {
+		line := a.getSourceName(source.Id)
+		if source.Category != "" {
+			line = fmt.Sprintf("%s (%s)", line, source.Category)
+		}
+		if source.IsSensitive {
+			line += ", sensitive"
+		}
+		d.text(line, fontRegular, 11, 12)
+	}
+	d.space(12)
+
+	d.text("Data recipients", fontBold, 13, 0)
+	d.rule()
+	if len(a.DataRecipients) == 0 {
+		d.text("No data flows to recipients found", fontRegular, 11, 12)
+	}
+	for _, category := range a.recipientCategories() {
+		d.text(category, fontBold, 11, 12)
+		d.text(strings.Join(a.DataRecipients[category], ", "), fontRegular, 11, 24)
+	}
+
+	// findings appendix
+	d.newPage()
+	d.text("Appendix: findings", fontBold, 18, 0)
+	d.space(8)
+	if len(a.Findings) == 0 {
+		d.text("No findings", fontRegular, 11, 0)
+	}
+	for i, finding := range a.Findings {
+		d.space(6)
+		d.text(fmt.Sprintf("%d. [%s] %s", i+1, strings.ToUpper(finding.Severity), finding.PolicyName), fontBold, 11, 0)
+		if finding.Description != "" {
+			d.text(finding.Description, fontRegular, 10, 12)
+		}
+		if finding.FileName != "" {
+			d.text(fmt.Sprintf("Location: %s:%d", filepath.ToSlash(finding.RelativeFileName()), finding.LineNumber), fontRegular, 10, 12)
+		}
+		d.text(fmt.Sprintf("Data element: %s", a.getSourceName(finding.SourceId)), fontRegular, 10, 12)
+		if finding.SinkId != "" {
+			d.text(fmt.Sprintf("Recipient: %s", a.getSinkName(finding.SinkId)), fontRegular, 10, 12)
+		}
+		if len(finding.Owners) > 0 {
+			d.text(fmt.Sprintf("Owners: %s", strings.Join(finding.Owners, ", ")), fontRegular, 10, 12)
+		}
+		if finding.Sample != "" {
+			d.text(fmt.Sprintf("Code: %s", finding.Sample), fontRegular, 10, 12)
+		}
+		d.text(fmt.Sprintf("Finding id: %s", finding.Id), fontRegular, 9, 12)
+	}
+
+	return d.bytes()
+}
+
+// Renders the report as markdown, with the same contents as the PDF
+func (a Audit) RenderMarkdown() string {
+	elements := Section{Title: fmt.Sprintf("Data elements (%d)", len(a.DataElements))}
+	for _, source := range a.DataElements {
+		line := a.getSourceName(source.Id)
+		if source.Category != "" {
+			line = fmt.Sprintf("%s (%s)", line, source.Category)
+		}
+		if source.IsSensitive {
+			line += ", sensitive"
+		}
+		elements.Lines = append(elements.Lines, escapeMarkdownCell(line))
+	}
+	recipients := Section{Title: fmt.Sprintf("Data recipients (%d)", a.countRecipients())}
+	for _, category := range a.recipientCategories() {
+		recipients.Lines = append(recipients.Lines, fmt.Sprintf("%s: %s", category, escapeMarkdownCell(strings.Join(a.DataRecipients[category], ", "))))
+	}
+
+	title := fmt.Sprintf("Privacy code scan report: %s", a.Repository)
+	if a.Scope != "" {
+		title = fmt.Sprintf("%s (findings %s)", title, a.Scope)
+	}
+	r := Report{
+		Title:    title,
+		Passed:   len(a.Findings) == 0,
+		Status:   a.overview(),
+		Findings: a.Findings,
+		Sections: []Section{elements, recipients},
+	}
+	return r.RenderMarkdown()
+}
@@ -0,0 +1,122 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+)
+
+// Findings in the format of the Generic Findings Import of DefectDojo
+// (scan type "Generic Findings Import"), so privacy findings are tracked
+// with the security findings of the product
+
+type defectDojoReport struct {
+	Findings []defectDojoFinding `json:"findings"`
+}
+
+type defectDojoFinding struct {
+	Title            string   `json:"title"`
+	Description      string   `json:"description"`
+	Severity         string   `json:"severity"`
+	Date             string   `json:"date,omitempty"`
+	FilePath         string   `json:"file_path,omitempty"`
+	Line             int      `json:"line,omitempty"`
+	ComponentName    string   `json:"component_name,omitempty"`
+	UniqueIdFromTool string   `json:"unique_id_from_tool"`
+	VulnIdFromTool   string   `json:"vuln_id_from_tool,omitempty"`
+	Tags             []string `json:"tags,omitempty"`
+	StaticFinding    bool     `json:"static_finding"`
+	DynamicFinding   bool     `json:"dynamic_finding"`
+}
+
+var defectDojoSeverities = map[string]string{
+	results.SeverityHigh:    "High",
+	results.SeverityMedium:  "Medium",
+	results.SeverityLow:     "Low",
+	results.SeverityUnknown: "Info",
+}
+
+// Renders the findings as a DefectDojo Generic Findings Import report
+func (a Audit) RenderDefectDojo() ([]byte, error) {
+	date := ""
+	if !a.ScannedAt.IsZero() {
+		date = a.ScannedAt.Format("2006-01-02")
+	}
+
+	report := defectDojoReport{Findings: []defectDojoFinding{}}
+	for _, finding := range a.Findings {
+		report.Findings = append(report.Findings, defectDojoFinding{
+			Title:            fmt.Sprintf("%s: %s", finding.PolicyName, a.getSourceName(finding.SourceId)),
+			Description:      a.describeFinding(finding),
+			Severity:         defectDojoSeverities[finding.Severity],
+			Date:             date,
+			FilePath:         filepath.ToSlash(finding.RelativeFileName()),
+			Line:             finding.LineNumber,
+			ComponentName:    a.Repository,
+			UniqueIdFromTool: finding.Id,
+			VulnIdFromTool:   finding.PolicyId,
+			Tags:             getOwnerTags(finding.Owners),
+			StaticFinding:    true,
+		})
+	}
+	return json.MarshalIndent(report, "", "  ")
+}
+
+// Returns the details of the finding as markdown, as in the findings
+// appendix of the report
+func (a Audit) describeFinding(finding results.Finding) string {
+	lines := []string{}
+	if finding.Description != "" {
+		lines = append(lines, finding.Description, "")
+	}
+	lines = append(lines, fmt.Sprintf("**Data element:** %s", a.getSourceName(finding.SourceId)))
+	if finding.SinkId != "" {
+		lines = append(lines, fmt.Sprintf("**Recipient:** %s", a.getSinkName(finding.SinkId)))
+	}
+	if len(finding.Owners) > 0 {
+		lines = append(lines, fmt.Sprintf("**Owners:** %s", strings.Join(finding.Owners, ", ")))
+	}
+	if finding.FileName != "" {
+		lines = append(lines, fmt.Sprintf("**Location:** %s:%d", filepath.ToSlash(finding.RelativeFileName()), finding.LineNumber))
+	}
+	if finding.Sample != "" {
+		lines = append(lines, "", "```", finding.Sample, "```")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Returns the owners as tags (owner:payments-team), tags cannot contain @ or /
+func getOwnerTags(owners []string) []string {
+	tags := []string{}
+	for _, owner := range owners {
+		tags = append(tags, "owner:"+strings.NewReplacer("@", "", "/", "-").Replace(owner))
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	return tags
+}
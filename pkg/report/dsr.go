@@ -0,0 +1,364 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Privado-Inc/privado-cli/pkg/baseline"
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+)
+
+// Data subject request (DSR) report (privado report --template dsr): for
+// each data element, every storage and third-party recipient it flows to,
+// with the code sending it there, to help engineers respond to access and
+// deletion requests with concrete code and system references
+
+// kinds of locations of data elements in the report, in order
+var dsrLocationKinds = []struct {
+	category string
+	title    string
+}{
+	{"external-storage", "Storages"},
+	{"third-party-sharing", "Third parties"},
+	{"leakage", "Logs and other leakages"},
+}
+
+const dsrGuidance = "For an access request, retrieve the data of the subject from every storage and third party listed for the requested data elements. " +
+	"For a deletion request, delete it there as well, including from the logs, and ask the third parties to delete it. " +
+	"The code references show where the data is sent to each of them."
+
+// A storage or recipient of a data element, with the code sending the
+// data element to it (file:line: code)
+type DSRLocation struct {
+	SinkId     string
+	Name       string
+	Domains    []string
+	References []string
+}
+
+type DSRDataElement struct {
+	Id        string
+	Name      string
+	Category  string
+	Sensitive bool
+
+	// locations by category (see dsrLocationKinds)
+	Locations map[string][]DSRLocation
+}
+
+type DSR struct {
+	Repository  string
+	Branch      string
+	CommitId    string
+	ScannedAt   time.Time
+	GeneratedAt time.Time
+
+	// data elements flowing to storages or recipients, sorted by name
+	DataElements []DSRDataElement
+	// names of the data elements without any
+	UnsharedDataElements []string
+}
+
+func NewDSR(r *results.Results, generatedAt time.Time) DSR {
+	d := DSR{
+		Repository:           r.RepoName,
+		Branch:               r.GitMetadata.BranchName,
+		CommitId:             r.GitMetadata.CommitId,
+		GeneratedAt:          generatedAt,
+		DataElements:         []DSRDataElement{},
+		UnsharedDataElements: []string{},
+	}
+	if r.CreatedAt > 0 {
+		d.ScannedAt = time.UnixMilli(r.CreatedAt)
+	}
+	sinkNames := map[string]string{}
+	for _, sink := range r.Sinks {
+		sinkNames[sink.Id] = sink.Name
+	}
+
+	// locations of each data element by sink id, with their references
+	locations := map[string]map[string]*DSRLocation{}
+	categories := map[string]string{}
+	for _, flows := range r.DataFlow {
+		for _, flow := range flows {
+			for _, sink := range flow.Sinks {
+				category := baseline.GetSinkCategory(sink.Sink)
+				if category == "" {
+					continue
+				}
+				categories[sink.Id] = category
+				if locations[flow.SourceId] == nil {
+					locations[flow.SourceId] = map[string]*DSRLocation{}
+				}
+				location := locations[flow.SourceId][sink.Id]
+				if location == nil {
+					name := sinkNames[sink.Id]
+					if name == "" {
+						name = sink.Name
+					}
+					if name == "" {
+						name = sink.Id
+					}
+					location = &DSRLocation{SinkId: sink.Id, Name: name, Domains: sink.Domains}
+					locations[flow.SourceId][sink.Id] = location
+				}
+				for _, path := range sink.Paths {
+					if len(path.Path) == 0 {
+						continue
+					}
+					last := path.Path[len(path.Path)-1]
+					reference := fmt.Sprintf("%s:%d", filepath.ToSlash(last.RelativeFileName()), last.LineNumber)
+					if sample := strings.TrimSpace(last.Sample); sample != "" {
+						reference = fmt.Sprintf("%s: %s", reference, sample)
+					}
+					location.References = appendUnique(location.References, reference)
+				}
+			}
+		}
+	}
+
+	for _, source := range r.Sources {
+		name := source.Name
+		if name == "" {
+			name = source.Id
+		}
+		if len(locations[source.Id]) == 0 {
+			d.UnsharedDataElements = append(d.UnsharedDataElements, name)
+			continue
+		}
+		element := DSRDataElement{Id: source.Id, Name: name, Category: source.Category, Sensitive: source.IsSensitive, Locations: map[string][]DSRLocation{}}
+		for sinkId, location := range locations[source.Id] {
+			sort.Strings(location.References)
+			element.Locations[categories[sinkId]] = append(element.Locations[categories[sinkId]], *location)
+		}
+		for _, kind := range element.Locations {
+			sort.Slice(kind, func(i, j int) bool { return kind[i].Name < kind[j].Name })
+		}
+		d.DataElements = append(d.DataElements, element)
+	}
+	sort.Slice(d.DataElements, func(i, j int) bool { return d.DataElements[i].Name < d.DataElements[j].Name })
+	sort.Strings(d.UnsharedDataElements)
+	return d
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
+
+func (e DSRDataElement) title() string {
+	title := e.Name
+	if e.Category != "" {
+		title = fmt.Sprintf("%s (%s)", title, e.Category)
+	}
+	if e.Sensitive {
+		title += ", sensitive"
+	}
+	return title
+}
+
+func (l DSRLocation) title() string {
+	if len(l.Domains) == 0 {
+		return l.Name
+	}
+	return fmt.Sprintf("%s (%s)", l.Name, strings.Join(l.Domains, ", "))
+}
+
+func (d DSR) overview() string {
+	return fmt.Sprintf("%d data element(s) of %s are stored or shared with third parties, %d other data element(s) are only processed in the code.",
+		len(d.DataElements), d.Repository, len(d.UnsharedDataElements))
+}
+
+// Renders the report as markdown: a section for each data element with
+// its storages and recipients
+func (d DSR) RenderMarkdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Data subject request report: %s\n\n", d.Repository)
+	if d.Branch != "" || d.CommitId != "" {
+		fmt.Fprintf(&b, "Branch `%s`, commit `%s`. ", d.Branch, d.CommitId)
+	}
+	fmt.Fprintf(&b, "Scanned %s, generated %s.\n\n", formatDate(d.ScannedAt), formatDate(d.GeneratedAt))
+	fmt.Fprintf(&b, "%s\n\n%s\n\n", d.overview(), dsrGuidance)
+
+	for _, element := range d.DataElements {
+		fmt.Fprintf(&b, "## %s\n\n", element.title())
+		for _, kind := range dsrLocationKinds {
+			locations := element.Locations[kind.category]
+			if len(locations) == 0 {
+				continue
+			}
+			fmt.Fprintf(&b, "### %s\n\n", kind.title)
+			for _, location := range locations {
+				fmt.Fprintf(&b, "- **%s**\n", location.title())
+				for _, reference := range location.References {
+					fmt.Fprintf(&b, "  - `%s`\n", strings.ReplaceAll(reference, "`", "'"))
+				}
+			}
+			b.WriteString("\n")
+		}
+	}
+	if len(d.UnsharedDataElements) > 0 {
+		fmt.Fprintf(&b, "## Data elements only processed in the code (%d)\n\n%s\n", len(d.UnsharedDataElements), strings.Join(d.UnsharedDataElements, ", "))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// Renders the report as a PDF document
+func (d DSR) RenderPDF() []byte {
+	p := newPDFDocument()
+	p.text("Data Subject Request Report", fontBold, 22, 0)
+	p.space(6)
+	p.text(d.Repository, fontRegular, 14, 0)
+	if d.Branch != "" {
+		p.text(fmt.Sprintf("Branch: %s", d.Branch), fontRegular, 11, 0)
+	}
+	if d.CommitId != "" {
+		p.text(fmt.Sprintf("Commit: %s", d.CommitId), fontRegular, 11, 0)
+	}
+	p.text(fmt.Sprintf("Scanned: %s", formatDate(d.ScannedAt)), fontRegular, 11, 0)
+	p.text(fmt.Sprintf("Generated: %s", formatDate(d.GeneratedAt)), fontRegular, 11, 0)
+	p.space(8)
+	p.text(d.overview(), fontRegular, 11, 0)
+	p.space(4)
+	p.text(dsrGuidance, fontRegular, 11, 0)
+
+	for _, element := range d.DataElements {
+		p.space(12)
+		p.text(element.title(), fontBold, 13, 0)
+		p.rule()
+		for _, kind := range dsrLocationKinds {
+			locations := element.Locations[kind.category]
+			if len(locations) == 0 {
+				continue
+			}
+			p.text(kind.title, fontBold, 11, 12)
+			for _, location := range locations {
+				p.text(location.title(), fontRegular, 11, 24)
+				for _, reference := range location.References {
+					p.text(reference, fontRegular, 9, 36)
+				}
+			}
+		}
+	}
+	if len(d.UnsharedDataElements) > 0 {
+		p.space(12)
+		p.text(fmt.Sprintf("Data elements only processed in the code (%d)", len(d.UnsharedDataElements)), fontBold, 13, 0)
+		p.rule()
+		p.text(strings.Join(d.UnsharedDataElements, ", "), fontRegular, 11, 12)
+	}
+	return p.bytes()
+}
+
+type htmlDSRKind struct {
+	Title     string
+	Locations []DSRLocation
+}
+
+type htmlDSRElement struct {
+	Title string
+	Kinds []htmlDSRKind
+}
+
+type htmlDSR struct {
+	DSR
+	Overview           string
+	Guidance           string
+	Scanned, Generated string
+	Elements           []htmlDSRElement
+}
+
+var htmlDSRTemplate = template.Must(template.New("dsr").Funcs(template.FuncMap{
+	"title": func(l DSRLocation) string { return l.title() },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Data subject request report: {{.Repository}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 0 auto; max-width: 960px; padding: 24px; color: #1f2328; }
+h2 { border-bottom: 1px solid #d0d7de; padding-bottom: 4px; margin-top: 32px; }
+.meta { color: #59636e; }
+code { background: #f6f8fa; border-radius: 4px; padding: 2px 4px; word-break: break-all; }
+</style>
+</head>
+<body>
+<h1>Data subject request report</h1>
+<div class="meta">
+<p><strong>{{.Repository}}</strong>{{if .Branch}} &middot; branch {{.Branch}}{{end}}{{if .CommitId}} &middot; commit <code>{{.CommitId}}</code>{{end}}</p>
+<p>Scanned {{.Scanned}} &middot; generated {{.Generated}}</p>
+</div>
+<p>{{.Overview}}</p>
+<p>{{.Guidance}}</p>
+{{range .Elements}}<h2>{{.Title}}</h2>
+{{range .Kinds}}<h3>{{.Title}}</h3>
+<ul>
+{{range .Locations}}<li><strong>{{title .}}</strong>
+<ul>
+{{range .References}}<li><code>{{.}}</code></li>
+{{end}}</ul>
+</li>
+{{end}}</ul>
+{{end}}{{end}}
+{{if .UnsharedDataElements}}<h2>Data elements only processed in the code ({{len .UnsharedDataElements}})</h2>
+<p>{{range $i, $name := .UnsharedDataElements}}{{if $i}}, {{end}}{{$name}}{{end}}</p>
+{{end}}</body>
+</html>
+`))
+
+// Renders the report as a self-contained HTML page
+func (d DSR) RenderHTML() ([]byte, error) {
+	r := htmlDSR{
+		DSR:       d,
+		Overview:  d.overview(),
+		Guidance:  dsrGuidance,
+		Scanned:   formatDate(d.ScannedAt),
+		Generated: formatDate(d.GeneratedAt),
+	}
+	for _, element := range d.DataElements {
+		e := htmlDSRElement{Title: element.title()}
+		for _, kind := range dsrLocationKinds {
+			if locations := element.Locations[kind.category]; len(locations) > 0 {
+				e.Kinds = append(e.Kinds, htmlDSRKind{Title: kind.title, Locations: locations})
+			}
+		}
+		r.Elements = append(r.Elements, e)
+	}
+
+	var b bytes.Buffer
+	if err := htmlDSRTemplate.Execute(&b, r); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
@@ -0,0 +1,224 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+package report
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Delivery of reports by email (privado report --email), e.g. a weekly
+// report of a scheduled scan sent to the data protection officer
+
+type SMTPServer struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	// implicit TLS, instead of STARTTLS when the server supports it
+	TLS bool
+}
+
+type Attachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+type Email struct {
+	To          []string
+	Subject     string
+	Body        string
+	Attachments []Attachment
+}
+
+// Returns the subject of the email of the report
+func (a Audit) EmailSubject() string {
+	return fmt.Sprintf("Privado report: %s (%d finding(s))", a.Repository, len(a.Findings))
+}
+
+// Renders the body of the email of the report: the executive summary and
+// the policies with the most findings, the report itself is attached
+func (a Audit) RenderEmailBody() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Privacy code scan report: %s\n\n", a.Repository)
+	fmt.Fprintf(&b, "Branch: %s\n", valueOrUnknown(a.Branch))
+	fmt.Fprintf(&b, "Commit: %s\n", valueOrUnknown(a.CommitId))
+	fmt.Fprintf(&b, "Scanned: %s\n\n", formatDate(a.ScannedAt))
+	fmt.Fprintf(&b, "%s\n", a.overview())
+	if len(a.TopPolicies) > 0 {
+		fmt.Fprintf(&b, "\nPolicies with the most findings:\n")
+		for _, policy := range a.TopPolicies {
+			fmt.Fprintf(&b, "- %s (%s): %d\n", policy.Name, policy.Severity, policy.Findings)
+		}
+	}
+	fmt.Fprintf(&b, "\nThe full report is attached.\n")
+	return b.String()
+}
+
+func valueOrUnknown(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+	return value
+}
+
+// Sends the email with the server, with STARTTLS when the server supports
+// it (required to authenticate, except on localhost)
+func (s SMTPServer) Send(email Email) error {
+	if s.Host == "" || s.From == "" {
+		return fmt.Errorf("the host and sender address of the SMTP server are required")
+	}
+	if len(email.To) == 0 {
+		return fmt.Errorf("no recipients")
+	}
+	message, err := email.build(s.From, time.Now())
+	if err != nil {
+		return err
+	}
+
+	port := s.Port
+	if port == 0 {
+		port = 587
+		if s.TLS {
+			port = 465
+		}
+	}
+	address := net.JoinHostPort(s.Host, strconv.Itoa(port))
+	tlsConfig := &tls.Config{ServerName: s.Host}
+
+	var client *smtp.Client
+	if s.TLS {
+		conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 30 * time.Second}, "tcp", address, tlsConfig)
+		if err != nil {
+			return err
+		}
+		if client, err = smtp.NewClient(conn, s.Host); err != nil {
+			conn.Close()
+			return err
+		}
+	} else {
+		conn, err := net.DialTimeout("tcp", address, 30*time.Second)
+		if err != nil {
+			return err
+		}
+		if client, err = smtp.NewClient(conn, s.Host); err != nil {
+			conn.Close()
+			return err
+		}
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				client.Close()
+				return err
+			}
+		}
+	}
+	defer client.Close()
+
+	if s.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.Username, s.Password, s.Host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(s.From); err != nil {
+		return err
+	}
+	for _, recipient := range email.To {
+		if err := client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("recipient %s rejected: %w", recipient, err)
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(message); err != nil {
+		writer.Close()
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// Returns the MIME message: the body as text and the attachments (base64)
+func (e Email) build(from string, date time.Time) ([]byte, error) {
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+
+	textHeader := textproto.MIMEHeader{}
+	textHeader.Set("Content-Type", "text/plain; charset=utf-8")
+	textHeader.Set("Content-Transfer-Encoding", "base64")
+	part, err := parts.CreatePart(textHeader)
+	if err != nil {
+		return nil, err
+	}
+	writeBase64Lines(part, []byte(strings.ReplaceAll(e.Body, "\n", "\r\n")))
+
+	for _, attachment := range e.Attachments {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", attachment.ContentType)
+		header.Set("Content-Transfer-Encoding", "base64")
+		header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Name}))
+		part, err := parts.CreatePart(header)
+		if err != nil {
+			return nil, err
+		}
+		writeBase64Lines(part, attachment.Data)
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\n", from)
+	fmt.Fprintf(&message, "To: %s\r\n", strings.Join(e.To, ", "))
+	fmt.Fprintf(&message, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", e.Subject))
+	fmt.Fprintf(&message, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	fmt.Fprintf(&message, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&message, "Content-Type: %s\r\n\r\n", mime.FormatMediaType("multipart/mixed", map[string]string{"boundary": parts.Boundary()}))
+	message.Write(body.Bytes())
+	return message.Bytes(), nil
+}
+
+// writes data base64 encoded, in lines of 76 characters (RFC 2045)
+func writeBase64Lines(w io.Writer, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		fmt.Fprintf(w, "%s\r\n", encoded[:76])
+		encoded = encoded[76:]
+	}
+	fmt.Fprintf(w, "%s\r\n", encoded)
+}
@@ -0,0 +1,168 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"path/filepath"
+	"strings"
+
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+)
+
+// The report as a self-contained HTML page (no external resources), with
+// the same contents as the PDF, to be opened locally after a scan
+
+type htmlFinding struct {
+	results.Finding
+	Location    string
+	DataElement string
+	Recipient   string
+}
+
+type htmlReport struct {
+	Audit
+	Overview           string
+	Scanned, Generated string
+	Severities         []string
+	DataElementLines   []string
+	RecipientLines     map[string]string
+	Categories         []string
+	HTMLFindings       []htmlFinding
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"upper": strings.ToUpper,
+	"join":  strings.Join,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Privacy code scan report: {{.Repository}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 0 auto; max-width: 960px; padding: 24px; color: #1f2328; }
+h1 { margin-bottom: 4px; }
+h2 { border-bottom: 1px solid #d0d7de; padding-bottom: 4px; margin-top: 32px; }
+.meta { color: #59636e; }
+.counts { display: flex; gap: 12px; }
+.count { border: 1px solid #d0d7de; border-radius: 6px; padding: 8px 16px; text-align: center; }
+.count strong { display: block; font-size: 24px; }
+.high { color: #cf222e; } .medium { color: #bc4c00; } .low { color: #9a6700; } .unknown { color: #59636e; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border-bottom: 1px solid #d0d7de; padding: 6px 8px; text-align: left; vertical-align: top; }
+details { border: 1px solid #d0d7de; border-radius: 6px; margin: 8px 0; padding: 8px 12px; }
+summary { cursor: pointer; font-weight: 600; }
+code { background: #f6f8fa; border-radius: 4px; padding: 2px 4px; word-break: break-all; }
+</style>
+</head>
+<body>
+<h1>Privacy code scan report</h1>
+<div class="meta">
+<p><strong>{{.Repository}}</strong>{{if .Scope}} &middot; findings {{.Scope}}{{end}}{{if .Branch}} &middot; branch {{.Branch}}{{end}}{{if .CommitId}} &middot; commit <code>{{.CommitId}}</code>{{end}}</p>
+<p>Scanned {{.Scanned}} &middot; generated {{.Generated}} &middot; Privado CLI {{.CLIVersion}}, engine {{.CoreVersion}}</p>
+</div>
+
+<h2>Executive summary</h2>
+<p>{{.Overview}}</p>
+<div class="counts">
+{{range .Severities}}<div class="count {{.}}"><strong>{{index $.FindingsBySeverity .}}</strong>{{.}}</div>
+{{end}}</div>
+
+{{if .TopPolicies}}<h2>Most violated policies</h2>
+<table>
+<tr><th>Policy</th><th>Severity</th><th>Findings</th></tr>
+{{range .TopPolicies}}<tr><td>{{.Name}}</td><td class="{{.Severity}}">{{.Severity}}</td><td>{{.Findings}}</td></tr>
+{{end}}</table>
+{{end}}
+<h2>Data elements ({{len .DataElementLines}})</h2>
+{{if .DataElementLines}}<ul>
+{{range .DataElementLines}}<li>{{.}}</li>
+{{end}}</ul>{{else}}<p>No data elements found</p>{{end}}
+
+<h2>Data recipients</h2>
+{{if .Categories}}<table>
+{{range .Categories}}<tr><th>{{.}}</th><td>{{index $.RecipientLines .}}</td></tr>
+{{end}}</table>{{else}}<p>No data flows to recipients found</p>{{end}}
+
+<h2>Findings ({{len .HTMLFindings}})</h2>
+{{if not .HTMLFindings}}<p>No findings</p>{{end}}
+{{range $i, $f := .HTMLFindings}}<details>
+<summary><span class="{{$f.Severity}}">[{{upper $f.Severity}}]</span> {{$f.PolicyName}}</summary>
+{{if $f.Description}}<p>{{$f.Description}}</p>{{end}}
+<table>
+{{if $f.Location}}<tr><th>Location</th><td><code>{{$f.Location}}</code></td></tr>{{end}}
+<tr><th>Data element</th><td>{{$f.DataElement}}</td></tr>
+{{if $f.Recipient}}<tr><th>Recipient</th><td>{{$f.Recipient}}</td></tr>{{end}}
+{{if $f.Owners}}<tr><th>Owners</th><td>{{join $f.Owners ", "}}</td></tr>{{end}}
+{{if $f.Sample}}<tr><th>Code</th><td><code>{{$f.Sample}}</code></td></tr>{{end}}
+<tr><th>Finding id</th><td><code>{{$f.Id}}</code></td></tr>
+</table>
+</details>
+{{end}}
+</body>
+</html>
+`))
+
+// Renders the report as a self-contained HTML page
+func (a Audit) RenderHTML() ([]byte, error) {
+	r := htmlReport{
+		Audit:          a,
+		Overview:       a.overview(),
+		Scanned:        formatDate(a.ScannedAt),
+		Generated:      formatDate(a.GeneratedAt),
+		Severities:     results.Severities,
+		RecipientLines: map[string]string{},
+		Categories:     a.recipientCategories(),
+	}
+	for _, source := range a.DataElements {
+		line := a.getSourceName(source.Id)
+		if source.Category != "" {
+			line = fmt.Sprintf("%s (%s)", line, source.Category)
+		}
+		if source.IsSensitive {
+			line += ", sensitive"
+		}
+		r.DataElementLines = append(r.DataElementLines, line)
+	}
+	for _, category := range r.Categories {
+		r.RecipientLines[category] = strings.Join(a.DataRecipients[category], ", ")
+	}
+	for _, finding := range a.Findings {
+		f := htmlFinding{Finding: finding, DataElement: a.getSourceName(finding.SourceId)}
+		if finding.FileName != "" {
+			f.Location = fmt.Sprintf("%s:%d", filepath.ToSlash(finding.RelativeFileName()), finding.LineNumber)
+		}
+		if finding.SinkId != "" {
+			f.Recipient = a.getSinkName(finding.SinkId)
+		}
+		r.HTMLFindings = append(r.HTMLFindings, f)
+	}
+
+	var b bytes.Buffer
+	if err := htmlReportTemplate.Execute(&b, r); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
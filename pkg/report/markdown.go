@@ -0,0 +1,165 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package report
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+)
+
+// findings listed in each table of the markdown report, comments and
+// descriptions of pull requests are limited in size
+const maxMarkdownFindings = 50
+
+// Collapsible section of the markdown report
+type Section struct {
+	Title string
+	Lines []string
+}
+
+// Findings of a scan to be rendered in a report
+type Report struct {
+	Title  string
+	Passed bool
+	Status string
+
+	Findings []results.Finding
+
+	// ids of the findings not in the baseline, nil without baseline
+	NewFindingIds map[string]bool
+
+	// ids of the findings failing the scan
+	FailedFindingIds map[string]bool
+
+	Sections []Section
+}
+
+func escapeMarkdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
+func writeFindingsTable(b *strings.Builder, findings []results.Finding, failed map[string]bool) {
+	// owners are shown if the repository has any (CODEOWNERS)
+	withOwners := false
+	for _, finding := range findings {
+		withOwners = withOwners || len(finding.Owners) > 0
+	}
+	if withOwners {
+		b.WriteString("| | Severity | Policy | Location | Owners |\n|---|---|---|---|---|\n")
+	} else {
+		b.WriteString("| | Severity | Policy | Location |\n|---|---|---|---|\n")
+	}
+	for i, finding := range findings {
+		if i == maxMarkdownFindings {
+			fmt.Fprintf(b, "\n..and %d more\n", len(findings)-maxMarkdownFindings)
+			break
+		}
+		status := ":warning:"
+		if failed[finding.Id] {
+			status = ":x:"
+		}
+		location := ""
+		if finding.FileName != "" {
+			location = fmt.Sprintf("`%s:%d`", finding.RelativeFileName(), finding.LineNumber)
+		}
+		if withOwners {
+			fmt.Fprintf(b, "| %s | %s | %s | %s | %s |\n", status, finding.Severity, escapeMarkdownCell(finding.PolicyName), location, escapeMarkdownCell(strings.Join(finding.Owners, " ")))
+			continue
+		}
+		fmt.Fprintf(b, "| %s | %s | %s | %s |\n", status, finding.Severity, escapeMarkdownCell(finding.PolicyName), location)
+	}
+}
+
+func writeCollapsible(b *strings.Builder, title string, open bool, content func()) {
+	if open {
+		b.WriteString("<details open>")
+	} else {
+		b.WriteString("<details>")
+	}
+	fmt.Fprintf(b, "<summary>%s</summary>\n\n", title)
+	content()
+	b.WriteString("\n</details>\n\n")
+}
+
+// Renders the report as compact markdown for pull request descriptions and
+// comments: new findings first, other findings and sections collapsed
+func (r Report) RenderMarkdown() string {
+	var b strings.Builder
+	counts := results.CountFindingsBySeverity(r.Findings)
+
+	icon := ":white_check_mark:"
+	if !r.Passed {
+		icon = ":x:"
+	}
+	fmt.Fprintf(&b, "### %s %s\n\n", icon, escapeMarkdownCell(r.Title))
+	fmt.Fprintf(&b, "**%d finding(s)**: high %d, medium %d, low %d, unknown %d\n\n",
+		len(r.Findings), counts[results.SeverityHigh], counts[results.SeverityMedium], counts[results.SeverityLow], counts[results.SeverityUnknown])
+	if r.Status != "" {
+		fmt.Fprintf(&b, "%s\n\n", r.Status)
+	}
+
+	if r.NewFindingIds == nil {
+		if len(r.Findings) > 0 {
+			writeCollapsible(&b, fmt.Sprintf("Findings (%d)", len(r.Findings)), !r.Passed, func() {
+				writeFindingsTable(&b, r.Findings, r.FailedFindingIds)
+			})
+		}
+	} else {
+		newFindings, existingFindings := []results.Finding{}, []results.Finding{}
+		for _, finding := range r.Findings {
+			if r.NewFindingIds[finding.Id] {
+				newFindings = append(newFindings, finding)
+			} else {
+				existingFindings = append(existingFindings, finding)
+			}
+		}
+		if len(newFindings) > 0 {
+			fmt.Fprintf(&b, "#### New findings (%d)\n\n", len(newFindings))
+			writeFindingsTable(&b, newFindings, r.FailedFindingIds)
+			b.WriteString("\n")
+		} else {
+			b.WriteString("No new findings :tada:\n\n")
+		}
+		if len(existingFindings) > 0 {
+			writeCollapsible(&b, fmt.Sprintf("Existing findings (%d)", len(existingFindings)), false, func() {
+				writeFindingsTable(&b, existingFindings, r.FailedFindingIds)
+			})
+		}
+	}
+
+	for _, section := range r.Sections {
+		if len(section.Lines) == 0 {
+			continue
+		}
+		writeCollapsible(&b, escapeMarkdownCell(section.Title), false, func() {
+			for _, line := range section.Lines {
+				fmt.Fprintf(&b, "- %s\n", line)
+			}
+		})
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
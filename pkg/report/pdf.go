@@ -0,0 +1,200 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Minimal PDF writer for text documents: A4 pages, the standard Helvetica
+// fonts (no embedding) and automatic line wrapping and page breaks
+// ref: https://opensource.adobe.com/dc-acrobat-sdk-docs/pdfstandards/PDF32000_2008.pdf
+
+const (
+	pageWidth    = 595.0
+	pageHeight   = 842.0
+	pageMargin   = 56.0
+	lineSpacing  = 1.35
+	contentWidth = pageWidth - 2*pageMargin
+)
+
+type pdfFont string
+
+const (
+	fontRegular pdfFont = "F1"
+	fontBold    pdfFont = "F2"
+)
+
+// average glyph width of the fonts, relative to the font size; the
+// standard fonts are not embedded, so lines are wrapped approximately
+var averageGlyphWidth = map[pdfFont]float64{
+	fontRegular: 0.5,
+	fontBold:    0.55,
+}
+
+type pdfDocument struct {
+	pages []*bytes.Buffer
+	page  *bytes.Buffer
+	y     float64
+}
+
+func newPDFDocument() *pdfDocument {
+	d := &pdfDocument{}
+	d.newPage()
+	return d
+}
+
+func (d *pdfDocument) newPage() {
+	d.page = &bytes.Buffer{}
+	d.pages = append(d.pages, d.page)
+	d.y = pageHeight - pageMargin
+}
+
+// Encodes the text as a PDF string (WinAnsi): characters outside of
+// Latin-1 are replaced
+func escapePDFString(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\t':
+			b.WriteString("    ")
+		case r < 32:
+			b.WriteByte(' ')
+		case r < 128:
+			b.WriteRune(r)
+		case r < 256:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// Splits the text in lines fitting the width, on spaces where possible
+func wrapText(text string, font pdfFont, size, width float64) []string {
+	maxChars := int(width / (averageGlyphWidth[font] * size))
+	if maxChars < 1 {
+		maxChars = 1
+	}
+
+	lines := []string{}
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := []rune{}
+		for _, word := range strings.Fields(paragraph) {
+			runes := []rune(word)
+			for len(runes) > maxChars {
+				if len(line) > 0 {
+					lines = append(lines, string(line))
+					line = []rune{}
+				}
+				lines = append(lines, string(runes[:maxChars]))
+				runes = runes[maxChars:]
+			}
+			if len(line) > 0 && len(line)+1+len(runes) > maxChars {
+				lines = append(lines, string(line))
+				line = []rune{}
+			}
+			if len(line) > 0 {
+				line = append(line, ' ')
+			}
+			line = append(line, runes...)
+		}
+		lines = append(lines, string(line))
+	}
+	return lines
+}
+
+// Writes the text at the indentation (from the margin), wrapped and
+// continued on new pages as needed
+func (d *pdfDocument) text(text string, font pdfFont, size, indent float64) {
+	lineHeight := size * lineSpacing
+	for _, line := range wrapText(text, font, size, contentWidth-indent) {
+		if d.y-lineHeight < pageMargin {
+			d.newPage()
+		}
+		d.y -= lineHeight
+		if line == "" {
+			continue
+		}
+		fmt.Fprintf(d.page, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, pageMargin+indent, d.y, escapePDFString(line))
+	}
+}
+
+// Adds vertical space, on the current page only
+func (d *pdfDocument) space(height float64) {
+	d.y -= height
+}
+
+// Draws a horizontal line across the content width
+func (d *pdfDocument) rule() {
+	if d.y-8 < pageMargin {
+		d.newPage()
+	}
+	d.y -= 4
+	fmt.Fprintf(d.page, "0.6 w %.2f %.2f m %.2f %.2f l S\n", pageMargin, d.y, pageWidth-pageMargin, d.y)
+	d.y -= 4
+}
+
+// Returns the document with page numbers in the footer
+func (d *pdfDocument) bytes() []byte {
+	var out bytes.Buffer
+	offsets := []int{}
+	object := func(content string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), content)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// objects 1-4: catalog, page tree and fonts; then a page and its
+	// content for each page
+	pageIds := []string{}
+	for i := range d.pages {
+		pageIds = append(pageIds, fmt.Sprintf("%d 0 R", 5+2*i))
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(pageIds, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, page := range d.pages {
+		content := page.String()
+		content += fmt.Sprintf("BT /%s 8.0 Tf %.2f %.2f Td (%s) Tj ET\n", fontRegular, pageWidth-pageMargin-40, pageMargin/2, escapePDFString(fmt.Sprintf("%d / %d", i+1, len(d.pages))))
+
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>", pageWidth, pageHeight, 6+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(content), content))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes()
+}
@@ -0,0 +1,121 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+)
+
+// Findings in the format of the Generic Issue Import of SonarQube
+// (sonar.externalIssuesReportPaths), with a rule for each policy, so
+// privacy findings are shown in dashboards and quality gates
+
+const sonarEngineId = "privado"
+
+type sonarReport struct {
+	Rules  []sonarRule  `json:"rules"`
+	Issues []sonarIssue `json:"issues"`
+}
+
+type sonarRule struct {
+	Id                 string        `json:"id"`
+	Name               string        `json:"name"`
+	Description        string        `json:"description"`
+	EngineId           string        `json:"engineId"`
+	CleanCodeAttribute string        `json:"cleanCodeAttribute"`
+	Impacts            []sonarImpact `json:"impacts"`
+}
+
+type sonarImpact struct {
+	SoftwareQuality string `json:"softwareQuality"`
+	Severity        string `json:"severity"`
+}
+
+type sonarIssue struct {
+	RuleId          string        `json:"ruleId"`
+	PrimaryLocation sonarLocation `json:"primaryLocation"`
+}
+
+type sonarLocation struct {
+	Message   string          `json:"message"`
+	FilePath  string          `json:"filePath"`
+	TextRange *sonarTextRange `json:"textRange,omitempty"`
+}
+
+type sonarTextRange struct {
+	StartLine int `json:"startLine"`
+}
+
+var sonarSeverities = map[string]string{
+	results.SeverityHigh:    "HIGH",
+	results.SeverityMedium:  "MEDIUM",
+	results.SeverityLow:     "LOW",
+	results.SeverityUnknown: "LOW",
+}
+
+// Renders the findings as a SonarQube Generic Issue Import report. Findings
+// without a location are left out: issues must be located in a file
+func (a Audit) RenderSonar() ([]byte, error) {
+	report := sonarReport{Rules: []sonarRule{}, Issues: []sonarIssue{}}
+	rules := map[string]bool{}
+	for _, finding := range a.Findings {
+		if finding.FileName == "" {
+			continue
+		}
+		if !rules[finding.PolicyId] {
+			rules[finding.PolicyId] = true
+			description := finding.Description
+			if description == "" {
+				description = finding.PolicyName
+			}
+			report.Rules = append(report.Rules, sonarRule{
+				Id:                 finding.PolicyId,
+				Name:               finding.PolicyName,
+				Description:        description,
+				EngineId:           sonarEngineId,
+				CleanCodeAttribute: "LAWFUL",
+				Impacts:            []sonarImpact{{SoftwareQuality: "SECURITY", Severity: sonarSeverities[finding.Severity]}},
+			})
+		}
+
+		message := fmt.Sprintf("%s: %s", finding.PolicyName, a.getSourceName(finding.SourceId))
+		if finding.SinkId != "" {
+			message = fmt.Sprintf("%s flows to %s", message, a.getSinkName(finding.SinkId))
+		}
+		if len(finding.Owners) > 0 {
+			message = fmt.Sprintf("%s (owners: %s)", message, strings.Join(finding.Owners, ", "))
+		}
+		location := sonarLocation{Message: message, FilePath: filepath.ToSlash(finding.RelativeFileName())}
+		if finding.LineNumber > 0 {
+			location.TextRange = &sonarTextRange{StartLine: finding.LineNumber}
+		}
+		report.Issues = append(report.Issues, sonarIssue{RuleId: finding.PolicyId, PrimaryLocation: location})
+	}
+	sort.Slice(report.Rules, func(i, j int) bool { return report.Rules[i].Id < report.Rules[j].Id })
+	return json.MarshalIndent(report, "", "  ")
+}
@@ -0,0 +1,176 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package results
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Per-scan result directories (.privado/scans/<id>): the results of each
+// scan are kept in addition to the results of the last scan in .privado,
+// so results of previous scans can be compared (privado scan --keep-results)
+
+const (
+	ScansDirectoryName = "scans"
+
+	// pointer to the directory of the last scan: a symlink, or a file with
+	// its id where symlinks cannot be created (e.g. on Windows)
+	LatestScanName = "latest"
+)
+
+const scanIdTimeFormat = "20060102-150405"
+
+// Returns the id of the scan directory, e.g. 20221016-153012-1a2b3c4
+func NewScanDirectoryId(completedAt time.Time, commitId string) string {
+	id := completedAt.Format(scanIdTimeFormat)
+	if len(commitId) > 7 {
+		commitId = commitId[:7]
+	}
+	if commitId != "" {
+		id += "-" + commitId
+	}
+	return id
+}
+
+// Copies the files of the privado directory (except excludedNames) to a
+// new scan directory with the id, and points latest to it. Returns its path
+func ArchiveScan(privadoDirectory, id string, excludedNames []string) (string, error) {
+	scansDirectory := filepath.Join(privadoDirectory, ScansDirectoryName)
+	scanDirectory := filepath.Join(scansDirectory, id)
+	for i := 2; exists(scanDirectory); i++ {
+		scanDirectory = filepath.Join(scansDirectory, fmt.Sprintf("%s-%d", id, i))
+	}
+	if err := os.MkdirAll(scanDirectory, os.ModePerm); err != nil {
+		return "", err
+	}
+
+	excluded := map[string]bool{}
+	for _, name := range excludedNames {
+		excluded[name] = true
+	}
+	entries, err := os.ReadDir(privadoDirectory)
+	if err != nil {
+		return "", err
+	}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || excluded[entry.Name()] {
+			continue
+		}
+		if err := copyFile(filepath.Join(privadoDirectory, entry.Name()), filepath.Join(scanDirectory, entry.Name())); err != nil {
+			return "", err
+		}
+	}
+
+	return scanDirectory, setLatestScan(scansDirectory, filepath.Base(scanDirectory))
+}
+
+func setLatestScan(scansDirectory, id string) error {
+	latestPath := filepath.Join(scansDirectory, LatestScanName)
+	if err := os.Remove(latestPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Symlink(id, latestPath); err == nil {
+		return nil
+	}
+	return os.WriteFile(latestPath, []byte(id+"\n"), 0644)
+}
+
+// Returns the ids of the scan directories, oldest first
+func ListScanDirectories(privadoDirectory string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(privadoDirectory, ScansDirectoryName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+	ids := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() && entry.Name() != LatestScanName {
+			ids = append(ids, entry.Name())
+		}
+	}
+	// ids start with the time of the scan
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// Removes the scan directories beyond the last keepLast (0 for all) and
+// older than maxAge (0 for any age). The last scan is always kept.
+// Returns the number of removed directories
+func PruneScanDirectories(privadoDirectory string, keepLast int, maxAge time.Duration, now time.Time) (int, error) {
+	ids, err := ListScanDirectories(privadoDirectory)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for i, id := range ids {
+		remaining := len(ids) - i
+		if remaining == 1 {
+			break
+		}
+		expired := false
+		if maxAge > 0 && len(id) >= len(scanIdTimeFormat) {
+			if scannedAt, err := time.ParseInLocation(scanIdTimeFormat, id[:len(scanIdTimeFormat)], time.Local); err == nil {
+				expired = now.Sub(scannedAt) > maxAge
+			}
+		}
+		if !expired && (keepLast <= 0 || remaining <= keepLast) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(privadoDirectory, ScansDirectoryName, id)); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func copyFile(source, destination string) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(destination)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
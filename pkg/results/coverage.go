@@ -0,0 +1,222 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package results
+
+import (
+	"encoding/json"
+	"io/fs"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Coverage of a scan: the source files of the repository analyzed by the
+// engine versus skipped ones, so that a scan without findings can be told
+// apart from a scan that did not see the code. Written next to the results
+// (.privado/coverage.json)
+
+const CoverageFileName = "coverage.json"
+
+// source file extensions, by language
+var languageExtensions = map[string]string{
+	".java": "java", ".py": "python", ".js": "javascript", ".jsx": "javascript", ".mjs": "javascript", ".cjs": "javascript",
+	".ts": "typescript", ".tsx": "typescript", ".rb": "ruby", ".go": "go", ".cs": "csharp", ".php": "php",
+	".kt": "kotlin", ".kts": "kotlin", ".scala": "scala", ".groovy": "groovy", ".swift": "swift", ".m": "objective-c",
+	".c": "c", ".h": "c", ".cc": "cpp", ".cpp": "cpp", ".hpp": "cpp", ".rs": "rust", ".dart": "dart",
+	".ex": "elixir", ".exs": "elixir", ".erl": "erlang", ".clj": "clojure", ".lua": "lua", ".pl": "perl", ".r": "r",
+}
+
+// languages analyzed by the engine by default; javascript and typescript
+// with --enable-javascript
+var DefaultAnalyzedLanguages = []string{"java", "python"}
+
+type LanguageCoverage struct {
+	Language    string `json:"language"`
+	Analyzed    bool   `json:"analyzed"`
+	Files       int    `json:"files"`
+	Excluded    int    `json:"excluded"`
+	Unparseable int    `json:"unparseable"`
+}
+
+type Coverage struct {
+	// source files, in any known language
+	SourceFiles int `json:"sourceFiles"`
+	// files of analyzed languages the engine analyzed
+	AnalyzedFiles int `json:"analyzedFiles"`
+	// files excluded from the scan (ignored by git, vendored, large or binary)
+	ExcludedFiles int `json:"excludedFiles"`
+	// files the engine reported it could not parse or skipped
+	UnparseableFiles int `json:"unparseableFiles"`
+	// files in languages the engine does not analyze
+	UnsupportedFiles int `json:"unsupportedFiles"`
+	// percentage of source files analyzed
+	Percentage float64            `json:"percentage"`
+	Languages  []LanguageCoverage `json:"languages"`
+}
+
+// Computes the coverage of the repository: excluded paths are relative and
+// slash separated (directories with or without a trailing slash),
+// unparseable files relative as well
+func ComputeCoverage(root string, analyzedLanguages, excludedPaths, unparseableFiles []string) (*Coverage, error) {
+	analyzed := map[string]bool{}
+	for _, language := range analyzedLanguages {
+		analyzed[language] = true
+	}
+	excluded := map[string]bool{}
+	for _, excludedPath := range excludedPaths {
+		excluded[strings.TrimSuffix(excludedPath, "/")] = true
+	}
+	unparseable := map[string]bool{}
+	for _, file := range unparseableFiles {
+		unparseable[file] = true
+	}
+
+	languages := map[string]*LanguageCoverage{}
+	coverage := &Coverage{Languages: []LanguageCoverage{}}
+	err := walkSourceFiles(root, excluded, func(relativePath string, isExcluded bool) {
+		language, ok := languageExtensions[strings.ToLower(path.Ext(relativePath))]
+		if !ok {
+			return
+		}
+		if languages[language] == nil {
+			languages[language] = &LanguageCoverage{Language: language, Analyzed: analyzed[language]}
+		}
+		languageCoverage := languages[language]
+		languageCoverage.Files++
+		coverage.SourceFiles++
+
+		switch {
+		case isExcluded:
+			languageCoverage.Excluded++
+			coverage.ExcludedFiles++
+		case !analyzed[language]:
+			coverage.UnsupportedFiles++
+		case unparseable[relativePath]:
+			languageCoverage.Unparseable++
+			coverage.UnparseableFiles++
+		default:
+			coverage.AnalyzedFiles++
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, languageCoverage := range languages {
+		coverage.Languages = append(coverage.Languages, *languageCoverage)
+	}
+	sort.Slice(coverage.Languages, func(i, j int) bool {
+		if coverage.Languages[i].Files != coverage.Languages[j].Files {
+			return coverage.Languages[i].Files > coverage.Languages[j].Files
+		}
+		return coverage.Languages[i].Language < coverage.Languages[j].Language
+	})
+	if coverage.SourceFiles > 0 {
+		coverage.Percentage = math.Round(float64(coverage.AnalyzedFiles)/float64(coverage.SourceFiles)*1000) / 10
+	}
+	return coverage, nil
+}
+
+// walks the files of the repository (except .git and the results directory),
+// files in excluded paths are walked as excluded
+func walkSourceFiles(root string, excluded map[string]bool, fn func(relativePath string, isExcluded bool)) error {
+	var walk func(directory, relativeDirectory string, isExcluded bool) error
+	walk = func(directory, relativeDirectory string, isExcluded bool) error {
+		entries, err := os.ReadDir(directory)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			relativePath := path.Join(relativeDirectory, entry.Name())
+			entryExcluded := isExcluded || excluded[relativePath]
+			if entry.IsDir() {
+				if relativePath == ".git" || relativePath == ".privado" {
+					continue
+				}
+				if err := walk(filepath.Join(directory, entry.Name()), relativePath, entryExcluded); err != nil {
+					return err
+				}
+			} else if entry.Type()&fs.ModeSymlink == 0 {
+				fn(relativePath, entryExcluded)
+			}
+		}
+		return nil
+	}
+	return walk(root, "", false)
+}
+
+// Returns the files of the repository (relative, slash separated) that
+// appear in the lines of engine output, e.g. parse errors. Paths in the
+// lines can be absolute in the container (sourceDirectory) or relative
+func FindFilesInOutput(root, sourceDirectory string, lines []string) []string {
+	found := map[string]bool{}
+	for _, line := range lines {
+		tokens := strings.FieldsFunc(line, func(r rune) bool {
+			return r == ' ' || r == '\t' || r == '\'' || r == '"' || r == '(' || r == ')' || r == ',' || r == '[' || r == ']'
+		})
+		for _, token := range tokens {
+			// file:line[:column]
+			if index := strings.Index(token, ":"); index > 0 {
+				token = token[:index]
+			}
+			token = strings.TrimRight(token, ".;")
+			token = strings.TrimPrefix(strings.TrimPrefix(token, sourceDirectory), "/")
+			if token == "" || path.Ext(token) == "" {
+				continue
+			}
+			if info, err := os.Stat(filepath.Join(root, filepath.FromSlash(token))); err == nil && !info.IsDir() {
+				found[token] = true
+			}
+		}
+	}
+
+	files := []string{}
+	for file := range found {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files
+}
+
+func (c *Coverage) Write(coveragePath string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(coveragePath, data, 0644)
+}
+
+func LoadCoverage(coveragePath string) (*Coverage, error) {
+	data, err := os.ReadFile(coveragePath)
+	if err != nil {
+		return nil, err
+	}
+	coverage := &Coverage{}
+	if err := json.Unmarshal(data, coverage); err != nil {
+		return nil, err
+	}
+	return coverage, nil
+}
@@ -0,0 +1,217 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+package results
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Encryption of scan outputs at rest (privado scan --encrypt-results):
+// each file is encrypted with a random AES-256-GCM key, which is encrypted
+// (RSA-OAEP, SHA-256) with the public key of the recipient, so only holders
+// of the private key can read it (privado results decrypt)
+//
+// format: magic, length of the encrypted key (uint16), encrypted key,
+// nonce, ciphertext
+
+const EncryptedFileExtension = ".enc"
+
+var encryptedFileMagic = []byte("PRIVADO-ENCRYPTED-1\n")
+
+var (
+	ErrNotEncrypted = errors.New("the file is not encrypted")
+	ErrEncrypted    = errors.New("the results are encrypted (see 'privado results decrypt')")
+)
+
+// Returns whether the data was encrypted with EncryptData
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, encryptedFileMagic)
+}
+
+// Reads the public key of the recipient of encrypted results from a PEM
+// file (PKIX "PUBLIC KEY" or PKCS#1 "RSA PUBLIC KEY")
+func LoadRecipientKey(path string) (*rsa.PublicKey, error) {
+	block, err := readPEMFile(path, "PUBLIC KEY")
+	if err != nil {
+		return nil, err
+	}
+	if block.Type == "RSA PUBLIC KEY" {
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an RSA public key", path)
+	}
+	return publicKey, nil
+}
+
+// Reads the private key to decrypt results with from a PEM file (PKCS#8
+// "PRIVATE KEY" or PKCS#1 "RSA PRIVATE KEY")
+func LoadIdentityKey(path string) (*rsa.PrivateKey, error) {
+	block, err := readPEMFile(path, "PRIVATE KEY")
+	if err != nil {
+		return nil, err
+	}
+	if block.Type == "RSA PRIVATE KEY" {
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an RSA private key", path)
+	}
+	return privateKey, nil
+}
+
+func readPEMFile(path, keyType string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not a PEM encoded key", path)
+	}
+	if strings.Contains(block.Type, "ENCRYPTED") {
+		return nil, fmt.Errorf("%s is protected with a passphrase, which is not supported", path)
+	}
+	if !strings.HasSuffix(block.Type, keyType) {
+		return nil, fmt.Errorf("%s is not a %s (found: %s)", path, strings.ToLower(keyType), strings.ToLower(block.Type))
+	}
+	return block, nil
+}
+
+func EncryptData(data []byte, recipient *rsa.PublicKey) ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	encryptedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, recipient, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	b.Write(encryptedFileMagic)
+	binary.Write(&b, binary.BigEndian, uint16(len(encryptedKey)))
+	b.Write(encryptedKey)
+	b.Write(nonce)
+	b.Write(gcm.Seal(nil, nonce, data, encryptedFileMagic))
+	return b.Bytes(), nil
+}
+
+func DecryptData(data []byte, identity *rsa.PrivateKey) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return nil, ErrNotEncrypted
+	}
+	data = data[len(encryptedFileMagic):]
+	if len(data) < 2 {
+		return nil, errors.New("the encrypted file is truncated")
+	}
+	keyLength := int(binary.BigEndian.Uint16(data))
+	data = data[2:]
+	if len(data) < keyLength {
+		return nil, errors.New("the encrypted file is truncated")
+	}
+	key, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, identity, data[:keyLength], nil)
+	if err != nil {
+		return nil, errors.New("the file was not encrypted for this key")
+	}
+	data = data[keyLength:]
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("the encrypted file is truncated")
+	}
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], encryptedFileMagic)
+	if err != nil {
+		return nil, errors.New("the encrypted file is corrupted")
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Encrypts the file to <path>.enc and removes the plaintext file
+func EncryptFile(path string, recipient *rsa.PublicKey) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	encrypted, err := EncryptData(data, recipient)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path+EncryptedFileExtension, encrypted, 0600); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// Decrypts the encrypted file (<path>.enc) to the output path
+func DecryptFile(path, output string, identity *rsa.PrivateKey) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	plaintext, err := DecryptData(data, identity)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(output, plaintext, 0600)
+}
@@ -0,0 +1,95 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package results
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+)
+
+// rank of the severity, higher is more severe
+func severityRank(severity string) int {
+	switch severity {
+	case SeverityHigh:
+		return 3
+	case SeverityMedium:
+		return 2
+	case SeverityLow:
+		return 1
+	}
+	return 0
+}
+
+func IsValidSeverity(severity string) bool {
+	for _, s := range Severities {
+		if s == severity {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns findings with severity at or above the threshold
+func FilterFindingsAtOrAbove(findings []Finding, threshold string) []Finding {
+	filtered := []Finding{}
+	for _, finding := range findings {
+		if severityRank(finding.Severity) >= severityRank(threshold) {
+			filtered = append(filtered, finding)
+		}
+	}
+	return filtered
+}
+
+// Returns path of the finding file relative to the scanned directory
+// (results refer to files by their path in the container)
+func (f Finding) RelativeFileName() string {
+	return getRelativeFileName(f.FileName)
+}
+
+// Returns path of the occurrence file relative to the scanned directory
+func (o Occurrence) RelativeFileName() string {
+	return getRelativeFileName(o.FileName)
+}
+
+func getRelativeFileName(fileName string) string {
+	sourceDir := config.AppConfig.Container.SourceCodeVolumeDir + "/"
+	return filepath.FromSlash(strings.TrimPrefix(fileName, sourceDir))
+}
+
+// Returns findings located in one of the files (relative to the scanned directory)
+func FilterFindingsInFiles(findings []Finding, files []string) []Finding {
+	fileSet := map[string]bool{}
+	for _, file := range files {
+		fileSet[filepath.Clean(file)] = true
+	}
+
+	filtered := []Finding{}
+	for _, finding := range findings {
+		if fileSet[finding.RelativeFileName()] {
+			filtered = append(filtered, finding)
+		}
+	}
+	return filtered
+}
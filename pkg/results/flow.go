@@ -0,0 +1,92 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+package results
+
+// Returns the finding with the id (or a unique prefix of it)
+func (r *Results) FindFinding(id string) (Finding, bool) {
+	matches := []Finding{}
+	for _, finding := range r.Findings() {
+		if finding.Id == id {
+			return finding, true
+		}
+		if len(id) >= 4 && len(finding.Id) > len(id) && finding.Id[:len(id)] == id {
+			matches = append(matches, finding)
+		}
+	}
+	if len(matches) == 1 {
+		return matches[0], true
+	}
+	return Finding{}, false
+}
+
+// Returns the dataflow path of the finding, from the source to the sink
+// (the location of the finding), or the occurrence of a processing finding
+func (r *Results) FindingPath(f Finding) []Occurrence {
+	if f.SinkId == "" {
+		if f.FileName == "" {
+			return nil
+		}
+		return []Occurrence{{FileName: f.FileName, LineNumber: f.LineNumber, Sample: f.Sample, Excerpt: f.Excerpt}}
+	}
+
+	pathIds := map[string]bool{}
+	for _, violation := range r.Violations {
+		if violation.PolicyId != f.PolicyId {
+			continue
+		}
+		for _, flow := range violation.DataFlow {
+			if flow.SourceId == f.SourceId && flow.SinkId == f.SinkId {
+				for _, pathId := range flow.PathIds {
+					pathIds[pathId] = true
+				}
+			}
+		}
+	}
+
+	// the path of the finding ends at its location
+	var fallback []Occurrence
+	for _, flows := range r.DataFlow {
+		for _, flow := range flows {
+			if flow.SourceId != f.SourceId {
+				continue
+			}
+			for _, sink := range flow.Sinks {
+				if sink.Id != f.SinkId {
+					continue
+				}
+				for _, path := range sink.Paths {
+					if !pathIds[path.PathId] || len(path.Path) == 0 {
+						continue
+					}
+					last := path.Path[len(path.Path)-1]
+					if last.FileName == f.FileName && last.Sample == f.Sample {
+						return path.Path
+					}
+					if fallback == nil {
+						fallback = path.Path
+					}
+				}
+			}
+		}
+	}
+	return fallback
+}
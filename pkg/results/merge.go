@@ -0,0 +1,113 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package results
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Merging of results of partial scans (shards), operating on the raw
+// results so fields not modelled by the CLI are preserved
+
+func LoadRawResults(resultsPath string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(resultsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := map[string]interface{}{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("cannot parse results (%s): %v", resultsPath, err)
+	}
+	return raw, nil
+}
+
+func WriteRawResults(resultsPath string, raw map[string]interface{}) error {
+	data, err := json.MarshalIndent(raw, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(resultsPath, data, 0644)
+}
+
+// Rewrites file names relative to sourceDir, so results of a module
+// scan refer to files relative to the repository: sourceDir/a.java
+// is rewritten to sourceDir/prefix/a.java
+func PrefixFileNames(value interface{}, sourceDir, prefix string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if fileName, ok := child.(string); ok && key == "fileName" && strings.HasPrefix(fileName, sourceDir+"/") {
+				v[key] = sourceDir + "/" + prefix + strings.TrimPrefix(fileName, sourceDir)
+			} else {
+				PrefixFileNames(child, sourceDir, prefix)
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			PrefixFileNames(child, sourceDir, prefix)
+		}
+	}
+}
+
+// Merges src into dst: arrays are concatenated (without duplicates),
+// objects are merged recursively and existing values in dst are kept
+func MergeRawResults(dst, src map[string]interface{}) {
+	for key, srcValue := range src {
+		dstValue, exists := dst[key]
+		if !exists {
+			dst[key] = srcValue
+			continue
+		}
+
+		switch d := dstValue.(type) {
+		case map[string]interface{}:
+			if s, ok := srcValue.(map[string]interface{}); ok {
+				MergeRawResults(d, s)
+			}
+		case []interface{}:
+			if s, ok := srcValue.([]interface{}); ok {
+				dst[key] = appendUnique(d, s)
+			}
+		}
+	}
+}
+
+func appendUnique(dst, src []interface{}) []interface{} {
+	seen := map[string]bool{}
+	for _, value := range dst {
+		data, _ := json.Marshal(value)
+		seen[string(data)] = true
+	}
+	for _, value := range src {
+		data, _ := json.Marshal(value)
+		if !seen[string(data)] {
+			seen[string(data)] = true
+			dst = append(dst, value)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,330 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package results
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/Privado-Inc/privado-cli/pkg/auth"
+)
+
+// Subset of the results (privado.json) generated by privado-core
+// Only the fields the CLI post-processes are modelled here
+
+type Results struct {
+	RepoName           string                `json:"repoName"`
+	GitMetadata        GitMetadata           `json:"gitMetadata"`
+	LocalScanPath      string                `json:"localScanPath"`
+	PrivadoCoreVersion string                `json:"privadoCoreVersion"`
+	PrivadoCLIVersion  string                `json:"privadoCLIVersion"`
+	CreatedAt          int64                 `json:"createdAt"`
+	Sources            []Source              `json:"sources"`
+	Processing         []Processing          `json:"processing"`
+	Sinks              []Sink                `json:"sinks"`
+	DataFlow           map[string][]DataFlow `json:"dataFlow"`
+	Violations         []Violation           `json:"violations"`
+	Collections        []Collection          `json:"collections"`
+	ScanMetadata       *ScanMetadata         `json:"scanMetadata,omitempty"`
+}
+
+type GitMetadata struct {
+	BranchName string `json:"branchName"`
+	CommitId   string `json:"commitId"`
+	RemoteUrl  string `json:"remoteUrl"`
+}
+
+// Metadata of the build the scan ran in, added by the CLI so findings can
+// be traced back to it
+type ScanMetadata struct {
+	CommitId   string `json:"commitId,omitempty"`
+	Branch     string `json:"branch,omitempty"`
+	CIProvider string `json:"ciProvider,omitempty"`
+	BuildId    string `json:"buildId,omitempty"`
+	BuildURL   string `json:"buildUrl,omitempty"`
+}
+
+type Source struct {
+	SourceType  string `json:"sourceType"`
+	Id          string `json:"id"`
+	Name        string `json:"name"`
+	Category    string `json:"category"`
+	Sensitivity string `json:"sensitivity"`
+	IsSensitive bool   `json:"isSensitive"`
+}
+
+type Occurrence struct {
+	Sample       string `json:"sample"`
+	LineNumber   int    `json:"lineNumber"`
+	ColumnNumber int    `json:"columnNumber"`
+	FileName     string `json:"fileName"`
+	Excerpt      string `json:"excerpt"`
+}
+
+type Processing struct {
+	SourceId    string       `json:"sourceId"`
+	Occurrences []Occurrence `json:"occurrences"`
+}
+
+type Sink struct {
+	SinkType string   `json:"sinkType"`
+	Id       string   `json:"id"`
+	Name     string   `json:"name"`
+	Domains  []string `json:"domains"`
+	ApiUrl   []string `json:"apiUrl"`
+}
+
+type DataFlowPath struct {
+	PathId string       `json:"pathId"`
+	Path   []Occurrence `json:"path"`
+}
+
+type DataFlowSink struct {
+	Sink
+	Paths []DataFlowPath `json:"paths"`
+}
+
+type DataFlow struct {
+	SourceId string         `json:"sourceId"`
+	Sinks    []DataFlowSink `json:"sinks"`
+}
+
+// Occurrence of a data element collected by an API endpoint of the service
+type CollectionOccurrence struct {
+	Occurrence
+	EndPoint string `json:"endPoint"`
+}
+
+type CollectionSource struct {
+	SourceId    string                 `json:"sourceId"`
+	Occurrences []CollectionOccurrence `json:"occurrences"`
+}
+
+// Data elements collected by the API endpoints of a framework (e.g. Spring
+// annotations, express routes)
+type Collection struct {
+	CollectionId string             `json:"collectionId"`
+	Name         string             `json:"name"`
+	Collections  []CollectionSource `json:"collections"`
+}
+
+type PolicyDetails struct {
+	Name        string            `json:"name"`
+	PolicyType  string            `json:"policyType"`
+	Description string            `json:"description"`
+	Fix         string            `json:"fix"`
+	Action      string            `json:"action"`
+	Severity    string            `json:"severity"`
+	Tags        map[string]string `json:"tags"`
+}
+
+type ViolationDataFlow struct {
+	SourceId string   `json:"sourceId"`
+	SinkId   string   `json:"sinkId"`
+	PathIds  []string `json:"pathIds"`
+}
+
+type Violation struct {
+	PolicyId      string              `json:"policyId"`
+	PolicyDetails PolicyDetails       `json:"policyDetails"`
+	DataFlow      []ViolationDataFlow `json:"dataFlow"`
+	Processing    []Processing        `json:"processing"`
+}
+
+// A finding is a single policy violation instance: either a dataflow
+// from a source to a sink or a processing occurrence of a source
+type Finding struct {
+	Id          string `json:"id"`
+	PolicyId    string `json:"policyId"`
+	PolicyName  string `json:"policyName"`
+	PolicyType  string `json:"policyType"`
+	Description string `json:"description"`
+	Severity    string `json:"severity"`
+	SourceId    string `json:"sourceId"`
+	SinkId      string `json:"sinkId,omitempty"`
+	FileName    string `json:"fileName,omitempty"`
+	LineNumber  int    `json:"lineNumber,omitempty"`
+	Sample      string `json:"sample,omitempty"`
+	Excerpt     string `json:"excerpt,omitempty"`
+
+	// owning teams or users, from CODEOWNERS (see owners.Assign)
+	Owners []string `json:"owners,omitempty"`
+}
+
+const (
+	SeverityHigh    = "high"
+	SeverityMedium  = "medium"
+	SeverityLow     = "low"
+	SeverityUnknown = "unknown"
+)
+
+var Severities = []string{SeverityHigh, SeverityMedium, SeverityLow, SeverityUnknown}
+
+func LoadResults(resultsPath string) (*Results, error) {
+	data, err := os.ReadFile(resultsPath)
+	if err != nil {
+		if _, statErr := os.Stat(resultsPath + EncryptedFileExtension); os.IsNotExist(err) && statErr == nil {
+			return nil, ErrEncrypted
+		}
+		return nil, err
+	}
+
+	results := &Results{}
+	if err := json.Unmarshal(data, results); err != nil {
+		return nil, fmt.Errorf("cannot parse results (%s): %v", resultsPath, err)
+	}
+
+	return results, nil
+}
+
+// Returns the severity of the policy: explicitly defined severity
+// (or severity tag) in the policy, else "unknown"
+func (p PolicyDetails) GetSeverity() string {
+	severity := p.Severity
+	if severity == "" {
+		severity = p.Tags["severity"]
+	}
+
+	switch strings.ToLower(severity) {
+	case SeverityHigh, "critical":
+		return SeverityHigh
+	case SeverityMedium:
+		return SeverityMedium
+	case SeverityLow, "info":
+		return SeverityLow
+	}
+	return SeverityUnknown
+}
+
+// Returns the location (last occurrence) of the path with pathId
+func (r *Results) findPathLocation(sourceId, sinkId string, pathIds []string) *Occurrence {
+	for _, flows := range r.DataFlow {
+		for _, flow := range flows {
+			if flow.SourceId != sourceId {
+				continue
+			}
+			for _, sink := range flow.Sinks {
+				if sink.Id != sinkId {
+					continue
+				}
+				for _, path := range sink.Paths {
+					for _, pathId := range pathIds {
+						if path.PathId == pathId && len(path.Path) > 0 {
+							return &path.Path[len(path.Path)-1]
+						}
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Flattens violations into findings. Finding Ids are stable fingerprints
+// of the policy, source, sink, file and matched sample (excluding line
+// numbers so findings survive unrelated edits in the same file)
+func (r *Results) Findings() []Finding {
+	findings := []Finding{}
+
+	for _, violation := range r.Violations {
+		base := Finding{
+			PolicyId:    violation.PolicyId,
+			PolicyName:  violation.PolicyDetails.Name,
+			PolicyType:  violation.PolicyDetails.PolicyType,
+			Description: violation.PolicyDetails.Description,
+			Severity:    violation.PolicyDetails.GetSeverity(),
+		}
+
+		for _, flow := range violation.DataFlow {
+			finding := base
+			finding.SourceId = flow.SourceId
+			finding.SinkId = flow.SinkId
+			if location := r.findPathLocation(flow.SourceId, flow.SinkId, flow.PathIds); location != nil {
+				finding.FileName = location.FileName
+				finding.LineNumber = location.LineNumber
+				finding.Sample = location.Sample
+				finding.Excerpt = location.Excerpt
+			}
+			finding.Id = fingerprint(finding)
+			findings = append(findings, finding)
+		}
+
+		for _, processing := range violation.Processing {
+			for _, occurrence := range processing.Occurrences {
+				finding := base
+				finding.SourceId = processing.SourceId
+				finding.FileName = occurrence.FileName
+				finding.LineNumber = occurrence.LineNumber
+				finding.Sample = occurrence.Sample
+				finding.Excerpt = occurrence.Excerpt
+				finding.Id = fingerprint(finding)
+				findings = append(findings, finding)
+			}
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Id < findings[j].Id
+	})
+
+	return findings
+}
+
+// Returns the sinks personal data flows to (data recipients, storages,
+// leakages), each once, sorted by id
+func (r *Results) DataFlowSinks() []Sink {
+	sinks := map[string]Sink{}
+	for _, flows := range r.DataFlow {
+		for _, flow := range flows {
+			for _, sink := range flow.Sinks {
+				sinks[sink.Id] = sink.Sink
+			}
+		}
+	}
+
+	sorted := []Sink{}
+	for _, sink := range sinks {
+		sorted = append(sorted, sink)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Id < sorted[j].Id })
+	return sorted
+}
+
+func fingerprint(f Finding) string {
+	return auth.CalculateSHA256Hash(strings.Join([]string{f.PolicyId, f.SourceId, f.SinkId, f.FileName, f.Sample}, "|"))[:16]
+}
+
+// Returns number of findings for each severity
+func CountFindingsBySeverity(findings []Finding) map[string]int {
+	counts := map[string]int{}
+	for _, severity := range Severities {
+		counts[severity] = 0
+	}
+	for _, finding := range findings {
+		counts[finding.Severity]++
+	}
+	return counts
+}
@@ -0,0 +1,196 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package roots
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Detection of the additional roots of a repository: directories outside
+// of it that it references with relative paths (go.work, go.mod replace
+// directives, file: dependencies, maven modules, gradle builds), which the
+// engine needs to resolve cross-repository dependencies
+
+// directories never containing manifests of the repository
+var skippedDirectories = map[string]bool{
+	"node_modules": true, "vendor": true, "target": true, "build": true, "dist": true,
+}
+
+type Root struct {
+	// absolute path of the root
+	Path string
+
+	// path of the root relative to the repository, e.g. ../shared
+	RelativePath string
+
+	// manifests referencing the root (relative to the repository)
+	ReferencedBy []string
+}
+
+var (
+	goWorkUsePattern      = regexp.MustCompile(`(?m)^\s*(?:use\s+)?(\.{1,2}/\S*)\s*$`)
+	goModReplacePattern   = regexp.MustCompile(`(?m)=>\s*(\.{1,2}/\S*)\s*$`)
+	mavenModulePattern    = regexp.MustCompile(`<module>\s*(\.\./[^<\s]*)\s*</module>`)
+	gradleIncludePattern  = regexp.MustCompile(`includeBuild\s*\(?\s*["'](\.\./[^"']*)["']`)
+	gradleProjectPattern  = regexp.MustCompile(`projectDir\s*=\s*(?:new\s+File\s*\(\s*settingsDir\s*,\s*|file\s*\(\s*)["'](\.\./[^"']*)["']`)
+	packageDependencyKeys = []string{"dependencies", "devDependencies", "optionalDependencies", "peerDependencies"}
+)
+
+// Returns the existing directories outside of the repository referenced by
+// its manifests, sorted by path. Nested roots are part of their parent
+func Detect(repositoryPath string) ([]Root, error) {
+	references := map[string][]string{}
+	err := filepath.Walk(repositoryPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != repositoryPath && (strings.HasPrefix(info.Name(), ".") || skippedDirectories[info.Name()]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		relativePaths := getReferencedPaths(path, info.Name())
+		if len(relativePaths) == 0 {
+			return nil
+		}
+		manifest, _ := filepath.Rel(repositoryPath, path)
+		for _, relativePath := range relativePaths {
+			root := filepath.FromSlash(relativePath)
+			if !filepath.IsAbs(root) {
+				root = filepath.Join(filepath.Dir(path), root)
+			}
+			root = filepath.Clean(root)
+			if !isOutside(repositoryPath, root) {
+				continue
+			}
+			if info, err := os.Stat(root); err != nil || !info.IsDir() {
+				continue
+			}
+			references[root] = appendUnique(references[root], filepath.ToSlash(manifest))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	paths := []string{}
+	for path := range references {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	roots := []Root{}
+	for _, path := range paths {
+		if len(roots) > 0 && !isOutside(roots[len(roots)-1].Path, path) {
+			// nested in the previous root
+			for _, manifest := range references[path] {
+				roots[len(roots)-1].ReferencedBy = appendUnique(roots[len(roots)-1].ReferencedBy, manifest)
+			}
+			continue
+		}
+		relativePath, _ := filepath.Rel(repositoryPath, path)
+		roots = append(roots, Root{Path: path, RelativePath: filepath.ToSlash(relativePath), ReferencedBy: references[path]})
+	}
+	return roots, nil
+}
+
+// Returns the relative paths referenced by the manifest (any, or outside
+// of its directory), nil if it is not a manifest
+func getReferencedPaths(path, name string) []string {
+	var patterns []*regexp.Regexp
+	switch name {
+	case "go.work":
+		patterns = []*regexp.Regexp{goWorkUsePattern, goModReplacePattern}
+	case "go.mod":
+		patterns = []*regexp.Regexp{goModReplacePattern}
+	case "pom.xml":
+		patterns = []*regexp.Regexp{mavenModulePattern}
+	case "settings.gradle", "settings.gradle.kts":
+		patterns = []*regexp.Regexp{gradleIncludePattern, gradleProjectPattern}
+	case "package.json":
+		return getPackageReferencedPaths(path)
+	default:
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	paths := []string{}
+	for _, pattern := range patterns {
+		for _, match := range pattern.FindAllStringSubmatch(string(data), -1) {
+			paths = append(paths, match[1])
+		}
+	}
+	return paths
+}
+
+// Returns the paths of the file: and link: dependencies of the package
+func getPackageReferencedPaths(path string) []string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	manifest := map[string]interface{}{}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil
+	}
+
+	paths := []string{}
+	for _, key := range packageDependencyKeys {
+		dependencies, _ := manifest[key].(map[string]interface{})
+		for _, version := range dependencies {
+			version, _ := version.(string)
+			for _, prefix := range []string{"file:", "link:"} {
+				if strings.HasPrefix(version, prefix) {
+					paths = append(paths, strings.TrimPrefix(version, prefix))
+				}
+			}
+		}
+	}
+	return paths
+}
+
+// Returns whether path is outside of (and not equal to) directory
+func isOutside(directory, path string) bool {
+	relativePath, err := filepath.Rel(directory, path)
+	return err != nil || relativePath == ".." || strings.HasPrefix(relativePath, ".."+string(filepath.Separator))
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
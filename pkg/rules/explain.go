@@ -0,0 +1,153 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package rules
+
+import (
+	"path/filepath"
+
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+)
+
+// An explanation of why a finding was raised: the policy it violates, the
+// rules of its data element and recipient with the identifiers they matched
+// in the code, and the flow semantics the dataflow went through
+
+type RuleReference struct {
+	Kind string `json:"kind"`
+	Id   string `json:"id"`
+	Name string `json:"name,omitempty"`
+	// definition of the rule, nil if it is not in the loaded rules
+	Rule *Rule `json:"rule,omitempty"`
+	// url of the rule in the repository of the default rules, empty for
+	// external rules
+	URL string `json:"url,omitempty"`
+}
+
+// An identifier (code sample) of the code a rule matched
+type MatchedIdentifier struct {
+	RuleId     string `json:"ruleId"`
+	Sample     string `json:"sample"`
+	FileName   string `json:"fileName"`
+	LineNumber int    `json:"lineNumber"`
+}
+
+type SemanticStep struct {
+	Step       int        `json:"step"`
+	Sample     string     `json:"sample"`
+	FileName   string     `json:"fileName"`
+	LineNumber int        `json:"lineNumber"`
+	Semantics  []Semantic `json:"semantics"`
+}
+
+type Explanation struct {
+	Finding            results.Finding     `json:"finding"`
+	Fix                string              `json:"fix,omitempty"`
+	Policy             RuleReference       `json:"policy"`
+	Source             RuleReference       `json:"source"`
+	Sink               *RuleReference      `json:"sink,omitempty"`
+	MatchedIdentifiers []MatchedIdentifier `json:"matchedIdentifiers"`
+	// steps of the dataflow path the data went through with the semantics
+	// of the methods called in them, empty for processing findings
+	Semantics []SemanticStep `json:"semantics"`
+	Steps     int            `json:"steps"`
+}
+
+// Explains the finding of the results with the rules (nil if no rules
+// could be loaded: the explanation then only links to the default rules)
+func Explain(scanResults *results.Results, finding results.Finding, set *RuleSet) Explanation {
+	explanation := Explanation{
+		Finding:            finding,
+		Policy:             getRuleReference(set, KindPolicy, finding.PolicyId, finding.PolicyName),
+		MatchedIdentifiers: []MatchedIdentifier{},
+		Semantics:          []SemanticStep{},
+	}
+	for _, violation := range scanResults.Violations {
+		if violation.PolicyId == finding.PolicyId {
+			explanation.Fix = violation.PolicyDetails.Fix
+			break
+		}
+	}
+
+	sourceName := ""
+	for _, source := range scanResults.Sources {
+		if source.Id == finding.SourceId {
+			sourceName = source.Name
+		}
+	}
+	explanation.Source = getRuleReference(set, KindSource, finding.SourceId, sourceName)
+	if finding.SinkId != "" {
+		sinkName := ""
+		for _, sink := range scanResults.Sinks {
+			if sink.Id == finding.SinkId {
+				sinkName = sink.Name
+			}
+		}
+		sink := getRuleReference(set, KindSink, finding.SinkId, sinkName)
+		explanation.Sink = &sink
+	}
+
+	path := scanResults.FindingPath(finding)
+	explanation.Steps = len(path)
+	if len(path) == 0 {
+		return explanation
+	}
+
+	// the source rule matched the first step, the sink rule the last one
+	first, last := path[0], path[len(path)-1]
+	explanation.MatchedIdentifiers = append(explanation.MatchedIdentifiers, MatchedIdentifier{
+		RuleId: finding.SourceId, Sample: first.Sample, FileName: relativeFileName(first), LineNumber: first.LineNumber,
+	})
+	if finding.SinkId == "" {
+		return explanation
+	}
+	explanation.MatchedIdentifiers = append(explanation.MatchedIdentifiers, MatchedIdentifier{
+		RuleId: finding.SinkId, Sample: last.Sample, FileName: relativeFileName(last), LineNumber: last.LineNumber,
+	})
+	for i, occurrence := range path[1:] {
+		if semantics := set.FindSemantics(occurrence.Sample); len(semantics) > 0 {
+			explanation.Semantics = append(explanation.Semantics, SemanticStep{
+				Step: i + 1, Sample: occurrence.Sample, FileName: relativeFileName(occurrence), LineNumber: occurrence.LineNumber, Semantics: semantics,
+			})
+		}
+	}
+	return explanation
+}
+
+func getRuleReference(set *RuleSet, kind, id, name string) RuleReference {
+	reference := RuleReference{Kind: kind, Id: id, Name: name}
+	if rule, ok := set.Find(id); ok {
+		reference.Rule = &rule
+		if reference.Name == "" {
+			reference.Name = rule.Name
+		}
+		if !rule.Default {
+			return reference
+		}
+	}
+	reference.URL = DefaultRuleURL(id)
+	return reference
+}
+
+func relativeFileName(occurrence results.Occurrence) string {
+	return filepath.ToSlash(occurrence.RelativeFileName())
+}
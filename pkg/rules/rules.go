@@ -0,0 +1,201 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package rules
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"gopkg.in/yaml.v3"
+)
+
+// Rule definitions of a rules and config directory, in the layout of the
+// default rules of the engine: yaml files with lists of rules of each kind
+// (sources, sinks, policies, collections) and flow semantics
+
+const (
+	KindSource     = "sources"
+	KindSink       = "sinks"
+	KindPolicy     = "policies"
+	KindCollection = "collections"
+)
+
+var kinds = []string{KindSource, KindSink, KindPolicy, KindCollection}
+
+type PolicyDataFlow struct {
+	Sources []string `yaml:"sources" json:"sources,omitempty"`
+	Sinks   []string `yaml:"sinks" json:"sinks,omitempty"`
+}
+
+type Rule struct {
+	Id          string            `yaml:"id" json:"id"`
+	Name        string            `yaml:"name" json:"name,omitempty"`
+	Description string            `yaml:"description" json:"description,omitempty"`
+	Category    string            `yaml:"category" json:"category,omitempty"`
+	Sensitivity string            `yaml:"sensitivity" json:"sensitivity,omitempty"`
+	Patterns    []string          `yaml:"patterns" json:"patterns,omitempty"`
+	Domains     []string          `yaml:"domains" json:"domains,omitempty"`
+	DataFlow    *PolicyDataFlow   `yaml:"dataflow" json:"dataflow,omitempty"`
+	Tags        map[string]string `yaml:"tags" json:"tags,omitempty"`
+
+	Kind string `yaml:"-" json:"kind"`
+	// path of the yaml file defining the rule
+	File string `yaml:"-" json:"file"`
+	// whether the rule is a default rule of the engine (not an external rule)
+	Default bool `yaml:"-" json:"default"`
+}
+
+// A flow semantic tells the engine how data flows through calls of the
+// method with the signature, e.g. "0->-1 1->-1" (from the receiver and the
+// first argument to the return value)
+type Semantic struct {
+	Id        string `yaml:"id" json:"id,omitempty"`
+	Signature string `yaml:"signature" json:"signature"`
+	Flow      string `yaml:"flow" json:"flow"`
+
+	File string `yaml:"-" json:"file"`
+}
+
+type RuleSet struct {
+	Rules     map[string]Rule
+	Semantics []Semantic
+}
+
+type ruleFile struct {
+	Sources     []Rule     `yaml:"sources"`
+	Sinks       []Rule     `yaml:"sinks"`
+	Policies    []Rule     `yaml:"policies"`
+	Collections []Rule     `yaml:"collections"`
+	Semantics   []Semantic `yaml:"semantics"`
+}
+
+// Loads the rules of the yaml files of the default rules directory of the
+// engine (if any) and the external rules directories, external rules
+// replacing the default rules with the same id (as the engine merges them)
+func Load(defaultDirectory string, externalDirectories ...string) (*RuleSet, error) {
+	set := &RuleSet{Rules: map[string]Rule{}}
+	if defaultDirectory != "" {
+		if err := set.loadDirectory(defaultDirectory, true); err != nil {
+			return nil, err
+		}
+	}
+	for _, directory := range externalDirectories {
+		if err := set.loadDirectory(directory, false); err != nil {
+			return nil, err
+		}
+	}
+	return set, nil
+}
+
+func (s *RuleSet) loadDirectory(directory string, isDefault bool) error {
+	return filepath.Walk(directory, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if ext := strings.ToLower(filepath.Ext(path)); ext != ".yaml" && ext != ".yml" {
+			return nil
+		}
+		return s.loadFile(path, isDefault)
+	})
+}
+
+func (s *RuleSet) loadFile(path string, isDefault bool) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	file := ruleFile{}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("cannot parse rules (%s): %v", path, err)
+	}
+
+	for i, rules := range [][]Rule{file.Sources, file.Sinks, file.Policies, file.Collections} {
+		for _, rule := range rules {
+			if rule.Id == "" {
+				continue
+			}
+			rule.Kind = kinds[i]
+			rule.File = path
+			rule.Default = isDefault
+			s.Rules[rule.Id] = rule
+		}
+	}
+	for _, semantic := range file.Semantics {
+		if semantic.Signature == "" {
+			continue
+		}
+		semantic.File = path
+		s.Semantics = append(s.Semantics, semantic)
+	}
+	return nil
+}
+
+// Returns the rule with the id
+func (s *RuleSet) Find(id string) (Rule, bool) {
+	if s == nil {
+		return Rule{}, false
+	}
+	rule, ok := s.Rules[id]
+	return rule, ok
+}
+
+// Returns the semantics of the methods called in the code sample (e.g. the
+// sample of a step of a dataflow path), sorted by signature
+func (s *RuleSet) FindSemantics(sample string) []Semantic {
+	matches := []Semantic{}
+	if s == nil {
+		return matches
+	}
+	for _, semantic := range s.Semantics {
+		if name := semantic.MethodName(); name != "" && strings.Contains(sample, name+"(") {
+			matches = append(matches, semantic)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Signature < matches[j].Signature })
+	return matches
+}
+
+// Returns the name of the method of the signature, e.g. concat for
+// java.lang.String.concat:java.lang.String(java.lang.String)
+func (s Semantic) MethodName() string {
+	name := strings.SplitN(s.Signature, ":", 2)[0]
+	name = strings.SplitN(name, "(", 2)[0]
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// Returns the url searching the default rules of the engine for the rule
+// (their repository, as the path of a rule is not part of the results)
+func DefaultRuleURL(id string) string {
+	query := url.Values{}
+	query.Set("q", fmt.Sprintf("repo:%s %q", config.AppConfig.PrivadoRulesRepositoryName, id))
+	query.Set("type", "code")
+	return "https://github.com/search?" + query.Encode()
+}
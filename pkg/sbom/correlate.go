@@ -0,0 +1,139 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package sbom
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+)
+
+// Correlation of third-party sinks found by the scan (e.g. the Stripe sdk)
+// to the components of the SBOM that implement them (com.stripe:stripe-java)
+
+type Match struct {
+	SinkId     string      `json:"sinkId"`
+	SinkName   string      `json:"sinkName"`
+	Components []Component `json:"components"`
+}
+
+var tokenSeparator = regexp.MustCompile(`[^a-z0-9]+`)
+
+func tokenize(value string) []string {
+	tokens := []string{}
+	for _, token := range tokenSeparator.Split(strings.ToLower(value), -1) {
+		if token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// Returns the sets of tokens a component must all have to implement the
+// sink: the words of its name (short words only if there is no other), or
+// the name of one of its domains (api.segment.io: segment)
+func getSinkKeys(sink results.Sink) [][]string {
+	keys := [][]string{}
+	nameTokens := []string{}
+	for _, token := range tokenize(sink.Name) {
+		if len(token) >= 3 {
+			nameTokens = append(nameTokens, token)
+		}
+	}
+	if len(nameTokens) == 0 {
+		nameTokens = tokenize(sink.Name)
+	}
+	if len(nameTokens) > 0 {
+		keys = append(keys, nameTokens)
+	}
+
+	for _, domain := range sink.Domains {
+		labels := strings.Split(strings.ToLower(strings.TrimSpace(domain)), ".")
+		if len(labels) >= 2 && len(labels[len(labels)-2]) >= 3 {
+			keys = append(keys, []string{labels[len(labels)-2]})
+		}
+	}
+	return keys
+}
+
+func getComponentTokens(component Component) map[string]bool {
+	tokens := map[string]bool{}
+	purl := component.Purl
+	if index := strings.IndexAny(purl, "@?#"); index >= 0 {
+		purl = purl[:index]
+	}
+	// the type of the purl (pkg:maven/) is not part of the name
+	if index := strings.Index(purl, "/"); index >= 0 {
+		purl = purl[index+1:]
+	}
+	for _, value := range []string{component.Name, component.Group, purl} {
+		for _, token := range tokenize(value) {
+			tokens[token] = true
+		}
+	}
+	return tokens
+}
+
+// Returns the components of each third-party sink, for the sinks that
+// matched any
+func Correlate(sinks []results.Sink, components []Component) []Match {
+	componentTokens := make([]map[string]bool, len(components))
+	for i, component := range components {
+		componentTokens[i] = getComponentTokens(component)
+	}
+
+	matches := []Match{}
+	for _, sink := range sinks {
+		match := Match{SinkId: sink.Id, SinkName: sink.Name, Components: []Component{}}
+		keys := getSinkKeys(sink)
+		seen := map[string]bool{}
+		for i, component := range components {
+			if seen[component.FullName()+"@"+component.Version] || !matchesAnyKey(componentTokens[i], keys) {
+				continue
+			}
+			seen[component.FullName()+"@"+component.Version] = true
+			match.Components = append(match.Components, component)
+		}
+		if len(match.Components) > 0 {
+			matches = append(matches, match)
+		}
+	}
+	return matches
+}
+
+func matchesAnyKey(tokens map[string]bool, keys [][]string) bool {
+	for _, key := range keys {
+		matched := true
+		for _, token := range key {
+			if !tokens[token] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
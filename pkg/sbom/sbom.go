@@ -0,0 +1,227 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package sbom
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"os"
+	"strings"
+)
+
+// Components of a software bill of materials: CycloneDX (json, xml) and
+// SPDX (json, tag-value)
+
+type Component struct {
+	Name     string `json:"name"`
+	Group    string `json:"group,omitempty"`
+	Version  string `json:"version,omitempty"`
+	Supplier string `json:"supplier,omitempty"`
+	Purl     string `json:"purl,omitempty"`
+}
+
+// Returns the name with the group (com.stripe:stripe-java)
+func (c Component) FullName() string {
+	if c.Group == "" {
+		return c.Name
+	}
+	return c.Group + ":" + c.Name
+}
+
+type SBOM struct {
+	Format     string      `json:"format"`
+	Components []Component `json:"components"`
+}
+
+func Load(sbomPath string) (*SBOM, error) {
+	data, err := os.ReadFile(sbomPath)
+	if err != nil {
+		return nil, err
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		return parseJSON(trimmed)
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		return parseCycloneDXXML(trimmed)
+	case bytes.Contains(trimmed, []byte("SPDXVersion:")):
+		return parseSPDXTagValue(trimmed), nil
+	}
+	return nil, errors.New("not a CycloneDX or SPDX document")
+}
+
+type cycloneDXComponent struct {
+	Name      string `json:"name" xml:"name"`
+	Group     string `json:"group" xml:"group"`
+	Version   string `json:"version" xml:"version"`
+	Publisher string `json:"publisher" xml:"publisher"`
+	Author    string `json:"author" xml:"author"`
+	Purl      string `json:"purl" xml:"purl"`
+	Supplier  struct {
+		Name string `json:"name" xml:"name"`
+	} `json:"supplier" xml:"supplier"`
+	Components []cycloneDXComponent `json:"components" xml:"components>component"`
+}
+
+type spdxPackage struct {
+	Name         string `json:"name"`
+	VersionInfo  string `json:"versionInfo"`
+	Supplier     string `json:"supplier"`
+	Originator   string `json:"originator"`
+	ExternalRefs []struct {
+		ReferenceType    string `json:"referenceType"`
+		ReferenceLocator string `json:"referenceLocator"`
+	} `json:"externalRefs"`
+}
+
+func parseJSON(data []byte) (*SBOM, error) {
+	document := struct {
+		BOMFormat   string               `json:"bomFormat"`
+		SPDXVersion string               `json:"spdxVersion"`
+		Components  []cycloneDXComponent `json:"components"`
+		Packages    []spdxPackage        `json:"packages"`
+	}{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case document.BOMFormat == "CycloneDX":
+		return &SBOM{Format: "cyclonedx", Components: flattenCycloneDX(document.Components)}, nil
+	case document.SPDXVersion != "":
+		sbom := &SBOM{Format: "spdx", Components: []Component{}}
+		for _, spdxPackage := range document.Packages {
+			component := Component{Name: spdxPackage.Name, Version: spdxPackage.VersionInfo, Supplier: getSPDXOrganization(spdxPackage.Supplier, spdxPackage.Originator)}
+			for _, reference := range spdxPackage.ExternalRefs {
+				if reference.ReferenceType == "purl" {
+					component.Purl = reference.ReferenceLocator
+				}
+			}
+			sbom.Components = append(sbom.Components, component)
+		}
+		return sbom, nil
+	}
+	return nil, errors.New("not a CycloneDX or SPDX document (no bomFormat or spdxVersion)")
+}
+
+func parseCycloneDXXML(data []byte) (*SBOM, error) {
+	document := struct {
+		XMLName    xml.Name
+		Components []cycloneDXComponent `xml:"components>component"`
+	}{}
+	if err := xml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	if document.XMLName.Local != "bom" {
+		return nil, errors.New("not a CycloneDX document (no bom element)")
+	}
+	return &SBOM{Format: "cyclonedx", Components: flattenCycloneDX(document.Components)}, nil
+}
+
+// components with their nested components
+func flattenCycloneDX(cycloneDXComponents []cycloneDXComponent) []Component {
+	components := []Component{}
+	for _, c := range cycloneDXComponents {
+		supplier := c.Supplier.Name
+		if supplier == "" {
+			supplier = c.Publisher
+		}
+		if supplier == "" {
+			supplier = c.Author
+		}
+		components = append(components, Component{Name: c.Name, Group: c.Group, Version: c.Version, Supplier: supplier, Purl: c.Purl})
+		components = append(components, flattenCycloneDX(c.Components)...)
+	}
+	return components
+}
+
+func parseSPDXTagValue(data []byte) *SBOM {
+	sbom := &SBOM{Format: "spdx", Components: []Component{}}
+	var current *Component
+	originator := ""
+	flush := func() {
+		if current != nil {
+			if current.Supplier == "" {
+				current.Supplier = getSPDXOrganization("", originator)
+			}
+			sbom.Components = append(sbom.Components, *current)
+		}
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		index := strings.Index(line, ":")
+		if index < 0 {
+			continue
+		}
+		tag, value := line[:index], strings.TrimSpace(line[index+1:])
+		switch tag {
+		case "PackageName":
+			flush()
+			current = &Component{Name: value}
+			originator = ""
+		case "PackageVersion":
+			if current != nil {
+				current.Version = value
+			}
+		case "PackageSupplier":
+			if current != nil {
+				current.Supplier = getSPDXOrganization(value, "")
+			}
+		case "PackageOriginator":
+			originator = value
+		case "ExternalRef":
+			// ExternalRef: PACKAGE-MANAGER purl pkg:maven/com.stripe/stripe-java@20.1.0
+			fields := strings.Fields(value)
+			if current != nil && len(fields) == 3 && fields[1] == "purl" {
+				current.Purl = fields[2]
+			}
+		}
+	}
+	flush()
+	return sbom
+}
+
+// SPDX suppliers and originators are "Organization: name" or
+// "Person: name", or NOASSERTION
+func getSPDXOrganization(supplier, originator string) string {
+	for _, value := range []string{supplier, originator} {
+		if value == "" || value == "NOASSERTION" {
+			continue
+		}
+		if index := strings.Index(value, ":"); index >= 0 {
+			value = value[index+1:]
+		}
+		// without the email (name (email))
+		if index := strings.Index(value, "("); index > 0 {
+			value = value[:index]
+		}
+		return strings.TrimSpace(value)
+	}
+	return ""
+}
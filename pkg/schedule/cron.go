@@ -0,0 +1,151 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Minimal cron expressions: "minute hour day-of-month month day-of-week"
+// Each field supports "*", values, ranges (1-5), lists (1,3) and steps
+// (*/15, 1-30/5, or 5/15: from 5 to the maximum)
+
+type Cron struct {
+	expression                          string
+	minutes, hours, days, months, weeks map[int]bool
+	anyDay, anyWeekday                  bool
+}
+
+func ParseCron(expression string) (*Cron, error) {
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q (expected 5 fields: minute hour day-of-month month day-of-week)", expression)
+	}
+
+	c := &Cron{expression: expression}
+	var err error
+	if c.minutes, err = parseField(fields[0], 0, 59); err != nil {
+		return nil, err
+	}
+	if c.hours, err = parseField(fields[1], 0, 23); err != nil {
+		return nil, err
+	}
+	if c.days, err = parseField(fields[2], 1, 31); err != nil {
+		return nil, err
+	}
+	if c.months, err = parseField(fields[3], 1, 12); err != nil {
+		return nil, err
+	}
+	if c.weeks, err = parseField(fields[4], 0, 7); err != nil {
+		return nil, err
+	}
+	// sunday is both 0 and 7
+	if c.weeks[7] {
+		c.weeks[0] = true
+	}
+	c.anyDay = fields[2] == "*"
+	c.anyWeekday = fields[4] == "*"
+
+	return c, nil
+}
+
+func parseField(field string, min, max int) (map[int]bool, error) {
+	values := map[int]bool{}
+	for _, part := range strings.Split(field, ",") {
+		step, hasStep := 1, false
+		if rangeAndStep := strings.SplitN(part, "/", 2); len(rangeAndStep) == 2 {
+			hasStep = true
+			var err error
+			if step, err = strconv.Atoi(rangeAndStep[1]); err != nil || step < 1 {
+				return nil, fmt.Errorf("invalid step in cron field %q", field)
+			}
+			part = rangeAndStep[0]
+		}
+
+		start, end := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if start, err = strconv.Atoi(bounds[0]); err != nil {
+				return nil, fmt.Errorf("invalid value in cron field %q", field)
+			}
+			end = start
+			if hasStep {
+				// a value with a step starts a range up to the maximum
+				end = max
+			}
+			if len(bounds) == 2 {
+				if end, err = strconv.Atoi(bounds[1]); err != nil {
+					return nil, fmt.Errorf("invalid range in cron field %q", field)
+				}
+			}
+		}
+		if start < min || end > max || start > end {
+			return nil, fmt.Errorf("cron field %q is out of range (%d-%d)", field, min, max)
+		}
+
+		for value := start; value <= end; value += step {
+			values[value] = true
+		}
+	}
+	return values, nil
+}
+
+func (c *Cron) String() string {
+	return c.expression
+}
+
+func (c *Cron) matches(t time.Time) bool {
+	if !c.minutes[t.Minute()] || !c.hours[t.Hour()] || !c.months[int(t.Month())] {
+		return false
+	}
+
+	// as in cron, if both day fields are restricted, either may match
+	dayMatches, weekdayMatches := c.days[t.Day()], c.weeks[int(t.Weekday())]
+	switch {
+	case c.anyDay && c.anyWeekday:
+		return true
+	case c.anyDay:
+		return weekdayMatches
+	case c.anyWeekday:
+		return dayMatches
+	}
+	return dayMatches || weekdayMatches
+}
+
+// Returns the next time (after t) matching the expression
+// zero time if there is no match within 5 years (e.g. 30th of February)
+func (c *Cron) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if c.matches(t) {
+			return t
+		}
+		t = t.Add(time.Minute)
+	}
+	return time.Time{}
+}
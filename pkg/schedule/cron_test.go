@@ -0,0 +1,113 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+package schedule
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseField(t *testing.T) {
+	tests := []struct {
+		field    string
+		min, max int
+		want     []int
+		wantErr  bool
+	}{
+		{field: "*", min: 0, max: 6, want: []int{0, 1, 2, 3, 4, 5, 6}},
+		{field: "5", min: 0, max: 59, want: []int{5}},
+		{field: "1-3", min: 0, max: 59, want: []int{1, 2, 3}},
+		{field: "1,3,5", min: 0, max: 59, want: []int{1, 3, 5}},
+		{field: "*/15", min: 0, max: 59, want: []int{0, 15, 30, 45}},
+		{field: "5/15", min: 0, max: 59, want: []int{5, 20, 35, 50}},
+		{field: "10-30/10", min: 0, max: 59, want: []int{10, 20, 30}},
+		{field: "1-2,20/20", min: 0, max: 59, want: []int{1, 2, 20, 40}},
+		{field: "*/5", min: 1, max: 12, want: []int{1, 6, 11}},
+		{field: "60", min: 0, max: 59, wantErr: true},
+		{field: "5-1", min: 0, max: 59, wantErr: true},
+		{field: "*/0", min: 0, max: 59, wantErr: true},
+		{field: "a", min: 0, max: 59, wantErr: true},
+		{field: "1-b", min: 0, max: 59, wantErr: true},
+		{field: "0/5", min: 1, max: 12, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			values, err := parseField(tt.field, tt.min, tt.max)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseField(%q) = %v, want an error", tt.field, values)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseField(%q) returned error: %v", tt.field, err)
+			}
+			want := map[int]bool{}
+			for _, value := range tt.want {
+				want[value] = true
+			}
+			if !reflect.DeepEqual(values, want) {
+				t.Errorf("parseField(%q) = %v, want %v", tt.field, values, want)
+			}
+		})
+	}
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, expression := range []string{"", "* * * *", "* * * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8"} {
+		if _, err := ParseCron(expression); err == nil {
+			t.Errorf("ParseCron(%q) returned no error", expression)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	// a monday
+	from := time.Date(2024, time.January, 1, 10, 7, 30, 0, time.UTC)
+	tests := []struct {
+		expression string
+		want       time.Time
+	}{
+		{expression: "* * * * *", want: time.Date(2024, time.January, 1, 10, 8, 0, 0, time.UTC)},
+		{expression: "5/15 * * * *", want: time.Date(2024, time.January, 1, 10, 20, 0, 0, time.UTC)},
+		{expression: "0 2 * * *", want: time.Date(2024, time.January, 2, 2, 0, 0, 0, time.UTC)},
+		{expression: "30 9 * * 1-5", want: time.Date(2024, time.January, 2, 9, 30, 0, 0, time.UTC)},
+		{expression: "0 0 * * 7", want: time.Date(2024, time.January, 7, 0, 0, 0, 0, time.UTC)},
+		{expression: "0 0 15 * 0", want: time.Date(2024, time.January, 7, 0, 0, 0, 0, time.UTC)},
+		{expression: "0 0 1 */3 *", want: time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		{expression: "0 0 30 2 *", want: time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			c, err := ParseCron(tt.expression)
+			if err != nil {
+				t.Fatalf("ParseCron(%q) returned error: %v", tt.expression, err)
+			}
+			if got := c.Next(from); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", from, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package schedule
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// Outcome of a scheduled scan, kept in the history and sent to webhooks
+type Run struct {
+	ScheduleId         string         `json:"scheduleId"`
+	Repository         string         `json:"repository"`
+	StartedAt          time.Time      `json:"startedAt"`
+	CompletedAt        time.Time      `json:"completedAt"`
+	Status             string         `json:"status"`
+	Error              string         `json:"error,omitempty"`
+	LogPath            string         `json:"logPath"`
+	ResultsPath        string         `json:"resultsPath,omitempty"`
+	FindingsBySeverity map[string]int `json:"findingsBySeverity,omitempty"`
+}
+
+// Returns the directory of the run in the history:
+// <history>/<schedule-id>/<started-at>
+func RunDirectory(historyDirectory, scheduleId string, startedAt time.Time) string {
+	return filepath.Join(historyDirectory, scheduleId, startedAt.UTC().Format("20060102T150405Z"))
+}
+
+// Appends the run to the history of the schedule (runs.jsonl)
+func RecordRun(historyDirectory string, run Run) error {
+	directory := filepath.Join(historyDirectory, run.ScheduleId)
+	if err := os.MkdirAll(directory, os.ModePerm); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(filepath.Join(directory, "runs.jsonl"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
+
+// Returns the runs in the history of the schedule, oldest first
+func LoadRuns(historyDirectory, scheduleId string) ([]Run, error) {
+	data, err := os.ReadFile(filepath.Join(historyDirectory, scheduleId, "runs.jsonl"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []Run{}, nil
+		}
+		return nil, err
+	}
+
+	runs := []Run{}
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		run := Run{}
+		if len(bytes.TrimSpace(line)) == 0 || json.Unmarshal(line, &run) != nil {
+			continue
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+// Removes runs (with their logs and results) started more than maxAge
+// before now and, if keepLast is not 0, all but the keepLast most recent
+// runs of each schedule in the history. Returns the number of removed runs
+func PruneRuns(historyDirectory string, keepLast int, maxAge time.Duration, now time.Time) (int, error) {
+	entries, err := os.ReadDir(historyDirectory)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		runs, err := LoadRuns(historyDirectory, entry.Name())
+		if err != nil {
+			return removed, err
+		}
+
+		kept := []Run{}
+		for i := len(runs) - 1; i >= 0; i-- {
+			run := runs[i]
+			if now.Sub(run.StartedAt) <= maxAge && (keepLast == 0 || len(kept) < keepLast) {
+				kept = append([]Run{run}, kept...)
+				continue
+			}
+			if err := os.RemoveAll(RunDirectory(historyDirectory, entry.Name(), run.StartedAt)); err != nil {
+				return removed, err
+			}
+			removed++
+		}
+		if len(kept) == len(runs) {
+			continue
+		}
+
+		var data bytes.Buffer
+		for _, run := range kept {
+			line, err := json.Marshal(run)
+			if err != nil {
+				return removed, err
+			}
+			data.Write(append(line, '\n'))
+		}
+		runsPath := filepath.Join(historyDirectory, entry.Name(), "runs.jsonl")
+		if err := os.WriteFile(runsPath+".tmp", data.Bytes(), 0644); err != nil {
+			return removed, err
+		}
+		if err := os.Rename(runsPath+".tmp", runsPath); err != nil {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
+
+var webhookClient = &http.Client{Timeout: 10 * time.Second}
+
+// Posts the run to the webhook url
+func NotifyWebhook(url string, run Run) error {
+	data, err := json.Marshal(map[string]interface{}{
+		"event": "scheduled-scan-completed",
+		"run":   run,
+	})
+	if err != nil {
+		return err
+	}
+
+	res, err := webhookClient.Post(url, "application/json", bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("received status %d", res.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package schedule
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Scheduled scans run by the daemon (privado daemon)
+
+type Schedule struct {
+	Id         string    `json:"id"`
+	Repository string    `json:"repository"`
+	Cron       string    `json:"cron"`
+	CreatedAt  time.Time `json:"createdAt"`
+
+	// additional flags passed to the scan
+	ScanArgs []string `json:"scanArgs,omitempty"`
+
+	// urls notified (POST) after each scheduled scan
+	NotifyWebhooks []string `json:"notifyWebhooks,omitempty"`
+
+	// recipients the report (pdf or markdown) is emailed to after each
+	// successful scheduled scan (see privado report --email)
+	EmailReports []string `json:"emailReports,omitempty"`
+	ReportFormat string   `json:"reportFormat,omitempty"`
+
+	LastRunAt  time.Time `json:"lastRunAt,omitempty"`
+	LastStatus string    `json:"lastStatus,omitempty"`
+}
+
+// Returns the next run of the schedule after t
+func (s *Schedule) NextRun(t time.Time) time.Time {
+	cron, err := ParseCron(s.Cron)
+	if err != nil {
+		return time.Time{}
+	}
+	return cron.Next(t)
+}
+
+func NewSchedule(repository, cron string) (*Schedule, error) {
+	if _, err := ParseCron(cron); err != nil {
+		return nil, err
+	}
+	return &Schedule{
+		Id:         strings.Split(uuid.NewString(), "-")[0],
+		Repository: repository,
+		Cron:       cron,
+		CreatedAt:  time.Now(),
+	}, nil
+}
+
+func Load(schedulesPath string) ([]*Schedule, error) {
+	data, err := os.ReadFile(schedulesPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []*Schedule{}, nil
+		}
+		return nil, err
+	}
+
+	schedules := []*Schedule{}
+	if err := json.Unmarshal(data, &schedules); err != nil {
+		return nil, fmt.Errorf("cannot parse schedules (%s): %v", schedulesPath, err)
+	}
+	return schedules, nil
+}
+
+func Save(schedulesPath string, schedules []*Schedule) error {
+	sort.SliceStable(schedules, func(i, j int) bool {
+		return schedules[i].CreatedAt.Before(schedules[j].CreatedAt)
+	})
+
+	data, err := json.MarshalIndent(schedules, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(schedulesPath), os.ModePerm); err != nil {
+		return err
+	}
+
+	// write atomically, the daemon may be reading the file
+	tmpPath := schedulesPath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, schedulesPath)
+}
@@ -0,0 +1,235 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package server
+
+//go:generate protoc -I ../../api/proto --go_out=../.. --go_opt=module=github.com/Privado-Inc/privado-cli --go-grpc_out=../.. --go-grpc_opt=module=github.com/Privado-Inc/privado-cli privado/v1/scan_service.proto
+
+import (
+	"bufio"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Privado-Inc/privado-cli/pkg/server/privadov1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// gRPC API to run scans as a service (ScanService in
+// api/proto/privado/v1/scan_service.proto): queue a scan, get its status
+// and progress, stream its output and get its results. Requests are
+// authenticated with the token of the server as "authorization" metadata
+
+// how often the log of a running scan is checked for new lines
+const logPollInterval = 500 * time.Millisecond
+
+type grpcService struct {
+	privadov1.UnimplementedScanServiceServer
+	server *Server
+}
+
+func (s *Server) GRPCServer() *grpc.Server {
+	grpcServer := grpc.NewServer(
+		grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			if err := s.authorize(ctx); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := s.authorize(stream.Context()); err != nil {
+				return err
+			}
+			return handler(srv, stream)
+		}),
+	)
+	privadov1.RegisterScanServiceServer(grpcServer, &grpcService{server: s})
+	return grpcServer
+}
+
+func (s *Server) authorize(ctx context.Context) error {
+	if s.config.Token == "" {
+		return nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	expected := []byte("Bearer " + s.config.Token)
+	if len(values) != 1 || subtle.ConstantTimeCompare([]byte(values[0]), expected) != 1 {
+		return status.Error(codes.Unauthenticated, "unauthorized")
+	}
+	return nil
+}
+
+func (g *grpcService) Scan(ctx context.Context, request *privadov1.ScanRequest) (*privadov1.Job, error) {
+	var retention *time.Duration
+	if request.Retention != "" {
+		duration, err := time.ParseDuration(request.Retention)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid retention: %s", err)
+		}
+		retention = &duration
+	}
+
+	job, err := g.server.queueScan(request.Repository, request.Args, retention)
+	var invalidErr *invalidRequestError
+	if errors.As(err, &invalidErr) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	} else if err == errQueueFull {
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return jobToProto(job), nil
+}
+
+func (g *grpcService) GetStatus(ctx context.Context, request *privadov1.GetStatusRequest) (*privadov1.JobStatus, error) {
+	job := g.server.jobs.get(request.Id)
+	if job == nil {
+		return nil, status.Error(codes.NotFound, "scan not found")
+	}
+
+	p := readProgress(g.server.jobs.progressPath(job.Id))
+	jobStatus := &privadov1.JobStatus{Job: jobToProto(job), Warnings: p.Warnings}
+	for _, phase := range p.Phases {
+		jobStatus.Phases = append(jobStatus.Phases, &privadov1.PhaseProgress{
+			Phase:      phase.Phase,
+			Status:     phase.Status,
+			DurationMs: phase.DurationMs,
+			Error:      phase.Error,
+		})
+	}
+	if p.Findings != nil {
+		findings := int32(*p.Findings)
+		jobStatus.Findings = &findings
+		jobStatus.FindingsBySeverity = map[string]int32{}
+		for severity, count := range p.FindingsBySeverity {
+			jobStatus.FindingsBySeverity[severity] = int32(count)
+		}
+	}
+	return jobStatus, nil
+}
+
+// Streams the lines of the log of the scan as they are written, until the
+// scan completes (or the client cancels)
+func (g *grpcService) StreamLogs(request *privadov1.StreamLogsRequest, stream privadov1.ScanService_StreamLogsServer) error {
+	if g.server.jobs.get(request.Id) == nil {
+		return status.Error(codes.NotFound, "scan not found")
+	}
+
+	var reader *bufio.Reader
+	line := ""
+	for {
+		job := g.server.jobs.get(request.Id)
+		if job == nil {
+			return status.Error(codes.NotFound, "scan removed")
+		}
+		// lines written before the scan completed are sent below
+		done := job.IsDone()
+
+		// the log is created once the scan starts
+		if reader == nil && job.StartedAt != nil {
+			if file, err := os.Open(g.server.jobs.logPath(job.Id)); err == nil {
+				defer file.Close()
+				if !request.FromStart {
+					_, _ = file.Seek(0, io.SeekEnd)
+				}
+				reader = bufio.NewReader(file)
+			}
+		}
+
+		for reader != nil {
+			chunk, err := reader.ReadString('\n')
+			line += chunk
+			if err != nil {
+				// a partial line is completed by the next write
+				break
+			}
+			if err := stream.Send(&privadov1.LogLine{Line: strings.TrimSuffix(line, "\n")}); err != nil {
+				return err
+			}
+			line = ""
+		}
+
+		if done {
+			if line != "" {
+				return stream.Send(&privadov1.LogLine{Line: line})
+			}
+			return nil
+		}
+
+		select {
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		case <-time.After(logPollInterval):
+		}
+	}
+}
+
+func (g *grpcService) GetResults(ctx context.Context, request *privadov1.GetResultsRequest) (*privadov1.Results, error) {
+	job := g.server.jobs.get(request.Id)
+	if job == nil {
+		return nil, status.Error(codes.NotFound, "scan not found")
+	}
+	if job.Status != JobStatusSucceeded {
+		return nil, status.Error(codes.FailedPrecondition, fmt.Sprintf("no results available (scan %s)", job.Status))
+	}
+
+	data, err := os.ReadFile(g.server.jobs.resultsPath(job.Id))
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &privadov1.Results{PrivadoJson: data}, nil
+}
+
+var jobStatuses = map[string]privadov1.Status{
+	JobStatusQueued:    privadov1.Status_STATUS_QUEUED,
+	JobStatusRunning:   privadov1.Status_STATUS_RUNNING,
+	JobStatusSucceeded: privadov1.Status_STATUS_SUCCEEDED,
+	JobStatusFailed:    privadov1.Status_STATUS_FAILED,
+}
+
+func jobToProto(job *Job) *privadov1.Job {
+	protoJob := &privadov1.Job{
+		Id:         job.Id,
+		Repository: job.Repository,
+		Args:       job.Args,
+		Status:     jobStatuses[job.Status],
+		Error:      job.Error,
+		CreatedAt:  timestamppb.New(job.CreatedAt),
+	}
+	if job.StartedAt != nil {
+		protoJob.StartedAt = timestamppb.New(*job.StartedAt)
+	}
+	if job.CompletedAt != nil {
+		protoJob.CompletedAt = timestamppb.New(*job.CompletedAt)
+	}
+	return protoJob
+}
@@ -0,0 +1,349 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Privado-Inc/privado-cli/pkg/progress"
+	"github.com/google/uuid"
+)
+
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+)
+
+const (
+	jobFileName      = "job.json"
+	jobLogFileName   = "scan.log"
+	progressFileName = "progress.ndjson"
+	resultsFileName  = "privado.json"
+	sourceDirectory  = "source"
+)
+
+type Job struct {
+	Id          string     `json:"id"`
+	Repository  string     `json:"repository"`
+	Args        []string   `json:"args,omitempty"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	StartedAt   *time.Time `json:"startedAt,omitempty"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+
+	// how long the job (and its logs and results) is kept once completed
+	Retention Duration `json:"retention"`
+
+	// set once completed, from CompletedAt and Retention
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+
+	// set for jobs triggered by webhooks, which scan a checkout of the commit
+	Trigger *Trigger `json:"trigger,omitempty"`
+}
+
+// time.Duration (un)marshalled as a string, e.g. "24h"
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = Duration(duration)
+	return nil
+}
+
+func newJob(repository string, args []string, retention time.Duration) *Job {
+	return &Job{
+		Id:         uuid.NewString(),
+		Repository: repository,
+		Args:       args,
+		Status:     JobStatusQueued,
+		CreatedAt:  time.Now().UTC(),
+		Retention:  Duration(retention),
+	}
+}
+
+func (j *Job) IsDone() bool {
+	return j.Status == JobStatusSucceeded || j.Status == JobStatusFailed
+}
+
+// Jobs in memory, persisted in a directory per job so that
+// the queue and the history survive restarts of the server
+type jobStore struct {
+	mu        sync.Mutex
+	directory string
+	jobs      map[string]*Job
+}
+
+func newJobStore(directory string) *jobStore {
+	return &jobStore{directory: directory, jobs: map[string]*Job{}}
+}
+
+func (s *jobStore) jobDirectory(id string) string {
+	return filepath.Join(s.directory, id)
+}
+
+func (s *jobStore) logPath(id string) string {
+	return filepath.Join(s.jobDirectory(id), jobLogFileName)
+}
+
+func (s *jobStore) progressPath(id string) string {
+	return filepath.Join(s.jobDirectory(id), progressFileName)
+}
+
+func (s *jobStore) sourcePath(id string) string {
+	return filepath.Join(s.jobDirectory(id), sourceDirectory)
+}
+
+func (s *jobStore) resultsPath(id string) string {
+	return filepath.Join(s.jobDirectory(id), resultsFileName)
+}
+
+// Loads persisted jobs. Jobs interrupted by a restart of the server are
+// marked failed; returns ids of queued jobs, oldest first
+func (s *jobStore) load() ([]string, error) {
+	entries, err := os.ReadDir(s.directory)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	queued := []*Job{}
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join(s.directory, entry.Name(), jobFileName))
+		if err != nil {
+			continue
+		}
+		job := &Job{}
+		if err := json.Unmarshal(data, job); err != nil || job.Id != entry.Name() {
+			continue
+		}
+
+		switch job.Status {
+		case JobStatusQueued:
+			queued = append(queued, job)
+		case JobStatusRunning:
+			job.complete("interrupted by a restart of the server")
+			_ = s.save(job)
+		}
+		s.jobs[job.Id] = job
+	}
+
+	sort.Slice(queued, func(i, j int) bool {
+		return queued[i].CreatedAt.Before(queued[j].CreatedAt)
+	})
+	ids := []string{}
+	for _, job := range queued {
+		ids = append(ids, job.Id)
+	}
+	return ids, nil
+}
+
+// callers hold s.mu
+func (s *jobStore) save(job *Job) error {
+	data, err := json.MarshalIndent(job, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.jobDirectory(job.Id), os.ModePerm); err != nil {
+		return err
+	}
+
+	// write atomically, jobs are read back on restart
+	jobPath := filepath.Join(s.jobDirectory(job.Id), jobFileName)
+	if err := os.WriteFile(jobPath+".tmp", data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(jobPath+".tmp", jobPath)
+}
+
+func (s *jobStore) add(job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.save(job); err != nil {
+		return err
+	}
+	s.jobs[job.Id] = job
+	return nil
+}
+
+// Returns a copy of the job, nil if not found
+func (s *jobStore) get(id string) *Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if job, ok := s.jobs[id]; ok {
+		jobCopy := *job
+		return &jobCopy
+	}
+	return nil
+}
+
+// Returns copies of jobs with the status (all if empty), latest first
+func (s *jobStore) list(status string) []*Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := []*Job{}
+	for _, job := range s.jobs {
+		if status != "" && job.Status != status {
+			continue
+		}
+		jobCopy := *job
+		jobs = append(jobs, &jobCopy)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.After(jobs[j].CreatedAt)
+	})
+	return jobs
+}
+
+// Returns the number of jobs for each status
+func (s *jobStore) count() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := map[string]int{
+		JobStatusQueued:    0,
+		JobStatusRunning:   0,
+		JobStatusSucceeded: 0,
+		JobStatusFailed:    0,
+	}
+	for _, job := range s.jobs {
+		counts[job.Status]++
+	}
+	return counts
+}
+
+func (s *jobStore) update(id string, fn func(job *Job)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if job, ok := s.jobs[id]; ok {
+		fn(job)
+		_ = s.save(job)
+	}
+}
+
+func (j *Job) complete(errorMessage string) {
+	completedAt := time.Now().UTC()
+	expiresAt := completedAt.Add(time.Duration(j.Retention))
+	j.CompletedAt = &completedAt
+	j.ExpiresAt = &expiresAt
+	if errorMessage != "" {
+		j.Status = JobStatusFailed
+		j.Error = errorMessage
+	} else {
+		j.Status = JobStatusSucceeded
+	}
+}
+
+// Removes completed jobs past their retention, with their files
+func (s *jobStore) removeExpired(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, job := range s.jobs {
+		if job.ExpiresAt != nil && now.After(*job.ExpiresAt) {
+			if err := os.RemoveAll(s.jobDirectory(id)); err == nil {
+				delete(s.jobs, id)
+			}
+		}
+	}
+}
+
+type PhaseProgress struct {
+	Phase      string `json:"phase"`
+	Status     string `json:"status"`
+	DurationMs *int64 `json:"durationMs,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Progress of a job, summarized from its progress events
+type Progress struct {
+	Phases             []PhaseProgress `json:"phases"`
+	Warnings           []string        `json:"warnings"`
+	Findings           *int            `json:"findings,omitempty"`
+	FindingsBySeverity map[string]int  `json:"findingsBySeverity,omitempty"`
+}
+
+func readProgress(progressPath string) Progress {
+	p := Progress{Phases: []PhaseProgress{}, Warnings: []string{}}
+
+	file, err := os.Open(progressPath)
+	if err != nil {
+		return p
+	}
+	defer file.Close()
+
+	phaseIndex := map[string]int{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		event := progress.Event{}
+		if err := json.Unmarshal([]byte(strings.TrimSpace(scanner.Text())), &event); err != nil {
+			continue
+		}
+
+		switch event.Type {
+		case progress.EventPhaseStarted:
+			phaseIndex[event.Phase] = len(p.Phases)
+			p.Phases = append(p.Phases, PhaseProgress{Phase: event.Phase, Status: "running"})
+		case progress.EventPhaseCompleted:
+			i, ok := phaseIndex[event.Phase]
+			if !ok {
+				continue
+			}
+			p.Phases[i].Status = "completed"
+			if event.Success != nil && !*event.Success {
+				p.Phases[i].Status = "failed"
+			}
+			p.Phases[i].DurationMs = event.DurationMs
+			p.Phases[i].Error = event.Error
+		case progress.EventFindingCount:
+			p.Findings = event.Total
+			p.FindingsBySeverity = event.BySeverity
+		case progress.EventWarning:
+			p.Warnings = append(p.Warnings, event.Message)
+		}
+	}
+
+	return p
+}
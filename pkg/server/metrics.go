@@ -0,0 +1,176 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package server
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Privado-Inc/privado-cli/pkg/progress"
+)
+
+// Metrics of the scan service in the Prometheus text exposition format
+
+var durationBuckets = []float64{30, 60, 120, 300, 600, 1200, 1800, 3600}
+
+type histogram struct {
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+func newHistogram(buckets []float64) *histogram {
+	return &histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+}
+
+func (h *histogram) observe(value float64) {
+	for i, bucket := range h.buckets {
+		if value <= bucket {
+			h.counts[i]++
+		}
+	}
+	h.sum += value
+	h.count++
+}
+
+func (h *histogram) write(w io.Writer, name string) {
+	for i, bucket := range h.buckets {
+		fmt.Fprintf(w, "%s_bucket{le=\"%g\"} %d\n", name, bucket, h.counts[i])
+	}
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", name, h.count)
+	fmt.Fprintf(w, "%s_sum %g\n", name, h.sum)
+	fmt.Fprintf(w, "%s_count %d\n", name, h.count)
+}
+
+type metrics struct {
+	mu sync.Mutex
+
+	// completed scans by status
+	scans        map[string]uint64
+	scanDuration *histogram
+
+	// image pulls by result (succeeded, failed)
+	imagePulls        map[string]uint64
+	imagePullDuration *histogram
+
+	rejectedScans uint64
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		scans:             map[string]uint64{JobStatusSucceeded: 0, JobStatusFailed: 0},
+		scanDuration:      newHistogram(durationBuckets),
+		imagePulls:        map[string]uint64{JobStatusSucceeded: 0, JobStatusFailed: 0},
+		imagePullDuration: newHistogram(durationBuckets),
+	}
+}
+
+func (m *metrics) observeJob(job *Job, p Progress) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.scans[job.Status]++
+	if job.StartedAt != nil && job.CompletedAt != nil {
+		m.scanDuration.observe(job.CompletedAt.Sub(*job.StartedAt).Seconds())
+	}
+
+	for _, phase := range p.Phases {
+		if phase.Phase != progress.PhaseImagePull || phase.Status == "running" {
+			continue
+		}
+		if phase.Status == "failed" {
+			m.imagePulls[JobStatusFailed]++
+		} else {
+			m.imagePulls[JobStatusSucceeded]++
+		}
+		if phase.DurationMs != nil {
+			m.imagePullDuration.observe(float64(*phase.DurationMs) / 1000)
+		}
+	}
+}
+
+func (m *metrics) observeRejected() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rejectedScans++
+}
+
+func writeCounterByLabel(w io.Writer, name, label string, values map[string]uint64) {
+	keys := []string{}
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s{%s=%q} %d\n", name, label, key, values[key])
+	}
+}
+
+func (m *metrics) write(w io.Writer, counts map[string]int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP privado_server_scans_queued Scans waiting in the queue.")
+	fmt.Fprintln(w, "# TYPE privado_server_scans_queued gauge")
+	fmt.Fprintf(w, "privado_server_scans_queued %d\n", counts[JobStatusQueued])
+
+	fmt.Fprintln(w, "# HELP privado_server_scans_running Scans currently running.")
+	fmt.Fprintln(w, "# TYPE privado_server_scans_running gauge")
+	fmt.Fprintf(w, "privado_server_scans_running %d\n", counts[JobStatusRunning])
+
+	fmt.Fprintln(w, "# HELP privado_server_scans_total Completed scans by status.")
+	fmt.Fprintln(w, "# TYPE privado_server_scans_total counter")
+	writeCounterByLabel(w, "privado_server_scans_total", "status", m.scans)
+
+	fmt.Fprintln(w, "# HELP privado_server_scans_rejected_total Scan requests rejected because the queue was full.")
+	fmt.Fprintln(w, "# TYPE privado_server_scans_rejected_total counter")
+	fmt.Fprintf(w, "privado_server_scans_rejected_total %d\n", m.rejectedScans)
+
+	fmt.Fprintln(w, "# HELP privado_server_scan_duration_seconds Duration of completed scans.")
+	fmt.Fprintln(w, "# TYPE privado_server_scan_duration_seconds histogram")
+	m.scanDuration.write(w, "privado_server_scan_duration_seconds")
+
+	fmt.Fprintln(w, "# HELP privado_server_image_pulls_total Image pulls by result.")
+	fmt.Fprintln(w, "# TYPE privado_server_image_pulls_total counter")
+	writeCounterByLabel(w, "privado_server_image_pulls_total", "result", m.imagePulls)
+
+	fmt.Fprintln(w, "# HELP privado_server_image_pull_duration_seconds Duration of image pulls.")
+	fmt.Fprintln(w, "# TYPE privado_server_image_pull_duration_seconds histogram")
+	m.imagePullDuration.write(w, "privado_server_image_pull_duration_seconds")
+}
+
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	var body strings.Builder
+	s.metrics.write(&body, s.jobs.count())
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = io.WriteString(w, body.String())
+}
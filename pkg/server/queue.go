@@ -0,0 +1,84 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package server
+
+import "sync"
+
+// FIFO queue of job ids, consumed by the scan workers. Jobs of the same
+// repository are run one at a time: they write the results to the same
+// path, a job waits until the running job of its repository completes
+type queue struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	entries []queueEntry
+	// repositories with a running job
+	running map[string]bool
+}
+
+type queueEntry struct {
+	id, repository string
+}
+
+func newQueue() *queue {
+	q := &queue{running: map[string]bool{}}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+func (q *queue) push(id, repository string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.entries = append(q.entries, queueEntry{id: id, repository: repository})
+	q.cond.Broadcast()
+}
+
+// Blocks until a job of a repository without a running job is available,
+// and returns its id and repository. The repository is released with done
+func (q *queue) pop() (string, string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for {
+		for i, entry := range q.entries {
+			if !q.running[entry.repository] {
+				q.entries = append(q.entries[:i], q.entries[i+1:]...)
+				q.running[entry.repository] = true
+				return entry.id, entry.repository
+			}
+		}
+		q.cond.Wait()
+	}
+}
+
+// Releases the repository of a completed job, its next job can be run
+func (q *queue) done(repository string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.running, repository)
+	q.cond.Broadcast()
+}
+
+func (q *queue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.entries)
+}
@@ -0,0 +1,539 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Privado-Inc/privado-cli/pkg/baseline"
+	"github.com/Privado-Inc/privado-cli/pkg/config"
+	"github.com/Privado-Inc/privado-cli/pkg/fileutils"
+	"github.com/Privado-Inc/privado-cli/pkg/gitutils"
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+)
+
+// HTTP API to run scans as a service:
+//
+//	POST   /api/v1/scans                  queue a scan {"repository": "<path>", "args": ["--flag"], "retention": "24h"}
+//	GET    /api/v1/scans                  list scans (?status=queued|running|succeeded|failed)
+//	GET    /api/v1/scans/{id}             status and progress of a scan
+//	GET    /api/v1/scans/{id}/results     results (privado.json) of a scan
+//	GET    /api/v1/scans/{id}/findings    findings of a scan (?new=true, ?severity=<min>)
+//	GET    /api/v1/scans/{id}/logs        output of a scan
+//	GET    /api/v1/baselines?repository=  baseline of a repository
+//	PUT    /api/v1/baselines              set baseline from a scan {"repository": "<path>", "scanId": "<id>"}
+//	DELETE /api/v1/baselines?repository=  remove baseline of a repository
+//	GET    /api/v1/queue                  number of queued and running scans
+//	GET    /metrics                       metrics in the Prometheus text format
+//
+// Scans can also be queued and followed with the gRPC API (see grpc.go) and
+// can be triggered by Git provider webhooks (see webhooks.go)
+
+// Runs a scan of the repository writing the output to logPath
+type ScanFunc func(repository, logPath string, args []string) error
+
+type Config struct {
+	Address string
+
+	// address of the gRPC API (see grpc.go), not served if empty
+	GRPCAddress string
+
+	// bearer token required for all requests, if set
+	Token string
+
+	// only repositories under these directories can be scanned
+	AllowedRoots []string
+
+	// directory for logs, progress and results of jobs
+	JobsDirectory string
+
+	// scans running at the same time, further scans are queued
+	MaxConcurrentScans int
+
+	// scans waiting in the queue, further requests are rejected
+	MaxQueuedScans int
+
+	// default retention of completed jobs, if not set in the request
+	JobRetention time.Duration
+
+	Webhooks WebhookConfig
+
+	Scan ScanFunc
+}
+
+type Server struct {
+	config  Config
+	jobs    *jobStore
+	queue   *queue
+	metrics *metrics
+}
+
+func New(config Config) *Server {
+	if config.MaxConcurrentScans < 1 {
+		config.MaxConcurrentScans = 1
+	}
+	return &Server{
+		config:  config,
+		jobs:    newJobStore(config.JobsDirectory),
+		queue:   newQueue(),
+		metrics: newMetrics(),
+	}
+}
+
+// Resumes persisted jobs and starts the scan workers
+func (s *Server) start() error {
+	queued, err := s.jobs.load()
+	if err != nil {
+		return fmt.Errorf("cannot load jobs: %s", err)
+	}
+	for _, id := range queued {
+		if job := s.jobs.get(id); job != nil {
+			s.queue.push(id, job.Repository)
+		}
+	}
+
+	for i := 0; i < s.config.MaxConcurrentScans; i++ {
+		go func() {
+			for {
+				id, repository := s.queue.pop()
+				s.runJob(id)
+				s.queue.done(repository)
+			}
+		}()
+	}
+
+	go func() {
+		for {
+			s.jobs.removeExpired(time.Now().UTC())
+			time.Sleep(10 * time.Minute)
+		}
+	}()
+
+	return nil
+}
+
+func (s *Server) ListenAndServe() error {
+	if err := s.start(); err != nil {
+		return err
+	}
+
+	// the server stops when either API stops
+	stopped := make(chan error, 2)
+	if s.config.GRPCAddress != "" {
+		listener, err := net.Listen("tcp", s.config.GRPCAddress)
+		if err != nil {
+			return err
+		}
+		go func() {
+			stopped <- s.GRPCServer().Serve(listener)
+		}()
+	}
+
+	server := &http.Server{
+		Addr:              s.config.Address,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		stopped <- server.ListenAndServe()
+	}()
+	return <-stopped
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	mux.HandleFunc("/api/v1/scans", s.authenticated(s.handleScans))
+	mux.HandleFunc("/api/v1/scans/", s.authenticated(s.handleScan))
+	mux.HandleFunc("/api/v1/baselines", s.authenticated(s.handleBaselines))
+	mux.HandleFunc("/api/v1/queue", s.authenticated(s.handleQueue))
+	mux.HandleFunc("/metrics", s.authenticated(s.handleMetrics))
+
+	// webhooks are authenticated with the secrets of the providers
+	mux.HandleFunc("/webhooks/github", s.handleGitHubWebhook)
+	mux.HandleFunc("/webhooks/gitlab", s.handleGitLabWebhook)
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+func (s *Server) authenticated(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.config.Token != "" {
+			expected := []byte("Bearer " + s.config.Token)
+			if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+				writeError(w, http.StatusUnauthorized, "unauthorized")
+				return
+			}
+		}
+		handler(w, r)
+	}
+}
+
+// Validates the repository is an existing directory under an allowed root
+func (s *Server) resolveRepository(repository string) (string, error) {
+	if repository == "" || !filepath.IsAbs(repository) {
+		return "", fmt.Errorf("repository must be an absolute path")
+	}
+	repository = filepath.Clean(repository)
+
+	allowed := false
+	for _, root := range s.config.AllowedRoots {
+		if fileutils.IsWithinDirectory(filepath.Clean(root), repository) {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return "", fmt.Errorf("repository is not under an allowed root")
+	}
+
+	if info, err := os.Stat(repository); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("repository does not exist")
+	}
+	return repository, nil
+}
+
+// only value-less flags (e.g. --skip-dependency-download) are accepted,
+// so that requests cannot refer to other files on the host
+func validateScanArgs(args []string) error {
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "--") || strings.Contains(arg, "=") {
+			return fmt.Errorf("invalid scan argument %q: only flags without values are accepted", arg)
+		}
+	}
+	return nil
+}
+
+func (s *Server) handleScans(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.jobs.list(r.URL.Query().Get("status")))
+	case http.MethodPost:
+		request := struct {
+			Repository string    `json:"repository"`
+			Args       []string  `json:"args"`
+			Retention  *Duration `json:"retention"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+
+		job, err := s.queueScan(request.Repository, request.Args, (*time.Duration)(request.Retention))
+		var invalidErr *invalidRequestError
+		if errors.As(err, &invalidErr) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		} else if err == errQueueFull {
+			w.Header().Set("Retry-After", "60")
+			writeError(w, http.StatusServiceUnavailable, err.Error())
+			return
+		} else if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusAccepted, job)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+var errQueueFull = errors.New("scan queue is full, retry later")
+
+// Error of a scan request that cannot be accepted as is
+type invalidRequestError struct {
+	message string
+}
+
+func (e *invalidRequestError) Error() string {
+	return e.message
+}
+
+// Validates the scan request and queues the scan, with the retention of the
+// server if not set. Returns an *invalidRequestError for invalid requests
+// and errQueueFull when the queue is full
+func (s *Server) queueScan(repository string, args []string, retention *time.Duration) (*Job, error) {
+	repository, err := s.resolveRepository(repository)
+	if err != nil {
+		return nil, &invalidRequestError{err.Error()}
+	}
+	if err := validateScanArgs(args); err != nil {
+		return nil, &invalidRequestError{err.Error()}
+	}
+
+	jobRetention := s.config.JobRetention
+	if retention != nil {
+		if *retention < 0 {
+			return nil, &invalidRequestError{"retention cannot be negative"}
+		}
+		jobRetention = *retention
+	}
+
+	if s.config.MaxQueuedScans > 0 && s.queue.len() >= s.config.MaxQueuedScans {
+		s.metrics.observeRejected()
+		return nil, errQueueFull
+	}
+
+	job := newJob(repository, args, jobRetention)
+	if err := s.jobs.add(job); err != nil {
+		return nil, err
+	}
+	s.queue.push(job.Id, job.Repository)
+	return job, nil
+}
+
+func (s *Server) handleQueue(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	counts := s.jobs.count()
+	writeJSON(w, http.StatusOK, map[string]int{
+		"queued":             counts[JobStatusQueued],
+		"running":            counts[JobStatusRunning],
+		"maxConcurrentScans": s.config.MaxConcurrentScans,
+		"maxQueuedScans":     s.config.MaxQueuedScans,
+	})
+}
+
+func (s *Server) runJob(id string) {
+	job := s.jobs.get(id)
+	if job == nil || job.Status != JobStatusQueued {
+		return
+	}
+	startedAt := time.Now().UTC()
+	s.jobs.update(id, func(job *Job) {
+		job.Status = JobStatusRunning
+		job.StartedAt = &startedAt
+	})
+
+	var err error
+	if job.Trigger != nil {
+		err = gitutils.FetchCommit(job.Trigger.CloneURL, job.Repository, job.Trigger.Commit, s.config.Webhooks.cloneAuthorizationHeader(job.Trigger))
+		if err != nil {
+			err = fmt.Errorf("cannot checkout %s: %s", job.Trigger.Commit, err)
+		}
+	}
+
+	if err == nil {
+		args := append(append([]string{}, job.Args...), "--progress-format", "ndjson", "--progress-output", s.jobs.progressPath(id))
+		err = s.config.Scan(job.Repository, s.jobs.logPath(id), args)
+	}
+
+	// keep the results of the job, the repository may be scanned again
+	if err == nil {
+		err = fileutils.CopyFile(filepath.Join(job.Repository, config.AppConfig.PrivacyResultsPathSuffix), s.jobs.resultsPath(id))
+	}
+	if job.Trigger != nil {
+		_ = os.RemoveAll(job.Repository)
+	}
+
+	s.jobs.update(id, func(job *Job) {
+		errorMessage := ""
+		if err != nil {
+			errorMessage = err.Error()
+		}
+		job.complete(errorMessage)
+	})
+	job = s.jobs.get(id)
+	if job == nil {
+		return
+	}
+	s.metrics.observeJob(job, readProgress(s.jobs.progressPath(id)))
+
+	if job.Trigger != nil && job.Trigger.PullRequest != 0 {
+		findings := []results.Finding{}
+		if scanResults, err := results.LoadResults(s.jobs.resultsPath(id)); err == nil {
+			findings = scanResults.Findings()
+		}
+		if err := s.config.Webhooks.commentOnPullRequest(job, findings); err != nil {
+			fmt.Printf("[WARN]: Could not comment on %s #%d: %s\n", job.Trigger.Repository, job.Trigger.PullRequest, err)
+		}
+	}
+}
+
+func (s *Server) handleScan(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// /api/v1/scans/{id}[/{resource}]
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/api/v1/scans/"), "/", 2)
+	job := s.jobs.get(parts[0])
+	if job == nil {
+		writeError(w, http.StatusNotFound, "scan not found")
+		return
+	}
+	resource := ""
+	if len(parts) == 2 {
+		resource = parts[1]
+	}
+
+	switch resource {
+	case "":
+		writeJSON(w, http.StatusOK, struct {
+			*Job
+			Progress Progress `json:"progress"`
+		}{job, readProgress(s.jobs.progressPath(job.Id))})
+	case "logs":
+		if job.StartedAt == nil {
+			writeError(w, http.StatusNotFound, "no logs available (scan queued)")
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		http.ServeFile(w, r, s.jobs.logPath(job.Id))
+	case "results":
+		if job.Status != JobStatusSucceeded {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("no results available (scan %s)", job.Status))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		http.ServeFile(w, r, s.jobs.resultsPath(job.Id))
+	case "findings":
+		s.handleFindings(w, r, job)
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (s *Server) handleFindings(w http.ResponseWriter, r *http.Request, job *Job) {
+	if job.Status != JobStatusSucceeded {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no results available (scan %s)", job.Status))
+		return
+	}
+	scanResults, err := results.LoadResults(s.jobs.resultsPath(job.Id))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	findings := scanResults.Findings()
+
+	if severity := r.URL.Query().Get("severity"); severity != "" {
+		if !results.IsValidSeverity(severity) {
+			writeError(w, http.StatusBadRequest, "invalid severity")
+			return
+		}
+		findings = results.FilterFindingsAtOrAbove(findings, severity)
+	}
+	if r.URL.Query().Get("new") == "true" {
+		b, err := baseline.Load(baselinePath(job.Repository))
+		if err != nil && err != baseline.ErrNoBaseline {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		// without a baseline, all findings are new
+		if b != nil {
+			findings = b.NewFindings(findings)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, findings)
+}
+
+func baselinePath(repository string) string {
+	return filepath.Join(repository, filepath.Dir(config.AppConfig.PrivacyResultsPathSuffix), baseline.FileName)
+}
+
+func (s *Server) handleBaselines(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet, http.MethodDelete:
+		repository, err := s.resolveRepository(r.URL.Query().Get("repository"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if r.Method == http.MethodDelete {
+			if err := baseline.Remove(baselinePath(repository)); err != nil {
+				writeError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		b, err := baseline.Load(baselinePath(repository))
+		if err == baseline.ErrNoBaseline {
+			writeError(w, http.StatusNotFound, "no baseline")
+			return
+		} else if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, b)
+	case http.MethodPut:
+		request := struct {
+			Repository string `json:"repository"`
+			ScanId     string `json:"scanId"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		repository, err := s.resolveRepository(request.Repository)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		job := s.jobs.get(request.ScanId)
+		if job == nil || job.Repository != repository || job.Status != JobStatusSucceeded {
+			writeError(w, http.StatusBadRequest, "scan not found or without results for the repository")
+			return
+		}
+
+		scanResults, err := results.LoadResults(s.jobs.resultsPath(job.Id))
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		b := baseline.NewFromResults(scanResults)
+		if err := b.Save(baselinePath(repository)); err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, b)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
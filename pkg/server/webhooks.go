@@ -0,0 +1,393 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package server
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Privado-Inc/privado-cli/pkg/results"
+)
+
+// Scans triggered by push and pull/merge request webhooks of Git providers:
+//
+//	POST /webhooks/github  (push, pull_request; signed with the webhook secret)
+//	POST /webhooks/gitlab  (Push Hook, Merge Request Hook; X-Gitlab-Token)
+//
+// The commit is checked out in the job directory and scanned; results of
+// pull/merge requests are posted back as a comment
+
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+
+	TriggerPush        = "push"
+	TriggerPullRequest = "pull-request"
+)
+
+const maxWebhookPayloadSize = 25 << 20
+
+type WebhookConfig struct {
+	GitHubSecret string
+	// to clone private repositories and comment on pull requests
+	GitHubToken string
+
+	GitLabSecret string
+	GitLabToken  string
+	GitLabHost   string
+
+	// glob patterns of repositories (owner/name) scans can be triggered for, all if empty
+	Repositories []string
+}
+
+// Event a job was triggered by
+type Trigger struct {
+	Provider    string `json:"provider"`
+	Event       string `json:"event"`
+	Repository  string `json:"repository"`
+	CloneURL    string `json:"cloneUrl"`
+	Ref         string `json:"ref"`
+	Commit      string `json:"commit"`
+	PullRequest int    `json:"pullRequest,omitempty"`
+
+	// GitLab project id, to comment on merge requests
+	ProjectId int `json:"projectId,omitempty"`
+}
+
+var webhookHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+func (c WebhookConfig) gitLabHost() string {
+	if c.GitLabHost == "" {
+		return "https://gitlab.com"
+	}
+	return strings.TrimSuffix(c.GitLabHost, "/")
+}
+
+func (c WebhookConfig) isRepositoryAllowed(repository string) bool {
+	if len(c.Repositories) == 0 {
+		return true
+	}
+	for _, pattern := range c.Repositories {
+		if matched, _ := filepath.Match(pattern, repository); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (c WebhookConfig) cloneAuthorizationHeader(trigger *Trigger) string {
+	switch {
+	case trigger.Provider == ProviderGitHub && c.GitHubToken != "":
+		return basicAuthorization("x-access-token", c.GitHubToken)
+	case trigger.Provider == ProviderGitLab && c.GitLabToken != "":
+		return basicAuthorization("oauth2", c.GitLabToken)
+	}
+	return ""
+}
+
+func basicAuthorization(username, password string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))
+}
+
+func isNullCommit(commit string) bool {
+	return strings.Trim(commit, "0") == ""
+}
+
+func (s *Server) handleGitHubWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if s.config.Webhooks.GitHubSecret == "" {
+		writeError(w, http.StatusNotFound, "github webhooks are not configured")
+		return
+	}
+
+	payload, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookPayloadSize))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "cannot read payload")
+		return
+	}
+	mac := hmac.New(sha256.New, []byte(s.config.Webhooks.GitHubSecret))
+	mac.Write(payload)
+	expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(r.Header.Get("X-Hub-Signature-256")), []byte(expected)) {
+		writeError(w, http.StatusUnauthorized, "invalid signature")
+		return
+	}
+
+	event := struct {
+		Action     string `json:"action"`
+		Ref        string `json:"ref"`
+		After      string `json:"after"`
+		Repository struct {
+			FullName string `json:"full_name"`
+			CloneURL string `json:"clone_url"`
+		} `json:"repository"`
+		PullRequest struct {
+			Number int `json:"number"`
+			Head   struct {
+				Ref string `json:"ref"`
+				Sha string `json:"sha"`
+			} `json:"head"`
+		} `json:"pull_request"`
+	}{}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+
+	trigger := &Trigger{
+		Provider:   ProviderGitHub,
+		Repository: event.Repository.FullName,
+		CloneURL:   event.Repository.CloneURL,
+	}
+	switch r.Header.Get("X-GitHub-Event") {
+	case "push":
+		if isNullCommit(event.After) {
+			writeJSON(w, http.StatusOK, map[string]string{"status": "ignored", "reason": "ref deleted"})
+			return
+		}
+		trigger.Event = TriggerPush
+		trigger.Ref = event.Ref
+		trigger.Commit = event.After
+	case "pull_request":
+		if event.Action != "opened" && event.Action != "synchronize" && event.Action != "reopened" {
+			writeJSON(w, http.StatusOK, map[string]string{"status": "ignored", "reason": "action " + event.Action})
+			return
+		}
+		trigger.Event = TriggerPullRequest
+		trigger.Ref = event.PullRequest.Head.Ref
+		trigger.Commit = event.PullRequest.Head.Sha
+		trigger.PullRequest = event.PullRequest.Number
+	default:
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ignored"})
+		return
+	}
+
+	s.enqueueTriggeredScan(w, trigger)
+}
+
+func (s *Server) handleGitLabWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if s.config.Webhooks.GitLabSecret == "" {
+		writeError(w, http.StatusNotFound, "gitlab webhooks are not configured")
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Gitlab-Token")), []byte(s.config.Webhooks.GitLabSecret)) != 1 {
+		writeError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	event := struct {
+		Ref     string `json:"ref"`
+		After   string `json:"after"`
+		Project struct {
+			Id                int    `json:"id"`
+			PathWithNamespace string `json:"path_with_namespace"`
+			GitHTTPURL        string `json:"git_http_url"`
+		} `json:"project"`
+		ObjectAttributes struct {
+			Action       string `json:"action"`
+			Iid          int    `json:"iid"`
+			SourceBranch string `json:"source_branch"`
+			LastCommit   struct {
+				Id string `json:"id"`
+			} `json:"last_commit"`
+		} `json:"object_attributes"`
+	}{}
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxWebhookPayloadSize)).Decode(&event); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+
+	trigger := &Trigger{
+		Provider:   ProviderGitLab,
+		Repository: event.Project.PathWithNamespace,
+		CloneURL:   event.Project.GitHTTPURL,
+		ProjectId:  event.Project.Id,
+	}
+	switch r.Header.Get("X-Gitlab-Event") {
+	case "Push Hook":
+		if isNullCommit(event.After) {
+			writeJSON(w, http.StatusOK, map[string]string{"status": "ignored", "reason": "ref deleted"})
+			return
+		}
+		trigger.Event = TriggerPush
+		trigger.Ref = event.Ref
+		trigger.Commit = event.After
+	case "Merge Request Hook":
+		action := event.ObjectAttributes.Action
+		if action != "open" && action != "update" && action != "reopen" {
+			writeJSON(w, http.StatusOK, map[string]string{"status": "ignored", "reason": "action " + action})
+			return
+		}
+		trigger.Event = TriggerPullRequest
+		trigger.Ref = event.ObjectAttributes.SourceBranch
+		trigger.Commit = event.ObjectAttributes.LastCommit.Id
+		trigger.PullRequest = event.ObjectAttributes.Iid
+	default:
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ignored"})
+		return
+	}
+
+	s.enqueueTriggeredScan(w, trigger)
+}
+
+func (s *Server) enqueueTriggeredScan(w http.ResponseWriter, trigger *Trigger) {
+	if trigger.Repository == "" || trigger.CloneURL == "" || trigger.Commit == "" {
+		writeError(w, http.StatusBadRequest, "incomplete payload")
+		return
+	}
+	if !s.config.Webhooks.isRepositoryAllowed(trigger.Repository) {
+		writeError(w, http.StatusForbidden, "repository is not allowed")
+		return
+	}
+	if s.config.MaxQueuedScans > 0 && s.queue.len() >= s.config.MaxQueuedScans {
+		s.metrics.observeRejected()
+		writeError(w, http.StatusServiceUnavailable, "scan queue is full, retry later")
+		return
+	}
+
+	job := newJob("", nil, s.config.JobRetention)
+	job.Repository = s.jobs.sourcePath(job.Id)
+	job.Trigger = trigger
+	if err := s.jobs.add(job); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.queue.push(job.Id, job.Repository)
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// Comments the outcome of the job on the pull/merge request that triggered it
+func (c WebhookConfig) commentOnPullRequest(job *Job, findings []results.Finding) error {
+	trigger := job.Trigger
+	body := renderPullRequestComment(job, findings)
+
+	var endpoint string
+	headers := map[string]string{}
+	switch trigger.Provider {
+	case ProviderGitHub:
+		if c.GitHubToken == "" {
+			return nil
+		}
+		endpoint = fmt.Sprintf("https://api.github.com/repos/%s/issues/%d/comments", trigger.Repository, trigger.PullRequest)
+		headers["Authorization"] = "Bearer " + c.GitHubToken
+		headers["Accept"] = "application/vnd.github+json"
+	case ProviderGitLab:
+		if c.GitLabToken == "" {
+			return nil
+		}
+		endpoint = fmt.Sprintf("%s/api/v4/projects/%s/merge_requests/%d/notes", c.gitLabHost(), url.PathEscape(fmt.Sprint(trigger.ProjectId)), trigger.PullRequest)
+		headers["PRIVATE-TOKEN"] = c.GitLabToken
+	default:
+		return nil
+	}
+
+	data, err := json.Marshal(map[string]string{"body": body})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	res, err := webhookHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("%s: received status %d", trigger.Provider, res.StatusCode)
+	}
+	return nil
+}
+
+// findings listed in comments, the counts cover all findings
+const maxCommentFindings = 25
+
+func renderPullRequestComment(job *Job, findings []results.Finding) string {
+	var b strings.Builder
+	commit := job.Trigger.Commit
+	if len(commit) > 8 {
+		commit = commit[:8]
+	}
+	fmt.Fprintf(&b, "### Privado scan of %s\n\n", commit)
+
+	if job.Status != JobStatusSucceeded {
+		fmt.Fprintf(&b, "The scan failed: `%s`\n", strings.ReplaceAll(job.Error, "`", "'"))
+		return b.String()
+	}
+
+	counts := results.CountFindingsBySeverity(findings)
+	fmt.Fprintf(&b, "| High | Medium | Low | Unknown |\n|---|---|---|---|\n| %d | %d | %d | %d |\n\n",
+		counts[results.SeverityHigh], counts[results.SeverityMedium], counts[results.SeverityLow], counts[results.SeverityUnknown])
+	if len(findings) == 0 {
+		b.WriteString("No findings :tada:\n")
+		return b.String()
+	}
+
+	// most severe first
+	listed := []results.Finding{}
+	for _, severity := range results.Severities {
+		for _, finding := range findings {
+			if finding.Severity == severity && len(listed) < maxCommentFindings {
+				listed = append(listed, finding)
+			}
+		}
+	}
+
+	b.WriteString("| Severity | Policy | Location |\n|---|---|---|\n")
+	for _, finding := range listed {
+		location := ""
+		if finding.FileName != "" {
+			location = fmt.Sprintf("`%s:%d`", finding.RelativeFileName(), finding.LineNumber)
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s |\n", finding.Severity, strings.ReplaceAll(finding.PolicyName, "|", "\\|"), location)
+	}
+	if len(findings) > len(listed) {
+		fmt.Fprintf(&b, "\n…and %d more finding(s)\n", len(findings)-len(listed))
+	}
+	return b.String()
+}
@@ -0,0 +1,201 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package shard
+
+import (
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Deterministic partitioning of the modules of a repository across
+// parallel workers. A module is assigned to a shard based on the hash
+// of its path, so assignments are stable as modules are added
+
+// build manifests identifying a module
+var moduleManifests = []string{
+	"pom.xml", "build.gradle", "build.gradle.kts", "package.json",
+	"go.mod", "requirements.txt", "setup.py", "pyproject.toml",
+	"Gemfile", "composer.json", "build.sbt", "Cargo.toml",
+}
+
+// directories never containing modules of the repository
+var skippedDirectories = map[string]bool{
+	"node_modules": true, "vendor": true, "target": true, "build": true, "dist": true,
+}
+
+type Shard struct {
+	Index, Total int
+}
+
+// Parses a shard in the form "<index>/<total>", e.g. "2/5" (1-based)
+func Parse(s string) (Shard, error) {
+	parts := strings.SplitN(s, "/", 2)
+	if len(parts) != 2 {
+		return Shard{}, fmt.Errorf("invalid shard %q (expected <index>/<total>, e.g. 2/5)", s)
+	}
+	index, indexErr := strconv.Atoi(parts[0])
+	total, totalErr := strconv.Atoi(parts[1])
+	if indexErr != nil || totalErr != nil || total < 1 || index < 1 || index > total {
+		return Shard{}, fmt.Errorf("invalid shard %q (expected <index>/<total> with 1 <= index <= total)", s)
+	}
+	return Shard{Index: index, Total: total}, nil
+}
+
+func (s Shard) String() string {
+	return fmt.Sprintf("%d/%d", s.Index, s.Total)
+}
+
+// the root module: the files of the repository outside of its modules
+const RootModule = "."
+
+// Returns directories (relative to root) of the top-most modules in the
+// repository. The root itself (RootModule) is a module as well when it
+// has a manifest or files outside of the modules, and is the only module
+// if no modules are found
+func DiscoverModules(root string) ([]string, error) {
+	modules := []string{}
+	hasRootFiles := isModule(root)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root {
+			return nil
+		}
+		if strings.HasPrefix(info.Name(), ".") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.IsDir() {
+			hasRootFiles = true
+			return nil
+		}
+		if skippedDirectories[info.Name()] {
+			return filepath.SkipDir
+		}
+
+		if isModule(path) {
+			relativePath, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			modules = append(modules, filepath.ToSlash(relativePath))
+			// nested modules are scanned as a part of the module
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(modules) == 0 {
+		return []string{RootModule}, nil
+	}
+	sort.Strings(modules)
+	if hasRootFiles {
+		modules = append([]string{RootModule}, modules...)
+	}
+	return modules, nil
+}
+
+// Returns the modules scanned separately that are nested in the module,
+// to be excluded from its scan
+func NestedModules(module string, modules []string) []string {
+	nested := []string{}
+	for _, other := range modules {
+		if other == module {
+			continue
+		}
+		if module == RootModule || strings.HasPrefix(other, module+"/") {
+			nested = append(nested, other)
+		}
+	}
+	return nested
+}
+
+func isModule(directory string) bool {
+	for _, manifest := range moduleManifests {
+		if _, err := os.Stat(filepath.Join(directory, manifest)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the modules assigned to the shard
+func (s Shard) Assign(modules []string) []string {
+	assigned := []string{}
+	for _, module := range modules {
+		if s.indexOf(module) == s.Index {
+			assigned = append(assigned, module)
+		}
+	}
+	return assigned
+}
+
+// Returns the number of modules assigned to each shard (by index - 1)
+func (s Shard) Distribution(modules []string) []int {
+	distribution := make([]int, s.Total)
+	for _, module := range modules {
+		distribution[s.indexOf(module)-1]++
+	}
+	return distribution
+}
+
+// Returns whether the modules are distributed unevenly across the shards:
+// a shard has no modules, or more than twice the modules of another
+func IsImbalanced(distribution []int) bool {
+	if len(distribution) < 2 {
+		return false
+	}
+	smallest, largest := distribution[0], distribution[0]
+	for _, count := range distribution[1:] {
+		if count < smallest {
+			smallest = count
+		}
+		if count > largest {
+			largest = count
+		}
+	}
+	return smallest == 0 || largest > 2*smallest
+}
+
+// Returns the (1-based) index of the shard the module is assigned to
+func (s Shard) indexOf(module string) int {
+	hash := fnv.New32a()
+	hash.Write([]byte(module))
+	return int(hash.Sum32()%uint32(s.Total)) + 1
+}
+
+// Returns name of the results file of the shard
+func (s Shard) ResultsFileName() string {
+	return fmt.Sprintf("shard-%d-of-%d.json", s.Index, s.Total)
+}
@@ -0,0 +1,162 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package syslog
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Minimal RFC5424 syslog client (log/syslog only speaks the BSD format and
+// is not available on windows), sending over udp, tcp (octet counting
+// framing, RFC6587) or a unix socket
+
+const (
+	SeverityError   = 3
+	SeverityWarning = 4
+	SeverityNotice  = 5
+	SeverityInfo    = 6
+)
+
+var facilities = map[string]int{
+	"user": 1, "daemon": 3, "auth": 4, "local0": 16, "local1": 17, "local2": 18,
+	"local3": 19, "local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+const (
+	appName = "privado"
+
+	// private SD-IDs require an enterprise number, 32473 is the one
+	// reserved for documentation (RFC5612)
+	structuredDataId = "privado@32473"
+	dialTimeout      = 5 * time.Second
+	sendTimeout      = 5 * time.Second
+)
+
+type Writer struct {
+	mu       sync.Mutex
+	conn     net.Conn
+	framed   bool
+	facility int
+	hostname string
+}
+
+// Connects to the target: udp://host:port, tcp://host:port or unix:///path
+// (e.g. unix:///dev/log); the facility is set with ?facility=local0 (default user)
+func Dial(target string) (*Writer, error) {
+	parsed, err := url.Parse(target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid syslog target '%s': %v", target, err)
+	}
+
+	facility := facilities["user"]
+	if name := parsed.Query().Get("facility"); name != "" {
+		value, ok := facilities[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("unsupported syslog facility: %s", name)
+		}
+		facility = value
+	}
+
+	writer := &Writer{facility: facility}
+	writer.hostname, _ = os.Hostname()
+	if writer.hostname == "" {
+		writer.hostname = "-"
+	}
+
+	switch parsed.Scheme {
+	case "udp", "tcp":
+		if parsed.Port() == "" {
+			return nil, fmt.Errorf("invalid syslog target '%s': port is required", target)
+		}
+		writer.conn, err = net.DialTimeout(parsed.Scheme, parsed.Host, dialTimeout)
+		writer.framed = parsed.Scheme == "tcp"
+	case "unix":
+		// /dev/log is a datagram socket on most systems
+		writer.conn, err = net.DialTimeout("unixgram", parsed.Path, dialTimeout)
+		if err != nil {
+			writer.conn, err = net.DialTimeout("unix", parsed.Path, dialTimeout)
+		}
+	default:
+		return nil, fmt.Errorf("invalid syslog target '%s': expected udp://, tcp:// or unix://", target)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+// Sends the message with the message id (e.g. the event type) and structured
+// data parameters (nil for none)
+func (w *Writer) Send(severity int, msgId string, params map[string]string, message string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	line := fmt.Sprintf("<%d>1 %s %s %s %d %s %s %s",
+		w.facility*8+severity,
+		time.Now().UTC().Format("2006-01-02T15:04:05.000000Z07:00"),
+		w.hostname, appName, os.Getpid(), headerValue(msgId),
+		structuredData(params), message)
+	if w.framed {
+		line = fmt.Sprintf("%d %s", len(line), line)
+	}
+
+	_ = w.conn.SetWriteDeadline(time.Now().Add(sendTimeout))
+	_, err := w.conn.Write([]byte(line))
+	return err
+}
+
+func (w *Writer) Close() error {
+	return w.conn.Close()
+}
+
+func headerValue(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+func structuredData(params map[string]string) string {
+	if len(params) == 0 {
+		return "-"
+	}
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+	element := "[" + structuredDataId
+	for _, key := range keys {
+		element += fmt.Sprintf(` %s="%s"`, key, escaper.Replace(params[key]))
+	}
+	return element + "]"
+}
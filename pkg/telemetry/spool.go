@@ -0,0 +1,183 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package telemetry
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// The spool is a json-lines file holding telemetry events that could not
+// be delivered. Events are flushed in batches on the next invocation, with
+// a short backoff between attempts. The spool is bounded so an endpoint
+// that stays unreachable does not grow it indefinitely
+const (
+	spoolMaxEvents    = 100
+	spoolBatchSize    = 20
+	spoolMaxAttempts  = 3
+	spoolBackoffStart = 250 * time.Millisecond
+)
+
+// guards the spool file against concurrent (async flush) access
+var spoolMutex sync.Mutex
+
+type spooledTelemetryEvent struct {
+	SpooledAt time.Time            `json:"spooledAt"`
+	Body      telemetryRequestBody `json:"body"`
+}
+
+func spoolTelemetryEvent(spoolFilePath string, body telemetryRequestBody) (spooledTelemetryEvent, error) {
+	spoolMutex.Lock()
+	defer spoolMutex.Unlock()
+
+	event := spooledTelemetryEvent{SpooledAt: time.Now().UTC(), Body: body}
+	events, _ := readSpooledTelemetry(spoolFilePath)
+	events = append(events, event)
+
+	// drop the oldest events when over capacity
+	if len(events) > spoolMaxEvents {
+		events = events[len(events)-spoolMaxEvents:]
+	}
+
+	return event, writeSpooledTelemetry(spoolFilePath, events)
+}
+
+func readSpooledTelemetry(spoolFilePath string) ([]spooledTelemetryEvent, error) {
+	data, err := os.ReadFile(spoolFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	events := []spooledTelemetryEvent{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		event := spooledTelemetryEvent{}
+		// ignore corrupt lines, they can never be delivered
+		if err := json.Unmarshal(scanner.Bytes(), &event); err == nil {
+			events = append(events, event)
+		}
+	}
+
+	return events, scanner.Err()
+}
+
+func writeSpooledTelemetry(spoolFilePath string, events []spooledTelemetryEvent) error {
+	if len(events) == 0 {
+		if err := os.Remove(spoolFilePath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(spoolFilePath), os.ModePerm); err != nil {
+		return err
+	}
+
+	buffer := bytes.Buffer{}
+	for _, event := range events {
+		line, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		buffer.Write(line)
+		buffer.WriteByte('\n')
+	}
+
+	return os.WriteFile(spoolFilePath, buffer.Bytes(), 0600)
+}
+
+// Attempts to deliver a batch of spooled events, retrying each with backoff
+// Each delivered or rejected (4xx) event is removed from the spool right
+// away, so an interrupted flush does not deliver it again. Stops on the
+// first event that cannot be delivered (the endpoint is likely still
+// unreachable) and keeps the remaining events in the spool. In anonymous
+// mode, events spooled before are anonymized before they are sent
+// Returns the number of delivered events
+func FlushSpooledTelemetry(spoolFilePath, url, authenticationKeyHash string) int {
+	spoolMutex.Lock()
+	events, err := readSpooledTelemetry(spoolFilePath)
+	spoolMutex.Unlock()
+	if err != nil || len(events) == 0 {
+		return 0
+	}
+
+	delivered := 0
+	for i := 0; i < len(events) && i < spoolBatchSize; i++ {
+		body := events[i].Body
+		if anonymousMode {
+			body = anonymizeRequestBody(body)
+		}
+		err := postWithBackoff(url, authenticationKeyHash, body)
+		if err != nil && !isRejected(err) {
+			break
+		}
+		if err == nil {
+			delivered++
+		}
+		if removeSpooledTelemetryEvent(spoolFilePath, events[i]) != nil {
+			break
+		}
+	}
+
+	return delivered
+}
+
+// Removes the event from the spool (re-read, events may have been spooled
+// while flushing)
+func removeSpooledTelemetryEvent(spoolFilePath string, event spooledTelemetryEvent) error {
+	spoolMutex.Lock()
+	defer spoolMutex.Unlock()
+
+	current, err := readSpooledTelemetry(spoolFilePath)
+	if err != nil {
+		return err
+	}
+	for i, spooled := range current {
+		if spooled.SpooledAt.Equal(event.SpooledAt) && spooled.Body == event.Body {
+			return writeSpooledTelemetry(spoolFilePath, append(current[:i], current[i+1:]...))
+		}
+	}
+	return nil
+}
+
+// Posts the event, retrying with backoff unless it is rejected
+func postWithBackoff(url, authenticationKeyHash string, body telemetryRequestBody) error {
+	backoff := spoolBackoffStart
+	var err error
+	for attempt := 1; attempt <= spoolMaxAttempts; attempt++ {
+		if err = postTelemetryRequestBody(url, authenticationKeyHash, body); err == nil || isRejected(err) {
+			return err
+		}
+		if attempt < spoolMaxAttempts {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+	return err
+}
@@ -25,9 +25,11 @@ package telemetry
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"runtime"
+	"time"
 )
 
 // Note: current implementation is based on creating a telemetry instance
@@ -39,9 +41,26 @@ import (
 // creating a DefaultInstance for global updates
 var DefaultInstance = InitiateTelemetryInstance()
 
+// telemetry should never hold up the cli for long on a flaky network
+var telemetryClient = &http.Client{Timeout: 10 * time.Second}
+
+// in anonymous mode only coarse, non-identifying metrics are recorded
+// (no command line, paths, environment or user hash)
+var anonymousMode = false
+
+func SetAnonymousMode(anonymous bool) {
+	anonymousMode = anonymous
+}
+
+func IsAnonymousMode() bool {
+	return anonymousMode
+}
+
 type Telemetry struct {
 	metricMap   map[string]interface{}
 	requestBody telemetryRequestBody
+	startTime   time.Time
+	hasError    bool
 	Recorded    bool
 }
 
@@ -54,9 +73,17 @@ type telemetryRequestBody struct {
 
 type TelemetryRequestConfig struct {
 	Url, UserHash, SessionId, AuthenticationKeyHash string
+
+	// when specified, events that cannot be delivered are spooled
+	// to this file and retried on the next invocation
+	SpoolFilePath string
 }
 
 func isSupportedMetric(key string) bool {
+	if anonymousMode {
+		return isAnonymousMetric(key)
+	}
+
 	switch key {
 	case
 		"os",
@@ -71,19 +98,53 @@ func isSupportedMetric(key string) bool {
 		"didParseCloudLink",
 		"didAutoSpawnBrowser",
 		"warning",
-		"error":
+		"error",
+		"errorCode",
+		"durationBucket",
+		"success":
+		return true
+	}
+
+	return false
+}
+
+// the code of a coded error (not its message) identifies the failure only
+func isAnonymousMetric(key string) bool {
+	switch key {
+	case
+		"version",
+		"errorCode",
+		"durationBucket",
+		"success":
 		return true
 	}
 
 	return false
 }
 
+// coarse duration buckets so the exact duration cannot be used to
+// correlate events
+func getDurationBucket(duration time.Duration) string {
+	switch {
+	case duration < time.Minute:
+		return "<1m"
+	case duration < 5*time.Minute:
+		return "1m-5m"
+	case duration < 15*time.Minute:
+		return "5m-15m"
+	case duration < time.Hour:
+		return "15m-60m"
+	}
+	return ">60m"
+}
+
 func InitiateTelemetryInstance() *Telemetry {
 	var newTelemetryInstance = &Telemetry{
 		metricMap: map[string]interface{}{},
 		requestBody: telemetryRequestBody{
 			EventType: "PRIVADO_CLI",
 		},
+		startTime: time.Now(),
 	}
 
 	// init with default runtime metrics
@@ -100,6 +161,11 @@ func (t *Telemetry) RecordAtomicMetric(key string, value interface{}) {
 }
 
 func (t *Telemetry) RecordArrayMetric(key string, value interface{}) {
+	// errors determine the success metric, even when not recorded themselves
+	if key == "error" {
+		t.hasError = true
+	}
+
 	// perform only if supported metric
 	if isSupportedMetric(key) {
 		// if key exists, append value, else define value
@@ -121,8 +187,57 @@ func (t *Telemetry) GetRecordedMetrics() map[string]interface{} {
 }
 
 func (t *Telemetry) PostRecordedTelemetry(reqConfig TelemetryRequestConfig) error {
-	t.requestBody.UserHash = reqConfig.UserHash
-	t.requestBody.SessionId = reqConfig.SessionId
+	if err := t.prepareRequestBody(reqConfig); err != nil {
+		return err
+	}
+
+	t.Recorded = true
+	return deliverRequestBody(reqConfig, t.requestBody)
+}
+
+// Same as PostRecordedTelemetry, but does not block for more than timeout
+// The event is spooled before it is posted: when the endpoint does not
+// respond in time, the delivery is abandoned when the process exits, and
+// the event is delivered on the next invocation. Events already flushed
+// from the spool are removed from it one by one
+func (t *Telemetry) PostRecordedTelemetryWithTimeout(reqConfig TelemetryRequestConfig, timeout time.Duration) error {
+	if err := t.prepareRequestBody(reqConfig); err != nil {
+		return err
+	}
+
+	// the delivery works on a copy of the event, the instance may still
+	// be used (e.g. on a panic) while it is in flight
+	t.Recorded = true
+	body := t.requestBody
+	spooled := spoolRequestBody(reqConfig, body)
+	done := make(chan error, 1)
+	go func() {
+		done <- postSpooledRequestBody(reqConfig, body, spooled)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(timeout):
+		return fmt.Errorf("telemetry timed out after %s", timeout)
+	}
+}
+
+func (t *Telemetry) prepareRequestBody(reqConfig TelemetryRequestConfig) error {
+	t.RecordAtomicMetric("durationBucket", getDurationBucket(time.Since(t.startTime)))
+	t.RecordAtomicMetric("success", !t.hasError)
+
+	if anonymousMode {
+		// drop anything recorded before the mode was applied
+		for key := range t.metricMap {
+			if !isAnonymousMetric(key) {
+				delete(t.metricMap, key)
+			}
+		}
+	} else {
+		t.requestBody.UserHash = reqConfig.UserHash
+		t.requestBody.SessionId = reqConfig.SessionId
+	}
 
 	if metricsJson, err := json.MarshalIndent(t.metricMap, "", "    "); err != nil {
 		return err
@@ -130,30 +245,123 @@ func (t *Telemetry) PostRecordedTelemetry(reqConfig TelemetryRequestConfig) erro
 		t.requestBody.EventMessage = string(metricsJson)
 	}
 
-	requestBody, err := json.Marshal(t.requestBody)
+	return nil
+}
+
+// Returns the event without the identifying fields and metrics, like an
+// event recorded in anonymous mode. Events whose metrics cannot be read
+// keep none of them
+func anonymizeRequestBody(body telemetryRequestBody) telemetryRequestBody {
+	metrics := map[string]interface{}{}
+	_ = json.Unmarshal([]byte(body.EventMessage), &metrics)
+	for key := range metrics {
+		if !isAnonymousMetric(key) {
+			delete(metrics, key)
+		}
+	}
+
+	body.UserHash = ""
+	body.SessionId = ""
+	body.EventMessage = "{}"
+	if metricsJson, err := json.MarshalIndent(metrics, "", "    "); err == nil {
+		body.EventMessage = string(metricsJson)
+	}
+	return body
+}
+
+// Delivers the event of this invocation, then events left over from
+// previous (failed) invocations, as long as the endpoint is reachable
+func deliverRequestBody(reqConfig TelemetryRequestConfig, body telemetryRequestBody) error {
+	return postSpooledRequestBody(reqConfig, body, spoolRequestBody(reqConfig, body))
+}
+
+// Spools the event before it is posted, so it is not lost if its delivery
+// is abandoned. Returns the spooled event, nil if it is not spooled
+func spoolRequestBody(reqConfig TelemetryRequestConfig, body telemetryRequestBody) *spooledTelemetryEvent {
+	if reqConfig.SpoolFilePath == "" {
+		return nil
+	}
+	event, err := spoolTelemetryEvent(reqConfig.SpoolFilePath, body)
 	if err != nil {
+		return nil
+	}
+	return &event
+}
+
+// Posts the (spooled) event: once delivered or rejected, it is removed
+// from the spool and, if delivered, the spool is flushed
+func postSpooledRequestBody(reqConfig TelemetryRequestConfig, body telemetryRequestBody, spooled *spooledTelemetryEvent) error {
+	err := postTelemetryRequestBody(reqConfig.Url, reqConfig.AuthenticationKeyHash, body)
+	if err != nil && !isRejected(err) {
+		// the endpoint is unreachable: a spooled event is kept for the next
+		// invocation instead of being dropped
+		if spooled != nil {
+			return nil
+		}
 		return err
 	}
 
-	req, err := http.NewRequest("POST", reqConfig.Url, bytes.NewBuffer(requestBody))
+	// rejected events are dropped, they would be rejected again
+	if spooled != nil {
+		_ = removeSpooledTelemetryEvent(reqConfig.SpoolFilePath, *spooled)
+	}
+	if err == nil && reqConfig.SpoolFilePath != "" {
+		FlushSpooledTelemetry(reqConfig.SpoolFilePath, reqConfig.Url, reqConfig.AuthenticationKeyHash)
+	}
+	return err
+}
+
+// Error of a response with a non-ok status
+type statusError struct {
+	StatusCode int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("received non-ok status from telemetry: %d", e.StatusCode)
+}
+
+// Returns whether the endpoint rejected the event (4xx), as opposed to
+// not being reachable or failing
+func isRejected(err error) bool {
+	var statusErr *statusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode >= 400 && statusErr.StatusCode < 500
+}
+
+func postTelemetryRequestBody(url, authenticationKeyHash string, body telemetryRequestBody) error {
+	requestBody, err := json.Marshal(body)
 	if err != nil {
 		return err
 	}
 
-	req.Header.Add("Authentication", reqConfig.AuthenticationKeyHash)
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(requestBody))
+	if err != nil {
+		return err
+	}
+
+	// the hash of the access key identifies the user: not sent anonymously
+	if !anonymousMode && authenticationKeyHash != "" {
+		req.Header.Add("Authentication", authenticationKeyHash)
+	}
 	req.Header.Add("Content-Type", "application/json")
 
-	res, err := http.DefaultClient.Do(req)
+	res, err := telemetryClient.Do(req)
 	if err != nil {
 		return err
 	}
 	defer res.Body.Close()
 
 	if res.StatusCode != 201 {
-		return fmt.Errorf("received non-ok status from telemetry: %d", res.StatusCode)
+		return &statusError{StatusCode: res.StatusCode}
 	}
 
-	t.Recorded = true
-
 	return nil
 }
+
+// Sends a crash report as a separate event type. Only to be used after
+// the user has consented to share crash reports
+func PostCrashReport(url, authenticationKeyHash, report string) error {
+	return postTelemetryRequestBody(url, authenticationKeyHash, telemetryRequestBody{
+		EventType:    "PRIVADO_CLI_CRASH",
+		EventMessage: report,
+	})
+}
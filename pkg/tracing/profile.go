@@ -0,0 +1,86 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package tracing
+
+import (
+	"sort"
+	"time"
+)
+
+type PhaseTiming struct {
+	Name     string
+	Duration time.Duration
+	// number of spans of the phase, e.g. when an image is pulled twice
+	Count int
+}
+
+// Records spans for the timing breakdown, whether or not tracing is enabled
+func EnableProfiling() {
+	if defaultTracer.profiling {
+		return
+	}
+	defaultTracer.profiling = true
+	defaultTracer.profilingStart = time.Now()
+}
+
+func IsProfilingEnabled() bool {
+	return defaultTracer.profiling
+}
+
+// Returns the time spent in each phase (ended spans, by name) in the order
+// the phases started, and the time since profiling was enabled
+func Profile() (phases []PhaseTiming, total time.Duration) {
+	defaultTracer.mu.Lock()
+	defer defaultTracer.mu.Unlock()
+
+	type phase struct {
+		PhaseTiming
+		start time.Time
+	}
+	byName := map[string]*phase{}
+	ordered := []*phase{}
+	for _, span := range defaultTracer.spans {
+		if span == defaultTracer.root {
+			continue
+		}
+		p, ok := byName[span.name]
+		if !ok {
+			p = &phase{PhaseTiming: PhaseTiming{Name: span.name}, start: span.start}
+			byName[span.name] = p
+			ordered = append(ordered, p)
+		}
+		p.Duration += span.end.Sub(span.start)
+		p.Count++
+		if span.start.Before(p.start) {
+			p.start = span.start
+		}
+	}
+
+	// spans are recorded as they end
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].start.Before(ordered[j].start) })
+	for _, p := range ordered {
+		phases = append(phases, p.PhaseTiming)
+	}
+
+	return phases, time.Since(defaultTracer.profilingStart)
+}
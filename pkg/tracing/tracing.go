@@ -0,0 +1,321 @@
+/**
+ * This file is part of Privado OSS.
+ *
+ * Privado is an open source static code analysis tool to discover data flows in the code.
+ * Copyright (C) 2022 Privado, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ * For more information, contact support@privado.ai
+ */
+
+package tracing
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Minimal OpenTelemetry tracing for the CLI phases. Spans are exported
+// using OTLP over HTTP (JSON encoding) and configured with the standard
+// OTEL_* environment variables, so the CLI fits into an existing tracing
+// stack without pulling the full SDK into the binary
+// Tracing is a no-op unless an OTLP endpoint is configured
+
+const (
+	serviceNameDefault  = "privado-cli"
+	instrumentationName = "github.com/Privado-Inc/privado-cli"
+	exportTimeout       = 5 * time.Second
+)
+
+type Span struct {
+	name         string
+	traceId      string
+	spanId       string
+	parentSpanId string
+	start        time.Time
+	end          time.Time
+	attributes   map[string]string
+	err          error
+}
+
+type tracer struct {
+	enabled      bool
+	endpoint     string
+	headers      map[string]string
+	serviceName  string
+	traceId      string
+	parentSpanId string
+	root         *Span
+
+	// spans are recorded for the timing breakdown (--profile) even
+	// when they are not exported
+	profiling      bool
+	profilingStart time.Time
+
+	mu    sync.Mutex
+	spans []*Span
+}
+
+var defaultTracer = newTracerFromEnvironment()
+
+func newTracerFromEnvironment() *tracer {
+	t := &tracer{serviceName: serviceNameDefault, headers: map[string]string{}}
+
+	if disabled, _ := strconv.ParseBool(os.Getenv("OTEL_SDK_DISABLED")); disabled {
+		return t
+	}
+	if exporter := os.Getenv("OTEL_TRACES_EXPORTER"); exporter != "" && exporter != "otlp" {
+		return t
+	}
+
+	if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"); endpoint != "" {
+		t.endpoint = endpoint
+	} else if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); endpoint != "" {
+		t.endpoint = strings.TrimSuffix(endpoint, "/") + "/v1/traces"
+	} else {
+		return t
+	}
+
+	protocol := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_PROTOCOL")
+	if protocol == "" {
+		protocol = os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL")
+	}
+	if protocol != "" && protocol != "http/json" {
+		fmt.Printf("[WARN]: OTLP protocol '%s' is not supported, exporting traces with 'http/json'\n", protocol)
+	}
+
+	for _, headers := range []string{os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"), os.Getenv("OTEL_EXPORTER_OTLP_TRACES_HEADERS")} {
+		for _, header := range strings.Split(headers, ",") {
+			if keyValue := strings.SplitN(header, "=", 2); len(keyValue) == 2 {
+				t.headers[strings.TrimSpace(keyValue[0])] = strings.TrimSpace(keyValue[1])
+			}
+		}
+	}
+
+	if serviceName := os.Getenv("OTEL_SERVICE_NAME"); serviceName != "" {
+		t.serviceName = serviceName
+	}
+
+	// continue an existing trace (W3C trace context), for instance
+	// when the CI pipeline itself is traced
+	if traceParent := strings.Split(os.Getenv("TRACEPARENT"), "-"); len(traceParent) == 4 && len(traceParent[1]) == 32 && len(traceParent[2]) == 16 {
+		t.traceId = traceParent[1]
+		t.parentSpanId = traceParent[2]
+	} else {
+		t.traceId = randomHexId(16)
+	}
+
+	t.enabled = true
+	return t
+}
+
+func randomHexId(numBytes int) string {
+	id := make([]byte, numBytes)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+func IsEnabled() bool {
+	return defaultTracer.enabled
+}
+
+// Starts the root span for the current invocation
+// All spans started after this are its children
+func StartRootSpan(name string) *Span {
+	span := startSpan(name, defaultTracer.parentSpanId)
+	defaultTracer.root = span
+	return span
+}
+
+// Starts a span as a child of the root span
+func StartSpan(name string) *Span {
+	parentSpanId := defaultTracer.parentSpanId
+	if defaultTracer.root != nil {
+		parentSpanId = defaultTracer.root.spanId
+	}
+	return startSpan(name, parentSpanId)
+}
+
+func startSpan(name, parentSpanId string) *Span {
+	if !defaultTracer.enabled && !defaultTracer.profiling {
+		return nil
+	}
+
+	return &Span{
+		name:         name,
+		traceId:      defaultTracer.traceId,
+		spanId:       randomHexId(8),
+		parentSpanId: parentSpanId,
+		start:        time.Now(),
+		attributes:   map[string]string{},
+	}
+}
+
+// Span methods are safe to call on a nil span (tracing disabled)
+
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.attributes[key] = fmt.Sprintf("%v", value)
+}
+
+func (s *Span) End(err error) {
+	if s == nil || !s.end.IsZero() {
+		return
+	}
+	s.end = time.Now()
+	s.err = err
+
+	defaultTracer.mu.Lock()
+	defer defaultTracer.mu.Unlock()
+	defaultTracer.spans = append(defaultTracer.spans, s)
+}
+
+// Returns the W3C traceparent for the span, used to propagate
+// the trace to the scan engine
+func (s *Span) TraceParent() string {
+	if s == nil {
+		return ""
+	}
+	return fmt.Sprintf("00-%s-%s-01", s.traceId, s.spanId)
+}
+
+// Ends the root span (if still open) and exports all ended spans
+// Call Profile before, as exported spans are not kept
+func Flush() error {
+	if !defaultTracer.enabled {
+		return nil
+	}
+
+	if defaultTracer.root != nil {
+		defaultTracer.root.End(nil)
+	}
+
+	defaultTracer.mu.Lock()
+	spans := defaultTracer.spans
+	defaultTracer.spans = nil
+	defaultTracer.mu.Unlock()
+
+	if len(spans) == 0 {
+		return nil
+	}
+
+	return exportSpans(spans)
+}
+
+type otlpKeyValue struct {
+	Key   string `json:"key"`
+	Value struct {
+		StringValue string `json:"stringValue"`
+	} `json:"value"`
+}
+
+type otlpSpan struct {
+	TraceId           string         `json:"traceId"`
+	SpanId            string         `json:"spanId"`
+	ParentSpanId      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            struct {
+		Code    int    `json:"code"`
+		Message string `json:"message,omitempty"`
+	} `json:"status"`
+}
+
+func toOTLPAttributes(attributes map[string]string) []otlpKeyValue {
+	keyValues := []otlpKeyValue{}
+	for key, value := range attributes {
+		keyValue := otlpKeyValue{Key: key}
+		keyValue.Value.StringValue = value
+		keyValues = append(keyValues, keyValue)
+	}
+	return keyValues
+}
+
+func exportSpans(spans []*Span) error {
+	exportedSpans := []otlpSpan{}
+	for _, span := range spans {
+		exportedSpan := otlpSpan{
+			TraceId:           span.traceId,
+			SpanId:            span.spanId,
+			ParentSpanId:      span.parentSpanId,
+			Name:              span.name,
+			Kind:              1, // SPAN_KIND_INTERNAL
+			StartTimeUnixNano: strconv.FormatInt(span.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.end.UnixNano(), 10),
+			Attributes:        toOTLPAttributes(span.attributes),
+		}
+		if span.err != nil {
+			exportedSpan.Status.Code = 2 // STATUS_CODE_ERROR
+			exportedSpan.Status.Message = span.err.Error()
+		}
+		exportedSpans = append(exportedSpans, exportedSpan)
+	}
+
+	payload := map[string]interface{}{
+		"resourceSpans": []interface{}{
+			map[string]interface{}{
+				"resource": map[string]interface{}{
+					"attributes": toOTLPAttributes(map[string]string{"service.name": defaultTracer.serviceName}),
+				},
+				"scopeSpans": []interface{}{
+					map[string]interface{}{
+						"scope": map[string]string{"name": instrumentationName},
+						"spans": exportedSpans,
+					},
+				},
+			},
+		},
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", defaultTracer.endpoint, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range defaultTracer.headers {
+		req.Header.Set(key, value)
+	}
+
+	res, err := (&http.Client{Timeout: exportTimeout}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("received non-ok status from trace collector: %d", res.StatusCode)
+	}
+
+	return nil
+}
@@ -24,13 +24,16 @@ package utils
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"os/exec"
 	"os/signal"
 	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -165,14 +168,50 @@ func ExtractURLFromString(str string) string {
 	return ""
 }
 
+// Answer of prompts given without asking (--yes, --non-interactive),
+// nil to ask
+var promptAnswer *bool
+
+// Answers confirmation prompts with answer, without asking. Consent
+// prompts are declined
+func SetPromptAnswer(answer bool) {
+	promptAnswer = &answer
+}
+
+// Answers of prompts are read from stdin, when it is not a terminal as
+// well (e.g. piped answers). The reader is shared by prompts, as it may
+// read ahead
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// Returns the answer of prompts without asking, if set
+func getPromptAnswer() (bool, bool) {
+	if promptAnswer != nil {
+		return *promptAnswer, true
+	}
+	return false, false
+}
+
+// Reads the answer of a prompt; at the end of stdin, the answer is empty
+// (the default)
+func readPromptAnswer() (string, error) {
+	ans, err := stdinReader.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return strings.TrimSpace(ans), nil
+}
+
 func ShowConfirmationPrompt(msg string) (bool, error) {
-	reader := bufio.NewReader(os.Stdin)
+	if answer, ok := getPromptAnswer(); ok {
+		fmt.Printf("%s (y/N): %s (non-interactive)\n", msg, map[bool]string{true: "y", false: "N"}[answer])
+		return answer, nil
+	}
+
 	fmt.Printf("%s (y/N): ", msg)
-	ans, err := reader.ReadString('\n')
+	ans, err := readPromptAnswer()
 	if err != nil {
 		return false, err
 	}
-	ans = strings.TrimSpace(ans)
 	ans = strings.ToLower(ans)
 
 	if ans == "y" || ans == "yes" || ans == "1" {
@@ -180,3 +219,52 @@ func ShowConfirmationPrompt(msg string) (bool, error) {
 	}
 	return false, nil
 }
+
+// Returns whether prompts are shown, i.e. prompts are not answered
+// without asking
+func IsInteractive() bool {
+	_, ok := getPromptAnswer()
+	return !ok
+}
+
+// Asks for a line of input, empty when prompts are not shown
+func ShowInputPrompt(msg string) (string, error) {
+	if !IsInteractive() {
+		fmt.Printf("%s: (non-interactive)\n", msg)
+		return "", nil
+	}
+
+	fmt.Printf("%s: ", msg)
+	return readPromptAnswer()
+}
+
+// Asks for consent (e.g. to share data). Unlike confirmation prompts,
+// consent is never given without asking
+func ShowConsentPrompt(msg string) (bool, error) {
+	if _, ok := getPromptAnswer(); ok {
+		fmt.Printf("%s (y/N): N (non-interactive)\n", msg)
+		return false, nil
+	}
+	return ShowConfirmationPrompt(msg)
+}
+
+// Parses a duration with a day (d) or week (w) unit, e.g. 90d or 2w,
+// else a go duration (e.g. 12h)
+func ParseDuration(value string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if !strings.HasSuffix(value, suffix) {
+			continue
+		}
+		count, err := strconv.Atoi(strings.TrimSuffix(value, suffix))
+		if err != nil || count <= 0 {
+			return 0, fmt.Errorf("invalid duration '%s'", value)
+		}
+		return time.Duration(count) * unit, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		return 0, fmt.Errorf("invalid duration '%s' (e.g. 90d, 2w or 12h)", value)
+	}
+	return duration, nil
+}